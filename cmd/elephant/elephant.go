@@ -3,23 +3,28 @@ package main
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
+	"maps"
 	"os"
-	"os/exec"
 	"os/signal"
-	"path/filepath"
-	"syscall"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/abenz1267/elephant/v2/internal/comm"
 	"github.com/abenz1267/elephant/v2/internal/comm/client"
 	"github.com/abenz1267/elephant/v2/internal/install"
 	"github.com/abenz1267/elephant/v2/internal/providers"
+	"github.com/abenz1267/elephant/v2/internal/scaffold"
 	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/internal/wizard"
 	"github.com/abenz1267/elephant/v2/pkg/common"
-	"github.com/adrg/xdg"
+	"github.com/abenz1267/elephant/v2/pkg/common/memory"
+	"github.com/abenz1267/elephant/v2/pkg/common/metrics"
+	"github.com/abenz1267/elephant/v2/pkg/common/store"
 	"github.com/urfave/cli/v3"
 )
 
@@ -27,6 +32,8 @@ import (
 var version string
 
 func main() {
+	install.Version = strings.TrimSpace(version)
+
 	cmd := &cli.Command{
 		Name:                   "Elephant",
 		Usage:                  "Data provider and executor",
@@ -34,69 +41,20 @@ func main() {
 		Commands: []*cli.Command{
 			{
 				Name:  "service",
-				Usage: "manage the user systemd service",
+				Usage: "manage the user service, systemd on linux, launchd on macOS and a login item on windows",
 				Commands: []*cli.Command{
 					{
 						Name:  "enable",
-						Usage: "enables the systemd service",
+						Usage: "enables the service",
 						Action: func(ctx context.Context, cmd *cli.Command) error {
-							h := xdg.ConfigHome
-							file := filepath.Join(h, "systemd", "user", "elephant.service")
-							os.MkdirAll(filepath.Dir(file), 0o755)
-
-							data := `
-[Unit]
-Description=Elephant
-After=graphical-session.target
-
-[Service]
-Type=simple
-ExecStart=elephant
-Restart=on-failure
-
-[Install]
-WantedBy=graphical-session.target
-							`
-
-							if !common.FileExists(file) {
-								err := os.WriteFile(file, []byte(data), 0o755)
-								if err != nil {
-									slog.Error("service", "enable write file", err)
-								}
-							}
-
-							sc := exec.Command("systemctl", "--user", "enable", "elephant.service")
-							out, err := sc.CombinedOutput()
-							if err != nil {
-								slog.Error("service", "enable systemd", err, "out", out)
-							}
-
-							slog.Info("service", "enable", out)
-
-							return nil
+							return enableService()
 						},
 					},
 					{
 						Name:  "disable",
-						Usage: "disables the systemd service",
+						Usage: "disables the service",
 						Action: func(ctx context.Context, cmd *cli.Command) error {
-							sc := exec.Command("systemctl", "--user", "disable", "elephant.service")
-							out, err := sc.CombinedOutput()
-							if err != nil {
-								slog.Error("service", "disable systemd", err, "out", out)
-							}
-
-							slog.Info("service", "disable", out)
-
-							h := xdg.ConfigHome
-							file := filepath.Join(h, "systemd", "user", "elephant.service")
-
-							err = os.Remove(file)
-							if err != nil {
-								slog.Error("service", "disable", err)
-							}
-
-							return nil
+							return disableService()
 						},
 					},
 				},
@@ -121,8 +79,9 @@ WantedBy=graphical-session.target
 					common.LoadGlobalConfig()
 
 					providers.Load(false)
+					defer providers.Shutdown()
 
-					for _, v := range providers.Providers {
+					for _, v := range providers.All() {
 						if *v.Name == "menus" {
 							for _, m := range common.Menus {
 								fmt.Printf("%s;menus:%s\n", m.NamePretty, m.Name)
@@ -165,6 +124,7 @@ WantedBy=graphical-session.target
 					slog.SetDefault(logger)
 
 					providers.Load(false)
+					defer providers.Shutdown()
 
 					util.GenerateDoc(cmd.StringArg("provider"))
 					return nil
@@ -218,13 +178,185 @@ WantedBy=graphical-session.target
 					return nil
 				},
 			},
+			{
+				Name:      "docs",
+				Usage:     "prints the readme and config schema of providers as json, all if none given",
+				ArgsUsage: "[provider...]",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					client.Docs(cmd.Args().Slice())
+
+					return nil
+				},
+			},
+			{
+				Name:  "migrate",
+				Usage: "rewrites outdated options of configs, keeping the previous files as .bak",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "only print what would be changed",
+					},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					logger := slog.New(slog.DiscardHandler)
+					slog.SetDefault(logger)
+
+					common.LoadGlobalConfig()
+
+					// providers register their migrations when they're loaded
+					providers.Load(false)
+
+					res, err := common.MigrateFiles(cmd.Bool("dry-run"))
+
+					for _, file := range slices.Sorted(maps.Keys(res)) {
+						fmt.Println(file)
+
+						for _, v := range res[file] {
+							fmt.Printf("  - %s\n", v)
+						}
+					}
+
+					if err != nil {
+						return err
+					}
+
+					if len(res) == 0 {
+						fmt.Println("nothing to migrate")
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:      "replay",
+				Usage:     "sends the requests of a session recorded with --record again and prints the responses",
+				ArgsUsage: "<file>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "timing",
+						Usage: "keep the delays between the recorded requests",
+					},
+					&cli.DurationFlag{
+						Name:  "wait",
+						Value: 2 * time.Second,
+						Usage: "time without responses after which replaying ends",
+					},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					if cmd.Args().Len() != 1 {
+						return errors.New("expected the recorded file")
+					}
+
+					client.Replay(cmd.Args().First(), cmd.Bool("timing"), cmd.Duration("wait"))
+
+					return nil
+				},
+			},
+			{
+				Name:  "speech",
+				Usage: "records until ctrl+c or 'speech --stop' and prints the transcribed text",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "stop",
+						Usage: "stops the running recording, f.e. when releasing a push-to-talk key",
+					},
+					&cli.StringFlag{
+						Name:  "language",
+						Usage: "language of the recording, defaults to the configured one",
+					},
+					&cli.BoolFlag{
+						Name:        "json",
+						Category:    "",
+						DefaultText: "output as json",
+						Usage:       "if you want json. use this.",
+					},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					client.Speech(cmd.String("language"), cmd.Bool("stop"), cmd.Bool("json"))
+
+					return nil
+				},
+			},
+			{
+				Name:  "init",
+				Usage: "detects installed tools and writes a starter config and an example menu",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "yes",
+						Usage: "take the defaults without asking",
+					},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return wizard.Run(cmd.Bool("yes"), enableService)
+				},
+			},
+			{
+				Name:  "new",
+				Usage: "generates skeletons, run within the elephant repository",
+				Commands: []*cli.Command{
+					{
+						Name:      "provider",
+						Usage:     "generates a provider and adds it to the builds",
+						ArgsUsage: "<name>",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							if cmd.Args().Len() != 1 {
+								return errors.New("expected the name of the provider")
+							}
+
+							return scaffold.Provider(".", cmd.Args().First())
+						},
+					},
+				},
+			},
+			{
+				Name:  "doctor",
+				Usage: "checks the requirements of installed community menus and providers and reports problems of loading menus",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					packages := install.Doctor()
+					menus := install.DoctorMenus()
+
+					if !packages || !menus {
+						return errors.New("problems found")
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:        "backup",
+				Usage:       "backs up the database of a provider",
+				Description: "writes a copy of the providers database to the given file. if no provider is given, it will list all databases instead",
+				ArgsUsage:   "<provider> <file>",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					if cmd.Args().Len() == 0 {
+						for _, v := range store.List() {
+							fmt.Println(v)
+						}
+
+						return nil
+					}
+
+					dest := cmd.Args().Get(1)
+					if dest == "" {
+						dest = fmt.Sprintf("%s-%s.db", cmd.Args().First(), time.Now().Format("20060102-150405"))
+					}
+
+					if err := store.Backup(cmd.Args().First(), dest); err != nil {
+						return err
+					}
+
+					fmt.Println(dest)
+
+					return nil
+				},
+			},
 			{
 				Name:  "community",
 				Usage: "elephant-community based actions",
 				Commands: []*cli.Command{
 					{
 						Name:        "install",
-						Description: "installs the given menus, if no menu is given , it will list availables instead",
+						Description: "installs the given menus, if no menu is given , it will list availables instead. pin a tag or commit with 'name@ref'",
 						Action: func(ctx context.Context, cmd *cli.Command) error {
 							install.Install(cmd.Args().Slice())
 
@@ -255,6 +387,24 @@ WantedBy=graphical-session.target
 						Action: func(ctx context.Context, cmd *cli.Command) error {
 							install.List()
 
+							return nil
+						},
+					},
+					{
+						Name:        "update",
+						Description: "updates the given menus, or all installed ones. pinned menus are skipped",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							install.Update(cmd.Args().Slice())
+
+							return nil
+						},
+					},
+					{
+						Name:        "outdated",
+						Description: "lists installed menus with available updates",
+						Action: func(ctx context.Context, cmd *cli.Command) error {
+							install.Outdated()
+
 							return nil
 						},
 					},
@@ -285,11 +435,26 @@ WantedBy=graphical-session.target
 					return nil
 				},
 			},
+			&cli.StringFlag{
+				Name:    "profile",
+				Aliases: []string{"p"},
+				Value:   "",
+				Usage:   "named configuration set, f.e. work or home, with its own socket, cache and history",
+				Sources: cli.EnvVars("ELEPHANT_PROFILE"),
+				Action: func(ctx context.Context, cmd *cli.Command, val string) error {
+					common.SetProfile(val)
+					return nil
+				},
+			},
 			&cli.BoolFlag{
 				Name:    "debug",
 				Aliases: []string{"d"},
 				Usage:   "enable debug logging",
 			},
+			&cli.StringFlag{
+				Name:  "record",
+				Usage: "record the frames of all client sessions to files in this folder, for 'elephant replay'",
+			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			start := time.Now()
@@ -297,16 +462,12 @@ WantedBy=graphical-session.target
 			common.LoadGlobalConfig()
 
 			signalChan := make(chan os.Signal, 1)
-			signal.Notify(signalChan,
-				syscall.SIGHUP,
-				syscall.SIGINT,
-				syscall.SIGTERM,
-				syscall.SIGKILL,
-				syscall.SIGQUIT, syscall.SIGUSR1)
+			signal.Notify(signalChan, shutdownSignals...)
 
 			go func() {
 				<-signalChan
 				os.Remove(comm.Socket)
+				providers.Shutdown()
 				os.Exit(0)
 			}()
 
@@ -317,12 +478,21 @@ WantedBy=graphical-session.target
 				slog.SetDefault(logger)
 			}
 
+			comm.RecordDir = cmd.String("record")
+
 			common.InitRunPrefix()
 
 			runBeforeCommands()
 
 			providers.Load(true)
 
+			go providers.Watchdog()
+			go memory.Watch()
+
+			if cfg := common.GetElephantConfig().Metrics; cfg.Enabled {
+				go metrics.Serve(cfg.Port)
+			}
+
 			slog.Info("elephant", "startup", time.Since(start))
 
 			comm.StartListen()
@@ -345,7 +515,7 @@ func runBeforeCommands() {
 
 	for _, v := range cfg.BeforeLoad {
 		for {
-			cmd := exec.Command("sh", "-c", v.Command)
+			cmd := common.Shell(v.Command)
 
 			out, err := cmd.CombinedOutput()
 			if err == nil || !v.MustSucceed {
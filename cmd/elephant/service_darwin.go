@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// enableService writes the launch agent of the selected profile, if it doesn't exist, and loads it.
+// PATH is taken over, as launchd starts agents with a minimal one.
+func enableService() error {
+	file, err := agentFile()
+	if err != nil {
+		return err
+	}
+
+	os.MkdirAll(filepath.Dir(file), 0o755)
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := fmt.Sprintf("<string>%s</string>", html.EscapeString(exe))
+
+	if p := common.Profile(); p != "" {
+		args += fmt.Sprintf("\n\t\t<string>--profile</string>\n\t\t<string>%s</string>", html.EscapeString(p))
+	}
+
+	data := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		%s
+	</array>
+	<key>EnvironmentVariables</key>
+	<dict>
+		<key>PATH</key>
+		<string>%s</string>
+	</dict>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+</dict>
+</plist>
+`, serviceName(), args, html.EscapeString(os.Getenv("PATH")))
+
+	if !common.FileExists(file) {
+		err := os.WriteFile(file, []byte(data), 0o644)
+		if err != nil {
+			slog.Error("service", "enable write file", err)
+		}
+	}
+
+	out, err := exec.Command("launchctl", "load", "-w", file).CombinedOutput()
+	if err != nil {
+		slog.Error("service", "enable launchd", err, "out", out)
+	}
+
+	slog.Info("service", "enable", out)
+
+	return nil
+}
+
+func disableService() error {
+	file, err := agentFile()
+	if err != nil {
+		return err
+	}
+
+	out, err := exec.Command("launchctl", "unload", "-w", file).CombinedOutput()
+	if err != nil {
+		slog.Error("service", "disable launchd", err, "out", out)
+	}
+
+	slog.Info("service", "disable", out)
+
+	err = os.Remove(file)
+	if err != nil {
+		slog.Error("service", "disable", err)
+	}
+
+	return nil
+}
+
+// serviceName is the label of the launch agent of the selected profile, so every profile can have its own.
+func serviceName() string {
+	if p := common.Profile(); p != "" {
+		return fmt.Sprintf("com.github.abenz1267.elephant.%s", p)
+	}
+
+	return "com.github.abenz1267.elephant"
+}
+
+func agentFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, "Library", "LaunchAgents", serviceName()+".plist"), nil
+}
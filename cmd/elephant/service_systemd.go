@@ -0,0 +1,88 @@
+//go:build !darwin && !windows
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/adrg/xdg"
+)
+
+// enableService writes the unit of the selected profile, if it doesn't exist, and enables it.
+func enableService() error {
+	h := xdg.ConfigHome
+	file := filepath.Join(h, "systemd", "user", serviceName())
+	os.MkdirAll(filepath.Dir(file), 0o755)
+
+	description, execStart := "Elephant", "elephant"
+
+	if p := common.Profile(); p != "" {
+		description = fmt.Sprintf("Elephant (%s)", p)
+		execStart = fmt.Sprintf("elephant --profile %s", p)
+	}
+
+	data := fmt.Sprintf(`
+[Unit]
+Description=%s
+After=graphical-session.target
+
+[Service]
+Type=simple
+ExecStart=%s
+Restart=on-failure
+
+[Install]
+WantedBy=graphical-session.target
+	`, description, execStart)
+
+	if !common.FileExists(file) {
+		err := os.WriteFile(file, []byte(data), 0o755)
+		if err != nil {
+			slog.Error("service", "enable write file", err)
+		}
+	}
+
+	sc := exec.Command("systemctl", "--user", "enable", serviceName())
+	out, err := sc.CombinedOutput()
+	if err != nil {
+		slog.Error("service", "enable systemd", err, "out", out)
+	}
+
+	slog.Info("service", "enable", out)
+
+	return nil
+}
+
+func disableService() error {
+	sc := exec.Command("systemctl", "--user", "disable", serviceName())
+	out, err := sc.CombinedOutput()
+	if err != nil {
+		slog.Error("service", "disable systemd", err, "out", out)
+	}
+
+	slog.Info("service", "disable", out)
+
+	h := xdg.ConfigHome
+	file := filepath.Join(h, "systemd", "user", serviceName())
+
+	err = os.Remove(file)
+	if err != nil {
+		slog.Error("service", "disable", err)
+	}
+
+	return nil
+}
+
+// serviceName is the systemd unit of the selected profile, so every profile can have its own.
+func serviceName() string {
+	if p := common.Profile(); p != "" {
+		return fmt.Sprintf("elephant-%s.service", p)
+	}
+
+	return "elephant.service"
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+const runKey = `HKCU\Software\Microsoft\Windows\CurrentVersion\Run`
+
+// enableService starts elephant on login of the current user, via the Run key of the registry.
+func enableService() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	command := `"` + exe + `"`
+
+	if p := common.Profile(); p != "" {
+		command = fmt.Sprintf("%s --profile %s", command, p)
+	}
+
+	out, err := exec.Command("reg", "add", runKey, "/v", serviceName(), "/t", "REG_SZ", "/d", command, "/f").CombinedOutput()
+	if err != nil {
+		slog.Error("service", "enable registry", err, "out", out)
+	}
+
+	slog.Info("service", "enable", out)
+
+	return nil
+}
+
+func disableService() error {
+	out, err := exec.Command("reg", "delete", runKey, "/v", serviceName(), "/f").CombinedOutput()
+	if err != nil {
+		slog.Error("service", "disable registry", err, "out", out)
+	}
+
+	slog.Info("service", "disable", out)
+
+	return nil
+}
+
+// serviceName is the value in the Run key of the selected profile, so every profile can have its own.
+func serviceName() string {
+	if p := common.Profile(); p != "" {
+		return fmt.Sprintf("Elephant-%s", p)
+	}
+
+	return "Elephant"
+}
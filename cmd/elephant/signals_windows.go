@@ -0,0 +1,11 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+var shutdownSignals = []os.Signal{
+	os.Interrupt,
+	syscall.SIGTERM,
+}
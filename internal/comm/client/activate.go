@@ -5,9 +5,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/json"
-	"net"
 	"strings"
 
+	"github.com/abenz1267/elephant/v2/pkg/common"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 )
 
@@ -22,12 +22,18 @@ func Activate(data string) {
 		Arguments:  v[4],
 	}
 
+	// several identifiers, separated by comma, are activated at once
+	if ids := strings.Split(v[1], ","); len(ids) > 1 {
+		req.Identifier = ""
+		req.Identifiers = ids
+	}
+
 	b, err := json.Marshal(&req)
 	if err != nil {
 		panic(err)
 	}
 
-	conn, err := net.Dial("unix", socket)
+	conn, err := common.Dial()
 	if err != nil {
 		panic(err)
 	}
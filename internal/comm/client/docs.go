@@ -0,0 +1,75 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// Docs prints the readme and config schema of the given providers, all if none given, as json.
+func Docs(providers []string) {
+	req := pb.DocsRequest{
+		Providers: providers,
+	}
+
+	b, err := json.Marshal(&req)
+	if err != nil {
+		panic(err)
+	}
+
+	conn, err := common.Dial()
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+
+	var buffer bytes.Buffer
+	buffer.Write([]byte{6})
+	buffer.Write([]byte{1})
+
+	lengthBuf := make([]byte, 4)
+	binary.BigEndian.PutUint32(lengthBuf, uint32(len(b)))
+	buffer.Write(lengthBuf)
+	buffer.Write(b)
+
+	_, err = conn.Write(buffer.Bytes())
+	if err != nil {
+		panic(err)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		header, err := reader.Peek(5)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			panic(err)
+		}
+
+		if header[0] == 253 {
+			break
+		}
+
+		if header[0] != 5 {
+			panic("invalid protocol prefix")
+		}
+
+		length := binary.BigEndian.Uint32(header[1:5])
+
+		msg := make([]byte, 5+length)
+		_, err = io.ReadFull(reader, msg)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Println(string(msg[5:]))
+	}
+}
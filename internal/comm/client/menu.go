@@ -4,8 +4,8 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/json"
-	"net"
 
+	"github.com/abenz1267/elephant/v2/pkg/common"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 )
 
@@ -19,7 +19,7 @@ func RequestMenu(menu string) {
 		panic(err)
 	}
 
-	conn, err := net.Dial("unix", socket)
+	conn, err := common.Dial()
 	if err != nil {
 		panic(err)
 	}
@@ -8,8 +8,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 
+	"github.com/abenz1267/elephant/v2/pkg/common"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 )
 
@@ -23,7 +23,7 @@ func ProviderState(data string, j bool) {
 		panic(err)
 	}
 
-	conn, err := net.Dial("unix", socket)
+	conn, err := common.Dial()
 	if err != nil {
 		panic(err)
 	}
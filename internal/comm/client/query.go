@@ -8,29 +8,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log/slog"
-	"net"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/abenz1267/elephant/v2/pkg/common"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 )
 
-var socket string
-
-func init() {
-	rd := os.Getenv("XDG_RUNTIME_DIR")
-
-	if rd == "" {
-		slog.Error("socket", "runtimedir", "XDG_RUNTIME_DIR not set. falling back to /tmp")
-		socket = filepath.Join(os.TempDir(), "elephant", "elephant.sock")
-	} else {
-		socket = filepath.Join(rd, "elephant", "elephant.sock")
-	}
-}
-
 func Query(data string, async, j bool) {
 	v := strings.Split(data, ";")
 	maxresults, _ := strconv.Atoi(v[2])
@@ -46,7 +30,7 @@ func Query(data string, async, j bool) {
 		panic(err)
 	}
 
-	conn, err := net.Dial("unix", socket)
+	conn, err := common.Dial()
 	if err != nil {
 		panic(err)
 	}
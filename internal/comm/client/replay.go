@@ -0,0 +1,173 @@
+package client
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm"
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// Replay sends the requests of a recorded session to the daemon and prints them and the responses. With
+// timing, the delays between the requests are kept. Compression of the recorded requests is dropped,
+// so responses are readable. It returns after no response came in for wait.
+func Replay(path string, timing bool, wait time.Duration) {
+	f, err := os.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	frames := []comm.TraceFrame{}
+	recorded := 0
+
+	dec := json.NewDecoder(f)
+
+	for {
+		var frame comm.TraceFrame
+
+		if err := dec.Decode(&frame); err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			panic(err)
+		}
+
+		if frame.Direction == comm.TraceOut {
+			recorded++
+			continue
+		}
+
+		frames = append(frames, frame)
+	}
+
+	conn, err := common.Dial()
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+
+	var last atomic.Int64
+	var received atomic.Int64
+
+	last.Store(time.Now().UnixNano())
+
+	go func() {
+		reader := bufio.NewReader(conn)
+		tagged := false
+
+		for {
+			size := 5
+			if tagged {
+				size += 4
+			}
+
+			header := make([]byte, size)
+			if _, err := io.ReadFull(reader, header); err != nil {
+				return
+			}
+
+			payload := make([]byte, binary.BigEndian.Uint32(header[size-4:]))
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+
+			last.Store(time.Now().UnixNano())
+			received.Add(1)
+
+			if tagged {
+				fmt.Printf("< %d #%d %s\n", header[0], binary.BigEndian.Uint32(header[1:5]), printable(payload))
+			} else {
+				fmt.Printf("< %d %s\n", header[0], printable(payload))
+			}
+
+			// the handshake is answered before the following requests are read
+			if header[0] == handlers.Handshake {
+				res := &pb.HandshakeResponse{}
+
+				if err := protocol.Unmarshal(payloadFormat(payload), payload, res); err == nil {
+					tagged = res.Error == "" && slices.Contains(res.Features, protocol.FeatureRequestIDs)
+				}
+			}
+		}
+	}()
+
+	tagged := false
+
+	for i, v := range frames {
+		if timing && i > 0 {
+			time.Sleep(v.Time.Sub(frames[i-1].Time))
+		}
+
+		req := protocol.Request{Type: v.Type, Format: v.Format & 0x0f, ID: v.ID, Payload: v.Payload}
+
+		var frame []byte
+
+		if tagged {
+			frame = protocol.AppendTaggedRequest(nil, req)
+		} else {
+			frame = protocol.AppendRequest(nil, req)
+		}
+
+		if _, err := conn.Write(frame); err != nil {
+			panic(err)
+		}
+
+		last.Store(time.Now().UnixNano())
+
+		if tagged {
+			fmt.Printf("> %d #%d %s\n", v.Type, v.ID, printable(v.Payload))
+		} else {
+			fmt.Printf("> %d %s\n", v.Type, printable(v.Payload))
+		}
+
+		if v.Type == comm.HandshakeRequestHandlerPos {
+			tagged = declaresRequestIDs(req)
+		}
+	}
+
+	for time.Since(time.Unix(0, last.Load())) < wait {
+		time.Sleep(wait / 10)
+	}
+
+	fmt.Printf("requests: %d, responses: %d (recorded: %d)\n", len(frames), received.Load(), recorded)
+}
+
+// printable returns json payloads as they are, protobuf ones only by size.
+func printable(b []byte) string {
+	if json.Valid(b) {
+		return string(b)
+	}
+
+	return fmt.Sprintf("<%d bytes>", len(b))
+}
+
+// declaresRequestIDs reports whether the daemon tags the frames following the handshake with request ids.
+func declaresRequestIDs(req protocol.Request) bool {
+	handshake := &pb.HandshakeRequest{}
+
+	if err := protocol.Unmarshal(req.Format, req.Payload, handshake); err != nil {
+		return false
+	}
+
+	return protocol.Compatible(handshake.ProtocolVersion) && slices.Contains(handshake.Features, protocol.FeatureRequestIDs)
+}
+
+func payloadFormat(b []byte) uint8 {
+	if json.Valid(b) {
+		return protocol.JSON
+	}
+
+	return protocol.Protobuf
+}
@@ -0,0 +1,107 @@
+// Package client provides simple functions to communicate with the socket.
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// Speech records until interrupted with ctrl+c, another call with stop or the max duration, and
+// prints the transcribed text.
+func Speech(language string, stop, j bool) {
+	conn := sendSpeech(&pb.SpeechRequest{
+		Stop:     stop,
+		Language: language,
+	})
+	defer conn.Close()
+
+	if !stop {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+		defer signal.Stop(sig)
+
+		go func() {
+			<-sig
+			sendSpeech(&pb.SpeechRequest{Stop: true}).Close()
+		}()
+	}
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		header, err := reader.Peek(5)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			panic(err)
+		}
+
+		if header[0] == 253 {
+			break
+		}
+
+		if header[0] != 4 {
+			panic("invalid protocol prefix")
+		}
+
+		length := binary.BigEndian.Uint32(header[1:5])
+
+		msg := make([]byte, 5+length)
+		_, err = io.ReadFull(reader, msg)
+		if err != nil {
+			panic(err)
+		}
+
+		payload := msg[5:]
+
+		resp := &pb.SpeechResponse{}
+		if err := json.Unmarshal(payload, resp); err != nil {
+			panic(err)
+		}
+
+		if !j {
+			fmt.Println(resp.Text)
+		} else {
+			fmt.Println(string(payload))
+		}
+	}
+}
+
+func sendSpeech(req *pb.SpeechRequest) net.Conn {
+	b, err := json.Marshal(req)
+	if err != nil {
+		panic(err)
+	}
+
+	conn, err := common.Dial()
+	if err != nil {
+		panic(err)
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write([]byte{5})
+	buffer.Write([]byte{1})
+
+	lengthBuf := make([]byte, 4)
+	binary.BigEndian.PutUint32(lengthBuf, uint32(len(b)))
+	buffer.Write(lengthBuf)
+	buffer.Write(b)
+
+	_, err = conn.Write(buffer.Bytes())
+	if err != nil {
+		panic(err)
+	}
+
+	return conn
+}
@@ -2,14 +2,15 @@
 package comm
 
 import (
-	"encoding/binary"
+	"errors"
 	"io"
 	"log/slog"
 	"net"
-	"os"
-	"path/filepath"
 
 	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/metrics"
 )
 
 // connection id
@@ -25,27 +26,20 @@ type MessageHandler interface {
 }
 
 const (
-	QueryRequestHandlerPos     = 0
-	ActivateRequestHandlerPos  = 1
-	SubscribeRequestHandlerPos = 2
-	MenuRequestHandlerPos      = 3
-	StateRequestHandlerPos     = 4
-	Protobuf                   = 0
-	JSON                       = 1
+	QueryRequestHandlerPos      = 0
+	ActivateRequestHandlerPos   = 1
+	SubscribeRequestHandlerPos  = 2
+	MenuRequestHandlerPos       = 3
+	StateRequestHandlerPos      = 4
+	SpeechRequestHandlerPos     = 5
+	DocsRequestHandlerPos       = 6
+	HandshakeRequestHandlerPos  = 7
+	VisibilityRequestHandlerPos = 8
+	Protobuf                    = protocol.Protobuf
+	JSON                        = protocol.JSON
 )
 
 func init() {
-	rd := os.Getenv("XDG_RUNTIME_DIR")
-
-	if rd == "" {
-		slog.Error("socket", "runtimedir", "XDG_RUNTIME_DIR not set. falling back to /tmp")
-		Socket = filepath.Join(os.TempDir(), "elephant", "elephant.sock")
-	} else {
-		Socket = filepath.Join(rd, "elephant", "elephant.sock")
-	}
-
-	os.MkdirAll(filepath.Dir(Socket), 0o755)
-
 	registry = make([]MessageHandler, 255)
 
 	registry[QueryRequestHandlerPos] = &handlers.QueryRequest{}
@@ -53,23 +47,27 @@ func init() {
 	registry[SubscribeRequestHandlerPos] = &handlers.SubscribeRequest{}
 	registry[MenuRequestHandlerPos] = &handlers.MenuRequest{}
 	registry[StateRequestHandlerPos] = &handlers.StateRequest{}
+	registry[SpeechRequestHandlerPos] = &handlers.SpeechRequest{}
+	registry[DocsRequestHandlerPos] = &handlers.DocsRequest{}
+	registry[HandshakeRequestHandlerPos] = &handlers.HandshakeRequest{}
+	registry[VisibilityRequestHandlerPos] = &handlers.VisibilityRequest{}
 }
 
 func StartListen() {
-	os.Remove(Socket)
+	// known only after the profile got selected
+	Socket = common.SocketFile()
 
-	l, err := net.ListenUnix("unix", &net.UnixAddr{
-		Name: Socket,
-	})
+	l, err := common.Listen(Socket)
 	if err != nil {
 		slog.Error("comm", "socket", err)
+		return
 	}
 	defer l.Close()
 
 	slog.Info("comm", "listen", "starting")
 
 	for {
-		conn, err := l.AcceptUnix()
+		conn, err := l.Accept()
 		if err != nil {
 			slog.Error("comm", "accept", err)
 		}
@@ -78,52 +76,76 @@ func StartListen() {
 
 		cid++
 
-		go handle(conn, cid)
+		metrics.Connections.Inc()
+
+		go handle(&compressConn{Conn: conn, recorder: newRecorder(cid)}, cid)
 	}
 }
 
-func handle(conn net.Conn, cid uint32) {
+func handle(conn *compressConn, cid uint32) {
+	metrics.OpenConnections.Add(1)
+	defer metrics.OpenConnections.Add(-1)
+
 	defer conn.Close()
+	defer conn.recorder.Close()
+	defer common.ForgetClient(conn)
+	defer handlers.ForgetConnection(cid)
+
+	limit := newLimiter()
 
 	for {
-		tb := make([]byte, 1)
-		if _, err := io.ReadFull(conn, tb); err != nil {
-			if err == io.EOF {
-				break
+		read := protocol.ReadRequest
+		if conn.tagged.Load() {
+			read = protocol.ReadTaggedRequest
+		}
+
+		req, err := read(conn)
+		if err != nil {
+			// after a broken frame the following ones can't be found anymore
+			// connections of rejected clients are closed by the handshake
+			if err != io.EOF && !errors.Is(err, net.ErrClosed) {
+				slog.Error("conn", "read", err)
 			}
 
-			slog.Error("conn", "readtype", err)
-			continue
+			break
 		}
 
-		mType := int(tb[0])
+		limit.wait()
 
-		fb := make([]byte, 1)
-		if _, err := io.ReadFull(conn, fb); err != nil {
-			if err == io.EOF {
-				break
-			}
+		format := conn.negotiate(req.Format)
+
+		conn.recorder.request(req)
 
-			slog.Error("conn", "readtype", err)
+		if int(req.Type) >= len(registry) || registry[req.Type] == nil {
+			slog.Error("conn", "type", "unknown request type", "type", req.Type)
 			continue
 		}
 
-		format := uint8(fb[0])
-
-		lb := make([]byte, 4)
-		if _, err := io.ReadFull(conn, lb); err != nil {
-			slog.Error("conn", "readlength", err)
-			continue
+		var c net.Conn = conn
+		if conn.tagged.Load() {
+			c = &requestConn{compressConn: conn, id: req.ID}
 		}
 
-		l := binary.BigEndian.Uint32(lb)
+		// the handshake has to be stored before the following requests are handled, frames after it
+		// carry request ids if the client asked for them
+		if req.Type == HandshakeRequestHandlerPos {
+			dispatch(registry[req.Type], format, cid, c, req.Payload)
+			conn.tagged.Store(common.HasHandshake(conn) && common.Supports(conn, protocol.FeatureRequestIDs))
 
-		p := make([]byte, l)
-		if _, err := io.ReadFull(conn, p); err != nil {
-			slog.Error("conn", "readpayload", err)
 			continue
 		}
 
-		go registry[mType].Handle(format, cid, conn, p)
+		go dispatch(registry[req.Type], format, cid, c, req.Payload)
 	}
 }
+
+// dispatch handles a request. A panicking handler only fails its request, not the daemon.
+func dispatch(h MessageHandler, format uint8, cid uint32, conn net.Conn, data []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("conn", "panic", r)
+		}
+	}()
+
+	h.Handle(format, cid, conn, data)
+}
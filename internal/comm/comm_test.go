@@ -0,0 +1,119 @@
+package comm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
+	"github.com/abenz1267/elephant/v2/internal/providers"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+type frame struct {
+	t  uint8
+	id uint32
+}
+
+// TestRequestIDs interleaves requests on one connection and matches all responses, including async
+// items, to their request by id.
+func TestRequestIDs(t *testing.T) {
+	name := "test"
+
+	providers.Replace(map[string]providers.Provider{
+		name: {
+			Name:       &name,
+			NamePretty: &name,
+			Query: func(conn net.Conn, query string, single bool, exact bool, format uint8) []*pb.QueryResponse_Item {
+				item := &pb.QueryResponse_Item{Identifier: "1", Text: query, Provider: name}
+
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					handlers.UpdateItem(format, query, conn, &pb.QueryResponse_Item{Identifier: "1", Text: "updated", Provider: name})
+				}()
+
+				return []*pb.QueryResponse_Item{item}
+			},
+			Activate: func(single bool, identifier, action, query, args string, format uint8, conn net.Conn) {
+				time.Sleep(20 * time.Millisecond)
+			},
+			State: func(provider string) *pb.ProviderStateResponse {
+				return &pb.ProviderStateResponse{}
+			},
+		},
+	})
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	go handle(&compressConn{Conn: server}, 1)
+
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+
+	handshake, _ := json.Marshal(&pb.HandshakeRequest{ProtocolVersion: protocol.Version, Features: []string{protocol.FeatureAsyncItems, protocol.FeatureRequestIDs}})
+
+	if _, err := client.Write(protocol.AppendRequest(nil, protocol.Request{Type: HandshakeRequestHandlerPos, Format: JSON, Payload: handshake})); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bufio.NewReader(client)
+
+	// the handshake is answered without id
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		t.Fatal(err)
+	}
+
+	if header[0] != handlers.Handshake {
+		t.Fatalf("expected handshake response, got type %d", header[0])
+	}
+
+	if _, err := reader.Discard(int(binary.BigEndian.Uint32(header[1:5]))); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := []protocol.Request{
+		{Type: ActivateRequestHandlerPos, Format: JSON, ID: 10, Payload: []byte(`{"provider":"test","identifier":"1"}`)},
+		{Type: QueryRequestHandlerPos, Format: JSON, ID: 11, Payload: []byte(`{"providers":["test"],"query":"doc","maxresults":10}`)},
+		{Type: StateRequestHandlerPos, Format: JSON, ID: 12, Payload: []byte(`{"provider":"test"}`)},
+	}
+
+	for _, v := range requests {
+		if _, err := client.Write(protocol.AppendTaggedRequest(nil, v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[frame]bool{
+		{handlers.ActivationFinished, 10}: true,
+		{handlers.QueryItem, 11}:          true,
+		{handlers.QueryDone, 11}:          true,
+		{handlers.QueryAsyncItem, 11}:     true,
+		{handlers.ProviderState, 12}:      true,
+		{handlers.StatusDone, 12}:         true,
+	}
+
+	for len(want) > 0 {
+		header := make([]byte, 9)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			t.Fatalf("%v, missing %v", err, want)
+		}
+
+		f := frame{header[0], binary.BigEndian.Uint32(header[1:5])}
+
+		if !want[f] {
+			t.Fatalf("unexpected frame of type %d for request %d", f.t, f.id)
+		}
+
+		delete(want, f)
+
+		if _, err := reader.Discard(int(binary.BigEndian.Uint32(header[5:9]))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
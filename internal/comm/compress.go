@@ -0,0 +1,179 @@
+package comm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression of response payloads, requested by clients in the upper bits of the format byte. Once
+// requested, every payload of the connection starts with a byte telling how it's compressed, as small
+// payloads stay uncompressed.
+const (
+	CompressionNone = 0
+	CompressionGzip = 1
+	CompressionZstd = 2
+
+	formatMask       = 0x0f
+	compressionShift = 4
+)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	gzipPool    = sync.Pool{
+		New: func() any {
+			return gzip.NewWriter(nil)
+		},
+	}
+)
+
+// compressConn compresses the payloads of the frames written to the connection, once the client asked
+// for it. Frames are always written as a whole, so each write is parsed into frames.
+type compressConn struct {
+	net.Conn
+	compression atomic.Uint32
+	// tagged is set once the client declared request ids in its handshake.
+	tagged   atomic.Bool
+	recorder *recorder
+}
+
+func (c *compressConn) Write(b []byte) (int, error) {
+	return c.write(b, 0, false)
+}
+
+// write writes the frames in b, compressed and tagged with the request id if the client asked for it.
+func (c *compressConn) write(b []byte, id uint32, tagged bool) (int, error) {
+	c.recorder.response(b, id)
+
+	out := b
+
+	if compression := c.compression.Load(); compression != CompressionNone {
+		compressed, ok := compressFrames(out, compression)
+		if !ok {
+			slog.Error("comm", "compress", "write doesn't consist of whole frames")
+		} else {
+			out = compressed
+		}
+	}
+
+	if tagged {
+		withID, ok := protocol.TagResponses(out, id)
+		if !ok {
+			slog.Error("comm", "request id", "write doesn't consist of whole frames")
+		} else {
+			out = withID
+		}
+	}
+
+	if _, err := c.Conn.Write(out); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// compressFrames rewrites the frames in b, returns false if b doesn't consist of whole frames.
+func compressFrames(b []byte, compression uint32) ([]byte, bool) {
+	threshold := compressionThreshold()
+
+	var out bytes.Buffer
+	out.Grow(len(b))
+
+	for len(b) > 0 {
+		if len(b) < 5 {
+			return nil, false
+		}
+
+		length := int(binary.BigEndian.Uint32(b[1:5]))
+
+		if len(b) < 5+length {
+			return nil, false
+		}
+
+		t, payload := b[0], b[5:5+length]
+		b = b[5+length:]
+
+		// status frames have no payload
+		if length == 0 {
+			out.Write([]byte{t, 0, 0, 0, 0})
+			continue
+		}
+
+		used := byte(CompressionNone)
+
+		if length >= threshold {
+			if compressed, err := compress(payload, compression); err == nil && len(compressed) < length {
+				payload = compressed
+				used = byte(compression)
+			} else if err != nil {
+				slog.Error("comm", "compress", err)
+			}
+		}
+
+		lengthBuf := make([]byte, 4)
+		binary.BigEndian.PutUint32(lengthBuf, uint32(len(payload)+1))
+
+		out.WriteByte(t)
+		out.Write(lengthBuf)
+		out.WriteByte(used)
+		out.Write(payload)
+	}
+
+	return out.Bytes(), true
+}
+
+func compress(b []byte, compression uint32) ([]byte, error) {
+	switch compression {
+	case CompressionZstd:
+		zstdOnce.Do(func() {
+			// can't fail without options
+			zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		})
+
+		return zstdEncoder.EncodeAll(b, nil), nil
+	default:
+		var out bytes.Buffer
+
+		w := gzipPool.Get().(*gzip.Writer)
+		defer gzipPool.Put(w)
+
+		w.Reset(&out)
+
+		if _, err := w.Write(b); err != nil {
+			return nil, err
+		}
+
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+
+		return out.Bytes(), nil
+	}
+}
+
+func compressionThreshold() int {
+	if c := common.GetElephantConfig(); c != nil {
+		return c.CompressionThreshold
+	}
+
+	return 1024
+}
+
+// negotiate splits the format byte into the format and the requested compression. A request without
+// compression doesn't turn it off again, as responses of other requests might still be written.
+func (c *compressConn) negotiate(b byte) uint8 {
+	if compression := uint32(b >> compressionShift); compression == CompressionGzip || compression == CompressionZstd {
+		c.compression.Store(compression)
+	}
+
+	return b & formatMask
+}
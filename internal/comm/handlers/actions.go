@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/abenz1267/elephant/v2/internal/providers"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/history"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// builtinActions describes actions shared by many providers.
+var builtinActions = map[string]*pb.ActionDescriptor{
+	history.ActionDelete: {Label: "Remove from history", Icon: "edit-clear-history"},
+	actionStartMacro:     {Label: "Search", Icon: "system-search"},
+}
+
+// providerActions returns the action descriptors of the provider by action name.
+func providerActions(provider string) map[string]*pb.ActionDescriptor {
+	res := make(map[string]*pb.ActionDescriptor)
+
+	base, _, _ := strings.Cut(provider, ":")
+
+	if p, ok := providers.Get(base); ok && p.Actions != nil {
+		for _, v := range p.Actions() {
+			res[v.Action] = v
+		}
+	}
+
+	for _, v := range pipelineActions(provider, pipelines()) {
+		res[v.Action] = v
+	}
+
+	return res
+}
+
+// actionLabel turns an action name like "open_new_window" into "Open new window".
+func actionLabel(action string) string {
+	label := strings.ReplaceAll(action, "_", " ")
+
+	r, size := utf8.DecodeRuneInString(label)
+	if r == utf8.RuneError {
+		return label
+	}
+
+	return string(unicode.ToUpper(r)) + label[size:]
+}
+
+// keybinding returns the keybinding the user configured for the action. Keys of menus, f.e.
+// "menus:bookmarks:open", are more specific than the ones of their provider and of all providers.
+func keybinding(provider, action string) string {
+	c := common.GetElephantConfig()
+	if c == nil || len(c.Keybindings) == 0 {
+		return ""
+	}
+
+	base, _, _ := strings.Cut(provider, ":")
+
+	for _, v := range []string{provider + ":" + action, base + ":" + action, action} {
+		if k, ok := c.Keybindings[v]; ok {
+			return k
+		}
+	}
+
+	return ""
+}
+
+// describeActions sets the action descriptors of the item. The action the provider declares as default
+// is marked, if the item has it, otherwise its first action is.
+func describeActions(item *pb.QueryResponse_Item, declared map[string]*pb.ActionDescriptor) {
+	item.ActionDescriptors = make([]*pb.ActionDescriptor, 0, len(item.Actions))
+
+	def := -1
+
+	for i, v := range item.Actions {
+		d := &pb.ActionDescriptor{
+			Action: v,
+			Label:  actionLabel(v),
+		}
+
+		src, ok := declared[v]
+		if !ok {
+			src, ok = builtinActions[v]
+		}
+
+		if ok {
+			if src.Label != "" {
+				d.Label = src.Label
+			}
+
+			d.Icon = src.Icon
+			d.Arguments = src.Arguments
+			d.Multiple = src.Multiple
+			d.Keybinding = src.Keybinding
+
+			if src.Default && def == -1 {
+				def = i
+			}
+		}
+
+		if k := keybinding(item.Provider, v); k != "" {
+			d.Keybinding = k
+		}
+
+		item.ActionDescriptors = append(item.ActionDescriptors, d)
+	}
+
+	if len(item.ActionDescriptors) == 0 {
+		return
+	}
+
+	if def == -1 {
+		def = 0
+	}
+
+	item.ActionDescriptors[def].Default = true
+}
+
+// describeEntries sets the action descriptors of all entries, asking every provider only once.
+func describeEntries(entries []*pb.QueryResponse_Item) {
+	declared := make(map[string]map[string]*pb.ActionDescriptor)
+
+	for _, v := range entries {
+		if _, ok := declared[v.Provider]; !ok {
+			declared[v.Provider] = providerActions(v.Provider)
+		}
+
+		describeActions(v, declared[v.Provider])
+	}
+}
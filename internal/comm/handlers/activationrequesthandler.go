@@ -3,12 +3,14 @@ package handlers
 import (
 	"bytes"
 	"encoding/binary"
-	"encoding/json"
 	"log/slog"
 	"net"
 	"strings"
 
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
 	"github.com/abenz1267/elephant/v2/internal/providers"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/metrics"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 	"google.golang.org/protobuf/proto"
 )
@@ -18,19 +20,16 @@ type ActivateRequest struct{}
 func (a *ActivateRequest) Handle(format uint8, cid uint32, conn net.Conn, data []byte) {
 	req := &pb.ActivateRequest{}
 
-	switch format {
-	case 0:
-		if err := proto.Unmarshal(data, req); err != nil {
-			slog.Error("activationrequesthandler", "protobuf", err)
+	if err := protocol.Unmarshal(format, data, req); err != nil {
+		slog.Error("activationrequesthandler", "unmarshal", err)
 
-			return
-		}
-	case 1:
-		if err := json.Unmarshal(data, req); err != nil {
-			slog.Error("activationrequesthandler", "protobuf", err)
+		return
+	}
 
-			return
-		}
+	if !luaActivation(cid, req) {
+		activationFinished(conn)
+
+		return
 	}
 
 	provider := req.Provider
@@ -39,19 +38,89 @@ func (a *ActivateRequest) Handle(format uint8, cid uint32, conn net.Conn, data [
 		provider = strings.Split(provider, ":")[0]
 	}
 
-	if p, ok := providers.Providers[provider]; ok {
-		p.Activate(req.Single, req.Identifier, req.Action, req.Query, req.Arguments, format, conn)
+	if provider == macroProvider {
+		startMacro(format, conn, req.Identifier)
+		activationFinished(conn)
 
-		var buffer bytes.Buffer
-		buffer.Write([]byte{ActivationFinished})
+		return
+	}
+
+	if pl, ok := findPipeline(pipelines(), req.Provider, req.Action); ok {
+		activatePipeline(cid, pl, req)
+		activationFinished(conn)
+
+		return
+	}
 
-		lengthBuf := make([]byte, 4)
-		binary.BigEndian.PutUint32(lengthBuf, uint32(0))
-		buffer.Write(lengthBuf)
+	if p, ok := providers.Get(provider); ok {
+		if len(req.Identifiers) > 0 {
+			activateMultiple(provider, p, cid, req, format, conn)
+		} else {
+			hook := hookActivation(cid, req)
 
-		_, err := conn.Write(buffer.Bytes())
-		if err != nil {
-			slog.Debug("activation done", "write", err)
+			common.RunHooks(common.HookBefore, hook)
+			guard(provider, func() {
+				p.Activate(req.Single, req.Identifier, req.Action, req.Query, req.Arguments, format, conn)
+			})
+			common.RunHooks(common.HookAfter, hook)
 		}
+
+		activationFinished(conn)
 	}
 }
+
+func activationFinished(conn net.Conn) {
+	var buffer bytes.Buffer
+	buffer.Write([]byte{ActivationFinished})
+
+	lengthBuf := make([]byte, 4)
+	binary.BigEndian.PutUint32(lengthBuf, uint32(0))
+	buffer.Write(lengthBuf)
+
+	_, err := conn.Write(buffer.Bytes())
+	if err != nil {
+		slog.Debug("activation done", "write", err)
+	}
+}
+
+// activateMultiple lets the provider combine the activation of all identifiers, if it can, otherwise
+// activates them one by one.
+func activateMultiple(name string, p providers.Provider, cid uint32, req *pb.ActivateRequest, format uint8, conn net.Conn) {
+	hooks := make([]common.HookActivation, 0, len(req.Identifiers))
+
+	for _, v := range req.Identifiers {
+		single := proto.Clone(req).(*pb.ActivateRequest)
+		single.Identifier = v
+
+		hooks = append(hooks, hookActivation(cid, single))
+	}
+
+	for _, v := range hooks {
+		common.RunHooks(common.HookBefore, v)
+	}
+
+	guard(name, func() {
+		if p.ActivateMultiple == nil || !p.ActivateMultiple(req.Identifiers, req.Action, req.Query, req.Arguments, format, conn) {
+			for _, v := range req.Identifiers {
+				p.Activate(req.Single, v, req.Action, req.Query, req.Arguments, format, conn)
+			}
+		}
+	})
+
+	for _, v := range hooks {
+		common.RunHooks(common.HookAfter, v)
+	}
+}
+
+// guard runs an activation of the provider, counting a panic as failed activation instead of crashing
+// the daemon.
+func guard(provider string, activate func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			metrics.ActivationFailures.Inc(provider)
+			slog.Error("activationrequesthandler", "panic", r, "provider", provider)
+		}
+	}()
+
+	activate()
+}
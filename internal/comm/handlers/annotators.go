@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/providers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// annotator enriches the items it knows something about with badges. Annotators check themselves which
+// items they apply to.
+type annotator func(items []*pb.QueryResponse_Item, cfg common.Annotator)
+
+var annotators = map[string]annotator{
+	"git_status": annotateGitStatus,
+	"bookmarked": annotateBookmarked,
+	"file_size":  annotateFileSize,
+	"command":    annotateCommand,
+}
+
+func annotatorConfigs() []common.Annotator {
+	if c := common.GetElephantConfig(); c != nil {
+		return c.Annotators
+	}
+
+	return nil
+}
+
+// annotateEntries runs the configured annotators in order, each with the items of its providers.
+func annotateEntries(entries []*pb.QueryResponse_Item, list []common.Annotator) {
+	for _, cfg := range list {
+		fn, ok := annotators[cfg.Type]
+		if !ok {
+			slog.Error("annotators", "unknown", cfg.Type)
+			continue
+		}
+
+		items := []*pb.QueryResponse_Item{}
+
+		for _, v := range entries {
+			base, _, _ := strings.Cut(v.Provider, ":")
+
+			if len(cfg.Providers) == 0 || slices.Contains(cfg.Providers, v.Provider) || slices.Contains(cfg.Providers, base) {
+				items = append(items, v)
+			}
+		}
+
+		if len(items) == 0 {
+			continue
+		}
+
+		start := time.Now()
+
+		fn(items, cfg)
+
+		slog.Debug("annotators", "type", cfg.Type, "items", len(items), "time", time.Since(start))
+	}
+}
+
+// badge appends the text to the subtext of the item and adds the state, if given.
+func badge(item *pb.QueryResponse_Item, text, state string) {
+	if text != "" {
+		if item.Subtext == "" {
+			item.Subtext = text
+		} else {
+			item.Subtext = fmt.Sprintf("%s · %s", item.Subtext, text)
+		}
+	}
+
+	if state != "" && !slices.Contains(item.State, state) {
+		item.State = append(item.State, state)
+	}
+}
+
+// itemPath returns the file the item is about: the file of its preview, or its text if it's an absolute path.
+func itemPath(item *pb.QueryResponse_Item) (string, bool) {
+	if item.PreviewType == util.PreviewTypeFile && filepath.IsAbs(item.Preview) {
+		return filepath.Clean(item.Preview), true
+	}
+
+	if filepath.IsAbs(item.Text) {
+		return filepath.Clean(item.Text), true
+	}
+
+	return "", false
+}
+
+func annotateFileSize(items []*pb.QueryResponse_Item, _ common.Annotator) {
+	for _, v := range items {
+		path, ok := itemPath(v)
+		if !ok {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		badge(v, formatBytes(info.Size()), "")
+	}
+}
+
+func formatBytes(b int64) string {
+	const unit = 1024
+
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// annotateGitStatus marks files with uncommitted changes and directories containing them. Every repository
+// is asked once, its status is reused for a few seconds.
+func annotateGitStatus(items []*pb.QueryResponse_Item, _ common.Annotator) {
+	statuses := make(map[string]map[string]string)
+
+	for _, v := range items {
+		path, ok := itemPath(v)
+		if !ok {
+			continue
+		}
+
+		root, ok := gitRoot(path)
+		if !ok {
+			continue
+		}
+
+		status, ok := statuses[root]
+		if !ok {
+			status = gitStatus(root)
+			statuses[root] = status
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			continue
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if s, ok := status[rel]; ok {
+			badge(v, s, "git_"+s)
+			continue
+		}
+
+		for k := range status {
+			if rel == "." || strings.HasPrefix(k, rel+"/") {
+				badge(v, "modified", "git_modified")
+				break
+			}
+		}
+	}
+}
+
+// gitRoot returns the repository containing the path.
+func gitRoot(path string) (string, bool) {
+	for dir := path; ; {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+
+		dir = parent
+	}
+}
+
+// gitStatus returns the status of the changed files of the repository by their path relative to it.
+func gitStatus(root string) map[string]string {
+	res := make(map[string]string)
+
+	out, err := common.Exec("annotators", exec.Command("git", "-C", root, "status", "--porcelain", "-z"), common.ExecOptions{CacheFor: 5 * time.Second})
+	if err != nil {
+		slog.Debug("annotators", "git", err, "root", root)
+		return res
+	}
+
+	fields := bytes.Split(out, []byte{0})
+
+	for i := 0; i < len(fields); i++ {
+		f := string(fields[i])
+		if len(f) < 4 {
+			continue
+		}
+
+		xy, path := f[:2], strings.TrimSuffix(f[3:], "/")
+
+		switch {
+		case xy == "??":
+			res[path] = "untracked"
+		case strings.Contains(xy, "U") || xy == "AA" || xy == "DD":
+			res[path] = "conflict"
+		case xy[0] == 'R' || xy[0] == 'C':
+			res[path] = "renamed"
+			// the original path follows
+			i++
+		case xy[0] == 'A':
+			res[path] = "added"
+		case strings.Contains(xy, "D"):
+			res[path] = "deleted"
+		default:
+			res[path] = "modified"
+		}
+	}
+
+	return res
+}
+
+var (
+	urlPattern = regexp.MustCompile(`https?://[^\s,]+`)
+
+	bookmarkedMu      sync.Mutex
+	bookmarkedURLs    map[string]bool
+	bookmarkedFetched time.Time
+)
+
+func normalizeURL(u string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(u, "https://"), "http://"), "/")
+}
+
+// bookmarked returns the urls of the bookmarks provider, asking it again every 30 seconds.
+func bookmarked() map[string]bool {
+	bookmarkedMu.Lock()
+	defer bookmarkedMu.Unlock()
+
+	if bookmarkedURLs != nil && time.Since(bookmarkedFetched) < 30*time.Second {
+		return bookmarkedURLs
+	}
+
+	res := make(map[string]bool)
+
+	if p, ok := providers.Get("bookmarks"); ok {
+		for _, v := range p.Query(nil, "", true, false, 0) {
+			// the url is the subtext, or the text for bookmarks without description
+			for _, u := range urlPattern.FindAllString(v.Subtext+" "+v.Text, -1) {
+				res[normalizeURL(u)] = true
+			}
+		}
+	}
+
+	bookmarkedURLs, bookmarkedFetched = res, time.Now()
+
+	return res
+}
+
+// annotateBookmarked marks items about urls that are bookmarked.
+func annotateBookmarked(items []*pb.QueryResponse_Item, _ common.Annotator) {
+	var urls map[string]bool
+
+	for _, v := range items {
+		if v.Provider == "bookmarks" {
+			continue
+		}
+
+		u := ""
+
+		for _, s := range []string{v.Identifier, v.Text, v.Subtext} {
+			if u = urlPattern.FindString(s); u != "" {
+				break
+			}
+		}
+
+		if u == "" {
+			continue
+		}
+
+		if urls == nil {
+			urls = bookmarked()
+		}
+
+		if urls[normalizeURL(u)] {
+			badge(v, "bookmarked", "bookmarked")
+		}
+	}
+}
+
+// annotateCommand passes the items to the command as json, one per line, and adds the line it prints for
+// every item as badge. Empty lines leave the item as it is.
+func annotateCommand(items []*pb.QueryResponse_Item, cfg common.Annotator) {
+	if cfg.Command == "" {
+		return
+	}
+
+	var in bytes.Buffer
+	enc := json.NewEncoder(&in)
+
+	for _, v := range items {
+		path, _ := itemPath(v)
+
+		enc.Encode(struct {
+			Provider   string `json:"provider"`
+			Identifier string `json:"identifier"`
+			Text       string `json:"text"`
+			Subtext    string `json:"subtext"`
+			Path       string `json:"path,omitempty"`
+		}{v.Provider, v.Identifier, v.Text, v.Subtext, path})
+	}
+
+	cmd := common.HostShell(cfg.Command)
+	cmd.Stdin = &in
+
+	out, err := common.Exec("annotators", cmd, common.ExecOptions{})
+	if err != nil {
+		slog.Error("annotators", "command", err)
+		return
+	}
+
+	lines := strings.Split(string(out), "\n")
+
+	for k, v := range items {
+		if k >= len(lines) {
+			break
+		}
+
+		badge(v, strings.TrimSpace(lines[k]), "")
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+func TestAnnotators(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "notes.txt")
+
+	if err := os.WriteFile(file, make([]byte, 2048), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	items := []*pb.QueryResponse_Item{
+		{Provider: "files", Text: file, Preview: file, PreviewType: util.PreviewTypeFile},
+		{Provider: "menus:notes", Text: dir, Subtext: "Notes"},
+		{Provider: "calc", Text: "1+1"},
+	}
+
+	annotateEntries(items, []common.Annotator{
+		{Type: "file_size", Providers: []string{"files", "calc"}},
+		{Type: "command", Providers: []string{"menus"}, Command: `while read -r l; do echo "seen"; done`},
+		{Type: "unknown"},
+	})
+
+	want := []string{"2.0 KiB", "Notes · seen", ""}
+
+	for k, v := range items {
+		if v.Subtext != want[k] {
+			t.Errorf("subtext of %s = %q, want %q", v.Text, v.Subtext, want[k])
+		}
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		return
+	}
+
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %s", out)
+	}
+
+	items = []*pb.QueryResponse_Item{
+		{Provider: "files", Text: file},
+		{Provider: "files", Text: dir},
+		{Provider: "files", Text: os.TempDir()},
+	}
+
+	annotateEntries(items, []common.Annotator{{Type: "git_status"}})
+
+	if items[0].Subtext != "untracked" || !slices.Contains(items[0].State, "git_untracked") {
+		t.Errorf("unexpected file item %v", items[0])
+	}
+
+	if items[1].Subtext != "modified" || items[2].Subtext != "" {
+		t.Errorf("unexpected directory items %q, %q", items[1].Subtext, items[2].Subtext)
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/abenz1267/elephant/v2/internal/providers"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// dashboardEntries assembles the start page shown for an empty query. Every section takes the best items
+// of its provider, optionally only those with one of the given states. Entries keep the order of the
+// sections.
+func dashboardEntries(conn net.Conn, format uint8) []*pb.QueryResponse_Item {
+	cfg := common.GetElephantConfig()
+	if cfg == nil {
+		return nil
+	}
+
+	sections := make([][]*pb.QueryResponse_Item, len(cfg.Dashboard))
+
+	var wg sync.WaitGroup
+
+	for i, s := range cfg.Dashboard {
+		name, query := s.Provider, s.Query
+
+		if base, menu, ok := strings.Cut(s.Provider, ":"); ok {
+			name = base
+			query = fmt.Sprintf("%s:%s", menu, query)
+		}
+
+		p, ok := providers.Get(name)
+		if !ok {
+			continue
+		}
+
+		wg.Go(func() {
+			res := p.Query(conn, query, false, false, format)
+
+			if len(s.State) > 0 {
+				res = slices.DeleteFunc(res, func(e *pb.QueryResponse_Item) bool {
+					return !slices.ContainsFunc(s.State, func(state string) bool {
+						return slices.Contains(e.State, state)
+					})
+				})
+			}
+
+			slices.SortFunc(res, sortEntries)
+
+			if s.Max > 0 && len(res) > s.Max {
+				res = res[:s.Max]
+			}
+
+			group := s.Title
+			if group == "" {
+				group = groupName(s.Provider, cfg.Groups)
+			}
+
+			for _, e := range res {
+				e.Group = group
+			}
+
+			sections[i] = res
+		})
+	}
+
+	wg.Wait()
+
+	entries := slices.Concat(sections...)
+
+	// scores are replaced, so sorting keeps the order of the sections
+	for i, e := range entries {
+		e.Score = int32(len(entries) - i)
+	}
+
+	return entries
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
+	"github.com/abenz1267/elephant/v2/internal/providers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// DocsRequest returns the readme and config schema of providers, f.e. for settings UIs of clients.
+type DocsRequest struct{}
+
+func (a *DocsRequest) Handle(format uint8, cid uint32, conn net.Conn, data []byte) {
+	req := &pb.DocsRequest{}
+
+	if err := protocol.Unmarshal(format, data, req); err != nil {
+		slog.Error("docsrequesthandler", "unmarshal", err)
+
+		return
+	}
+
+	names := req.Providers
+
+	if len(names) == 0 {
+		names = []string{"elephant"}
+
+		for k := range providers.All() {
+			names = append(names, k)
+		}
+
+		slices.Sort(names[1:])
+	}
+
+	res := &pb.DocsResponse{}
+
+	for _, v := range names {
+		if d := doc(v); d != nil {
+			res.Docs = append(res.Docs, d)
+		}
+	}
+
+	w := newFrameWriter(conn, format)
+	defer w.Close()
+
+	if err := w.message(Docs, res); err != nil {
+		slog.Error("docsrequesthandler", "write", err)
+		return
+	}
+
+	if err := w.status(StatusDone); err != nil {
+		slog.Error("docsrequesthandler", "write", err)
+	}
+}
+
+// doc describes the provider, or the general config for "elephant". The readme is the output of
+// PrintDoc up to the config tables, which are returned structured instead.
+func doc(name string) *pb.DocsResponse_Doc {
+	if name == "elephant" {
+		return &pb.DocsResponse_Doc{
+			Name:       name,
+			NamePretty: "Elephant",
+			Tables:     util.ConfigTables(common.ElephantConfig{}),
+		}
+	}
+
+	p, ok := providers.Get(name)
+	if !ok {
+		return nil
+	}
+
+	readme := providers.Doc(name)
+
+	if i := strings.Index(readme, fmt.Sprintf("`~/.config/elephant/%s.toml`", name)); i >= 0 {
+		readme = readme[:i]
+	}
+
+	return &pb.DocsResponse_Doc{
+		Name:       name,
+		NamePretty: *p.NamePretty,
+		Readme:     strings.TrimSpace(readme),
+		Tables:     util.Schema(name),
+	}
+}
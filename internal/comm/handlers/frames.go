@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// frameBufferSize is the size of batched writes. Bigger frames are written on their own.
+const frameBufferSize = 64 * 1024
+
+var (
+	writerPool = sync.Pool{
+		New: func() any {
+			return bufio.NewWriterSize(nil, frameBufferSize)
+		},
+	}
+	framePool = sync.Pool{
+		New: func() any {
+			b := make([]byte, 0, 1024)
+			return &b
+		},
+	}
+)
+
+// frameWriter batches the frames of a response into few writes. Frames are never split across writes,
+// so async updates written to the same connection in the meantime can't end up within a frame.
+type frameWriter struct {
+	w      *bufio.Writer
+	format uint8
+}
+
+func newFrameWriter(w io.Writer, format uint8) *frameWriter {
+	bw := writerPool.Get().(*bufio.Writer)
+	bw.Reset(w)
+
+	return &frameWriter{w: bw, format: format}
+}
+
+// message writes a frame with the message, marshalled into a pooled buffer behind the header.
+func (f *frameWriter) message(t byte, m proto.Message) error {
+	bp := framePool.Get().(*[]byte)
+
+	defer func() {
+		// huge frames, f.e. with thumbnails, would keep their buffer alive otherwise
+		if cap(*bp) <= frameBufferSize {
+			framePool.Put(bp)
+		}
+	}()
+
+	b, err := appendFrame((*bp)[:0], t, m, f.format)
+	if err != nil {
+		return err
+	}
+
+	*bp = b
+
+	return f.write(b)
+}
+
+// status writes a frame without payload.
+func (f *frameWriter) status(status int) error {
+	return f.write([]byte{byte(status), 0, 0, 0, 0})
+}
+
+func (f *frameWriter) write(frame []byte) error {
+	if len(frame) > f.w.Available() && f.w.Buffered() > 0 {
+		if err := f.w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	_, err := f.w.Write(frame)
+
+	return err
+}
+
+// Close flushes the remaining frames and returns the writer to the pool.
+func (f *frameWriter) Close() error {
+	err := f.w.Flush()
+
+	f.w.Reset(nil)
+	writerPool.Put(f.w)
+
+	return err
+}
+
+// appendFrame appends the frame of the message to b: type, length and payload.
+func appendFrame(b []byte, t byte, m proto.Message, format uint8) ([]byte, error) {
+	b = append(b, t, 0, 0, 0, 0)
+
+	var err error
+
+	switch format {
+	case 0:
+		b, err = proto.MarshalOptions{}.MarshalAppend(b, m)
+	case 1:
+		var j []byte
+
+		j, err = json.Marshal(m)
+		b = append(b, j...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	binary.BigEndian.PutUint32(b[1:5], uint32(len(b)-5))
+
+	return b, nil
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func benchmarkItems(n int) []*pb.QueryResponse_Item {
+	items := make([]*pb.QueryResponse_Item, n)
+
+	for i := range items {
+		items[i] = &pb.QueryResponse_Item{
+			Identifier: fmt.Sprintf("item-%d", i),
+			Text:       fmt.Sprintf("Item %d", i),
+			Subtext:    "/usr/share/applications/item.desktop",
+			Icon:       "application-x-executable",
+			Provider:   "desktopapplications",
+			Score:      int32(n - i),
+			Type:       pb.QueryResponse_REGULAR,
+		}
+	}
+
+	return items
+}
+
+// benchmarkConn returns a unix socket connection like the ones of clients, with the other end drained.
+func benchmarkConn(b *testing.B) net.Conn {
+	l, err := net.Listen("unix", filepath.Join(b.TempDir(), "elephant.sock"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer l.Close()
+
+	accepted := make(chan net.Conn)
+
+	go func() {
+		c, _ := l.Accept()
+		accepted <- c
+	}()
+
+	conn, err := net.Dial("unix", l.Addr().String())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	remote := <-accepted
+
+	go io.Copy(io.Discard, remote)
+
+	b.Cleanup(func() {
+		conn.Close()
+		remote.Close()
+	})
+
+	return conn
+}
+
+// BenchmarkFramesUnbatched writes every frame on its own, as done before batching.
+func BenchmarkFramesUnbatched(b *testing.B) {
+	items := benchmarkItems(5000)
+	conn := benchmarkConn(b)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		for _, v := range items {
+			data, err := proto.Marshal(&pb.QueryResponse{Query: "item", Item: v})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			var buffer bytes.Buffer
+			buffer.Write([]byte{QueryItem})
+
+			lengthBuf := make([]byte, 4)
+			binary.BigEndian.PutUint32(lengthBuf, uint32(len(data)))
+			buffer.Write(lengthBuf)
+			buffer.Write(data)
+
+			if _, err := conn.Write(buffer.Bytes()); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		writeStatus(QueryDone, conn)
+	}
+}
+
+func BenchmarkFramesBatched(b *testing.B) {
+	items := benchmarkItems(5000)
+	conn := benchmarkConn(b)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		w := newFrameWriter(conn, 0)
+
+		for _, v := range items {
+			if err := w.message(QueryItem, &pb.QueryResponse{Query: "item", Item: v}); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		w.status(QueryDone)
+
+		if err := w.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestFrameWriter(t *testing.T) {
+	items := benchmarkItems(3000)
+	// bigger than the buffer, so it's written on its own
+	items[10].Preview = string(bytes.Repeat([]byte("a"), frameBufferSize*2))
+
+	var out bytes.Buffer
+
+	w := newFrameWriter(&out, 0)
+
+	for _, v := range items {
+		if err := w.message(QueryItem, &pb.QueryResponse{Item: v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w.status(QueryDone)
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := out.Bytes()
+
+	for k, v := range items {
+		if data[0] != QueryItem {
+			t.Fatalf("frame %d: type %d", k, data[0])
+		}
+
+		length := binary.BigEndian.Uint32(data[1:5])
+
+		res := &pb.QueryResponse{}
+		if err := proto.Unmarshal(data[5:5+length], res); err != nil {
+			t.Fatal(err)
+		}
+
+		if res.Item.Identifier != v.Identifier || res.Item.Preview != v.Preview {
+			t.Fatalf("frame %d: got %s", k, res.Item.Identifier)
+		}
+
+		data = data[5+length:]
+	}
+
+	if !bytes.Equal(data, []byte{QueryDone, 0, 0, 0, 0}) {
+		t.Fatalf("unexpected rest: %v", data)
+	}
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
+	"github.com/abenz1267/elephant/v2/internal/providers"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+type handler interface {
+	Handle(format uint8, cid uint32, conn net.Conn, data []byte)
+}
+
+var update = flag.Bool("update", false, "update the golden files")
+
+var activated []string
+
+func TestMain(m *testing.M) {
+	name := "test"
+	pretty := "Test"
+	rich := "rich"
+	richPretty := "Rich"
+	clock := "clock"
+
+	providers.Replace(map[string]providers.Provider{
+		name: {
+			Name:       &name,
+			NamePretty: &pretty,
+			Query: func(conn net.Conn, query string, single bool, exact bool, format uint8) []*pb.QueryResponse_Item {
+				return []*pb.QueryResponse_Item{
+					{Identifier: "1", Text: "first " + query, Provider: name, Score: 20, Actions: []string{"open"}},
+					{Identifier: "2", Text: "second " + query, Provider: name, Score: 10, Actions: []string{"open"}},
+				}
+			},
+			Activate: func(single bool, identifier, action, query, args string, format uint8, conn net.Conn) {
+				activated = append(activated, fmt.Sprintf("%s:%s", identifier, action))
+			},
+			State: func(provider string) *pb.ProviderStateResponse {
+				return &pb.ProviderStateResponse{States: []string{"ready"}, Actions: []string{"open"}}
+			},
+		},
+		rich: {
+			Name:       &rich,
+			NamePretty: &richPretty,
+			Query: func(conn net.Conn, query string, single bool, exact bool, format uint8) []*pb.QueryResponse_Item {
+				return []*pb.QueryResponse_Item{
+					{Identifier: "1", Text: "file", Provider: rich, Group: "Files", Preview: "/tmp/file", PreviewType: "file", Thumbnail: []byte{1, 2, 3}},
+					{Identifier: "2", Text: "text", Provider: rich, Group: "Files", Preview: "text", PreviewType: "text"},
+				}
+			},
+		},
+		clock: {
+			Name:       &clock,
+			NamePretty: &clock,
+			Query: func(conn net.Conn, query string, single bool, exact bool, format uint8) []*pb.QueryResponse_Item {
+				return []*pb.QueryResponse_Item{{Identifier: "now", Text: "0", Provider: clock, Score: 5, LiveInterval: 1}}
+			},
+			Live: func(identifier string) *pb.QueryResponse_Item {
+				ticks.Add(1)
+				return &pb.QueryResponse_Item{Identifier: identifier, Text: fmt.Sprint(ticks.Load()), LiveInterval: 1}
+			},
+		},
+	})
+
+	os.Exit(m.Run())
+}
+
+// serve runs the handler with the request in the given format and returns the written frames.
+func serve(t *testing.T, h handler, format uint8, req proto.Message) []byte {
+	t.Helper()
+
+	var data []byte
+	var err error
+
+	if format == protocol.JSON {
+		data, err = json.Marshal(req)
+	} else {
+		data, err = proto.Marshal(req)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server, client := net.Pipe()
+
+	out := make(chan []byte)
+
+	go func() {
+		b, _ := io.ReadAll(client)
+		out <- b
+	}()
+
+	h.Handle(format, 1, server, data)
+	server.Close()
+
+	return <-out
+}
+
+// qidField is replaced, as the qid counter is shared by all queries.
+var qidField = regexp.MustCompile(`"qid":\s*\d+`)
+
+// render prints the frames with indented json payloads.
+func render(t *testing.T, frames []byte) string {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	for len(frames) > 0 {
+		if len(frames) < 5 {
+			t.Fatalf("truncated frame header %x", frames)
+		}
+
+		length := int(binary.BigEndian.Uint32(frames[1:5]))
+		if len(frames) < 5+length {
+			t.Fatalf("truncated frame of type %d", frames[0])
+		}
+
+		fmt.Fprintf(&out, "type %d\n", frames[0])
+
+		if payload := frames[5 : 5+length]; length > 0 {
+			if err := json.Indent(&out, qidField.ReplaceAll(payload, []byte(`"qid":0`)), "", "  "); err != nil {
+				t.Fatalf("payload of type %d: %v", frames[0], err)
+			}
+
+			out.WriteString("\n")
+		}
+
+		frames = frames[5+length:]
+	}
+
+	return out.String()
+}
+
+func golden(t *testing.T, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", t.Name()+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run with -update to create it", err)
+	}
+
+	if got != string(want) {
+		t.Errorf("response differs from %s:\n%s", path, got)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	activated = nil
+
+	tests := []struct {
+		name    string
+		handler handler
+		req     proto.Message
+	}{
+		{"query", &QueryRequest{}, &pb.QueryRequest{Providers: []string{"test"}, Query: "doc", Maxresults: 10}},
+		{"query_max_results", &QueryRequest{}, &pb.QueryRequest{Providers: []string{"test"}, Query: "doc", Maxresults: 1}},
+		{"query_negative_max_results", &QueryRequest{}, &pb.QueryRequest{Providers: []string{"test"}, Query: "doc", Maxresults: -1}},
+		{"query_unknown_provider", &QueryRequest{}, &pb.QueryRequest{Providers: []string{"unknown"}, Query: "doc", Maxresults: 10}},
+		{"activate", &ActivateRequest{}, &pb.ActivateRequest{Provider: "test", Identifier: "1", Action: "open"}},
+		{"activate_multiple", &ActivateRequest{}, &pb.ActivateRequest{Provider: "test", Identifiers: []string{"1", "2"}, Action: "open"}},
+		{"state", &StateRequest{}, &pb.ProviderStateRequest{Provider: "test"}},
+		{"state_unknown_provider", &StateRequest{}, &pb.ProviderStateRequest{Provider: "unknown"}},
+		{"docs", &DocsRequest{}, &pb.DocsRequest{Providers: []string{"test", "unknown"}}},
+		{"speech_stop", &SpeechRequest{}, &pb.SpeechRequest{Stop: true}},
+		{"subscribe", &SubscribeRequest{}, &pb.SubscribeRequest{Provider: "unknown", Interval: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			golden(t, render(t, serve(t, tt.handler, protocol.JSON, tt.req)))
+		})
+	}
+
+	if fmt.Sprint(activated) != "[1:open 1:open 2:open]" {
+		t.Errorf("unexpected activations %v", activated)
+	}
+}
+
+// TestGoldenMenu opens a menu, which is sent to clients subscribed to menus.
+func TestGoldenMenu(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	(&SubscribeRequest{}).Handle(protocol.JSON, 1, server, []byte(`{"provider":"menus"}`))
+
+	go (&MenuRequest{}).Handle(protocol.JSON, 1, server, []byte(`{"menu":"screenshots"}`))
+
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(client, header); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint32(header[1:5]))
+	if _, err := io.ReadFull(client, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	golden(t, render(t, append(header, payload...)))
+}
+
+// TestMalformedRequests sends broken payloads and unknown formats, which are dropped without a response.
+func TestMalformedRequests(t *testing.T) {
+	handlers := map[string]handler{
+		"query":      &QueryRequest{},
+		"activate":   &ActivateRequest{},
+		"subscribe":  &SubscribeRequest{},
+		"menu":       &MenuRequest{},
+		"state":      &StateRequest{},
+		"speech":     &SpeechRequest{},
+		"docs":       &DocsRequest{},
+		"handshake":  &HandshakeRequest{},
+		"visibility": &VisibilityRequest{},
+	}
+
+	inputs := []struct {
+		format uint8
+		data   []byte
+	}{
+		{protocol.Protobuf, []byte{0xff, 0xff}},
+		{protocol.JSON, []byte(`{"query":`)},
+		{protocol.JSON, []byte(`[]`)},
+		{7, []byte(`{}`)},
+	}
+
+	for name, h := range handlers {
+		for _, in := range inputs {
+			t.Run(fmt.Sprintf("%s/%d/%s", name, in.format, in.data), func(t *testing.T) {
+				server, client := net.Pipe()
+
+				out := make(chan []byte)
+
+				go func() {
+					b, _ := io.ReadAll(client)
+					out <- b
+				}()
+
+				h.Handle(in.format, 1, server, in.data)
+				server.Close()
+
+				if b := <-out; len(b) != 0 {
+					t.Errorf("expected no response, got %x", b)
+				}
+			})
+		}
+	}
+}
+
+// TestHandshake tailors the responses of a connection to the features declared in its handshake.
+func TestHandshake(t *testing.T) {
+	tests := []struct {
+		name      string
+		handshake *pb.HandshakeRequest
+	}{
+		{"none", nil},
+		{"all", &pb.HandshakeRequest{ProtocolVersion: protocol.Version, Features: protocol.Features, PreviewTypes: []string{"file", "text"}}},
+		{"minimal", &pb.HandshakeRequest{ProtocolVersion: protocol.Version, Features: []string{"previews", "unknown"}, PreviewTypes: []string{"text"}}},
+		{"incompatible", &pb.HandshakeRequest{ProtocolVersion: protocol.Version + 1, Client: "future"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := net.Pipe()
+
+			out := make(chan []byte)
+
+			go func() {
+				b, _ := io.ReadAll(client)
+				out <- b
+			}()
+
+			if tt.handshake != nil {
+				data, err := json.Marshal(tt.handshake)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				(&HandshakeRequest{}).Handle(protocol.JSON, 1, server, data)
+			}
+
+			(&QueryRequest{}).Handle(protocol.JSON, 1, server, []byte(`{"providers":["rich"],"maxresults":10,"grouped":true}`))
+			server.Close()
+
+			golden(t, render(t, <-out))
+		})
+	}
+}
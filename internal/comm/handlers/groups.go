@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/internal/providers"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// groupName returns the configured group of the provider, or its pretty name.
+func groupName(provider string, cfg common.Groups) string {
+	if v, ok := cfg.Names[provider]; ok {
+		return v
+	}
+
+	base, menu, isMenu := strings.Cut(provider, ":")
+
+	if v, ok := cfg.Names[base]; ok {
+		return v
+	}
+
+	if isMenu {
+		if m, ok := common.Menus[menu]; ok && m.NamePretty != "" {
+			return m.NamePretty
+		}
+	}
+
+	if p, ok := providers.Get(base); ok && p.NamePretty != nil {
+		return *p.NamePretty
+	}
+
+	return base
+}
+
+// groupEntries sorts the entries, which are already sorted by score, by group. Groups in the configured
+// order come first, the others follow by their best entry.
+func groupEntries(entries []*pb.QueryResponse_Item) []*pb.QueryResponse_Item {
+	cfg := common.Groups{}
+
+	if c := common.GetElephantConfig(); c != nil {
+		cfg = c.Groups
+	}
+
+	order := []string{}
+	grouped := make(map[string][]*pb.QueryResponse_Item)
+
+	for _, v := range entries {
+		if v.Group == "" {
+			v.Group = groupName(v.Provider, cfg)
+		}
+
+		if _, ok := grouped[v.Group]; !ok {
+			order = append(order, v.Group)
+		}
+
+		if cfg.MaxPerGroup > 0 && len(grouped[v.Group]) >= cfg.MaxPerGroup {
+			continue
+		}
+
+		grouped[v.Group] = append(grouped[v.Group], v)
+	}
+
+	rank := func(group string) int {
+		if i := slices.Index(cfg.Order, group); i != -1 {
+			return i
+		}
+
+		return len(cfg.Order)
+	}
+
+	// stable, so unconfigured groups keep the order of their best entry
+	slices.SortStableFunc(order, func(a, b string) int {
+		return rank(a) - rank(b)
+	})
+
+	res := make([]*pb.QueryResponse_Item, 0, len(entries))
+
+	for _, g := range order {
+		res = append(res, grouped[g]...)
+	}
+
+	return res
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// HandshakeRequest stores the features a client declared, responses to it are tailored to them. Clients
+// of an incompatible protocol version get an error and the connection is closed.
+type HandshakeRequest struct{}
+
+func (a *HandshakeRequest) Handle(format uint8, cid uint32, conn net.Conn, data []byte) {
+	req := &pb.HandshakeRequest{}
+
+	if err := protocol.Unmarshal(format, data, req); err != nil {
+		slog.Error("handshakerequesthandler", "unmarshal", err)
+
+		return
+	}
+
+	res := &pb.HandshakeResponse{
+		ProtocolVersion: protocol.Version,
+	}
+
+	compatible := protocol.Compatible(req.ProtocolVersion)
+
+	if compatible {
+		res.Features = protocol.Supported(req.Features)
+
+		common.SetClientFeatures(conn, common.ClientFeatures{
+			Features:     res.Features,
+			PreviewTypes: req.PreviewTypes,
+		})
+		common.SetWantsThumbnails(conn, slices.Contains(res.Features, protocol.FeatureThumbnails))
+
+		slog.Info("handshakerequesthandler", "client", req.Client, "version", req.ProtocolVersion, "features", res.Features)
+	} else {
+		res.Error = fmt.Sprintf("unsupported protocol version %d, elephant speaks version %d", req.ProtocolVersion, protocol.Version)
+
+		slog.Error("handshakerequesthandler", "client", req.Client, "version", req.ProtocolVersion, "error", res.Error)
+	}
+
+	w := newFrameWriter(conn, format)
+
+	if err := w.message(Handshake, res); err != nil {
+		slog.Error("handshakerequesthandler", "write", err)
+	}
+
+	if err := w.Close(); err != nil {
+		slog.Error("handshakerequesthandler", "write", err)
+	}
+
+	if !compatible {
+		conn.Close()
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"sync"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// shown holds the items of the last query per client, so hooks can be given the text of activated items.
+var (
+	shown   = make(map[uint32]map[string]*pb.QueryResponse_Item)
+	shownMu sync.Mutex
+)
+
+func itemKey(provider, identifier string) string {
+	return provider + "\x00" + identifier
+}
+
+func rememberItems(cid uint32, items []*pb.QueryResponse_Item) {
+	if !common.HasHooks() && !common.HasPipelines() && !common.HasLuaHooks() {
+		return
+	}
+
+	res := make(map[string]*pb.QueryResponse_Item, len(items))
+
+	for _, v := range items {
+		res[itemKey(v.Provider, v.Identifier)] = v
+	}
+
+	shownMu.Lock()
+	shown[cid] = res
+	shownMu.Unlock()
+}
+
+func hookActivation(cid uint32, req *pb.ActivateRequest) common.HookActivation {
+	a := common.HookActivation{
+		Provider:   req.Provider,
+		Action:     req.Action,
+		Identifier: req.Identifier,
+		Query:      req.Query,
+		Arguments:  req.Arguments,
+	}
+
+	shownMu.Lock()
+	item, ok := shown[cid][itemKey(req.Provider, req.Identifier)]
+	shownMu.Unlock()
+
+	if ok {
+		a.Text = item.Text
+		a.Subtext = item.Subtext
+	}
+
+	return a
+}
+
+// luaActivation passes the activation to on_activate of hooks.lua, which can rewrite the request. It returns
+// false if the activation was vetoed. Activations of several items are passed on item by item, vetoed items
+// are skipped and only their identifiers can be rewritten.
+func luaActivation(cid uint32, req *pb.ActivateRequest) bool {
+	if len(req.Identifiers) == 0 {
+		a, ok := common.LuaActivateHook(hookActivation(cid, req))
+		if !ok {
+			return false
+		}
+
+		req.Provider, req.Identifier, req.Action, req.Query, req.Arguments = a.Provider, a.Identifier, a.Action, a.Query, a.Arguments
+
+		return true
+	}
+
+	identifiers := make([]string, 0, len(req.Identifiers))
+
+	for _, v := range req.Identifiers {
+		single := proto.Clone(req).(*pb.ActivateRequest)
+		single.Identifier = v
+
+		if a, ok := common.LuaActivateHook(hookActivation(cid, single)); ok {
+			identifiers = append(identifiers, a.Identifier)
+		}
+	}
+
+	req.Identifiers = identifiers
+
+	return len(identifiers) > 0
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/abenz1267/elephant/v2/pkg/common/jobs"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// JobsAll is the query to subscribe to the output of all jobs. Subscribing with the id of a job as query
+// only streams its output.
+const JobsAll = "*"
+
+func init() {
+	jobs.Listen(streamJob)
+}
+
+// streamJob sends output of jobs to subscribers of the "jobs" provider.
+func streamJob(e jobs.Event) {
+	if e.Line == "" {
+		return
+	}
+
+	id := strconv.FormatUint(uint64(e.Job.ID), 10)
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	for k, v := range subs {
+		if v.provider != "jobs" || (v.query != JobsAll && v.query != id) {
+			continue
+		}
+
+		if ok := writeSubscription(v.format, v.conn, &pb.SubscribeResponse{Value: "jobs", Job: e.Job.ID, Output: e.Line}); !ok {
+			delete(subs, k)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
+	"github.com/abenz1267/elephant/v2/internal/providers"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// minLiveInterval keeps live items with tiny intervals from keeping the cpu busy.
+const minLiveInterval = 250 * time.Millisecond
+
+type liveItem struct {
+	item  *pb.QueryResponse_Item
+	query string
+}
+
+// liveClient holds the live items of the last query of a client and updates the visible ones.
+type liveClient struct {
+	items    map[string]liveItem
+	watchers map[string]context.CancelFunc
+}
+
+var (
+	live   = make(map[uint32]*liveClient)
+	liveMu sync.Mutex
+)
+
+// rememberLive stores the live items sent to the client. Items of previous results aren't updated anymore.
+func rememberLive(cid uint32, query string, items []*pb.QueryResponse_Item) {
+	res := make(map[string]liveItem)
+
+	for _, v := range items {
+		if v.LiveInterval > 0 {
+			res[itemKey(v.Provider, v.Identifier)] = liveItem{item: v, query: query}
+		}
+	}
+
+	liveMu.Lock()
+	defer liveMu.Unlock()
+
+	c, ok := live[cid]
+	if !ok {
+		if len(res) == 0 {
+			return
+		}
+
+		c = &liveClient{watchers: make(map[string]context.CancelFunc)}
+		live[cid] = c
+	}
+
+	c.items = res
+
+	for k, cancel := range c.watchers {
+		if _, ok := res[k]; !ok {
+			cancel()
+			delete(c.watchers, k)
+		}
+	}
+}
+
+// ForgetConnection stops updating the live items of a closed connection.
+func ForgetConnection(cid uint32) {
+	liveMu.Lock()
+	defer liveMu.Unlock()
+
+	if c, ok := live[cid]; ok {
+		for _, cancel := range c.watchers {
+			cancel()
+		}
+
+		delete(live, cid)
+	}
+}
+
+// VisibilityRequest sets the items the client currently shows. Visible live items are updated with
+// QueryAsyncItem frames in their interval, until they're hidden.
+type VisibilityRequest struct{}
+
+func (a *VisibilityRequest) Handle(format uint8, cid uint32, conn net.Conn, data []byte) {
+	req := &pb.VisibilityRequest{}
+
+	if err := protocol.Unmarshal(format, data, req); err != nil {
+		slog.Error("visibilityrequesthandler", "unmarshal", err)
+
+		return
+	}
+
+	visible := make(map[string]bool, len(req.Items))
+
+	for _, v := range req.Items {
+		visible[itemKey(v.Provider, v.Identifier)] = true
+	}
+
+	liveMu.Lock()
+	defer liveMu.Unlock()
+
+	c, ok := live[cid]
+	if !ok {
+		return
+	}
+
+	for k, cancel := range c.watchers {
+		if !visible[k] {
+			cancel()
+			delete(c.watchers, k)
+		}
+	}
+
+	for k := range visible {
+		if _, ok := c.watchers[k]; ok {
+			continue
+		}
+
+		item, ok := c.items[k]
+		if !ok {
+			continue
+		}
+
+		base, _, _ := strings.Cut(item.item.Provider, ":")
+
+		p, ok := providers.Get(base)
+		if !ok || p.Live == nil {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		c.watchers[k] = cancel
+
+		go watchLive(ctx, p, item, format, conn)
+	}
+}
+
+// watchLive asks the provider for the current state of the item in its interval and sends it to the client.
+func watchLive(ctx context.Context, p providers.Provider, live liveItem, format uint8, conn net.Conn) {
+	ticker := time.NewTicker(max(time.Duration(live.item.LiveInterval)*time.Millisecond, minLiveInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		item := p.Live(live.item.Identifier)
+		if item == nil {
+			continue
+		}
+
+		// keep the position and highlighting of the query result
+		item.Provider = live.item.Provider
+		item.Score = live.item.Score
+
+		if item.Fuzzyinfo == nil {
+			item.Fuzzyinfo = live.item.Fuzzyinfo
+		}
+
+		UpdateItem(format, live.query, conn, item)
+
+		// f.e. a job that finished
+		if item.LiveInterval == 0 {
+			return
+		}
+	}
+}
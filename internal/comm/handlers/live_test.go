@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var ticks atomic.Int32
+
+// TestLive updates a live item while it's visible.
+func TestLive(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	defer ForgetConnection(2)
+
+	frames := make(chan *pb.QueryResponse)
+
+	go func() {
+		reader := bufio.NewReader(client)
+
+		for {
+			header := make([]byte, 5)
+			if _, err := io.ReadFull(reader, header); err != nil {
+				return
+			}
+
+			payload := make([]byte, binary.BigEndian.Uint32(header[1:5]))
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+
+			if header[0] == QueryAsyncItem {
+				res := &pb.QueryResponse{}
+				json.Unmarshal(payload, res)
+				frames <- res
+			}
+		}
+	}()
+
+	(&QueryRequest{}).Handle(protocol.JSON, 2, server, []byte(`{"providers":["clock"],"maxresults":10}`))
+	(&VisibilityRequest{}).Handle(protocol.JSON, 2, server, []byte(`{"items":[{"provider":"clock","identifier":"now"}]}`))
+
+	for range 2 {
+		select {
+		case res := <-frames:
+			if res.Item.Identifier != "now" || res.Item.Provider != "clock" || res.Item.Score != 5 || res.Item.Text == "0" {
+				t.Fatalf("unexpected update %v", res.Item)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("no update of the visible live item")
+		}
+	}
+
+	(&VisibilityRequest{}).Handle(protocol.JSON, 2, server, []byte(`{}`))
+
+	liveMu.Lock()
+	watching := len(live[2].watchers)
+	liveMu.Unlock()
+
+	if watching != 0 {
+		t.Fatalf("%d hidden items are still updated", watching)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+const testHooks = `
+function on_query(query, providers)
+	if query == "secret" then
+		return false
+	end
+
+	if providers[1] == "calc" then
+		return string.gsub(query, "x", "*")
+	end
+end
+
+function on_activate(provider, identifier, action, item)
+	if identifier == "blocked" then
+		return false
+	end
+
+	if provider == "files" and item.arguments == "dir" then
+		return { action = "open_dir" }
+	end
+end
+`
+
+func TestLuaHooks(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "elephant"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "elephant", "hooks.lua"), []byte(testHooks), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := []struct {
+		query     string
+		providers []string
+		want      string
+		allowed   bool
+	}{
+		{"secret", []string{"files"}, "secret", false},
+		{"2x3", []string{"calc"}, "2*3", true},
+		{"2x3", []string{"files"}, "2x3", true},
+	}
+
+	for _, tt := range queries {
+		if got, allowed := common.LuaQueryHook(tt.query, tt.providers); got != tt.want || allowed != tt.allowed {
+			t.Errorf("LuaQueryHook(%q) = %q, %v", tt.query, got, allowed)
+		}
+	}
+
+	req := &pb.ActivateRequest{Provider: "files", Identifier: "a", Action: "open", Arguments: "dir"}
+
+	if !luaActivation(0, req) || req.Action != "open_dir" || req.Identifier != "a" {
+		t.Errorf("unexpected activation %v", req)
+	}
+
+	if luaActivation(0, &pb.ActivateRequest{Provider: "files", Identifier: "blocked"}) {
+		t.Error("activation wasn't vetoed")
+	}
+
+	req = &pb.ActivateRequest{Provider: "files", Identifiers: []string{"a", "blocked", "b"}}
+
+	if !luaActivation(0, req) || !slices.Equal(req.Identifiers, []string{"a", "b"}) {
+		t.Errorf("unexpected identifiers %v", req.Identifiers)
+	}
+
+	if !common.HasLuaHooks() {
+		t.Error("hooks.lua isn't loaded")
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"log/slog"
+	"net"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+const (
+	// macroProvider answers for the entries of macros. It isn't a loaded provider, the handlers answer for it.
+	macroProvider    = "macros"
+	actionStartMacro = "start_macro"
+	minMacroScore    = 30
+)
+
+func macros() []common.Macro {
+	if c := common.GetElephantConfig(); c != nil {
+		return c.Macros
+	}
+
+	return nil
+}
+
+// findMacro returns the macro whose prefix starts the query and the query without the prefix. The longest
+// prefix wins, so "p " and "proj " can both be used.
+func findMacro(query string, list []common.Macro) (common.Macro, string, bool) {
+	res, found := common.Macro{}, false
+
+	for _, v := range list {
+		if v.Prefix == "" || len(v.Providers) == 0 || !strings.HasPrefix(query, v.Prefix) {
+			continue
+		}
+
+		if !found || len(v.Prefix) > len(res.Prefix) {
+			res, found = v, true
+		}
+	}
+
+	if !found {
+		return res, query, false
+	}
+
+	return res, strings.TrimPrefix(query, res.Prefix), true
+}
+
+// macroQuery transforms the query for the provider.
+func macroQuery(m common.Macro, provider, query string) string {
+	tpl, ok := m.Queries[provider]
+	if !ok {
+		tpl = m.Query
+	}
+
+	if tpl == "" {
+		tpl = "%QUERY%"
+	}
+
+	return strings.ReplaceAll(tpl, "%QUERY%", query)
+}
+
+// macroEntries returns entries for the macros matching the query, so macros can be found without knowing
+// their prefix. Activating one suggests the prefix as query.
+func macroEntries(query string, exact bool, list []common.Macro) []*pb.QueryResponse_Item {
+	res := []*pb.QueryResponse_Item{}
+
+	if query == "" {
+		return res
+	}
+
+	for _, v := range list {
+		if v.Name == "" || v.Prefix == "" || len(v.Providers) == 0 {
+			continue
+		}
+
+		score, pos, start := common.FuzzyScore(query, v.Name, exact)
+		if score <= minMacroScore {
+			continue
+		}
+
+		names := []string{}
+
+		for _, p := range v.Providers {
+			names = append(names, macroProviderName(p))
+		}
+
+		icon := v.Icon
+		if icon == "" {
+			icon = "system-search"
+		}
+
+		res = append(res, &pb.QueryResponse_Item{
+			Identifier: v.Name,
+			Text:       v.Name,
+			Subtext:    strings.Join(names, ", "),
+			Icon:       icon,
+			Provider:   macroProvider,
+			Score:      score,
+			Type:       pb.QueryResponse_REGULAR,
+			Actions:    []string{actionStartMacro},
+			Fuzzyinfo: &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			},
+		})
+	}
+
+	return res
+}
+
+// macroProviderName is the pretty name of the provider, menus are named by themselves.
+func macroProviderName(provider string) string {
+	_, menu, isMenu := strings.Cut(provider, ":")
+
+	if !isMenu {
+		return groupName(provider, common.Groups{})
+	}
+
+	if m, ok := common.Menus[menu]; ok && m.NamePretty != "" {
+		return m.NamePretty
+	}
+
+	return menu
+}
+
+// startMacro answers with a query suggestion of the macros prefix, clients put it into their search field.
+func startMacro(format uint8, conn net.Conn, name string) {
+	for _, v := range macros() {
+		if v.Name != name {
+			continue
+		}
+
+		w := newFrameWriter(conn, format)
+		defer w.Close()
+
+		if err := w.message(QuerySuggestion, &pb.SpeechResponse{Text: v.Prefix}); err != nil {
+			slog.Error("macros", "write", err)
+		}
+
+		return
+	}
+
+	slog.Error("macros", "start", "unknown macro", "name", name)
+}
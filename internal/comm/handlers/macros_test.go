@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+func TestMacros(t *testing.T) {
+	list := []common.Macro{
+		{Name: "Projects", Prefix: "p ", Providers: []string{"test", "menus:tmux"}, Queries: map[string]string{"menus:tmux": "dev-%QUERY%"}},
+		{Name: "Project files", Prefix: "pf ", Providers: []string{"test"}, Query: "~/projects/%QUERY%"},
+		{Name: "Broken", Prefix: "b "},
+	}
+
+	tests := []struct {
+		query    string
+		prefix   string
+		rest     string
+		provider string
+		want     string
+	}{
+		{"p foo", "p ", "foo", "menus:tmux", "dev-foo"},
+		{"p foo", "p ", "foo", "test", "foo"},
+		{"pf foo", "pf ", "foo", "test", "~/projects/foo"},
+		{"b foo", "", "b foo", "", ""},
+		{"foo", "", "foo", "", ""},
+	}
+
+	for _, tt := range tests {
+		m, rest, ok := findMacro(tt.query, list)
+
+		if ok != (tt.prefix != "") || m.Prefix != tt.prefix || rest != tt.rest {
+			t.Errorf("findMacro(%q) = %q, %q, %v", tt.query, m.Prefix, rest, ok)
+			continue
+		}
+
+		if ok {
+			if got := macroQuery(m, tt.provider, rest); got != tt.want {
+				t.Errorf("macroQuery(%q, %q) = %q, want %q", tt.query, tt.provider, got, tt.want)
+			}
+		}
+	}
+
+	entries := macroEntries("proj", false, list)
+
+	if len(entries) != 2 || entries[0].Provider != macroProvider || entries[0].Subtext != "Test, tmux" {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/internal/providers"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+func init() {
+	common.SetNotificationActivator(activateNotification)
+}
+
+// activateNotification runs the activation of a clicked notification button.
+func activateNotification(a common.NotificationAction) {
+	name, _, _ := strings.Cut(a.Provider, ":")
+
+	p, ok := providers.Get(name)
+	if !ok {
+		slog.Error("notify", "activate", fmt.Sprintf("unknown provider: %s", a.Provider))
+		return
+	}
+
+	// no client is waiting for the result, so whatever the provider writes is discarded
+	conn, discard := net.Pipe()
+	defer conn.Close()
+
+	go io.Copy(io.Discard, discard)
+
+	hook := common.HookActivation{
+		Provider:   a.Provider,
+		Action:     a.Action,
+		Identifier: a.Identifier,
+		Query:      a.Query,
+		Arguments:  a.Arguments,
+	}
+
+	common.RunHooks(common.HookBefore, hook)
+	p.Activate(false, a.Identifier, a.Action, a.Query, a.Arguments, 0, conn)
+	common.RunHooks(common.HookAfter, hook)
+}
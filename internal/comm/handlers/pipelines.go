@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func pipelines() []common.Pipeline {
+	if c := common.GetElephantConfig(); c != nil {
+		return c.Pipelines
+	}
+
+	return nil
+}
+
+// pipelineMatches reports whether the pipeline applies to items of the provider. Pipelines of a provider
+// apply to all of its menus, f.e. "menus" to "menus:bookmarks".
+func pipelineMatches(p common.Pipeline, provider string) bool {
+	if p.Action == "" || len(p.Steps) == 0 {
+		return false
+	}
+
+	if p.Provider == provider {
+		return true
+	}
+
+	base, _, _ := strings.Cut(provider, ":")
+
+	return p.Provider == base
+}
+
+// findPipeline returns the pipeline of the action of the provider.
+func findPipeline(list []common.Pipeline, provider, action string) (common.Pipeline, bool) {
+	for _, v := range list {
+		if v.Action == action && pipelineMatches(v, provider) {
+			return v, true
+		}
+	}
+
+	return common.Pipeline{}, false
+}
+
+// addPipelineActions adds the actions of the pipelines of its provider to the item.
+func addPipelineActions(item *pb.QueryResponse_Item, list []common.Pipeline) {
+	for _, v := range list {
+		if pipelineMatches(v, item.Provider) && !slices.Contains(item.Actions, v.Action) {
+			item.Actions = append(item.Actions, v.Action)
+		}
+	}
+}
+
+// pipelineActions describes the actions of the pipelines of the provider.
+func pipelineActions(provider string, list []common.Pipeline) []*pb.ActionDescriptor {
+	res := []*pb.ActionDescriptor{}
+
+	for _, v := range list {
+		if pipelineMatches(v, provider) {
+			res = append(res, &pb.ActionDescriptor{Action: v.Action, Label: v.Label, Icon: v.Icon})
+		}
+	}
+
+	return res
+}
+
+// activatePipeline runs the pipeline for every activated item instead of the activation of the provider.
+// The identifier is the value of the item.
+func activatePipeline(cid uint32, p common.Pipeline, req *pb.ActivateRequest) {
+	identifiers := req.Identifiers
+	if len(identifiers) == 0 {
+		identifiers = []string{req.Identifier}
+	}
+
+	for _, v := range identifiers {
+		single := proto.Clone(req).(*pb.ActivateRequest)
+		single.Identifier = v
+
+		hook := hookActivation(cid, single)
+
+		name := p.Label
+		if name == "" {
+			name = actionLabel(p.Action)
+		}
+
+		vars := common.PipelineVars{Value: v, Query: req.Query, Args: req.Arguments, Text: hook.Text}
+
+		common.RunHooks(common.HookBefore, hook)
+
+		go func() {
+			common.RunPipeline(name, p.Steps, vars)
+			common.RunHooks(common.HookAfter, hook)
+		}()
+	}
+}
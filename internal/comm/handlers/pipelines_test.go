@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+func TestPipelines(t *testing.T) {
+	steps := []common.PipelineStep{{Run: "true"}}
+
+	list := []common.Pipeline{
+		{Provider: "files", Action: "checksum", Steps: steps},
+		{Provider: "menus", Action: "share", Steps: steps},
+		{Provider: "menus:bookmarks", Action: "archive", Steps: steps},
+		{Provider: "files", Action: "empty"},
+	}
+
+	tests := []struct {
+		provider string
+		want     []string
+	}{
+		{"files", []string{"open", "checksum"}},
+		{"menus:bookmarks", []string{"open", "share", "archive"}},
+		{"menus:power", []string{"open", "share"}},
+		{"calc", []string{"open"}},
+	}
+
+	for _, tt := range tests {
+		item := &pb.QueryResponse_Item{Provider: tt.provider, Actions: []string{"open"}}
+
+		addPipelineActions(item, list)
+
+		if !slices.Equal(item.Actions, tt.want) {
+			t.Errorf("actions of %s = %v, want %v", tt.provider, item.Actions, tt.want)
+		}
+	}
+
+	if _, ok := findPipeline(list, "menus:power", "archive"); ok {
+		t.Error("found pipeline of another menu")
+	}
+
+	if p, ok := findPipeline(list, "menus:bookmarks", "share"); !ok || p.Provider != "menus" {
+		t.Errorf("findPipeline = %v, %v", p, ok)
+	}
+}
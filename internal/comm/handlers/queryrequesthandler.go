@@ -1,10 +1,7 @@
 package handlers
 
 import (
-	"bytes"
 	"context"
-	"encoding/binary"
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net"
@@ -14,9 +11,11 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
 	"github.com/abenz1267/elephant/v2/internal/providers"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/metrics"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
-	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -27,6 +26,9 @@ const (
 	QueryAsyncItem     = 1
 	ActivationFinished = 2
 	ProviderState      = 3
+	QuerySuggestion    = 4
+	Docs               = 5
+	Handshake          = 6
 )
 
 var (
@@ -40,35 +42,32 @@ var (
 type QueryRequest struct{}
 
 func UpdateItem(format uint8, query string, conn net.Conn, item *pb.QueryResponse_Item) {
-	req := pb.QueryResponse{
-		Query: query,
-		Item:  item,
+	// providers running as executables have no connection to update
+	if conn == nil {
+		return
+	}
+
+	if !common.Supports(conn, protocol.FeatureAsyncItems) {
+		return
 	}
 
-	var b []byte
-	var err error
+	tailorItem(conn, item)
+
+	addPipelineActions(item, pipelines())
+	describeActions(item, providerActions(item.Provider))
 
-	switch format {
-	case 0:
-		b, err = proto.Marshal(&req)
-	case 1:
-		b, err = json.Marshal(&req)
+	req := pb.QueryResponse{
+		Query: query,
+		Item:  item,
 	}
 
+	b, err := appendFrame(nil, QueryAsyncItem, &req, format)
 	if err != nil {
 		slog.Debug("async update", "marshal", err)
 		return
 	}
 
-	var buffer bytes.Buffer
-	buffer.Write([]byte{QueryAsyncItem})
-
-	lengthBuf := make([]byte, 4)
-	binary.BigEndian.PutUint32(lengthBuf, uint32(len(b)))
-	buffer.Write(lengthBuf)
-	buffer.Write(b)
-
-	_, err = conn.Write(buffer.Bytes())
+	_, err = conn.Write(b)
 	if err != nil {
 		slog.Debug("async update", "write", err)
 		return
@@ -83,19 +82,15 @@ func (h *QueryRequest) Handle(format uint8, cid uint32, conn net.Conn, data []by
 
 	req := &pb.QueryRequest{}
 
-	switch format {
-	case 0:
-		if err := proto.Unmarshal(data, req); err != nil {
-			slog.Error("queryhandler", "protobuf", err)
+	if err := protocol.Unmarshal(format, data, req); err != nil {
+		slog.Error("queryhandler", "unmarshal", err)
 
-			return
-		}
-	case 1:
-		if err := json.Unmarshal(data, req); err != nil {
-			slog.Error("queryhandler", "protobuf", err)
+		return
+	}
 
-			return
-		}
+	// after a handshake its features decide
+	if !common.HasHandshake(conn) {
+		common.SetWantsThumbnails(conn, req.Thumbnails)
 	}
 
 	wsprefix := ""
@@ -132,57 +127,137 @@ func (h *QueryRequest) Handle(format uint8, cid uint32, conn net.Conn, data []by
 		}
 	}
 
+	if d := debounce(); d > 0 {
+		select {
+		case <-ctx.Done():
+			metrics.DebouncedQueries.Inc()
+
+			if hasRequestIDs(conn) {
+				writeStatus(QueryDone, conn)
+			}
+
+			return
+		case <-time.After(d):
+		}
+	}
+
 	var mut sync.Mutex
 
 	var wg sync.WaitGroup
-	wg.Add(len(req.Providers))
 
 	entries := []*pb.QueryResponse_Item{}
 
-	for _, v := range req.Providers {
-		query := req.Query
+	// searches of several providers starting with the prefix of a macro search its providers instead,
+	// grouped by provider
+	isMacro := false
 
-		if strings.HasPrefix(v, "menus:") {
-			split := strings.Split(v, ":")
-			v = split[0]
-			query = fmt.Sprintf("%s:%s", split[1], query)
+	if req.Dashboard && req.Query == "" {
+		entries = dashboardEntries(conn, format)
+	} else {
+		// on_query of hooks.lua can rewrite the query or veto it, returning no results
+		rewritten, allowed := common.LuaQueryHook(rewriteQuery(req.Query), req.Providers)
+
+		macro, rest, ok := findMacro(rewritten, macros())
+		isMacro = ok && allowed && len(req.Providers) > 1
+
+		list := req.Providers
+
+		if !allowed {
+			list = nil
+		} else if isMacro {
+			list = macro.Providers
+		} else if len(req.Providers) > 1 {
+			entries = append(entries, macroEntries(rewritten, req.Exactsearch, macros())...)
 		}
 
-		go func(text string, wg *sync.WaitGroup) {
-			defer wg.Done()
-			if p, ok := providers.Providers[v]; ok {
-				res := p.Query(conn, text, len(req.Providers) == 1, req.Exactsearch, format)
+		wg.Add(len(list))
 
-				mut.Lock()
-				entries = append(entries, res...)
-				mut.Unlock()
+		for _, v := range list {
+			query := rewritten
+
+			if isMacro {
+				query = macroQuery(macro, v, rest)
 			}
-		}(query, &wg)
-	}
 
-	wg.Wait()
+			query = rewriteProviderQuery(v, query)
+
+			if strings.HasPrefix(v, "menus:") {
+				split := strings.Split(v, ":")
+				v = split[0]
+				query = fmt.Sprintf("%s:%s", split[1], query)
+			}
+
+			go func(text string, wg *sync.WaitGroup) {
+				defer wg.Done()
+				if p, ok := providers.Get(v); ok {
+					queryStart := time.Now()
+					res := p.Query(conn, text, len(list) == 1, req.Exactsearch, format)
+
+					metrics.Queries.Inc(v)
+					metrics.QueryDuration.Since(queryStart, v)
 
+					mut.Lock()
+					entries = append(entries, res...)
+					mut.Unlock()
+				}
+			}(query, &wg)
+		}
+
+		wg.Wait()
+	}
+
+	// clients with request ids wait for the end of every query, others only for the last one
 	if isCncld() {
+		if hasRequestIDs(conn) {
+			writeStatus(QueryDone, conn)
+		}
+
 		return
 	}
 
 	slices.SortFunc(entries, sortEntries)
 
+	if (req.Grouped || isMacro) && common.Supports(conn, protocol.FeatureGroups) {
+		entries = groupEntries(entries)
+	}
+
 	if len(entries) == 0 {
+		rememberLive(cid, req.Query, nil)
 		writeStatus(QueryNoResults, conn)
 		writeStatus(QueryDone, conn)
 		slog.Info("providers", "p", strings.Join(req.Providers, ","), "results", len(entries), "time", time.Since(start))
 		return
 	}
 
-	if len(entries) > int(req.Maxresults) {
-		entries = entries[:req.Maxresults]
+	if limit := int(max(req.Maxresults, 0)); len(entries) > limit {
+		entries = entries[:limit]
 	}
 
 	hideWebsearch := len(req.Providers) > 1 && len(entries) > MaxGlobalItemsToDisplayWebsearch
 
+	if list := annotatorConfigs(); len(list) > 0 {
+		annotateEntries(entries, list)
+	}
+
+	if list := pipelines(); len(list) > 0 {
+		for _, v := range entries {
+			addPipelineActions(v, list)
+		}
+	}
+
+	describeEntries(entries)
+	rememberItems(cid, entries)
+	rememberLive(cid, req.Query, entries)
+
+	w := newFrameWriter(conn, format)
+	defer w.Close()
+
 	for _, v := range entries {
 		if isCncld() {
+			if hasRequestIDs(conn) {
+				w.status(QueryDone)
+			}
+
 			return
 		}
 
@@ -190,45 +265,54 @@ func (h *QueryRequest) Handle(format uint8, cid uint32, conn net.Conn, data []by
 			continue
 		}
 
-		req := pb.QueryResponse{
+		tailorItem(conn, v)
+
+		res := pb.QueryResponse{
 			Qid:   int32(qqid),
 			Query: req.Query,
 			Item:  v,
 		}
 
-		var b []byte
-		var err error
-
-		switch format {
-		case 0:
-			b, err = proto.Marshal(&req)
-		case 1:
-			b, err = json.Marshal(&req)
+		if err := w.message(QueryItem, &res); err != nil {
+			slog.Error("queryrequesthandler", "write", err, "item", v.Text)
+			return
 		}
+	}
 
-		if err != nil {
-			slog.Error("queryrequesthandler", "marshal", err)
-			continue
-		}
+	w.status(QueryDone)
 
-		var buffer bytes.Buffer
-		buffer.Write([]byte{QueryItem})
+	slog.Info("providers", "p", strings.Join(req.Providers, ","), "results", len(entries), "time", time.Since(start))
+}
 
-		lengthBuf := make([]byte, 4)
-		binary.BigEndian.PutUint32(lengthBuf, uint32(len(b)))
-		buffer.Write(lengthBuf)
-		buffer.Write(b)
+// debounce returns how long queries wait for a superseding query before running.
+func debounce() time.Duration {
+	if c := common.GetElephantConfig(); c != nil {
+		return time.Duration(c.Limits.Debounce) * time.Millisecond
+	}
 
-		_, err = conn.Write(buffer.Bytes())
-		if err != nil {
-			slog.Error("queryrequesthandler", "write", err, "item", v.Text)
-			return
-		}
+	return 0
+}
+
+// hasRequestIDs reports whether the client declared request ids in its handshake.
+func hasRequestIDs(conn net.Conn) bool {
+	return common.HasHandshake(conn) && common.Supports(conn, protocol.FeatureRequestIDs)
+}
+
+// tailorItem removes what the client can't handle: thumbnails it didn't ask for, and groups and previews
+// it didn't declare in its handshake.
+func tailorItem(conn net.Conn, item *pb.QueryResponse_Item) {
+	if !common.WantsThumbnails(conn) {
+		item.Thumbnail = nil
 	}
 
-	writeStatus(QueryDone, conn)
+	if !common.Supports(conn, protocol.FeatureGroups) {
+		item.Group = ""
+	}
 
-	slog.Info("providers", "p", strings.Join(req.Providers, ","), "results", len(entries), "time", time.Since(start))
+	if item.Preview != "" && (!common.Supports(conn, protocol.FeaturePreviews) || !common.SupportsPreview(conn, item.PreviewType)) {
+		item.Preview = ""
+		item.PreviewType = ""
+	}
 }
 
 func sortEntries(a *pb.QueryResponse_Item, b *pb.QueryResponse_Item) int {
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/gob"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/history"
+)
+
+// middleware rewrites the query before it's passed to providers. Middlewares check whether they're
+// enabled themselves and return the query unchanged otherwise.
+type middleware func(query string, cfg common.Rewrite) string
+
+var (
+	// queryMiddlewares run once per query, in order.
+	queryMiddlewares = []middleware{trimQuery, expandAbbreviations, correctSpelling}
+	// providerMiddlewares run for the query of a single provider.
+	providerMiddlewares = map[string][]middleware{
+		"files": {expandHome},
+	}
+)
+
+func rewriteConfig() common.Rewrite {
+	if c := common.GetElephantConfig(); c != nil {
+		return c.Rewrite
+	}
+
+	return common.Rewrite{}
+}
+
+func rewriteQuery(query string) string {
+	cfg := rewriteConfig()
+
+	for _, fn := range queryMiddlewares {
+		query = fn(query, cfg)
+	}
+
+	return query
+}
+
+func rewriteProviderQuery(provider, query string) string {
+	cfg := rewriteConfig()
+
+	for _, fn := range providerMiddlewares[provider] {
+		query = fn(query, cfg)
+	}
+
+	return query
+}
+
+func trimQuery(query string, cfg common.Rewrite) string {
+	if !cfg.Trim {
+		return query
+	}
+
+	return strings.TrimSpace(query)
+}
+
+func expandAbbreviations(query string, cfg common.Rewrite) string {
+	if len(cfg.Abbreviations) == 0 {
+		return query
+	}
+
+	words := strings.Split(query, " ")
+
+	for i, v := range words {
+		if e, ok := cfg.Abbreviations[v]; ok {
+			words[i] = e
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+func expandHome(query string, cfg common.Rewrite) string {
+	if !cfg.Home {
+		return query
+	}
+
+	if query != "~" && !strings.HasPrefix(query, "~/") {
+		return query
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return query
+	}
+
+	return home + strings.TrimPrefix(query, "~")
+}
+
+var (
+	vocabulary   map[string]int
+	vocabularyAt time.Time
+	vocabularyMu sync.Mutex
+)
+
+// words shorter than this are never corrected
+const minSpellingLength = 4
+
+// loadVocabulary collects the words of all queries saved in the history of providers, counted by usage.
+// It's reloaded every few minutes, as the history grows.
+func loadVocabulary() map[string]int {
+	vocabularyMu.Lock()
+	defer vocabularyMu.Unlock()
+
+	if vocabulary != nil && time.Since(vocabularyAt) < 5*time.Minute {
+		return vocabulary
+	}
+
+	vocabulary = make(map[string]int)
+	vocabularyAt = time.Now()
+
+	files, _ := filepath.Glob(common.CacheFile("*_history.gob"))
+
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			slog.Error("rewrite", "vocabulary", err)
+			continue
+		}
+
+		var h history.History
+
+		if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&h); err != nil {
+			slog.Error("rewrite", "vocabulary", err, "file", file)
+			continue
+		}
+
+		for query, items := range h.Data {
+			amount := 0
+
+			for _, v := range items {
+				amount += v.Amount
+			}
+
+			for w := range strings.FieldsSeq(strings.ToLower(query)) {
+				if len(w) >= minSpellingLength {
+					vocabulary[w] += amount
+				}
+			}
+		}
+	}
+
+	return vocabulary
+}
+
+// correctSpelling replaces words that aren't in the vocabulary with the most used word of it that's
+// one typo away, two for longer words. Words that are the beginning of a known word are kept, as they're
+// likely still being typed.
+func correctSpelling(query string, cfg common.Rewrite) string {
+	if !cfg.Spelling {
+		return query
+	}
+
+	vocab := loadVocabulary()
+
+	if len(vocab) == 0 {
+		return query
+	}
+
+	words := strings.Split(query, " ")
+
+	for i, v := range words {
+		w := strings.ToLower(v)
+
+		if len(w) < minSpellingLength {
+			continue
+		}
+
+		if _, ok := vocab[w]; ok {
+			continue
+		}
+
+		maxDist := 1
+		if len(w) >= 8 {
+			maxDist = 2
+		}
+
+		best := ""
+		bestCount := 0
+		prefix := false
+
+		for k, count := range vocab {
+			if strings.HasPrefix(k, w) {
+				prefix = true
+				break
+			}
+
+			if count > bestCount && editDistance(w, k, maxDist) <= maxDist {
+				best = k
+				bestCount = count
+			}
+		}
+
+		if !prefix && best != "" {
+			words[i] = best
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// editDistance returns the optimal string alignment distance of a and b, or max+1 if it exceeds max.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+
+			rowMin = min(rowMin, cur[j])
+		}
+
+		if rowMin > limit {
+			return limit + 1
+		}
+
+		prev2, prev, cur = prev, cur, prev2
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"al.essio.dev/pkg/shellescape"
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// SpeechRequest records from the microphone until a request with stop is sent or the max duration is
+// reached, transcribes the recording and sends the text to the client as query suggestion. There is
+// only one recording at a time.
+type SpeechRequest struct{}
+
+var (
+	recordingMu sync.Mutex
+	recording   *exec.Cmd
+)
+
+func (a *SpeechRequest) Handle(format uint8, cid uint32, conn net.Conn, data []byte) {
+	req := &pb.SpeechRequest{}
+
+	if err := protocol.Unmarshal(format, data, req); err != nil {
+		slog.Error("speechrequesthandler", "unmarshal", err)
+
+		return
+	}
+
+	if req.Stop {
+		stopRecording()
+		writeStatus(StatusDone, conn)
+		return
+	}
+
+	text, err := speechToText(req.Language)
+	if err != nil {
+		slog.Error("speechrequesthandler", "speech", err)
+		writeStatus(StatusDone, conn)
+		return
+	}
+
+	res := &pb.SpeechResponse{
+		Text: text,
+	}
+
+	var b []byte
+
+	switch format {
+	case 0:
+		b, err = proto.Marshal(res)
+	case 1:
+		b, err = json.Marshal(res)
+	}
+
+	if err != nil {
+		slog.Error("speechrequesthandler", "marshal", err)
+		return
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write([]byte{QuerySuggestion})
+
+	lengthBuf := make([]byte, 4)
+	binary.BigEndian.PutUint32(lengthBuf, uint32(len(b)))
+	buffer.Write(lengthBuf)
+	buffer.Write(b)
+
+	_, err = conn.Write(buffer.Bytes())
+	if err != nil {
+		slog.Error("speechrequesthandler", "write", err)
+		return
+	}
+
+	writeStatus(StatusDone, conn)
+}
+
+func speechConfig() common.Speech {
+	if c := common.GetElephantConfig(); c != nil {
+		return c.Speech
+	}
+
+	return common.Speech{}
+}
+
+// speechToText records and transcribes. Recording blocks until it's stopped.
+func speechToText(language string) (string, error) {
+	cfg := speechConfig()
+
+	if cfg.Record == "" || cfg.Transcribe == "" {
+		return "", errors.New("speech isn't configured")
+	}
+
+	if language == "" {
+		language = cfg.Language
+	}
+
+	f, err := os.CreateTemp("", "elephant-speech-*.wav")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	file := shellescape.Quote(f.Name())
+
+	cmd := common.HostShell(strings.ReplaceAll(cfg.Record, "%FILE%", file))
+	// interrupting the process group reaches the recorder, not just the shell
+	common.NewProcessGroup(cmd)
+
+	recordingMu.Lock()
+	if recording != nil {
+		recordingMu.Unlock()
+		return "", errors.New("already recording")
+	}
+
+	if err := cmd.Start(); err != nil {
+		recordingMu.Unlock()
+		return "", fmt.Errorf("record: %w", err)
+	}
+
+	recording = cmd
+	recordingMu.Unlock()
+
+	if cfg.MaxDuration > 0 {
+		timer := time.AfterFunc(time.Duration(cfg.MaxDuration)*time.Second, stopRecording)
+		defer timer.Stop()
+	}
+
+	// recorders exit with an error when interrupted, so only the recording itself is checked
+	cmd.Wait()
+
+	recordingMu.Lock()
+	recording = nil
+	recordingMu.Unlock()
+
+	if info, err := os.Stat(f.Name()); err != nil || info.Size() == 0 {
+		return "", errors.New("nothing recorded")
+	}
+
+	transcribe := strings.ReplaceAll(cfg.Transcribe, "%FILE%", file)
+	transcribe = strings.ReplaceAll(transcribe, "%LANGUAGE%", shellescape.Quote(language))
+
+	out, err := common.HostShell(transcribe).Output()
+	if err != nil {
+		return "", fmt.Errorf("transcribe: %w", err)
+	}
+
+	return strings.Join(strings.Fields(string(out)), " "), nil
+}
+
+func stopRecording() {
+	recordingMu.Lock()
+	defer recordingMu.Unlock()
+
+	if recording == nil || recording.Process == nil {
+		return
+	}
+
+	if err := common.Interrupt(recording); err != nil {
+		slog.Error("speechrequesthandler", "stop", err)
+	}
+}
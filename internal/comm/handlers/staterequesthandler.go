@@ -8,6 +8,7 @@ import (
 	"net"
 	"strings"
 
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
 	"github.com/abenz1267/elephant/v2/internal/providers"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 	"google.golang.org/protobuf/proto"
@@ -18,19 +19,10 @@ type StateRequest struct{}
 func (a *StateRequest) Handle(format uint8, cid uint32, conn net.Conn, data []byte) {
 	req := &pb.ProviderStateRequest{}
 
-	switch format {
-	case 0:
-		if err := proto.Unmarshal(data, req); err != nil {
-			slog.Error("staterequesthandler", "protobuf", err)
-
-			return
-		}
-	case 1:
-		if err := json.Unmarshal(data, req); err != nil {
-			slog.Error("staterequesthandler", "protobuf", err)
+	if err := protocol.Unmarshal(format, data, req); err != nil {
+		slog.Error("staterequesthandler", "unmarshal", err)
 
-			return
-		}
+		return
 	}
 
 	p := req.Provider
@@ -39,7 +31,14 @@ func (a *StateRequest) Handle(format uint8, cid uint32, conn net.Conn, data []by
 		p = "menus"
 	}
 
-	res := providers.Providers[p].State(req.Provider)
+	provider, ok := providers.Get(p)
+	if !ok {
+		slog.Error("staterequesthandler", "provider", "unknown provider", "provider", req.Provider)
+		writeStatus(StatusDone, conn)
+		return
+	}
+
+	res := provider.State(req.Provider)
 	res.Provider = req.Provider
 
 	var b []byte
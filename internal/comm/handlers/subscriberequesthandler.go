@@ -13,6 +13,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
 	"github.com/abenz1267/elephant/v2/internal/providers"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 	"google.golang.org/protobuf/proto"
@@ -23,19 +24,10 @@ type SubscribeRequest struct{}
 func (a *SubscribeRequest) Handle(format uint8, cid uint32, conn net.Conn, data []byte) {
 	req := &pb.SubscribeRequest{}
 
-	switch format {
-	case 0:
-		if err := proto.Unmarshal(data, req); err != nil {
-			slog.Error("activationrequesthandler", "protobuf", err)
-
-			return
-		}
-	case 1:
-		if err := json.Unmarshal(data, req); err != nil {
-			slog.Error("activationrequesthandler", "protobuf", err)
+	if err := protocol.Unmarshal(format, data, req); err != nil {
+		slog.Error("subscriberequesthandler", "unmarshal", err)
 
-			return
-		}
+		return
 	}
 
 	subscribe(format, int(req.Interval), req.Provider, req.Query, conn)
@@ -75,16 +67,13 @@ func init() {
 		for p := range ProviderUpdated {
 			value := p
 
-			if strings.HasPrefix(p, "menus:") {
-				p = "menus"
-			}
-
-			if strings.HasPrefix(p, "bluetooth:") {
-				p = "bluetooth"
-			}
+			// providers send "<provider>:<detail>", f.e. "menus:power", subscribers get the detail as value
+			p, _, _ = strings.Cut(p, ":")
 
 			toDelete := []uint32{}
 
+			mut.Lock()
+
 			for k, v := range subs {
 				if v.provider == p && v.interval == 0 && v.query == "" {
 					if ok := updated(v.format, v.conn, value); !ok {
@@ -96,6 +85,8 @@ func init() {
 			for _, v := range toDelete {
 				delete(subs, v)
 			}
+
+			mut.Unlock()
 		}
 	}()
 }
@@ -125,12 +116,23 @@ func subscribe(format uint8, interval int, provider, query string, conn net.Conn
 }
 
 func watch(format uint8, s *sub, conn net.Conn) {
-	p := providers.Providers[s.provider]
+	p, ok := providers.Get(s.provider)
+	if !ok {
+		slog.Error("subscription", "provider", "unknown provider", "provider", s.provider)
+
+		unsubscribe(s.sid)
+
+		return
+	}
 
 	for {
 		time.Sleep(time.Duration(s.interval) * time.Millisecond)
 
-		if _, ok := subs[s.sid]; !ok {
+		mut.Lock()
+		_, ok := subs[s.sid]
+		mut.Unlock()
+
+		if !ok {
 			return
 		}
 
@@ -144,7 +146,7 @@ func watch(format uint8, s *sub, conn net.Conn) {
 				s.results = res
 
 				if ok := updated(format, conn, ""); !ok {
-					delete(subs, s.sid)
+					unsubscribe(s.sid)
 				}
 
 				continue
@@ -156,7 +158,7 @@ func watch(format uint8, s *sub, conn net.Conn) {
 					s.results = res
 
 					if ok := updated(format, conn, ""); !ok {
-						delete(subs, s.sid)
+						unsubscribe(s.sid)
 					}
 
 					break
@@ -168,19 +170,27 @@ func watch(format uint8, s *sub, conn net.Conn) {
 	}
 }
 
+func unsubscribe(sid uint32) {
+	mut.Lock()
+	delete(subs, sid)
+	mut.Unlock()
+}
+
 func updated(format uint8, conn net.Conn, value string) bool {
-	resp := pb.SubscribeResponse{
+	return writeSubscription(format, conn, &pb.SubscribeResponse{
 		Value: value,
-	}
+	})
+}
 
+func writeSubscription(format uint8, conn net.Conn, resp *pb.SubscribeResponse) bool {
 	var b []byte
 	var err error
 
 	switch format {
 	case 0:
-		b, err = proto.Marshal(&resp)
+		b, err = proto.Marshal(resp)
 	case 1:
-		b, err = json.Marshal(&resp)
+		b, err = json.Marshal(resp)
 	}
 
 	if err != nil {
@@ -197,7 +207,7 @@ func updated(format uint8, conn net.Conn, value string) bool {
 
 	_, err = conn.Write(buffer.Bytes())
 	if err != nil {
-		slog.Debug("subscriptionrequesthandler", "write", err, "value", value)
+		slog.Debug("subscriptionrequesthandler", "write", err, "value", resp.Value)
 		return false
 	}
 
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// TestSubscribeDetail passes updates of "<provider>:<detail>" to subscribers of the provider.
+func TestSubscribeDetail(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	subscribe(protocol.JSON, 0, "libvirt", "", server)
+
+	defer func() {
+		mut.Lock()
+		for k, v := range subs {
+			if v.conn == server {
+				delete(subs, k)
+			}
+		}
+		mut.Unlock()
+	}()
+
+	go func() {
+		ProviderUpdated <- "libvirt:vm1"
+	}()
+
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(client, header); err != nil {
+		t.Fatalf("no update: %v", err)
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint32(header[1:5]))
+	if _, err := io.ReadFull(client, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	res := &pb.SubscribeResponse{}
+	if err := json.Unmarshal(payload, res); err != nil {
+		t.Fatal(err)
+	}
+
+	if header[0] != SubscriptionDataChanged || res.Value != "libvirt:vm1" {
+		t.Errorf("unexpected update %d %q", header[0], res.Value)
+	}
+}
@@ -0,0 +1,43 @@
+package protocol
+
+import "slices"
+
+// Version is the protocol version of the daemon. It changes only when existing frames change
+// incompatibly, new request types and fields are announced as features instead.
+const Version = 1
+
+// Features clients declare in the handshake. Clients without a handshake get everything, as before.
+const (
+	// FeatureAsyncItems are items updated after the response, sent as QueryAsyncItem frames.
+	FeatureAsyncItems = "async_items"
+	// FeatureGroups are the group of items and grouped sorting.
+	FeatureGroups = "groups"
+	// FeatureThumbnails are icons and previews as png bytes in the thumbnail field of items.
+	FeatureThumbnails = "thumbnails"
+	// FeaturePreviews are previews of the declared preview types.
+	FeaturePreviews = "previews"
+	// FeatureRequestIDs tags all following frames with a request id, so responses, async items and
+	// subscription updates can be matched to their request.
+	FeatureRequestIDs = "request_ids"
+)
+
+// Features are all features the daemon supports.
+var Features = []string{FeatureAsyncItems, FeatureGroups, FeatureThumbnails, FeaturePreviews, FeatureRequestIDs}
+
+// Compatible reports whether clients of the protocol version can talk to the daemon.
+func Compatible(version uint32) bool {
+	return version == Version
+}
+
+// Supported returns the declared features known to the daemon, unknown ones are dropped.
+func Supported(features []string) []string {
+	res := []string{}
+
+	for _, v := range features {
+		if slices.Contains(Features, v) && !slices.Contains(res, v) {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,139 @@
+// Package protocol reads and decodes the requests of the socket protocol. Requests are framed as type,
+// format, big endian uint32 length and payload. Clients that declared FeatureRequestIDs put a uint32
+// request id in front of the length, of requests and responses.
+package protocol
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	Protobuf = 0
+	JSON     = 1
+
+	// MaxPayload limits the payload of requests, so a broken length can't allocate gigabytes.
+	MaxPayload = 16 << 20
+)
+
+var (
+	ErrPayloadTooLarge = errors.New("payload too large")
+	ErrUnknownFormat   = errors.New("unknown format")
+)
+
+type Request struct {
+	Type uint8
+	// Format is the raw format byte, the upper bits request compression of responses.
+	Format uint8
+	// ID is set by clients that declared FeatureRequestIDs, their responses carry it as well.
+	ID      uint32
+	Payload []byte
+}
+
+// ReadRequest reads the next request. It returns io.EOF only if the connection ended between requests.
+// After any other error the connection is out of sync and has to be closed.
+func ReadRequest(r io.Reader) (Request, error) {
+	return readRequest(r, false)
+}
+
+// ReadTaggedRequest reads the next request of a client that declared FeatureRequestIDs. Its frames
+// have the request id between format and length.
+func ReadTaggedRequest(r io.Reader) (Request, error) {
+	return readRequest(r, true)
+}
+
+func readRequest(r io.Reader, tagged bool) (Request, error) {
+	size := 6
+	if tagged {
+		size += 4
+	}
+
+	header := make([]byte, size)
+
+	if _, err := io.ReadFull(r, header); err != nil {
+		return Request{}, err
+	}
+
+	req := Request{Type: header[0], Format: header[1]}
+
+	if tagged {
+		req.ID = binary.BigEndian.Uint32(header[2:6])
+	}
+
+	length := binary.BigEndian.Uint32(header[size-4:])
+	if length > MaxPayload {
+		return Request{}, fmt.Errorf("%w: %d bytes", ErrPayloadTooLarge, length)
+	}
+
+	req.Payload = make([]byte, length)
+
+	if _, err := io.ReadFull(r, req.Payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return Request{}, err
+	}
+
+	return req, nil
+}
+
+// AppendRequest appends the frame of a request to b.
+func AppendRequest(b []byte, req Request) []byte {
+	b = append(b, req.Type, req.Format)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(req.Payload)))
+
+	return append(b, req.Payload...)
+}
+
+// AppendTaggedRequest appends the frame of a request with its id to b.
+func AppendTaggedRequest(b []byte, req Request) []byte {
+	b = append(b, req.Type, req.Format)
+	b = binary.BigEndian.AppendUint32(b, req.ID)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(req.Payload)))
+
+	return append(b, req.Payload...)
+}
+
+// TagResponses puts the request id between type and length of the response frames in b. It returns
+// false if b doesn't consist of whole frames.
+func TagResponses(b []byte, id uint32) ([]byte, bool) {
+	out := make([]byte, 0, len(b)+len(b)/8+4)
+
+	for len(b) > 0 {
+		if len(b) < 5 {
+			return nil, false
+		}
+
+		length := int(binary.BigEndian.Uint32(b[1:5]))
+
+		if len(b) < 5+length {
+			return nil, false
+		}
+
+		out = append(out, b[0])
+		out = binary.BigEndian.AppendUint32(out, id)
+		out = append(out, b[1:5+length]...)
+
+		b = b[5+length:]
+	}
+
+	return out, true
+}
+
+// Unmarshal decodes the payload of a request in the given format, without compression bits.
+func Unmarshal(format uint8, data []byte, m proto.Message) error {
+	switch format {
+	case Protobuf:
+		return proto.Unmarshal(data, m)
+	case JSON:
+		return json.Unmarshal(data, m)
+	default:
+		return fmt.Errorf("%w: %d", ErrUnknownFormat, format)
+	}
+}
@@ -0,0 +1,225 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"testing/quick"
+
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// requests returns a message of every request type, by type byte.
+func requests() map[uint8]func() proto.Message {
+	return map[uint8]func() proto.Message{
+		0: func() proto.Message { return &pb.QueryRequest{} },
+		1: func() proto.Message { return &pb.ActivateRequest{} },
+		2: func() proto.Message { return &pb.SubscribeRequest{} },
+		3: func() proto.Message { return &pb.MenuRequest{} },
+		4: func() proto.Message { return &pb.ProviderStateRequest{} },
+		5: func() proto.Message { return &pb.SpeechRequest{} },
+		6: func() proto.Message { return &pb.DocsRequest{} },
+		7: func() proto.Message { return &pb.HandshakeRequest{} },
+	}
+}
+
+func seedRequests() [][]byte {
+	query, _ := proto.Marshal(&pb.QueryRequest{Providers: []string{"files"}, Query: "doc", Maxresults: 10})
+	activate, _ := proto.Marshal(&pb.ActivateRequest{Provider: "files", Identifier: "1", Action: "open"})
+
+	return [][]byte{
+		AppendRequest(nil, Request{Type: 0, Format: Protobuf, Payload: query}),
+		AppendRequest(nil, Request{Type: 1, Format: Protobuf, Payload: activate}),
+		AppendRequest(nil, Request{Type: 0, Format: JSON, Payload: []byte(`{"providers":["files"],"query":"doc","maxresults":10}`)}),
+		AppendRequest(nil, Request{Type: 6, Format: JSON | 2<<4, Payload: []byte(`{}`)}),
+		AppendRequest(nil, Request{Type: 255, Format: 15}),
+		{0, 1, 0xff, 0xff, 0xff, 0xff},
+		{0, 1, 0, 0, 0, 10, '{'},
+		{0},
+		{},
+	}
+}
+
+func FuzzReadRequest(f *testing.F) {
+	for _, v := range seedRequests() {
+		f.Add(v)
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		r := bytes.NewReader(b)
+
+		for {
+			consumed := len(b) - r.Len()
+
+			req, err := ReadRequest(r)
+			if err != nil {
+				if err == io.EOF && consumed != len(b) {
+					t.Fatalf("io.EOF within a frame at %d of %d bytes", consumed, len(b))
+				}
+
+				return
+			}
+
+			if len(req.Payload) > MaxPayload {
+				t.Fatalf("payload of %d bytes exceeds the max", len(req.Payload))
+			}
+
+			frame := AppendRequest(nil, req)
+
+			if !bytes.Equal(frame, b[consumed:len(b)-r.Len()]) {
+				t.Fatalf("frame %x doesn't match the read bytes %x", frame, b[consumed:len(b)-r.Len()])
+			}
+		}
+	})
+}
+
+func FuzzReadTaggedRequest(f *testing.F) {
+	for _, v := range seedRequests() {
+		f.Add(v)
+	}
+
+	f.Add(AppendTaggedRequest(nil, Request{Type: 7, Format: JSON, ID: 42, Payload: []byte(`{}`)}))
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		r := bytes.NewReader(b)
+
+		for {
+			consumed := len(b) - r.Len()
+
+			req, err := ReadTaggedRequest(r)
+			if err != nil {
+				if err == io.EOF && consumed != len(b) {
+					t.Fatalf("io.EOF within a frame at %d of %d bytes", consumed, len(b))
+				}
+
+				return
+			}
+
+			if frame := AppendTaggedRequest(nil, req); !bytes.Equal(frame, b[consumed:len(b)-r.Len()]) {
+				t.Fatalf("frame %x doesn't match the read bytes %x", frame, b[consumed:len(b)-r.Len()])
+			}
+		}
+	})
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	for _, v := range seedRequests() {
+		if req, err := ReadRequest(bytes.NewReader(v)); err == nil {
+			f.Add(req.Type, req.Format&0x0f, req.Payload)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, typ uint8, format uint8, data []byte) {
+		newMessage, ok := requests()[typ]
+		if !ok {
+			return
+		}
+
+		m := newMessage()
+
+		err := Unmarshal(format, data, m)
+
+		if format > JSON {
+			if !errors.Is(err, ErrUnknownFormat) {
+				t.Fatalf("format %d: expected ErrUnknownFormat, got %v", format, err)
+			}
+
+			return
+		}
+
+		if err != nil || format != Protobuf {
+			return
+		}
+
+		// decoded messages survive a round trip
+		b, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		again := newMessage()
+
+		if err := Unmarshal(Protobuf, b, again); err != nil {
+			t.Fatal(err)
+		}
+
+		if !proto.Equal(m, again) {
+			t.Fatalf("round trip changed %v to %v", m, again)
+		}
+	})
+}
+
+func TestRequestRoundTrip(t *testing.T) {
+	roundTrip := func(typ, format uint8, payload []byte) bool {
+		req, err := ReadRequest(bytes.NewReader(AppendRequest(nil, Request{Type: typ, Format: format, Payload: payload})))
+		if err != nil {
+			return false
+		}
+
+		return req.Type == typ && req.Format == format && bytes.Equal(req.Payload, payload)
+	}
+
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTaggedRequestRoundTrip(t *testing.T) {
+	roundTrip := func(typ, format uint8, id uint32, payload []byte) bool {
+		req, err := ReadTaggedRequest(bytes.NewReader(AppendTaggedRequest(nil, Request{Type: typ, Format: format, ID: id, Payload: payload})))
+		if err != nil {
+			return false
+		}
+
+		return req.Type == typ && req.Format == format && req.ID == id && bytes.Equal(req.Payload, payload)
+	}
+
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTagResponses(t *testing.T) {
+	frames := []byte{255, 0, 0, 0, 0, 0, 0, 0, 0, 2, '{', '}'}
+
+	got, ok := TagResponses(frames, 7)
+	if !ok {
+		t.Fatal("whole frames rejected")
+	}
+
+	want := []byte{255, 0, 0, 0, 7, 0, 0, 0, 0, 0, 0, 0, 0, 7, 0, 0, 0, 2, '{', '}'}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("expected %x, got %x", want, got)
+	}
+
+	if _, ok := TagResponses(frames[:len(frames)-1], 7); ok {
+		t.Fatal("truncated frame accepted")
+	}
+}
+
+func TestReadRequestErrors(t *testing.T) {
+	tooLarge := binary.BigEndian.AppendUint32([]byte{0, 0}, MaxPayload+1)
+
+	tests := []struct {
+		name  string
+		input []byte
+		err   error
+	}{
+		{"empty", []byte{}, io.EOF},
+		{"truncated header", []byte{0, 1, 0}, io.ErrUnexpectedEOF},
+		{"truncated payload", []byte{0, 1, 0, 0, 0, 4, 'a'}, io.ErrUnexpectedEOF},
+		{"missing payload", []byte{0, 1, 0, 0, 0, 4}, io.ErrUnexpectedEOF},
+		{"too large", tooLarge, ErrPayloadTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadRequest(bytes.NewReader(tt.input)); !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
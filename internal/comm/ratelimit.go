@@ -0,0 +1,58 @@
+package comm
+
+import (
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/metrics"
+)
+
+// limiter is a token bucket limiting the requests of a connection. Requests above the rate aren't
+// dropped, reading them is delayed, so a client flooding the socket only slows down itself.
+type limiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newLimiter returns nil if rate limiting is disabled.
+func newLimiter() *limiter {
+	c := common.GetElephantConfig()
+	if c == nil || c.Limits.Requests <= 0 {
+		return nil
+	}
+
+	burst := float64(max(c.Limits.Burst, 1))
+
+	return &limiter{
+		rate:   float64(c.Limits.Requests),
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until the next request may be handled.
+func (l *limiter) wait() {
+	if l == nil {
+		return
+	}
+
+	now := time.Now()
+
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return
+	}
+
+	metrics.ThrottledRequests.Inc()
+
+	time.Sleep(time.Duration((1 - l.tokens) / l.rate * float64(time.Second)))
+
+	l.tokens = 0
+	l.last = time.Now()
+}
@@ -0,0 +1,28 @@
+package comm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := &limiter{rate: 100, burst: 5, tokens: 5, last: time.Now()}
+
+	start := time.Now()
+
+	for range 5 {
+		l.wait()
+	}
+
+	if d := time.Since(start); d > 5*time.Millisecond {
+		t.Fatalf("burst was delayed by %v", d)
+	}
+
+	for range 10 {
+		l.wait()
+	}
+
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Fatalf("10 requests above the burst took %v, expected about 100ms", d)
+	}
+}
@@ -0,0 +1,121 @@
+package comm
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/protocol"
+)
+
+// RecordDir is the directory client sessions are recorded to, for replaying them with "elephant replay".
+// Recording is disabled if it's empty.
+var RecordDir string
+
+const (
+	TraceIn  = "in"
+	TraceOut = "out"
+)
+
+// TraceFrame is a recorded frame, one json object per line. Requests are recorded as read, responses
+// before they're compressed.
+type TraceFrame struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	Type      uint8     `json:"type"`
+	// Format is the format byte of requests, including the requested compression.
+	Format uint8 `json:"format,omitempty"`
+	// ID is the request id, for clients that declared request ids in the handshake.
+	ID      uint32 `json:"id,omitempty"`
+	Payload []byte `json:"payload"`
+}
+
+// recorder writes the frames of a session to a trace file.
+type recorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+func newRecorder(cid uint32) *recorder {
+	if RecordDir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(RecordDir, 0o755); err != nil {
+		slog.Error("comm", "record", err)
+		return nil
+	}
+
+	path := filepath.Join(RecordDir, fmt.Sprintf("%s-%d.jsonl", time.Now().Format("20060102-150405"), cid))
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		slog.Error("comm", "record", err)
+		return nil
+	}
+
+	slog.Info("comm", "record", path)
+
+	return &recorder{f: f, enc: json.NewEncoder(f)}
+}
+
+func (r *recorder) request(req protocol.Request) {
+	if r == nil {
+		return
+	}
+
+	r.write(TraceFrame{Time: time.Now(), Direction: TraceIn, Type: req.Type, Format: req.Format, ID: req.ID, Payload: req.Payload})
+}
+
+// response records the frames of a write. Handlers always write whole frames.
+func (r *recorder) response(b []byte, id uint32) {
+	if r == nil {
+		return
+	}
+
+	now := time.Now()
+
+	for len(b) >= 5 {
+		length := int(binary.BigEndian.Uint32(b[1:5]))
+
+		if len(b) < 5+length {
+			break
+		}
+
+		r.write(TraceFrame{Time: now, Direction: TraceOut, Type: b[0], ID: id, Payload: b[5 : 5+length]})
+
+		b = b[5+length:]
+	}
+}
+
+func (r *recorder) write(f TraceFrame) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// async responses might still come in after the client disconnected
+	if r.f == nil {
+		return
+	}
+
+	if err := r.enc.Encode(f); err != nil {
+		slog.Error("comm", "record", err)
+	}
+}
+
+func (r *recorder) Close() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.f.Close()
+	r.f = nil
+}
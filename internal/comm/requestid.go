@@ -0,0 +1,19 @@
+package comm
+
+import "net"
+
+// requestConn is the connection handlers of a request get, once the client declared request ids. The
+// frames written to it, including async items and subscription updates later on, carry the request id.
+type requestConn struct {
+	*compressConn
+	id uint32
+}
+
+func (c *requestConn) Write(b []byte) (int, error) {
+	return c.write(b, c.id, true)
+}
+
+// NetConn returns the connection of the client, which its capabilities are stored for.
+func (c *requestConn) NetConn() net.Conn {
+	return c.compressConn
+}
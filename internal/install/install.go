@@ -1,8 +1,7 @@
-// Package install provides the ability to install menus from elephant-community
+// Package install provides the ability to install menus from elephant-community and additional registries
 package install
 
 import (
-	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
@@ -10,12 +9,15 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/abenz1267/elephant/v2/pkg/common"
 	"github.com/adrg/xdg"
 )
 
-var repo = filepath.Join(os.TempDir(), "elephant-community")
+func installDir() string {
+	return filepath.Join(xdg.DataHome, "elephant", "install")
+}
 
 func Readme(menu string) {
 	if menu == "" {
@@ -26,8 +28,8 @@ func Readme(menu string) {
 		return
 	}
 
-	dest := filepath.Join(xdg.DataHome, "elephant", "install")
-	installed := filepath.Join(dest, menu, "README.md")
+	dest := installDir()
+	installed := filepath.Join(dest, filepath.Base(menu), "README.md")
 
 	if common.FileExists(installed) {
 		b, err := os.ReadFile(installed)
@@ -42,26 +44,30 @@ func Readme(menu string) {
 		return
 	}
 
-	cloneOrPull()
-
-	available := filepath.Join(repo, menu, "README.md")
+	p, err := resolve(menu)
+	if err != nil {
+		slog.Error("readme", "resolve", err)
+		return
+	}
 
-	if common.FileExists(available) {
-		b, err := os.ReadFile(available)
-		if err != nil {
-			slog.Error("readme", "readfile", err)
-			return
-		}
+	if err := sync(p); err != nil {
+		slog.Error("readme", "sync", err)
+		return
+	}
 
-		fmt.Println("Available:")
-		fmt.Println("----------")
-		fmt.Println(string(b))
+	b, err := git(p.dir(), "show", p.tree("HEAD")+"/README.md")
+	if err != nil {
+		slog.Error("readme", "not found", menu)
 		return
 	}
+
+	fmt.Println("Available:")
+	fmt.Println("----------")
+	fmt.Println(b)
 }
 
 func Remove(menus []string) {
-	dest := filepath.Join(xdg.DataHome, "elephant", "install")
+	dest := installDir()
 
 	if len(menus) == 0 {
 		fmt.Println("installed:")
@@ -83,6 +89,8 @@ func Remove(menus []string) {
 		return
 	}
 
+	lock := readLock()
+
 	for _, v := range menus {
 		path := filepath.Join(dest, v)
 
@@ -94,36 +102,37 @@ func Remove(menus []string) {
 				slog.Info("remove", "delete", v)
 			}
 		}
+
+		delete(lock, v)
 	}
+
+	writeLock(lock)
 }
 
 func List() {
-	if err := cloneOrPull(); err != nil {
-		slog.Error("list", "cloneOrPull", err)
-		return
-	}
-
-	dest := filepath.Join(xdg.DataHome, "elephant", "install")
+	dest := installDir()
 
-	filepath.WalkDir(repo, func(path string, d fs.DirEntry, err error) error {
-		if strings.Contains(path, ".git") || path == repo {
-			return nil
+	for _, r := range registries() {
+		res, err := packages(r)
+		if err != nil {
+			slog.Error("list", r.Name, err)
+			continue
 		}
 
-		if d.IsDir() {
-			menuName := filepath.Base(path)
-			installedPath := filepath.Join(dest, menuName)
+		for _, v := range res {
+			line := v.qualified()
 
-			if common.FileExists(installedPath) {
-				fmt.Printf("%s (installed)\n", menuName)
-			} else {
-				fmt.Println(menuName)
+			if common.FileExists(filepath.Join(dest, v.Name)) {
+				line = fmt.Sprintf("%s (installed)", line)
+			}
+
+			if v.Description != "" {
+				line = fmt.Sprintf("%s - %s", line, v.Description)
 			}
-			return filepath.SkipDir
-		}
 
-		return nil
-	})
+			fmt.Println(line)
+		}
+	}
 }
 
 func Install(menus []string) {
@@ -135,63 +144,219 @@ func Install(menus []string) {
 		return
 	}
 
-	if err := cloneOrPull(); err != nil {
-		slog.Error("install", "cloneOrPull", err)
-		return
+	lock := readLock()
+
+	for _, v := range menus {
+		name, pin, _ := strings.Cut(v, "@")
+
+		p, err := resolve(name)
+		if err != nil {
+			slog.Error("install", "resolve", err)
+			continue
+		}
+
+		locked, err := installPackage(p, pin)
+		if err != nil {
+			slog.Error("install", "package", name, "err", err)
+			continue
+		}
+
+		name = p.Name
+		lock[name] = locked
+
+		if pin != "" {
+			fmt.Printf("[%s] Done! Pinned to %s (%s). Restart Elephant to see changes\n", name, pin, short(locked.Commit))
+		} else {
+			fmt.Printf("[%s] Done! Restart Elephant to see changes\n", name)
+		}
 	}
 
-	dest := filepath.Join(xdg.DataHome, "elephant", "install")
-	if err := os.MkdirAll(dest, 0o755); err != nil {
-		slog.Error("install", "mkdirs", err)
-		return
+	writeLock(lock)
+}
+
+// Update installs the latest version of the given packages, or all installed ones. Pinned packages are skipped.
+func Update(menus []string) {
+	lock := readLock()
+
+	if len(menus) == 0 {
+		menus = installed()
 	}
 
 	for _, v := range menus {
-		path := filepath.Join(repo, v)
+		locked := lock[v]
 
-		if common.FileExists(path) {
-			cmd := exec.Command("cp", "-r", path, dest)
-			if err := cmd.Run(); err != nil {
-				slog.Error("install", "copy", err)
-			} else {
-				fmt.Printf("[%s] Done! Restart Elephant to see changes\n", v)
-			}
+		if locked.Pin != "" {
+			fmt.Printf("[%s] pinned to %s, skipping. Install with '%s' to unpin.\n", v, locked.Pin, v)
+			continue
+		}
+
+		p, err := resolve(locked.source(v))
+		if err != nil {
+			slog.Error("update", "resolve", err)
+			continue
+		}
+
+		latest, err := latestCommit(p, "HEAD")
+		if err != nil || latest == "" {
+			slog.Error("update", "not found", v)
+			continue
+		}
+
+		if latest == locked.Commit {
+			fmt.Printf("[%s] up to date\n", v)
+			continue
+		}
+
+		updated, err := installPackage(p, "")
+		if err != nil {
+			slog.Error("update", "package", v, "err", err)
+			continue
+		}
+
+		lock[v] = updated
+
+		fmt.Printf("[%s] %s -> %s\n", v, unknown(locked.Commit), short(updated.Commit))
+	}
+
+	writeLock(lock)
+}
+
+// Outdated lists installed packages with available updates.
+func Outdated() {
+	lock := readLock()
+
+	for _, v := range installed() {
+		locked := lock[v]
+
+		p, err := resolve(locked.source(v))
+		if err != nil {
+			continue
+		}
+
+		latest, ok := newer(p, locked)
+		if !ok {
+			continue
+		}
+
+		if locked.Pin != "" {
+			fmt.Printf("%s %s -> %s (pinned to %s)\n", v, short(locked.Commit), short(latest), locked.Pin)
 		} else {
-			slog.Error("install", "not found", v)
+			fmt.Printf("%s %s -> %s\n", v, unknown(locked.Commit), short(latest))
 		}
 	}
 }
 
-func cloneOrPull() error {
-	if common.FileExists(repo) {
-		if err := pull(repo); err != nil {
-			slog.Error("install", "pull", "can't pull latest changes. re-cloning.")
+// newer returns the last commit changing the package, if the installed version lacks it. Pinned packages are
+// locked to the commit of their pin, which is compared by the last commit changing the package as of it.
+func newer(p Package, locked Locked) (string, bool) {
+	latest, err := latestCommit(p, "HEAD")
+	if err != nil || latest == "" {
+		return "", false
+	}
 
-			os.RemoveAll(repo)
+	current := locked.Commit
 
-			if err := clone(); err != nil {
-				slog.Error("install", "clone", err)
-				return errors.New("can't clone repository")
-			}
+	if locked.Pin != "" && current != "" {
+		if current, err = latestCommit(p, current); err != nil {
+			return "", false
 		}
-	} else {
-		if err := clone(); err != nil {
-			slog.Error("install", "clone", err)
-			return errors.New("can't clone repository")
+	}
+
+	return latest, latest != current
+}
+
+// installPackage extracts the package at the pinned ref, or the latest version, into the install dir.
+func installPackage(p Package, pin string) (Locked, error) {
+	if err := sync(p); err != nil {
+		return Locked{}, err
+	}
+
+	ref := "HEAD"
+	if pin != "" {
+		ref = pin
+	}
+
+	commit, err := git(p.dir(), "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return Locked{}, fmt.Errorf("unknown tag or commit: %s", ref)
+	}
+
+	if _, err := git(p.dir(), "cat-file", "-e", p.tree(commit)); err != nil {
+		return Locked{}, fmt.Errorf("not found at %s", ref)
+	}
+
+	m, err := manifestAt(p, commit)
+	if err != nil {
+		return Locked{}, fmt.Errorf("invalid %s: %w", manifestFile, err)
+	}
+
+	if err := verify(p.Name, m); err != nil {
+		return Locked{}, err
+	}
+
+	if pin == "" {
+		commit, err = latestCommit(p, ref)
+		if err != nil {
+			return Locked{}, err
 		}
 	}
 
-	return nil
+	if err := os.MkdirAll(installDir(), 0o755); err != nil {
+		return Locked{}, err
+	}
+
+	if err := os.RemoveAll(filepath.Join(installDir(), p.Name)); err != nil {
+		return Locked{}, err
+	}
+
+	archive := exec.Command("git", "archive", "--prefix="+p.Name+"/", p.tree(ref))
+	archive.Dir = p.dir()
+
+	extract := exec.Command("tar", "-x", "-C", installDir())
+
+	extract.Stdin, err = archive.StdoutPipe()
+	if err != nil {
+		return Locked{}, err
+	}
+
+	if err := extract.Start(); err != nil {
+		return Locked{}, err
+	}
+
+	if err := archive.Run(); err != nil {
+		extract.Wait()
+		return Locked{}, err
+	}
+
+	if err := extract.Wait(); err != nil {
+		return Locked{}, err
+	}
+
+	return Locked{Commit: commit, Pin: pin, Registry: p.Registry, Installed: time.Now()}, nil
 }
 
-func pull(path string) error {
-	cmd := exec.Command("git", "pull")
-	cmd.Dir = path
-	return cmd.Run()
+func installed() []string {
+	res := []string{}
+
+	entries, err := os.ReadDir(installDir())
+	if err != nil {
+		return res
+	}
+
+	for _, v := range entries {
+		if v.IsDir() && !strings.HasPrefix(v.Name(), ".") {
+			res = append(res, v.Name())
+		}
+	}
+
+	return res
 }
 
-func clone() error {
-	cmd := exec.Command("git", "clone", "https://github.com/abenz1267/elephant-community")
-	cmd.Dir = os.TempDir()
-	return cmd.Run()
+// unknown marks packages installed before the lockfile existed.
+func unknown(commit string) string {
+	if commit == "" {
+		return "unknown"
+	}
+
+	return short(commit)
 }
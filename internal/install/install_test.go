@@ -0,0 +1,82 @@
+package install
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("needs git")
+	}
+
+	// the clone of the registry is in the temp dir
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv("TMP", tmp)
+
+	p := Package{Name: "power", URL: "test", Path: "power", Registry: "test"}
+	dir := p.dir()
+
+	run := func(args ...string) string {
+		t.Helper()
+
+		out, err := git(dir, args...)
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+
+		return out
+	}
+
+	write := func(file, content string) {
+		t.Helper()
+
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "test")
+
+	write("power/power.toml", "name = 'power'")
+	run("add", ".")
+	run("commit", "-qm", "add power")
+
+	// the tag is on a commit not touching the package
+	write("README.md", "packages")
+	run("add", ".")
+	run("commit", "-qm", "add readme")
+	run("tag", "v1")
+
+	pinned := Locked{Commit: run("rev-parse", "v1^{commit}"), Pin: "v1"}
+
+	if latest, ok := newer(p, pinned); ok {
+		t.Errorf("package pinned to v1 reported outdated, latest %s", latest)
+	}
+
+	write("power/power.toml", "name = 'power'\nicon = 'system-shutdown'")
+	run("add", ".")
+	run("commit", "-qm", "update power")
+
+	latest, ok := newer(p, pinned)
+	if !ok || latest != run("rev-parse", "HEAD") {
+		t.Errorf("update after v1 not reported: %s, %v", latest, ok)
+	}
+
+	if _, ok := newer(p, Locked{Commit: latest}); ok {
+		t.Error("latest version reported outdated")
+	}
+}
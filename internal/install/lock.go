@@ -0,0 +1,90 @@
+package install
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// Locked is an installed package and the commit it was installed from.
+type Locked struct {
+	Commit    string    `json:"commit"`
+	Pin       string    `json:"pin,omitempty"`
+	Registry  string    `json:"registry,omitempty"`
+	Installed time.Time `json:"installed"`
+}
+
+func lockFile() string {
+	return filepath.Join(installDir(), "elephant.lock")
+}
+
+func readLock() map[string]Locked {
+	res := make(map[string]Locked)
+
+	if !common.FileExists(lockFile()) {
+		return res
+	}
+
+	b, err := os.ReadFile(lockFile())
+	if err != nil {
+		slog.Error("lock", "read", err)
+		return res
+	}
+
+	if err := json.Unmarshal(b, &res); err != nil {
+		slog.Error("lock", "decode", err)
+	}
+
+	return res
+}
+
+func writeLock(lock map[string]Locked) {
+	b, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		slog.Error("lock", "encode", err)
+		return
+	}
+
+	if err := os.MkdirAll(installDir(), 0o755); err != nil {
+		slog.Error("lock", "mkdirs", err)
+		return
+	}
+
+	if err := os.WriteFile(lockFile(), b, 0o644); err != nil {
+		slog.Error("lock", "write", err)
+	}
+}
+
+// latestCommit is the last commit at the given ref touching the package.
+func latestCommit(p Package, ref string) (string, error) {
+	path := strings.TrimPrefix(p.tree(ref), ref+":")
+
+	if path == "" {
+		return git(p.dir(), "log", "-1", "--format=%H", ref)
+	}
+
+	return git(p.dir(), "log", "-1", "--format=%H", ref, "--", path)
+}
+
+// source is the name to resolve the package with. Packages installed before registries existed come from the default one.
+func (l Locked) source(name string) string {
+	if l.Registry == "" {
+		return fmt.Sprintf("%s/%s", DefaultRegistry, name)
+	}
+
+	return fmt.Sprintf("%s/%s", l.Registry, name)
+}
+
+func short(commit string) string {
+	if len(commit) > 8 {
+		return commit[:8]
+	}
+
+	return commit
+}
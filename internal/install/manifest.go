@@ -0,0 +1,183 @@
+package install
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/pelletier/go-toml/v2"
+)
+
+const manifestFile = "manifest.toml"
+
+// Version of the running elephant, used to check the packages requirements.
+var Version string
+
+// Manifest declares the requirements of a package.
+type Manifest struct {
+	// Elephant is the minimum elephant version, f.e. "2.16.0".
+	Elephant string `toml:"elephant"`
+	// Requires lists binaries that have to be in $PATH.
+	Requires []string `toml:"requires"`
+}
+
+func parseManifest(b []byte) (Manifest, error) {
+	var m Manifest
+
+	err := toml.Unmarshal(b, &m)
+
+	return m, err
+}
+
+// check returns the missing binaries and whether the elephant version is sufficient.
+func (m Manifest) check() ([]string, bool) {
+	missing := []string{}
+
+	for _, v := range m.Requires {
+		if _, err := exec.LookPath(v); err != nil {
+			missing = append(missing, v)
+		}
+	}
+
+	return missing, m.Elephant == "" || compareVersions(Version, m.Elephant) >= 0
+}
+
+// manifestAt reads the manifest of the package at the given ref. Packages without one have no requirements.
+func manifestAt(p Package, ref string) (Manifest, error) {
+	out, err := git(p.dir(), "show", p.tree(ref)+"/"+manifestFile)
+	if err != nil {
+		return Manifest{}, nil
+	}
+
+	return parseManifest([]byte(out))
+}
+
+func installedManifest(name string) (Manifest, error) {
+	b, err := os.ReadFile(filepath.Join(installDir(), name, manifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Manifest{}, nil
+		}
+
+		return Manifest{}, err
+	}
+
+	return parseManifest(b)
+}
+
+// verify fails if the elephant version is too old and warns about missing binaries.
+func verify(name string, m Manifest) error {
+	missing, ok := m.check()
+
+	if !ok {
+		return fmt.Errorf("requires elephant >= %s, running %s", m.Elephant, Version)
+	}
+
+	if len(missing) > 0 {
+		fmt.Printf("[%s] warning: missing dependencies: %s\n", name, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// Doctor re-checks the requirements of all installed packages. Returns false if there are problems.
+func Doctor() bool {
+	healthy := true
+	lock := readLock()
+
+	for _, v := range installed() {
+		problems := []string{}
+
+		m, err := installedManifest(v)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("invalid %s: %s", manifestFile, err))
+		}
+
+		missing, ok := m.check()
+
+		if !ok {
+			problems = append(problems, fmt.Sprintf("requires elephant >= %s, running %s", m.Elephant, Version))
+		}
+
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("missing dependencies: %s", strings.Join(missing, ", ")))
+		}
+
+		if _, ok := lock[v]; !ok {
+			problems = append(problems, "not in lockfile, run 'elephant community update' to track it")
+		}
+
+		if len(problems) == 0 {
+			fmt.Printf("[%s] ok\n", v)
+			continue
+		}
+
+		healthy = false
+
+		for _, p := range problems {
+			fmt.Printf("[%s] %s\n", v, p)
+		}
+	}
+
+	return healthy
+}
+
+// DoctorMenus reports errors and duplicate names of the last loading of menus by the daemon. Returns
+// false if there are problems.
+func DoctorMenus() bool {
+	s, err := common.ReadMenuSummary()
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("[menus] not loaded yet, start elephant first")
+			return true
+		}
+
+		fmt.Printf("[menus] can't read load summary: %s\n", err)
+		return false
+	}
+
+	fmt.Printf("[menus] %d menus from %d files, loaded %s in %s\n", s.Menus, s.Files, s.Loaded.Format(time.DateTime), s.Took.Round(time.Millisecond))
+
+	for _, v := range s.Errors {
+		fmt.Printf("[menus] %s: %s\n", v.Path, v.Error)
+	}
+
+	for _, v := range s.Duplicates {
+		fmt.Printf("[menus] duplicate '%s': using %s, ignoring %s\n", v.Name, v.Used, strings.Join(v.Ignored, ", "))
+	}
+
+	return len(s.Errors) == 0 && len(s.Duplicates) == 0
+}
+
+// compareVersions compares dotted versions numerically. Unknown versions are considered new enough.
+func compareVersions(a, b string) int {
+	if a == "" {
+		return 1
+	}
+
+	as := strings.Split(strings.TrimPrefix(strings.TrimSpace(a), "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(strings.TrimSpace(b), "v"), ".")
+
+	for i := range max(len(as), len(bs)) {
+		var x, y int
+
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+
+		if x != y {
+			return x - y
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,223 @@
+package install
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+const DefaultRegistry = "community"
+
+// Package is an installable menu or provider, located in a git repository.
+type Package struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	// Path inside the repository. Defaults to the repository root for index packages and the name for git registries.
+	Path     string `json:"path"`
+	Registry string `json:"-"`
+}
+
+type index struct {
+	Packages []Package `json:"packages"`
+}
+
+var synced = []string{}
+
+func registries() []common.Registry {
+	if common.GetElephantConfig() == nil {
+		common.LoadGlobalConfig()
+	}
+
+	res := []common.Registry{{Name: DefaultRegistry, URL: "https://github.com/abenz1267/elephant-community"}}
+
+	for _, v := range common.GetElephantConfig().Registries {
+		if v.Name == "" || v.URL == "" {
+			continue
+		}
+
+		res = append(res, v)
+	}
+
+	return res
+}
+
+func isIndex(r common.Registry) bool {
+	return strings.HasPrefix(r.URL, "http") && strings.HasSuffix(r.URL, ".json")
+}
+
+// qualified prefixes packages of additional registries with the registry name.
+func (p Package) qualified() string {
+	if p.Registry == DefaultRegistry {
+		return p.Name
+	}
+
+	return fmt.Sprintf("%s/%s", p.Registry, p.Name)
+}
+
+// dir is the local clone of the packages repository.
+func (p Package) dir() string {
+	md5 := md5.Sum([]byte(p.URL))
+	return filepath.Join(os.TempDir(), fmt.Sprintf("elephant-%s-%s", p.Registry, hex.EncodeToString(md5[:])[:8]))
+}
+
+// tree is the package directory at the given ref, as understood by git.
+func (p Package) tree(ref string) string {
+	path := strings.TrimPrefix(filepath.Clean(p.Path), "/")
+
+	if path == "." {
+		path = ""
+	}
+
+	return fmt.Sprintf("%s:%s", ref, path)
+}
+
+func packages(r common.Registry) ([]Package, error) {
+	if isIndex(r) {
+		return fetchIndex(r)
+	}
+
+	p := Package{Registry: r.Name, URL: r.URL}
+
+	if err := sync(p); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(p.dir())
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Package{}
+
+	for _, v := range entries {
+		if !v.IsDir() || strings.HasPrefix(v.Name(), ".") {
+			continue
+		}
+
+		res = append(res, Package{
+			Name:     v.Name(),
+			URL:      r.URL,
+			Path:     v.Name(),
+			Registry: r.Name,
+		})
+	}
+
+	return res, nil
+}
+
+func fetchIndex(r common.Registry) ([]Package, error) {
+	client := http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Get(r.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("index %s: %s", r.URL, resp.Status)
+	}
+
+	var i index
+
+	if err := json.NewDecoder(resp.Body).Decode(&i); err != nil {
+		return nil, err
+	}
+
+	res := []Package{}
+
+	for _, v := range i.Packages {
+		if v.Name == "" || v.URL == "" {
+			continue
+		}
+
+		v.Registry = r.Name
+		res = append(res, v)
+	}
+
+	return res, nil
+}
+
+// resolve finds the package in the registries, in order. Use 'registry/name' to pick a specific registry.
+func resolve(name string) (Package, error) {
+	registry, pkg, ok := strings.Cut(name, "/")
+	if !ok {
+		registry, pkg = "", name
+	}
+
+	for _, r := range registries() {
+		if registry != "" && r.Name != registry {
+			continue
+		}
+
+		res, err := packages(r)
+		if err != nil {
+			slog.Error("registry", r.Name, err)
+			continue
+		}
+
+		i := slices.IndexFunc(res, func(p Package) bool {
+			return p.Name == pkg
+		})
+
+		if i != -1 {
+			return res[i], nil
+		}
+	}
+
+	return Package{}, fmt.Errorf("not found: %s", name)
+}
+
+// sync clones or pulls the packages repository, once per run. Private repositories authenticate through the
+// ssh agent when using ssh urls.
+func sync(p Package) error {
+	dir := p.dir()
+
+	if slices.Contains(synced, dir) {
+		return nil
+	}
+
+	if common.FileExists(dir) {
+		if _, err := git(dir, "pull"); err == nil {
+			synced = append(synced, dir)
+			return nil
+		}
+
+		fmt.Printf("[%s] can't pull latest changes. re-cloning.\n", p.Registry)
+
+		os.RemoveAll(dir)
+	}
+
+	cmd := exec.Command("git", "clone", p.URL, dir)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("clone %s: %s", p.URL, strings.TrimSpace(string(out)))
+	}
+
+	synced = append(synced, dir)
+
+	return nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	out, err := cmd.Output()
+
+	return strings.TrimSpace(string(out)), err
+}
@@ -68,10 +68,10 @@ func Available() bool {
 	return true
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 const (
@@ -83,7 +83,7 @@ const (
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
 	switch action {
 	case ActionCopyPassword:
-		toRun := "wl-copy $(op item get %VALUE% --fields password --reveal)"
+		toRun := `printf %s "$(op item get %VALUE% --fields password --reveal)" | ` + common.CopyCommand()
 
 		cmd := common.ReplaceResultOrStdinCmd(toRun, identifier)
 		stderr, _ := cmd.StderrPipe()
@@ -101,7 +101,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 
 					if config.ClearAfter > 0 {
 						time.Sleep(time.Duration(config.ClearAfter))
-						exec.Command("wl-copy", "--clear")
+						common.ClearClipboard().Run()
 					}
 				}
 			}
@@ -111,7 +111,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 
 				if config.ClearAfter > 0 {
 					time.Sleep(time.Duration(config.ClearAfter))
-					exec.Command("wl-copy", "--clear")
+					common.ClearClipboard().Run()
 				}
 			}
 
@@ -128,7 +128,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 			}
 		}
 
-		cmd := common.ReplaceResultOrStdinCmd("wl-copy", res)
+		cmd := common.ReplaceResultOrStdinCmd(common.CopyCommand(), res)
 		err := cmd.Start()
 		if err != nil {
 			slog.Error(Name, "copy username", err)
@@ -140,11 +140,11 @@ func Activate(single bool, identifier, action string, query string, args string,
 
 			if config.ClearAfter > 0 {
 				time.Sleep(time.Duration(config.ClearAfter))
-				exec.Command("wl-copy", "--clear")
+				common.ClearClipboard().Run()
 			}
 		}
 	case ActionCopy2FA:
-		toRun := "wl-copy $(op item get %VALUE% --otp)"
+		toRun := `printf %s "$(op item get %VALUE% --otp)" | ` + common.CopyCommand()
 
 		cmd := common.ReplaceResultOrStdinCmd(toRun, identifier)
 		stderr, _ := cmd.StderrPipe()
@@ -166,7 +166,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 
 					if config.ClearAfter > 0 {
 						time.Sleep(time.Duration(config.ClearAfter))
-						exec.Command("wl-copy", "--clear")
+						common.ClearClipboard().Run()
 					}
 				}
 			}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+const (
+	BackendOllama = "ollama"
+	BackendOpenAI = "openai"
+)
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string    `json:"model"`
+	Messages []message `json:"messages"`
+	Stream   bool      `json:"stream"`
+}
+
+// stream sends the prompt to the configured endpoint and calls fn with each chunk of the response.
+func stream(ctx context.Context, prompt string, fn func(chunk string)) error {
+	messages := []message{}
+
+	if config.System != "" {
+		messages = append(messages, message{Role: "system", Content: config.System})
+	}
+
+	messages = append(messages, message{Role: "user", Content: prompt})
+
+	body, err := json.Marshal(chatRequest{
+		Model:    config.Model,
+		Messages: messages,
+		Stream:   true,
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimSuffix(config.Endpoint, "/")
+
+	switch config.Backend {
+	case BackendOpenAI:
+		endpoint = fmt.Sprintf("%s/chat/completions", endpoint)
+	default:
+		endpoint = fmt.Sprintf("%s/api/chat", endpoint)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	key, err := apiKey()
+	if err != nil {
+		return err
+	}
+
+	if key != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", key))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s %s", endpoint, resp.Status, strings.TrimSpace(string(b)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			continue
+		}
+
+		var chunk string
+		var done bool
+
+		switch config.Backend {
+		case BackendOpenAI:
+			chunk, done, err = parseOpenAI(line)
+		default:
+			chunk, done, err = parseOllama(line)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if chunk != "" {
+			fn(chunk)
+		}
+
+		if done {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseOpenAI parses a line of the server-sent events of OpenAI compatible endpoints.
+func parseOpenAI(line string) (string, bool, error) {
+	data, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return "", false, nil
+	}
+
+	data = strings.TrimSpace(data)
+
+	if data == "[DONE]" {
+		return "", true, nil
+	}
+
+	var res struct {
+		Choices []struct {
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+		} `json:"choices"`
+	}
+
+	if err := json.Unmarshal([]byte(data), &res); err != nil {
+		return "", false, err
+	}
+
+	if len(res.Choices) == 0 {
+		return "", false, nil
+	}
+
+	return res.Choices[0].Delta.Content, false, nil
+}
+
+// parseOllama parses a line of the newline delimited JSON of Ollama.
+func parseOllama(line string) (string, bool, error) {
+	var res struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Done  bool   `json:"done"`
+		Error string `json:"error"`
+	}
+
+	if err := json.Unmarshal([]byte(line), &res); err != nil {
+		return "", false, err
+	}
+
+	if res.Error != "" {
+		return "", false, fmt.Errorf("ollama: %s", res.Error)
+	}
+
+	return res.Message.Content, res.Done, nil
+}
+
+// apiKey returns the configured key or the output of the key command.
+func apiKey() (string, error) {
+	if config.APIKeyCmd != "" {
+		return common.Secret(fmt.Sprintf("cmd:%s", config.APIKeyCmd)).Resolve()
+	}
+
+	return config.APIKey.Resolve()
+}
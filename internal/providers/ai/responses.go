@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// Response is a finished answer to a prompt, newest first.
+type Response struct {
+	ID     uint64
+	Prompt string
+	Input  string
+	Text   string
+	Time   time.Time
+}
+
+var (
+	mu        sync.Mutex
+	responses = []Response{}
+	nextID    uint64
+)
+
+func loadResponses() {
+	file := common.CacheFile(fmt.Sprintf("%s.gob", Name))
+
+	if !common.FileExists(file) {
+		return
+	}
+
+	f, err := os.ReadFile(file)
+	if err != nil {
+		slog.Error(Name, "responses", err)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := gob.NewDecoder(bytes.NewReader(f)).Decode(&responses); err != nil {
+		slog.Error(Name, "decoding", err)
+	}
+
+	for _, v := range responses {
+		nextID = max(nextID, v.ID)
+	}
+}
+
+func addResponse(prompt, input, text string) Response {
+	mu.Lock()
+	defer mu.Unlock()
+
+	nextID++
+
+	r := Response{
+		ID:     nextID,
+		Prompt: prompt,
+		Input:  input,
+		Text:   text,
+		Time:   time.Now(),
+	}
+
+	responses = append([]Response{r}, responses...)
+
+	saveResponses()
+
+	return r
+}
+
+func removeResponse(id uint64) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for k, v := range responses {
+		if v.ID == id {
+			responses = append(responses[:k], responses[k+1:]...)
+			break
+		}
+	}
+
+	saveResponses()
+}
+
+// findResponse returns the newest response matching fn.
+func findResponse(fn func(Response) bool) (Response, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, v := range responses {
+		if fn(v) {
+			return v, true
+		}
+	}
+
+	return Response{}, false
+}
+
+// saveResponses expects mu to be locked.
+func saveResponses() {
+	if len(responses) > config.MaxItems {
+		responses = responses[:config.MaxItems]
+	}
+
+	var b bytes.Buffer
+
+	if err := gob.NewEncoder(&b).Encode(responses); err != nil {
+		slog.Error(Name, "encode", err)
+		return
+	}
+
+	file := common.CacheFile(fmt.Sprintf("%s.gob", Name))
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		slog.Error(Name, "createdirs", err)
+		return
+	}
+
+	if err := os.WriteFile(file, b.Bytes(), 0o600); err != nil {
+		slog.Error(Name, "writefile", err)
+	}
+}
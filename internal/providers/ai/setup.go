@@ -0,0 +1,397 @@
+// Package ai sends text to a language model with prompt templates.
+package main
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "ai"
+	NamePretty = "AI"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Backend       string        `koanf:"backend" desc:"api of the endpoint. 'ollama' or 'openai' for OpenAI compatible ones." default:"ollama"`
+	Endpoint      string        `koanf:"endpoint" desc:"base url, f.e. 'https://api.openai.com/v1' for openai" default:"http://localhost:11434"`
+	Model         string        `koanf:"model" desc:"model to use" default:"llama3.2"`
+	APIKey        common.Secret `koanf:"api_key" desc:"api key, f.e. 'env:OPENAI_API_KEY'" default:""`
+	APIKeyCmd     string        `koanf:"api_key_cmd" desc:"command printing the api key" default:""`
+	System        string        `koanf:"system" desc:"system prompt" default:"Answer concisely. Don't use markdown."`
+	Prompts       []Prompt      `koanf:"prompts" desc:"prompt templates" default:"Explain, Summarize, Fix grammar"`
+	Copy          string        `koanf:"copy" desc:"command to copy. supports %VALUE%." default:"wl-copy, xclip on X11, pbcopy on macOS, clip on Windows"`
+	Type          string        `koanf:"type" desc:"command to type. supports %VALUE%." default:"wtype -, xdotool type --file - on X11"`
+	Delay         int           `koanf:"delay" desc:"delay in ms before typing to avoid potential focus issues" default:"100"`
+	Timeout       int           `koanf:"timeout" desc:"timeout of a request in seconds" default:"120"`
+	MaxItems      int           `koanf:"max_items" desc:"max amount of kept responses" default:"50"`
+}
+
+type Prompt struct {
+	Name     string `koanf:"name" desc:"name of the entry" default:""`
+	Template string `koanf:"template" desc:"prompt, %INPUT% is replaced with the query or the clipboard" default:""`
+	Icon     string `koanf:"icon" desc:"icon to display, falls back to global" default:""`
+}
+
+const (
+	ActionAsk    = "ask"
+	ActionCopy   = "copy"
+	ActionType   = "type"
+	ActionDelete = "delete"
+
+	prefixPrompt   = "prompt:"
+	prefixResponse = "response:"
+)
+
+// request is a running request of a prompt.
+type request struct {
+	cancel context.CancelFunc
+}
+
+var (
+	runningMu sync.Mutex
+	// running requests by prompt identifier
+	running = make(map[string]*request)
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "dialog-information",
+			MinScore: 30,
+		},
+		Backend:  BackendOllama,
+		Endpoint: "http://localhost:11434",
+		Model:    "llama3.2",
+		System:   "Answer concisely. Don't use markdown.",
+		Prompts: []Prompt{
+			{Name: "Explain", Template: "Explain the following:\n\n%INPUT%", Icon: "dialog-question"},
+			{Name: "Summarize", Template: "Summarize the following:\n\n%INPUT%", Icon: "format-justify-fill"},
+			{Name: "Fix grammar", Template: "Fix the grammar and spelling of the following text. Only reply with the corrected text.\n\n%INPUT%", Icon: "tools-check-spelling"},
+		},
+		Copy:     common.CopyCommand(),
+		Type:     common.TypeCommand(),
+		Delay:    100,
+		Timeout:  120,
+		MaxItems: 50,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	loadResponses()
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionAsk, Label: "Ask", Icon: "mail-send", Default: true},
+		{Action: ActionCopy, Label: "Copy", Icon: "edit-copy"},
+		{Action: ActionType, Label: "Type", Icon: "input-keyboard"},
+		{Action: ActionDelete, Label: "Delete", Icon: "edit-delete"},
+	}
+}
+
+// input returns the query or, if it's empty, the clipboard.
+func input(query string) (string, bool) {
+	if strings.TrimSpace(query) != "" {
+		return query, false
+	}
+
+	return common.ClipboardText(), true
+}
+
+func promptByIdentifier(identifier string) (Prompt, bool) {
+	i, err := strconv.Atoi(strings.TrimPrefix(identifier, prefixPrompt))
+	if err != nil || i < 0 || i >= len(config.Prompts) {
+		return Prompt{}, false
+	}
+
+	return config.Prompts[i], true
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	var r Response
+	var found bool
+
+	if after, ok := strings.CutPrefix(identifier, prefixResponse); ok {
+		id, _ := strconv.ParseUint(after, 10, 64)
+
+		r, found = findResponse(func(r Response) bool {
+			return r.ID == id
+		})
+
+		if !found {
+			slog.Error(Name, "activate", fmt.Sprintf("unknown response: %s", identifier))
+			return
+		}
+	} else {
+		p, ok := promptByIdentifier(identifier)
+		if !ok {
+			slog.Error(Name, "activate", fmt.Sprintf("unknown prompt: %s", identifier))
+			return
+		}
+
+		in, _ := input(query)
+
+		r, found = findResponse(func(r Response) bool {
+			return r.Prompt == p.Name && r.Input == in
+		})
+
+		if (action == "" && !found) || action == ActionAsk {
+			ask(identifier, p, in, query, format, conn)
+			return
+		}
+	}
+
+	if action == "" {
+		action = ActionCopy
+	}
+
+	if !found {
+		slog.Error(Name, "activate", "no response yet")
+		return
+	}
+
+	switch action {
+	case ActionCopy:
+		cmd := common.QuoteResultOrStdinCmd(config.Copy, r.Text)
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "copy", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+	case ActionType:
+		time.Sleep(time.Duration(config.Delay) * time.Millisecond)
+
+		if out, err := common.QuoteResultOrStdinCmd(config.Type, r.Text).CombinedOutput(); err != nil {
+			slog.Error(Name, "type", err, "output", strings.TrimSpace(string(out)))
+		}
+	case ActionDelete:
+		removeResponse(r.ID)
+		handlers.ProviderUpdated <- Name
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+// ask streams the response to the prompt as updates of its item. A running request of the same prompt
+// is cancelled.
+func ask(identifier string, p Prompt, in, query string, format uint8, conn net.Conn) {
+	if strings.TrimSpace(in) == "" {
+		slog.Error(Name, "ask", "no input")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Timeout)*time.Second)
+
+	req := &request{cancel: cancel}
+
+	runningMu.Lock()
+	if r, ok := running[identifier]; ok {
+		r.cancel()
+	}
+	running[identifier] = req
+	runningMu.Unlock()
+
+	e := promptEntry(identifier, p, in, strings.TrimSpace(query) == "")
+	e.Text = "..."
+	e.State = []string{"streaming"}
+
+	handlers.UpdateItem(format, query, conn, e)
+
+	go func() {
+		defer func() {
+			cancel()
+
+			runningMu.Lock()
+			if running[identifier] == req {
+				delete(running, identifier)
+			}
+			runningMu.Unlock()
+		}()
+
+		var b strings.Builder
+		last := time.Now()
+
+		err := stream(ctx, strings.ReplaceAll(p.Template, "%INPUT%", in), func(chunk string) {
+			b.WriteString(chunk)
+
+			// limits the amount of updates sent to the client
+			if time.Since(last) < 100*time.Millisecond {
+				return
+			}
+
+			last = time.Now()
+			setText(e, b.String())
+			handlers.UpdateItem(format, query, conn, e)
+		})
+
+		if err != nil {
+			if ctx.Err() == context.Canceled {
+				return
+			}
+
+			slog.Error(Name, "ask", err)
+
+			e.Text = fmt.Sprintf("failed: %s", err)
+			e.State = []string{"failed"}
+			handlers.UpdateItem(format, query, conn, e)
+
+			return
+		}
+
+		text := strings.TrimSpace(b.String())
+		addResponse(p.Name, in, text)
+
+		setText(e, text)
+		e.State = []string{"done"}
+		e.Actions = []string{ActionCopy, ActionType, ActionAsk}
+		handlers.UpdateItem(format, query, conn, e)
+	}()
+}
+
+func setText(e *pb.QueryResponse_Item, text string) {
+	e.Text = strings.Join(strings.Fields(text), " ")
+	e.Preview = text
+	e.PreviewType = util.PreviewTypeText
+}
+
+func promptEntry(identifier string, p Prompt, in string, fromClipboard bool) *pb.QueryResponse_Item {
+	icon := p.Icon
+	if icon == "" {
+		icon = config.Icon
+	}
+
+	subtext := strings.Join(strings.Fields(in), " ")
+	if len(subtext) > 80 {
+		subtext = subtext[:80] + "..."
+	}
+
+	if fromClipboard {
+		subtext = fmt.Sprintf("clipboard: %s", subtext)
+	}
+
+	return &pb.QueryResponse_Item{
+		Identifier: identifier,
+		Text:       p.Name,
+		Subtext:    subtext,
+		Icon:       icon,
+		Provider:   Name,
+		Actions:    []string{ActionAsk},
+		Type:       pb.QueryResponse_REGULAR,
+	}
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	in, fromClipboard := input(query)
+
+	if strings.TrimSpace(in) != "" {
+		for k, v := range config.Prompts {
+			e := promptEntry(fmt.Sprintf("%s%d", prefixPrompt, k), v, in, fromClipboard)
+			e.Score = int32(1_000_000 - k)
+
+			if r, ok := findResponse(func(r Response) bool {
+				return r.Prompt == v.Name && r.Input == in
+			}); ok {
+				e.Text = fmt.Sprintf("%s: %s", v.Name, strings.Join(strings.Fields(r.Text), " "))
+				e.Preview = r.Text
+				e.PreviewType = util.PreviewTypeText
+				e.State = []string{"done"}
+				e.Actions = []string{ActionCopy, ActionType, ActionAsk}
+			}
+
+			entries = append(entries, e)
+		}
+	}
+
+	if !single {
+		return entries
+	}
+
+	mu.Lock()
+	list := append([]Response{}, responses...)
+	mu.Unlock()
+
+	for k, v := range list {
+		e := &pb.QueryResponse_Item{
+			Identifier:  fmt.Sprintf("%s%d", prefixResponse, v.ID),
+			Text:        strings.Join(strings.Fields(v.Text), " "),
+			Subtext:     fmt.Sprintf("%s - %s", v.Prompt, strings.Join(strings.Fields(v.Input), " ")),
+			Icon:        config.Icon,
+			Provider:    Name,
+			Actions:     []string{ActionCopy, ActionType, ActionDelete},
+			State:       []string{"saved"},
+			Score:       int32(len(list) - k),
+			Preview:     v.Text,
+			PreviewType: util.PreviewTypeText,
+			Type:        pb.QueryResponse_REGULAR,
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, e.Text, exact)
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
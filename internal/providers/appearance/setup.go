@@ -0,0 +1,219 @@
+//go:build linux
+
+// Package appearance switches themes and the color scheme.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os/exec"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "appearance"
+	NamePretty = "Appearance"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config    `koanf:",squash"`
+	Kinds            []string `koanf:"kinds" desc:"settings to list. 'color-scheme', 'gtk-theme', 'icon-theme', 'cursor-theme' and 'qt-theme'." default:"[\"color-scheme\", \"gtk-theme\", \"icon-theme\", \"cursor-theme\", \"qt-theme\"]"`
+	SwitchGTKVariant bool     `koanf:"switch_gtk_variant" desc:"switch to the -dark variant of the GTK theme with the color scheme, for GTK3 apps" default:"true"`
+	Hooks            []string `koanf:"hooks" desc:"commands to run after switching, f.e. to restart apps. supports %KIND% and %VALUE%." default:""`
+}
+
+const (
+	ActionSet        = "set"
+	ActionToggleDark = "toggle_dark"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "preferences-desktop-theme",
+			MinScore: 30,
+		},
+		Kinds:            []string{KindColorScheme, KindGTK, KindIcons, KindCursor, KindQt},
+		SwitchGTKVariant: true,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+}
+
+func Available() bool {
+	if _, err := exec.LookPath("gsettings"); err != nil {
+		slog.Info(Name, "available", "gsettings not found. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionSet, Label: "Apply", Icon: "preferences-desktop-theme", Default: true},
+		{Action: ActionToggleDark, Label: "Toggle dark mode", Icon: "weather-clear-night"},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	var name, value string
+
+	switch action {
+	case ActionToggleDark:
+		name, value = KindColorScheme, "prefer-dark"
+
+		if colorScheme() == "prefer-dark" {
+			value = "prefer-light"
+		}
+	case ActionSet, "":
+		var ok bool
+
+		name, value, ok = strings.Cut(identifier, ":")
+		if !ok {
+			slog.Error(Name, "activate", fmt.Sprintf("invalid identifier: %s", identifier))
+			return
+		}
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	k, ok := kindByName(name)
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown kind: %s", name))
+		return
+	}
+
+	if err := k.set(value); err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	runHooks(name, value)
+
+	handlers.ProviderUpdated <- Name
+}
+
+func runHooks(kind, value string) {
+	for _, v := range config.Hooks {
+		cmd := strings.ReplaceAll(v, "%KIND%", common.Quote(kind))
+		cmd = strings.ReplaceAll(cmd, "%VALUE%", common.Quote(value))
+
+		if err := run(common.HostShell(cmd)); err != nil {
+			slog.Error(Name, "hook", err)
+		}
+	}
+}
+
+func enabled() []kind {
+	res := []kind{}
+
+	for _, v := range config.Kinds {
+		k, ok := kindByName(v)
+		if !ok {
+			slog.Error(Name, "kinds", fmt.Sprintf("unknown kind: %s", v))
+			continue
+		}
+
+		res = append(res, k)
+	}
+
+	return res
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	for i, k := range enabled() {
+		current := k.get()
+		values := k.values()
+
+		for j, v := range values {
+			e := &pb.QueryResponse_Item{
+				Identifier: fmt.Sprintf("%s:%s", k.name, v),
+				Text:       v,
+				Subtext:    k.label,
+				Icon:       config.Icon,
+				Provider:   Name,
+				Actions:    []string{ActionSet},
+				Score:      int32(10000 - i*1000 - j),
+				Type:       pb.QueryResponse_REGULAR,
+			}
+
+			if v == current {
+				e.State = []string{"current"}
+			}
+
+			if query != "" {
+				score, pos, start := common.FuzzyScore(query, v, exact)
+
+				// allows narrowing down to a kind, f.e. "cursor"
+				if s, _, _ := common.FuzzyScore(query, fmt.Sprintf("%s %s", k.label, v), exact); s > score {
+					score, pos, start = s, nil, 0
+				}
+
+				if score <= config.MinScore {
+					continue
+				}
+
+				e.Score = score
+				e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+					Start:     start,
+					Field:     "text",
+					Positions: pos,
+				}
+			}
+
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+// State contains the current values as '<kind>:<value>', f.e. 'color-scheme:prefer-dark'.
+func State(provider string) *pb.ProviderStateResponse {
+	states := []string{}
+
+	for _, k := range enabled() {
+		if v := k.get(); v != "" {
+			states = append(states, fmt.Sprintf("%s:%s", k.name, v))
+		}
+	}
+
+	return &pb.ProviderStateResponse{
+		States:  states,
+		Actions: []string{ActionToggleDark},
+	}
+}
@@ -0,0 +1,268 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/adrg/xdg"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	KindColorScheme = "color-scheme"
+	KindGTK         = "gtk-theme"
+	KindIcons       = "icon-theme"
+	KindCursor      = "cursor-theme"
+	KindQt          = "qt-theme"
+
+	interfaceSchema = "org.gnome.desktop.interface"
+)
+
+// kind is a setting that can be switched, f.e. the GTK theme.
+type kind struct {
+	name  string
+	label string
+	// values lists the available values
+	values func() []string
+	get    func() string
+	set    func(value string) error
+}
+
+var kinds = []kind{
+	{
+		name:  KindColorScheme,
+		label: "Color scheme",
+		values: func() []string {
+			return []string{"prefer-dark", "prefer-light", "default"}
+		},
+		get: colorScheme,
+		set: func(value string) error {
+			if err := gsettingsSet("color-scheme", value); err != nil {
+				return err
+			}
+
+			// GTK3 apps don't know color-scheme, so dark variants of the theme are used
+			if !config.SwitchGTKVariant {
+				return nil
+			}
+
+			theme := gsettingsGet("gtk-theme")
+			base := strings.TrimSuffix(theme, "-dark")
+
+			if value == "prefer-dark" && slices.Contains(gtkThemes(), base+"-dark") {
+				return gsettingsSet("gtk-theme", base+"-dark")
+			}
+
+			if value != "prefer-dark" && base != theme {
+				return gsettingsSet("gtk-theme", base)
+			}
+
+			return nil
+		},
+	},
+	{
+		name:   KindGTK,
+		label:  "GTK theme",
+		values: gtkThemes,
+		get: func() string {
+			return gsettingsGet("gtk-theme")
+		},
+		set: func(value string) error {
+			return gsettingsSet("gtk-theme", value)
+		},
+	},
+	{
+		name:   KindIcons,
+		label:  "Icon theme",
+		values: iconThemes,
+		get: func() string {
+			return gsettingsGet("icon-theme")
+		},
+		set: func(value string) error {
+			return gsettingsSet("icon-theme", value)
+		},
+	},
+	{
+		name:   KindCursor,
+		label:  "Cursor theme",
+		values: cursorThemes,
+		get: func() string {
+			return gsettingsGet("cursor-theme")
+		},
+		set: func(value string) error {
+			return gsettingsSet("cursor-theme", value)
+		},
+	},
+	{
+		name:   KindQt,
+		label:  "Qt theme",
+		values: kvantumThemes,
+		get: func() string {
+			b, err := os.ReadFile(filepath.Join(xdg.ConfigHome, "Kvantum", "kvantum.kvconfig"))
+			if err != nil {
+				return ""
+			}
+
+			for line := range strings.Lines(string(b)) {
+				if v, ok := strings.CutPrefix(strings.TrimSpace(line), "theme="); ok {
+					return v
+				}
+			}
+
+			return ""
+		},
+		set: func(value string) error {
+			return run(exec.Command("kvantummanager", "--set", value))
+		},
+	},
+}
+
+func kindByName(name string) (kind, bool) {
+	for _, v := range kinds {
+		if v.name == name {
+			return v, true
+		}
+	}
+
+	return kind{}, false
+}
+
+func gsettingsGet(key string) string {
+	out, err := exec.Command("gsettings", "get", interfaceSchema, key).Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.Trim(strings.TrimSpace(string(out)), "'")
+}
+
+func gsettingsSet(key, value string) error {
+	return run(exec.Command("gsettings", "set", interfaceSchema, key, value))
+}
+
+func run(cmd *exec.Cmd) error {
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", strings.Join(cmd.Args, " "), err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// colorScheme reads the color scheme from the settings portal, which is what applications see, and
+// falls back to gsettings.
+func colorScheme() string {
+	bus, err := dbus.SessionBus()
+	if err == nil {
+		var v dbus.Variant
+
+		err = bus.Object("org.freedesktop.portal.Desktop", "/org/freedesktop/portal/desktop").
+			Call("org.freedesktop.portal.Settings.ReadOne", 0, "org.freedesktop.appearance", "color-scheme").
+			Store(&v)
+		if err == nil {
+			if scheme, ok := v.Value().(uint32); ok {
+				// 0: no preference, 1: prefer dark, 2: prefer light
+				switch scheme {
+				case 1:
+					return "prefer-dark"
+				case 2:
+					return "prefer-light"
+				default:
+					return "default"
+				}
+			}
+		}
+	}
+
+	return gsettingsGet("color-scheme")
+}
+
+// dataDirs returns the directories themes can be in, with the ones of the user first.
+func dataDirs(sub string) []string {
+	home, _ := os.UserHomeDir()
+
+	res := []string{filepath.Join(xdg.DataHome, sub)}
+
+	switch sub {
+	case "themes", "icons":
+		res = append(res, filepath.Join(home, "."+sub))
+	case "Kvantum":
+		res = append(res, filepath.Join(xdg.ConfigHome, sub))
+	}
+
+	for _, v := range xdg.DataDirs {
+		res = append(res, filepath.Join(v, sub))
+	}
+
+	return res
+}
+
+// themes lists the subdirectories of the data directories for which has returns true.
+func themes(sub string, has func(dir string) bool) []string {
+	res := []string{}
+
+	for _, dir := range dataDirs(sub) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, e := range entries {
+			if !slices.Contains(res, e.Name()) && has(filepath.Join(dir, e.Name())) {
+				res = append(res, e.Name())
+			}
+		}
+	}
+
+	slices.Sort(res)
+
+	return res
+}
+
+func gtkThemes() []string {
+	return themes("themes", func(dir string) bool {
+		return common.FileExists(filepath.Join(dir, "gtk-3.0")) || common.FileExists(filepath.Join(dir, "gtk-4.0"))
+	})
+}
+
+func iconThemes() []string {
+	return themes("icons", func(dir string) bool {
+		b, err := os.ReadFile(filepath.Join(dir, "index.theme"))
+		if err != nil {
+			return false
+		}
+
+		// cursor themes have an index.theme as well, but no icon directories
+		return strings.Contains(string(b), "Directories=") && !strings.Contains(string(b), "Hidden=true")
+	})
+}
+
+func cursorThemes() []string {
+	return themes("icons", func(dir string) bool {
+		return common.FileExists(filepath.Join(dir, "cursors"))
+	})
+}
+
+func kvantumThemes() []string {
+	if _, err := exec.LookPath("kvantummanager"); err != nil {
+		return []string{}
+	}
+
+	res := themes("Kvantum", func(dir string) bool {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.kvconfig"))
+		return len(matches) > 0
+	})
+
+	if !slices.Contains(res, "Default") {
+		res = append([]string{"Default"}, res...)
+	}
+
+	return res
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type Member struct {
+	Name string
+	Size int64
+}
+
+type listing struct {
+	modified time.Time
+	members  []Member
+}
+
+var (
+	listingsMu sync.Mutex
+	listings   = make(map[string]listing)
+)
+
+var errNotFound = errors.New("not in archive")
+
+// kind returns the format of the archive by its extension, "" if it isn't supported.
+func kind(path string) string {
+	path = strings.ToLower(path)
+
+	switch {
+	case strings.HasSuffix(path, ".zip"), strings.HasSuffix(path, ".jar"):
+		return "zip"
+	case strings.HasSuffix(path, ".tar"):
+		return "tar"
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(path, ".tar.bz2"), strings.HasSuffix(path, ".tbz2"):
+		return "tar.bz2"
+	case strings.HasSuffix(path, ".tar.zst"), strings.HasSuffix(path, ".tzst"):
+		return "tar.zst"
+	default:
+		return ""
+	}
+}
+
+// members lists the files in the archive. Listings are cached until the archive changes.
+func members(archive string) ([]Member, error) {
+	info, err := os.Stat(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	listingsMu.Lock()
+	l, ok := listings[archive]
+	listingsMu.Unlock()
+
+	if ok && l.modified.Equal(info.ModTime()) {
+		return l.members, nil
+	}
+
+	res := []Member{}
+
+	err = walk(archive, func(name string, size int64, _ func() (io.ReadCloser, error)) (bool, error) {
+		res = append(res, Member{Name: name, Size: size})
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	listingsMu.Lock()
+	listings[archive] = listing{modified: info.ModTime(), members: res}
+	listingsMu.Unlock()
+
+	return res, nil
+}
+
+// walk calls fn for the regular files in the archive until it returns true. Zip archives are read via
+// their central directory, tar archives are streamed, so nothing is extracted that isn't opened.
+func walk(archive string, fn func(name string, size int64, open func() (io.ReadCloser, error)) (bool, error)) error {
+	k := kind(archive)
+
+	if k == "zip" {
+		r, err := zip.OpenReader(archive)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		for _, f := range r.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+
+			if done, err := fn(f.Name, int64(f.UncompressedSize64), f.Open); done || err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+
+	switch k {
+	case "tar.gz":
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+
+		r = gz
+	case "tar.bz2":
+		r = bzip2.NewReader(file)
+	case "tar.zst":
+		zr, err := zstd.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+
+		r = zr
+	case "tar":
+	default:
+		return fmt.Errorf("unsupported archive: %s", archive)
+	}
+
+	tr := tar.NewReader(r)
+
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		open := func() (io.ReadCloser, error) {
+			return io.NopCloser(tr), nil
+		}
+
+		if done, err := fn(h.Name, h.Size, open); done || err != nil {
+			return err
+		}
+	}
+}
+
+// extract writes the member to the directory and returns its path. Existing files aren't overwritten, a
+// number is added to the name instead.
+func extract(archive, name, dir string) (string, error) {
+	var dest string
+
+	err := walk(archive, func(member string, _ int64, open func() (io.ReadCloser, error)) (bool, error) {
+		if member != name {
+			return false, nil
+		}
+
+		r, err := open()
+		if err != nil {
+			return true, err
+		}
+		defer r.Close()
+
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return true, err
+		}
+
+		dest = freePath(filepath.Join(dir, filepath.Base(name)))
+
+		f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return true, err
+		}
+
+		if _, err := io.Copy(f, r); err != nil {
+			f.Close()
+			os.Remove(dest)
+
+			return true, err
+		}
+
+		return true, f.Close()
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if dest == "" {
+		return "", fmt.Errorf("%w: %s", errNotFound, name)
+	}
+
+	return dest, nil
+}
+
+// freePath returns the path, or "name (n).ext" for the first n that doesn't exist yet.
+func freePath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return path
+		}
+
+		path = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+}
@@ -0,0 +1,311 @@
+// Package archives lists and extracts the files in zip and tar archives found by the files provider.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/providers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "archives"
+	NamePretty = "Archives"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	ExtractDir    string `koanf:"extract_dir" desc:"directory files are extracted to" default:"~/Downloads"`
+	MaxItems      int    `koanf:"max_items" desc:"maximum number of files listed per archive" default:"500"`
+}
+
+const (
+	ActionExplore = "explore"
+	ActionOpen    = "open"
+	ActionExtract = "extract"
+
+	// separator between archive and member in identifiers, clean paths don't contain it
+	separator = "//"
+)
+
+// extensions are queried from the files provider to find archives.
+var extensions = []string{".zip", ".jar", ".tar", ".tgz", ".tbz2", ".tzst"}
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "package-x-generic",
+			MinScore: 20,
+		},
+		ExtractDir: "~/Downloads",
+		MaxItems:   500,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	// files opened temporarily are kept until the next start
+	if err := os.RemoveAll(tempDir()); err != nil {
+		slog.Error(Name, "cleanup", err)
+	}
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionExplore, Label: "Show files", Icon: "go-next", Default: true},
+		{Action: ActionOpen, Label: "Open", Icon: "document-open"},
+		{Action: ActionExtract, Label: "Extract", Icon: "document-save"},
+	}
+}
+
+func tempDir() string {
+	return filepath.Join(os.TempDir(), "elephant-archives")
+}
+
+func extractDir() string {
+	if after, ok := strings.CutPrefix(config.ExtractDir, "~/"); ok {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, after)
+	}
+
+	return config.ExtractDir
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	archive, member, isMember := strings.Cut(identifier, separator)
+
+	if action == "" {
+		action = ActionExplore
+
+		if isMember {
+			action = ActionOpen
+		}
+	}
+
+	switch action {
+	case ActionExplore:
+		if isMember {
+			slog.Error(Name, "activate", fmt.Sprintf("not an archive: %s", identifier))
+			return
+		}
+
+		// clients open the archive like a submenu
+		handlers.ProviderUpdated <- fmt.Sprintf("%s:%s", Name, archive)
+	case ActionOpen:
+		path := archive
+
+		if isMember {
+			if err := os.MkdirAll(tempDir(), 0o700); err != nil {
+				slog.Error(Name, "open", err)
+				return
+			}
+
+			// a directory per file keeps its name
+			dir, err := os.MkdirTemp(tempDir(), "")
+			if err != nil {
+				slog.Error(Name, "open", err)
+				return
+			}
+
+			path, err = extract(archive, member, dir)
+			if err != nil {
+				slog.Error(Name, "open", err)
+				return
+			}
+		}
+
+		open(identifier, path)
+	case ActionExtract:
+		if !isMember {
+			slog.Error(Name, "activate", fmt.Sprintf("not a file in an archive: %s", identifier))
+			return
+		}
+
+		path, err := extract(archive, member, extractDir())
+		if err != nil {
+			slog.Error(Name, "extract", err)
+			return
+		}
+
+		slog.Info(Name, "extracted", path)
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+func open(identifier, path string) {
+	cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix("", Name, identifier), common.OpenCommand(), common.Quote(path))))
+	common.Detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		slog.Error(Name, "open", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+}
+
+// parseQuery splits queries of the form "<archive>:<filter>". Other queries filter the archives.
+func parseQuery(query string) (string, string) {
+	if !filepath.IsAbs(query) {
+		return "", query
+	}
+
+	if i := strings.LastIndex(query, ":"); i != -1 {
+		return query[:i], query[i+1:]
+	}
+
+	return query, ""
+}
+
+// archives returns the supported archives known to the files provider.
+func archives(conn net.Conn, format uint8) []string {
+	files, ok := providers.Get("files")
+	if !ok {
+		return nil
+	}
+
+	res := []string{}
+	seen := make(map[string]bool)
+
+	for _, ext := range extensions {
+		for _, v := range files.Query(conn, ext, false, false, format) {
+			if seen[v.Text] || kind(v.Text) == "" {
+				continue
+			}
+
+			seen[v.Text] = true
+			res = append(res, v.Text)
+		}
+	}
+
+	return res
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, format uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	archive, query := parseQuery(query)
+
+	if archive == "" {
+		for k, v := range archives(conn, format) {
+			entries = filter(entries, &pb.QueryResponse_Item{
+				Identifier: v,
+				Text:       filepath.Base(v),
+				Subtext:    filepath.Dir(v),
+				Provider:   Name,
+				Icon:       config.Icon,
+				Actions:    []string{ActionExplore, ActionOpen},
+				Type:       pb.QueryResponse_REGULAR,
+				Score:      int32(1_000_000 - k),
+			}, query, exact)
+		}
+
+		return entries
+	}
+
+	res, err := members(archive)
+	if err != nil {
+		slog.Error(Name, "query", err, "archive", archive)
+		return entries
+	}
+
+	for k, v := range res {
+		if config.MaxItems > 0 && len(entries) >= config.MaxItems {
+			break
+		}
+
+		entries = filter(entries, &pb.QueryResponse_Item{
+			Identifier: archive + separator + v.Name,
+			Text:       strings.TrimPrefix(v.Name, "./"),
+			Subtext:    formatBytes(v.Size),
+			Provider:   Name,
+			Icon:       "text-x-generic",
+			Actions:    []string{ActionOpen, ActionExtract},
+			Type:       pb.QueryResponse_REGULAR,
+			Score:      int32(1_000_000 - k),
+		}, query, exact)
+	}
+
+	return entries
+}
+
+// filter adds the item if it matches the query.
+func filter(entries []*pb.QueryResponse_Item, e *pb.QueryResponse_Item, query string, exact bool) []*pb.QueryResponse_Item {
+	if query == "" {
+		return append(entries, e)
+	}
+
+	score, pos, start := common.FuzzyScore(query, e.Text, exact)
+
+	if score <= config.MinScore {
+		return entries
+	}
+
+	e.Score = score
+	e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+		Start:     start,
+		Field:     "text",
+		Positions: pos,
+	}
+
+	return append(entries, e)
+}
+
+func formatBytes(b int64) string {
+	const unit = 1024
+
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
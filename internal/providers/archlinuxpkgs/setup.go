@@ -1,3 +1,5 @@
+//go:build linux
+
 package main
 
 import (
@@ -5,6 +7,7 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
@@ -17,6 +20,7 @@ import (
 
 	"github.com/abenz1267/elephant/v2/internal/util"
 	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/memory"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 	"github.com/tinylib/msgp/msgp"
 )
@@ -131,10 +135,21 @@ func Setup() {
 		NamePretty = config.NamePretty
 	}
 
+	memory.Register(memory.Index{
+		Name: Name,
+		Size: func() int64 {
+			return int64(len(cachedData.Packages)) * packageSize
+		},
+		Evict: freeMem,
+	})
+
 	setup()
 	go clearCache()
 }
 
+// packageSize roughly estimates the memory of a package, mostly its full info.
+const packageSize = 1024
+
 func setup() {
 	getInstalled()
 	getOfficialPkgs()
@@ -161,10 +176,10 @@ func Available() bool {
 	return true
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
@@ -172,7 +187,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 
 	switch action {
 	case ActionVisitURL:
-		run := strings.TrimSpace(fmt.Sprintf("%s xdg-open '%s'", common.LaunchPrefix(""), cachedData.Packages[identifier].URL))
+		run := strings.TrimSpace(fmt.Sprintf("%s xdg-open '%s'", common.ScopedLaunchPrefix("", Name, identifier), cachedData.Packages[identifier].URL))
 		cmd := exec.Command("sh", "-c", run)
 
 		err := cmd.Start()
@@ -216,7 +231,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 		toRun = pkgcmd
 	}
 
-	cmd := exec.Command("sh", "-c", toRun)
+	cmd := common.HostShell(toRun)
 	err := cmd.Start()
 	if err != nil {
 		slog.Error(Name, "activate", err)
@@ -229,6 +244,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 
 func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
 	cacheChan <- struct{}{}
+	memory.Touch(Name)
 
 	entries := []*pb.QueryResponse_Item{}
 
@@ -0,0 +1,96 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/godbus/dbus/v5"
+)
+
+// selected is the adapter chosen via select_adapter. Falls back to the configured or the first adapter.
+var selected dbus.ObjectPath
+
+func currentAdapter() (Adapter, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if a, ok := adapters[selected]; ok {
+		return *a, true
+	}
+
+	paths := []dbus.ObjectPath{}
+
+	for k, v := range adapters {
+		if config.Adapter != "" && strings.HasSuffix(string(k), "/"+config.Adapter) {
+			return *v, true
+		}
+
+		paths = append(paths, k)
+	}
+
+	if len(paths) == 0 {
+		return Adapter{}, false
+	}
+
+	slices.Sort(paths)
+
+	return *adapters[paths[0]], true
+}
+
+// adapterNames returns the names of the adapters as used by select_adapter, f.e. "hci0".
+func adapterNames() []string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	res := []string{}
+
+	for k := range adapters {
+		res = append(res, strings.TrimPrefix(string(k), "/org/bluez/"))
+	}
+
+	slices.Sort(res)
+
+	return res
+}
+
+func selectAdapter(name string) {
+	path := dbus.ObjectPath(fmt.Sprintf("/org/bluez/%s", name))
+
+	mu.Lock()
+	_, ok := adapters[path]
+	if ok {
+		selected = path
+	}
+	mu.Unlock()
+
+	if !ok {
+		slog.Error(Name, "select adapter", fmt.Sprintf("unknown adapter: %s", name))
+		return
+	}
+
+	handlers.ProviderUpdated <- "bluetooth:adapter"
+}
+
+func discover(start bool) {
+	a, ok := currentAdapter()
+	if !ok || !a.Powered || a.Discovering == start {
+		return
+	}
+
+	if !start {
+		call(a.Path, adapterIface+".StopDiscovery")
+		return
+	}
+
+	call(a.Path, adapterIface+".StartDiscovery")
+
+	time.AfterFunc(time.Duration(config.DiscoveryTime)*time.Second, func() {
+		call(a.Path, adapterIface+".StopDiscovery")
+	})
+}
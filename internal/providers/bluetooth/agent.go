@@ -0,0 +1,236 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	agentPath    = dbus.ObjectPath("/org/elephant/bluetooth/agent")
+	agentIface   = "org.bluez.Agent1"
+	agentManager = "org.bluez.AgentManager1"
+	agentTimeout = 60 * time.Second
+
+	// IdentifierAgent is the identifier of the entry asking the user to confirm or enter a pin/passkey.
+	IdentifierAgent = "agent"
+
+	ActionConfirm = "confirm"
+	ActionReject  = "reject"
+	ActionEnter   = "enter"
+)
+
+const (
+	promptConfirm = iota
+	promptPin
+	promptPasskey
+	promptDisplay
+)
+
+type prompt struct {
+	device  dbus.ObjectPath
+	kind    int
+	display string
+	reply   chan string
+}
+
+var (
+	pending   *prompt
+	pendingMu sync.Mutex
+)
+
+var (
+	errRejected = dbus.NewError("org.bluez.Error.Rejected", nil)
+	errCanceled = dbus.NewError("org.bluez.Error.Canceled", nil)
+)
+
+// agent implements org.bluez.Agent1. Requests are surfaced as an entry and answered via Activate.
+type agent struct{}
+
+func registerAgent() error {
+	if err := bus.Export(agent{}, agentPath, agentIface); err != nil {
+		return err
+	}
+
+	return bus.Object(bluez, "/org/bluez").Call(agentManager+".RegisterAgent", 0, agentPath, "KeyboardDisplay").Err
+}
+
+// ask surfaces the prompt to the clients and blocks until it got answered, rejected or timed out.
+func ask(p *prompt) (string, *dbus.Error) {
+	p.reply = make(chan string, 1)
+
+	pendingMu.Lock()
+	pending = p
+	pendingMu.Unlock()
+
+	handlers.ProviderUpdated <- "bluetooth:agent"
+
+	defer func() {
+		pendingMu.Lock()
+		if pending == p {
+			pending = nil
+		}
+		pendingMu.Unlock()
+
+		handlers.ProviderUpdated <- "bluetooth:agent"
+	}()
+
+	select {
+	case res, ok := <-p.reply:
+		if !ok {
+			return "", errRejected
+		}
+
+		return res, nil
+	case <-time.After(agentTimeout):
+		return "", errCanceled
+	}
+}
+
+func answer(action, value string) {
+	pendingMu.Lock()
+	p := pending
+	pending = nil
+	pendingMu.Unlock()
+
+	if p == nil {
+		return
+	}
+
+	switch action {
+	case ActionConfirm, ActionEnter:
+		p.reply <- value
+	default:
+		close(p.reply)
+	}
+}
+
+// clearPrompt removes a prompt that only displayed a code, once the pairing finished.
+func clearPrompt(device dbus.ObjectPath) {
+	pendingMu.Lock()
+	p := pending
+	pendingMu.Unlock()
+
+	if p != nil && p.device == device && p.kind == promptDisplay {
+		answer(ActionReject, "")
+	}
+}
+
+func (agent) Release() *dbus.Error {
+	return nil
+}
+
+func (agent) RequestPinCode(device dbus.ObjectPath) (string, *dbus.Error) {
+	return ask(&prompt{device: device, kind: promptPin})
+}
+
+func (agent) DisplayPinCode(device dbus.ObjectPath, pincode string) *dbus.Error {
+	go ask(&prompt{device: device, kind: promptDisplay, display: pincode})
+	return nil
+}
+
+func (agent) RequestPasskey(device dbus.ObjectPath) (uint32, *dbus.Error) {
+	res, err := ask(&prompt{device: device, kind: promptPasskey})
+	if err != nil {
+		return 0, err
+	}
+
+	passkey, perr := strconv.ParseUint(res, 10, 32)
+	if perr != nil {
+		slog.Error(Name, "passkey", perr)
+		return 0, errRejected
+	}
+
+	return uint32(passkey), nil
+}
+
+func (agent) DisplayPasskey(device dbus.ObjectPath, passkey uint32, entered uint16) *dbus.Error {
+	pendingMu.Lock()
+	showing := pending != nil && pending.kind == promptDisplay && pending.device == device
+	pendingMu.Unlock()
+
+	if !showing {
+		go ask(&prompt{device: device, kind: promptDisplay, display: fmt.Sprintf("%06d", passkey)})
+	}
+
+	return nil
+}
+
+func (agent) RequestConfirmation(device dbus.ObjectPath, passkey uint32) *dbus.Error {
+	_, err := ask(&prompt{device: device, kind: promptConfirm, display: fmt.Sprintf("%06d", passkey)})
+	return err
+}
+
+func (agent) RequestAuthorization(device dbus.ObjectPath) *dbus.Error {
+	_, err := ask(&prompt{device: device, kind: promptConfirm})
+	return err
+}
+
+func (agent) AuthorizeService(device dbus.ObjectPath, uuid string) *dbus.Error {
+	return nil
+}
+
+func (agent) Cancel() *dbus.Error {
+	answer(ActionReject, "")
+	return nil
+}
+
+func promptEntry(query string) *pb.QueryResponse_Item {
+	pendingMu.Lock()
+	p := pending
+	pendingMu.Unlock()
+
+	if p == nil {
+		return nil
+	}
+
+	name := string(p.device)
+
+	mu.Lock()
+	if d, ok := devices[p.device]; ok {
+		name = d.Name
+	}
+	mu.Unlock()
+
+	e := &pb.QueryResponse_Item{
+		Identifier: IdentifierAgent,
+		Icon:       config.Icon,
+		Subtext:    name,
+		Provider:   Name,
+		Score:      1_000_000,
+		State:      []string{"agent"},
+		Type:       pb.QueryResponse_REGULAR,
+	}
+
+	switch p.kind {
+	case promptConfirm:
+		e.Text = "Allow pairing?"
+
+		if p.display != "" {
+			e.Text = fmt.Sprintf("Confirm passkey %s", p.display)
+		}
+
+		e.Actions = []string{ActionConfirm, ActionReject}
+	case promptPin, promptPasskey:
+		e.Text = "Type the PIN and press enter"
+
+		if query != "" {
+			e.Text = fmt.Sprintf("Use PIN %s", query)
+		}
+
+		e.Actions = []string{ActionEnter, ActionReject}
+	case promptDisplay:
+		e.Text = fmt.Sprintf("Enter %s on the device", p.display)
+		e.Actions = []string{ActionReject}
+	}
+
+	return e
+}
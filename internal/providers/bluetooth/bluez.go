@@ -0,0 +1,311 @@
+//go:build linux
+
+package main
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	bluez         = "org.bluez"
+	adapterIface  = "org.bluez.Adapter1"
+	deviceIface   = "org.bluez.Device1"
+	batteryIface  = "org.bluez.Battery1"
+	objectManager = "org.freedesktop.DBus.ObjectManager"
+	properties    = "org.freedesktop.DBus.Properties"
+)
+
+type Device struct {
+	Path      dbus.ObjectPath
+	Adapter   dbus.ObjectPath
+	Name      string
+	Mac       string
+	Icon      string
+	Paired    bool
+	Trusted   bool
+	Connected bool
+	Battery   int
+}
+
+type Adapter struct {
+	Path         dbus.ObjectPath
+	Name         string
+	Mac          string
+	Powered      bool
+	Discoverable bool
+	Discovering  bool
+}
+
+var (
+	bus      *dbus.Conn
+	mu       sync.Mutex
+	devices  = make(map[dbus.ObjectPath]*Device)
+	adapters = make(map[dbus.ObjectPath]*Adapter)
+	busy     = make(map[dbus.ObjectPath]string)
+	failed   = make(map[dbus.ObjectPath]string)
+)
+
+// relevant properties trigger an update for subscribed clients. Others, like RSSI during discovery, are too noisy.
+var relevant = []string{"Alias", "Name", "Icon", "Paired", "Trusted", "Connected", "Percentage", "Powered", "Discoverable", "Discovering"}
+
+func connectBus() error {
+	var err error
+
+	bus, err = dbus.ConnectSystemBus()
+	if err != nil {
+		return err
+	}
+
+	err = bus.AddMatchSignal(
+		dbus.WithMatchSender(bluez),
+		dbus.WithMatchInterface(objectManager),
+	)
+	if err != nil {
+		return err
+	}
+
+	err = bus.AddMatchSignal(
+		dbus.WithMatchSender(bluez),
+		dbus.WithMatchInterface(properties),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchPathNamespace("/org/bluez"),
+	)
+	if err != nil {
+		return err
+	}
+
+	var objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+
+	err = bus.Object(bluez, "/").Call(objectManager+".GetManagedObjects", 0).Store(&objects)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	for path, ifaces := range objects {
+		for iface, props := range ifaces {
+			apply(path, iface, props)
+		}
+	}
+	mu.Unlock()
+
+	signals := make(chan *dbus.Signal, 10)
+	bus.Signal(signals)
+
+	go func() {
+		for s := range signals {
+			if handleSignal(s) {
+				handlers.ProviderUpdated <- "bluetooth:changed"
+			}
+		}
+	}()
+
+	return nil
+}
+
+func handleSignal(s *dbus.Signal) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	switch s.Name {
+	case objectManager + ".InterfacesAdded":
+		var path dbus.ObjectPath
+		var ifaces map[string]map[string]dbus.Variant
+
+		if err := dbus.Store(s.Body, &path, &ifaces); err != nil {
+			slog.Error(Name, "interfacesadded", err)
+			return false
+		}
+
+		changed := false
+
+		for iface, props := range ifaces {
+			changed = apply(path, iface, props) || changed
+		}
+
+		return changed
+	case objectManager + ".InterfacesRemoved":
+		var path dbus.ObjectPath
+		var ifaces []string
+
+		if err := dbus.Store(s.Body, &path, &ifaces); err != nil {
+			slog.Error(Name, "interfacesremoved", err)
+			return false
+		}
+
+		if slices.Contains(ifaces, deviceIface) {
+			delete(devices, path)
+			return true
+		}
+
+		if slices.Contains(ifaces, adapterIface) {
+			delete(adapters, path)
+
+			if selected == path {
+				selected = ""
+			}
+
+			return true
+		}
+
+		if d, ok := devices[path]; ok && slices.Contains(ifaces, batteryIface) {
+			d.Battery = -1
+			return true
+		}
+	case properties + ".PropertiesChanged":
+		var iface string
+		var props map[string]dbus.Variant
+		var invalidated []string
+
+		if err := dbus.Store(s.Body, &iface, &props, &invalidated); err != nil {
+			slog.Error(Name, "propertieschanged", err)
+			return false
+		}
+
+		return apply(s.Path, iface, props)
+	}
+
+	return false
+}
+
+// apply updates the cached objects. Expects mu to be locked.
+func apply(path dbus.ObjectPath, iface string, props map[string]dbus.Variant) bool {
+	changed := false
+
+	for k := range props {
+		if slices.Contains(relevant, k) {
+			changed = true
+			break
+		}
+	}
+
+	switch iface {
+	case deviceIface:
+		d, ok := devices[path]
+		if !ok {
+			d = &Device{Path: path, Battery: -1}
+			devices[path] = d
+			changed = true
+		}
+
+		for k, v := range props {
+			switch k {
+			case "Address":
+				d.Mac, _ = v.Value().(string)
+			case "Alias":
+				d.Name, _ = v.Value().(string)
+			case "Icon":
+				d.Icon, _ = v.Value().(string)
+			case "Paired":
+				d.Paired, _ = v.Value().(bool)
+			case "Trusted":
+				d.Trusted, _ = v.Value().(bool)
+			case "Connected":
+				d.Connected, _ = v.Value().(bool)
+			case "Adapter":
+				d.Adapter, _ = v.Value().(dbus.ObjectPath)
+			}
+		}
+	case batteryIface:
+		d, ok := devices[path]
+		if !ok {
+			d = &Device{Path: path, Battery: -1}
+			devices[path] = d
+		}
+
+		if v, ok := props["Percentage"]; ok {
+			if p, ok := v.Value().(byte); ok {
+				d.Battery = int(p)
+			}
+		}
+	case adapterIface:
+		a, ok := adapters[path]
+		if !ok {
+			a = &Adapter{Path: path, Name: strings.TrimPrefix(string(path), "/org/bluez/")}
+			adapters[path] = a
+			changed = true
+		}
+
+		for k, v := range props {
+			switch k {
+			case "Address":
+				a.Mac, _ = v.Value().(string)
+			case "Powered":
+				a.Powered, _ = v.Value().(bool)
+			case "Discoverable":
+				a.Discoverable, _ = v.Value().(bool)
+			case "Discovering":
+				a.Discovering, _ = v.Value().(bool)
+			}
+		}
+	default:
+		return false
+	}
+
+	return changed
+}
+
+func deviceByMac(mac string) (Device, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, v := range devices {
+		if v.Mac == mac {
+			return *v, true
+		}
+	}
+
+	return Device{}, false
+}
+
+// call runs the method in the background. Resulting state changes reach the clients through PropertiesChanged.
+func call(path dbus.ObjectPath, method string, args ...any) {
+	go func() {
+		if err := bus.Object(bluez, path).Call(method, 0, args...).Err; err != nil {
+			slog.Error(Name, "call", err, "method", method, "path", path)
+		}
+	}()
+}
+
+// transition works like call, but marks the device with the progress state until the method returns.
+// Failures are kept on the device until the next transition.
+func transition(d Device, progress string, path dbus.ObjectPath, method string, args ...any) {
+	mu.Lock()
+	busy[d.Path] = progress
+	delete(failed, d.Path)
+	mu.Unlock()
+
+	handlers.ProviderUpdated <- "bluetooth:progress"
+
+	go func() {
+		err := bus.Object(bluez, path).Call(method, 0, args...).Err
+
+		mu.Lock()
+		delete(busy, d.Path)
+
+		if err != nil {
+			slog.Error(Name, "call", err, "method", method, "path", path)
+
+			if dbusErr, ok := err.(dbus.Error); ok {
+				failed[d.Path] = strings.TrimPrefix(dbusErr.Name, "org.bluez.Error.")
+			} else {
+				failed[d.Path] = err.Error()
+			}
+		}
+		mu.Unlock()
+
+		clearPrompt(d.Path)
+
+		handlers.ProviderUpdated <- "bluetooth:progress"
+	}()
+}
+
+func setProperty(path dbus.ObjectPath, iface, prop string, value any) {
+	call(path, properties+".Set", iface, prop, dbus.MakeVariant(value))
+}
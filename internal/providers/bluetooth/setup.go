@@ -1,26 +1,28 @@
-// Package symbols provides symbols/emojis.
+//go:build linux
+
+// Package bluetooth provides bluetooth device management through BlueZ.
 package main
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
-	"os/exec"
+	"slices"
 	"strings"
 	"time"
 
 	_ "embed"
 
-	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
 	"github.com/abenz1267/elephant/v2/internal/util"
 	"github.com/abenz1267/elephant/v2/pkg/common"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"github.com/godbus/dbus/v5"
 )
 
 var (
 	Name       = "bluetooth"
 	NamePretty = "Bluetooth"
-	find       = false
 )
 
 //go:embed README.md
@@ -28,19 +30,10 @@ var readme string
 
 type Config struct {
 	common.Config `koanf:",squash"`
+	DiscoveryTime int    `koanf:"discovery_time" desc:"time in seconds to search for new devices" default:"30"`
+	Adapter       string `koanf:"adapter" desc:"adapter to use, f.e. 'hci1'. defaults to the first one" default:""`
 }
 
-type Device struct {
-	Name      string
-	Mac       string
-	Icon      string
-	Paired    bool
-	Trusted   bool
-	Connected bool
-}
-
-var devices []Device
-
 var config *Config
 
 func Setup() {
@@ -51,6 +44,7 @@ func Setup() {
 			Icon:     "bluetooth-symbolic",
 			MinScore: 20,
 		},
+		DiscoveryTime: 30,
 	}
 
 	common.LoadConfig(Name, config)
@@ -59,24 +53,40 @@ func Setup() {
 		NamePretty = config.NamePretty
 	}
 
+	if err := connectBus(); err != nil {
+		slog.Error(Name, "dbus", err)
+		return
+	}
+
+	if err := registerAgent(); err != nil {
+		slog.Error(Name, "agent", err)
+	}
+
 	slog.Info(Name, "loaded", time.Since(start))
 }
 
 func Available() bool {
-	p, err := exec.LookPath("bluetoothctl")
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		slog.Info(Name, "available", "system bus not available. disabling")
+		return false
+	}
 
-	if p == "" || err != nil {
-		slog.Info(Name, "available", "bluetoothctl not found. disabling")
+	var running bool
+
+	err = conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, bluez).Store(&running)
+	if err != nil || !running {
+		slog.Info(Name, "available", "bluez not running. disabling")
 		return false
 	}
 
 	return true
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 const (
@@ -87,129 +97,125 @@ const (
 	ActionTrust      = "trust"
 	ActionUntrust    = "untrust"
 	ActionFind       = "find"
+	ActionStopFind   = "stop_find"
+
+	ActionPowerOn         = "power_on"
+	ActionPowerOff        = "power_off"
+	ActionDiscoverableOn  = "discoverable_on"
+	ActionDiscoverableOff = "discoverable_off"
+	ActionSelectAdapter   = "select_adapter:"
 )
 
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
-	cmd := exec.Command("bluetoothctl")
-
-	removed := false
-	added := false
-	disconnect := false
-	connect := false
-
 	switch action {
 	case ActionFind:
-		find = true
-		handlers.ProviderUpdated <- "bluetooth:find"
+		discover(true)
+		return
+	case ActionStopFind:
+		discover(false)
+		return
+	case ActionPowerOn, ActionPowerOff:
+		if a, ok := currentAdapter(); ok {
+			setProperty(a.Path, adapterIface, "Powered", action == ActionPowerOn)
+		}
+
+		return
+	case ActionDiscoverableOn, ActionDiscoverableOff:
+		if a, ok := currentAdapter(); ok {
+			setProperty(a.Path, adapterIface, "Discoverable", action == ActionDiscoverableOn)
+		}
+
 		return
+	}
+
+	if after, ok := strings.CutPrefix(action, ActionSelectAdapter); ok {
+		selectAdapter(after)
+		return
+	}
+
+	if identifier == IdentifierAgent {
+		if args == "" {
+			args = query
+		}
+
+		answer(action, args)
+		return
+	}
+
+	d, ok := deviceByMac(identifier)
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown device: %s", identifier))
+		return
+	}
+
+	switch action {
 	case ActionPair:
-		added = true
-		handlers.ProviderUpdated <- "bluetooth:pair"
-		cmd.Stdin = strings.NewReader(fmt.Sprintf(`power on
-pair %s
-quit
-`, identifier))
+		transition(d, "pairing", d.Path, deviceIface+".Pair")
 	case ActionRemove:
-		removed = true
-		handlers.ProviderUpdated <- "bluetooth:remove"
-		cmd.Stdin = strings.NewReader(fmt.Sprintf(`power on
-remove %s
-quit
-`, identifier))
+		transition(d, "removing", d.Adapter, adapterIface+".RemoveDevice", d.Path)
 	case ActionTrust:
-		handlers.ProviderUpdated <- "bluetooth:trust"
-		cmd.Stdin = strings.NewReader(fmt.Sprintf(`power on
-trust %s
-quit
-`, identifier))
-	case ActionConnect:
-		connect = true
-		handlers.ProviderUpdated <- "bluetooth:connect"
-		cmd.Stdin = strings.NewReader(fmt.Sprintf(`power on
-connect %s
-quit
-`, identifier))
+		setProperty(d.Path, deviceIface, "Trusted", true)
 	case ActionUntrust:
-		handlers.ProviderUpdated <- "bluetooth:untrust"
-		cmd.Stdin = strings.NewReader(fmt.Sprintf(`power on
-untrust %s
-quit
-`, identifier))
+		setProperty(d.Path, deviceIface, "Trusted", false)
+	case ActionConnect:
+		transition(d, "connecting", d.Path, deviceIface+".Connect")
 	case ActionDisconnect:
-		disconnect = true
-		handlers.ProviderUpdated <- "bluetooth:disconnect"
-		cmd.Stdin = strings.NewReader(fmt.Sprintf(`power on
-disconnect %s
-quit
-`, identifier))
+		transition(d, "disconnecting", d.Path, deviceIface+".Disconnect")
 	default:
 		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
-		return
-	}
-
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		slog.Error(Name, "activate", err)
 	}
+}
 
-	slog.Debug(Name, "activate", out)
+func Query(conn net.Conn, query string, _ bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	start := time.Now()
+	entries := []*pb.QueryResponse_Item{}
 
-	if added || removed {
-		for {
-			found := make(map[string]struct{})
-			time.Sleep(1 * time.Second)
+	current, _ := currentAdapter()
+	find := current.Discovering
 
-			cmd = exec.Command("bluetoothctl", "devices", "Paired")
-			out, err = cmd.CombinedOutput()
-			if err != nil {
-				slog.Error(Name, "get devices", err)
-			}
+	if e := promptEntry(query); e != nil {
+		entries = append(entries, e)
+	}
 
-			for v := range strings.Lines(strings.TrimSpace(string(out))) {
-				fields := strings.Fields(v)
+	mu.Lock()
+	list := []Device{}
+	progress := make(map[dbus.ObjectPath]string)
+	failures := make(map[dbus.ObjectPath]string)
 
-				found[fields[1]] = struct{}{}
-			}
+	for _, v := range devices {
+		if v.Adapter != current.Path {
+			continue
+		}
 
-			if _, ok := found[identifier]; removed && !ok || added && ok {
-				break
-			}
+		if v.Mac != "" && (v.Paired || find || busy[v.Path] != "") {
+			list = append(list, *v)
+			progress[v.Path] = busy[v.Path]
+			failures[v.Path] = failed[v.Path]
 		}
 	}
+	mu.Unlock()
 
-	if connect || disconnect {
-	outer:
-		for {
-			time.Sleep(1 * time.Second)
-
-			cmd := exec.Command("bluetoothctl", "info", identifier)
-			out, err := cmd.CombinedOutput()
-			if err != nil {
-				slog.Error(Name, "get info", err)
+	slices.SortFunc(list, func(a, b Device) int {
+		if a.Connected != b.Connected {
+			if a.Connected {
+				return -1
 			}
 
-			for l := range strings.Lines(string(out)) {
-				if strings.HasPrefix(strings.TrimSpace(l), "Connected") {
-					if connect && strings.Contains(l, "yes") {
-						break outer
-					}
+			return 1
+		}
 
-					if disconnect && !strings.Contains(l, "yes") {
-						break outer
-					}
-				}
+		if a.Paired != b.Paired {
+			if a.Paired {
+				return -1
 			}
-		}
-	}
-}
 
-func Query(conn net.Conn, query string, _ bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
-	start := time.Now()
-	entries := []*pb.QueryResponse_Item{}
+			return 1
+		}
 
-	getDevices()
+		return strings.Compare(a.Name, b.Name)
+	})
 
-	for k, v := range devices {
+	for k, v := range list {
 		s := []string{}
 		a := []string{}
 
@@ -218,22 +224,37 @@ func Query(conn net.Conn, query string, _ bool, exact bool, _ uint8) []*pb.Query
 			a = append(a, ActionRemove)
 
 			if v.Trusted {
+				s = append(s, "trusted")
 				a = append(a, ActionUntrust)
 			} else {
 				a = append(a, ActionTrust)
+			}
 
-				if v.Connected {
-					a = append(a, ActionDisconnect)
-				} else {
-					s = append(s, "disconnected")
-					a = append(s, ActionConnect)
-				}
+			if v.Connected {
+				s = append(s, "connected")
+				a = append(a, ActionDisconnect)
+			} else {
+				s = append(s, "disconnected")
+				a = append(a, ActionConnect)
 			}
 		} else {
 			s = append(s, "unpaired")
 			a = append(a, ActionPair)
 		}
 
+		sub := v.Mac
+		if v.Battery >= 0 {
+			sub = fmt.Sprintf("%s - %d%%", v.Mac, v.Battery)
+		}
+
+		if p := progress[v.Path]; p != "" {
+			s = append(s, p)
+			sub = fmt.Sprintf("%s - %s...", v.Mac, p)
+		} else if err := failures[v.Path]; err != "" {
+			s = append(s, "failed")
+			sub = fmt.Sprintf("%s - failed: %s", v.Mac, err)
+		}
+
 		e := &pb.QueryResponse_Item{
 			Identifier: v.Mac,
 			Score:      1000 - int32(k),
@@ -241,7 +262,7 @@ func Query(conn net.Conn, query string, _ bool, exact bool, _ uint8) []*pb.Query
 			Actions:    a,
 			Icon:       v.Icon,
 			Text:       v.Name,
-			Subtext:    v.Mac,
+			Subtext:    sub,
 			Provider:   Name,
 			Type:       pb.QueryResponse_REGULAR,
 		}
@@ -275,99 +296,46 @@ func HideFromProviderlist() bool {
 }
 
 func State(provider string) *pb.ProviderStateResponse {
-	if !find {
+	a, ok := currentAdapter()
+	if !ok {
 		return &pb.ProviderStateResponse{
-			States:   []string{},
-			Actions:  []string{ActionFind},
-			Provider: "",
+			States: []string{"no_adapter"},
 		}
 	}
 
-	return &pb.ProviderStateResponse{}
-}
+	states := []string{fmt.Sprintf("adapter:%s", a.Name)}
+	actions := []string{}
 
-func getDevices() {
-	devices = []Device{}
+	if a.Powered {
+		states = append(states, "powered")
+		actions = append(actions, ActionPowerOff)
 
-	if find {
-		cmd := exec.Command("bluetoothctl", "--timeout", "5", "scan", "on")
-		out, err := cmd.CombinedOutput()
-		if err != nil {
-			slog.Error(Name, "find devices", err)
-			return
+		if a.Discovering {
+			states = append(states, "discovering")
+			actions = append(actions, ActionStopFind)
+		} else {
+			actions = append(actions, ActionFind)
 		}
 
-		for l := range strings.Lines(string(out)) {
-			if strings.Contains(l, "Device") {
-				f := strings.SplitN(l, " ", 4)
-
-				d := Device{
-					Name: strings.TrimSpace(f[3]),
-					Mac:  f[2],
-				}
-
-				devices = append(devices, d)
-			}
+		if a.Discoverable {
+			states = append(states, "discoverable")
+			actions = append(actions, ActionDiscoverableOff)
+		} else {
+			actions = append(actions, ActionDiscoverableOn)
 		}
-
-		find = false
-
-		cmd = exec.Command("bluetoothctl", "scan", "off")
-		cmd.Run()
-
-		return
+	} else {
+		states = append(states, "unpowered")
+		actions = append(actions, ActionPowerOn)
 	}
 
-	devices = []Device{}
-
-	cmd := exec.Command("bluetoothctl", "devices", "Paired")
-
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		slog.Error(Name, "get devices", err)
+	for _, v := range adapterNames() {
+		if v != a.Name {
+			actions = append(actions, ActionSelectAdapter+v)
+		}
 	}
 
-	for v := range strings.Lines(string(out)) {
-		if strings.Contains(v, "Device") {
-			fields := strings.SplitN(v, " ", 3)
-			d := Device{
-				Name: strings.TrimSpace(fields[2]),
-				Mac:  fields[1],
-			}
-
-			cmd := exec.Command("bluetoothctl", "info", d.Mac)
-			out, err := cmd.CombinedOutput()
-			if err != nil {
-				slog.Error(Name, "get info", err)
-			}
-
-			for l := range strings.Lines(string(out)) {
-				if strings.HasPrefix(strings.TrimSpace(l), "Icon") {
-					d.Icon = strings.TrimPrefix(strings.TrimSpace(l), "Icon: ")
-				}
-
-				if strings.HasPrefix(strings.TrimSpace(l), "Paired") {
-					if strings.Contains(l, "yes") {
-						d.Paired = true
-					}
-				}
-
-				if strings.HasPrefix(strings.TrimSpace(l), "Connected") {
-					if strings.Contains(l, "yes") {
-						d.Connected = true
-					}
-				}
-
-				if strings.HasPrefix(strings.TrimSpace(l), "Trusted") {
-					if strings.Contains(l, "yes") {
-						d.Trusted = true
-					}
-				}
-			}
-
-			if d.Paired {
-				devices = append(devices, d)
-			}
-		}
+	return &pb.ProviderStateResponse{
+		States:  states,
+		Actions: actions,
 	}
 }
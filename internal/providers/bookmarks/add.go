@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+const ActionAdd = "add"
+
+var (
+	client = http.Client{Timeout: 5 * time.Second}
+	title  = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+)
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionOpen, Label: "Open", Icon: "web-browser", Default: true},
+		{Action: ActionDelete, Label: "Delete", Icon: "edit-delete"},
+		{Action: ActionAdd, Label: "Add bookmark", Icon: "bookmark-new", Arguments: []*pb.ActionArgument{
+			{Name: "url", Type: util.ArgumentText, Placeholder: "URL, defaults to the clipboard"},
+		}},
+	}
+}
+
+// add bookmarks the URL from the arguments, the query or the clipboard, in that order. The title is
+// fetched from the page.
+func add(args, query string) {
+	candidates := []string{args, query, common.ClipboardText()}
+
+	var link string
+
+	for _, v := range candidates {
+		if u, ok := parseURL(v); ok {
+			link = u
+			break
+		}
+	}
+
+	if link == "" {
+		slog.Error(Name, "add", "no URL in arguments, query or clipboard")
+		return
+	}
+
+	for _, v := range bookmarks {
+		if normalizeURL(v.URL) == normalizeURL(link) {
+			slog.Info(Name, "add", fmt.Sprintf("already bookmarked: %s", link))
+			return
+		}
+	}
+
+	b := Bookmark{
+		URL:         link,
+		Description: link,
+		CreatedAt:   time.Now(),
+	}
+
+	if config.FetchTitles {
+		if t, err := fetchTitle(link); err != nil {
+			slog.Error(Name, "fetch title", err)
+		} else if t != "" {
+			b.Description = t
+		}
+	}
+
+	bookmarks = append([]Bookmark{b}, bookmarks...)
+
+	saveBookmarks()
+
+	handlers.ProviderUpdated <- Name
+}
+
+// parseURL returns the value as http(s) URL, if it looks like one. Missing schemes default to https.
+func parseURL(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+
+	if value == "" || strings.ContainsAny(value, " \n\t") {
+		return "", false
+	}
+
+	if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+		value = "https://" + value
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || !strings.Contains(u.Host, ".") {
+		return "", false
+	}
+
+	return u.String(), true
+}
+
+// fetchTitle returns the title of the page. Semicolons are replaced, as they separate the columns of the
+// bookmarks file.
+func fetchTitle(link string) (string, error) {
+	resp, err := client.Get(link)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", link, resp.Status)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
+	if err != nil {
+		return "", err
+	}
+
+	m := title.FindSubmatch(b)
+	if m == nil {
+		return "", nil
+	}
+
+	res := strings.Join(strings.Fields(html.UnescapeString(string(m[1]))), " ")
+
+	return strings.ReplaceAll(res, ";", ","), nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	_ "embed"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"os"
@@ -45,6 +46,7 @@ type Config struct {
 	SetBrowserOnImport bool       `koanf:"set_browser_on_import" desc:"set browser name on imported bookmarks" default:"false"`
 	History            bool       `koanf:"history" desc:"make use of history for sorting" default:"true"`
 	HistoryWhenEmpty   bool       `koanf:"history_when_empty" desc:"consider history when query is empty" default:"false"`
+	FetchTitles        bool       `koanf:"fetch_titles" desc:"fetch the title of new bookmarks without description" default:"true"`
 	w                  *git.Worktree
 	r                  *git.Repository
 }
@@ -65,6 +67,14 @@ func (config *Config) SetRepository(val *git.Repository) {
 	config.r = val
 }
 
+func (config *Config) GitReload() {
+	loadMu.Lock()
+	loaded = false
+	loadMu.Unlock()
+
+	loadBookmarks()
+}
+
 type Category struct {
 	Name   string `koanf:"name" desc:"name for category" default:""`
 	Prefix string `koanf:"prefix" desc:"prefix to store item in category" default:""`
@@ -274,6 +284,7 @@ func Setup() {
 		},
 		Location:           "",
 		SetBrowserOnImport: false,
+		FetchTitles:        true,
 	}
 
 	common.LoadConfig(Name, config)
@@ -310,13 +321,17 @@ func Available() bool {
 	return true
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if common.GitResolve(Name, action) {
+		return
+	}
+
 	i, _ := strconv.Atoi(identifier)
 
 	switch action {
@@ -328,6 +343,9 @@ func Activate(single bool, identifier, action string, query string, args string,
 			importBrowserBookmarks()
 			return
 		}
+	case ActionAdd:
+		add(args, query)
+		return
 	case ActionSave:
 		if after, ok := strings.CutPrefix(identifier, "CREATE:"); ok {
 			creating = false
@@ -409,7 +427,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 			command = fmt.Sprintf("%s %s", command, shellescape.Quote(bookmarks[i].URL))
 		}
 
-		cmd := exec.Command("sh", "-c", command)
+		cmd := common.HostShell(command)
 		err := cmd.Start()
 		if err != nil {
 			slog.Error(Name, "open", err)
@@ -435,6 +453,15 @@ func Activate(single bool, identifier, action string, query string, args string,
 func store(query string) {
 	b := Bookmark{}
 	b.fromQuery(query)
+
+	if config.FetchTitles && b.Description == b.URL {
+		if t, err := fetchTitle(b.URL); err != nil {
+			slog.Error(Name, "fetch title", err)
+		} else if t != "" {
+			b.Description = t
+		}
+	}
+
 	bookmarks = append([]Bookmark{b}, bookmarks...)
 
 	saveBookmarks()
@@ -766,7 +793,7 @@ func HideFromProviderlist() bool {
 }
 
 func State(provider string) *pb.ProviderStateResponse {
-	actions := []string{ActionImport}
+	actions := []string{ActionImport, ActionAdd}
 	states := []string{}
 
 	if creating {
@@ -777,6 +804,10 @@ func State(provider string) *pb.ProviderStateResponse {
 		actions = append(actions, ActionCreate)
 	}
 
+	gitStates, gitActions := common.GitState(Name)
+	states = append(states, gitStates...)
+	actions = append(actions, gitActions...)
+
 	return &pb.ProviderStateResponse{
 		States:  states,
 		Actions: actions,
@@ -0,0 +1,250 @@
+// Package browsertabs lists and focuses open tabs of web browsers.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "browsertabs"
+	NamePretty = "Browser Tabs"
+	config     *Config
+	btPath     string
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Endpoints     []string `koanf:"endpoints" desc:"remote debugging endpoints of Chromium based browsers" default:"[\"http://127.0.0.1:9222\"]"`
+	Brotab        bool     `koanf:"brotab" desc:"list tabs via the brotab extension, if 'bt' is installed" default:"true"`
+	Copy          string   `koanf:"copy" desc:"command to copy. supports %VALUE%." default:"wl-copy, xclip on X11, pbcopy on macOS, clip on Windows"`
+}
+
+const (
+	ActionFocus   = "focus"
+	ActionClose   = "close"
+	ActionCopyURL = "copy_url"
+)
+
+var (
+	mu sync.Mutex
+	// tabs of the last query by identifier
+	tabs = make(map[string]Tab)
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "web-browser",
+			MinScore: 30,
+		},
+		Endpoints: []string{"http://127.0.0.1:9222"},
+		Brotab:    true,
+		Copy:      common.CopyCommand(),
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	if p, err := exec.LookPath("bt"); err == nil {
+		btPath = p
+	}
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionFocus, Label: "Focus", Icon: "go-jump", Default: true},
+		{Action: ActionClose, Label: "Close tab", Icon: "tab-close"},
+		{Action: ActionCopyURL, Label: "Copy URL", Icon: "edit-copy"},
+	}
+}
+
+func tabIdentifier(t Tab) string {
+	return fmt.Sprintf("%s/%s", t.Source, t.ID)
+}
+
+func source(name string) (Source, bool) {
+	for _, v := range sources() {
+		if v.Name() == name {
+			return v, true
+		}
+	}
+
+	return nil, false
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	mu.Lock()
+	tab, ok := tabs[identifier]
+	mu.Unlock()
+
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown tab: %s", identifier))
+		return
+	}
+
+	if action == "" {
+		action = ActionFocus
+	}
+
+	if action == ActionCopyURL {
+		cmd := common.ReplaceResultOrStdinCmd(config.Copy, tab.URL)
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "activate", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+
+		return
+	}
+
+	s, ok := source(tab.Source)
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown source: %s", tab.Source))
+		return
+	}
+
+	switch action {
+	case ActionFocus:
+		if err := s.Focus(tab.ID); err != nil {
+			slog.Error(Name, "activate", err)
+		}
+	case ActionClose:
+		if err := s.Close(tab.ID); err != nil {
+			slog.Error(Name, "activate", err)
+			return
+		}
+
+		handlers.ProviderUpdated <- Name
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+// list fetches the tabs of all sources concurrently. Browsers that aren't running are skipped.
+func list() []Tab {
+	src := sources()
+	res := make([][]Tab, len(src))
+
+	var wg sync.WaitGroup
+
+	for k, v := range src {
+		wg.Go(func() {
+			t, err := v.Tabs()
+			if err != nil {
+				slog.Debug(Name, "source", v.Name(), "error", err)
+				return
+			}
+
+			res[k] = t
+		})
+	}
+
+	wg.Wait()
+
+	all := []Tab{}
+
+	for _, v := range res {
+		all = append(all, v...)
+	}
+
+	return all
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	start := time.Now()
+	entries := []*pb.QueryResponse_Item{}
+
+	current := list()
+	found := make(map[string]Tab, len(current))
+
+	for k, v := range current {
+		id := tabIdentifier(v)
+		found[id] = v
+
+		e := &pb.QueryResponse_Item{
+			Identifier: id,
+			Text:       v.Title,
+			Subtext:    v.URL,
+			Icon:       config.Icon,
+			Provider:   Name,
+			Actions:    []string{ActionFocus, ActionClose, ActionCopyURL},
+			Score:      int32(len(current) - k),
+			Type:       pb.QueryResponse_REGULAR,
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, v.Title, exact)
+			field := "text"
+
+			if urlScore, urlPos, urlStart := common.FuzzyScore(query, v.URL, exact); urlScore > score {
+				score, pos, start = urlScore, urlPos, urlStart
+				field = "subtext"
+			}
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     field,
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	mu.Lock()
+	tabs = found
+	mu.Unlock()
+
+	slog.Debug(Name, "query", time.Since(start))
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
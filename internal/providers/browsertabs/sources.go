@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// Tab is an open tab of a browser.
+type Tab struct {
+	// ID of the tab within its source.
+	ID     string
+	Title  string
+	URL    string
+	Source string
+}
+
+// Source lists and controls the tabs of one or more browsers.
+type Source interface {
+	Name() string
+	Tabs() ([]Tab, error)
+	Focus(id string) error
+	Close(id string) error
+}
+
+var client = http.Client{Timeout: 2 * time.Second}
+
+// devtools uses the HTTP endpoints of the remote debugging protocol of Chromium based browsers, started
+// with f.e. --remote-debugging-port=9222.
+type devtools struct {
+	endpoint string
+}
+
+func (d devtools) Name() string {
+	u, err := url.Parse(d.endpoint)
+	if err != nil {
+		return d.endpoint
+	}
+
+	return u.Host
+}
+
+func (d devtools) get(path string, v any) error {
+	resp, err := client.Get(fmt.Sprintf("%s/json/%s", strings.TrimSuffix(d.endpoint, "/"), path))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s %s", path, resp.Status, strings.TrimSpace(string(b)))
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (d devtools) Tabs() ([]Tab, error) {
+	targets := []struct {
+		ID    string `json:"id"`
+		Type  string `json:"type"`
+		Title string `json:"title"`
+		URL   string `json:"url"`
+	}{}
+
+	if err := d.get("list", &targets); err != nil {
+		return nil, err
+	}
+
+	res := []Tab{}
+
+	for _, v := range targets {
+		if v.Type != "page" {
+			continue
+		}
+
+		res = append(res, Tab{
+			ID:     v.ID,
+			Title:  v.Title,
+			URL:    v.URL,
+			Source: d.Name(),
+		})
+	}
+
+	return res, nil
+}
+
+func (d devtools) Focus(id string) error {
+	return d.get(fmt.Sprintf("activate/%s", url.PathEscape(id)), nil)
+}
+
+func (d devtools) Close(id string) error {
+	return d.get(fmt.Sprintf("close/%s", url.PathEscape(id)), nil)
+}
+
+// brotab uses the brotab companion extension, which talks to its client 'bt' via native messaging. Works
+// for Firefox and Chromium based browsers.
+type brotab struct{}
+
+func (brotab) Name() string {
+	return "brotab"
+}
+
+func (brotab) Tabs() ([]Tab, error) {
+	out, err := common.Exec(Name, exec.Command(btPath, "list"), common.ExecOptions{CacheFor: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Tab{}
+
+	// <prefix>.<window>.<tab>\t<title>\t<url>
+	for line := range strings.Lines(string(out)) {
+		fields := strings.SplitN(strings.TrimRight(line, "\n"), "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		res = append(res, Tab{
+			ID:     fields[0],
+			Title:  fields[1],
+			URL:    fields[2],
+			Source: "brotab",
+		})
+	}
+
+	return res, nil
+}
+
+func (brotab) Focus(id string) error {
+	return run(exec.Command(btPath, "activate", "--focused", id))
+}
+
+func (brotab) Close(id string) error {
+	return run(exec.Command(btPath, "close", id))
+}
+
+func run(cmd *exec.Cmd) error {
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", strings.Join(cmd.Args, " "), err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func sources() []Source {
+	res := []Source{}
+
+	if config.Brotab && btPath != "" {
+		res = append(res, brotab{})
+	}
+
+	for _, v := range config.Endpoints {
+		res = append(res, devtools{endpoint: v})
+	}
+
+	return res
+}
@@ -0,0 +1,80 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	BackendLogind  = "logind"
+	BackendCommand = "command"
+)
+
+// inhibitor keeps the system from going idle until released.
+type inhibitor interface {
+	inhibit() error
+	release() error
+}
+
+// logind takes an idle inhibitor lock of systemd-logind. The lock is held as long as the file descriptor
+// is open.
+type logind struct {
+	fd *os.File
+}
+
+func (l *logind) inhibit() error {
+	bus, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return err
+	}
+	defer bus.Close()
+
+	var fd dbus.UnixFD
+
+	err = bus.Object("org.freedesktop.login1", "/org/freedesktop/login1").
+		Call("org.freedesktop.login1.Manager.Inhibit", 0, "idle", "elephant", "Caffeine", "block").
+		Store(&fd)
+	if err != nil {
+		return err
+	}
+
+	l.fd = os.NewFile(uintptr(fd), "inhibitor")
+
+	return nil
+}
+
+func (l *logind) release() error {
+	if l.fd == nil {
+		return nil
+	}
+
+	err := l.fd.Close()
+	l.fd = nil
+
+	return err
+}
+
+// command runs configured commands, f.e. to pause swayidle or hypridle, which don't respect logind
+// inhibitors.
+type command struct{}
+
+func (command) inhibit() error {
+	if config.InhibitCommand == "" {
+		return errors.New("inhibit_command not set")
+	}
+
+	return common.HostShell(config.InhibitCommand).Run()
+}
+
+func (command) release() error {
+	if config.ReleaseCommand == "" {
+		return errors.New("release_command not set")
+	}
+
+	return common.HostShell(config.ReleaseCommand).Run()
+}
@@ -0,0 +1,383 @@
+//go:build linux
+
+// Package caffeine keeps the system awake by inhibiting idle.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "caffeine"
+	NamePretty = "Caffeine"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config  `koanf:",squash"`
+	Backend        string   `koanf:"backend" desc:"how to inhibit idle. 'logind' or 'command'." default:"logind"`
+	InhibitCommand string   `koanf:"inhibit_command" desc:"command to inhibit idle for the command backend, f.e. 'pkill -STOP swayidle'" default:""`
+	ReleaseCommand string   `koanf:"release_command" desc:"command to release the inhibit for the command backend, f.e. 'pkill -CONT swayidle'" default:""`
+	Durations      []string `koanf:"durations" desc:"preset durations to list" default:"[\"15m\", \"30m\", \"1h\", \"2h\"]"`
+	Notify         bool     `koanf:"notify" desc:"show a notification when a timed inhibit ends" default:"true"`
+}
+
+const (
+	ActionStart = "start"
+	ActionStop  = "stop"
+
+	// identifier of the item inhibiting until stopped
+	indefinitely = "indefinitely"
+)
+
+var (
+	mu       sync.Mutex
+	backend  inhibitor
+	active   bool
+	deadline time.Time
+	// stops the timer of the current inhibit
+	cancel chan struct{}
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "caffeine-cup-full",
+			MinScore: 30,
+		},
+		Backend:   BackendLogind,
+		Durations: []string{"15m", "30m", "1h", "2h"},
+		Notify:    true,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	switch config.Backend {
+	case BackendCommand:
+		backend = command{}
+	default:
+		backend = &logind{}
+	}
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+var duration = &pb.ActionArgument{Name: "duration", Type: util.ArgumentText, Placeholder: "Duration, f.e. 45m"}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionStart, Label: "Keep awake", Icon: "caffeine-cup-full", Default: true, Arguments: []*pb.ActionArgument{duration}},
+		{Action: ActionStop, Label: "Stop", Icon: "caffeine-cup-empty"},
+	}
+}
+
+// parseDuration parses durations like "45m" or "1h30m". Plain numbers are minutes.
+func parseDuration(s string) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+
+	if s == "" || s == indefinitely {
+		return 0, s == indefinitely
+	}
+
+	if i, err := strconv.Atoi(s); err == nil {
+		s = fmt.Sprintf("%dm", i)
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+
+	return d, true
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionStart
+
+		if identifier == ActionStop {
+			action = ActionStop
+		}
+	}
+
+	switch action {
+	case ActionStop:
+		stop()
+	case ActionStart:
+		value := identifier
+		if args != "" {
+			value = args
+		}
+
+		d, ok := parseDuration(value)
+		if !ok {
+			slog.Error(Name, "activate", fmt.Sprintf("invalid duration: %s", value))
+			return
+		}
+
+		if err := start(d); err != nil {
+			slog.Error(Name, "activate", err)
+			return
+		}
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	handlers.ProviderUpdated <- Name
+}
+
+// start inhibits idle for the duration, or until stopped if it's 0. A running inhibit is extended or
+// shortened instead of taken again.
+func start(d time.Duration) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !active {
+		if err := backend.inhibit(); err != nil {
+			return err
+		}
+
+		active = true
+	}
+
+	if cancel != nil {
+		close(cancel)
+		cancel = nil
+	}
+
+	deadline = time.Time{}
+
+	if d > 0 {
+		deadline = time.Now().Add(d)
+		cancel = make(chan struct{})
+		go countdown(deadline, cancel)
+	}
+
+	return nil
+}
+
+func stop() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	release()
+}
+
+// release expects mu to be locked.
+func release() {
+	if cancel != nil {
+		close(cancel)
+		cancel = nil
+	}
+
+	if !active {
+		return
+	}
+
+	if err := backend.release(); err != nil {
+		slog.Error(Name, "release", err)
+	}
+
+	active = false
+	deadline = time.Time{}
+}
+
+// countdown updates the remaining time every minute and releases the inhibit at the deadline.
+func countdown(until time.Time, done chan struct{}) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	timer := time.NewTimer(time.Until(until))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			handlers.ProviderUpdated <- Name
+		case <-timer.C:
+			mu.Lock()
+			if cancel == done {
+				cancel = nil
+				release()
+			}
+			mu.Unlock()
+
+			handlers.ProviderUpdated <- Name
+
+			if config.Notify {
+				_, err := common.Notify(common.Notification{
+					Title:   "Caffeine",
+					Body:    "The system can go idle again",
+					Icon:    "caffeine-cup-empty",
+					Urgency: "low",
+				})
+				if err != nil {
+					slog.Error(Name, "notify", err)
+				}
+			}
+
+			return
+		}
+	}
+}
+
+func label(d time.Duration) string {
+	if d == 0 {
+		return "Keep awake"
+	}
+
+	return fmt.Sprintf("Keep awake for %s", formatDuration(d))
+}
+
+// formatDuration formats durations without trailing zero units, f.e. "1h" instead of "1h0m0s".
+func formatDuration(d time.Duration) string {
+	s := d.Round(time.Second).String()
+
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+
+	return s
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	mu.Lock()
+	isActive, until := active, deadline
+	mu.Unlock()
+
+	if isActive {
+		subtext := "active until stopped"
+		if !until.IsZero() {
+			subtext = fmt.Sprintf("%s remaining", formatDuration(time.Until(until).Round(time.Minute)))
+		}
+
+		entries = append(entries, &pb.QueryResponse_Item{
+			Identifier: ActionStop,
+			Text:       "Stop keeping awake",
+			Subtext:    subtext,
+			Icon:       "caffeine-cup-empty",
+			Provider:   Name,
+			Actions:    []string{ActionStop},
+			State:      []string{"active"},
+			Score:      1000,
+			Type:       pb.QueryResponse_REGULAR,
+		})
+	}
+
+	// a duration in the query, f.e. "45m", gets its own item
+	if fields := strings.Fields(query); len(fields) > 0 {
+		if d, ok := parseDuration(fields[len(fields)-1]); ok && d > 0 {
+			entries = append(entries, &pb.QueryResponse_Item{
+				Identifier: d.String(),
+				Text:       label(d),
+				Icon:       config.Icon,
+				Provider:   Name,
+				Actions:    []string{ActionStart},
+				Score:      900,
+				Type:       pb.QueryResponse_REGULAR,
+			})
+
+			return entries
+		}
+	}
+
+	presets := append([]string{indefinitely}, config.Durations...)
+
+	for k, v := range presets {
+		d, ok := parseDuration(v)
+		if !ok {
+			slog.Error(Name, "durations", fmt.Sprintf("invalid duration: %s", v))
+			continue
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier: v,
+			Text:       label(d),
+			Icon:       config.Icon,
+			Provider:   Name,
+			Actions:    []string{ActionStart},
+			Score:      int32(len(presets) - k),
+			Type:       pb.QueryResponse_REGULAR,
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, e.Text, exact)
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if active {
+		return &pb.ProviderStateResponse{
+			States:  []string{"active"},
+			Actions: []string{ActionStop},
+		}
+	}
+
+	return &pb.ProviderStateResponse{
+		States: []string{"inactive"},
+	}
+}
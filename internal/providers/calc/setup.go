@@ -6,11 +6,13 @@ import (
 	"encoding/gob"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
 	"time"
@@ -45,7 +47,7 @@ type Config struct {
 	Placeholder   string `koanf:"placeholder" desc:"placeholder to display for async update" default:"calculating..."`
 	RequireNumber bool   `koanf:"require_number" desc:"don't perform if query does not contain a number" default:"true"`
 	MinChars      int    `koanf:"min_chars" desc:"don't perform if query is shorter than min_chars" default:"3"`
-	Command       string `koanf:"command" desc:"default command to be executed. supports %VALUE%." default:"wl-copy -n %VALUE%"`
+	Command       string `koanf:"command" desc:"default command to be executed. supports %VALUE%." default:"wl-copy -n %VALUE%, xclip on X11, pbcopy on macOS, clip on Windows"`
 	Async         bool   `koanf:"async" desc:"calculation will be send async" default:"true"`
 	Autosave      bool   `koanf:"autosave" desc:"automatically save results" default:"false"`
 }
@@ -72,6 +74,10 @@ func Setup() {
 		Autosave:      false,
 	}
 
+	if runtime.GOOS != "linux" || common.X11() {
+		config.Command = common.CopyCommand()
+	}
+
 	common.LoadConfig(Name, config)
 
 	if config.NamePretty != "" {
@@ -103,10 +109,10 @@ func Available() bool {
 	return true
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
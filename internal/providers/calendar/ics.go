@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+// Event is a VEVENT. Recurring events are expanded into occurrences.
+type Event struct {
+	UID          string
+	Summary      string
+	Location     string
+	Description  string
+	URL          string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID time.Time
+	Cancelled    bool
+	Calendar     string
+	// Conference is a url set by the calendar, f.e. X-GOOGLE-CONFERENCE.
+	Conference string
+}
+
+// Occurrence is a single instance of an event.
+type Occurrence struct {
+	Event
+	Start time.Time
+	End   time.Time
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func unfold(r io.Reader) []string {
+	res := []string{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(res) > 0 {
+			res[len(res)-1] += line[1:]
+			continue
+		}
+
+		res = append(res, line)
+	}
+
+	return res
+}
+
+func parseProperty(line string) (property, bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return property{}, false
+	}
+
+	parts := strings.Split(head, ";")
+
+	p := property{
+		name:   strings.ToUpper(parts[0]),
+		params: make(map[string]string),
+		value:  value,
+	}
+
+	for _, v := range parts[1:] {
+		if k, val, ok := strings.Cut(v, "="); ok {
+			p.params[strings.ToUpper(k)] = strings.Trim(val, `"`)
+		}
+	}
+
+	return p, true
+}
+
+func unescape(s string) string {
+	r := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return strings.TrimSpace(r.Replace(s))
+}
+
+// parseTime handles UTC, floating, TZID based and date only values. Unknown time zones fall back to local time.
+func parseTime(p property) (time.Time, bool, error) {
+	if p.params["VALUE"] == "DATE" || len(p.value) == 8 {
+		t, err := time.ParseInLocation("20060102", p.value, time.Local)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(p.value, "Z") {
+		t, err := time.Parse("20060102T150405Z", p.value)
+		return t.Local(), false, err
+	}
+
+	loc := time.Local
+
+	if tzid := p.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", p.value, loc)
+
+	return t, false, err
+}
+
+var durationRe = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration parses RFC 5545 durations, f.e. "PT1H30M".
+func parseDuration(s string) time.Duration {
+	m := durationRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+
+	var d time.Duration
+
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if n, err := strconv.Atoi(m[i+2]); err == nil {
+			d += time.Duration(n) * unit
+		}
+	}
+
+	if m[1] == "-" {
+		d = -d
+	}
+
+	return d
+}
+
+func parseEvents(r io.Reader, calendar string) []Event {
+	res := []Event{}
+
+	var e *Event
+	var duration time.Duration
+	depth := 0
+
+	for _, line := range unfold(r) {
+		p, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case p.name == "BEGIN" && p.value == "VEVENT":
+			e = &Event{Calendar: calendar}
+			duration = 0
+			depth = 0
+			continue
+		case p.name == "BEGIN" && e != nil:
+			// nested components, f.e. VALARM
+			depth++
+			continue
+		case p.name == "END" && e != nil && depth > 0:
+			depth--
+			continue
+		case p.name == "END" && p.value == "VEVENT" && e != nil:
+			if e.End.IsZero() {
+				switch {
+				case duration != 0:
+					e.End = e.Start.Add(duration)
+				case e.AllDay:
+					e.End = e.Start.AddDate(0, 0, 1)
+				default:
+					e.End = e.Start
+				}
+			}
+
+			if !e.Start.IsZero() {
+				res = append(res, *e)
+			}
+
+			e = nil
+			continue
+		}
+
+		if e == nil || depth > 0 {
+			continue
+		}
+
+		switch p.name {
+		case "UID":
+			e.UID = p.value
+		case "SUMMARY":
+			e.Summary = unescape(p.value)
+		case "LOCATION":
+			e.Location = unescape(p.value)
+		case "DESCRIPTION":
+			e.Description = unescape(p.value)
+		case "URL":
+			e.URL = p.value
+		case "X-GOOGLE-CONFERENCE", "X-MICROSOFT-SKYPETEAMSMEETINGURL":
+			e.Conference = p.value
+		case "STATUS":
+			e.Cancelled = strings.EqualFold(p.value, "CANCELLED")
+		case "RRULE":
+			e.RRule = p.value
+		case "DURATION":
+			duration = parseDuration(p.value)
+		case "DTSTART", "DTEND", "RECURRENCE-ID":
+			t, allDay, err := parseTime(p)
+			if err != nil {
+				slog.Debug(Name, "parse", err, "calendar", calendar)
+				continue
+			}
+
+			switch p.name {
+			case "DTSTART":
+				e.Start, e.AllDay = t, allDay
+			case "DTEND":
+				e.End = t
+			case "RECURRENCE-ID":
+				e.RecurrenceID = t
+			}
+		case "EXDATE":
+			for v := range strings.SplitSeq(p.value, ",") {
+				if t, _, err := parseTime(property{params: p.params, value: v}); err == nil {
+					e.ExDates = append(e.ExDates, t)
+				}
+			}
+		}
+	}
+
+	return res
+}
+
+// occurrences expands the events into the instances overlapping the given range. Modified instances
+// (RECURRENCE-ID) replace the original ones.
+func occurrences(events []Event, from, to time.Time) []Occurrence {
+	res := []Occurrence{}
+
+	overrides := make(map[string][]time.Time)
+
+	for _, e := range events {
+		if !e.RecurrenceID.IsZero() {
+			overrides[e.UID] = append(overrides[e.UID], e.RecurrenceID)
+		}
+	}
+
+	for _, e := range events {
+		if e.Cancelled {
+			continue
+		}
+
+		duration := e.End.Sub(e.Start)
+
+		if e.RRule == "" || !e.RecurrenceID.IsZero() {
+			if e.End.After(from) && e.Start.Before(to) || e.Start.Equal(from) {
+				res = append(res, Occurrence{Event: e, Start: e.Start, End: e.End})
+			}
+
+			continue
+		}
+
+		opt, err := rrule.StrToROption(e.RRule)
+		if err != nil {
+			slog.Debug(Name, "rrule", err, "event", e.Summary)
+			continue
+		}
+
+		opt.Dtstart = e.Start
+
+		r, err := rrule.NewRRule(*opt)
+		if err != nil {
+			slog.Debug(Name, "rrule", err, "event", e.Summary)
+			continue
+		}
+
+		set := rrule.Set{}
+		set.RRule(r)
+
+		for _, v := range slices.Concat(e.ExDates, overrides[e.UID]) {
+			set.ExDate(v)
+		}
+
+		for _, start := range set.Between(from.Add(-duration), to, true) {
+			end := start.Add(duration)
+
+			if end.After(from) || start.Equal(from) {
+				res = append(res, Occurrence{Event: e, Start: start, End: end})
+			}
+		}
+	}
+
+	return res
+}
+
+// readCalendars reads an .ics file or all .ics files in a directory, f.e. a vdirsyncer storage.
+func readCalendars(path string) []Event {
+	res := []Event{}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		slog.Error(Name, "read", err)
+		return res
+	}
+
+	files := []string{path}
+	calendar := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	if info.IsDir() {
+		files = []string{}
+
+		filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".ics") {
+				files = append(files, p)
+			}
+
+			return nil
+		})
+	}
+
+	for _, v := range files {
+		f, err := os.Open(v)
+		if err != nil {
+			slog.Error(Name, "read", err)
+			continue
+		}
+
+		name := calendar
+		if info.IsDir() {
+			name = filepath.Base(filepath.Dir(v))
+		}
+
+		res = append(res, parseEvents(f, name)...)
+		f.Close()
+	}
+
+	return res
+}
+
+// khalPaths reads the calendar paths from khals config. Discover paths are globs.
+func khalPaths() []string {
+	res := []string{}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return res
+	}
+
+	f, err := os.Open(filepath.Join(dir, "khal", "config"))
+	if err != nil {
+		return res
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	section := ""
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") && !strings.HasPrefix(line, "[[") {
+			section = strings.Trim(line, "[]")
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if section != "calendars" || !ok || strings.TrimSpace(k) != "path" {
+			continue
+		}
+
+		path := expand(strings.TrimSpace(v))
+
+		matches, err := filepath.Glob(path)
+		if err != nil || len(matches) == 0 {
+			res = append(res, path)
+			continue
+		}
+
+		res = append(res, matches...)
+	}
+
+	return res
+}
+
+func expand(path string) string {
+	if after, ok := strings.CutPrefix(path, "~/"); ok {
+		home, _ := os.UserHomeDir()
+		path = filepath.Join(home, after)
+	}
+
+	return path
+}
@@ -0,0 +1,395 @@
+// Package calendar provides upcoming events from .ics files and khal/vdirsyncer collections.
+package main
+
+import (
+	"crypto/md5"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "calendar"
+	NamePretty = "Calendar"
+	config     *Config
+	mu         sync.RWMutex
+	events     = []Event{}
+	loaded     time.Time
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Paths         []string `koanf:"paths" desc:"ics files or directories containing them, f.e. a vdirsyncer storage" default:""`
+	Khal          bool     `koanf:"khal" desc:"read the calendars configured in khal" default:"true"`
+	Days          int      `koanf:"days" desc:"how many days ahead to list events" default:"14"`
+	ReloadEvery   int      `koanf:"reload_every" desc:"minutes after which calendars get re-read on query" default:"5"`
+	Copy          string   `koanf:"copy" desc:"command to copy. supports %VALUE%." default:"wl-copy, xclip on X11, pbcopy on macOS, clip on Windows"`
+	Open          string   `koanf:"open" desc:"command to open a meeting link. supports %VALUE%." default:"xdg-open"`
+}
+
+const (
+	ActionCopy     = "copy"
+	ActionCopyLink = "copy_link"
+	ActionOpenLink = "open_link"
+	ActionRefresh  = "refresh"
+)
+
+// meetingRe matches links of common video-call services.
+var meetingRe = regexp.MustCompile(`https://(?:[\w-]+\.)?(?:zoom\.us/(?:j|my|w)/|meet\.google\.com/|teams\.microsoft\.com/l/meetup-join/|teams\.live\.com/meet/|[\w-]+\.webex\.com/|meet\.jit\.si/|whereby\.com/|bluejeans\.com/|meet\.goto\.com/|app\.gather\.town/|discord\.gg/)[^\s"'<>)\]]*`)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "x-office-calendar",
+			MinScore: 30,
+		},
+		Khal:        true,
+		Days:        14,
+		ReloadEvery: 5,
+		Copy:        common.CopyCommand(),
+		Open:        "xdg-open",
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	load()
+}
+
+// Refresh re-reads all calendars.
+func Refresh() {
+	load()
+}
+
+func paths() []string {
+	res := []string{}
+
+	for _, v := range config.Paths {
+		res = append(res, expand(v))
+	}
+
+	if config.Khal {
+		for _, v := range khalPaths() {
+			if !slices.Contains(res, v) {
+				res = append(res, v)
+			}
+		}
+	}
+
+	return res
+}
+
+func load() {
+	res := []Event{}
+
+	for _, v := range paths() {
+		res = append(res, readCalendars(v)...)
+	}
+
+	mu.Lock()
+	events = res
+	loaded = time.Now()
+	mu.Unlock()
+}
+
+func Available() bool {
+	if len(paths()) == 0 {
+		slog.Info(Name, "available", "no calendars configured and no khal config found. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+// upcoming returns the ongoing and upcoming occurrences, sorted by start time.
+func upcoming() []Occurrence {
+	mu.RLock()
+	stale := time.Since(loaded) > time.Duration(config.ReloadEvery)*time.Minute
+	mu.RUnlock()
+
+	if stale {
+		load()
+	}
+
+	now := time.Now()
+
+	mu.RLock()
+	res := occurrences(events, now, now.AddDate(0, 0, config.Days))
+	mu.RUnlock()
+
+	slices.SortStableFunc(res, func(a, b Occurrence) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return res
+}
+
+func occurrenceID(o Occurrence) string {
+	md5 := md5.Sum(fmt.Appendf(nil, "%s%d", o.UID, o.Start.Unix()))
+	return hex.EncodeToString(md5[:])
+}
+
+// meetingLink finds a video-call url in the event. Explicit conference urls are preferred.
+func meetingLink(e Event) string {
+	if e.Conference != "" {
+		return e.Conference
+	}
+
+	for _, v := range []string{e.URL, e.Location, e.Description} {
+		if link := meetingRe.FindString(v); link != "" {
+			return link
+		}
+	}
+
+	return ""
+}
+
+// relative describes when the occurrence happens, f.e. "in 25m", "now, ends in 10m" or "tomorrow 09:00".
+func relative(o Occurrence, now time.Time) string {
+	if !o.Start.After(now) {
+		if o.AllDay {
+			return "today"
+		}
+
+		return fmt.Sprintf("now, ends in %s", duration(o.End.Sub(now)))
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	days := int(o.Start.Sub(today).Hours() / 24)
+
+	clock := o.Start.Format("15:04")
+
+	switch {
+	case o.AllDay && days == 0:
+		return "today"
+	case o.AllDay && days == 1:
+		return "tomorrow"
+	case o.AllDay:
+		return o.Start.Format("Mon 02.01.")
+	case o.Start.Sub(now) < 12*time.Hour && days == 0:
+		return fmt.Sprintf("in %s", duration(o.Start.Sub(now)))
+	case days == 0:
+		return fmt.Sprintf("today %s", clock)
+	case days == 1:
+		return fmt.Sprintf("tomorrow %s", clock)
+	case days < 7:
+		return o.Start.Format("Mon 15:04")
+	}
+
+	return o.Start.Format("Mon 02.01. 15:04")
+}
+
+func duration(d time.Duration) string {
+	d = d.Round(time.Minute)
+
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == ActionRefresh {
+		load()
+		return
+	}
+
+	list := upcoming()
+
+	i := slices.IndexFunc(list, func(o Occurrence) bool {
+		return occurrenceID(o) == identifier
+	})
+
+	if i == -1 {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown identifier: %s", identifier))
+		return
+	}
+
+	o := list[i]
+	link := meetingLink(o.Event)
+
+	if action == "" {
+		action = ActionCopy
+
+		if link != "" {
+			action = ActionOpenLink
+		}
+	}
+
+	if (action == ActionCopyLink || action == ActionOpenLink) && link == "" {
+		slog.Error(Name, "activate", "no meeting link")
+		return
+	}
+
+	var cmd *exec.Cmd
+
+	switch action {
+	case ActionCopy:
+		cmd = common.ReplaceResultOrStdinCmd(config.Copy, fmt.Sprintf("%s, %s", o.Summary, o.Start.Format("Mon 02.01.2006 15:04")))
+	case ActionCopyLink:
+		cmd = common.ReplaceResultOrStdinCmd(config.Copy, link)
+	case ActionOpenLink:
+		open := config.Open
+		if !strings.Contains(open, "%VALUE%") {
+			open = fmt.Sprintf("%s %%VALUE%%", open)
+		}
+
+		cmd = common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), strings.ReplaceAll(open, "%VALUE%", common.Quote(link)))))
+		common.Detach(cmd)
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	err := cmd.Start()
+	if err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	res := []*pb.QueryResponse_Item{}
+
+	now := time.Now()
+	list := upcoming()
+
+	for i, v := range list {
+		subtext := relative(v, now)
+
+		if v.Location != "" && meetingRe.FindString(v.Location) == "" {
+			subtext = fmt.Sprintf("%s - %s", subtext, v.Location)
+		}
+
+		actions := []string{ActionCopy}
+		state := []string{}
+
+		if link := meetingLink(v.Event); link != "" {
+			actions = append([]string{ActionOpenLink, ActionCopyLink}, actions...)
+			state = append(state, "meeting")
+		}
+
+		if !v.Start.After(now) {
+			state = append(state, "ongoing")
+		}
+
+		if v.AllDay {
+			state = append(state, "allday")
+		}
+
+		text := v.Summary
+		if text == "" {
+			text = "(no title)"
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier:  occurrenceID(v),
+			Text:        text,
+			Subtext:     subtext,
+			Icon:        config.Icon,
+			Provider:    Name,
+			Actions:     actions,
+			State:       state,
+			Score:       int32(len(list) - i),
+			Type:        pb.QueryResponse_REGULAR,
+			Preview:     preview(v),
+			PreviewType: util.PreviewTypeText,
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, text, exact)
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Field:     "text",
+				Positions: pos,
+				Start:     start,
+			}
+
+			for _, n := range []string{v.Location, v.Calendar} {
+				if s2, _, _ := common.FuzzyScore(query, n, exact); s2 > e.Score {
+					e.Score = s2
+					e.Fuzzyinfo = nil
+				}
+			}
+
+			if e.Score > config.MinScore {
+				// keep upcoming events first among similar matches
+				e.Score = e.Score + int32(len(list)-i)
+			}
+		}
+
+		if e.Score > config.MinScore || query == "" {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+// preview describes the occurrence. The countdown provider reads the date from the second line.
+func preview(o Occurrence) string {
+	lines := []string{o.Summary}
+
+	if o.AllDay {
+		lines = append(lines, o.Start.Format("Monday, 02.01.2006"))
+	} else {
+		lines = append(lines, fmt.Sprintf("%s - %s", o.Start.Format("Monday, 02.01.2006 15:04"), o.End.Format("15:04")))
+	}
+
+	for _, v := range []string{o.Location, meetingLink(o.Event), o.Calendar, o.Description} {
+		if v != "" {
+			lines = append(lines, "", v)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{
+		Actions: []string{ActionRefresh},
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// Backend lists the rooms of an account and opens them in the corresponding client.
+type Backend interface {
+	// Account identifies the configured account, f.e. "matrix:work".
+	Account() string
+	Rooms() ([]Room, error)
+	OpenRoom(r Room) error
+}
+
+// Room is a room, channel, direct message or buffer.
+type Room struct {
+	ID      string
+	Name    string
+	Topic   string
+	Unread  int
+	Mention int
+	Direct  bool
+	Account string
+	// Data is backend specific, f.e. the team id for slack.
+	Data string
+}
+
+var client = http.Client{Timeout: 15 * time.Second}
+
+// secret returns the value or the output of the command.
+// secret resolves the value, the command takes precedence for configs written before secrets could
+// reference commands.
+func secret(value common.Secret, cmd string) (string, error) {
+	if cmd != "" {
+		value = common.Secret(fmt.Sprintf("cmd:%s", cmd))
+	}
+
+	return value.Resolve()
+}
+
+// getJSON decodes the response of an authenticated request.
+func getJSON(req *http.Request, v any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s %s", req.URL.Path, resp.Status, strings.TrimSpace(string(b)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func label(kind, name string) string {
+	if name == "" {
+		return kind
+	}
+
+	return fmt.Sprintf("%s:%s", kind, name)
+}
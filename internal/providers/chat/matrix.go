@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+type Matrix struct {
+	Name       string        `koanf:"name" desc:"name of the account" default:""`
+	Homeserver string        `koanf:"homeserver" desc:"homeserver url, f.e. https://matrix.org" default:""`
+	Token      common.Secret `koanf:"token" desc:"access token, f.e. 'cmd:pass show matrix'" default:""`
+	TokenCmd   string        `koanf:"token_cmd" desc:"command printing the access token" default:""`
+	Open       string        `koanf:"open" desc:"command to open a room. supports %ID% and %NAME%" default:"xdg-open https://matrix.to/#/%ID%"`
+}
+
+// syncFilter only requests what's needed for names and unread counts.
+const syncFilter = `{"room":{"timeline":{"limit":1},"state":{"types":["m.room.name","m.room.canonical_alias","m.room.topic"]},"ephemeral":{"not_types":["*"]},"account_data":{"types":["m.direct"]}},"presence":{"not_types":["*"]},"account_data":{"types":["m.direct"]}}`
+
+type matrixSync struct {
+	AccountData struct {
+		Events []matrixEvent `json:"events"`
+	} `json:"account_data"`
+	Rooms struct {
+		Join map[string]struct {
+			Summary struct {
+				Heroes []string `json:"m.heroes"`
+			} `json:"summary"`
+			State struct {
+				Events []matrixEvent `json:"events"`
+			} `json:"state"`
+			UnreadNotifications struct {
+				NotificationCount int `json:"notification_count"`
+				HighlightCount    int `json:"highlight_count"`
+			} `json:"unread_notifications"`
+		} `json:"join"`
+	} `json:"rooms"`
+}
+
+type matrixEvent struct {
+	Type    string          `json:"type"`
+	Content json.RawMessage `json:"content"`
+}
+
+func (m Matrix) Account() string {
+	return label("matrix", m.Name)
+}
+
+func (m Matrix) Rooms() ([]Room, error) {
+	token, err := secret(m.Token, m.TokenCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	u := fmt.Sprintf("%s/_matrix/client/v3/sync?timeout=0&filter=%s", strings.TrimSuffix(m.Homeserver, "/"), url.QueryEscape(syncFilter))
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var s matrixSync
+
+	if err := getJSON(req, &s); err != nil {
+		return nil, err
+	}
+
+	direct := make(map[string]bool)
+
+	for _, e := range s.AccountData.Events {
+		if e.Type != "m.direct" {
+			continue
+		}
+
+		var d map[string][]string
+		json.Unmarshal(e.Content, &d)
+
+		for _, rooms := range d {
+			for _, r := range rooms {
+				direct[r] = true
+			}
+		}
+	}
+
+	res := []Room{}
+
+	for id, v := range s.Rooms.Join {
+		r := Room{
+			ID:      id,
+			Unread:  v.UnreadNotifications.NotificationCount,
+			Mention: v.UnreadNotifications.HighlightCount,
+			Direct:  direct[id],
+			Account: m.Account(),
+		}
+
+		var alias string
+
+		for _, e := range v.State.Events {
+			var c struct {
+				Name  string `json:"name"`
+				Alias string `json:"alias"`
+				Topic string `json:"topic"`
+			}
+
+			json.Unmarshal(e.Content, &c)
+
+			switch e.Type {
+			case "m.room.name":
+				r.Name = c.Name
+			case "m.room.canonical_alias":
+				alias = c.Alias
+			case "m.room.topic":
+				r.Topic = c.Topic
+			}
+		}
+
+		switch {
+		case r.Name != "":
+		case alias != "":
+			r.Name = alias
+		case len(v.Summary.Heroes) > 0:
+			r.Name = strings.Join(v.Summary.Heroes, ", ")
+		default:
+			r.Name = id
+		}
+
+		if alias != "" {
+			// aliases make for nicer links
+			r.Data = alias
+		}
+
+		res = append(res, r)
+	}
+
+	return res, nil
+}
+
+func (m Matrix) OpenRoom(r Room) error {
+	id := r.ID
+	if r.Data != "" {
+		id = r.Data
+	}
+
+	open := m.Open
+	if open == "" {
+		open = matrixOpen
+	}
+
+	return run(open, id, r.Name)
+}
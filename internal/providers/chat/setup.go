@@ -0,0 +1,299 @@
+// Package chat provides a quick-switcher for Matrix rooms, Slack channels and weechat buffers.
+package main
+
+import (
+	"crypto/md5"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/history"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "chat"
+	NamePretty = "Chat"
+	config     *Config
+	h          = history.Load(Name)
+	mu         sync.RWMutex
+	rooms      = make(map[string][]Room)
+	backends   = []Backend{}
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config    `koanf:",squash"`
+	History          bool      `koanf:"history" desc:"make use of history for sorting" default:"true"`
+	HistoryWhenEmpty bool      `koanf:"history_when_empty" desc:"consider history when query is empty" default:"true"`
+	Matrix           []Matrix  `koanf:"matrix" desc:"matrix accounts" default:""`
+	Slack            []Slack   `koanf:"slack" desc:"slack workspaces" default:""`
+	Weechat          []Weechat `koanf:"weechat" desc:"weechat api relays" default:""`
+	RefreshInterval  int       `koanf:"refresh_interval" desc:"seconds between refreshing rooms and unread counts" default:"60"`
+	UnreadOnly       bool      `koanf:"unread_only" desc:"only list rooms with unread messages when the query is empty" default:"false"`
+}
+
+const (
+	ActionOpen = "open"
+
+	matrixOpen = "xdg-open https://matrix.to/#/%ID%"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "internet-chat",
+			MinScore: 30,
+		},
+		History:          true,
+		HistoryWhenEmpty: true,
+		RefreshInterval:  60,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	backends = []Backend{}
+
+	for _, v := range config.Matrix {
+		backends = append(backends, v)
+	}
+
+	for _, v := range config.Slack {
+		backends = append(backends, v)
+	}
+
+	for _, v := range config.Weechat {
+		backends = append(backends, v)
+	}
+
+	go func() {
+		refresh()
+
+		if config.RefreshInterval <= 0 {
+			return
+		}
+
+		for range time.Tick(time.Duration(config.RefreshInterval) * time.Second) {
+			refresh()
+		}
+	}()
+}
+
+// Refresh reloads rooms and unread counts of all accounts.
+func Refresh() {
+	refresh()
+}
+
+// refresh queries all backends in parallel. Accounts that fail keep their previous rooms.
+func refresh() {
+	var wg sync.WaitGroup
+
+	for _, b := range backends {
+		wg.Go(func() {
+			res, err := b.Rooms()
+			if err != nil {
+				slog.Error(Name, "refresh", err, "account", b.Account())
+				return
+			}
+
+			mu.Lock()
+			rooms[b.Account()] = res
+			mu.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	handlers.ProviderUpdated <- Name
+}
+
+func Available() bool {
+	if len(config.Matrix) == 0 && len(config.Slack) == 0 && len(config.Weechat) == 0 {
+		slog.Info(Name, "available", "no accounts configured. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func roomID(r Room) string {
+	md5 := md5.Sum([]byte(r.Account + r.ID))
+	return hex.EncodeToString(md5[:])
+}
+
+// run starts the command, replacing %ID% and %NAME%.
+func run(command, id, name string) error {
+	command = strings.NewReplacer("%ID%", common.Quote(id), "%NAME%", common.Quote(name)).Replace(command)
+
+	cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, id), command)))
+	common.Detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+
+	return nil
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == history.ActionDelete {
+		h.Remove(identifier)
+		return
+	}
+
+	if action != "" && action != ActionOpen {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	for _, b := range backends {
+		mu.RLock()
+		list := rooms[b.Account()]
+		mu.RUnlock()
+
+		for _, r := range list {
+			if roomID(r) != identifier {
+				continue
+			}
+
+			if err := b.OpenRoom(r); err != nil {
+				slog.Error(Name, "activate", err)
+				return
+			}
+
+			if config.History {
+				h.Save(query, identifier)
+			}
+
+			return
+		}
+	}
+
+	slog.Error(Name, "activate", fmt.Sprintf("unknown identifier: %s", identifier))
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	res := []*pb.QueryResponse_Item{}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for account, list := range rooms {
+		for _, r := range list {
+			if query == "" && config.UnreadOnly && r.Unread == 0 {
+				continue
+			}
+
+			subtext := account
+
+			switch {
+			case r.Mention > 0:
+				subtext = fmt.Sprintf("%s - %d unread, %d mentions", subtext, r.Unread, r.Mention)
+			case r.Unread > 0:
+				subtext = fmt.Sprintf("%s - %d unread", subtext, r.Unread)
+			}
+
+			state := []string{strings.Split(account, ":")[0]}
+
+			if r.Direct {
+				state = append(state, "direct")
+			}
+
+			if r.Unread > 0 {
+				state = append(state, "unread")
+			}
+
+			if r.Mention > 0 {
+				state = append(state, "mention")
+			}
+
+			e := &pb.QueryResponse_Item{
+				Identifier: roomID(r),
+				Text:       r.Name,
+				Subtext:    subtext,
+				Icon:       config.Icon,
+				Provider:   Name,
+				Actions:    []string{ActionOpen},
+				State:      state,
+				Type:       pb.QueryResponse_REGULAR,
+			}
+
+			if query == "" {
+				// mentions first, then unread rooms
+				e.Score = int32(min(r.Mention, 100)*100 + min(r.Unread, 99))
+			} else {
+				score, pos, start := common.FuzzyScore(query, r.Name, exact)
+
+				e.Score = score
+				e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+					Field:     "text",
+					Positions: pos,
+					Start:     start,
+				}
+
+				if r.Topic != "" {
+					if s2, _, _ := common.FuzzyScore(query, r.Topic, exact); s2 > e.Score {
+						e.Score = s2
+						e.Fuzzyinfo = nil
+					}
+				}
+			}
+
+			if config.History {
+				if e.Score > config.MinScore || query == "" && config.HistoryWhenEmpty {
+					usageScore := h.CalcUsageScore(query, e.Identifier)
+
+					if usageScore != 0 {
+						e.State = append(e.State, "history")
+						e.Actions = append(e.Actions, history.ActionDelete)
+					}
+
+					e.Score = e.Score + usageScore
+				}
+			}
+
+			if e.Score > config.MinScore || query == "" {
+				res = append(res, e)
+			}
+		}
+	}
+
+	return res
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+type Slack struct {
+	Name      string        `koanf:"name" desc:"name of the workspace" default:""`
+	Token     common.Secret `koanf:"token" desc:"xoxp or xoxc token, f.e. 'env:SLACK_TOKEN'" default:""`
+	TokenCmd  string        `koanf:"token_cmd" desc:"command printing the token" default:""`
+	Cookie    common.Secret `koanf:"cookie" desc:"value of the 'd' cookie, required for xoxc tokens" default:""`
+	CookieCmd string        `koanf:"cookie_cmd" desc:"command printing the 'd' cookie" default:""`
+	Open      string        `koanf:"open" desc:"command to open a channel. supports %ID%, %NAME% and %TEAM%" default:"xdg-open 'slack://channel?team=%TEAM%&id=%ID%'"`
+}
+
+const slackOpen = "xdg-open 'slack://channel?team=%TEAM%&id=%ID%'"
+
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+type slackChannel struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	IsIM    bool   `json:"is_im"`
+	IsMpIM  bool   `json:"is_mpim"`
+	User    string `json:"user"`
+	Purpose struct {
+		Value string `json:"value"`
+	} `json:"purpose"`
+}
+
+type slackCount struct {
+	ID           string `json:"id"`
+	HasUnreads   bool   `json:"has_unreads"`
+	MentionCount int    `json:"mention_count"`
+}
+
+func (s Slack) Account() string {
+	return label("slack", s.Name)
+}
+
+func (s Slack) call(method string, params url.Values, v any) error {
+	token, err := secret(s.Token, s.TokenCmd)
+	if err != nil {
+		return err
+	}
+
+	cookie, err := secret(s.Cookie, s.CookieCmd)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "https://slack.com/api/"+method, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: "d", Value: cookie})
+	}
+
+	return getJSON(req, v)
+}
+
+func (s Slack) Rooms() ([]Room, error) {
+	var auth struct {
+		slackResponse
+		TeamID string `json:"team_id"`
+	}
+
+	if err := s.call("auth.test", url.Values{}, &auth); err != nil {
+		return nil, err
+	}
+
+	if !auth.OK {
+		return nil, fmt.Errorf("auth.test: %s", auth.Error)
+	}
+
+	channels := []slackChannel{}
+	cursor := ""
+
+	for {
+		var list struct {
+			slackResponse
+			Channels []slackChannel `json:"channels"`
+			Meta     struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+
+		params := url.Values{
+			"types":            {"public_channel,private_channel,mpim,im"},
+			"exclude_archived": {"true"},
+			"limit":            {"1000"},
+		}
+
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+
+		if err := s.call("users.conversations", params, &list); err != nil {
+			return nil, err
+		}
+
+		if !list.OK {
+			return nil, fmt.Errorf("users.conversations: %s", list.Error)
+		}
+
+		channels = append(channels, list.Channels...)
+
+		if cursor = list.Meta.NextCursor; cursor == "" {
+			break
+		}
+	}
+
+	users := s.users(channels)
+	counts := s.counts()
+
+	res := []Room{}
+
+	for _, c := range channels {
+		r := Room{
+			ID:      c.ID,
+			Name:    c.Name,
+			Topic:   c.Purpose.Value,
+			Direct:  c.IsIM || c.IsMpIM,
+			Account: s.Account(),
+			Data:    auth.TeamID,
+		}
+
+		if c.IsIM {
+			r.Name = users[c.User]
+
+			if r.Name == "" {
+				r.Name = c.User
+			}
+		} else if !c.IsMpIM {
+			r.Name = "#" + r.Name
+		}
+
+		if v, ok := counts[c.ID]; ok {
+			r.Mention = v.MentionCount
+
+			if v.HasUnreads {
+				r.Unread = max(1, v.MentionCount)
+			}
+		}
+
+		res = append(res, r)
+	}
+
+	return res, nil
+}
+
+// users resolves the names of direct message partners.
+func (s Slack) users(channels []slackChannel) map[string]string {
+	res := make(map[string]string)
+
+	needed := false
+
+	for _, c := range channels {
+		if c.IsIM {
+			needed = true
+			break
+		}
+	}
+
+	if !needed {
+		return res
+	}
+
+	cursor := ""
+
+	for {
+		var list struct {
+			slackResponse
+			Members []struct {
+				ID      string `json:"id"`
+				Name    string `json:"name"`
+				Profile struct {
+					DisplayName string `json:"display_name"`
+					RealName    string `json:"real_name"`
+				} `json:"profile"`
+			} `json:"members"`
+			Meta struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+
+		params := url.Values{"limit": {"1000"}}
+
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+
+		if err := s.call("users.list", params, &list); err != nil || !list.OK {
+			return res
+		}
+
+		for _, m := range list.Members {
+			name := m.Profile.DisplayName
+
+			if name == "" {
+				name = m.Profile.RealName
+			}
+
+			if name == "" {
+				name = m.Name
+			}
+
+			res[m.ID] = name
+		}
+
+		if cursor = list.Meta.NextCursor; cursor == "" {
+			return res
+		}
+	}
+}
+
+// counts uses the endpoint of the web client, which is only available to xoxc tokens. Without it, unread
+// counts are unknown.
+func (s Slack) counts() map[string]slackCount {
+	res := make(map[string]slackCount)
+
+	var counts struct {
+		slackResponse
+		Channels []slackCount `json:"channels"`
+		IMs      []slackCount `json:"ims"`
+		MpIMs    []slackCount `json:"mpims"`
+	}
+
+	if err := s.call("client.counts", url.Values{}, &counts); err != nil || !counts.OK {
+		return res
+	}
+
+	for _, list := range [][]slackCount{counts.Channels, counts.IMs, counts.MpIMs} {
+		for _, v := range list {
+			res[v.ID] = v
+		}
+	}
+
+	return res
+}
+
+func (s Slack) OpenRoom(r Room) error {
+	open := s.Open
+	if open == "" {
+		open = slackOpen
+	}
+
+	if r.Data == "" {
+		return errors.New("unknown team")
+	}
+
+	return run(strings.ReplaceAll(open, "%TEAM%", r.Data), r.ID, r.Name)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// Weechat uses the api relay of weechat 4.3 or newer.
+type Weechat struct {
+	Name        string        `koanf:"name" desc:"name of the relay" default:""`
+	URL         string        `koanf:"url" desc:"relay url, f.e. http://localhost:9000" default:""`
+	Password    common.Secret `koanf:"password" desc:"relay password" default:""`
+	PasswordCmd string        `koanf:"password_cmd" desc:"command printing the relay password" default:""`
+	Focus       string        `koanf:"focus" desc:"command to focus the weechat window after switching the buffer. supports %NAME%" default:""`
+}
+
+type weechatBuffer struct {
+	ID             int64             `json:"id"`
+	Name           string            `json:"name"`
+	ShortName      string            `json:"short_name"`
+	Title          string            `json:"title"`
+	LocalVariables map[string]string `json:"local_variables"`
+}
+
+type weechatHotlist struct {
+	BufferID int64 `json:"buffer_id"`
+	// Count is low, message, private and highlight.
+	Count []int `json:"count"`
+}
+
+func (w Weechat) Account() string {
+	return label("weechat", w.Name)
+}
+
+func (w Weechat) request(method, path string, body any, v any) error {
+	password, err := secret(w.Password, w.PasswordCmd)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+
+	if body != nil {
+		if err := json.NewEncoder(&b).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(w.URL, "/")+path, &b)
+	if err != nil {
+		return err
+	}
+
+	req.SetBasicAuth("plain", password)
+	req.Header.Set("Content-Type", "application/json")
+
+	if v == nil {
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("%s: %s", path, resp.Status)
+		}
+
+		return nil
+	}
+
+	return getJSON(req, v)
+}
+
+func (w Weechat) Rooms() ([]Room, error) {
+	var buffers []weechatBuffer
+
+	if err := w.request(http.MethodGet, "/api/buffers", nil, &buffers); err != nil {
+		return nil, err
+	}
+
+	var hotlist []weechatHotlist
+
+	if err := w.request(http.MethodGet, "/api/hotlist", nil, &hotlist); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int64]weechatHotlist)
+
+	for _, v := range hotlist {
+		counts[v.BufferID] = v
+	}
+
+	res := []Room{}
+
+	for _, b := range buffers {
+		kind := b.LocalVariables["type"]
+
+		if kind != "channel" && kind != "private" {
+			continue
+		}
+
+		name := b.ShortName
+		if name == "" {
+			name = b.Name
+		}
+
+		if server := b.LocalVariables["server"]; server != "" {
+			name = fmt.Sprintf("%s (%s)", name, server)
+		}
+
+		r := Room{
+			ID:      b.Name,
+			Name:    name,
+			Topic:   b.Title,
+			Direct:  kind == "private",
+			Account: w.Account(),
+		}
+
+		if h, ok := counts[b.ID]; ok && len(h.Count) == 4 {
+			r.Unread = h.Count[1] + h.Count[2] + h.Count[3]
+			r.Mention = h.Count[2] + h.Count[3]
+		}
+
+		res = append(res, r)
+	}
+
+	return res, nil
+}
+
+// OpenRoom switches weechat to the buffer and runs the focus command.
+func (w Weechat) OpenRoom(r Room) error {
+	input := map[string]string{
+		"buffer_name": "core.weechat",
+		"command":     "/buffer " + r.ID,
+	}
+
+	if err := w.request(http.MethodPost, "/api/input", input, nil); err != nil {
+		return err
+	}
+
+	if w.Focus == "" {
+		return nil
+	}
+
+	return run(w.Focus, r.ID, r.Name)
+}
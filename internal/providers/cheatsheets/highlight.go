@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	colorComment     = "#7f848e"
+	colorString      = "#98c379"
+	colorFlag        = "#c678dd"
+	colorVariable    = "#e5c07b"
+	colorPlaceholder = "#61afef"
+	colorCommand     = "#e06c75"
+)
+
+// highlight escapes the shell command and colors the command names, flags, strings, variables, comments and
+// placeholders like "<file>" or "{{file}}".
+func highlight(command string) string {
+	var b strings.Builder
+
+	span := func(color, s string) {
+		fmt.Fprintf(&b, "<span foreground=\"%s\">%s</span>", color, html.EscapeString(s))
+	}
+
+	r := []rune(command)
+	// the first word of a line or after a pipe is a command
+	start := true
+
+	for i := 0; i < len(r); {
+		c := r[i]
+
+		switch {
+		case c == '\n' || c == '|' || c == ';' || c == '&':
+			b.WriteString(html.EscapeString(string(c)))
+			// "2>&1" doesn't start a command
+			start = c != '&' || i == 0 || r[i-1] != '>'
+			i++
+		case unicode.IsSpace(c):
+			b.WriteRune(c)
+			i++
+		case c == '#' && (i == 0 || unicode.IsSpace(r[i-1])):
+			end := i
+
+			for end < len(r) && r[end] != '\n' {
+				end++
+			}
+
+			span(colorComment, string(r[i:end]))
+			i = end
+		case c == '"' || c == '\'':
+			end := i + 1
+
+			for end < len(r) && r[end] != c {
+				if r[end] == '\\' && c == '"' {
+					end++
+				}
+
+				end++
+			}
+
+			end = min(end+1, len(r))
+
+			span(colorString, string(r[i:end]))
+			start = false
+			i = end
+		case c == '$':
+			end := i + 1
+
+			if end < len(r) && r[end] == '{' {
+				for end < len(r) && r[end] != '}' {
+					end++
+				}
+
+				end = min(end+1, len(r))
+			} else {
+				for end < len(r) && (r[end] == '_' || unicode.IsLetter(r[end]) || unicode.IsDigit(r[end])) {
+					end++
+				}
+			}
+
+			span(colorVariable, string(r[i:end]))
+			i = end
+		case c == '<' && i+1 < len(r) && (unicode.IsLetter(r[i+1]) || r[i+1] == '_'):
+			end := i + 1
+
+			for end < len(r) && r[end] != '>' && r[end] != '\n' {
+				end++
+			}
+
+			// redirections aren't placeholders
+			if end >= len(r) || r[end] != '>' {
+				b.WriteString(html.EscapeString(string(c)))
+				i++
+				continue
+			}
+
+			span(colorPlaceholder, string(r[i:end+1]))
+			start = false
+			i = end + 1
+		case c == '{' && i+1 < len(r) && r[i+1] == '{':
+			end := i + 2
+
+			for end+1 < len(r) && !(r[end] == '}' && r[end+1] == '}') && r[end] != '\n' {
+				end++
+			}
+
+			if end+1 >= len(r) || r[end] != '}' {
+				b.WriteString(html.EscapeString(string(c)))
+				i++
+				continue
+			}
+
+			span(colorPlaceholder, string(r[i:end+2]))
+			start = false
+			i = end + 2
+		default:
+			end := i
+
+			for end < len(r) && !unicode.IsSpace(r[end]) && !strings.ContainsRune("|;&\"'$<", r[end]) {
+				end++
+			}
+
+			// f.e. a redirection
+			end = max(end, i+1)
+
+			word := string(r[i:end])
+
+			switch {
+			case start && word != "sudo":
+				span(colorCommand, word)
+				start = false
+			case strings.HasPrefix(word, "-"):
+				span(colorFlag, word)
+			default:
+				b.WriteString(html.EscapeString(word))
+			}
+
+			i = end
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,264 @@
+// Package cheatsheets shows the commands of cheat.sh and local cheatsheets for a tool.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "cheatsheets"
+	NamePretty = "Cheatsheets"
+	config     *Config
+
+	queries common.Debouncer
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Dirs          []string `koanf:"dirs" desc:"directories of cheatsheets in the format of cheat, named by the tool" default:"['~/.config/cheat/cheatsheets']"`
+	CheatSh       bool     `koanf:"cheat_sh" desc:"fetch sheets of cheat.sh" default:"true"`
+	URL           string   `koanf:"url" desc:"url of cheat.sh, f.e. for a self-hosted instance" default:"https://cheat.sh"`
+	CacheDays     int      `koanf:"cache_days" desc:"days sheets of cheat.sh are cached" default:"7"`
+	Debounce      int      `koanf:"debounce" desc:"milliseconds to wait for further typing before fetching a sheet" default:"400"`
+	Copy          string   `koanf:"copy" desc:"command to copy. supports %VALUE%." default:"wl-copy, xclip on X11, pbcopy on macOS, clip on Windows"`
+	Type          string   `koanf:"type" desc:"command to type. supports %VALUE%." default:"wtype -, xdotool type --file - on X11"`
+	Delay         int      `koanf:"delay" desc:"delay in ms before typing to avoid potential focus issues" default:"100"`
+}
+
+const (
+	ActionCopy = "copy"
+	ActionType = "type"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "accessories-text-editor",
+			MinScore: 30,
+		},
+		Dirs:      []string{"~/.config/cheat/cheatsheets"},
+		CheatSh:   true,
+		URL:       "https://cheat.sh",
+		CacheDays: 7,
+		Debounce:  400,
+		Copy:      common.CopyCommand(),
+		Type:      common.TypeCommand(),
+		Delay:     100,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	go cleanCache()
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionCopy, Label: "Copy", Icon: "edit-copy", Default: true},
+		{Action: ActionType, Label: "Type", Icon: "input-keyboard"},
+	}
+}
+
+// find returns the entry of the identifier, "<source>:<tool>#<index>".
+func find(identifier string) (Entry, bool) {
+	source, rest, _ := strings.Cut(identifier, ":")
+	tool, index, _ := strings.Cut(rest, "#")
+
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return Entry{}, false
+	}
+
+	var entries []Entry
+
+	switch source {
+	case SourceLocal:
+		entries = readLocal(tool)
+	case SourceCheatSh:
+		entries, _, _ = cached(tool)
+	}
+
+	if i < 0 || i >= len(entries) {
+		return Entry{}, false
+	}
+
+	return entries[i], true
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionCopy
+	}
+
+	e, ok := find(identifier)
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown entry: %s", identifier))
+		return
+	}
+
+	switch action {
+	case ActionCopy:
+		cmd := common.QuoteResultOrStdinCmd(config.Copy, e.Command)
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "copy", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+	case ActionType:
+		time.Sleep(time.Duration(config.Delay) * time.Millisecond)
+
+		if out, err := common.QuoteResultOrStdinCmd(config.Type, e.Command).CombinedOutput(); err != nil {
+			slog.Error(Name, "type", err, "output", strings.TrimSpace(string(out)))
+		}
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+// Query expects the tool followed by an optional filter, f.e. "tar extract". Local sheets are listed before the
+// ones of cheat.sh.
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	tool, filter, _ := strings.Cut(strings.TrimSpace(query), " ")
+	filter = strings.TrimSpace(filter)
+
+	if !validTool.MatchString(tool) {
+		return entries
+	}
+
+	sheet := readLocal(tool)
+
+	if config.CheatSh {
+		remote, err := remote(tool)
+		if err != nil {
+			slog.Error(Name, "cheat.sh", err)
+		}
+
+		sheet = append(sheet, remote...)
+	}
+
+	for k, v := range sheet {
+		text, subtext := v.Description, firstLine(v.Command)
+
+		if text == "" {
+			text, subtext = subtext, ""
+		}
+
+		subtext = strings.Join(nonEmpty(subtext, v.Source), " · ")
+
+		preview := highlight(v.Command)
+
+		if v.Description != "" {
+			preview = fmt.Sprintf("<span foreground=\"%s\"><i>%s</i></span>\n\n%s", colorComment, html.EscapeString(v.Description), preview)
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier:  v.ID(),
+			Text:        text,
+			Subtext:     subtext,
+			Provider:    Name,
+			Icon:        config.Icon,
+			State:       []string{v.Source},
+			Actions:     []string{ActionCopy, ActionType},
+			Type:        pb.QueryResponse_REGULAR,
+			Score:       int32(len(sheet) - k),
+			Preview:     preview,
+			PreviewType: util.PreviewTypePango,
+		}
+
+		if filter != "" {
+			score, pos, start := common.FuzzyScore(filter, text, exact)
+
+			// matches of the command don't highlight the text
+			if s, _, _ := common.FuzzyScore(filter, v.Command, exact); s > score {
+				score, pos, start = s, nil, 0
+			}
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// remote returns the sheet of cheat.sh.
+func remote(tool string) ([]Entry, error) {
+	if _, _, fresh := cached(tool); !queries.Wait(config.Debounce, fresh || isUnknown(tool)) {
+		return nil, nil
+	}
+
+	return fetch(tool)
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+func nonEmpty(values ...string) []string {
+	res := []string{}
+
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+const (
+	SourceLocal   = "local"
+	SourceCheatSh = "cheat.sh"
+)
+
+// Entry is a command of a cheatsheet with the comment above it.
+type Entry struct {
+	Source      string
+	Tool        string
+	Index       int
+	Description string
+	Command     string
+}
+
+// ID identifies the entry as "<source>:<tool>#<index>".
+func (e Entry) ID() string {
+	return fmt.Sprintf("%s:%s#%d", e.Source, e.Tool, e.Index)
+}
+
+var (
+	client = http.Client{Timeout: 10 * time.Second}
+
+	// tools like "go" or topics like "go/slices"
+	validTool = regexp.MustCompile(`^[\w+-][\w.+-]*(/[\w+-][\w.+-]*)?$`)
+
+	// tools cheat.sh has no sheet for, so they aren't requested again on every keystroke
+	unknownMu sync.Mutex
+	unknown   = map[string]time.Time{}
+)
+
+// parseSheet splits a sheet into entries. Sheets, of cheat.sh or in the format of cheat, are blocks of
+// "# comment" lines followed by the command, separated by empty lines. The front matter of cheat is skipped.
+func parseSheet(r io.Reader, source, tool string) []Entry {
+	res := []Entry{}
+
+	var comment, command []string
+
+	flush := func() {
+		if len(command) > 0 {
+			res = append(res, Entry{
+				Source:      source,
+				Tool:        tool,
+				Index:       len(res),
+				Description: strings.Join(comment, " "),
+				Command:     strings.Join(command, "\n"),
+			})
+		}
+
+		comment, command = nil, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	frontMatter, first := false, true
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+
+		if first {
+			first = false
+
+			if line == "---" {
+				frontMatter = true
+				continue
+			}
+		}
+
+		if frontMatter {
+			frontMatter = line != "---"
+			continue
+		}
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case strings.HasPrefix(line, "#"):
+			// a comment after a command starts the next entry
+			if len(command) > 0 {
+				flush()
+			}
+
+			comment = append(comment, strings.TrimSpace(strings.TrimLeft(line, "#")))
+		default:
+			command = append(command, line)
+		}
+	}
+
+	flush()
+
+	return res
+}
+
+// localSheets returns the files of the cheatsheet directories by tool. Sheets of later directories take
+// precedence, as with cheat's personal sheets.
+func localSheets() map[string]string {
+	res := map[string]string{}
+
+	for _, dir := range config.Dirs {
+		dir = expand(dir)
+
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if d.IsDir() {
+				if path != dir && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if strings.HasPrefix(d.Name(), ".") {
+				return nil
+			}
+
+			res[strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))] = path
+
+			return nil
+		})
+	}
+
+	return res
+}
+
+func expand(path string) string {
+	if after, ok := strings.CutPrefix(path, "~/"); ok {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, after)
+	}
+
+	return path
+}
+
+func readLocal(tool string) []Entry {
+	file, ok := localSheets()[tool]
+	if !ok {
+		return nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	return parseSheet(f, SourceLocal, tool)
+}
+
+func cacheFile(tool string) string {
+	return common.CacheFile(filepath.Join(Name, strings.ReplaceAll(tool, "/", "_")+".txt"))
+}
+
+// cached returns the cached sheet of cheat.sh and if it's still fresh.
+func cached(tool string) ([]Entry, bool, bool) {
+	file := cacheFile(tool)
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, false, false
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, false, false
+	}
+	defer f.Close()
+
+	return parseSheet(f, SourceCheatSh, tool), true, time.Since(info.ModTime()) < time.Duration(config.CacheDays)*24*time.Hour
+}
+
+// isUnknown reports if cheat.sh had no sheet for the tool within the last hour.
+func isUnknown(tool string) bool {
+	unknownMu.Lock()
+	defer unknownMu.Unlock()
+
+	t, ok := unknown[tool]
+
+	return ok && time.Since(t) < time.Hour
+}
+
+// fetch downloads the sheet of cheat.sh into the cache. Stale sheets are used if the download fails.
+func fetch(tool string) ([]Entry, error) {
+	entries, ok, fresh := cached(tool)
+	if fresh {
+		return entries, nil
+	}
+
+	if isUnknown(tool) {
+		return nil, nil
+	}
+
+	res, err := download(tool)
+	if err != nil {
+		if ok {
+			return entries, nil
+		}
+
+		return nil, err
+	}
+
+	if res == "" {
+		unknownMu.Lock()
+		unknown[tool] = time.Now()
+		unknownMu.Unlock()
+
+		return nil, nil
+	}
+
+	file := cacheFile(tool)
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(file, []byte(res), 0o600); err != nil {
+		return nil, err
+	}
+
+	return parseSheet(strings.NewReader(res), SourceCheatSh, tool), nil
+}
+
+// download requests the plain text sheet, empty if there is none.
+func download(tool string) (string, error) {
+	// ?T omits the terminal colors
+	u := fmt.Sprintf("%s/%s?T", strings.TrimSuffix(config.URL, "/"), tool)
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// browsers get html
+	req.Header.Set("User-Agent", "curl/8.0 (elephant)")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", u, resp.Status)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	text := strings.TrimSpace(string(b))
+
+	if text == "" || strings.HasPrefix(text, "Unknown topic") || strings.Contains(text, "404 NOT FOUND") {
+		return "", nil
+	}
+
+	return text, nil
+}
+
+// cleanCache removes sheets that weren't downloaded again for a while, f.e. of tools looked up once.
+func cleanCache() {
+	dir := common.CacheFile(Name)
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, v := range files {
+		info, err := v.Info()
+		if err != nil {
+			continue
+		}
+
+		if time.Since(info.ModTime()) > 4*time.Duration(config.CacheDays)*24*time.Hour {
+			os.Remove(filepath.Join(dir, v.Name()))
+		}
+	}
+}
+
+// localTools returns the names of the local sheets.
+func localTools() []string {
+	res := []string{}
+
+	for k := range localSheets() {
+		res = append(res, k)
+	}
+
+	slices.Sort(res)
+
+	return res
+}
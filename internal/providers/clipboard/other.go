@@ -0,0 +1,20 @@
+//go:build !linux
+
+package main
+
+func available() bool {
+	return true
+}
+
+// handleChange polls the clipboard, as there's no portable way to watch it.
+func handleChange() {
+	pollChanges()
+}
+
+func getClipboardText() (string, error) {
+	return pasteText()
+}
+
+func getMimetypes() []string {
+	return []string{}
+}
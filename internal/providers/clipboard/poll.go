@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// pollChanges polls the text of the clipboard, for when there's no way to watch it. Images are only
+// stored with wl-clipboard.
+func pollChanges() {
+	last, _ := pasteText()
+
+	for range time.Tick(time.Second) {
+		if paused {
+			continue
+		}
+
+		text, err := pasteText()
+		if err != nil || text == last {
+			continue
+		}
+
+		last = text
+
+		mu.Lock()
+		updateText(text)
+		mu.Unlock()
+	}
+}
+
+func pasteText() (string, error) {
+	out, err := common.PasteCommand().Output()
+
+	return strings.TrimSuffix(string(out), "\r\n"), err
+}
@@ -2,7 +2,6 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"crypto/md5"
 	_ "embed"
@@ -10,8 +9,8 @@ import (
 	"encoding/hex"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"io/fs"
-	"log"
 	"log/slog"
 	"net"
 	"os"
@@ -21,7 +20,6 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 	"unicode/utf8"
 
@@ -71,7 +69,7 @@ type Config struct {
 	MaxItems       int    `koanf:"max_items" desc:"max amount of clipboard history items" default:"100"`
 	ImageEditorCmd string `koanf:"image_editor_cmd" desc:"editor to use for images. use '%FILE%' as placeholder for file path." default:""`
 	TextEditorCmd  string `koanf:"text_editor_cmd" desc:"editor to use for text, otherwise default for mimetype. use '%FILE%' as placeholder for file path." default:""`
-	Command        string `koanf:"command" desc:"default command to be executed" default:"wl-copy"`
+	Command        string `koanf:"command" desc:"default command to be executed" default:"wl-copy, xclip on X11, pbcopy on macOS, clip on Windows"`
 	IgnoreSymbols  bool   `koanf:"ignore_symbols" desc:"ignores symbols/unicode" default:"true"`
 	AutoCleanup    int    `koanf:"auto_cleanup" desc:"will automatically cleanup entries entries older than X minutes" default:"0"`
 }
@@ -87,7 +85,7 @@ func Setup() {
 		MaxItems:       100,
 		ImageEditorCmd: "",
 		TextEditorCmd:  "",
-		Command:        "wl-copy",
+		Command:        common.CopyCommand(),
 		IgnoreSymbols:  true,
 		AutoCleanup:    0,
 	}
@@ -125,19 +123,7 @@ func Setup() {
 }
 
 func Available() bool {
-	p, err := exec.LookPath("wl-paste")
-	if p == "" || err != nil {
-		slog.Info(Name, "available", "wl-clipboard not found. disabling")
-		return false
-	}
-
-	p, err = exec.LookPath("identify")
-	if p == "" || err != nil {
-		slog.Info(Name, "available", "imagemagick not found. disabling")
-		return false
-	}
-
-	return true
+	return available()
 }
 
 func cleanup() {
@@ -253,8 +239,7 @@ func loadFromFile() {
 }
 
 func cleanupImages() {
-	d, _ := os.UserCacheDir()
-	folder := filepath.Join(d, "elephant", "clipboardimages")
+	folder := common.CacheFile("clipboardimages")
 
 	filepath.Walk(folder, func(path string, info fs.FileInfo, err error) error {
 		if info != nil && !info.IsDir() {
@@ -291,62 +276,6 @@ func saveToFile() {
 	}
 }
 
-func handleChange() {
-	cmd := exec.Command("wl-paste", "--watch", "echo", "clipboard-changed")
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		log.Fatal("Error creating stdout pipe:", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		log.Fatal("Error starting wl-paste watch:", err)
-	}
-
-	scanner := bufio.NewScanner(stdout)
-
-	for scanner.Scan() {
-		if paused {
-			continue
-		}
-
-		text, texterr := getClipboardText()
-		if texterr == nil {
-			mu.Lock()
-			updateText(text)
-			mu.Unlock()
-			continue
-		}
-
-		img, imgerr := getClipboardImage()
-		if imgerr == nil {
-			mu.Lock()
-			updateImage(img)
-			mu.Unlock()
-			continue
-		}
-	}
-}
-
-func getClipboardImage() ([]byte, error) {
-	cmd := exec.Command("wl-paste", "-t", "image", "-n")
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		slog.Debug(Name, "get clipboard img", string(out))
-	}
-
-	return out, err
-}
-
-func getClipboardText() (string, error) {
-	cmd := exec.Command("wl-paste", "-t", "text", "-n")
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		slog.Debug(Name, "get clipboard text", string(out))
-	}
-
-	return string(out), err
-}
-
 var ignoreMimetypes = []string{"x-kde-passwordManagerHint", "text/uri-list"}
 
 func handleSaveToFile() {
@@ -477,8 +406,7 @@ func trim() {
 }
 
 func saveImg(b []byte, ext string) string {
-	d, _ := os.UserCacheDir()
-	folder := filepath.Join(d, "elephant", "clipboardimages")
+	folder := common.CacheFile("clipboardimages")
 
 	os.MkdirAll(folder, 0o755)
 
@@ -499,10 +427,10 @@ func saveImg(b []byte, ext string) string {
 	return file
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 const (
@@ -522,6 +450,21 @@ const (
 	Combined   = "combined"
 )
 
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionCopy, Label: "Copy", Icon: "edit-copy", Default: true},
+		{Action: ActionEdit, Label: "Edit", Icon: "document-edit"},
+		{Action: ActionRemove, Label: "Remove", Icon: "edit-delete"},
+		{Action: ActionRemoveAll, Label: "Remove all", Icon: "edit-clear-all"},
+		{Action: ActionLocalsend, Label: "Send with LocalSend", Icon: "document-send"},
+		{Action: ActionPause, Label: "Pause", Icon: "media-playback-pause"},
+		{Action: ActionUnpause, Label: "Resume", Icon: "media-playback-start"},
+		{Action: ActionImagesOnly, Label: "Show images only", Icon: "image-x-generic"},
+		{Action: ActionTextOnly, Label: "Show text only", Icon: "text-x-generic"},
+		{Action: ActionCombined, Label: "Show all", Icon: "view-list"},
+	}
+}
+
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
 	if action == "" {
 		action = ActionCopy
@@ -549,11 +492,8 @@ func Activate(single bool, identifier, action string, query string, args string,
 			path = f.Name()
 		}
 
-		cmd := exec.Command("sh", "-c", strings.TrimSpace(fmt.Sprintf("%s %s %s", common.LaunchPrefix(""), "localsend", path)))
-
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			Setsid: true,
-		}
+		cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix("", Name, identifier), "localsend", common.Quote(path))))
+		common.Detach(cmd)
 
 		err := cmd.Start()
 		if err != nil {
@@ -590,7 +530,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 
 			toRun := strings.ReplaceAll(config.ImageEditorCmd, "%FILE%", item.Img)
 
-			cmd := exec.Command("sh", "-c", toRun)
+			cmd := common.HostShell(toRun)
 
 			err := cmd.Start()
 			if err != nil {
@@ -618,14 +558,14 @@ func Activate(single bool, identifier, action string, query string, args string,
 		if config.TextEditorCmd != "" {
 			run = strings.ReplaceAll(config.TextEditorCmd, "%FILE%", tmpFile.Name())
 		} else {
-			run = fmt.Sprintf("xdg-open file://%s", tmpFile.Name())
+			run = fmt.Sprintf("%s %s", common.OpenCommand(), common.Quote(tmpFile.Name()))
 
 			if common.ForceTerminalForFile(tmpFile.Name()) {
 				run = common.WrapWithTerminal(run)
 			}
 		}
 
-		cmd := exec.Command("sh", "-c", run)
+		cmd := common.HostShell(run)
 		err = cmd.Start()
 		if err != nil {
 			slog.Error(Name, "openedit", err)
@@ -659,7 +599,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 		cleanupImages()
 		mu.Unlock()
 	case ActionCopy:
-		cmd := exec.Command("sh", "-c", config.Command)
+		cmd := common.HostShell(config.Command)
 
 		item := clipboardhistory[identifier]
 		if item.Img != "" {
@@ -753,20 +693,16 @@ func Query(conn net.Conn, query string, _ bool, exact bool, _ uint8) []*pb.Query
 		}
 	}
 
-	return entries
-}
-
-func getMimetypes() []string {
-	cmd := exec.Command("wl-paste", "--list-types")
-
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		log.Println(err)
-		log.Println(string(out))
-		return []string{}
+	// remote clients can't read the image files.
+	if common.WantsThumbnails(conn) {
+		for _, e := range entries {
+			if e.PreviewType == util.PreviewTypeFile {
+				e.Thumbnail = common.Thumbnail(e.Preview)
+			}
+		}
 	}
 
-	return strings.Fields(string(out))
+	return entries
 }
 
 func Icon() string {
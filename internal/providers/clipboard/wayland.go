@@ -0,0 +1,121 @@
+//go:build linux
+
+package main
+
+import (
+	"bufio"
+	"log"
+	"log/slog"
+	"os/exec"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+func available() bool {
+	if common.X11() {
+		p, err := exec.LookPath("xclip")
+		if p == "" || err != nil {
+			slog.Info(Name, "available", "xclip not found. disabling")
+			return false
+		}
+
+		return true
+	}
+
+	p, err := exec.LookPath("wl-paste")
+	if p == "" || err != nil {
+		slog.Info(Name, "available", "wl-clipboard not found. disabling")
+		return false
+	}
+
+	p, err = exec.LookPath("identify")
+	if p == "" || err != nil {
+		slog.Info(Name, "available", "imagemagick not found. disabling")
+		return false
+	}
+
+	return true
+}
+
+func handleChange() {
+	if common.X11() {
+		pollChanges()
+		return
+	}
+
+	cmd := exec.Command("wl-paste", "--watch", "echo", "clipboard-changed")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		log.Fatal("Error creating stdout pipe:", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		log.Fatal("Error starting wl-paste watch:", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+
+	for scanner.Scan() {
+		if paused {
+			continue
+		}
+
+		text, texterr := getClipboardText()
+		if texterr == nil {
+			mu.Lock()
+			updateText(text)
+			mu.Unlock()
+			continue
+		}
+
+		img, imgerr := getClipboardImage()
+		if imgerr == nil {
+			mu.Lock()
+			updateImage(img)
+			mu.Unlock()
+			continue
+		}
+	}
+}
+
+func getClipboardImage() ([]byte, error) {
+	cmd := exec.Command("wl-paste", "-t", "image", "-n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		slog.Debug(Name, "get clipboard img", string(out))
+	}
+
+	return out, err
+}
+
+func getClipboardText() (string, error) {
+	if common.X11() {
+		return pasteText()
+	}
+
+	cmd := exec.Command("wl-paste", "-t", "text", "-n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		slog.Debug(Name, "get clipboard text", string(out))
+	}
+
+	return string(out), err
+}
+
+func getMimetypes() []string {
+	if common.X11() {
+		return []string{}
+	}
+
+	cmd := exec.Command("wl-paste", "--list-types")
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Println(err)
+		log.Println(string(out))
+		return []string{}
+	}
+
+	return strings.Fields(string(out))
+}
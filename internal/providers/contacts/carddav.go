@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+type CardDAV struct {
+	Name        string        `koanf:"name" desc:"name of the addressbook" default:""`
+	URL         string        `koanf:"url" desc:"url of the addressbook collection" default:""`
+	Username    string        `koanf:"username" desc:"username for basic auth" default:""`
+	Password    common.Secret `koanf:"password" desc:"password for basic auth, f.e. 'libsecret:service carddav user me'" default:""`
+	PasswordCmd string        `koanf:"password_cmd" desc:"command printing the password, f.e. 'pass show carddav'" default:""`
+}
+
+const addressbookQuery = `<?xml version="1.0" encoding="utf-8"?>
+<card:addressbook-query xmlns:d="DAV:" xmlns:card="urn:ietf:params:xml:ns:carddav">
+  <d:prop>
+    <d:getetag/>
+    <card:address-data/>
+  </d:prop>
+</card:addressbook-query>`
+
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Status string `xml:"status"`
+			Prop   struct {
+				AddressData string `xml:"address-data"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// fetch downloads all vCards of the addressbook with a single REPORT request.
+func (c CardDAV) fetch() ([]Contact, error) {
+	req, err := http.NewRequest("REPORT", c.URL, strings.NewReader(addressbookQuery))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", "1")
+
+	if c.Username != "" {
+		secret := c.Password
+
+		if c.PasswordCmd != "" {
+			secret = common.Secret(fmt.Sprintf("cmd:%s", c.PasswordCmd))
+		}
+
+		password, err := secret.Resolve()
+		if err != nil {
+			return nil, err
+		}
+
+		req.SetBasicAuth(c.Username, password)
+	}
+
+	client := http.Client{Timeout: 30 * time.Second}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("%s: %s", c.URL, resp.Status)
+	}
+
+	var ms multistatus
+
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, err
+	}
+
+	res := []Contact{}
+
+	for _, r := range ms.Responses {
+		for _, p := range r.Propstat {
+			if p.Prop.AddressData == "" {
+				continue
+			}
+
+			res = append(res, parseVCards(strings.NewReader(p.Prop.AddressData), c.label())...)
+		}
+	}
+
+	return res, nil
+}
+
+func (c CardDAV) label() string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	return c.URL
+}
+
+func cacheFile() string {
+	return common.CacheFile(fmt.Sprintf("%s.gob", Name))
+}
+
+func photoFile(name string) string {
+	return common.CacheFile(filepath.Join(Name, name))
+}
+
+// syncRemote fetches all addressbooks. Addressbooks that can't be reached keep their cached contacts.
+func syncRemote() {
+	cached := loadCache()
+	res := []Contact{}
+
+	for _, v := range config.CardDAV {
+		contacts, err := v.fetch()
+		if err != nil {
+			slog.Error(Name, "carddav", err, "addressbook", v.label())
+
+			for _, c := range cached {
+				if c.Source == v.label() {
+					res = append(res, c)
+				}
+			}
+
+			continue
+		}
+
+		res = append(res, contacts...)
+	}
+
+	mu.Lock()
+	remote = res
+	mu.Unlock()
+
+	writeCache(res)
+}
+
+func loadCache() []Contact {
+	res := []Contact{}
+
+	file := cacheFile()
+
+	if !common.FileExists(file) {
+		return res
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		slog.Error(Name, "cache", err)
+		return res
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&res); err != nil {
+		slog.Error(Name, "cache", err)
+	}
+
+	return res
+}
+
+func writeCache(contacts []Contact) {
+	var b bytes.Buffer
+
+	if err := gob.NewEncoder(&b).Encode(contacts); err != nil {
+		slog.Error(Name, "cache", err)
+		return
+	}
+
+	file := cacheFile()
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		slog.Error(Name, "cache", err)
+		return
+	}
+
+	if err := os.WriteFile(file, b.Bytes(), 0o600); err != nil {
+		slog.Error(Name, "cache", err)
+	}
+}
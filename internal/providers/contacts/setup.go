@@ -0,0 +1,331 @@
+// Package contacts provides email addresses and phone numbers from vCard files and CardDAV addressbooks.
+package main
+
+import (
+	"crypto/md5"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/history"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "contacts"
+	NamePretty = "Contacts"
+	config     *Config
+	h          = history.Load(Name)
+	mu         sync.RWMutex
+	local      = []Contact{}
+	remote     = []Contact{}
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config    `koanf:",squash"`
+	History          bool      `koanf:"history" desc:"make use of history for sorting" default:"true"`
+	HistoryWhenEmpty bool      `koanf:"history_when_empty" desc:"consider history when query is empty" default:"false"`
+	Paths            []string  `koanf:"paths" desc:"vcf files or directories containing them, f.e. a vdirsyncer storage" default:""`
+	CardDAV          []CardDAV `koanf:"carddav" desc:"carddav addressbooks" default:""`
+	SyncInterval     int       `koanf:"sync_interval" desc:"minutes between carddav syncs. 0 to only sync on start and refresh" default:"60"`
+	Copy             string    `koanf:"copy" desc:"command to copy a value. supports %VALUE%." default:"wl-copy, xclip on X11, pbcopy on macOS, clip on Windows"`
+	Compose          string    `koanf:"compose" desc:"command to compose a mail. supports %VALUE%." default:"xdg-email %VALUE%"`
+	Call             string    `koanf:"call" desc:"command to call a number. supports %VALUE%." default:"xdg-open tel:%VALUE%"`
+	SMS              string    `koanf:"sms" desc:"command to text a number. supports %VALUE%." default:"xdg-open sms:%VALUE%"`
+}
+
+const (
+	ActionCopy    = "copy"
+	ActionCompose = "compose"
+	ActionCall    = "call"
+	ActionSMS     = "sms"
+)
+
+// entry is a single email address or phone number of a contact.
+type entry struct {
+	identifier string
+	contact    Contact
+	value      Value
+	email      bool
+}
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "x-office-address-book",
+			MinScore: 30,
+		},
+		History:          true,
+		HistoryWhenEmpty: false,
+		SyncInterval:     60,
+		Copy:             common.CopyCommand(),
+		Compose:          "xdg-email %VALUE%",
+		Call:             "xdg-open tel:%VALUE%",
+		SMS:              "xdg-open sms:%VALUE%",
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	loadLocal()
+
+	if len(config.CardDAV) > 0 {
+		mu.Lock()
+		remote = loadCache()
+		mu.Unlock()
+
+		go func() {
+			syncRemote()
+
+			if config.SyncInterval <= 0 {
+				return
+			}
+
+			for range time.Tick(time.Duration(config.SyncInterval) * time.Minute) {
+				syncRemote()
+			}
+		}()
+	}
+}
+
+// Refresh re-reads the vCard files and syncs the CardDAV addressbooks.
+func Refresh() {
+	loadLocal()
+
+	if len(config.CardDAV) > 0 {
+		syncRemote()
+	}
+}
+
+func loadLocal() {
+	res := []Contact{}
+
+	for _, v := range config.Paths {
+		if after, ok := strings.CutPrefix(v, "~/"); ok {
+			home, _ := os.UserHomeDir()
+			v = filepath.Join(home, after)
+		}
+
+		res = append(res, readVCards(v)...)
+	}
+
+	mu.Lock()
+	local = res
+	mu.Unlock()
+}
+
+func Available() bool {
+	if len(config.Paths) == 0 && len(config.CardDAV) == 0 {
+		slog.Info(Name, "available", "no paths or carddav addressbooks configured. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func entries() []entry {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	res := []entry{}
+
+	for _, list := range [][]Contact{local, remote} {
+		for _, c := range list {
+			for _, v := range c.Emails {
+				res = append(res, entry{identifier: identifier(c, v), contact: c, value: v, email: true})
+			}
+
+			for _, v := range c.Phones {
+				res = append(res, entry{identifier: identifier(c, v), contact: c, value: v})
+			}
+		}
+	}
+
+	return res
+}
+
+func identifier(c Contact, v Value) string {
+	md5 := md5.Sum([]byte(c.UID + v.Value))
+	return hex.EncodeToString(md5[:])
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == history.ActionDelete {
+		h.Remove(identifier)
+		return
+	}
+
+	var e *entry
+
+	for _, v := range entries() {
+		if v.identifier == identifier {
+			e = &v
+			break
+		}
+	}
+
+	if e == nil {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown identifier: %s", identifier))
+		return
+	}
+
+	if action == "" {
+		action = ActionCopy
+	}
+
+	var cmd *exec.Cmd
+
+	switch action {
+	case ActionCopy:
+		cmd = common.ReplaceResultOrStdinCmd(config.Copy, e.value.Value)
+	case ActionCompose, ActionCall, ActionSMS:
+		c := map[string]string{ActionCompose: config.Compose, ActionCall: config.Call, ActionSMS: config.SMS}[action]
+
+		value := e.value.Value
+		if !e.email {
+			value = strings.ReplaceAll(value, " ", "")
+		}
+
+		cmd = common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), strings.ReplaceAll(c, "%VALUE%", common.Quote(value)))))
+		common.Detach(cmd)
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	err := cmd.Start()
+	if err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+
+	if config.History {
+		h.Save(query, identifier)
+	}
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	res := []*pb.QueryResponse_Item{}
+
+	for _, v := range entries() {
+		subtext := v.value.Value
+		if len(v.value.Types) > 0 {
+			subtext = fmt.Sprintf("%s (%s)", subtext, strings.Join(v.value.Types, ", "))
+		}
+
+		if v.contact.Org != "" {
+			subtext = fmt.Sprintf("%s - %s", subtext, v.contact.Org)
+		}
+
+		actions := []string{ActionCopy, ActionCompose}
+		state := []string{"email"}
+
+		if !v.email {
+			actions = []string{ActionCopy, ActionCall, ActionSMS}
+			state = []string{"phone"}
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier: v.identifier,
+			Text:       v.contact.Name,
+			Subtext:    subtext,
+			Icon:       config.Icon,
+			Provider:   Name,
+			Actions:    actions,
+			State:      state,
+			Type:       pb.QueryResponse_REGULAR,
+		}
+
+		if v.contact.Photo != "" {
+			e.Preview = v.contact.Photo
+			e.PreviewType = util.PreviewTypeFile
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, v.contact.Name, exact)
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Field:     "text",
+				Positions: pos,
+				Start:     start,
+			}
+
+			if s2, p2, start2 := common.FuzzyScore(query, v.value.Value, exact); s2 > e.Score {
+				e.Score = s2
+				e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+					Field:     "subtext",
+					Positions: p2,
+					Start:     start2,
+				}
+			}
+
+			// nicknames and the organization aren't highlighted, but should still match.
+			for _, n := range slices.Concat(v.contact.Nicknames, []string{v.contact.Org}) {
+				if s2, _, _ := common.FuzzyScore(query, n, exact); s2 > e.Score {
+					e.Score = s2
+					e.Fuzzyinfo = nil
+				}
+			}
+		}
+
+		if config.History {
+			if e.Score > config.MinScore || query == "" && config.HistoryWhenEmpty {
+				usageScore := h.CalcUsageScore(query, e.Identifier)
+
+				if usageScore != 0 {
+					e.State = append(e.State, "history")
+					e.Actions = append(e.Actions, history.ActionDelete)
+				}
+
+				e.Score = e.Score + usageScore
+			}
+		}
+
+		if e.Score > config.MinScore || query == "" {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Contact is a single vCard.
+type Contact struct {
+	UID       string
+	Name      string
+	Nicknames []string
+	Org       string
+	Emails    []Value
+	Phones    []Value
+	// Photo is a local image file, embedded photos get extracted to the cache.
+	Photo  string
+	Source string
+}
+
+// Value is an email address or phone number with its types, f.e. "work".
+type Value struct {
+	Value string
+	Types []string
+}
+
+type property struct {
+	name   string
+	params map[string][]string
+	value  string
+}
+
+// unfold joins continuation lines, which start with a space or tab.
+func unfold(r io.Reader) []string {
+	res := []string{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(res) > 0 {
+			res[len(res)-1] += line[1:]
+			continue
+		}
+
+		res = append(res, line)
+	}
+
+	return res
+}
+
+func parseProperty(line string) (property, bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return property{}, false
+	}
+
+	parts := strings.Split(head, ";")
+	name := strings.ToUpper(parts[0])
+
+	// drop groups, f.e. "item1.EMAIL"
+	if i := strings.LastIndex(name, "."); i != -1 {
+		name = name[i+1:]
+	}
+
+	p := property{
+		name:   name,
+		params: make(map[string][]string),
+		value:  value,
+	}
+
+	for _, v := range parts[1:] {
+		k, val, ok := strings.Cut(v, "=")
+		if !ok {
+			// vCard 2.1 style, f.e. "TEL;CELL:..."
+			k, val = "TYPE", v
+		}
+
+		k = strings.ToUpper(k)
+
+		for t := range strings.SplitSeq(strings.Trim(val, `"`), ",") {
+			p.params[k] = append(p.params[k], strings.ToLower(t))
+		}
+	}
+
+	return p, true
+}
+
+func unescape(s string) string {
+	r := strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return strings.TrimSpace(r.Replace(s))
+}
+
+// parseVCards reads all vCards of the file.
+func parseVCards(r io.Reader, source string) []Contact {
+	res := []Contact{}
+
+	var c *Contact
+
+	for _, line := range unfold(r) {
+		p, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch p.name {
+		case "BEGIN":
+			c = &Contact{Source: source}
+		case "END":
+			if c != nil && (len(c.Emails) > 0 || len(c.Phones) > 0) {
+				if c.Name == "" {
+					c.Name = c.fallbackName()
+				}
+
+				if c.UID == "" {
+					md5 := md5.Sum([]byte(c.Name + source))
+					c.UID = hex.EncodeToString(md5[:])
+				}
+
+				res = append(res, *c)
+			}
+
+			c = nil
+		}
+
+		if c == nil {
+			continue
+		}
+
+		switch p.name {
+		case "UID":
+			c.UID = p.value
+		case "FN":
+			c.Name = unescape(p.value)
+		case "N":
+			if c.Name == "" {
+				parts := strings.Split(p.value, ";")
+				if len(parts) > 1 {
+					c.Name = unescape(strings.TrimSpace(parts[1] + " " + parts[0]))
+				}
+			}
+		case "NICKNAME":
+			for v := range strings.SplitSeq(p.value, ",") {
+				if v = unescape(v); v != "" {
+					c.Nicknames = append(c.Nicknames, v)
+				}
+			}
+		case "ORG":
+			c.Org = unescape(strings.ReplaceAll(p.value, ";", " "))
+		case "EMAIL":
+			c.Emails = append(c.Emails, Value{Value: unescape(p.value), Types: types(p)})
+		case "TEL":
+			c.Phones = append(c.Phones, Value{Value: strings.TrimPrefix(unescape(p.value), "tel:"), Types: types(p)})
+		case "PHOTO":
+			c.Photo = photo(p, c)
+		}
+	}
+
+	return res
+}
+
+func types(p property) []string {
+	res := []string{}
+
+	for _, v := range p.params["TYPE"] {
+		switch v {
+		case "internet", "pref", "voice", "x400":
+			continue
+		}
+
+		res = append(res, v)
+	}
+
+	return res
+}
+
+func (c Contact) fallbackName() string {
+	switch {
+	case c.Org != "":
+		return c.Org
+	case len(c.Emails) > 0:
+		return c.Emails[0].Value
+	case len(c.Phones) > 0:
+		return c.Phones[0].Value
+	}
+
+	return ""
+}
+
+// photo returns a local file for the photo. Embedded photos (vCard 3 base64 or vCard 4 data uris) are written to
+// the cache, remote urls are ignored.
+func photo(p property, c *Contact) string {
+	value := p.value
+
+	if after, ok := strings.CutPrefix(value, "file://"); ok {
+		return after
+	}
+
+	ext := "jpg"
+
+	if after, ok := strings.CutPrefix(value, "data:"); ok {
+		meta, data, ok := strings.Cut(after, ",")
+		if !ok || !strings.HasSuffix(meta, ";base64") {
+			return ""
+		}
+
+		if _, sub, ok := strings.Cut(strings.TrimSuffix(meta, ";base64"), "/"); ok {
+			ext = sub
+		}
+
+		value = data
+	} else if !strings.EqualFold(first(p.params["ENCODING"]), "b") && !strings.EqualFold(first(p.params["ENCODING"]), "base64") {
+		return ""
+	} else if t := first(p.params["TYPE"]); t != "" {
+		ext = t
+	}
+
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return ""
+	}
+
+	md5 := md5.Sum(b)
+	file := photoFile(fmt.Sprintf("%s.%s", hex.EncodeToString(md5[:]), strings.ToLower(ext)))
+
+	if _, err := os.Stat(file); err == nil {
+		return file
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		slog.Error(Name, "photo", err)
+		return ""
+	}
+
+	if err := os.WriteFile(file, b, 0o644); err != nil {
+		slog.Error(Name, "photo", err)
+		return ""
+	}
+
+	return file
+}
+
+func first(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	return s[0]
+}
+
+// readVCards reads a .vcf file or all .vcf files in a directory, f.e. a vdirsyncer storage.
+func readVCards(path string) []Contact {
+	res := []Contact{}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		slog.Error(Name, "read", err)
+		return res
+	}
+
+	files := []string{path}
+
+	if info.IsDir() {
+		files = []string{}
+
+		filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".vcf") {
+				files = append(files, p)
+			}
+
+			return nil
+		})
+	}
+
+	for _, v := range files {
+		f, err := os.Open(v)
+		if err != nil {
+			slog.Error(Name, "read", err)
+			continue
+		}
+
+		res = append(res, parseVCards(f, v)...)
+		f.Close()
+	}
+
+	return res
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/providers"
+)
+
+const (
+	SourceConfig   = "config"
+	SourceCalendar = "calendar"
+)
+
+// Countdown is the next occurrence of a date.
+type Countdown struct {
+	ID     string
+	Name   string
+	Icon   string
+	Source string
+	Date   time.Time
+	// Days until the date, 0 is today.
+	Days int
+	// Age the date turns, f.e. of a birthday. 0 if unknown.
+	Age       int
+	Reminders []int
+}
+
+// midnight returns the start of the day of t.
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// daysBetween counts the days from a to b, both at midnight. Rounding hides days with daylight saving changes.
+func daysBetween(a, b time.Time) int {
+	return int((b.Sub(a).Hours() + 12) / 24)
+}
+
+// parseDate reads "2006-01-02", or "01-02" for dates repeating every year.
+func parseDate(s string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, true, nil
+	}
+
+	t, err := time.ParseInLocation("01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date %q, expected 2006-01-02 or 01-02", s)
+	}
+
+	return t, false, nil
+}
+
+// configured returns the next occurrences of the configured dates. Passed dates that don't repeat are dropped.
+func configured(today time.Time) []Countdown {
+	res := []Countdown{}
+
+	for _, v := range config.Dates {
+		// invalid dates were dropped in the setup
+		t, hasYear, err := parseDate(v.Date)
+		if err != nil {
+			continue
+		}
+
+		c := Countdown{
+			ID:        SourceConfig + ":" + v.Name,
+			Name:      v.Name,
+			Icon:      v.Icon,
+			Source:    SourceConfig,
+			Date:      t,
+			Reminders: v.Reminders,
+		}
+
+		if v.Yearly || !hasYear {
+			c.Date = time.Date(today.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+
+			if c.Date.Before(today) {
+				c.Date = c.Date.AddDate(1, 0, 0)
+			}
+
+			if hasYear {
+				c.Age = c.Date.Year() - t.Year()
+			}
+		}
+
+		if c.Date.Before(today) {
+			continue
+		}
+
+		c.Days = daysBetween(today, c.Date)
+
+		res = append(res, c)
+	}
+
+	return res
+}
+
+// calendarDates returns the events of the calendar provider, limited to the days it lists ahead.
+func calendarDates(today time.Time) []Countdown {
+	res := []Countdown{}
+
+	if config.CalendarEvents == CalendarNone {
+		return res
+	}
+
+	calendar, ok := providers.Get("calendar")
+	if !ok {
+		return res
+	}
+
+	for _, v := range calendar.Query(nil, "", false, false, 0) {
+		if config.CalendarEvents == CalendarAllDay && !slices.Contains(v.State, "allday") {
+			continue
+		}
+
+		lines := strings.Split(v.Preview, "\n")
+		if len(lines) < 2 {
+			continue
+		}
+
+		// f.e. "Monday, 02.01.2006 15:04 - 16:00"
+		_, date, _ := strings.Cut(lines[1], ", ")
+
+		t, err := time.ParseInLocation("02.01.2006", date[:min(len(date), 10)], time.Local)
+		if err != nil || t.Before(today) {
+			continue
+		}
+
+		res = append(res, Countdown{
+			ID:     SourceCalendar + ":" + v.Identifier,
+			Name:   v.Text,
+			Source: SourceCalendar,
+			Date:   t,
+			Days:   daysBetween(today, t),
+		})
+	}
+
+	return res
+}
+
+// countdowns returns all dates, the closest first.
+func countdowns() []Countdown {
+	today := midnight(time.Now())
+
+	res := append(configured(today), calendarDates(today)...)
+
+	for k, v := range res {
+		if v.Reminders == nil {
+			res[k].Reminders = config.Reminders
+		}
+	}
+
+	slices.SortStableFunc(res, func(a, b Countdown) int {
+		return a.Days - b.Days
+	})
+
+	return res
+}
+
+// when describes the distance of the date, f.e. "today", "tomorrow" or "in 12 days".
+func when(days int) string {
+	switch days {
+	case 0:
+		return "today"
+	case 1:
+		return "tomorrow"
+	}
+
+	if days%7 == 0 && days <= 28 {
+		if days == 7 {
+			return "in 1 week"
+		}
+
+		return fmt.Sprintf("in %d weeks", days/7)
+	}
+
+	return fmt.Sprintf("in %d days", days)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/store"
+)
+
+// ActionRemind is the action of reminders passed to hooks, they aren't activated by clients.
+const ActionRemind = "remind"
+
+var db *store.Store
+
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS reminded (
+		key TEXT PRIMARY KEY,
+		at INTEGER NOT NULL
+	);`,
+}
+
+func openDB() error {
+	var err error
+
+	db, err = store.Open(Name, migrations...)
+
+	return err
+}
+
+// remindLoop checks for due reminders every minute.
+func remindLoop() {
+	// reminders older than a month can't be due again
+	if _, err := db.Exec("DELETE FROM reminded WHERE at < ?", time.Now().AddDate(0, -1, 0).Unix()); err != nil {
+		slog.Error(Name, "reminders", err)
+	}
+
+	for {
+		remind(time.Now())
+		time.Sleep(time.Minute)
+	}
+}
+
+// remind sends the reminders that are due, each once. Reminders missed for more than a day, f.e. while the
+// computer was off, are skipped.
+func remind(now time.Time) {
+	clock, err := time.Parse("15:04", config.ReminderTime)
+	if err != nil {
+		slog.Error(Name, "reminder_time", err)
+		return
+	}
+
+	for _, c := range countdowns() {
+		for _, offset := range c.Reminders {
+			if offset < 0 || offset > c.Days {
+				continue
+			}
+
+			day := c.Date.AddDate(0, 0, -offset)
+			at := time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+
+			if now.Before(at) || now.Sub(at) > 24*time.Hour {
+				continue
+			}
+
+			key := fmt.Sprintf("%s|%s|%d", c.ID, c.Date.Format("2006-01-02"), offset)
+
+			res, err := db.Exec("INSERT OR IGNORE INTO reminded (key, at) VALUES (?, ?)", key, at.Unix())
+			if err != nil {
+				slog.Error(Name, "reminders", err)
+				continue
+			}
+
+			if n, _ := res.RowsAffected(); n == 0 {
+				continue
+			}
+
+			send(c)
+		}
+	}
+}
+
+// send notifies about the date and runs the hooks configured for the "remind" action.
+func send(c Countdown) {
+	subtext := subtext(c)
+
+	slog.Info(Name, "remind", c.Name, "when", subtext)
+
+	if config.Notify {
+		_, err := common.Notify(common.Notification{
+			Title: c.Name,
+			Body:  subtext,
+			Icon:  icon(c),
+		})
+		if err != nil {
+			slog.Error(Name, "notify", err)
+		}
+	}
+
+	common.RunHooks(common.HookAfter, common.HookActivation{
+		Provider:   Name,
+		Action:     ActionRemind,
+		Identifier: c.ID,
+		Text:       c.Name,
+		Subtext:    subtext,
+	})
+}
@@ -0,0 +1,235 @@
+// Package countdown counts down the days to important dates, f.e. birthdays and deadlines.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "countdown"
+	NamePretty = "Countdown"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config  `koanf:",squash"`
+	Dates          []Date `koanf:"dates" desc:"important dates" default:""`
+	CalendarEvents string `koanf:"calendar_events" desc:"events of the calendar provider to count down to: 'allday', 'all' or 'none'" default:"allday"`
+	Reminders      []int  `koanf:"reminders" desc:"days before a date to remind of it, 0 is the day itself" default:"[7, 1, 0]"`
+	ReminderTime   string `koanf:"reminder_time" desc:"time of day reminders are sent at" default:"09:00"`
+	Notify         bool   `koanf:"notify" desc:"show reminders as notification, hooks for the 'remind' action run regardless" default:"true"`
+	SoonDays       int    `koanf:"soon_days" desc:"dates within this many days are in the 'soon' state" default:"7"`
+}
+
+type Date struct {
+	Name      string `koanf:"name" desc:"name of the date" default:""`
+	Date      string `koanf:"date" desc:"'2006-01-02', or '01-02' to repeat every year" default:""`
+	Yearly    bool   `koanf:"yearly" desc:"repeat every year, f.e. birthdays with the year of birth to show the age" default:"false"`
+	Icon      string `koanf:"icon" desc:"icon of the date" default:""`
+	Reminders []int  `koanf:"reminders" desc:"days before the date to remind of it, overriding the default" default:""`
+}
+
+const (
+	CalendarAllDay = "allday"
+	CalendarAll    = "all"
+	CalendarNone   = "none"
+
+	ActionCopy = "copy"
+
+	StateToday = "today"
+	StateSoon  = "soon"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "alarm-symbolic",
+			MinScore: 20,
+		},
+		CalendarEvents: CalendarAllDay,
+		Reminders:      []int{7, 1, 0},
+		ReminderTime:   "09:00",
+		Notify:         true,
+		SoonDays:       7,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	config.Dates = slices.DeleteFunc(config.Dates, func(d Date) bool {
+		_, _, err := parseDate(d.Date)
+		if err != nil {
+			slog.Error(Name, d.Name, err)
+		}
+
+		return err != nil
+	})
+
+	if err := openDB(); err != nil {
+		slog.Error(Name, "db", err)
+		return
+	}
+
+	go remindLoop()
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionCopy, Label: "Copy date", Icon: "edit-copy", Default: true},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionCopy
+	}
+
+	list := countdowns()
+
+	i := slices.IndexFunc(list, func(c Countdown) bool { return c.ID == identifier })
+	if i < 0 {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown date: %s", identifier))
+		return
+	}
+
+	c := list[i]
+
+	switch action {
+	case ActionCopy:
+		cmd := common.CopyCmd(fmt.Sprintf("%s: %s", c.Name, c.Date.Format("02.01.2006")))
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "copy", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+func icon(c Countdown) string {
+	if c.Icon != "" {
+		return c.Icon
+	}
+
+	return config.Icon
+}
+
+// subtext is f.e. "in 12 days · Sat 24.12. · turns 35".
+func subtext(c Countdown) string {
+	parts := []string{when(c.Days)}
+
+	if c.Days > 1 {
+		parts = append(parts, c.Date.Format("Mon 02.01."))
+	}
+
+	if c.Age > 0 {
+		parts = append(parts, fmt.Sprintf("turns %d", c.Age))
+	}
+
+	return strings.Join(parts, " · ")
+}
+
+func preview(c Countdown) string {
+	lines := []string{c.Name, c.Date.Format("Monday, 02.01.2006"), when(c.Days)}
+
+	if c.Age > 0 {
+		lines = append(lines, fmt.Sprintf("turns %d", c.Age))
+	}
+
+	lines = append(lines, "", fmt.Sprintf("from %s", c.Source))
+
+	return strings.Join(lines, "\n")
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	list := countdowns()
+
+	for k, v := range list {
+		// the closest first
+		e := &pb.QueryResponse_Item{
+			Identifier:  v.ID,
+			Text:        v.Name,
+			Subtext:     subtext(v),
+			Provider:    Name,
+			Icon:        icon(v),
+			State:       []string{v.Source},
+			Actions:     []string{ActionCopy},
+			Type:        pb.QueryResponse_REGULAR,
+			Score:       int32(len(list) - k),
+			Preview:     preview(v),
+			PreviewType: util.PreviewTypeText,
+		}
+
+		switch {
+		case v.Days == 0:
+			e.State = append(e.State, StateToday)
+		case v.Days <= config.SoonDays:
+			e.State = append(e.State, StateSoon)
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, v.Name, exact)
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			// closer dates first among similar matches
+			e.Score = score + int32(len(list)-k)
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
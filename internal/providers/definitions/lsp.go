@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// lsp is a minimal language server client, only used for 'workspace/symbol'.
+type lsp struct {
+	project string
+	stdin   io.WriteCloser
+	writeMu sync.Mutex
+	nextID  atomic.Int64
+	pending map[int64]chan lspResponse
+	mu      sync.Mutex
+	ready   chan struct{}
+	dead    atomic.Bool
+}
+
+type lspMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+type lspResponse struct {
+	result json.RawMessage
+	err    error
+}
+
+type lspSymbol struct {
+	Name          string `json:"name"`
+	Kind          int    `json:"kind"`
+	ContainerName string `json:"containerName"`
+	Location      struct {
+		URI   string `json:"uri"`
+		Range *struct {
+			Start struct {
+				Line      int `json:"line"`
+				Character int `json:"character"`
+			} `json:"start"`
+		} `json:"range"`
+	} `json:"location"`
+}
+
+// symbolKinds maps the SymbolKind of the protocol to the names used by ctags.
+var symbolKinds = []string{"", "file", "module", "namespace", "package", "class", "method", "property", "field", "constructor", "enum", "interface", "function", "variable", "constant", "string", "number", "boolean", "array", "object", "key", "null", "enumerator", "struct", "event", "operator", "typeparameter"}
+
+var (
+	servers   = make(map[string]*lsp)
+	serversMu sync.Mutex
+)
+
+// server returns the running language server of the project, starting it if needed.
+func server(p Project) *lsp {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+
+	if s, ok := servers[p.path()]; ok && !s.dead.Load() {
+		return s
+	}
+
+	s, err := startLSP(p)
+	if err != nil {
+		slog.Error(Name, "lsp", err, "project", p.path())
+		return nil
+	}
+
+	servers[p.path()] = s
+
+	return s
+}
+
+func startLSP(p Project) (*lsp, error) {
+	cmd := common.HostShell(p.LSP)
+	cmd.Dir = p.path()
+	common.DieWithParent(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	s := &lsp{
+		project: p.path(),
+		stdin:   stdin,
+		pending: make(map[int64]chan lspResponse),
+		ready:   make(chan struct{}),
+	}
+
+	go func() {
+		s.read(bufio.NewReader(stdout))
+		cmd.Wait()
+	}()
+
+	go s.initialize()
+
+	return s, nil
+}
+
+func (s *lsp) initialize() {
+	uri := (&url.URL{Scheme: "file", Path: s.project}).String()
+
+	kinds := []int{}
+	for i := 1; i < len(symbolKinds); i++ {
+		kinds = append(kinds, i)
+	}
+
+	params := map[string]any{
+		"processId": os.Getpid(),
+		"rootUri":   uri,
+		"workspaceFolders": []map[string]string{
+			{"uri": uri, "name": s.project},
+		},
+		"capabilities": map[string]any{
+			"workspace": map[string]any{
+				"symbol": map[string]any{
+					"symbolKind": map[string]any{"valueSet": kinds},
+				},
+				"workspaceFolders": true,
+			},
+		},
+	}
+
+	// indexing big projects can take a while
+	if _, err := s.request("initialize", params, 2*time.Minute); err != nil {
+		slog.Error(Name, "lsp", err, "project", s.project)
+		s.stdin.Close()
+		return
+	}
+
+	if err := s.write(map[string]any{"jsonrpc": "2.0", "method": "initialized", "params": map[string]any{}}); err != nil {
+		slog.Error(Name, "lsp", err, "project", s.project)
+		return
+	}
+
+	close(s.ready)
+}
+
+func (s *lsp) write(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	_, err = fmt.Fprintf(s.stdin, "Content-Length: %d\r\n\r\n%s", len(b), b)
+
+	return err
+}
+
+func (s *lsp) request(method string, params any, timeout time.Duration) (json.RawMessage, error) {
+	id := s.nextID.Add(1)
+	ch := make(chan lspResponse, 1)
+
+	s.mu.Lock()
+	s.pending[id] = ch
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	if err := s.write(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case res := <-ch:
+		return res.result, res.err
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("%s: timeout", method)
+	}
+}
+
+// read dispatches responses and answers requests of the server, which would otherwise wait forever.
+func (s *lsp) read(r *bufio.Reader) {
+	defer func() {
+		s.dead.Store(true)
+
+		s.mu.Lock()
+		for _, ch := range s.pending {
+			select {
+			case ch <- lspResponse{err: errors.New("language server exited")}:
+			default:
+			}
+		}
+		s.mu.Unlock()
+	}()
+
+	for {
+		length := 0
+
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			line = strings.TrimSpace(line)
+
+			if line == "" {
+				break
+			}
+
+			if v, ok := strings.CutPrefix(line, "Content-Length:"); ok {
+				length, _ = strconv.Atoi(strings.TrimSpace(v))
+			}
+		}
+
+		b := make([]byte, length)
+
+		if _, err := io.ReadFull(r, b); err != nil {
+			return
+		}
+
+		var msg lspMessage
+
+		if err := json.Unmarshal(b, &msg); err != nil {
+			continue
+		}
+
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			var result any
+
+			if msg.Method == "workspace/configuration" {
+				var p struct {
+					Items []any `json:"items"`
+				}
+
+				json.Unmarshal(msg.Params, &p)
+				result = make([]any, len(p.Items))
+			}
+
+			s.write(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "result": result})
+		case msg.Method == "" && msg.ID != nil:
+			id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+			if err != nil {
+				continue
+			}
+
+			s.mu.Lock()
+			ch, ok := s.pending[id]
+			s.mu.Unlock()
+
+			if !ok {
+				continue
+			}
+
+			res := lspResponse{result: msg.Result}
+
+			if msg.Error != nil {
+				res.err = errors.New(msg.Error.Message)
+			}
+
+			ch <- res
+		}
+	}
+}
+
+// symbols queries 'workspace/symbol'. Servers that are still initializing return nothing.
+func (s *lsp) symbols(query string) []Symbol {
+	select {
+	case <-s.ready:
+	default:
+		return nil
+	}
+
+	b, err := s.request("workspace/symbol", map[string]string{"query": query}, time.Duration(config.LSPTimeout)*time.Millisecond)
+	if err != nil {
+		slog.Error(Name, "lsp", err, "project", s.project)
+		return nil
+	}
+
+	var list []lspSymbol
+
+	if err := json.Unmarshal(b, &list); err != nil {
+		slog.Error(Name, "lsp", err, "project", s.project)
+		return nil
+	}
+
+	res := []Symbol{}
+
+	for _, v := range list {
+		u, err := url.Parse(v.Location.URI)
+		if err != nil || u.Scheme != "file" {
+			continue
+		}
+
+		sym := Symbol{
+			Name:      v.Name,
+			File:      u.Path,
+			Line:      1,
+			Container: v.ContainerName,
+			Project:   s.project,
+		}
+
+		if v.Kind > 0 && v.Kind < len(symbolKinds) {
+			sym.Kind = symbolKinds[v.Kind]
+		}
+
+		if v.Location.Range != nil {
+			sym.Line = v.Location.Range.Start.Line + 1
+			sym.Column = v.Location.Range.Start.Character + 1
+		}
+
+		if !kindEnabled(sym.Kind) {
+			continue
+		}
+
+		res = append(res, sym)
+	}
+
+	return res
+}
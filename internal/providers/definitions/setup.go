@@ -0,0 +1,331 @@
+// Package definitions provides code symbols from tags files and language servers of configured projects.
+package main
+
+import (
+	"crypto/md5"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/history"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "definitions"
+	NamePretty = "Definitions"
+	config     *Config
+	h          = history.Load(Name)
+	mu         sync.RWMutex
+	tags       = make(map[string]tagsCache)
+	results    = make(map[string]Symbol)
+	resultsMu  sync.Mutex
+)
+
+// tagsCache holds the parsed tags of a project and the modification time of its tags file.
+type tagsCache struct {
+	file     string
+	modified time.Time
+	symbols  []Symbol
+}
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	History       bool      `koanf:"history" desc:"make use of history for sorting" default:"true"`
+	Projects      []Project `koanf:"projects" desc:"projects to read definitions from" default:""`
+	Kinds         []string  `koanf:"kinds" desc:"only list these kinds, f.e. 'function', 'method', 'struct'. all if empty" default:""`
+	Command       string    `koanf:"command" desc:"command to open the definition. supports %FILE%, %LINE%, %COLUMN% and %PROJECT%" default:"nvim +%LINE% %FILE%"`
+	Terminal      bool      `koanf:"terminal" desc:"run the command in a terminal" default:"true"`
+	Generate      bool      `koanf:"generate" desc:"generate tags with ctags for projects without tags file" default:"true"`
+	CtagsCommand  string    `koanf:"ctags_command" desc:"command generating the tags file, the output file is appended" default:"ctags -R --fields=+nKZ --exclude=.git --exclude=node_modules"`
+	LSPTimeout    int       `koanf:"lsp_timeout" desc:"milliseconds to wait for language servers" default:"500"`
+}
+
+type Project struct {
+	Path string `koanf:"path" desc:"project root" default:""`
+	Tags string `koanf:"tags" desc:"tags file relative to the root. looks for tags, .tags, TAGS and .git/tags if empty" default:""`
+	LSP  string `koanf:"lsp" desc:"command starting a language server on stdio, f.e. 'gopls'. used instead of tags" default:""`
+}
+
+func (p Project) path() string {
+	if after, ok := strings.CutPrefix(p.Path, "~/"); ok {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, after)
+	}
+
+	return filepath.Clean(p.Path)
+}
+
+const ActionOpen = "open"
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "code-context",
+			MinScore: 30,
+		},
+		History:      true,
+		Command:      "nvim +%LINE% %FILE%",
+		Terminal:     true,
+		Generate:     true,
+		CtagsCommand: "ctags -R --fields=+nKZ --exclude=.git --exclude=node_modules",
+		LSPTimeout:   500,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	go func() {
+		for _, p := range config.Projects {
+			if p.LSP == "" {
+				loadTags(p, false)
+			}
+		}
+	}()
+}
+
+// Refresh regenerates tags files and reloads them.
+func Refresh() {
+	for _, p := range config.Projects {
+		if p.LSP == "" {
+			loadTags(p, true)
+		}
+	}
+}
+
+func loadTags(p Project, regenerate bool) {
+	start := time.Now()
+
+	file, err := p.tagsFile(regenerate)
+	if err != nil {
+		slog.Error(Name, "tags", err)
+		return
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		slog.Error(Name, "tags", err)
+		return
+	}
+
+	symbols, err := readTags(p, file)
+	if err != nil {
+		slog.Error(Name, "tags", err)
+		return
+	}
+
+	mu.Lock()
+	tags[p.path()] = tagsCache{file: file, modified: info.ModTime(), symbols: symbols}
+	mu.Unlock()
+
+	slog.Info(Name, "tags", len(symbols), "project", p.path(), "time", time.Since(start))
+}
+
+// current returns the tags of the project, reloading them if the tags file changed.
+func current(p Project) []Symbol {
+	mu.RLock()
+	c, ok := tags[p.path()]
+	mu.RUnlock()
+
+	if ok {
+		if info, err := os.Stat(c.file); err == nil && !info.ModTime().Equal(c.modified) {
+			loadTags(p, false)
+
+			mu.RLock()
+			c = tags[p.path()]
+			mu.RUnlock()
+		}
+	}
+
+	return c.symbols
+}
+
+func Available() bool {
+	if len(config.Projects) == 0 {
+		slog.Info(Name, "available", "no projects configured. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func symbolID(s Symbol) string {
+	md5 := md5.Sum(fmt.Appendf(nil, "%s\x00%s\x00%d\x00%s", s.Name, s.File, s.Line, s.Pattern))
+	return hex.EncodeToString(md5[:])
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == history.ActionDelete {
+		h.Remove(identifier)
+		return
+	}
+
+	if action != "" && action != ActionOpen {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	resultsMu.Lock()
+	s, ok := results[identifier]
+	resultsMu.Unlock()
+
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown identifier: %s", identifier))
+		return
+	}
+
+	run := strings.NewReplacer(
+		"%FILE%", common.Quote(s.File),
+		"%LINE%", strconv.Itoa(resolveLine(s)),
+		"%COLUMN%", strconv.Itoa(max(s.Column, 1)),
+		"%PROJECT%", common.Quote(s.Project),
+	).Replace(config.Command)
+
+	if config.Terminal {
+		run = common.WrapWithTerminal(run)
+	}
+
+	cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), run)))
+	cmd.Dir = s.Project
+	common.Detach(cmd)
+
+	err := cmd.Start()
+	if err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+
+	if config.History {
+		h.Save(query, identifier)
+	}
+}
+
+// Query only returns results for non-empty queries, as projects easily have thousands of definitions.
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	res := []*pb.QueryResponse_Item{}
+
+	if query == "" {
+		return res
+	}
+
+	candidates := []Symbol{}
+
+	for _, p := range config.Projects {
+		if p.LSP == "" {
+			candidates = append(candidates, current(p)...)
+			continue
+		}
+
+		if s := server(p); s != nil {
+			candidates = append(candidates, s.symbols(query)...)
+		}
+	}
+
+	found := make(map[string]Symbol)
+
+	for _, s := range candidates {
+		score, pos, start := common.FuzzyScore(query, s.Name, exact)
+
+		if score <= config.MinScore {
+			continue
+		}
+
+		id := symbolID(s)
+		found[id] = s
+
+		rel, err := filepath.Rel(s.Project, s.File)
+		if err != nil {
+			rel = s.File
+		}
+
+		subtext := rel
+		if s.Line > 0 {
+			subtext = fmt.Sprintf("%s:%d", rel, s.Line)
+		}
+
+		if s.Container != "" {
+			subtext = fmt.Sprintf("%s - %s", s.Container, subtext)
+		}
+
+		if s.Kind != "" {
+			subtext = fmt.Sprintf("%s %s", s.Kind, subtext)
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier: id,
+			Text:       s.Name,
+			Subtext:    subtext,
+			Icon:       config.Icon,
+			Provider:   Name,
+			Actions:    []string{ActionOpen},
+			State:      []string{s.Kind},
+			Type:       pb.QueryResponse_REGULAR,
+			Score:      score,
+			Fuzzyinfo: &pb.QueryResponse_Item_FuzzyInfo{
+				Field:     "text",
+				Positions: pos,
+				Start:     start,
+			},
+			Preview:     s.File,
+			PreviewType: util.PreviewTypeFile,
+		}
+
+		if config.History {
+			usageScore := h.CalcUsageScore(query, e.Identifier)
+
+			if usageScore != 0 {
+				e.State = append(e.State, "history")
+				e.Actions = append(e.Actions, history.ActionDelete)
+			}
+
+			e.Score = e.Score + usageScore
+		}
+
+		res = append(res, e)
+	}
+
+	resultsMu.Lock()
+	results = found
+	resultsMu.Unlock()
+
+	return res
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
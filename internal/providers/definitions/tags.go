@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// Symbol is a definition found in a tags file or returned by a language server.
+type Symbol struct {
+	Name      string
+	Kind      string
+	File      string
+	Line      int
+	Column    int
+	Container string
+	// Pattern is the ex search pattern of tags without line numbers. The line is resolved on activation.
+	Pattern string
+	Project string
+}
+
+// tagFiles are looked up in the project, if no tags file is configured.
+var tagFiles = []string{"tags", ".tags", "TAGS", ".git/tags"}
+
+// tagsFile returns the tags file of the project. If none exists and generating is enabled, ctags
+// writes one to the cache.
+func (p Project) tagsFile(regenerate bool) (string, error) {
+	if p.Tags != "" {
+		return filepath.Join(p.path(), p.Tags), nil
+	}
+
+	for _, v := range tagFiles {
+		file := filepath.Join(p.path(), v)
+
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+	}
+
+	if !config.Generate {
+		return "", fmt.Errorf("no tags file found in %s", p.path())
+	}
+
+	md5 := md5.Sum([]byte(p.path()))
+	file := common.CacheFile(filepath.Join(Name, hex.EncodeToString(md5[:])+".tags"))
+
+	if _, err := os.Stat(file); err == nil && !regenerate {
+		return file, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", err
+	}
+
+	cmd := common.HostShell(fmt.Sprintf("%s -f %s", config.CtagsCommand, common.Quote(file)))
+	cmd.Dir = p.path()
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("%s: %w %s", config.CtagsCommand, err, strings.TrimSpace(string(out)))
+	}
+
+	return file, nil
+}
+
+// readTags parses a tags file in the format of universal-ctags or exuberant ctags:
+// 'name<TAB>file<TAB>address;"<TAB>kind<TAB>line:42<TAB>...'.
+func readTags(p Project, file string) ([]Symbol, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	base := filepath.Dir(file)
+
+	// generated tags files live in the cache, but paths are relative to the project.
+	if p.Tags == "" && !strings.HasPrefix(file, p.path()) {
+		base = p.path()
+	}
+
+	res := []Symbol{}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "!_TAG_") {
+			continue
+		}
+
+		s, ok := parseTag(line)
+		if !ok {
+			continue
+		}
+
+		if !filepath.IsAbs(s.File) {
+			s.File = filepath.Join(base, s.File)
+		}
+
+		s.Project = p.path()
+
+		if !kindEnabled(s.Kind) {
+			continue
+		}
+
+		res = append(res, s)
+	}
+
+	if err := scanner.Err(); err != nil {
+		slog.Error(Name, "tags", err, "file", file)
+	}
+
+	return res, nil
+}
+
+func parseTag(line string) (Symbol, bool) {
+	name, rest, ok := strings.Cut(line, "\t")
+	if !ok {
+		return Symbol{}, false
+	}
+
+	file, rest, ok := strings.Cut(rest, "\t")
+	if !ok {
+		return Symbol{}, false
+	}
+
+	s := Symbol{
+		Name: name,
+		File: file,
+	}
+
+	address, fields, _ := strings.Cut(rest, ";\"")
+
+	if n, err := strconv.Atoi(address); err == nil {
+		s.Line = n
+	} else {
+		s.Pattern = address
+	}
+
+	for i, field := range strings.Split(strings.TrimPrefix(fields, "\t"), "\t") {
+		key, value, ok := strings.Cut(field, ":")
+
+		if !ok {
+			// the kind is the only field without key
+			if i == 0 {
+				s.Kind = field
+			}
+
+			continue
+		}
+
+		switch key {
+		case "kind":
+			s.Kind = value
+		case "line":
+			s.Line, _ = strconv.Atoi(value)
+		case "scope":
+			// with --fields=+Z, f.e. 'scope:struct:Config'
+			_, s.Container, _ = strings.Cut(value, ":")
+		case "class", "struct", "interface", "namespace", "module", "enum", "function":
+			s.Container = value
+		}
+	}
+
+	return s, true
+}
+
+// resolveLine searches the file for the ex pattern, f.e. '/^func main() {$/'.
+func resolveLine(s Symbol) int {
+	if s.Line > 0 || s.Pattern == "" {
+		return max(s.Line, 1)
+	}
+
+	pattern := s.Pattern
+
+	if len(pattern) < 2 || (pattern[0] != '/' && pattern[0] != '?') {
+		return 1
+	}
+
+	pattern = pattern[1 : len(pattern)-1]
+	pattern = strings.NewReplacer(`\/`, "/", `\?`, "?", `\\`, `\`).Replace(pattern)
+
+	prefix := strings.HasPrefix(pattern, "^")
+	suffix := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "^"), "$")
+
+	f, err := os.Open(s.File)
+	if err != nil {
+		return 1
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	n := 0
+
+	for scanner.Scan() {
+		n++
+		line := scanner.Text()
+
+		switch {
+		case prefix && suffix && line == pattern,
+			prefix && !suffix && strings.HasPrefix(line, pattern),
+			!prefix && suffix && strings.HasSuffix(line, pattern),
+			!prefix && !suffix && strings.Contains(line, pattern):
+			return n
+		}
+	}
+
+	return 1
+}
+
+func kindEnabled(kind string) bool {
+	return len(config.Kinds) == 0 || slices.Contains(config.Kinds, kind)
+}
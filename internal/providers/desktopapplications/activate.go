@@ -1,3 +1,5 @@
+//go:build linux
+
 package main
 
 import (
@@ -11,11 +13,11 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
-	"syscall"
 
 	"github.com/abenz1267/elephant/v2/pkg/common"
 	"github.com/abenz1267/elephant/v2/pkg/common/history"
 	"github.com/abenz1267/elephant/v2/pkg/common/wlr"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 	"github.com/neurlang/wayland/wl"
 )
 
@@ -28,6 +30,17 @@ const (
 	ActionNewInstance = "new_instance"
 )
 
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionStart, Label: "Launch", Icon: "system-run", Default: true},
+		{Action: ActionNewInstance, Label: "New instance", Icon: "window-new"},
+		{Action: ActionPin, Label: "Pin", Icon: "view-pin"},
+		{Action: ActionUnpin, Label: "Unpin", Icon: "view-pin"},
+		{Action: ActionPinUp, Label: "Move up", Icon: "go-up"},
+		{Action: ActionPinDown, Label: "Move down", Icon: "go-down"},
+	}
+}
+
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
 	switch action {
 	case ActionPinUp:
@@ -42,7 +55,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 		return
 	case ActionStart, ActionNewInstance:
 		toRun := ""
-		prefix := common.LaunchPrefix(config.LaunchPrefix)
+		prefix := common.ScopedLaunchPrefix(config.LaunchPrefix, Name, identifier)
 
 		parts := strings.Split(identifier, ":")
 
@@ -87,9 +100,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 			cmd.Dir = files[parts[0]].Path
 		}
 
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			Setsid: true,
-		}
+		common.Detach(cmd)
 
 		if config.WMIntegration && wmi != nil {
 			appid := files[parts[0]].StartupWMClass
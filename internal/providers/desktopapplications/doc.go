@@ -1,13 +1,16 @@
+//go:build linux
+
 package main
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/abenz1267/elephant/v2/internal/util"
 )
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
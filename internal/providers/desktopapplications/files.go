@@ -1,6 +1,9 @@
+//go:build linux
+
 package main
 
 import (
+	"fmt"
 	"io/fs"
 	"log/slog"
 	"os"
@@ -10,6 +13,7 @@ import (
 	"time"
 
 	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/pkg/common"
 	"github.com/adrg/xdg"
 	"github.com/charlievieth/fastwalk"
 	"github.com/fsnotify/fsnotify"
@@ -28,12 +32,13 @@ var (
 	dirs          []string
 )
 
+// snapshotVersion changes whenever parsed files would differ, f.e. with another locale.
+func snapshotVersion() string {
+	return fmt.Sprintf("1:%s:%s:%s", regionLocale, config.IconPlaceholder, strings.Join(config.Blacklist, ","))
+}
+
 func loadFiles() {
-	start := time.Now()
 	setVars()
-	conf := fastwalk.Config{
-		Follow: true,
-	}
 
 	var err error
 	watcher, err = fsnotify.NewWatcher()
@@ -42,19 +47,52 @@ func loadFiles() {
 		return
 	}
 
+	cached := make(map[string]*DesktopFile)
+
+	if common.LoadSnapshot(Name, snapshotVersion(), &cached) {
+		filesMu.Lock()
+		files = cached
+		filesMu.Unlock()
+
+		go func() {
+			scanFiles()
+			handlers.ProviderUpdated <- Name
+		}()
+
+		return
+	}
+
+	scanFiles()
+}
+
+// scanFiles parses all desktop files and swaps them in at once, so a snapshot loaded before stays
+// queryable while scanning.
+func scanFiles() {
+	start := time.Now()
+	conf := fastwalk.Config{
+		Follow: true,
+	}
+
+	scanned := make(map[string]*DesktopFile)
+
 	for _, root := range dirs {
 		if _, err := os.Stat(root); err != nil {
 			continue
 		}
 
-		if err := fastwalk.Walk(&conf, root, walkFunction); err != nil {
+		if err := fastwalk.Walk(&conf, root, walkInto(scanned)); err != nil {
 			slog.Error(Name, "walk", err)
 			continue
 		}
 	}
 
-	fileCount := len(files)
-	slog.Info(Name, "files", fileCount, "time", time.Since(start))
+	filesMu.Lock()
+	files = scanned
+	filesMu.Unlock()
+
+	slog.Info(Name, "files", len(scanned), "time", time.Since(start))
+
+	common.SaveSnapshot(Name, snapshotVersion(), scanned)
 
 	slog.Info(Name, "watcher_dirs", len(watchedDirs))
 	go watchFiles()
@@ -72,38 +110,40 @@ func setVars() {
 	dirs = xdg.ApplicationDirs
 }
 
-func walkFunction(path string, d fs.DirEntry, err error) error {
-	if err != nil {
-		return err
-	}
+func walkInto(target map[string]*DesktopFile) fs.WalkDirFunc {
+	return func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
 
-	if filepath.Ext(path) == ".desktop" {
-		check := strings.TrimSuffix(filepath.Base(path), ".desktop")
+		if filepath.Ext(path) == ".desktop" {
+			check := strings.TrimSuffix(filepath.Base(path), ".desktop")
 
-		for _, v := range br {
-			if v.MatchString(check) {
-				return nil
+			for _, v := range br {
+				if v.MatchString(check) {
+					return nil
+				}
 			}
 		}
-	}
 
-	filesMu.RLock()
-	_, exists := files[filepath.Base(path)]
-	filesMu.RUnlock()
+		filesMu.RLock()
+		_, exists := target[filepath.Base(path)]
+		filesMu.RUnlock()
 
-	if exists {
-		return nil
-	}
+		if exists {
+			return nil
+		}
 
-	if !d.IsDir() && filepath.Ext(path) == ".desktop" {
-		addNewEntry(path)
-	}
+		if !d.IsDir() && filepath.Ext(path) == ".desktop" {
+			addEntry(target, path)
+		}
 
-	if d.IsDir() {
-		addDirToWatcher(path, watchedDirs)
-	}
+		if d.IsDir() {
+			addDirToWatcher(path, watchedDirs)
+		}
 
-	return err
+		return err
+	}
 }
 
 func trackSymlinks(filename string) {
@@ -274,6 +314,10 @@ func handleFileRemove(path string) {
 }
 
 func addNewEntry(path string) {
+	addEntry(files, path)
+}
+
+func addEntry(target map[string]*DesktopFile, path string) {
 	if origin, sym := isSymlink(path); sym {
 		// check the file the symlink points to actually exists
 		// otherwise it'll panic if you point to a location that's invalid
@@ -285,7 +329,7 @@ func addNewEntry(path string) {
 
 	filesMu.Lock()
 	if f, err := parseFile(path, langLocale, regionLocale); err == nil {
-		files[filepath.Base(path)] = f
+		target[filepath.Base(path)] = f
 	} else {
 		slog.Error(Name, "parsing", err)
 	}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+type Entry struct {
+	Doc  string
+	Name string
+	Path string
+	Type string
+}
+
+var (
+	client = http.Client{Timeout: 30 * time.Second}
+
+	indexMu    sync.RWMutex
+	entries    []Entry
+	indexSize  int64
+	refreshing atomic.Bool
+
+	// rendered previews by doc and path, as every keystroke renders the best matches
+	previewMu sync.Mutex
+	previews  = map[string]page{}
+)
+
+func indexFile(doc string) string {
+	return common.CacheFile(filepath.Join(Name, doc+".json"))
+}
+
+// pageFile is the cached html of the page. Paths come from the index, cleaning keeps them in the cache.
+func pageFile(doc, path string) string {
+	return common.CacheFile(filepath.Join(Name, "pages", doc, filepath.Clean("/"+path)+".html"))
+}
+
+func docsURL() string {
+	return strings.TrimSuffix(config.DocsURL, "/")
+}
+
+func stale(file string) bool {
+	info, err := os.Stat(file)
+
+	return err != nil || time.Since(info.ModTime()) > time.Duration(config.RefreshInterval)*24*time.Hour
+}
+
+// download writes the response to the file, replacing it only if the download succeeded.
+func download(u, file string) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("User-Agent", "elephant")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", u, resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+
+	tmp := file + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
+
+// refresh downloads the indexes of the docs older than refresh_interval, or all with force, and loads them.
+func refresh(force bool) {
+	if !refreshing.CompareAndSwap(false, true) {
+		return
+	}
+	defer refreshing.Store(false)
+
+	updated := 0
+
+	for _, doc := range config.Docs {
+		file := indexFile(doc)
+
+		if !force && !stale(file) {
+			continue
+		}
+
+		if err := download(fmt.Sprintf("%s/%s/index.json", docsURL(), doc), file); err != nil {
+			slog.Error(Name, "index", err)
+			continue
+		}
+
+		updated++
+	}
+
+	if updated == 0 && loaded() {
+		return
+	}
+
+	load()
+
+	previewMu.Lock()
+	clear(previews)
+	previewMu.Unlock()
+
+	slog.Info(Name, "docs", len(config.Docs), "updated", updated)
+
+	handlers.ProviderUpdated <- Name
+}
+
+func loaded() bool {
+	indexMu.RLock()
+	defer indexMu.RUnlock()
+
+	return entries != nil
+}
+
+// load reads the downloaded indexes.
+func load() {
+	res := []Entry{}
+	size := int64(0)
+
+	for _, doc := range config.Docs {
+		b, err := os.ReadFile(indexFile(doc))
+		if err != nil {
+			continue
+		}
+
+		var index struct {
+			Entries []struct {
+				Name string `json:"name"`
+				Path string `json:"path"`
+				Type string `json:"type"`
+			} `json:"entries"`
+		}
+
+		if err := json.Unmarshal(b, &index); err != nil {
+			slog.Error(Name, "index", fmt.Errorf("%s: %w", doc, err))
+			continue
+		}
+
+		for _, v := range index.Entries {
+			res = append(res, Entry{Doc: doc, Name: v.Name, Path: v.Path, Type: v.Type})
+			size += int64(len(doc) + len(v.Name) + len(v.Path) + len(v.Type))
+		}
+	}
+
+	indexMu.Lock()
+	entries, indexSize = res, size
+	indexMu.Unlock()
+}
+
+// evict drops the entries, they're read from the downloaded indexes again when needed.
+func evict() {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+
+	entries, indexSize = nil, 0
+}
+
+func index() []Entry {
+	if !loaded() {
+		load()
+	}
+
+	indexMu.RLock()
+	defer indexMu.RUnlock()
+
+	return entries
+}
+
+func findEntry(doc, path string) (Entry, bool) {
+	for _, v := range index() {
+		if v.Doc == doc && v.Path == path {
+			return v, true
+		}
+	}
+
+	return Entry{}, false
+}
+
+// preview renders the part of the page the entry points to. Pages are downloaded when first shown.
+func preview(e Entry) (page, error) {
+	key := e.Doc + "/" + e.Path
+
+	previewMu.Lock()
+	p, ok := previews[key]
+	previewMu.Unlock()
+
+	if ok {
+		return p, nil
+	}
+
+	path, anchor, _ := strings.Cut(e.Path, "#")
+	file := pageFile(e.Doc, path)
+
+	if stale(file) {
+		if err := download(fmt.Sprintf("%s/%s/%s.html", docsURL(), e.Doc, path), file); err != nil && !common.FileExists(file) {
+			return page{}, err
+		}
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return page{}, err
+	}
+	defer f.Close()
+
+	p = render(f, anchor)
+
+	previewMu.Lock()
+	if len(previews) > 500 {
+		clear(previews)
+	}
+
+	previews[key] = p
+	previewMu.Unlock()
+
+	return p, nil
+}
+
+// cleanPages removes downloaded pages that weren't refreshed within refresh_interval, f.e. of removed docs.
+func cleanPages() {
+	filepath.WalkDir(common.CacheFile(filepath.Join(Name, "pages")), func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && stale(path) {
+			os.Remove(path)
+		}
+
+		return nil
+	})
+}
+
+// docName formats slugs like "python~3.12" as "python 3.12".
+func docName(doc string) string {
+	return strings.ReplaceAll(doc, "~", " ")
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// previews longer than this are cut, documentation pages can be huge
+const maxPreview = 16 << 10
+
+var (
+	blockElements   = []string{"p", "div", "section", "ul", "ol", "dl", "dt", "dd", "table", "tr", "blockquote", "br", "hr"}
+	headingElements = []string{"h1", "h2", "h3", "h4", "h5", "h6"}
+)
+
+// page is the rendered documentation, pango markup for the preview and the code blocks to copy.
+type page struct {
+	markup string
+	blocks []string
+}
+
+// render converts the html to pango markup. With an anchor, only the part starting at the element with that id
+// until the next heading is rendered, as f.e. the functions of a go package share a page. Code blocks are
+// numbered, so they can be copied by their number.
+func render(r io.Reader, anchor string) page {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	var b strings.Builder
+	var res page
+
+	capturing := anchor == ""
+	skip := 0
+
+	var pre *strings.Builder
+	preLang := ""
+
+	// a heading of the level of the anchors one or above ends its section
+	sectionLevel := 0
+
+	newline := func() {
+		s := b.String()
+
+		if s != "" && !strings.HasSuffix(s, "\n\n") {
+			if strings.HasSuffix(s, "\n") {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+	}
+
+	for b.Len() < maxPreview {
+		t, err := d.Token()
+		if err != nil {
+			break
+		}
+
+		switch v := t.(type) {
+		case xml.StartElement:
+			name := v.Name.Local
+
+			if !capturing {
+				if attr(v, "id") != anchor {
+					continue
+				}
+
+				capturing = true
+
+				// sections of other elements end at any heading
+				sectionLevel = len(headingElements)
+
+				if i := slices.Index(headingElements, name); i >= 0 {
+					sectionLevel = i + 1
+				}
+			} else if i := slices.Index(headingElements, name); i >= 0 && i+1 <= sectionLevel {
+				return finish(b.String(), res)
+			}
+
+			switch {
+			case name == "script" || name == "style":
+				skip++
+			case name == "pre":
+				pre = &strings.Builder{}
+				preLang = language(v)
+			case pre != nil:
+			case slices.Contains(headingElements, name):
+				newline()
+				b.WriteString("<b><big>")
+			case name == "li":
+				if !strings.HasSuffix(b.String(), "\n") && b.Len() > 0 {
+					b.WriteString("\n")
+				}
+
+				b.WriteString("• ")
+			case name == "code" || name == "tt" || name == "kbd":
+				b.WriteString("<tt>")
+			case name == "b" || name == "strong":
+				b.WriteString("<b>")
+			case name == "i" || name == "em" || name == "var":
+				b.WriteString("<i>")
+			case slices.Contains(blockElements, name):
+				newline()
+			case name == "td" || name == "th":
+				b.WriteString(" ")
+			}
+		case xml.EndElement:
+			if !capturing {
+				continue
+			}
+
+			name := v.Name.Local
+
+			switch {
+			case name == "script" || name == "style":
+				skip--
+			case name == "pre":
+				if pre == nil {
+					continue
+				}
+
+				code := strings.Trim(pre.String(), "\n")
+				pre = nil
+
+				if code == "" {
+					continue
+				}
+
+				res.blocks = append(res.blocks, code)
+
+				newline()
+				fmt.Fprintf(&b, "<span foreground=\"%s\">[%d]</span>\n<tt>%s</tt>", colorComment, len(res.blocks), highlight(code, preLang))
+				newline()
+			case pre != nil:
+			case slices.Contains(headingElements, name):
+				b.WriteString("</big></b>")
+				newline()
+			case name == "code" || name == "tt" || name == "kbd":
+				b.WriteString("</tt>")
+			case name == "b" || name == "strong":
+				b.WriteString("</b>")
+			case name == "i" || name == "em" || name == "var":
+				b.WriteString("</i>")
+			case name == "li":
+				b.WriteString("\n")
+			case slices.Contains(blockElements, name):
+				newline()
+			}
+		case xml.CharData:
+			if !capturing || skip > 0 {
+				continue
+			}
+
+			if pre != nil {
+				pre.Write(v)
+				continue
+			}
+
+			text := strings.Join(strings.Fields(string(v)), " ")
+
+			if text == "" {
+				if len(v) > 0 && !strings.HasSuffix(b.String(), " ") && !strings.HasSuffix(b.String(), "\n") {
+					b.WriteString(" ")
+				}
+
+				continue
+			}
+
+			// keep the spaces around inline elements
+			if unicode.IsSpace(rune(v[0])) && !strings.HasSuffix(b.String(), "\n") {
+				b.WriteString(" ")
+			}
+
+			b.WriteString(html.EscapeString(text))
+
+			if unicode.IsSpace(rune(v[len(v)-1])) {
+				b.WriteString(" ")
+			}
+		}
+	}
+
+	return finish(b.String(), res)
+}
+
+func finish(markup string, p page) page {
+	p.markup = strings.TrimSpace(markup)
+
+	if len(p.markup) >= maxPreview {
+		p.markup += "…"
+	}
+
+	return p
+}
+
+func attr(e xml.StartElement, name string) string {
+	for _, v := range e.Attr {
+		if v.Name.Local == name {
+			return v.Value
+		}
+	}
+
+	return ""
+}
+
+// language reads the language of a code block, from devdocs' data-language or "lang-*" classes.
+func language(e xml.StartElement) string {
+	if l := attr(e, "data-language"); l != "" {
+		return l
+	}
+
+	for v := range strings.FieldsSeq(attr(e, "class")) {
+		if l, ok := strings.CutPrefix(v, "lang-"); ok {
+			return l
+		}
+
+		if l, ok := strings.CutPrefix(v, "language-"); ok {
+			return l
+		}
+	}
+
+	return ""
+}
+
+const (
+	colorKeyword = "#c678dd"
+	colorString  = "#98c379"
+	colorComment = "#7f848e"
+	colorNumber  = "#d19a66"
+)
+
+// keywords of common languages. Highlighting doesn't need to be exact, it's just a preview.
+var keywords = map[string]bool{}
+
+func init() {
+	for v := range strings.FieldsSeq(`break case catch class const continue def default defer delete do elif else enum
+		except export extends false finally fn for func function go if impl import in interface is lambda let match
+		mod module mut new nil none null package pass pub raise return self static struct super switch this throw
+		trait true try type typeof undefined use var void while with yield async await select range chan map
+		None True False and or not from as`) {
+		keywords[v] = true
+	}
+}
+
+// languages using "#" for comments. Elsewhere it's f.e. a preprocessor directive or a selector.
+var hashComments = []string{"python", "py", "ruby", "rb", "bash", "sh", "shell", "console", "yaml", "yml", "perl", "r", "toml", "nix", "elixir", "dockerfile", "make", "makefile", "powershell", "coffeescript"}
+
+// highlight escapes the code and colors keywords, strings, numbers and comments.
+func highlight(code, lang string) string {
+	var b strings.Builder
+
+	lang = strings.ToLower(lang)
+	hash := slices.Contains(hashComments, lang)
+	dashes := lang == "sql" || lang == "lua" || lang == "haskell"
+	// lifetimes look like quotes
+	singleQuotes := lang != "rust"
+
+	span := func(color, s string) {
+		fmt.Fprintf(&b, "<span foreground=\"%s\">%s</span>", color, html.EscapeString(s))
+	}
+
+	r := []rune(code)
+
+	for i := 0; i < len(r); {
+		c := r[i]
+		rest := string(r[i:min(i+2, len(r))])
+
+		switch {
+		case rest == "//" || hash && c == '#' || dashes && rest == "--":
+			end := i
+
+			for end < len(r) && r[end] != '\n' {
+				end++
+			}
+
+			span(colorComment, string(r[i:end]))
+			i = end
+		case rest == "/*":
+			end := i + 2
+
+			for end < len(r) && !(r[end-1] == '*' && r[end] == '/' && end > i+2) {
+				end++
+			}
+
+			end = min(end+1, len(r))
+
+			span(colorComment, string(r[i:end]))
+			i = end
+		case c == '"' || c == '`' || c == '\'' && singleQuotes:
+			end := i + 1
+
+			for end < len(r) && r[end] != c && (c == '`' || r[end] != '\n') {
+				if r[end] == '\\' {
+					end++
+				}
+
+				end++
+			}
+
+			// unterminated quotes, f.e. rust lifetimes, aren't strings
+			if end >= len(r) || r[end] != c {
+				b.WriteString(html.EscapeString(string(c)))
+				i++
+				continue
+			}
+
+			span(colorString, string(r[i:end+1]))
+			i = end + 1
+		case unicode.IsDigit(c) && (i == 0 || !isIdent(r[i-1])):
+			end := i
+
+			for end < len(r) && (isIdent(r[end]) || r[end] == '.') {
+				end++
+			}
+
+			span(colorNumber, string(r[i:end]))
+			i = end
+		case isIdent(c):
+			end := i
+
+			for end < len(r) && isIdent(r[end]) {
+				end++
+			}
+
+			word := string(r[i:end])
+
+			if keywords[word] {
+				span(colorKeyword, word)
+			} else {
+				b.WriteString(html.EscapeString(word))
+			}
+
+			i = end
+		default:
+			b.WriteString(html.EscapeString(string(c)))
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+func isIdent(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
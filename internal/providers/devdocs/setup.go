@@ -0,0 +1,363 @@
+// Package devdocs searches api documentation of devdocs.io and answers on stack exchange sites.
+package main
+
+import (
+	"cmp"
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/memory"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "devdocs"
+	NamePretty = "DevDocs"
+	config     *Config
+
+	// queries debounces the stack exchange queries
+	queries common.Debouncer
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config       `koanf:",squash"`
+	Docs                []string `koanf:"docs" desc:"slugs of the docs to search, as in devdocs.io urls, f.e. 'python~3.12'" default:"[\"go\", \"javascript\", \"css\", \"html\"]"`
+	DocsURL             string   `koanf:"docs_url" desc:"url the indexes and pages of the docs are downloaded from. for a local devdocs, f.e. 'http://localhost:9292/docs'" default:"https://documents.devdocs.io"`
+	SiteURL             string   `koanf:"site_url" desc:"url entries are opened at" default:"https://devdocs.io"`
+	RefreshInterval     int      `koanf:"refresh_interval" desc:"days after which indexes and pages are downloaded again" default:"7"`
+	MaxResults          int      `koanf:"max_results" desc:"maximum number of entries or questions returned" default:"20"`
+	Previews            int      `koanf:"previews" desc:"number of best matching entries that get a preview" default:"5"`
+	StackExchangePrefix string   `koanf:"stackexchange_prefix" desc:"prefix that searches stack exchange instead" default:"so "`
+	StackExchangeSite   string   `koanf:"stackexchange_site" desc:"stack exchange site to search, f.e. 'superuser' or 'unix'" default:"stackoverflow"`
+	StackExchangeKey    string   `koanf:"stackexchange_key" desc:"api key for a higher request quota" default:""`
+	Debounce            int      `koanf:"debounce" desc:"milliseconds to wait for further typing before searching stack exchange" default:"400"`
+	CacheTTL            int      `koanf:"cache_ttl" desc:"minutes stack exchange searches are cached" default:"30"`
+}
+
+const (
+	ActionOpen     = "open"
+	ActionCopyCode = "copy_code"
+	ActionRefresh  = "refresh"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "accessories-dictionary-symbolic",
+			MinScore: 30,
+		},
+		Docs:                []string{"go", "javascript", "css", "html"},
+		DocsURL:             "https://documents.devdocs.io",
+		SiteURL:             "https://devdocs.io",
+		RefreshInterval:     7,
+		MaxResults:          20,
+		Previews:            5,
+		StackExchangePrefix: "so ",
+		StackExchangeSite:   "stackoverflow",
+		Debounce:            400,
+		CacheTTL:            30,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	memory.Register(memory.Index{
+		Name: Name,
+		Size: func() int64 {
+			indexMu.RLock()
+			defer indexMu.RUnlock()
+
+			return indexSize
+		},
+		Evict: evict,
+	})
+
+	go func() {
+		cleanPages()
+		refresh(false)
+	}()
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+var block = &pb.ActionArgument{Name: "block", Type: util.ArgumentText, Placeholder: "Code block, f.e. 2"}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionOpen, Label: "Open", Icon: "web-browser", Default: true},
+		{Action: ActionCopyCode, Label: "Copy code", Icon: "edit-copy", Arguments: []*pb.ActionArgument{block}},
+		{Action: ActionRefresh, Label: "Refresh docs", Icon: "view-refresh"},
+	}
+}
+
+// Identifiers are "se:<question id>" for questions and "<doc>/<path>" for entries.
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionOpen
+	}
+
+	if action == ActionRefresh {
+		go refresh(true)
+		return
+	}
+
+	link, p, err := resolve(identifier)
+	if err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	var cmd *exec.Cmd
+
+	switch action {
+	case ActionOpen:
+		cmd = common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix("", Name, identifier), common.OpenCommand(), common.Quote(link))))
+		common.Detach(cmd)
+	case ActionCopyCode:
+		n := 1
+
+		if args = strings.TrimSpace(args); args != "" {
+			n, err = strconv.Atoi(args)
+			if err != nil {
+				slog.Error(Name, "copy_code", fmt.Sprintf("invalid block: %s", args))
+				return
+			}
+		}
+
+		if n < 1 || n > len(p.blocks) {
+			slog.Error(Name, "copy_code", fmt.Sprintf("no code block %d of %d", n, len(p.blocks)))
+			return
+		}
+
+		cmd = common.CopyCmd(p.blocks[n-1])
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	if err := cmd.Start(); err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+}
+
+// resolve returns the url and rendered page of the entry or question.
+func resolve(identifier string) (string, page, error) {
+	if v, ok := strings.CutPrefix(identifier, "se:"); ok {
+		id, _ := strconv.Atoi(v)
+
+		q, ok := question(id)
+		if !ok {
+			return "", page{}, fmt.Errorf("unknown question: %s", identifier)
+		}
+
+		return q.Link, render(strings.NewReader(q.Answer), ""), nil
+	}
+
+	doc, path, _ := strings.Cut(identifier, "/")
+
+	e, ok := findEntry(doc, path)
+	if !ok {
+		return "", page{}, fmt.Errorf("unknown entry: %s", identifier)
+	}
+
+	p, err := preview(e)
+	if err != nil {
+		slog.Error(Name, "preview", err)
+	}
+
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(config.SiteURL, "/"), doc, path), p, nil
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	if config.StackExchangePrefix != "" {
+		if q, ok := strings.CutPrefix(query, config.StackExchangePrefix); ok {
+			return queryStackExchange(strings.TrimSpace(q))
+		}
+	}
+
+	entries := []*pb.QueryResponse_Item{}
+
+	if query == "" {
+		return entries
+	}
+
+	memory.Touch(Name)
+
+	for _, v := range index() {
+		score, pos, start := common.FuzzyScore(query, v.Name, exact)
+
+		if score <= config.MinScore {
+			continue
+		}
+
+		entries = append(entries, &pb.QueryResponse_Item{
+			Identifier: v.Doc + "/" + v.Path,
+			Text:       v.Name,
+			Subtext:    strings.Join(nonEmpty(docName(v.Doc), v.Type), " · "),
+			Provider:   Name,
+			Icon:       config.Icon,
+			Actions:    []string{ActionOpen},
+			Type:       pb.QueryResponse_REGULAR,
+			Score:      score,
+			Fuzzyinfo: &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			},
+		})
+	}
+
+	slices.SortStableFunc(entries, func(a, b *pb.QueryResponse_Item) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	if len(entries) > config.MaxResults {
+		entries = entries[:config.MaxResults]
+	}
+
+	// pages are downloaded for the best matches only
+	var wg sync.WaitGroup
+
+	for _, e := range entries[:min(config.Previews, len(entries))] {
+		wg.Go(func() {
+			doc, path, _ := strings.Cut(e.Identifier, "/")
+
+			p, err := preview(Entry{Doc: doc, Path: path})
+			if err != nil {
+				slog.Error(Name, "preview", err)
+				return
+			}
+
+			setPreview(e, p)
+		})
+	}
+
+	wg.Wait()
+
+	return entries
+}
+
+func queryStackExchange(query string) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	if query == "" {
+		return entries
+	}
+
+	if _, ok := cachedQuestions(query); !queries.Wait(config.Debounce, ok) {
+		return entries
+	}
+
+	questions, err := searchQuestions(query)
+	if err != nil {
+		slog.Error(Name, "stackexchange", err)
+		return entries
+	}
+
+	// the api sorted by relevance
+	for k, v := range questions {
+		subtext := fmt.Sprintf("%d votes · %d answers", v.Score, v.Answers)
+
+		if v.Accepted {
+			subtext += " · accepted"
+		}
+
+		if len(v.Tags) > 0 {
+			subtext += " · " + strings.Join(v.Tags, ", ")
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier: fmt.Sprintf("se:%d", v.ID),
+			Text:       v.Title,
+			Subtext:    subtext,
+			Provider:   Name,
+			Icon:       config.Icon,
+			Actions:    []string{ActionOpen},
+			Type:       pb.QueryResponse_REGULAR,
+			Score:      int32(len(questions) - k),
+		}
+
+		if v.Answer != "" {
+			setPreview(e, render(strings.NewReader(v.Answer), ""))
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func setPreview(e *pb.QueryResponse_Item, p page) {
+	if p.markup == "" {
+		return
+	}
+
+	e.Preview = p.markup
+	e.PreviewType = util.PreviewTypePango
+
+	if len(p.blocks) > 0 {
+		e.Actions = append(e.Actions, ActionCopyCode)
+	}
+}
+
+func nonEmpty(values ...string) []string {
+	res := []string{}
+
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	states := []string{}
+
+	if refreshing.Load() {
+		states = append(states, "refreshing")
+	}
+
+	return &pb.ProviderStateResponse{
+		States:  states,
+		Actions: []string{ActionRefresh},
+	}
+}
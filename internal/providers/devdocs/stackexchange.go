@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const stackExchangeAPI = "https://api.stackexchange.com/2.3"
+
+type Question struct {
+	ID      int
+	Title   string
+	Link    string
+	Score   int
+	Answers int
+	Tags    []string
+	// Answer is the html of the accepted or, without one, the best voted answer.
+	Answer   string
+	Accepted bool
+}
+
+type cachedSearch struct {
+	fetched   time.Time
+	questions []int
+}
+
+var (
+	seMu        sync.Mutex
+	seSearches  = map[string]cachedSearch{}
+	seQuestions = map[int]Question{}
+)
+
+func seGet(path string, query url.Values, v any) error {
+	query.Set("site", config.StackExchangeSite)
+
+	if config.StackExchangeKey != "" {
+		query.Set("key", config.StackExchangeKey)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, stackExchangeAPI+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("User-Agent", "elephant")
+
+	// responses are always compressed, the transport decompresses them as it asks for gzip itself
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"error_message"`
+		}
+
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+
+		return fmt.Errorf("stackexchange: %s %s", resp.Status, apiErr.Message)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// cachedQuestions returns the questions of the query if it was searched within cache_ttl.
+func cachedQuestions(query string) ([]Question, bool) {
+	seMu.Lock()
+	defer seMu.Unlock()
+
+	c, ok := seSearches[query]
+	if !ok || time.Since(c.fetched) >= time.Duration(config.CacheTTL)*time.Minute {
+		return nil, false
+	}
+
+	res := []Question{}
+
+	for _, id := range c.questions {
+		res = append(res, seQuestions[id])
+	}
+
+	return res, true
+}
+
+// searchQuestions finds answered questions and fetches their answers with a second request.
+func searchQuestions(query string) ([]Question, error) {
+	if res, ok := cachedQuestions(query); ok {
+		return res, nil
+	}
+
+	var search struct {
+		Items []struct {
+			ID       int      `json:"question_id"`
+			Title    string   `json:"title"`
+			Link     string   `json:"link"`
+			Score    int      `json:"score"`
+			Answers  int      `json:"answer_count"`
+			Tags     []string `json:"tags"`
+			Accepted int      `json:"accepted_answer_id"`
+		} `json:"items"`
+	}
+
+	err := seGet("/search/advanced", url.Values{
+		"q":        {query},
+		"order":    {"desc"},
+		"sort":     {"relevance"},
+		"answers":  {"1"},
+		"pagesize": {strconv.Itoa(config.MaxResults)},
+	}, &search)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Question{}
+	ids := []string{}
+
+	for _, v := range search.Items {
+		res = append(res, Question{
+			ID:      v.ID,
+			Title:   html.UnescapeString(v.Title),
+			Link:    v.Link,
+			Score:   v.Score,
+			Answers: v.Answers,
+			Tags:    v.Tags,
+		})
+
+		ids = append(ids, strconv.Itoa(v.ID))
+	}
+
+	if len(ids) > 0 {
+		var answers struct {
+			Items []struct {
+				Question int    `json:"question_id"`
+				Accepted bool   `json:"is_accepted"`
+				Body     string `json:"body"`
+			} `json:"items"`
+		}
+
+		// sorted by votes, so the first answer of a question is its best voted one
+		err := seGet("/questions/"+strings.Join(ids, ";")+"/answers", url.Values{
+			"order":    {"desc"},
+			"sort":     {"votes"},
+			"filter":   {"withbody"},
+			"pagesize": {"100"},
+		}, &answers)
+		if err != nil {
+			return nil, err
+		}
+
+		for k, q := range res {
+			for _, a := range answers.Items {
+				if a.Question != q.ID || res[k].Accepted {
+					continue
+				}
+
+				if res[k].Answer == "" || a.Accepted {
+					res[k].Answer = a.Body
+					res[k].Accepted = a.Accepted
+				}
+			}
+		}
+	}
+
+	seMu.Lock()
+	defer seMu.Unlock()
+
+	// older searches are fetched again
+	if len(seQuestions) > 1000 {
+		clear(seQuestions)
+		clear(seSearches)
+	}
+
+	c := cachedSearch{fetched: time.Now()}
+
+	for _, v := range res {
+		seQuestions[v.ID] = v
+		c.questions = append(c.questions, v.ID)
+	}
+
+	seSearches[query] = c
+
+	return res, nil
+}
+
+func question(id int) (Question, bool) {
+	seMu.Lock()
+	defer seMu.Unlock()
+
+	q, ok := seQuestions[id]
+
+	return q, ok
+}
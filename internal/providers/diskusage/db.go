@@ -0,0 +1,144 @@
+//go:build linux
+
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common/store"
+)
+
+var db *store.Store
+
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS entries (
+		path TEXT PRIMARY KEY,
+		parent TEXT NOT NULL,
+		root TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		dir INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_entries_parent ON entries(parent, size DESC);
+	CREATE INDEX IF NOT EXISTS idx_entries_root ON entries(root);
+	CREATE TABLE IF NOT EXISTS roots (
+		root TEXT PRIMARY KEY,
+		scanned INTEGER NOT NULL
+	);`,
+}
+
+type Entry struct {
+	Path   string
+	Parent string
+	Size   int64
+	Dir    bool
+}
+
+func openDB() error {
+	var err error
+
+	db, err = store.Open(Name, migrations...)
+
+	return err
+}
+
+// putRoot replaces the entries of the root with the scanned ones.
+func putRoot(root string, entries []Entry) error {
+	return db.Tx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM entries WHERE root = ?", root); err != nil {
+			return err
+		}
+
+		stmt, err := db.Stmt("INSERT OR REPLACE INTO entries (path, parent, root, size, dir) VALUES (?, ?, ?, ?, ?)")
+		if err != nil {
+			return err
+		}
+
+		stmt = tx.Stmt(stmt)
+		defer stmt.Close()
+
+		for _, e := range entries {
+			if _, err := stmt.Exec(e.Path, e.Parent, root, e.Size, e.Dir); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec("INSERT OR REPLACE INTO roots (root, scanned) VALUES (?, ?)", root, time.Now().Unix())
+
+		return err
+	})
+}
+
+// scanned returns when the root was scanned last, the zero time if never.
+func scanned(root string) time.Time {
+	var unix int64
+
+	if err := db.QueryRow("SELECT scanned FROM roots WHERE root = ?", root).Scan(&unix); err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(unix, 0)
+}
+
+// children returns the entries in the directory, largest first.
+func children(parent string) ([]Entry, error) {
+	rows, err := db.Query("SELECT path, size, dir FROM entries WHERE parent = ? ORDER BY size DESC", parent)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []Entry{}
+
+	for rows.Next() {
+		e := Entry{Parent: parent}
+
+		if err := rows.Scan(&e.Path, &e.Size, &e.Dir); err != nil {
+			return nil, err
+		}
+
+		res = append(res, e)
+	}
+
+	return res, rows.Err()
+}
+
+func getEntry(path string) *Entry {
+	e := Entry{Path: path}
+
+	err := db.QueryRow("SELECT parent, size, dir FROM entries WHERE path = ?", path).Scan(&e.Parent, &e.Size, &e.Dir)
+	if err != nil {
+		return nil
+	}
+
+	return &e
+}
+
+// removeEntry deletes the entry and everything below it and subtracts its size from its ancestors.
+func removeEntry(e *Entry) error {
+	return db.Tx(func(tx *sql.Tx) error {
+		prefix := e.Path + string(filepath.Separator)
+
+		if _, err := tx.Exec("DELETE FROM entries WHERE path = ? OR substr(path, 1, ?) = ?", e.Path, len(prefix), prefix); err != nil {
+			return err
+		}
+
+		for parent := e.Parent; parent != ""; {
+			var next string
+
+			err := tx.QueryRow("UPDATE entries SET size = max(size - ?, 0) WHERE path = ? RETURNING parent", e.Size, parent).Scan(&next)
+			if err == sql.ErrNoRows {
+				break
+			}
+
+			if err != nil {
+				return err
+			}
+
+			parent = next
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,136 @@
+//go:build linux
+
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+)
+
+var scanning atomic.Bool
+
+// roots returns the configured roots as clean absolute paths.
+func roots() []string {
+	res := []string{}
+
+	for _, v := range config.Roots {
+		if v == "~" || strings.HasPrefix(v, "~/") {
+			home, _ := os.UserHomeDir()
+			v = filepath.Join(home, strings.TrimPrefix(v, "~"))
+		}
+
+		res = append(res, filepath.Clean(v))
+	}
+
+	return res
+}
+
+// rescan scans roots older than the rescan interval, or all if forced.
+func rescan(force bool) {
+	if !scanning.CompareAndSwap(false, true) {
+		return
+	}
+	defer scanning.Store(false)
+
+	updated := false
+
+	for _, root := range roots() {
+		if !force && time.Since(scanned(root)) < time.Duration(config.RescanInterval)*time.Minute {
+			continue
+		}
+
+		start := time.Now()
+
+		entries, err := scan(root)
+		if err != nil {
+			slog.Error(Name, "scan", err, "root", root)
+			continue
+		}
+
+		if err := putRoot(root, entries); err != nil {
+			slog.Error(Name, "scan", err, "root", root)
+			continue
+		}
+
+		updated = true
+
+		slog.Info(Name, "scanned", root, "entries", len(entries), "time", time.Since(start))
+	}
+
+	if updated {
+		handlers.ProviderUpdated <- Name
+	}
+}
+
+// scan walks the root like du, summing the allocated size of directories. Directories and files of at least
+// min_file_size are returned.
+func scan(root string) ([]Entry, error) {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	minSize := int64(config.MinFileSize) * 1024 * 1024
+
+	var walk func(path, parent string, dev uint64) int64
+
+	walk = func(path, parent string, dev uint64) int64 {
+		files, err := os.ReadDir(path)
+		if err != nil {
+			slog.Debug(Name, "scan", err)
+		}
+
+		total := int64(0)
+
+		for _, f := range files {
+			info, err := f.Info()
+			if err != nil {
+				continue
+			}
+
+			st, ok := info.Sys().(*syscall.Stat_t)
+			if !ok {
+				continue
+			}
+
+			child := filepath.Join(path, f.Name())
+
+			if f.IsDir() {
+				if config.OneFileSystem && st.Dev != dev {
+					continue
+				}
+
+				total += walk(child, path, st.Dev)
+				continue
+			}
+
+			size := st.Blocks * 512
+			total += size
+
+			if info.Mode().IsRegular() && size >= minSize {
+				entries = append(entries, Entry{Path: child, Parent: path, Size: size})
+			}
+		}
+
+		entries = append(entries, Entry{Path: path, Parent: parent, Size: total, Dir: true})
+
+		return total
+	}
+
+	dev := uint64(0)
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		dev = st.Dev
+	}
+
+	walk(root, "", dev)
+
+	return entries, nil
+}
@@ -0,0 +1,298 @@
+//go:build linux
+
+// Package diskusage shows the largest directories and files to free disk space.
+package main
+
+import (
+	"cmp"
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "diskusage"
+	NamePretty = "Disk Usage"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config  `koanf:",squash"`
+	Roots          []string `koanf:"roots" desc:"directories to scan, they shouldn't overlap" default:"['~']"`
+	MinFileSize    int      `koanf:"min_file_size" desc:"size in MiB from which files are listed" default:"10"`
+	OneFileSystem  bool     `koanf:"one_file_system" desc:"don't descend into other file systems, like 'du -x'" default:"true"`
+	RescanInterval int      `koanf:"rescan_interval" desc:"minutes after which the roots are scanned again" default:"60"`
+	TrashCommand   string   `koanf:"trash_command" desc:"command moving a path to the trash" default:"gio trash"`
+	MaxItems       int      `koanf:"max_items" desc:"maximum number of entries listed per directory" default:"50"`
+}
+
+const (
+	ActionExplore = "explore"
+	ActionOpen    = "open"
+	ActionTrash   = "trash"
+	ActionRescan  = "rescan"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "drive-harddisk",
+			MinScore: 20,
+		},
+		Roots:          []string{"~"},
+		MinFileSize:    10,
+		OneFileSystem:  true,
+		RescanInterval: 60,
+		TrashCommand:   "gio trash",
+		MaxItems:       50,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	if err := openDB(); err != nil {
+		slog.Error(Name, "db", err)
+		return
+	}
+
+	go func() {
+		for {
+			rescan(false)
+			time.Sleep(time.Minute)
+		}
+	}()
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionExplore, Label: "Show contents", Icon: "go-next", Default: true},
+		{Action: ActionOpen, Label: "Open", Icon: "document-open"},
+		{Action: ActionTrash, Label: "Move to trash", Icon: "user-trash"},
+		{Action: ActionRescan, Label: "Rescan", Icon: "view-refresh"},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == ActionRescan {
+		go rescan(true)
+		return
+	}
+
+	e := getEntry(identifier)
+	if e == nil {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown path: %s", identifier))
+		return
+	}
+
+	if action == "" {
+		action = ActionOpen
+
+		if e.Dir {
+			action = ActionExplore
+		}
+	}
+
+	switch action {
+	case ActionExplore:
+		if !e.Dir {
+			slog.Error(Name, "activate", fmt.Sprintf("not a directory: %s", identifier))
+			return
+		}
+
+		// clients open the directory like a submenu
+		handlers.ProviderUpdated <- fmt.Sprintf("%s:%s", Name, e.Path)
+	case ActionOpen:
+		cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix("", Name, identifier), common.OpenCommand(), common.Quote(e.Path))))
+		common.Detach(cmd)
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "activate", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+	case ActionTrash:
+		cmd := common.Shell(fmt.Sprintf("%s %s", config.TrashCommand, common.Quote(e.Path)))
+
+		if out, err := cmd.CombinedOutput(); err != nil {
+			slog.Error(Name, "trash", err, "msg", string(out))
+			return
+		}
+
+		if err := removeEntry(e); err != nil {
+			slog.Error(Name, "trash", err)
+		}
+
+		handlers.ProviderUpdated <- Name
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+// parseQuery splits queries of the form "<directory>:<filter>". Other queries filter the contents of the roots.
+func parseQuery(query string) (string, string) {
+	if !strings.HasPrefix(query, string(filepath.Separator)) {
+		return "", query
+	}
+
+	if i := strings.LastIndex(query, ":"); i != -1 {
+		return query[:i], query[i+1:]
+	}
+
+	return query, ""
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	if db == nil {
+		return entries
+	}
+
+	dir, query := parseQuery(query)
+
+	dirs := []string{filepath.Clean(dir)}
+	if dir == "" {
+		dirs = roots()
+	}
+
+	found := []Entry{}
+	total := int64(0)
+
+	for _, v := range dirs {
+		res, err := children(v)
+		if err != nil {
+			slog.Error(Name, "query", err)
+			continue
+		}
+
+		found = append(found, res...)
+
+		if e := getEntry(v); e != nil {
+			total += e.Size
+		}
+	}
+
+	slices.SortStableFunc(found, func(a, b Entry) int {
+		return cmp.Compare(b.Size, a.Size)
+	})
+
+	if config.MaxItems > 0 && len(found) > config.MaxItems {
+		found = found[:config.MaxItems]
+	}
+
+	for k, v := range found {
+		e := item(v, total)
+		e.Score = int32(len(found) - k)
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, e.Text, exact)
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func item(e Entry, total int64) *pb.QueryResponse_Item {
+	res := &pb.QueryResponse_Item{
+		Identifier: e.Path,
+		Text:       filepath.Base(e.Path),
+		Subtext:    formatBytes(e.Size),
+		Provider:   Name,
+		Icon:       "text-x-generic",
+		Actions:    []string{ActionOpen, ActionTrash},
+		Type:       pb.QueryResponse_REGULAR,
+	}
+
+	if total > 0 {
+		res.Subtext = fmt.Sprintf("%s, %.0f%%", res.Subtext, 100*float64(e.Size)/float64(total))
+	}
+
+	if e.Dir {
+		res.Icon = "folder"
+		res.Actions = []string{ActionExplore, ActionOpen, ActionTrash}
+	}
+
+	return res
+}
+
+func formatBytes(b int64) string {
+	const unit = 1024
+
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+// State is "scanning" while the roots are scanned.
+func State(provider string) *pb.ProviderStateResponse {
+	states := []string{}
+
+	if scanning.Load() {
+		states = append(states, "scanning")
+	}
+
+	return &pb.ProviderStateResponse{
+		States:  states,
+		Actions: []string{ActionRescan},
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/abenz1267/elephant/v2/pkg/common/store"
+)
+
+var db *store.Store
+
+// FTS5 needs the sqlite_fts5 build tag for elephant and every plugin, FTS4 is always compiled in.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS documents (
+		path TEXT PRIMARY KEY,
+		modified INTEGER NOT NULL
+	);
+	CREATE VIRTUAL TABLE IF NOT EXISTS pages USING fts4(path, page, content, notindexed=path, notindexed=page, tokenize=unicode61);`,
+}
+
+type Page struct {
+	Path    string
+	Page    int
+	Content string
+	Snippet string
+}
+
+func openDB() error {
+	var err error
+
+	db, err = store.Open(Name, migrations...)
+
+	return err
+}
+
+// indexed returns the indexed documents with their modification time.
+func indexed() (map[string]time.Time, error) {
+	rows, err := db.Query("SELECT path, modified FROM documents")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[string]time.Time)
+
+	for rows.Next() {
+		var path string
+		var modified int64
+
+		if err := rows.Scan(&path, &modified); err != nil {
+			return nil, err
+		}
+
+		res[path] = time.Unix(modified, 0)
+	}
+
+	return res, rows.Err()
+}
+
+// putDocument replaces the pages of the document.
+func putDocument(path string, modified time.Time, pages []string) error {
+	return db.Tx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM pages WHERE path = ?", path); err != nil {
+			return err
+		}
+
+		for k, v := range pages {
+			if strings.TrimSpace(v) == "" {
+				continue
+			}
+
+			if _, err := tx.Exec("INSERT INTO pages (path, page, content) VALUES (?, ?, ?)", path, k+1, v); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec("INSERT OR REPLACE INTO documents (path, modified) VALUES (?, ?)", path, modified.Unix())
+
+		return err
+	})
+}
+
+func removeDocument(path string) error {
+	return db.Tx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM pages WHERE path = ?", path); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec("DELETE FROM documents WHERE path = ?", path)
+
+		return err
+	})
+}
+
+// matchQuery turns the query into prefix terms that all have to match, so user input can't break the FTS syntax.
+func matchQuery(query string) string {
+	terms := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for k, v := range terms {
+		terms[k] = v + "*"
+	}
+
+	return strings.Join(terms, " ")
+}
+
+// search returns the matching pages, pages with more matches first.
+func search(query string, limit int) ([]Page, error) {
+	match := matchQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	rows, err := db.Query(`SELECT path, page, content, snippet(pages, '', '', '…', 2, 16) FROM pages
+		WHERE content MATCH ? ORDER BY length(offsets(pages)) DESC LIMIT ?`, match, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []Page{}
+
+	for rows.Next() {
+		var p Page
+
+		if err := rows.Scan(&p.Path, &p.Page, &p.Content, &p.Snippet); err != nil {
+			return nil, err
+		}
+
+		res = append(res, p)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// extract returns the text of the documents pages. Epub chapters count as pages, docx and odt documents are a
+// single page.
+func extract(path string) ([]string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pdf":
+		return extractPDF(path)
+	case ".epub":
+		return extractZip(path, func(name string) bool {
+			ext := strings.ToLower(filepath.Ext(name))
+			return ext == ".xhtml" || ext == ".html" || ext == ".htm"
+		})
+	case ".docx":
+		return extractZip(path, func(name string) bool {
+			return name == "word/document.xml"
+		})
+	case ".odt":
+		return extractZip(path, func(name string) bool {
+			return name == "content.xml"
+		})
+	default:
+		return nil, fmt.Errorf("unsupported document: %s", path)
+	}
+}
+
+// extractPDF runs pdftotext, which separates pages by form feeds.
+func extractPDF(path string) ([]string, error) {
+	out, err := exec.Command("pdftotext", "-q", "-enc", "UTF-8", path, "-").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(string(out), "\f"), nil
+}
+
+// extractZip returns the text of the matching xml files in the archive, one page per file in the order of the
+// archive.
+func extractZip(path string, match func(name string) bool) ([]string, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	res := []string{}
+
+	for _, f := range r.File {
+		if !match(f.Name) {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		text, err := xmlText(rc)
+		rc.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+
+		res = append(res, text)
+	}
+
+	return res, nil
+}
+
+// blocks end a line of text in xhtml, docx and odt documents.
+var blocks = []string{"p", "br", "div", "li", "tr", "h1", "h2", "h3", "h4", "h5", "h6", "h", "tab"}
+
+// xmlText returns the character data of the document, skipping scripts and styles.
+func xmlText(r io.Reader) (string, error) {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	var b strings.Builder
+
+	skip := 0
+
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return b.String(), err
+		}
+
+		switch v := t.(type) {
+		case xml.StartElement:
+			if v.Name.Local == "script" || v.Name.Local == "style" {
+				skip++
+			}
+		case xml.EndElement:
+			switch {
+			case v.Name.Local == "script" || v.Name.Local == "style":
+				skip--
+			case slices.Contains(blocks, v.Name.Local):
+				b.WriteString("\n")
+			}
+		case xml.CharData:
+			if skip == 0 {
+				b.Write(v)
+			}
+		}
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+)
+
+var indexing atomic.Bool
+
+// dirs returns the configured directories as clean absolute paths.
+func dirs() []string {
+	res := []string{}
+
+	for _, v := range config.Dirs {
+		if after, ok := strings.CutPrefix(v, "~/"); ok {
+			home, _ := os.UserHomeDir()
+			v = filepath.Join(home, after)
+		}
+
+		res = append(res, filepath.Clean(v))
+	}
+
+	return res
+}
+
+// index extracts documents that changed since they were indexed and removes the ones that are gone.
+func index() {
+	if !indexing.CompareAndSwap(false, true) {
+		return
+	}
+	defer indexing.Store(false)
+
+	start := time.Now()
+
+	known, err := indexed()
+	if err != nil {
+		slog.Error(Name, "index", err)
+		return
+	}
+
+	_, err = exec.LookPath("pdftotext")
+	hasPdftotext := err == nil
+
+	found := make(map[string]bool)
+	changed := 0
+
+	for _, dir := range dirs() {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if strings.HasPrefix(d.Name(), ".") && path != dir {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+
+			if d.IsDir() || !slices.Contains(config.Extensions, ext) || (ext == "pdf" && !hasPdftotext) {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+
+			found[path] = true
+
+			if modified, ok := known[path]; ok && modified.Equal(info.ModTime().Truncate(time.Second)) {
+				return nil
+			}
+
+			pages, err := extract(path)
+			if err != nil {
+				slog.Debug(Name, "extract", err, "path", path)
+			}
+
+			// documents that fail are stored without pages, so they aren't retried until they change
+			if err := putDocument(path, info.ModTime(), pages); err != nil {
+				slog.Error(Name, "index", err, "path", path)
+				return nil
+			}
+
+			changed++
+
+			return nil
+		})
+	}
+
+	for path := range known {
+		if found[path] {
+			continue
+		}
+
+		if err := removeDocument(path); err != nil {
+			slog.Error(Name, "index", err, "path", path)
+		}
+
+		changed++
+	}
+
+	if !hasPdftotext && slices.Contains(config.Extensions, "pdf") {
+		slog.Info(Name, "index", "pdftotext not found, skipping pdfs")
+	}
+
+	slog.Info(Name, "indexed", changed, "documents", len(found), "time", time.Since(start))
+
+	if changed > 0 {
+		handlers.ProviderUpdated <- Name
+	}
+}
@@ -0,0 +1,212 @@
+// Package documents searches the text of pdf, epub, docx and odt documents.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "documents"
+	NamePretty = "Documents"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config   `koanf:",squash"`
+	Dirs            []string `koanf:"dirs" desc:"directories to index" default:"['~/Documents']"`
+	Extensions      []string `koanf:"extensions" desc:"document types to index, pdfs need pdftotext" default:"['pdf', 'epub', 'docx', 'odt']"`
+	ReindexInterval int      `koanf:"reindex_interval" desc:"minutes between checks for changed documents" default:"30"`
+	MinQueryLength  int      `koanf:"min_query_length" desc:"minimum query length to search" default:"3"`
+	MaxResults      int      `koanf:"max_results" desc:"maximum number of matching pages" default:"50"`
+	PageCommand     string   `koanf:"page_command" desc:"opens a pdf at a page, %FILE% and %PAGE% are replaced. detected for zathura, okular and evince if empty" default:""`
+}
+
+const (
+	ActionOpen    = "open"
+	ActionReindex = "reindex"
+)
+
+// pageCommands open pdfs at a page, by viewer.
+var pageCommands = []struct {
+	viewer  string
+	command string
+}{
+	{"zathura", "zathura --page=%PAGE% %FILE%"},
+	{"okular", "okular --page %PAGE% %FILE%"},
+	{"evince", "evince --page-label=%PAGE% %FILE%"},
+}
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "x-office-document",
+			MinScore: 20,
+		},
+		Dirs:            []string{"~/Documents"},
+		Extensions:      []string{"pdf", "epub", "docx", "odt"},
+		ReindexInterval: 30,
+		MinQueryLength:  3,
+		MaxResults:      50,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	if config.PageCommand == "" {
+		for _, v := range pageCommands {
+			if _, err := exec.LookPath(v.viewer); err == nil {
+				config.PageCommand = v.command
+				break
+			}
+		}
+	}
+
+	if err := openDB(); err != nil {
+		slog.Error(Name, "db", err)
+		return
+	}
+
+	go func() {
+		for {
+			index()
+			time.Sleep(time.Duration(config.ReindexInterval) * time.Minute)
+		}
+	}()
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionOpen, Label: "Open", Icon: "document-open", Default: true},
+		{Action: ActionReindex, Label: "Reindex", Icon: "view-refresh"},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == ActionReindex {
+		go index()
+		return
+	}
+
+	if action != "" && action != ActionOpen {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	path, page := splitIdentifier(identifier)
+
+	run := fmt.Sprintf("%s %s", common.OpenCommand(), common.Quote(path))
+
+	if config.PageCommand != "" && page > 0 && strings.EqualFold(filepath.Ext(path), ".pdf") {
+		run = strings.ReplaceAll(config.PageCommand, "%PAGE%", strconv.Itoa(page))
+		run = strings.ReplaceAll(run, "%FILE%", common.Quote(path))
+	}
+
+	cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), run)))
+	common.Detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+}
+
+// splitIdentifier splits "<path>#<page>" identifiers.
+func splitIdentifier(identifier string) (string, int) {
+	i := strings.LastIndex(identifier, "#")
+	if i == -1 {
+		return identifier, 0
+	}
+
+	page, err := strconv.Atoi(identifier[i+1:])
+	if err != nil {
+		return identifier, 0
+	}
+
+	return identifier[:i], page
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	if db == nil || len(query) < config.MinQueryLength {
+		return entries
+	}
+
+	pages, err := search(query, config.MaxResults)
+	if err != nil {
+		slog.Error(Name, "query", err)
+		return entries
+	}
+
+	for k, v := range pages {
+		entries = append(entries, &pb.QueryResponse_Item{
+			Identifier:  fmt.Sprintf("%s#%d", v.Path, v.Page),
+			Text:        fmt.Sprintf("%s, page %d", filepath.Base(v.Path), v.Page),
+			Subtext:     strings.Join(strings.Fields(v.Snippet), " "),
+			Provider:    Name,
+			Icon:        config.Icon,
+			Actions:     []string{ActionOpen},
+			Preview:     v.Content,
+			PreviewType: util.PreviewTypeText,
+			Type:        pb.QueryResponse_REGULAR,
+			Score:       int32(config.MaxResults - k),
+		})
+	}
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+// State is "indexing" while documents are extracted.
+func State(provider string) *pb.ProviderStateResponse {
+	states := []string{}
+
+	if indexing.Load() {
+		states = append(states, "indexing")
+	}
+
+	return &pb.ProviderStateResponse{
+		States:  states,
+		Actions: []string{ActionReindex},
+	}
+}
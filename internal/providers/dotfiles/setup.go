@@ -0,0 +1,379 @@
+// Package dotfiles opens configured dotfiles in an editor and reloads the programs owning them.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "dotfiles"
+	NamePretty = "Dotfiles"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Files         []File `koanf:"files" desc:"dotfiles to list" default:"hyprland, sway, niri, waybar, shell rc files, elephant"`
+	Editor        string `koanf:"editor" desc:"command opening the file. supports %PATH%, otherwise it's appended" default:"$EDITOR in a terminal, $VISUAL if not set"`
+	MaxFiles      int    `koanf:"max_files" desc:"maximum number of files listed of a directory" default:"100"`
+}
+
+type File struct {
+	Path   string `koanf:"path" desc:"file, glob like '~/.config/hypr/*.conf' or directory" default:""`
+	Name   string `koanf:"name" desc:"displayed name, the path if empty" default:""`
+	Reload string `koanf:"reload" desc:"command reloading the program owning the file" default:""`
+	Icon   string `koanf:"icon" desc:"icon to display, falls back to global" default:""`
+}
+
+const (
+	ActionEdit       = "edit"
+	ActionEditReload = "edit_reload"
+	ActionReload     = "reload"
+	ActionCopyPath   = "copy_path"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "preferences-system",
+			MinScore: 30,
+		},
+		Files: []File{
+			{Path: "~/.config/hypr/*.conf", Name: "Hyprland", Reload: "hyprctl reload"},
+			{Path: "~/.config/sway/config", Name: "Sway", Reload: "swaymsg reload"},
+			{Path: "~/.config/niri/config.kdl", Name: "niri", Reload: "niri msg action load-config-file"},
+			{Path: "~/.config/waybar/*", Name: "Waybar", Reload: "pkill -SIGUSR2 waybar"},
+			{Path: "~/.bashrc"},
+			{Path: "~/.zshrc"},
+			{Path: "~/.config/fish/config.fish"},
+			{Path: "~/.config/elephant/*.toml", Name: "elephant"},
+		},
+		MaxFiles: 100,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionEdit, Label: "Edit", Icon: "document-edit", Default: true},
+		{Action: ActionEditReload, Label: "Edit and reload", Icon: "document-edit"},
+		{Action: ActionReload, Label: "Reload", Icon: "view-refresh"},
+		{Action: ActionCopyPath, Label: "Copy path", Icon: "edit-copy"},
+	}
+}
+
+func expand(path string) string {
+	if after, ok := strings.CutPrefix(path, "~/"); ok {
+		home, _ := os.UserHomeDir()
+		path = filepath.Join(home, after)
+	}
+
+	return path
+}
+
+// Match is an existing file of a configured entry.
+type Match struct {
+	Path  string
+	Entry int
+}
+
+// matches resolves the globs and directories of the configured files. Files of several entries belong to the
+// first.
+func matches() []Match {
+	res := []Match{}
+	seen := map[string]bool{}
+
+	add := func(path string, entry int) {
+		if !seen[path] {
+			seen[path] = true
+			res = append(res, Match{Path: path, Entry: entry})
+		}
+	}
+
+	for k, v := range config.Files {
+		paths, err := filepath.Glob(expand(v.Path))
+		if err != nil {
+			slog.Error(Name, "glob", err, "path", v.Path)
+			continue
+		}
+
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+
+			if !info.IsDir() {
+				add(path, k)
+				continue
+			}
+
+			n := 0
+
+			filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+				if n >= config.MaxFiles {
+					return filepath.SkipAll
+				}
+
+				if err != nil {
+					return nil
+				}
+
+				if d.IsDir() {
+					if file != path && strings.HasPrefix(d.Name(), ".") {
+						return filepath.SkipDir
+					}
+
+					return nil
+				}
+
+				if d.Type().IsRegular() || d.Type()&fs.ModeSymlink != 0 {
+					add(file, k)
+					n++
+				}
+
+				return nil
+			})
+		}
+	}
+
+	return res
+}
+
+func find(path string) (Match, bool) {
+	all := matches()
+
+	i := slices.IndexFunc(all, func(m Match) bool {
+		return m.Path == path
+	})
+
+	if i < 0 {
+		return Match{}, false
+	}
+
+	return all[i], true
+}
+
+// withPath replaces %PATH% of the command with the path, or appends it.
+func withPath(command, path string) string {
+	if strings.Contains(command, "%PATH%") {
+		return strings.ReplaceAll(command, "%PATH%", common.Quote(path))
+	}
+
+	return fmt.Sprintf("%s %s", command, common.Quote(path))
+}
+
+func editorCommand(path string) string {
+	if config.Editor != "" {
+		return withPath(config.Editor, path)
+	}
+
+	if v := os.Getenv("EDITOR"); v != "" {
+		return common.WrapWithTerminal(withPath(v, path))
+	}
+
+	if v := os.Getenv("VISUAL"); v != "" {
+		return withPath(v, path)
+	}
+
+	return ""
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionEdit
+	}
+
+	m, ok := find(identifier)
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown file: %s", identifier))
+		return
+	}
+
+	f := config.Files[m.Entry]
+
+	switch action {
+	case ActionEdit, ActionEditReload:
+		run := editorCommand(m.Path)
+		if run == "" {
+			slog.Error(Name, "edit", "neither editor, $EDITOR nor $VISUAL is set")
+			return
+		}
+
+		cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), run)))
+		common.Detach(cmd)
+		cmd.Dir = filepath.Dir(m.Path)
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "edit", err)
+			return
+		}
+
+		// terminals run until the editor exits, so the program can be reloaded afterwards
+		go func() {
+			cmd.Wait()
+
+			if action == ActionEditReload && f.Reload != "" {
+				reload(f)
+			}
+		}()
+	case ActionReload:
+		if f.Reload == "" {
+			slog.Error(Name, "reload", fmt.Sprintf("no reload command for %s", identifier))
+			return
+		}
+
+		go reload(f)
+	case ActionCopyPath:
+		cmd := common.CopyCmd(m.Path)
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "copy", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+func reload(f File) {
+	out, err := common.Shell(f.Reload).CombinedOutput()
+	if err != nil {
+		slog.Error(Name, "reload", err, "command", f.Reload, "output", strings.TrimSpace(string(out)))
+		return
+	}
+
+	slog.Info(Name, "reloaded", f.Reload)
+}
+
+// display shortens the home directory to "~".
+func display(path string) string {
+	home, _ := os.UserHomeDir()
+
+	if home != "" && strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + strings.TrimPrefix(path, home)
+	}
+
+	return path
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	all := matches()
+
+	for k, v := range all {
+		f := config.Files[v.Entry]
+
+		text := display(v.Path)
+		subtext := f.Name
+
+		actions := []string{ActionEdit, ActionCopyPath}
+
+		if f.Reload != "" {
+			actions = append(actions, ActionEditReload, ActionReload)
+			subtext = strings.Join(nonEmpty(subtext, "reload: "+f.Reload), " · ")
+		}
+
+		icon := f.Icon
+		if icon == "" {
+			icon = config.Icon
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier:  v.Path,
+			Text:        text,
+			Subtext:     subtext,
+			Provider:    Name,
+			Icon:        icon,
+			Actions:     actions,
+			Type:        pb.QueryResponse_REGULAR,
+			Score:       int32(len(all) - k),
+			Preview:     v.Path,
+			PreviewType: util.PreviewTypeFile,
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, text, exact)
+
+			// matches of the name don't highlight the path
+			if s, _, _ := common.FuzzyScore(query, f.Name, exact); s > score {
+				score, pos, start = s, nil, 0
+			}
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func nonEmpty(values ...string) []string {
+	res := []string{}
+
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
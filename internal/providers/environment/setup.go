@@ -0,0 +1,340 @@
+// Package environment lists the environment variables of the daemon and resolved XDG paths.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"github.com/adrg/xdg"
+)
+
+var (
+	Name       = "environment"
+	NamePretty = "Environment"
+	config     *Config
+	systemd    = make(map[string]string)
+	systemdAt  time.Time
+	systemdMu  sync.Mutex
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config  `koanf:",squash"`
+	Copy           string   `koanf:"copy" desc:"command to copy a value. supports %VALUE%." default:"wl-copy, xclip on X11, pbcopy on macOS, clip on Windows"`
+	Mask           []string `koanf:"mask" desc:"values of variables containing one of these are masked. copying still copies the value" default:"['TOKEN', 'SECRET', 'PASSWORD', 'PASSWD', 'API_KEY']"`
+	CompareSystemd bool     `koanf:"compare_systemd" desc:"mark variables that differ from the systemd user environment" default:"true"`
+}
+
+const (
+	ActionCopy       = "copy"
+	ActionCopyExport = "copy_export"
+
+	KindEnv = "env"
+	KindXDG = "xdg"
+)
+
+// Entry is an environment variable or a resolved path.
+type Entry struct {
+	Kind  string
+	Name  string
+	Value string
+}
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "utilities-terminal",
+			MinScore: 30,
+		},
+		Copy:           common.CopyCommand(),
+		Mask:           []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "API_KEY"},
+		CompareSystemd: true,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func entries() []Entry {
+	res := []Entry{}
+
+	env := os.Environ()
+	slices.Sort(env)
+
+	for _, v := range env {
+		name, value, _ := strings.Cut(v, "=")
+		res = append(res, Entry{Kind: KindEnv, Name: name, Value: value})
+	}
+
+	xdgPaths := []struct {
+		name  string
+		value string
+	}{
+		{"ConfigHome", xdg.ConfigHome},
+		{"DataHome", xdg.DataHome},
+		{"CacheHome", xdg.CacheHome},
+		{"StateHome", xdg.StateHome},
+		{"RuntimeDir", xdg.RuntimeDir},
+		{"BinHome", xdg.BinHome},
+		{"ConfigDirs", strings.Join(xdg.ConfigDirs, ":")},
+		{"DataDirs", strings.Join(xdg.DataDirs, ":")},
+		{"ApplicationDirs", strings.Join(xdg.ApplicationDirs, ":")},
+		{"FontDirs", strings.Join(xdg.FontDirs, ":")},
+		{"Desktop", xdg.UserDirs.Desktop},
+		{"Documents", xdg.UserDirs.Documents},
+		{"Download", xdg.UserDirs.Download},
+		{"Music", xdg.UserDirs.Music},
+		{"Pictures", xdg.UserDirs.Pictures},
+		{"PublicShare", xdg.UserDirs.PublicShare},
+		{"Templates", xdg.UserDirs.Templates},
+		{"Videos", xdg.UserDirs.Videos},
+		{"ElephantConfigDirs", strings.Join(common.ConfigDirs(), ":")},
+		{"ElephantCacheDir", filepath.Dir(common.CacheFile(Name))},
+	}
+
+	for _, v := range xdgPaths {
+		res = append(res, Entry{Kind: KindXDG, Name: v.name, Value: v.value})
+	}
+
+	return res
+}
+
+func entryID(e Entry) string {
+	return fmt.Sprintf("%s:%s", e.Kind, e.Name)
+}
+
+func masked(e Entry) bool {
+	if e.Kind != KindEnv {
+		return false
+	}
+
+	upper := strings.ToUpper(e.Name)
+
+	for _, v := range config.Mask {
+		if strings.Contains(upper, strings.ToUpper(v)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func display(e Entry) string {
+	if masked(e) && e.Value != "" {
+		return "********"
+	}
+
+	return e.Value
+}
+
+// systemdEnv returns the systemd user environment, which is what services and most launchers pass on.
+// It's cached for a short while, as it's queried on every keystroke.
+func systemdEnv() (map[string]string, bool) {
+	systemdMu.Lock()
+	defer systemdMu.Unlock()
+
+	if time.Since(systemdAt) < 10*time.Second {
+		return systemd, len(systemd) > 0
+	}
+
+	systemdAt = time.Now()
+	clear(systemd)
+
+	out, err := exec.Command("systemctl", "--user", "show-environment").Output()
+	if err != nil {
+		return systemd, false
+	}
+
+	for line := range strings.Lines(string(out)) {
+		name, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+
+		// values with special characters are printed as $'...'
+		if unquoted, ok := strings.CutPrefix(value, "$'"); ok {
+			value = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\'`, "'", `\\`, `\`).Replace(strings.TrimSuffix(unquoted, "'"))
+		}
+
+		systemd[name] = value
+	}
+
+	return systemd, true
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	var entry Entry
+	var found bool
+
+	for _, v := range entries() {
+		if entryID(v) == identifier {
+			entry = v
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown identifier: %s", identifier))
+		return
+	}
+
+	value := entry.Value
+
+	switch action {
+	case ActionCopy, "":
+	case ActionCopyExport:
+		if entry.Kind != KindEnv {
+			slog.Error(Name, "activate", fmt.Sprintf("can't export %s", identifier))
+			return
+		}
+
+		value = fmt.Sprintf("export %s=%s", entry.Name, common.Quote(entry.Value))
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	cmd := common.ReplaceResultOrStdinCmd(config.Copy, value)
+
+	err := cmd.Start()
+	if err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	res := []*pb.QueryResponse_Item{}
+
+	var sd map[string]string
+	var hasSystemd bool
+
+	if config.CompareSystemd {
+		sd, hasSystemd = systemdEnv()
+	}
+
+	for _, v := range entries() {
+		value := display(v)
+
+		e := &pb.QueryResponse_Item{
+			Identifier:  entryID(v),
+			Text:        v.Name,
+			Subtext:     value,
+			Icon:        config.Icon,
+			Provider:    Name,
+			Actions:     []string{ActionCopy},
+			State:       []string{v.Kind},
+			Type:        pb.QueryResponse_REGULAR,
+			PreviewType: util.PreviewTypeText,
+		}
+
+		if v.Kind == KindEnv {
+			e.Actions = append(e.Actions, ActionCopyExport)
+		}
+
+		if masked(v) {
+			e.State = append(e.State, "masked")
+		}
+
+		preview := fmt.Sprintf("%s\n\n%s", v.Name, listValue(value))
+
+		if hasSystemd && v.Kind == KindEnv {
+			if sv, ok := sd[v.Name]; !ok {
+				e.State = append(e.State, "not_in_systemd")
+				preview = fmt.Sprintf("%s\n\nnot set in the systemd user environment", preview)
+			} else if sv != v.Value {
+				e.State = append(e.State, "differs_from_systemd")
+
+				if !masked(v) {
+					preview = fmt.Sprintf("%s\n\nsystemd user environment:\n%s", preview, listValue(sv))
+				} else {
+					preview = fmt.Sprintf("%s\n\ndiffers from the systemd user environment", preview)
+				}
+			}
+		}
+
+		e.Preview = preview
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, v.Name, exact)
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Field:     "text",
+				Positions: pos,
+				Start:     start,
+			}
+
+			if !masked(v) {
+				if s2, p2, start2 := common.FuzzyScore(query, v.Value, exact); s2 > score {
+					e.Score = s2
+					e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+						Field:     "subtext",
+						Positions: p2,
+						Start:     start2,
+					}
+				}
+			}
+		}
+
+		if e.Score > config.MinScore || query == "" {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+// listValue puts the entries of path lists like PATH or XDG_DATA_DIRS on separate lines.
+func listValue(value string) string {
+	if !strings.Contains(value, ":") || !strings.HasPrefix(value, "/") {
+		return value
+	}
+
+	return strings.ReplaceAll(value, ":", "\n")
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
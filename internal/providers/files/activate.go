@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
-	"os/exec"
+	"os"
 	"path/filepath"
 	"strings"
-	"syscall"
 
+	"github.com/abenz1267/elephant/v2/internal/util"
 	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 )
 
 const (
@@ -18,8 +19,25 @@ const (
 	ActionCopyPath  = "copypath"
 	ActionCopyFile  = "copyfile"
 	ActionLocalsend = "localsend"
+	ActionRename    = "rename"
 )
 
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionOpen, Label: "Open", Icon: "document-open", Default: true},
+		{Action: ActionOpenDir, Label: "Open folder", Icon: "folder-open"},
+		{Action: ActionCopyPath, Label: "Copy path", Icon: "edit-copy", Multiple: true},
+		{Action: ActionCopyFile, Label: "Copy file", Icon: "edit-copy", Multiple: true},
+		{Action: ActionLocalsend, Label: "Send with LocalSend", Icon: "document-send"},
+		{Action: ActionRename, Label: "Rename", Icon: "edit-rename", Arguments: []*pb.ActionArgument{
+			{Name: "name", Type: util.ArgumentText, Placeholder: "New name", Required: true},
+		}},
+		{Action: ActionCompress, Label: "Compress", Icon: "package-x-generic", Multiple: true, Arguments: []*pb.ActionArgument{
+			{Name: "name", Type: util.ArgumentText, Placeholder: "archive.tar.gz"},
+		}},
+	}
+}
+
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
 	f := getFile(identifier)
 
@@ -36,11 +54,8 @@ func Activate(single bool, identifier, action string, query string, args string,
 
 	switch action {
 	case ActionLocalsend:
-		cmd := exec.Command("sh", "-c", strings.TrimSpace(fmt.Sprintf("%s %s %s", common.LaunchPrefix(""), "localsend", path)))
-
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			Setsid: true,
-		}
+		cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix("", Name, identifier), "localsend", common.Quote(path))))
+		common.Detach(cmd)
 
 		err := cmd.Start()
 		if err != nil {
@@ -55,16 +70,14 @@ func Activate(single bool, identifier, action string, query string, args string,
 			path = filepath.Dir(path)
 		}
 
-		run := strings.TrimSpace(fmt.Sprintf("%s xdg-open '%s'", common.LaunchPrefix(config.LaunchPrefix), path))
+		run := strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix(config.LaunchPrefix, Name, identifier), common.OpenCommand(), common.Quote(path)))
 
 		if common.ForceTerminalForFile(path) {
 			run = common.WrapWithTerminal(run)
 		}
 
-		cmd := exec.Command("sh", "-c", run)
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			Setsid: true,
-		}
+		cmd := common.Shell(run)
+		common.Detach(cmd)
 
 		err := cmd.Start()
 		if err != nil {
@@ -75,7 +88,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 			}()
 		}
 	case ActionCopyPath:
-		cmd := exec.Command("wl-copy", path)
+		cmd := common.CopyCmd(path)
 
 		err := cmd.Start()
 		if err != nil {
@@ -87,7 +100,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 		}
 
 	case ActionCopyFile:
-		cmd := exec.Command("wl-copy", "-t", "text/uri-list", fmt.Sprintf("file://%s", path))
+		cmd := copyFiles([]string{path})
 
 		err := cmd.Start()
 		if err != nil {
@@ -97,6 +110,26 @@ func Activate(single bool, identifier, action string, query string, args string,
 				cmd.Wait()
 			}()
 		}
+	case ActionCompress:
+		compress([]string{strings.TrimSuffix(path, "/")}, args)
+	case ActionRename:
+		name := strings.TrimSpace(args)
+
+		if name == "" || strings.ContainsRune(name, filepath.Separator) || name == "." || name == ".." {
+			slog.Error(Name, "actionrename", fmt.Sprintf("invalid name: '%s'", name))
+			return
+		}
+
+		dest := filepath.Join(filepath.Dir(strings.TrimSuffix(path, "/")), name)
+
+		if common.FileExists(dest) {
+			slog.Error(Name, "actionrename", fmt.Sprintf("already exists: %s", dest))
+			return
+		}
+
+		if err := os.Rename(path, dest); err != nil {
+			slog.Error(Name, "actionrename", err)
+		}
 	default:
 		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
 		return
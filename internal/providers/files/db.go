@@ -4,74 +4,85 @@ import (
 	"database/sql"
 	"log/slog"
 	"os"
+	"sync/atomic"
 	"time"
 
-	"github.com/abenz1267/elephant/v2/pkg/common"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/abenz1267/elephant/v2/pkg/common/store"
 )
 
-var db *sql.DB
-
-func openDB() error {
-	path := common.CacheFile("files.db")
-	os.Remove(path)
-	os.Create(path)
-
-	var err error
-
-	db, err = sql.Open("sqlite3", path+"?_journal_mode=WAL&_synchronous=NORMAL&_cache_size=10000&_temp_store=memory")
-	if err != nil {
-		return err
-	}
+var (
+	db *store.Store
+	// queried is set when the page caches of the store filled up by querying, until they're released.
+	queried atomic.Bool
+)
 
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS files (
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS files (
 		identifier TEXT PRIMARY KEY,
 		path TEXT NOT NULL,
 		changed INTEGER
-	)`)
-	if err != nil {
-		return err
-	}
+	);
+	CREATE INDEX IF NOT EXISTS idx_files_path ON files(path);
+	CREATE INDEX IF NOT EXISTS idx_files_changed ON files(changed DESC);`,
+}
 
-	// Create indexes for query performance
-	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_files_path ON files(path)`)
-	if err != nil {
+func openDB() error {
+	// the index is rebuilt on every start
+	if err := store.Remove(Name); err != nil {
 		return err
 	}
 
-	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_files_changed ON files(changed DESC)`)
-	if err != nil {
-		return err
-	}
+	var err error
 
-	return nil
+	db, err = store.Open(Name, migrations...)
+
+	return err
 }
 
-func putFileBatch(files []File) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return err
+// cacheSize estimates the memory of the page caches of the store, which grow up to the size of the
+// database while querying.
+func cacheSize() int64 {
+	if !queried.Load() {
+		return 0
 	}
-	defer tx.Rollback()
 
-	stmt, err := tx.Prepare("INSERT OR REPLACE INTO files (identifier, path, changed) VALUES (?, ?, ?)")
+	info, err := os.Stat(store.File(Name))
 	if err != nil {
-		return err
+		return 0
 	}
-	defer stmt.Close()
 
-	for _, f := range files {
-		changedUnix := int64(0)
-		if !f.Changed.IsZero() {
-			changedUnix = f.Changed.Unix()
-		}
-		_, err = stmt.Exec(f.Identifier, f.Path, changedUnix)
+	// cache_size of the store in pages of 4 KiB
+	return min(info.Size(), 10000*4096)
+}
+
+func releaseCache() {
+	queried.Store(false)
+	db.Release()
+}
+
+func putFileBatch(files []File) error {
+	return db.Tx(func(tx *sql.Tx) error {
+		stmt, err := db.Stmt("INSERT OR REPLACE INTO files (identifier, path, changed) VALUES (?, ?, ?)")
 		if err != nil {
 			return err
 		}
-	}
 
-	return tx.Commit()
+		stmt = tx.Stmt(stmt)
+		defer stmt.Close()
+
+		for _, f := range files {
+			changedUnix := int64(0)
+			if !f.Changed.IsZero() {
+				changedUnix = f.Changed.Unix()
+			}
+
+			if _, err := stmt.Exec(f.Identifier, f.Path, changedUnix); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
 func putFile(f File) {
@@ -107,21 +118,14 @@ func getFile(identifier string) *File {
 func getFilesByQuery(query string, _ bool) []File {
 	var result []File
 
-	path := common.CacheFile("files.db")
-	queryDB, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_synchronous=NORMAL&_cache_size=10000&_temp_store=memory")
-	if err != nil {
-		slog.Error(Name, "open query db", err)
-		return nil
-	}
-	defer queryDB.Close()
-
 	var rows *sql.Rows
+	var err error
 
 	if query != "" {
 		likePattern := "%" + query + "%"
-		rows, err = queryDB.Query("SELECT identifier, path, changed FROM files WHERE path LIKE ? ORDER BY changed DESC LIMIT 1000", likePattern)
+		rows, err = db.Query("SELECT identifier, path, changed FROM files WHERE path LIKE ? ORDER BY changed DESC LIMIT 1000", likePattern)
 	} else {
-		rows, err = queryDB.Query("SELECT identifier, path, changed FROM files WHERE path NOT LIKE '%/' ORDER BY changed DESC LIMIT 100")
+		rows, err = db.Query("SELECT identifier, path, changed FROM files WHERE path NOT LIKE '%/' ORDER BY changed DESC LIMIT 100")
 	}
 
 	if err != nil {
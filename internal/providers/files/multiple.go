@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"al.essio.dev/pkg/shellescape"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/jobs"
+)
+
+const ActionCompress = "compress"
+
+// ActivateMultiple combines copying and compressing of several files. Other actions are run per file.
+func ActivateMultiple(identifiers []string, action, query, args string, format uint8, conn net.Conn) bool {
+	paths := []string{}
+
+	for _, v := range identifiers {
+		f := getFile(v)
+
+		if f == nil {
+			slog.Error(Name, "activate", fmt.Sprintf("file not found: %s", v))
+			continue
+		}
+
+		paths = append(paths, strings.TrimSuffix(f.Path, "/"))
+	}
+
+	if len(paths) == 0 {
+		return true
+	}
+
+	var cmd *exec.Cmd
+
+	switch action {
+	case ActionCompress:
+		compress(paths, args)
+		return true
+	case ActionCopyPath:
+		cmd = common.CopyCmd(strings.Join(paths, "\n"))
+	case ActionCopyFile:
+		cmd = copyFiles(paths)
+	default:
+		return false
+	}
+
+	if err := cmd.Start(); err != nil {
+		slog.Error(Name, "activatemultiple", err)
+	} else {
+		go func() {
+			cmd.Wait()
+		}()
+	}
+
+	return true
+}
+
+// copyFiles copies the files, so they can be pasted into file managers. Only wl-clipboard can set the
+// uri-list type, elsewhere the paths are copied.
+func copyFiles(paths []string) *exec.Cmd {
+	if runtime.GOOS != "linux" {
+		return common.CopyCmd(strings.Join(paths, "\n"))
+	}
+
+	uris := []string{}
+
+	for _, v := range paths {
+		uris = append(uris, fmt.Sprintf("file://%s", v))
+	}
+
+	return exec.Command("wl-copy", "-t", "text/uri-list", strings.Join(uris, "\n"))
+}
+
+// commonDir returns the deepest folder containing all paths.
+func commonDir(paths []string) string {
+	dir := filepath.Dir(paths[0])
+
+	for _, v := range paths {
+		for dir != "/" && !strings.HasPrefix(v, dir+"/") {
+			dir = filepath.Dir(dir)
+		}
+	}
+
+	return dir
+}
+
+// compress puts the files into an archive next to them. The format is chosen by tar based on the
+// extension of the name.
+func compress(paths []string, name string) {
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		if len(paths) == 1 {
+			name = fmt.Sprintf("%s.tar.gz", filepath.Base(paths[0]))
+		} else {
+			name = fmt.Sprintf("archive-%s.tar.gz", time.Now().Format("20060102-150405"))
+		}
+	}
+
+	if strings.ContainsRune(name, filepath.Separator) {
+		slog.Error(Name, "compress", fmt.Sprintf("invalid name: '%s'", name))
+		return
+	}
+
+	dir := commonDir(paths)
+	archive := filepath.Join(dir, name)
+
+	if common.FileExists(archive) {
+		slog.Error(Name, "compress", fmt.Sprintf("already exists: %s", archive))
+		return
+	}
+
+	files := []string{}
+
+	for _, v := range paths {
+		rel, err := filepath.Rel(dir, v)
+		if err != nil {
+			slog.Error(Name, "compress", err)
+			return
+		}
+
+		files = append(files, shellescape.Quote(rel))
+	}
+
+	cmd := fmt.Sprintf("tar -C %s -caf %s -- %s", shellescape.Quote(dir), shellescape.Quote(archive), strings.Join(files, " "))
+
+	if _, err := jobs.Start(Name, fmt.Sprintf("Compress %s", name), cmd); err != nil {
+		slog.Error(Name, "compress", err)
+	}
+}
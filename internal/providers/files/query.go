@@ -8,6 +8,7 @@ import (
 
 	"github.com/abenz1267/elephant/v2/internal/util"
 	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/memory"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 )
 
@@ -15,7 +16,10 @@ func Query(conn net.Conn, query string, _ bool, exact bool, _ uint8) []*pb.Query
 	start := time.Now()
 
 	entries := []*pb.QueryResponse_Item{}
-	actions := []string{ActionOpen, ActionOpenDir, ActionCopyFile, ActionCopyPath}
+	actions := []string{ActionOpen, ActionOpenDir, ActionCopyFile, ActionCopyPath, ActionRename, ActionCompress}
+
+	memory.Touch(Name)
+	queried.Store(true)
 
 	results := getFilesByQuery(query, exact)
 
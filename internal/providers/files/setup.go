@@ -6,6 +6,7 @@ import (
 	_ "embed"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"os"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/abenz1267/elephant/v2/internal/util"
 	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/memory"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 	"github.com/djherbis/times"
 	"github.com/fsnotify/fsnotify"
@@ -59,6 +61,12 @@ func Setup() {
 		return
 	}
 
+	memory.Register(memory.Index{
+		Name:  Name,
+		Size:  cacheSize,
+		Evict: releaseCache,
+	})
+
 	ls, err := exec.LookPath("localsend")
 	if ls != "" && err == nil {
 		hasLocalsend = true
@@ -324,10 +332,10 @@ func handleRegular(regularChan chan string) {
 	}
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 func Icon() string {
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/store"
+)
+
+var db *store.Store
+
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS done (
+		habit TEXT NOT NULL,
+		day TEXT NOT NULL,
+		PRIMARY KEY (habit, day)
+	);`,
+}
+
+// dayFormat is the format of days in the store and the synced file.
+const dayFormat = "2006-01-02"
+
+func openDB() error {
+	var err error
+
+	db, err = store.Open(Name, migrations...)
+
+	return err
+}
+
+func setDone(habit, day string, done bool) error {
+	var err error
+
+	if done {
+		_, err = db.Exec("INSERT OR IGNORE INTO done (habit, day) VALUES (?, ?)", habit, day)
+	} else {
+		_, err = db.Exec("DELETE FROM done WHERE habit = ? AND day = ?", habit, day)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if config.w != nil {
+		if err := exportFile(); err != nil {
+			return err
+		}
+
+		go common.GitPush(Name, syncFile, config.w, config.r)
+	}
+
+	return nil
+}
+
+// doneDays returns the days each habit was done on.
+func doneDays() (map[string]map[string]bool, error) {
+	rows, err := db.Query("SELECT habit, day FROM done")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := map[string]map[string]bool{}
+
+	for rows.Next() {
+		var habit, day string
+
+		if err := rows.Scan(&habit, &day); err != nil {
+			return nil, err
+		}
+
+		if res[habit] == nil {
+			res[habit] = map[string]bool{}
+		}
+
+		res[habit][day] = true
+	}
+
+	return res, rows.Err()
+}
+
+// syncFile holds the done days in the git repository, as "habit;day" per line.
+const syncFile = "habits.csv"
+
+func exportFile() error {
+	all, err := doneDays()
+	if err != nil {
+		return err
+	}
+
+	lines := []string{}
+
+	for habit, days := range all {
+		for day := range days {
+			lines = append(lines, fmt.Sprintf("%s;%s", habit, day))
+		}
+	}
+
+	slices.Sort(lines)
+
+	return os.WriteFile(filepath.Join(config.Location, syncFile), []byte(strings.Join(append([]string{"habit;day"}, lines...), "\n")+"\n"), 0o644)
+}
+
+// importFile replaces the done days with the ones of the repository, which is the source of truth when syncing.
+func importFile() {
+	b, err := os.ReadFile(filepath.Join(config.Location, syncFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Error(Name, "import", err)
+		}
+
+		return
+	}
+
+	err = db.Tx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM done"); err != nil {
+			return err
+		}
+
+		for l := range strings.Lines(string(b)) {
+			// names may contain the separator, days don't
+			l = strings.TrimSpace(l)
+
+			i := strings.LastIndex(l, ";")
+			if i < 0 || l == "habit;day" {
+				continue
+			}
+
+			habit, day := l[:i], l[i+1:]
+
+			if _, err := tx.Exec("INSERT OR IGNORE INTO done (habit, day) VALUES (?, ?)", habit, day); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		slog.Error(Name, "import", err)
+	}
+}
@@ -0,0 +1,334 @@
+// Package habits tracks daily habits and their streaks.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"github.com/go-git/go-git/v6"
+)
+
+var (
+	Name       = "habits"
+	NamePretty = "Habits"
+	config     *Config
+	isGit      bool
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Habits        []Habit `koanf:"habits" desc:"daily habits" default:""`
+	Location      string  `koanf:"location" desc:"git repository to sync the done days with, f.e. 'https://github.com/user/habits'" default:""`
+	PreviewWeeks  int     `koanf:"preview_weeks" desc:"weeks shown in the preview" default:"8"`
+	w             *git.Worktree
+	r             *git.Repository
+}
+
+type Habit struct {
+	Name string `koanf:"name" desc:"name of the habit, identifying its done days" default:""`
+	Icon string `koanf:"icon" desc:"icon of the habit" default:""`
+}
+
+func (config *Config) SetLocation(val string) {
+	config.Location = val
+}
+
+func (config *Config) URL() string {
+	return config.Location
+}
+
+func (config *Config) SetWorktree(val *git.Worktree) {
+	config.w = val
+}
+
+func (config *Config) SetRepository(val *git.Repository) {
+	config.r = val
+}
+
+func (config *Config) GitReload() {
+	importFile()
+	handlers.ProviderUpdated <- Name
+}
+
+const (
+	ActionDone = "done"
+	ActionUndo = "undo"
+
+	StateDone = "done"
+	StateOpen = "open"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "checkbox-checked",
+			MinScore: 20,
+		},
+		PreviewWeeks: 8,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	if err := openDB(); err != nil {
+		slog.Error(Name, "db", err)
+		return
+	}
+
+	if strings.HasPrefix(config.Location, "https://") {
+		isGit = true
+
+		if !common.GetElephantConfig().GitOnDemand {
+			common.SetupGit(Name, config)
+			importFile()
+		}
+	}
+}
+
+func Available() bool {
+	if len(config.Habits) == 0 {
+		slog.Info(Name, "available", "no habits configured. disabling")
+		return false
+	}
+
+	return db != nil
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionDone, Label: "Done today", Icon: "object-select", Default: true},
+		{Action: ActionUndo, Label: "Not done today", Icon: "edit-undo"},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if common.GitResolve(Name, action) {
+		handlers.ProviderUpdated <- Name
+		return
+	}
+
+	if !slices.ContainsFunc(config.Habits, func(h Habit) bool { return h.Name == identifier }) {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown habit: %s", identifier))
+		return
+	}
+
+	if action == "" {
+		action = ActionDone
+	}
+
+	switch action {
+	case ActionDone, ActionUndo:
+		if err := setDone(identifier, time.Now().Format(dayFormat), action == ActionDone); err != nil {
+			slog.Error(Name, action, err)
+			return
+		}
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	handlers.ProviderUpdated <- Name
+}
+
+// day returns the day n days before t.
+func day(t time.Time, n int) string {
+	return time.Date(t.Year(), t.Month(), t.Day()-n, 12, 0, 0, 0, t.Location()).Format(dayFormat)
+}
+
+// streak counts the consecutive days up to today. Today not being done yet doesn't break the streak.
+func streak(days map[string]bool, now time.Time) int {
+	start := 0
+
+	if !days[day(now, 0)] {
+		start = 1
+	}
+
+	n := 0
+
+	for days[day(now, start+n)] {
+		n++
+	}
+
+	return n
+}
+
+func best(days map[string]bool) int {
+	res := 0
+
+	for d := range days {
+		t, err := time.ParseInLocation(dayFormat, d, time.Local)
+		if err != nil {
+			continue
+		}
+
+		// only the first day of a streak counts it
+		if days[day(t, 1)] {
+			continue
+		}
+
+		n := 0
+
+		for days[day(t, -n)] {
+			n++
+		}
+
+		res = max(res, n)
+	}
+
+	return res
+}
+
+func plural(n int, s string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, s)
+	}
+
+	return fmt.Sprintf("%d %ss", n, s)
+}
+
+// preview draws the done days of the last weeks, a row per week from monday to sunday.
+func preview(days map[string]bool, now time.Time) string {
+	var b strings.Builder
+
+	b.WriteString("Mo Tu We Th Fr Sa Su\n")
+
+	// days since the monday of the first week
+	offset := (int(now.Weekday())+6)%7 + 7*(config.PreviewWeeks-1)
+
+	for i := offset; i >= 0; i-- {
+		mark := "·"
+
+		if days[day(now, i)] {
+			mark = "■"
+		}
+
+		b.WriteString(" " + mark)
+
+		if (offset-i)%7 == 6 || i == 0 {
+			b.WriteString("\n")
+		} else {
+			b.WriteString(" ")
+		}
+	}
+
+	return b.String()
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	if db == nil {
+		return entries
+	}
+
+	if isGit && config.r == nil {
+		common.SetupGit(Name, config)
+		importFile()
+	}
+
+	all, err := doneDays()
+	if err != nil {
+		slog.Error(Name, "query", err)
+		return entries
+	}
+
+	now := time.Now()
+
+	for k, v := range config.Habits {
+		days := all[v.Name]
+		done := days[day(now, 0)]
+		current := streak(days, now)
+
+		subtext := []string{fmt.Sprintf("%s streak", plural(current, "day")), fmt.Sprintf("best %d", max(best(days), current))}
+
+		if !done {
+			subtext = append(subtext, "not done today")
+		}
+
+		icon := v.Icon
+		if icon == "" {
+			icon = config.Icon
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier:  v.Name,
+			Text:        v.Name,
+			Subtext:     strings.Join(subtext, " · "),
+			Provider:    Name,
+			Icon:        icon,
+			State:       []string{StateOpen},
+			Actions:     []string{ActionDone},
+			Type:        pb.QueryResponse_REGULAR,
+			Preview:     preview(days, now),
+			PreviewType: util.PreviewTypeText,
+		}
+
+		// open habits first, in the configured order
+		e.Score = int32(len(config.Habits) - k)
+
+		if done {
+			e.State = []string{StateDone}
+			e.Actions = []string{ActionUndo}
+		} else {
+			e.Score += int32(len(config.Habits))
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, v.Name, exact)
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	states, actions := common.GitState(Name)
+
+	return &pb.ProviderStateResponse{
+		States:  states,
+		Actions: actions,
+	}
+}
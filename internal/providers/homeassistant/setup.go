@@ -0,0 +1,257 @@
+// Package homeassistant provides lights, switches and scenes of a Home Assistant instance.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/history"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "homeassistant"
+	NamePretty = "Home Assistant"
+	config     *Config
+	h          = history.Load(Name)
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config    `koanf:",squash"`
+	History          bool          `koanf:"history" desc:"make use of history for sorting" default:"true"`
+	HistoryWhenEmpty bool          `koanf:"history_when_empty" desc:"consider history when query is empty" default:"true"`
+	URL              string        `koanf:"url" desc:"url of the instance, f.e. http://homeassistant.local:8123" default:""`
+	Token            common.Secret `koanf:"token" desc:"long-lived access token, f.e. 'libsecret:service homeassistant'" default:""`
+	TokenCmd         string        `koanf:"token_cmd" desc:"command printing the access token" default:""`
+	Domains          []string      `koanf:"domains" desc:"domains to list" default:"['light', 'switch', 'scene']"`
+	Entities         []string      `koanf:"entities" desc:"additional entities to list, f.e. 'script.good_night'" default:""`
+	Exclude          []string      `koanf:"exclude" desc:"entities to hide" default:""`
+}
+
+const (
+	ActionToggle   = "toggle"
+	ActionTurnOn   = "turn_on"
+	ActionTurnOff  = "turn_off"
+	ActionActivate = "activate"
+)
+
+// toggleable domains support turn_on, turn_off and toggle. Others are only turned on, f.e. scenes.
+var toggleable = []string{"light", "switch", "fan", "input_boolean", "automation", "group", "media_player", "climate", "humidifier", "siren", "cover"}
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "home-assistant",
+			MinScore: 30,
+		},
+		History:          true,
+		HistoryWhenEmpty: true,
+		Domains:          []string{"light", "switch", "scene"},
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	if config.URL != "" {
+		go connectLoop()
+	}
+}
+
+func Available() bool {
+	if config.URL == "" {
+		slog.Info(Name, "available", "no url configured. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func tracked(entity string) bool {
+	if slices.Contains(config.Exclude, entity) {
+		return false
+	}
+
+	domain, _, _ := strings.Cut(entity, ".")
+
+	return slices.Contains(config.Domains, domain) || slices.Contains(config.Entities, entity)
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == history.ActionDelete {
+		h.Remove(identifier)
+		return
+	}
+
+	entitiesMu.RLock()
+	e, ok := entities[identifier]
+	entitiesMu.RUnlock()
+
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown entity: %s", identifier))
+		return
+	}
+
+	if action == "" {
+		action = ActionActivate
+
+		if slices.Contains(toggleable, e.domain()) {
+			action = ActionToggle
+		}
+	}
+
+	service := action
+
+	switch action {
+	case ActionToggle, ActionTurnOn, ActionTurnOff:
+	case ActionActivate:
+		service = ActionTurnOn
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	if err := callService(e.domain(), service, e.ID); err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	if config.History {
+		h.Save(query, identifier)
+	}
+}
+
+// describe summarizes the state, f.e. "on - 80%" for dimmed lights.
+func describe(e Entity) string {
+	res := e.State
+
+	if e.domain() == "scene" || e.domain() == "script" {
+		res = fmt.Sprintf("last activated %s", e.Changed.Local().Format("Jan 2 15:04"))
+	}
+
+	if b, ok := e.Attributes["brightness"].(float64); ok && e.State == "on" {
+		res = fmt.Sprintf("%s - %d%%", res, int(b/255*100+0.5))
+	}
+
+	return res
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	res := []*pb.QueryResponse_Item{}
+
+	entitiesMu.RLock()
+	defer entitiesMu.RUnlock()
+
+	for _, v := range entities {
+		actions := []string{ActionActivate}
+
+		if slices.Contains(toggleable, v.domain()) {
+			actions = []string{ActionToggle, ActionTurnOn, ActionTurnOff}
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier:  v.ID,
+			Text:        v.name(),
+			Subtext:     describe(v),
+			Icon:        config.Icon,
+			Provider:    Name,
+			Actions:     actions,
+			State:       []string{v.domain(), v.State},
+			Type:        pb.QueryResponse_REGULAR,
+			Preview:     preview(v),
+			PreviewType: util.PreviewTypeText,
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, v.name(), exact)
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Field:     "text",
+				Positions: pos,
+				Start:     start,
+			}
+
+			if s2, _, _ := common.FuzzyScore(query, v.ID, exact); s2 > score {
+				e.Score = s2
+				e.Fuzzyinfo = nil
+			}
+		}
+
+		if config.History {
+			if e.Score > config.MinScore || query == "" && config.HistoryWhenEmpty {
+				usageScore := h.CalcUsageScore(query, e.Identifier)
+
+				if usageScore != 0 {
+					e.State = append(e.State, "history")
+					e.Actions = append(e.Actions, history.ActionDelete)
+				}
+
+				e.Score = e.Score + usageScore
+			}
+		}
+
+		if e.Score > config.MinScore || query == "" {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+func preview(e Entity) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n%s\n\nState: %s\nChanged: %s\n", e.name(), e.ID, e.State, e.Changed.Local().Format(time.DateTime))
+
+	keys := []string{}
+
+	for k := range e.Attributes {
+		if k != "friendly_name" {
+			keys = append(keys, k)
+		}
+	}
+
+	slices.Sort(keys)
+
+	if len(keys) > 0 {
+		b.WriteString("\n")
+	}
+
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s: %v\n", k, e.Attributes[k])
+	}
+
+	return b.String()
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
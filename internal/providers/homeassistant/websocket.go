@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+// Entity is the state of a Home Assistant entity.
+type Entity struct {
+	ID         string         `json:"entity_id"`
+	State      string         `json:"state"`
+	Attributes map[string]any `json:"attributes"`
+	Changed    time.Time      `json:"last_changed"`
+}
+
+type message struct {
+	ID      int64           `json:"id"`
+	Type    string          `json:"type"`
+	Success bool            `json:"success"`
+	Result  json.RawMessage `json:"result"`
+	Error   *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+	Event struct {
+		Data struct {
+			EntityID string  `json:"entity_id"`
+			NewState *Entity `json:"new_state"`
+		} `json:"data"`
+	} `json:"event"`
+}
+
+var (
+	entities   = make(map[string]Entity)
+	entitiesMu sync.RWMutex
+	conn       *websocket.Conn
+	connMu     sync.Mutex
+	nextID     atomic.Int64
+	statesID   atomic.Int64
+)
+
+func (e Entity) domain() string {
+	d, _, _ := strings.Cut(e.ID, ".")
+	return d
+}
+
+func (e Entity) name() string {
+	if v, ok := e.Attributes["friendly_name"].(string); ok && v != "" {
+		return v
+	}
+
+	return e.ID
+}
+
+func token() (string, error) {
+	if config.TokenCmd != "" {
+		return common.Secret(fmt.Sprintf("cmd:%s", config.TokenCmd)).Resolve()
+	}
+
+	return config.Token.Resolve()
+}
+
+func websocketURL() string {
+	u := strings.TrimSuffix(config.URL, "/")
+	u = strings.Replace(u, "http://", "ws://", 1)
+	u = strings.Replace(u, "https://", "wss://", 1)
+
+	return u + "/api/websocket"
+}
+
+// connectLoop keeps the websocket connected, reconnecting with backoff.
+func connectLoop() {
+	backoff := time.Second
+
+	for {
+		start := time.Now()
+
+		err := connect()
+
+		if err != nil {
+			slog.Error(Name, "websocket", err)
+		}
+
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+
+		time.Sleep(backoff)
+		backoff = min(backoff*2, 2*time.Minute)
+	}
+}
+
+func connect() error {
+	ctx := context.Background()
+
+	c, _, err := websocket.Dial(ctx, websocketURL(), nil)
+	if err != nil {
+		return err
+	}
+	defer c.CloseNow()
+
+	// the initial list of states is bigger than the default limit.
+	c.SetReadLimit(32 << 20)
+
+	t, err := token()
+	if err != nil {
+		return err
+	}
+
+	var msg message
+
+	if err := wsjson.Read(ctx, c, &msg); err != nil {
+		return err
+	}
+
+	if msg.Type != "auth_required" {
+		return fmt.Errorf("unexpected message: %s", msg.Type)
+	}
+
+	if err := wsjson.Write(ctx, c, map[string]string{"type": "auth", "access_token": t}); err != nil {
+		return err
+	}
+
+	if err := wsjson.Read(ctx, c, &msg); err != nil {
+		return err
+	}
+
+	if msg.Type != "auth_ok" {
+		return errors.New("authentication failed")
+	}
+
+	connMu.Lock()
+	conn = c
+	connMu.Unlock()
+
+	defer func() {
+		connMu.Lock()
+		conn = nil
+		connMu.Unlock()
+	}()
+
+	if err := send(map[string]any{"type": "subscribe_events", "event_type": "state_changed"}); err != nil {
+		return err
+	}
+
+	id := nextID.Add(1)
+	statesID.Store(id)
+
+	if err := write(c, map[string]any{"id": id, "type": "get_states"}); err != nil {
+		return err
+	}
+
+	slog.Info(Name, "websocket", "connected")
+
+	for {
+		var msg message
+
+		if err := wsjson.Read(ctx, c, &msg); err != nil {
+			return err
+		}
+
+		switch msg.Type {
+		case "result":
+			if !msg.Success && msg.Error != nil {
+				slog.Error(Name, "result", msg.Error.Message)
+				continue
+			}
+
+			if msg.ID == statesID.Load() {
+				var states []Entity
+
+				if err := json.Unmarshal(msg.Result, &states); err != nil {
+					slog.Error(Name, "states", err)
+					continue
+				}
+
+				res := make(map[string]Entity)
+
+				for _, v := range states {
+					if tracked(v.ID) {
+						res[v.ID] = v
+					}
+				}
+
+				entitiesMu.Lock()
+				entities = res
+				entitiesMu.Unlock()
+
+				handlers.ProviderUpdated <- Name
+			}
+		case "event":
+			data := msg.Event.Data
+
+			if !tracked(data.EntityID) {
+				continue
+			}
+
+			entitiesMu.Lock()
+			if data.NewState == nil {
+				delete(entities, data.EntityID)
+			} else {
+				entities[data.EntityID] = *data.NewState
+			}
+			entitiesMu.Unlock()
+
+			handlers.ProviderUpdated <- Name
+		}
+	}
+}
+
+func write(c *websocket.Conn, v any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return wsjson.Write(ctx, c, v)
+}
+
+// send writes a command with a new id to the current connection.
+func send(cmd map[string]any) error {
+	connMu.Lock()
+	defer connMu.Unlock()
+
+	if conn == nil {
+		return errors.New("not connected")
+	}
+
+	cmd["id"] = nextID.Add(1)
+
+	return write(conn, cmd)
+}
+
+func callService(domain, service, entity string) error {
+	return send(map[string]any{
+		"type":    "call_service",
+		"domain":  domain,
+		"service": service,
+		"target":  map[string]string{"entity_id": entity},
+	})
+}
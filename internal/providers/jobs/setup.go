@@ -0,0 +1,266 @@
+// Package jobs lists running and finished background jobs.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/jobs"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "jobs"
+	NamePretty = "Jobs"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	LogCommand    string `koanf:"log_command" desc:"command to show the log of a job, runs in a terminal. supports %FILE%." default:"less +F %FILE%"`
+	Notify        bool   `koanf:"notify" desc:"show a notification when a job finished" default:"true"`
+}
+
+const (
+	ActionShowLog = "show_log"
+	ActionCancel  = "cancel"
+	ActionRemove  = "remove"
+	ActionClear   = "clear"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "system-run",
+			MinScore: 30,
+		},
+		LogCommand: "less +F %FILE%",
+		Notify:     true,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	jobs.Listen(func(e jobs.Event) {
+		if e.Line != "" {
+			return
+		}
+
+		handlers.ProviderUpdated <- Name
+
+		if config.Notify && e.Job.State != jobs.StateRunning {
+			notify(e.Job)
+		}
+	})
+}
+
+func notify(job jobs.Job) {
+	showLog := common.NotificationAction{Label: "Show log", Provider: Name, Identifier: strconv.FormatUint(uint64(job.ID), 10), Action: ActionShowLog}
+
+	// clicking the notification shows the log as well
+	clicked := showLog
+	clicked.Default = true
+
+	n := common.Notification{
+		Title:   fmt.Sprintf("Job %s", job.State),
+		Body:    job.Title,
+		Icon:    config.Icon,
+		Actions: []common.NotificationAction{clicked, showLog},
+	}
+
+	if job.State == jobs.StateFailed {
+		n.Urgency = "critical"
+	}
+
+	if _, err := common.Notify(n); err != nil {
+		slog.Error(Name, "notify", err)
+	}
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionShowLog, Label: "Show log", Icon: "text-x-log", Default: true},
+		{Action: ActionCancel, Label: "Cancel", Icon: "process-stop"},
+		{Action: ActionRemove, Label: "Remove", Icon: "edit-delete"},
+		{Action: ActionClear, Label: "Clear finished", Icon: "edit-clear-all"},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == ActionClear {
+		jobs.Clear()
+		handlers.ProviderUpdated <- Name
+		return
+	}
+
+	id, err := strconv.ParseUint(identifier, 10, 32)
+	if err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	job, ok := jobs.Get(uint32(id))
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown job: %s", identifier))
+		return
+	}
+
+	if action == "" {
+		action = ActionShowLog
+	}
+
+	switch action {
+	case ActionCancel:
+		jobs.Cancel(job.ID)
+	case ActionRemove:
+		jobs.Remove(job.ID)
+		handlers.ProviderUpdated <- Name
+	case ActionShowLog:
+		run := strings.ReplaceAll(config.LogCommand, "%FILE%", common.Quote(job.Log))
+
+		cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), common.WrapWithTerminal(run))))
+		common.Detach(cmd)
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "activate", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+func subtext(job jobs.Job) string {
+	if job.State == jobs.StateRunning {
+		return fmt.Sprintf("%s - %s", job.State, time.Since(job.Started).Round(time.Second))
+	}
+
+	res := fmt.Sprintf("%s - %s", job.State, job.Finished.Sub(job.Started).Round(time.Second))
+
+	if job.State == jobs.StateFailed {
+		res = fmt.Sprintf("%s - exit code %d", res, job.ExitCode)
+	}
+
+	return fmt.Sprintf("%s - %s", res, job.Finished.Format(time.TimeOnly))
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	list := jobs.List()
+
+	for k, v := range list {
+		e := item(v)
+		e.Score = int32(len(list) - k)
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, v.Title, exact)
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// Live updates the runtime of running jobs while clients show them.
+func Live(identifier string) *pb.QueryResponse_Item {
+	id, err := strconv.ParseUint(identifier, 10, 32)
+	if err != nil {
+		return nil
+	}
+
+	job, ok := jobs.Get(uint32(id))
+	if !ok {
+		return nil
+	}
+
+	return item(job)
+}
+
+func item(job jobs.Job) *pb.QueryResponse_Item {
+	actions := []string{ActionShowLog, ActionCancel}
+
+	if job.State != jobs.StateRunning {
+		actions = []string{ActionShowLog, ActionRemove}
+	}
+
+	e := &pb.QueryResponse_Item{
+		Identifier:  strconv.FormatUint(uint64(job.ID), 10),
+		Text:        job.Title,
+		Subtext:     subtext(job),
+		Icon:        config.Icon,
+		Provider:    Name,
+		Actions:     actions,
+		State:       []string{job.State},
+		Preview:     job.Log,
+		PreviewType: util.PreviewTypeFile,
+		Type:        pb.QueryResponse_REGULAR,
+	}
+
+	if job.State == jobs.StateRunning {
+		e.LiveInterval = 1000
+	}
+
+	return e
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	for _, v := range jobs.List() {
+		if v.State != jobs.StateRunning {
+			return &pb.ProviderStateResponse{
+				Actions: []string{ActionClear},
+			}
+		}
+	}
+
+	return &pb.ProviderStateResponse{}
+}
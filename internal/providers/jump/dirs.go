@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common/store"
+)
+
+// Dir is a directory with its frecency, higher is more relevant.
+type Dir struct {
+	Path  string
+	Score float64
+}
+
+// backend keeps track of the visited directories.
+type backend interface {
+	list() ([]Dir, error)
+	add(path string) error
+	remove(path string) error
+}
+
+type zoxide struct{}
+
+func (zoxide) run(args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return exec.CommandContext(ctx, "zoxide", args...).Output()
+}
+
+// list parses "zoxide query --list --score", lines like "  12.0 /home/user/projects".
+func (z zoxide) list() ([]Dir, error) {
+	out, err := z.run("query", "--list", "--score")
+	if err != nil {
+		// zoxide fails if there are no directories yet
+		if len(bytes.TrimSpace(out)) == 0 {
+			return []Dir{}, nil
+		}
+
+		return nil, err
+	}
+
+	res := []Dir{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+
+	for scanner.Scan() {
+		score, path, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok {
+			continue
+		}
+
+		s, err := strconv.ParseFloat(score, 64)
+		if err != nil {
+			continue
+		}
+
+		res = append(res, Dir{Path: strings.TrimSpace(path), Score: s})
+	}
+
+	return res, scanner.Err()
+}
+
+func (z zoxide) add(path string) error {
+	_, err := z.run("add", "--", path)
+	return err
+}
+
+func (z zoxide) remove(path string) error {
+	_, err := z.run("remove", "--", path)
+	return err
+}
+
+// builtin ranks directories like zoxide: every visit adds 1 to the rank, which is weighted by the time since
+// the last visit. Once the ranks sum up to max_age, they're aged so old directories drop out.
+type builtin struct {
+	db *store.Store
+}
+
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS dirs (
+		path TEXT PRIMARY KEY,
+		rank REAL NOT NULL,
+		last INTEGER NOT NULL
+	);`,
+}
+
+func (b builtin) list() ([]Dir, error) {
+	rows, err := b.db.Query("SELECT path, rank, last FROM dirs")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []Dir{}
+
+	for rows.Next() {
+		var path string
+		var rank float64
+		var last int64
+
+		if err := rows.Scan(&path, &rank, &last); err != nil {
+			return nil, err
+		}
+
+		res = append(res, Dir{Path: path, Score: frecency(rank, time.Unix(last, 0))})
+	}
+
+	return res, rows.Err()
+}
+
+func frecency(rank float64, last time.Time) float64 {
+	switch since := time.Since(last); {
+	case since < time.Hour:
+		return rank * 4
+	case since < 24*time.Hour:
+		return rank * 2
+	case since < 7*24*time.Hour:
+		return rank / 2
+	default:
+		return rank / 4
+	}
+}
+
+func (b builtin) add(path string) error {
+	_, err := b.db.Exec(`INSERT INTO dirs (path, rank, last) VALUES (?, 1, ?)
+		ON CONFLICT(path) DO UPDATE SET rank = rank + 1, last = excluded.last`, path, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+
+	var total float64
+
+	if err := b.db.QueryRow("SELECT COALESCE(SUM(rank), 0) FROM dirs").Scan(&total); err != nil {
+		return err
+	}
+
+	if total <= float64(config.MaxAge) {
+		return nil
+	}
+
+	if _, err := b.db.Exec("UPDATE dirs SET rank = rank * ?", 0.9*float64(config.MaxAge)/total); err != nil {
+		return err
+	}
+
+	_, err = b.db.Exec("DELETE FROM dirs WHERE rank < 1")
+
+	return err
+}
+
+func (b builtin) remove(path string) error {
+	_, err := b.db.Exec("DELETE FROM dirs WHERE path = ?", path)
+	return err
+}
@@ -0,0 +1,340 @@
+// Package jump jumps to frequently and recently visited directories, ranked by zoxide or its own database.
+package main
+
+import (
+	"cmp"
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/store"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "jump"
+	NamePretty = "Jump"
+	config     *Config
+
+	dirs backend
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Backend       string `koanf:"backend" desc:"'zoxide', 'builtin' or 'auto' to use zoxide if it's installed" default:"auto"`
+	Terminal      string `koanf:"terminal" desc:"command opening a terminal in the directory. supports %PATH%, otherwise it's the working directory" default:"detected terminal"`
+	FileManager   string `koanf:"file_manager" desc:"command opening the directory in a file manager. supports %PATH%, otherwise it's appended" default:"xdg-open, open on macOS, explorer on Windows"`
+	Editor        string `koanf:"editor" desc:"command opening the directory in an editor. supports %PATH%, otherwise it's appended" default:"$VISUAL, or $EDITOR in a terminal"`
+	MaxResults    int    `koanf:"max_results" desc:"maximum number of directories returned" default:"50"`
+	MaxAge        int    `koanf:"max_age" desc:"sum of the ranks of the builtin database after which they're aged" default:"10000"`
+}
+
+const (
+	ActionTerminal    = "terminal"
+	ActionFileManager = "file_manager"
+	ActionEditor      = "editor"
+	ActionRemove      = "remove"
+
+	BackendAuto    = "auto"
+	BackendZoxide  = "zoxide"
+	BackendBuiltin = "builtin"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "folder",
+			MinScore: 30,
+		},
+		Backend:     BackendAuto,
+		FileManager: common.OpenCommand(),
+		MaxResults:  50,
+		MaxAge:      10000,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	backend := config.Backend
+
+	if backend == BackendAuto {
+		backend = BackendBuiltin
+
+		if _, err := exec.LookPath("zoxide"); err == nil {
+			backend = BackendZoxide
+		}
+	}
+
+	switch backend {
+	case BackendZoxide:
+		dirs = zoxide{}
+	case BackendBuiltin:
+		db, err := store.Open(Name, migrations...)
+		if err != nil {
+			slog.Error(Name, "db", err)
+			return
+		}
+
+		dirs = builtin{db: db}
+	default:
+		slog.Error(Name, "backend", fmt.Sprintf("unknown backend: %s", config.Backend))
+		return
+	}
+
+	slog.Info(Name, "backend", backend)
+}
+
+func Available() bool {
+	return dirs != nil
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionTerminal, Label: "Open terminal", Icon: "utilities-terminal", Default: true},
+		{Action: ActionFileManager, Label: "Open file manager", Icon: "system-file-manager"},
+		{Action: ActionEditor, Label: "Open in editor", Icon: "accessories-text-editor"},
+		{Action: ActionRemove, Label: "Remove", Icon: "edit-delete"},
+	}
+}
+
+// withPath replaces %PATH% of the command with the path, or appends it.
+func withPath(command, path string) string {
+	if strings.Contains(command, "%PATH%") {
+		return strings.ReplaceAll(command, "%PATH%", common.Quote(path))
+	}
+
+	return fmt.Sprintf("%s %s", command, common.Quote(path))
+}
+
+func terminalCommand(path string) string {
+	if config.Terminal != "" {
+		if strings.Contains(config.Terminal, "%PATH%") {
+			return withPath(config.Terminal, path)
+		}
+
+		return config.Terminal
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return withPath("open -a Terminal", path)
+	case "windows":
+		return withPath("start \"\" cmd /K cd /d", path)
+	}
+
+	return common.GetTerminal()
+}
+
+func editorCommand(path string) string {
+	if config.Editor != "" {
+		return withPath(config.Editor, path)
+	}
+
+	if v := os.Getenv("VISUAL"); v != "" {
+		return withPath(v, path)
+	}
+
+	if v := os.Getenv("EDITOR"); v != "" {
+		return common.WrapWithTerminal(withPath(v, path))
+	}
+
+	return ""
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionTerminal
+	}
+
+	path := identifier
+
+	if action == ActionRemove {
+		if err := dirs.remove(path); err != nil {
+			slog.Error(Name, "remove", err)
+			return
+		}
+
+		handlers.ProviderUpdated <- Name
+
+		return
+	}
+
+	var run string
+
+	switch action {
+	case ActionTerminal:
+		run = terminalCommand(path)
+	case ActionFileManager:
+		run = withPath(config.FileManager, path)
+	case ActionEditor:
+		run = editorCommand(path)
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	if run == "" {
+		slog.Error(Name, action, "no command configured")
+		return
+	}
+
+	cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), run)))
+	common.Detach(cmd)
+	cmd.Dir = path
+
+	if err := cmd.Start(); err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+
+	if err := dirs.add(path); err != nil {
+		slog.Error(Name, "add", err)
+	}
+}
+
+// display shortens the home directory to "~".
+func display(path string) string {
+	home, _ := os.UserHomeDir()
+
+	if home != "" && (path == home || strings.HasPrefix(path, home+string(filepath.Separator))) {
+		return "~" + strings.TrimPrefix(path, home)
+	}
+
+	return path
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	list, err := dirs.list()
+	if err != nil {
+		slog.Error(Name, "list", err)
+	}
+
+	query = strings.TrimSpace(query)
+
+	// typed paths can be jumped to, which adds them
+	if strings.HasPrefix(query, "/") || strings.HasPrefix(query, "~/") {
+		path := query
+
+		if after, ok := strings.CutPrefix(query, "~/"); ok {
+			home, _ := os.UserHomeDir()
+			path = filepath.Join(home, after)
+		}
+
+		path = filepath.Clean(path)
+
+		if isDir(path) && !slices.ContainsFunc(list, func(d Dir) bool { return d.Path == path }) {
+			entries = append(entries, item(Dir{Path: path}, math.MaxInt32))
+		}
+	}
+
+	for _, v := range list {
+		e := item(v, int32(min(v.Score, 1000)*10))
+
+		if query != "" {
+			text := display(v.Path)
+
+			score, pos, start := common.FuzzyScore(query, text, exact)
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			// frecency decides between similar matches
+			e.Score = score + int32(min(math.Log1p(v.Score)*10, 100))
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	slices.SortStableFunc(entries, func(a, b *pb.QueryResponse_Item) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	// removed directories are only checked for the shown ones
+	res := []*pb.QueryResponse_Item{}
+
+	for _, e := range entries {
+		if len(res) >= config.MaxResults {
+			break
+		}
+
+		if isDir(e.Identifier) {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+func item(d Dir, score int32) *pb.QueryResponse_Item {
+	subtext := ""
+
+	if d.Score > 0 {
+		subtext = fmt.Sprintf("frecency %.1f", d.Score)
+	}
+
+	return &pb.QueryResponse_Item{
+		Identifier:  d.Path,
+		Text:        display(d.Path),
+		Subtext:     subtext,
+		Provider:    Name,
+		Icon:        config.Icon,
+		Actions:     []string{ActionTerminal, ActionFileManager, ActionEditor, ActionRemove},
+		Type:        pb.QueryResponse_REGULAR,
+		Score:       score,
+		Preview:     d.Path,
+		PreviewType: util.PreviewTypeFile,
+	}
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
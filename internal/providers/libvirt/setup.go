@@ -0,0 +1,352 @@
+// Package libvirt lists libvirt domains and their snapshots and controls them via virsh.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os/exec"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "libvirt"
+	NamePretty = "Virtual Machines"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	URI           string `koanf:"uri" desc:"libvirt connection uri" default:"qemu:///system"`
+	Viewer        string `koanf:"viewer" desc:"command opening the console of a domain. supports %URI% and %DOMAIN%." default:"virt-manager --connect %URI% --show-domain-console %DOMAIN%"`
+	Timeout       int    `koanf:"timeout" desc:"seconds to wait for virsh" default:"60"`
+}
+
+const (
+	ActionStart    = "start"
+	ActionShutdown = "shutdown"
+	ActionForceOff = "force_off"
+	ActionReboot   = "reboot"
+	ActionPause    = "pause"
+	ActionResume   = "resume"
+	ActionViewer   = "viewer"
+
+	ActionSnapshots      = "snapshots"
+	ActionCreateSnapshot = "create_snapshot"
+	ActionRevert         = "revert"
+	ActionDeleteSnapshot = "delete_snapshot"
+
+	StateRunning = "running"
+	StatePaused  = "paused"
+	StateStopped = "stopped"
+	StateCurrent = "current"
+
+	// snapshots are identified as "<domain>/<snapshot>"
+	separator = "/"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "computer",
+			MinScore: 30,
+		},
+		URI:     "qemu:///system",
+		Viewer:  "virt-manager --connect %URI% --show-domain-console %DOMAIN%",
+		Timeout: 60,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	if Available() {
+		go events()
+	}
+}
+
+func Available() bool {
+	if _, err := exec.LookPath("virsh"); err != nil {
+		slog.Info(Name, "available", "virsh not found. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+var snapshotName = &pb.ActionArgument{Name: "name", Type: util.ArgumentText, Placeholder: "Snapshot name, generated if empty"}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionStart, Label: "Start", Icon: "media-playback-start"},
+		{Action: ActionViewer, Label: "Open viewer", Icon: "video-display"},
+		{Action: ActionShutdown, Label: "Shut down", Icon: "system-shutdown"},
+		{Action: ActionForceOff, Label: "Force off", Icon: "process-stop"},
+		{Action: ActionReboot, Label: "Reboot", Icon: "system-reboot"},
+		{Action: ActionPause, Label: "Pause", Icon: "media-playback-pause"},
+		{Action: ActionResume, Label: "Resume", Icon: "media-playback-start"},
+		{Action: ActionSnapshots, Label: "Snapshots", Icon: "document-open-recent"},
+		{Action: ActionCreateSnapshot, Label: "Create snapshot", Icon: "document-save", Arguments: []*pb.ActionArgument{snapshotName}},
+		{Action: ActionRevert, Label: "Revert", Icon: "edit-undo"},
+		{Action: ActionDeleteSnapshot, Label: "Delete snapshot", Icon: "edit-delete"},
+	}
+}
+
+// state maps the states of virsh to the item states. Others, f.e. "in shutdown" or "crashed", keep their name.
+func state(s string) string {
+	switch s {
+	case "running", "idle":
+		return StateRunning
+	case "paused", "pmsuspended":
+		return StatePaused
+	case "shut off":
+		return StateStopped
+	}
+
+	return strings.ReplaceAll(s, " ", "_")
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	domain, snapshot, isSnapshot := strings.Cut(identifier, separator)
+
+	if action == "" {
+		action = defaultAction(identifier)
+	}
+
+	var cmd []string
+
+	switch action {
+	case ActionStart:
+		cmd = []string{"start", domain}
+	case ActionShutdown:
+		cmd = []string{"shutdown", domain}
+	case ActionForceOff:
+		cmd = []string{"destroy", domain}
+	case ActionReboot:
+		cmd = []string{"reboot", domain}
+	case ActionPause:
+		cmd = []string{"suspend", domain}
+	case ActionResume:
+		cmd = []string{"resume", domain}
+	case ActionCreateSnapshot:
+		cmd = []string{"snapshot-create-as", "--domain", domain}
+
+		if name := strings.TrimSpace(args); name != "" {
+			cmd = append(cmd, "--name", name)
+		}
+	case ActionRevert, ActionDeleteSnapshot:
+		if !isSnapshot {
+			slog.Error(Name, action, fmt.Sprintf("not a snapshot: %s", identifier))
+			return
+		}
+
+		cmd = []string{"snapshot-revert", "--domain", domain, "--snapshotname", snapshot}
+
+		if action == ActionDeleteSnapshot {
+			cmd = []string{"snapshot-delete", "--domain", domain, "--snapshotname", snapshot}
+		}
+	case ActionSnapshots:
+		// clients open the snapshots like a submenu
+		handlers.ProviderUpdated <- fmt.Sprintf("%s:%s", Name, domain)
+		return
+	case ActionViewer:
+		run := strings.NewReplacer("%URI%", common.Quote(config.URI), "%DOMAIN%", common.Quote(domain)).Replace(config.Viewer)
+
+		c := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), run)))
+		common.Detach(c)
+
+		if err := c.Start(); err != nil {
+			slog.Error(Name, "viewer", err)
+			return
+		}
+
+		go func() {
+			c.Wait()
+		}()
+
+		return
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	// shutting down waits for the guest, the state is updated by the lifecycle events
+	go func() {
+		if _, err := virsh(cmd...); err != nil {
+			slog.Error(Name, action, err)
+			return
+		}
+
+		switch action {
+		case ActionCreateSnapshot, ActionRevert, ActionDeleteSnapshot:
+			handlers.ProviderUpdated <- fmt.Sprintf("%s:%s", Name, domain)
+		}
+	}()
+}
+
+// defaultAction starts stopped domains, resumes paused ones and opens the viewer of running ones. Snapshots are
+// reverted to.
+func defaultAction(identifier string) string {
+	if strings.Contains(identifier, separator) {
+		return ActionRevert
+	}
+
+	list, err := domains()
+	if err != nil {
+		slog.Error(Name, "domains", err)
+		return ActionViewer
+	}
+
+	for _, v := range list {
+		if v.Name != identifier {
+			continue
+		}
+
+		switch state(v.State) {
+		case StateStopped:
+			return ActionStart
+		case StatePaused:
+			return ActionResume
+		}
+	}
+
+	return ActionViewer
+}
+
+// parseQuery splits queries of the form "<domain>:<filter>" listing the snapshots of the domain. Other queries
+// filter the domains.
+func parseQuery(query string, list []Domain) (string, string) {
+	for _, v := range list {
+		if after, ok := strings.CutPrefix(query, v.Name+":"); ok {
+			return v.Name, strings.TrimSpace(after)
+		}
+	}
+
+	return "", query
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	list, err := domains()
+	if err != nil {
+		slog.Error(Name, "domains", err)
+		return entries
+	}
+
+	domain, query := parseQuery(strings.TrimSpace(query), list)
+
+	if domain != "" {
+		snaps, err := snapshots(domain)
+		if err != nil {
+			slog.Error(Name, "snapshots", err)
+			return entries
+		}
+
+		for k, v := range snaps {
+			e := &pb.QueryResponse_Item{
+				Identifier: domain + separator + v.Name,
+				Text:       v.Name,
+				Subtext:    fmt.Sprintf("%s · %s", v.Created, v.State),
+				Provider:   Name,
+				Icon:       "document-open-recent",
+				State:      []string{},
+				Actions:    []string{ActionRevert, ActionDeleteSnapshot},
+				Type:       pb.QueryResponse_REGULAR,
+				Score:      int32(len(snaps) - k),
+			}
+
+			if v.Current {
+				e.State = append(e.State, StateCurrent)
+			}
+
+			entries = filter(entries, e, query, exact)
+		}
+
+		return entries
+	}
+
+	for k, v := range list {
+		s := state(v.State)
+
+		var actions []string
+
+		switch s {
+		case StateRunning:
+			actions = []string{ActionViewer, ActionShutdown, ActionForceOff, ActionReboot, ActionPause}
+		case StatePaused:
+			actions = []string{ActionResume, ActionViewer, ActionForceOff}
+		case StateStopped:
+			actions = []string{ActionStart}
+		default:
+			actions = []string{ActionViewer, ActionForceOff}
+		}
+
+		actions = append(actions, ActionSnapshots, ActionCreateSnapshot)
+
+		entries = filter(entries, &pb.QueryResponse_Item{
+			Identifier: v.Name,
+			Text:       v.Name,
+			Subtext:    v.State,
+			Provider:   Name,
+			Icon:       config.Icon,
+			State:      []string{s},
+			Actions:    actions,
+			Type:       pb.QueryResponse_REGULAR,
+			Score:      int32(len(list) - k),
+		}, query, exact)
+	}
+
+	return entries
+}
+
+func filter(entries []*pb.QueryResponse_Item, e *pb.QueryResponse_Item, query string, exact bool) []*pb.QueryResponse_Item {
+	if query == "" {
+		return append(entries, e)
+	}
+
+	score, pos, start := common.FuzzyScore(query, e.Text, exact)
+
+	if score <= config.MinScore {
+		return entries
+	}
+
+	e.Score = score
+	e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+		Start:     start,
+		Field:     "text",
+		Positions: pos,
+	}
+
+	return append(entries, e)
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
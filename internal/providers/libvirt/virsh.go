@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+)
+
+type Domain struct {
+	Name  string
+	State string
+}
+
+type Snapshot struct {
+	Name    string
+	Created string
+	State   string
+	Current bool
+}
+
+// columns of the tables virsh prints are separated by at least two spaces, values may contain single ones
+var columns = regexp.MustCompile(`\s{2,}`)
+
+func virsh(args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Timeout)*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "virsh", append([]string{"--connect", config.URI}, args...)...).CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("virsh %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+
+	return out, nil
+}
+
+// table parses the rows of a table like
+//
+//	 Id   Name    State
+//	------------------------
+//	 1    win11   running
+//	 -    arch    shut off
+func table(out []byte) [][]string {
+	res := [][]string{}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	header := true
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if header {
+			header = !strings.HasPrefix(line, "---")
+			continue
+		}
+
+		if line != "" {
+			res = append(res, columns.Split(line, -1))
+		}
+	}
+
+	return res
+}
+
+func domains() ([]Domain, error) {
+	out, err := virsh("list", "--all")
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Domain{}
+
+	for _, v := range table(out) {
+		if len(v) < 3 {
+			continue
+		}
+
+		res = append(res, Domain{Name: v[1], State: v[2]})
+	}
+
+	return res, nil
+}
+
+func snapshots(domain string) ([]Snapshot, error) {
+	out, err := virsh("snapshot-list", "--domain", domain)
+	if err != nil {
+		return nil, err
+	}
+
+	current := ""
+
+	if out, err := virsh("snapshot-current", "--domain", domain, "--name"); err == nil {
+		current = strings.TrimSpace(string(out))
+	}
+
+	res := []Snapshot{}
+
+	for _, v := range table(out) {
+		if len(v) < 3 {
+			continue
+		}
+
+		res = append(res, Snapshot{Name: v[0], Created: v[1], State: v[2], Current: v[0] == current})
+	}
+
+	// virsh sorts by name. Creation times are "2006-01-02 15:04:05 -0700", newest first
+	slices.SortStableFunc(res, func(a, b Snapshot) int {
+		return strings.Compare(b.Created, a.Created)
+	})
+
+	return res, nil
+}
+
+// events follows the lifecycle events of the domains, so subscribed clients get the new states. virsh is
+// restarted if it exits, f.e. because libvirtd restarted.
+func events() {
+	for {
+		start := time.Now()
+
+		cmd := exec.Command("virsh", "--connect", config.URI, "event", "--loop", "--event", "lifecycle")
+
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			slog.Error(Name, "events", err)
+			return
+		}
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "events", err)
+			return
+		}
+
+		// "event 'lifecycle' for domain 'win11': Started Booted"
+		scanner := bufio.NewScanner(stdout)
+
+		for scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "event ") {
+				handlers.ProviderUpdated <- Name
+			}
+		}
+
+		err = cmd.Wait()
+		slog.Error(Name, "events", "virsh exited", "err", err)
+
+		if time.Since(start) < time.Minute {
+			time.Sleep(time.Minute)
+		}
+	}
+}
@@ -2,8 +2,10 @@
 package providers
 
 import (
+	"io"
 	"io/fs"
 	"log/slog"
+	"maps"
 	"net"
 	"os"
 	"path/filepath"
@@ -25,7 +27,7 @@ type ProviderStateResponse struct {
 type Provider struct {
 	Name                 *string
 	Available            func() bool
-	PrintDoc             func()
+	PrintDoc             func(io.Writer)
 	NamePretty           *string
 	State                func(string) *pb.ProviderStateResponse
 	Setup                func()
@@ -33,22 +35,66 @@ type Provider struct {
 	Icon                 func() string
 	Activate             func(single bool, identifier, action, query, args string, format uint8, conn net.Conn)
 	Query                func(conn net.Conn, query string, single bool, exact bool, format uint8) []*pb.QueryResponse_Item
+	// Refresh is optional. It reloads the providers data.
+	Refresh func()
+	// Actions is optional. It describes the actions of the provider, so clients can show labels and icons
+	// instead of the action names.
+	Actions func() []*pb.ActionDescriptor
+	// ActivateMultiple is optional. It activates several items at once, f.e. to put all of them into one
+	// archive. Returns false if the action can't be combined, then the items are activated one by one.
+	ActivateMultiple func(identifiers []string, action, query, args string, format uint8, conn net.Conn) bool
+	// Live is optional. It returns the current state of an item sent with a live interval, f.e. the
+	// progress of a download, while clients show it. Returning nil skips the update.
+	Live func(identifier string) *pb.QueryResponse_Item
+	// Restart is set for providers running in their own process and restarts it. Providers running in the
+	// daemon can't be restarted.
+	Restart func() error
 }
 
 var (
-	Providers      map[string]Provider
 	QueryProviders map[uint32][]string
+
+	// loaded are the available and enabled providers by name. Disabling and enabling changes them while
+	// requests are handled, so they're only accessed via Get and All.
+	loaded      = make(map[string]Provider)
+	providersMu sync.RWMutex
 )
 
+// Get returns the loaded provider.
+func Get(name string) (Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	p, ok := loaded[name]
+
+	return p, ok
+}
+
+// All returns a copy of the loaded providers.
+func All() map[string]Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	return maps.Clone(loaded)
+}
+
+// Replace sets the loaded providers, f.e. for tests.
+func Replace(list map[string]Provider) {
+	providersMu.Lock()
+	loaded = list
+	providersMu.Unlock()
+}
+
 func Load(setup bool) {
 	common.LoadMenus()
 	ignored := common.GetElephantConfig().IgnoredProviders
+	disabled := loadDisabled()
 
 	var mut sync.Mutex
 	have := []string{}
 	dirs := append(common.ConfigDirs(), os.Getenv("ELEPHANT_PROVIDER_DIR"))
 
-	Providers = make(map[string]Provider)
+	Replace(make(map[string]Provider))
 	QueryProviders = make(map[uint32][]string)
 
 	if os.Getenv("ELEPHANT_DEV") == "true" {
@@ -70,6 +116,11 @@ func Load(setup bool) {
 				os.Exit(1)
 			}
 
+			// providers of other profiles
+			if d.IsDir() && path == filepath.Join(v, common.ProfilesDir) {
+				return filepath.SkipDir
+			}
+
 			mut.Lock()
 			done := slices.Contains(have, filepath.Base(path))
 			mut.Unlock()
@@ -81,6 +132,8 @@ func Load(setup bool) {
 				have = append(have, filepath.Base(path))
 				mut.Unlock()
 
+				setStatus(Status{Name: fn, Reason: ReasonIgnored})
+
 				return nil
 			}
 
@@ -88,6 +141,7 @@ func Load(setup bool) {
 				p, err := plugin.Open(path)
 				if err != nil {
 					slog.Error("providers", "load", path, "err", err)
+					setStatus(Status{Name: fn, Reason: err.Error()})
 					return nil
 				}
 
@@ -149,26 +203,28 @@ func Load(setup bool) {
 					Query:                queryFunc.(func(net.Conn, string, bool, bool, uint8) []*pb.QueryResponse_Item),
 					NamePretty:           namePretty.(*string),
 					HideFromProviderlist: hideFromProviderlistFunc.(func() bool),
-					PrintDoc:             printDocFunc.(func()),
+					PrintDoc:             printDocFunc.(func(io.Writer)),
 					Available:            availableFunc.(func() bool),
 					State:                stateFunc.(func(string) *pb.ProviderStateResponse),
 				}
 
-				available := provider.Available()
+				if refreshFunc, err := p.Lookup("Refresh"); err == nil {
+					provider.Refresh = refreshFunc.(func())
+				}
 
-				if setup && available {
-					go provider.Setup()
+				if actionsFunc, err := p.Lookup("Actions"); err == nil {
+					provider.Actions = actionsFunc.(func() []*pb.ActionDescriptor)
 				}
 
-				if available {
-					mut.Lock()
-					Providers[*provider.Name] = provider
-					mut.Unlock()
+				if activateMultipleFunc, err := p.Lookup("ActivateMultiple"); err == nil {
+					provider.ActivateMultiple = activateMultipleFunc.(func([]string, string, string, string, uint8, net.Conn) bool)
 				}
 
-				slog.Info("providers", "loaded", *provider.Name)
+				if liveFunc, err := p.Lookup("Live"); err == nil {
+					provider.Live = liveFunc.(func(string) *pb.QueryResponse_Item)
+				}
 
-				if available {
+				if register(provider, setup, disabled) {
 					mut.Lock()
 					have = append(have, filepath.Base(path))
 					mut.Unlock()
@@ -183,4 +239,45 @@ func Load(setup bool) {
 			os.Exit(1)
 		}
 	}
+
+	loadRPC(setup, ignored, disabled)
+	loadWasm(setup, ignored, disabled)
+}
+
+// register sets the status of the provider and adds it, if it's available and not disabled. Returns false
+// if the provider is unavailable, so another one with the same name can be tried.
+func register(provider Provider, setup bool, disabled []string) bool {
+	status := Status{Name: *provider.Name, provider: &provider}
+
+	if slices.Contains(disabled, *provider.Name) {
+		status.Disabled = true
+		status.Reason = ReasonDisabled
+		setStatus(status)
+
+		return true
+	}
+
+	available := provider.Available()
+
+	status.Available = available
+
+	if !available {
+		status.Reason = ReasonUnavailable
+	}
+
+	setStatus(status)
+
+	if setup && available {
+		go runSetup(provider)
+	}
+
+	if available {
+		providersMu.Lock()
+		loaded[*provider.Name] = provider
+		providersMu.Unlock()
+	}
+
+	slog.Info("providers", "loaded", *provider.Name)
+
+	return available
 }
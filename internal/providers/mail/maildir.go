@@ -0,0 +1,232 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// headers are cached by file, maildir files never change their content.
+var (
+	headerMu sync.Mutex
+	headers  = make(map[string]Message)
+)
+
+var decoder = mime.WordDecoder{
+	CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+		// unknown charsets are passed through, which is good enough for subjects and names.
+		return input, nil
+	},
+}
+
+// maildirMessages lists the messages of all configured maildirs, newest first.
+func maildirMessages() []Message {
+	res := []Message{}
+
+	for _, dir := range config.Maildirs {
+		dir = expand(dir)
+
+		for _, sub := range []string{"new", "cur"} {
+			entries, err := os.ReadDir(filepath.Join(dir, sub))
+			if err != nil {
+				slog.Debug(Name, "maildir", err)
+				continue
+			}
+
+			for _, v := range entries {
+				if v.IsDir() {
+					continue
+				}
+
+				file := filepath.Join(dir, sub, v.Name())
+
+				m, ok := readHeaders(file)
+				if !ok {
+					continue
+				}
+
+				m.Unread = sub == "new" || !strings.Contains(flags(v.Name()), "S")
+
+				if config.UnreadOnly && !m.Unread {
+					continue
+				}
+
+				res = append(res, m)
+			}
+		}
+	}
+
+	slices.SortFunc(res, func(a, b Message) int {
+		return b.Date.Compare(a.Date)
+	})
+
+	if len(res) > config.Limit {
+		res = res[:config.Limit]
+	}
+
+	return res
+}
+
+func readHeaders(file string) (Message, bool) {
+	headerMu.Lock()
+	m, ok := headers[file]
+	headerMu.Unlock()
+
+	if ok {
+		return m, true
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		slog.Debug(Name, "maildir", err)
+		return Message{}, false
+	}
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	if err != nil {
+		slog.Debug(Name, "maildir", err, "file", file)
+		return Message{}, false
+	}
+
+	from := msg.Header.Get("From")
+
+	if addr, err := (&mail.AddressParser{WordDecoder: &decoder}).Parse(from); err == nil {
+		from = addr.Address
+
+		if addr.Name != "" {
+			from = addr.Name
+		}
+	}
+
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	date, err := msg.Header.Date()
+	if err != nil {
+		if info, err := os.Stat(file); err == nil {
+			date = info.ModTime()
+		}
+	}
+
+	key := md5.Sum([]byte(unique(filepath.Base(file))))
+
+	m = Message{
+		ID:        hex.EncodeToString(key[:]),
+		MessageID: strings.Trim(msg.Header.Get("Message-Id"), "<>"),
+		From:      from,
+		Subject:   subject,
+		Date:      date,
+		File:      file,
+		Count:     1,
+	}
+
+	headerMu.Lock()
+	headers[file] = m
+	headerMu.Unlock()
+
+	return m, true
+}
+
+// unique is the part of the filename that doesn't change with flags.
+func unique(name string) string {
+	u, _, _ := strings.Cut(name, ":")
+	return u
+}
+
+func flags(name string) string {
+	_, info, ok := strings.Cut(name, ":2,")
+	if !ok {
+		return ""
+	}
+
+	return info
+}
+
+// markRead moves the message to cur and adds the seen flag.
+func markRead(file string) error {
+	dir := filepath.Dir(filepath.Dir(file))
+	name := filepath.Base(file)
+
+	f := flags(name)
+	if !strings.Contains(f, "S") {
+		f = string(slices.Sorted(slices.Values([]rune(f + "S"))))
+	}
+
+	return move(file, filepath.Join(dir, "cur", fmt.Sprintf("%s:2,%s", unique(name), f)))
+}
+
+// archive moves the message into the archive maildir, marking it as read.
+func archive(file string) error {
+	if config.ArchiveDir == "" {
+		return fmt.Errorf("no archive_dir configured")
+	}
+
+	name := filepath.Base(file)
+
+	f := flags(name)
+	if !strings.Contains(f, "S") {
+		f = string(slices.Sorted(slices.Values([]rune(f + "S"))))
+	}
+
+	dest := expand(config.ArchiveDir)
+
+	for _, v := range []string{"cur", "new", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(dest, v), 0o700); err != nil {
+			return err
+		}
+	}
+
+	return move(file, filepath.Join(dest, "cur", fmt.Sprintf("%s:2,%s", unique(name), f)))
+}
+
+func move(from, to string) error {
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+
+	headerMu.Lock()
+	delete(headers, from)
+	headerMu.Unlock()
+
+	return nil
+}
+
+func expand(path string) string {
+	if after, ok := strings.CutPrefix(path, "~/"); ok {
+		home, _ := os.UserHomeDir()
+		path = filepath.Join(home, after)
+	}
+
+	return path
+}
+
+// ago is a short relative time, f.e. "5m", "3h" or "2d".
+func ago(t time.Time) string {
+	d := time.Since(t)
+
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 7*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+
+	return t.Format("02.01.2006")
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+type notmuchThread struct {
+	Thread    string   `json:"thread"`
+	Timestamp int64    `json:"timestamp"`
+	Authors   string   `json:"authors"`
+	Subject   string   `json:"subject"`
+	Tags      []string `json:"tags"`
+	Matched   int      `json:"matched"`
+	Total     int      `json:"total"`
+}
+
+func notmuch(args ...string) ([]byte, error) {
+	out, err := exec.Command("notmuch", args...).Output()
+	if err != nil {
+		if exit, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("notmuch %s: %s", args[0], strings.TrimSpace(string(exit.Stderr)))
+		}
+
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// notmuchMessages lists the threads matching the configured query, newest first.
+func notmuchMessages() ([]Message, error) {
+	query := config.Query
+	if config.UnreadOnly {
+		query = fmt.Sprintf("(%s) and tag:unread", query)
+	}
+
+	out, err := notmuch("search", "--format=json", "--output=summary", "--sort=newest-first", fmt.Sprintf("--limit=%d", config.Limit), query)
+	if err != nil {
+		return nil, err
+	}
+
+	var threads []notmuchThread
+
+	if err := json.Unmarshal(out, &threads); err != nil {
+		return nil, err
+	}
+
+	res := []Message{}
+
+	for _, v := range threads {
+		// matched authors come before the '|', f.e. "Jane, Bob| Alice"
+		from, _, _ := strings.Cut(v.Authors, "|")
+		from, _, _ = strings.Cut(from, ",")
+
+		res = append(res, Message{
+			ID:      "thread:" + v.Thread,
+			From:    strings.TrimSpace(from),
+			Subject: v.Subject,
+			Date:    time.Unix(v.Timestamp, 0),
+			Unread:  slices.Contains(v.Tags, "unread"),
+			Count:   v.Total,
+		})
+	}
+
+	return res, nil
+}
+
+// notmuchFile returns the first file and the message id of the thread, used to open it.
+func notmuchFile(thread string) (string, string, error) {
+	out, err := notmuch("search", "--output=files", "--sort=oldest-first", "--limit=1", thread)
+	if err != nil {
+		return "", "", err
+	}
+
+	id, err := notmuch("search", "--output=messages", "--sort=oldest-first", "--limit=1", thread)
+	if err != nil {
+		return "", "", err
+	}
+
+	return strings.TrimSpace(string(out)), strings.TrimPrefix(strings.TrimSpace(string(id)), "id:"), nil
+}
+
+// notmuchTag changes tags, f.e. "-unread" or "-inbox".
+func notmuchTag(thread string, tags []string) error {
+	args := append([]string{"tag"}, tags...)
+	args = append(args, "--", thread)
+
+	_, err := notmuch(args...)
+
+	return err
+}
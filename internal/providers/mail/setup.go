@@ -0,0 +1,305 @@
+// Package mail provides recent and unread mail from notmuch or maildirs.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "mail"
+	NamePretty = "Mail"
+	config     *Config
+	backend    string
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Backend       string   `koanf:"backend" desc:"notmuch or maildir. detected if empty" default:""`
+	Query         string   `koanf:"query" desc:"notmuch query to list" default:"tag:inbox"`
+	Maildirs      []string `koanf:"maildirs" desc:"maildirs to list, when not using notmuch" default:""`
+	ArchiveDir    string   `koanf:"archive_dir" desc:"maildir to move archived mail to, when not using notmuch" default:""`
+	ArchiveTags   []string `koanf:"archive_tags" desc:"notmuch tag changes to archive" default:"['-inbox', '-unread']"`
+	UnreadOnly    bool     `koanf:"unread_only" desc:"only list unread mail" default:"false"`
+	Limit         int      `koanf:"limit" desc:"max amount of mails to list" default:"50"`
+	Open          string   `koanf:"open" desc:"command to open a mail. supports %FILE%, %ID% (Message-ID) and %FOLDER%" default:"xdg-open %FILE%"`
+	Terminal      bool     `koanf:"terminal" desc:"run the open command in a terminal" default:"false"`
+}
+
+// Message is a mail, or a thread when using notmuch.
+type Message struct {
+	ID        string
+	MessageID string
+	From      string
+	Subject   string
+	Date      time.Time
+	Unread    bool
+	Count     int
+	File      string
+}
+
+const (
+	BackendNotmuch = "notmuch"
+	BackendMaildir = "maildir"
+
+	ActionOpen     = "open"
+	ActionMarkRead = "mark_read"
+	ActionArchive  = "archive"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "mail-unread",
+			MinScore: 30,
+		},
+		Query:       "tag:inbox",
+		ArchiveTags: []string{"-inbox", "-unread"},
+		Limit:       50,
+		Open:        "xdg-open %FILE%",
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	backend = config.Backend
+
+	if backend == "" {
+		backend = BackendMaildir
+
+		if _, err := exec.LookPath("notmuch"); err == nil && len(config.Maildirs) == 0 {
+			backend = BackendNotmuch
+		}
+	}
+}
+
+func Available() bool {
+	switch backend {
+	case BackendNotmuch:
+		if _, err := exec.LookPath("notmuch"); err != nil {
+			slog.Info(Name, "available", "notmuch not found. disabling")
+			return false
+		}
+	case BackendMaildir:
+		if len(config.Maildirs) == 0 {
+			slog.Info(Name, "available", "notmuch not found and no maildirs configured. disabling")
+			return false
+		}
+	default:
+		slog.Info(Name, "available", fmt.Sprintf("unknown backend: %s. disabling", backend))
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func messages() []Message {
+	if backend == BackendNotmuch {
+		res, err := notmuchMessages()
+		if err != nil {
+			slog.Error(Name, "notmuch", err)
+		}
+
+		return res
+	}
+
+	return maildirMessages()
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionOpen
+	}
+
+	var m *Message
+
+	if backend == BackendNotmuch {
+		m = &Message{ID: identifier}
+	} else {
+		for _, v := range maildirMessages() {
+			if v.ID == identifier {
+				m = &v
+				break
+			}
+		}
+
+		if m == nil {
+			slog.Error(Name, "activate", fmt.Sprintf("unknown identifier: %s", identifier))
+			return
+		}
+	}
+
+	var err error
+
+	switch action {
+	case ActionOpen:
+		err = open(m)
+	case ActionMarkRead:
+		if backend == BackendNotmuch {
+			err = notmuchTag(m.ID, []string{"-unread"})
+		} else {
+			err = markRead(m.File)
+		}
+	case ActionArchive:
+		if backend == BackendNotmuch {
+			err = notmuchTag(m.ID, config.ArchiveTags)
+		} else {
+			err = archive(m.File)
+		}
+	default:
+		err = fmt.Errorf("unknown action: %s", action)
+	}
+
+	if err != nil {
+		slog.Error(Name, "activate", err)
+	}
+}
+
+func open(m *Message) error {
+	if backend == BackendNotmuch {
+		file, id, err := notmuchFile(m.ID)
+		if err != nil {
+			return err
+		}
+
+		m.File, m.MessageID = file, id
+	}
+
+	folder := filepath.Dir(filepath.Dir(m.File))
+
+	r := strings.NewReplacer(
+		"%FILE%", common.Quote(m.File),
+		"%ID%", common.Quote(m.MessageID),
+		"%FOLDER%", common.Quote(folder),
+	)
+
+	run := r.Replace(config.Open)
+
+	if config.Terminal {
+		run = common.WrapWithTerminal(run)
+	}
+
+	cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, m.ID), run)))
+	common.Detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+
+	return nil
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	res := []*pb.QueryResponse_Item{}
+
+	list := messages()
+
+	for i, v := range list {
+		subtext := fmt.Sprintf("%s - %s", v.From, ago(v.Date))
+
+		if v.Count > 1 {
+			subtext = fmt.Sprintf("%s (%d)", subtext, v.Count)
+		}
+
+		actions := []string{ActionOpen}
+		state := []string{"read"}
+		icon := "mail-read"
+
+		if v.Unread {
+			actions = append(actions, ActionMarkRead)
+			state = []string{"unread"}
+			icon = config.Icon
+		}
+
+		if backend == BackendNotmuch || config.ArchiveDir != "" {
+			actions = append(actions, ActionArchive)
+		}
+
+		subject := v.Subject
+		if subject == "" {
+			subject = "(no subject)"
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier: v.ID,
+			Text:       subject,
+			Subtext:    subtext,
+			Icon:       icon,
+			Provider:   Name,
+			Actions:    actions,
+			State:      state,
+			Score:      int32(len(list) - i),
+			Type:       pb.QueryResponse_REGULAR,
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, subject, exact)
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Field:     "text",
+				Positions: pos,
+				Start:     start,
+			}
+
+			if s2, p2, start2 := common.FuzzyScore(query, v.From, exact); s2 > e.Score {
+				e.Score = s2
+				e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+					Field:     "subtext",
+					Positions: p2,
+					Start:     start2,
+				}
+			}
+
+			if e.Score > config.MinScore {
+				// newer mail first among similar matches
+				e.Score = e.Score + int32(len(list)-i)
+			}
+		}
+
+		if e.Score > config.MinScore || query == "" {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
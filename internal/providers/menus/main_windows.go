@@ -0,0 +1,19 @@
+package main
+
+import "github.com/abenz1267/elephant/v2/pkg/sdk"
+
+// Windows has no Go plugins, so the provider is built as executable and served via RPC.
+func main() {
+	sdk.ServeExports(sdk.Exports{
+		Name:                 &Name,
+		NamePretty:           &NamePretty,
+		Icon:                 Icon,
+		HideFromProviderlist: HideFromProviderlist,
+		Setup:                Setup,
+		Available:            Available,
+		PrintDoc:             PrintDoc,
+		State:                State,
+		Query:                Query,
+		Activate:             Activate,
+	})
+}
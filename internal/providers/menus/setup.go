@@ -3,19 +3,19 @@ package main
 import (
 	_ "embed"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"os"
-	"os/exec"
 	"slices"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
 	"github.com/abenz1267/elephant/v2/internal/util"
 	"github.com/abenz1267/elephant/v2/pkg/common"
 	"github.com/abenz1267/elephant/v2/pkg/common/history"
+	"github.com/abenz1267/elephant/v2/pkg/common/metrics"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 	lua "github.com/yuin/gopher-lua"
 )
@@ -29,11 +29,11 @@ var (
 //go:embed README.md
 var readme string
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(common.MenuConfig{}, Name)
-	util.PrintConfig(common.Menu{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, common.MenuConfig{}, Name)
+	util.PrintConfig(w, common.Menu{}, Name)
 }
 
 func Setup() {}
@@ -105,6 +105,16 @@ func Activate(single bool, identifier, action string, query string, args string,
 			return
 		}
 
+		if steps, ok := pipeline(menu, e, action); ok {
+			go common.RunPipeline(e.Text, steps, common.PipelineVars{Value: e.Value, Query: query, Args: args, Text: e.Text})
+
+			if menu.History {
+				h.Save(query, identifier)
+			}
+
+			return
+		}
+
 		run := ""
 
 		if after, ok := strings.CutPrefix(identifier, "dmenu:"); ok {
@@ -148,11 +158,16 @@ func Activate(single bool, identifier, action string, query string, args string,
 			if state != nil {
 				functionName := after
 
-				if err := state.CallByParam(lua.P{
+				start := time.Now()
+				err := state.CallByParam(lua.P{
 					Fn:      state.GetGlobal(functionName),
 					NRet:    0,
 					Protect: true,
-				}, lua.LString(e.Value), lua.LString(args)); err != nil {
+				}, lua.LString(e.Value), lua.LString(args))
+
+				metrics.LuaDuration.Since(start, menu.Name, functionName)
+
+				if err != nil {
 					slog.Error(Name, "lua function call", err, "function", functionName)
 				}
 
@@ -190,11 +205,8 @@ func Activate(single bool, identifier, action string, query string, args string,
 			run = common.WrapWithTerminal(run)
 		}
 
-		cmd := exec.Command("sh", "-c", run)
-
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			Setsid: true,
-		}
+		cmd := common.HostShell(run)
+		common.Detach(cmd)
 
 		if pipe && e.Value != "" {
 			cmd.Stdin = strings.NewReader(e.Value)
@@ -214,7 +226,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 		}
 
 		if slices.Contains(menu.AsyncActions, action) {
-			updated := itemToEntry(format, query, conn, menu.Actions, menu.NamePretty, single, menu.Icon, &e)
+			updated := itemToEntry(format, query, conn, menu.Actions, menu.Pipelines, menu.NamePretty, single, menu.Icon, &e)
 			handlers.UpdateItem(format, query, conn, updated)
 
 		}
@@ -245,7 +257,7 @@ func Query(conn net.Conn, query string, single bool, exact bool, format uint8) [
 		}
 
 		for k, me := range v.Entries {
-			e := itemToEntry(format, query, conn, v.Actions, v.NamePretty, single, v.Icon, &v.Entries[k])
+			e := itemToEntry(format, query, conn, v.Actions, v.Pipelines, v.NamePretty, single, v.Icon, &v.Entries[k])
 
 			if v.FixedOrder {
 				e.Score = 1_000_000 - int32(k)
@@ -310,6 +322,21 @@ func State(provider string) *pb.ProviderStateResponse {
 	return &pb.ProviderStateResponse{}
 }
 
+// pipeline returns the steps of the action, the ones of the entry take precedence over the ones of the menu.
+func pipeline(menu *common.Menu, e common.Entry, action string) ([]common.PipelineStep, bool) {
+	if steps, ok := e.Pipelines[action]; ok {
+		return steps, true
+	}
+
+	if menu == nil {
+		return nil, false
+	}
+
+	steps, ok := menu.Pipelines[action]
+
+	return steps, ok
+}
+
 func calcScore(q string, d common.Entry, exact bool) (string, int32, []int32, int32, bool) {
 	var scoreRes int32
 	var posRes []int32
@@ -341,7 +368,7 @@ func calcScore(q string, d common.Entry, exact bool) (string, int32, []int32, in
 	return match, scoreRes, posRes, startRes, true
 }
 
-func itemToEntry(format uint8, query string, conn net.Conn, menuActions map[string]string, namePretty string, single bool, icon string, me *common.Entry) *pb.QueryResponse_Item {
+func itemToEntry(format uint8, query string, conn net.Conn, menuActions map[string]string, menuPipelines map[string][]common.PipelineStep, namePretty string, single bool, icon string, me *common.Entry) *pb.QueryResponse_Item {
 	if me.Icon != "" {
 		icon = me.Icon
 	}
@@ -362,12 +389,24 @@ func itemToEntry(format uint8, query string, conn net.Conn, menuActions map[stri
 		actions = append(actions, k)
 	}
 
+	for k := range me.Pipelines {
+		if !slices.Contains(actions, k) {
+			actions = append(actions, k)
+		}
+	}
+
 	for k := range menuActions {
 		if !slices.Contains(actions, k) {
 			actions = append(actions, k)
 		}
 	}
 
+	for k := range menuPipelines {
+		if !slices.Contains(actions, k) {
+			actions = append(actions, k)
+		}
+	}
+
 	if strings.HasPrefix(me.Identifier, "menus:") {
 		actions = append(actions, ActionOpen)
 	}
@@ -393,7 +432,7 @@ func itemToEntry(format uint8, query string, conn net.Conn, menuActions map[stri
 		me.Value = ""
 
 		go func() {
-			cmd := exec.Command("sh", "-c", me.Async)
+			cmd := common.HostShell(me.Async)
 			out, err := cmd.CombinedOutput()
 
 			if err == nil {
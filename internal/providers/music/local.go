@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+var (
+	localMu      sync.Mutex
+	localTracks  []Track
+	localScanned time.Time
+)
+
+var audioExtensions = []string{".mp3", ".flac", ".ogg", ".opus", ".m4a", ".wav", ".aac"}
+
+// covers are looked up next to the tracks.
+var covers = []string{"cover.jpg", "cover.png", "folder.jpg", "folder.png", "front.jpg", "front.png"}
+
+func musicDir() string {
+	if after, ok := strings.CutPrefix(config.MusicDir, "~/"); ok {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, after)
+	}
+
+	return config.MusicDir
+}
+
+// local returns the tracks in the music directory. Tags aren't read, artist and album are taken from the
+// "<artist>/<album>/<track>" layout most libraries use. The list is refreshed after a few minutes.
+func local() []Track {
+	localMu.Lock()
+	defer localMu.Unlock()
+
+	if localTracks != nil && time.Since(localScanned) < 5*time.Minute {
+		return localTracks
+	}
+
+	dir := musicDir()
+	res := []Track{}
+
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !slices.Contains(audioExtensions, strings.ToLower(filepath.Ext(path))) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+
+		t := Track{File: rel, Title: trackTitle(filepath.Base(path))}
+
+		if parts := strings.Split(filepath.Dir(rel), string(filepath.Separator)); len(parts) >= 2 {
+			t.Artist, t.Album = parts[len(parts)-2], parts[len(parts)-1]
+		} else if parts[0] != "." {
+			t.Artist = parts[0]
+		}
+
+		res = append(res, t)
+
+		return nil
+	})
+
+	localTracks = res
+	localScanned = time.Now()
+
+	return res
+}
+
+// trackTitle strips the extension and a leading track number, f.e. "01 - Title.flac".
+func trackTitle(name string) string {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	trimmed := strings.TrimLeftFunc(name, unicode.IsDigit)
+	if trimmed != name {
+		trimmed = strings.TrimLeft(trimmed, " .-_")
+	}
+
+	if trimmed == "" {
+		return name
+	}
+
+	return trimmed
+}
+
+// cover returns the album art next to the track, which is relative to the music directory.
+func cover(file string) string {
+	dir := filepath.Join(musicDir(), filepath.Dir(file))
+
+	for _, v := range covers {
+		if p := filepath.Join(dir, v); common.FileExists(p) {
+			return p
+		}
+	}
+
+	return ""
+}
+
+// addToLocalPlaylist appends the track to "<playlist>.m3u" in the playlist directory.
+func addToLocalPlaylist(playlist, file string) error {
+	if playlist == "" || strings.ContainsRune(playlist, filepath.Separator) {
+		return fmt.Errorf("invalid playlist: '%s'", playlist)
+	}
+
+	dir := filepath.Join(musicDir(), "Playlists")
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, playlist+".m3u"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(f, filepath.Join(musicDir(), file)); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+)
+
+// mpd is a connection speaking the mpd text protocol. Commands are serialized.
+type mpd struct {
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+var (
+	mpdMu     sync.Mutex
+	mpdClient *mpd
+)
+
+// mpdAddress returns the configured address, or the one from MPD_HOST and MPD_PORT. Addresses starting with
+// "/" are unix sockets.
+func mpdAddress() (network, address string) {
+	address = config.MPDAddress
+
+	if address == "" {
+		host, port := os.Getenv("MPD_HOST"), os.Getenv("MPD_PORT")
+
+		if host == "" {
+			host = "localhost"
+		}
+
+		if port == "" {
+			port = "6600"
+		}
+
+		address = host
+
+		if !strings.HasPrefix(host, "/") {
+			address = net.JoinHostPort(host, port)
+		}
+	}
+
+	if strings.HasPrefix(address, "/") {
+		return "unix", address
+	}
+
+	return "tcp", address
+}
+
+func dialMPD() (*mpd, error) {
+	network, address := mpdAddress()
+
+	conn, err := net.DialTimeout(network, address, 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &mpd{conn: conn, r: bufio.NewReader(conn)}
+
+	greeting, err := m.r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(greeting, "OK MPD") {
+		conn.Close()
+		return nil, fmt.Errorf("mpd: unexpected greeting %q", greeting)
+	}
+
+	if config.MPDPassword != "" {
+		if _, err := m.command("password", config.MPDPassword); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// client returns the shared connection, reconnecting if it was closed.
+func client() (*mpd, error) {
+	mpdMu.Lock()
+	defer mpdMu.Unlock()
+
+	if mpdClient != nil {
+		return mpdClient, nil
+	}
+
+	m, err := dialMPD()
+	if err != nil {
+		return nil, err
+	}
+
+	mpdClient = m
+
+	return m, nil
+}
+
+// run sends the command on the shared connection, retrying once on a new connection as mpd closes idle ones.
+func run(args ...string) ([][2]string, error) {
+	for range 2 {
+		m, err := client()
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := m.command(args...)
+
+		var ack ackError
+		if err == nil || errors.As(err, &ack) {
+			return res, err
+		}
+
+		mpdMu.Lock()
+		if mpdClient == m {
+			mpdClient.conn.Close()
+			mpdClient = nil
+		}
+		mpdMu.Unlock()
+	}
+
+	return nil, errors.New("mpd: connection lost")
+}
+
+type ackError string
+
+func (e ackError) Error() string {
+	return string(e)
+}
+
+// command sends the command and returns the key value pairs of the response.
+func (m *mpd) command(args ...string) ([][2]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	line := args[0]
+
+	for _, v := range args[1:] {
+		line += " " + quoteArg(v)
+	}
+
+	m.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	defer m.conn.SetDeadline(time.Time{})
+
+	if _, err := fmt.Fprintf(m.conn, "%s\n", line); err != nil {
+		return nil, err
+	}
+
+	return m.response()
+}
+
+func (m *mpd) response() ([][2]string, error) {
+	res := [][2]string{}
+
+	for {
+		line, err := m.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "OK":
+			return res, nil
+		case strings.HasPrefix(line, "ACK "):
+			return nil, ackError(line)
+		}
+
+		if k, v, ok := strings.Cut(line, ": "); ok {
+			res = append(res, [2]string{k, v})
+		}
+	}
+}
+
+func quoteArg(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+type Track struct {
+	File   string
+	Artist string
+	Album  string
+	Title  string
+}
+
+// tracks groups the pairs of a response by file.
+func tracks(pairs [][2]string) []Track {
+	res := []Track{}
+
+	for _, v := range pairs {
+		if v[0] == "file" {
+			res = append(res, Track{File: v[1]})
+			continue
+		}
+
+		if len(res) == 0 {
+			continue
+		}
+
+		t := &res[len(res)-1]
+
+		switch v[0] {
+		case "Artist":
+			t.Artist = v[1]
+		case "Album":
+			t.Album = v[1]
+		case "Title":
+			t.Title = v[1]
+		}
+	}
+
+	return res
+}
+
+func mpdSearch(query string) ([]Track, error) {
+	pairs, err := run("search", "any", query)
+	if err != nil {
+		return nil, err
+	}
+
+	return tracks(pairs), nil
+}
+
+// mpdStatus returns the player state, "play", "pause" or "stop", and the current track.
+func mpdStatus() (string, *Track, error) {
+	status, err := run("status")
+	if err != nil {
+		return "", nil, err
+	}
+
+	state := ""
+
+	for _, v := range status {
+		if v[0] == "state" {
+			state = v[1]
+		}
+	}
+
+	song, err := run("currentsong")
+	if err != nil {
+		return state, nil, err
+	}
+
+	if t := tracks(song); len(t) > 0 {
+		return state, &t[0], nil
+	}
+
+	return state, nil, nil
+}
+
+func mpdPlay(file string) error {
+	res, err := run("addid", file)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range res {
+		if v[0] == "Id" {
+			_, err := run("playid", v[1])
+			return err
+		}
+	}
+
+	return errors.New("mpd: no id for added track")
+}
+
+func mpdQueue(file string) error {
+	_, err := run("add", file)
+	return err
+}
+
+func mpdAddToPlaylist(playlist, file string) error {
+	_, err := run("playlistadd", playlist, file)
+	return err
+}
+
+// idle waits for player, queue and database changes on a separate connection and updates subscribers.
+func idle() {
+	for {
+		m, err := dialMPD()
+		if err != nil {
+			time.Sleep(10 * time.Second)
+			continue
+		}
+
+		for {
+			if _, err := fmt.Fprintf(m.conn, "idle player playlist database\n"); err != nil {
+				break
+			}
+
+			if _, err := m.response(); err != nil {
+				break
+			}
+
+			handlers.ProviderUpdated <- Name
+		}
+
+		m.conn.Close()
+		time.Sleep(time.Second)
+	}
+}
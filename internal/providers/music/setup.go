@@ -0,0 +1,299 @@
+// Package music searches and plays tracks of an mpd database or a local music folder.
+package main
+
+import (
+	"cmp"
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "music"
+	NamePretty = "Music"
+	config     *Config
+	useMPD     bool
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config  `koanf:",squash"`
+	Backend        string `koanf:"backend" desc:"'mpd', 'local' or 'auto', which uses mpd if it's running" default:"auto"`
+	MPDAddress     string `koanf:"mpd_address" desc:"host:port or socket path of mpd. MPD_HOST and MPD_PORT if empty" default:""`
+	MPDPassword    string `koanf:"mpd_password" desc:"password of mpd" default:""`
+	MusicDir       string `koanf:"music_dir" desc:"music folder, used for album art with mpd as well" default:"~/Music"`
+	PlayCommand    string `koanf:"play_command" desc:"plays local tracks, %FILE% is replaced" default:"mpv --no-video --force-window=no %FILE%"`
+	MinQueryLength int    `koanf:"min_query_length" desc:"minimum query length to search" default:"2"`
+	MaxResults     int    `koanf:"max_results" desc:"maximum number of tracks returned" default:"50"`
+}
+
+const (
+	ActionPlay          = "play"
+	ActionQueue         = "queue"
+	ActionAddToPlaylist = "add_to_playlist"
+
+	StatePlaying = "playing"
+	StatePaused  = "paused"
+	StateStopped = "stopped"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "audio-x-generic",
+			MinScore: 20,
+		},
+		Backend:        "auto",
+		MusicDir:       "~/Music",
+		PlayCommand:    "mpv --no-video --force-window=no %FILE%",
+		MinQueryLength: 2,
+		MaxResults:     50,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	switch config.Backend {
+	case "mpd":
+		useMPD = true
+	case "local":
+		useMPD = false
+	default:
+		_, err := client()
+		useMPD = err == nil
+	}
+
+	if useMPD {
+		go idle()
+	}
+
+	slog.Info(Name, "mpd", useMPD)
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionPlay, Label: "Play", Icon: "media-playback-start", Default: true},
+		{Action: ActionQueue, Label: "Add to queue", Icon: "list-add"},
+		{Action: ActionAddToPlaylist, Label: "Add to playlist", Icon: "view-media-playlist", Arguments: []*pb.ActionArgument{
+			{Name: "playlist", Type: util.ArgumentText, Placeholder: "Playlist", Required: true},
+		}},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionPlay
+	}
+
+	var err error
+
+	switch action {
+	case ActionPlay:
+		if useMPD {
+			err = mpdPlay(identifier)
+		} else {
+			err = playLocal(identifier)
+		}
+	case ActionQueue:
+		if !useMPD {
+			slog.Error(Name, "activate", "queueing needs mpd")
+			return
+		}
+
+		err = mpdQueue(identifier)
+	case ActionAddToPlaylist:
+		if useMPD {
+			err = mpdAddToPlaylist(strings.TrimSpace(args), identifier)
+		} else {
+			err = addToLocalPlaylist(strings.TrimSpace(args), identifier)
+		}
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	if err != nil {
+		slog.Error(Name, action, err)
+	}
+}
+
+func playLocal(file string) error {
+	run := strings.ReplaceAll(config.PlayCommand, "%FILE%", common.Quote(filepath.Join(musicDir(), file)))
+
+	cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, file), run)))
+	common.Detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+
+	return nil
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	if len(query) < config.MinQueryLength {
+		return entries
+	}
+
+	var found []Track
+
+	if useMPD {
+		var err error
+
+		found, err = mpdSearch(query)
+		if err != nil {
+			slog.Error(Name, "query", err)
+			return entries
+		}
+	} else {
+		found = local()
+	}
+
+	actions := []string{ActionPlay, ActionAddToPlaylist}
+	if useMPD {
+		actions = []string{ActionPlay, ActionQueue, ActionAddToPlaylist}
+	}
+
+	for _, v := range found {
+		title := v.Title
+		if title == "" {
+			title = filepath.Base(v.File)
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier: v.File,
+			Text:       title,
+			Subtext:    strings.Join(nonEmpty(v.Artist, v.Album), " · "),
+			Provider:   Name,
+			Icon:       config.Icon,
+			Actions:    actions,
+			Type:       pb.QueryResponse_REGULAR,
+		}
+
+		// mpd already filtered, local tracks are matched by artist, album and title
+		score, pos, start := common.FuzzyScore(query, e.Text, exact)
+
+		if s, _, _ := common.FuzzyScore(query, strings.Join(nonEmpty(v.Artist, v.Album, title), " "), exact); s > score {
+			score, pos, start = s, nil, 0
+		}
+
+		if !useMPD && score <= config.MinScore {
+			continue
+		}
+
+		e.Score = score
+		e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+			Start:     start,
+			Field:     "text",
+			Positions: pos,
+		}
+
+		entries = append(entries, e)
+	}
+
+	entries = topScored(entries, config.MaxResults)
+
+	// album art is looked up after filtering
+	for _, e := range entries {
+		if c := cover(e.Identifier); c != "" {
+			e.Preview = c
+			e.PreviewType = util.PreviewTypeFile
+		}
+	}
+
+	return entries
+}
+
+// topScored returns the best scored entries.
+func topScored(entries []*pb.QueryResponse_Item, n int) []*pb.QueryResponse_Item {
+	if n <= 0 || len(entries) <= n {
+		return entries
+	}
+
+	slices.SortStableFunc(entries, func(a, b *pb.QueryResponse_Item) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	return entries[:n]
+}
+
+func nonEmpty(values ...string) []string {
+	res := []string{}
+
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+// State is the state of mpd, "playing", "paused" or "stopped", with "track:<artist> - <title>" of the current
+// track.
+func State(provider string) *pb.ProviderStateResponse {
+	res := &pb.ProviderStateResponse{States: []string{}}
+
+	if !useMPD {
+		return res
+	}
+
+	state, track, err := mpdStatus()
+	if err != nil {
+		slog.Error(Name, "state", err)
+		return res
+	}
+
+	switch state {
+	case "play":
+		res.States = append(res.States, StatePlaying)
+	case "pause":
+		res.States = append(res.States, StatePaused)
+	default:
+		res.States = append(res.States, StateStopped)
+	}
+
+	if track != nil {
+		res.States = append(res.States, "track:"+strings.Join(nonEmpty(track.Artist, track.Title), " - "))
+	}
+
+	return res
+}
@@ -1,9 +1,12 @@
+//go:build linux
+
 package main
 
 import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"os"
@@ -78,10 +81,10 @@ func Available() bool {
 	return false
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 type OpenedOrChangedEvent struct {
@@ -151,7 +154,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 		for _, w := range v.Windows {
 			go monitor(w.AppID, res)
 
-			cmd := exec.Command("sh", "-c", w.Command)
+			cmd := common.HostShell(w.Command)
 			err := cmd.Start()
 			if err != nil {
 				slog.Error(Name, "activate", err)
@@ -168,7 +171,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 			for _, v := range w.After {
 				toRun := strings.ReplaceAll(v, "%ID%", idStr)
 
-				cmd := exec.Command("sh", "-c", toRun)
+				cmd := common.HostShell(toRun)
 
 				err := cmd.Run()
 				if err != nil {
@@ -179,7 +182,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 		}
 
 		for _, c := range v.After {
-			cmd := exec.Command("sh", "-c", c)
+			cmd := common.HostShell(c)
 
 			err := cmd.Run()
 			if err != nil {
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/charlievieth/fastwalk"
+)
+
+const (
+	SourceDir      = "dir"
+	SourceRegistry = "registry"
+)
+
+// Flake is a flake found in a configured directory or in the registry.
+type Flake struct {
+	// Ref is the flake reference passed to nix, f.e. 'nixpkgs' or '/home/user/project'.
+	Ref    string
+	Name   string
+	Path   string
+	Source string
+	// Target is what the registry entry resolves to.
+	Target string
+}
+
+// Metadata is the cached result of evaluating a flake.
+type Metadata struct {
+	Description  string
+	URL          string
+	Revision     string
+	LastModified int64
+	// Outputs are the output attributes for the current system, f.e. 'devShells.default'.
+	Outputs []string
+	// Stamp invalidates local flakes when flake.nix or flake.lock change.
+	Stamp     string
+	Evaluated time.Time
+	Error     string
+}
+
+var (
+	flakes     = []Flake{}
+	flakesMu   sync.RWMutex
+	metadata   = make(map[string]Metadata)
+	metadataMu sync.RWMutex
+	evaluating = make(map[string]struct{})
+	// evaluations can be slow and fetch from the network, so only run a few at once.
+	evaluations = make(chan struct{}, 2)
+)
+
+// scan finds flakes in the configured directories and the registry. Directories containing a flake
+// aren't descended into.
+func scan() {
+	start := time.Now()
+
+	found := []Flake{}
+	var mut sync.Mutex
+
+	conf := fastwalk.Config{
+		Follow: false,
+	}
+
+	for _, root := range config.Dirs {
+		root = filepath.Clean(expand(root))
+		depth := strings.Count(root, string(filepath.Separator))
+
+		walkFn := func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+
+			if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "result") {
+				return filepath.SkipDir
+			}
+
+			if _, err := os.Stat(filepath.Join(path, "flake.nix")); err == nil {
+				mut.Lock()
+				found = append(found, Flake{
+					Ref:    path,
+					Name:   filepath.Base(path),
+					Path:   path,
+					Source: SourceDir,
+				})
+				mut.Unlock()
+
+				return filepath.SkipDir
+			}
+
+			if strings.Count(path, string(filepath.Separator))-depth >= config.Depth {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if err := fastwalk.Walk(&conf, root, walkFn); err != nil {
+			slog.Error(Name, "scan", err, "root", root)
+		}
+	}
+
+	slices.SortFunc(found, func(a, b Flake) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	if config.Registry {
+		found = append(found, registry()...)
+	}
+
+	flakesMu.Lock()
+	flakes = found
+	flakesMu.Unlock()
+
+	slog.Info(Name, "flakes", len(found), "time", time.Since(start))
+}
+
+// registry parses 'nix registry list', f.e. 'global flake:nixpkgs github:NixOS/nixpkgs/nixpkgs-unstable'.
+// Entries shadowed by a higher priority registry are skipped.
+func registry() []Flake {
+	out, err := nix("registry", "list").Output()
+	if err != nil {
+		slog.Error(Name, "registry", err)
+		return nil
+	}
+
+	res := []Flake{}
+	seen := make(map[string]struct{})
+
+	for line := range strings.Lines(string(out)) {
+		fields := strings.Fields(line)
+
+		if len(fields) < 3 {
+			continue
+		}
+
+		ref := strings.TrimPrefix(fields[1], "flake:")
+
+		if _, ok := seen[ref]; ok {
+			continue
+		}
+
+		seen[ref] = struct{}{}
+
+		f := Flake{
+			Ref:    ref,
+			Name:   ref,
+			Source: SourceRegistry,
+			Target: fields[2],
+		}
+
+		if after, ok := strings.CutPrefix(fields[2], "path:"); ok {
+			f.Path, _, _ = strings.Cut(after, "?")
+		}
+
+		res = append(res, f)
+	}
+
+	return res
+}
+
+// stamp changes whenever flake.nix or flake.lock of a local flake change.
+func stamp(f Flake) string {
+	if f.Path == "" {
+		return ""
+	}
+
+	var b strings.Builder
+
+	for _, file := range []string{"flake.nix", "flake.lock"} {
+		if info, err := os.Stat(filepath.Join(f.Path, file)); err == nil {
+			fmt.Fprintf(&b, "%d-%d;", info.ModTime().UnixNano(), info.Size())
+		}
+	}
+
+	return b.String()
+}
+
+// cached returns the metadata of the flake and whether it is still valid.
+func cached(f Flake) (Metadata, bool) {
+	metadataMu.RLock()
+	m, ok := metadata[f.Ref]
+	metadataMu.RUnlock()
+
+	if !ok {
+		return m, false
+	}
+
+	if f.Path != "" {
+		return m, m.Stamp == stamp(f)
+	}
+
+	return m, time.Since(m.Evaluated) < time.Duration(config.RegistryCacheHours)*time.Hour
+}
+
+// evaluate fetches the metadata in the background, unless already evaluating.
+func evaluate(f Flake) {
+	metadataMu.Lock()
+
+	if _, ok := evaluating[f.Ref]; ok {
+		metadataMu.Unlock()
+		return
+	}
+
+	evaluating[f.Ref] = struct{}{}
+	metadataMu.Unlock()
+
+	go func() {
+		evaluations <- struct{}{}
+		m := evaluateFlake(f)
+		<-evaluations
+
+		metadataMu.Lock()
+		metadata[f.Ref] = m
+		delete(evaluating, f.Ref)
+		metadataMu.Unlock()
+
+		writeCache()
+
+		handlers.ProviderUpdated <- Name
+	}()
+}
+
+func evaluateFlake(f Flake) Metadata {
+	m := Metadata{
+		Stamp:     stamp(f),
+		Evaluated: time.Now(),
+	}
+
+	var meta struct {
+		Description  string `json:"description"`
+		ResolvedURL  string `json:"resolvedUrl"`
+		Revision     string `json:"revision"`
+		LastModified int64  `json:"lastModified"`
+	}
+
+	if err := nixJSON(&meta, "flake", "metadata", "--json", "--no-write-lock-file", f.Ref); err != nil {
+		slog.Error(Name, "metadata", err, "flake", f.Ref)
+		m.Error = err.Error()
+		return m
+	}
+
+	m.Description = meta.Description
+	m.URL = meta.ResolvedURL
+	m.Revision = meta.Revision
+	m.LastModified = meta.LastModified
+
+	// showing outputs evaluates the flake, which is too expensive for big remote flakes like nixpkgs.
+	if f.Source == SourceDir || config.EvaluateRegistry {
+		var show map[string]json.RawMessage
+
+		if err := nixJSON(&show, "flake", "show", "--json", "--no-write-lock-file", f.Ref); err != nil {
+			slog.Error(Name, "show", err, "flake", f.Ref)
+			return m
+		}
+
+		m.Outputs = outputs(show)
+	}
+
+	return m
+}
+
+// outputs lists the per-system outputs that can be used with 'nix develop' and 'nix run'.
+func outputs(show map[string]json.RawMessage) []string {
+	res := []string{}
+
+	for _, kind := range []string{"devShells", "apps", "packages"} {
+		var systems map[string]map[string]json.RawMessage
+
+		if err := json.Unmarshal(show[kind], &systems); err != nil {
+			continue
+		}
+
+		names := []string{}
+
+		for name := range systems[system()] {
+			names = append(names, fmt.Sprintf("%s.%s", kind, name))
+		}
+
+		slices.Sort(names)
+		res = append(res, names...)
+	}
+
+	return res
+}
+
+func system() string {
+	arch := runtime.GOARCH
+
+	switch arch {
+	case "amd64":
+		arch = "x86_64"
+	case "arm64":
+		arch = "aarch64"
+	case "386":
+		arch = "i686"
+	}
+
+	return fmt.Sprintf("%s-%s", arch, runtime.GOOS)
+}
+
+func nixJSON(v any, args ...string) error {
+	var stderr bytes.Buffer
+
+	cmd := nix(args...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, lastLine(stderr.String()))
+	}
+
+	return json.Unmarshal(out, v)
+}
+
+// nix enables flakes, in case they aren't enabled in nix.conf.
+func nix(args ...string) *exec.Cmd {
+	return exec.Command("nix", append([]string{"--extra-experimental-features", "nix-command flakes"}, args...)...)
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func expand(path string) string {
+	if after, ok := strings.CutPrefix(path, "~/"); ok {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, after)
+	}
+
+	return path
+}
+
+func cacheFile() string {
+	return common.CacheFile(fmt.Sprintf("%s.gob", Name))
+}
+
+func loadCache() {
+	file := cacheFile()
+
+	if !common.FileExists(file) {
+		return
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		slog.Error(Name, "cache", err)
+		return
+	}
+
+	res := make(map[string]Metadata)
+
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&res); err != nil {
+		slog.Error(Name, "cache", err)
+		return
+	}
+
+	metadataMu.Lock()
+	metadata = res
+	metadataMu.Unlock()
+}
+
+func writeCache() {
+	var b bytes.Buffer
+
+	metadataMu.RLock()
+	err := gob.NewEncoder(&b).Encode(metadata)
+	metadataMu.RUnlock()
+
+	if err != nil {
+		slog.Error(Name, "cache", err)
+		return
+	}
+
+	file := cacheFile()
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		slog.Error(Name, "cache", err)
+		return
+	}
+
+	if err := os.WriteFile(file, b.Bytes(), 0o600); err != nil {
+		slog.Error(Name, "cache", err)
+	}
+}
@@ -0,0 +1,338 @@
+// Package nix provides flakes from configured directories and the flake registry.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/history"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "nix"
+	NamePretty = "Nix Flakes"
+	config     *Config
+	h          = history.Load(Name)
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config      `koanf:",squash"`
+	History            bool     `koanf:"history" desc:"make use of history for sorting" default:"true"`
+	HistoryWhenEmpty   bool     `koanf:"history_when_empty" desc:"consider history when query is empty" default:"false"`
+	Dirs               []string `koanf:"dirs" desc:"directories to scan for flakes" default:"['~/projects']"`
+	Depth              int      `koanf:"depth" desc:"max depth to scan directories" default:"3"`
+	Registry           bool     `koanf:"registry" desc:"list flakes from the registry" default:"true"`
+	EvaluateRegistry   bool     `koanf:"evaluate_registry" desc:"list outputs of registry flakes. expensive for big flakes like nixpkgs" default:"false"`
+	RegistryCacheHours int      `koanf:"registry_cache_hours" desc:"hours to cache metadata of registry flakes. local flakes are re-evaluated when flake.nix or flake.lock change" default:"24"`
+	CommandDevelop     string   `koanf:"command_develop" desc:"command to enter the devshell, run in a terminal in the flake's directory. supports %REF%." default:"nix develop %REF%"`
+	CommandRun         string   `koanf:"command_run" desc:"command to run the flake. supports %REF%." default:"nix run %REF%"`
+	RunInTerminal      bool     `koanf:"run_in_terminal" desc:"wraps the run command with the terminal" default:"true"`
+	Copy               string   `koanf:"copy" desc:"command to copy the flake ref. supports %VALUE%." default:"wl-copy, xclip on X11, pbcopy on macOS, clip on Windows"`
+}
+
+const (
+	ActionDevelop = "develop"
+	ActionRun     = "run"
+	ActionCopy    = "copy_ref"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "nix-snowflake",
+			MinScore: 30,
+		},
+		History:            true,
+		HistoryWhenEmpty:   false,
+		Dirs:               []string{"~/projects"},
+		Depth:              3,
+		Registry:           true,
+		EvaluateRegistry:   false,
+		RegistryCacheHours: 24,
+		CommandDevelop:     "nix develop %REF%",
+		CommandRun:         "nix run %REF%",
+		RunInTerminal:      true,
+		Copy:               common.CopyCommand(),
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	loadCache()
+
+	go scan()
+}
+
+// Refresh rescans the directories and the registry.
+func Refresh() {
+	scan()
+}
+
+func Available() bool {
+	if _, err := exec.LookPath("nix"); err != nil {
+		slog.Info(Name, "available", "nix not found. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == history.ActionDelete {
+		h.Remove(identifier)
+		return
+	}
+
+	if action == "" {
+		action = ActionDevelop
+	}
+
+	f, ok := flakeByRef(identifier)
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown flake: %s", identifier))
+		return
+	}
+
+	ref := f.Ref
+	if args != "" {
+		// selects an output, f.e. 'devShells.ci' or 'apps.fmt'.
+		ref = fmt.Sprintf("%s#%s", ref, args)
+	}
+
+	var run string
+
+	switch action {
+	case ActionDevelop:
+		run = common.WrapWithTerminal(strings.ReplaceAll(config.CommandDevelop, "%REF%", common.Quote(ref)))
+	case ActionRun:
+		run = strings.ReplaceAll(config.CommandRun, "%REF%", common.Quote(ref))
+
+		if config.RunInTerminal {
+			run = common.WrapWithTerminal(run)
+		}
+	case ActionCopy:
+		cmd := common.ReplaceResultOrStdinCmd(config.Copy, ref)
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "activate", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+
+		return
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), run)))
+	common.Detach(cmd)
+
+	if f.Path != "" {
+		cmd.Dir = f.Path
+	} else if home, err := os.UserHomeDir(); err == nil {
+		cmd.Dir = home
+	}
+
+	err := cmd.Start()
+	if err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+
+	if config.History {
+		h.Save(query, identifier)
+	}
+}
+
+func flakeByRef(ref string) (Flake, bool) {
+	flakesMu.RLock()
+	defer flakesMu.RUnlock()
+
+	for _, v := range flakes {
+		if v.Ref == ref {
+			return v, true
+		}
+	}
+
+	return Flake{}, false
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	res := []*pb.QueryResponse_Item{}
+
+	flakesMu.RLock()
+	list := flakes
+	flakesMu.RUnlock()
+
+	for _, f := range list {
+		m, valid := cached(f)
+
+		if !valid {
+			evaluate(f)
+		}
+
+		subtext := m.Description
+
+		if subtext == "" {
+			subtext = f.Path
+		}
+
+		if subtext == "" {
+			subtext = f.Target
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier:  f.Ref,
+			Text:        f.Name,
+			Subtext:     subtext,
+			Icon:        config.Icon,
+			Provider:    Name,
+			Actions:     []string{ActionDevelop, ActionRun, ActionCopy},
+			State:       []string{f.Source},
+			Type:        pb.QueryResponse_REGULAR,
+			Preview:     preview(f, m, valid),
+			PreviewType: util.PreviewTypeText,
+		}
+
+		if !valid {
+			e.State = append(e.State, "evaluating")
+		}
+
+		if m.Error != "" {
+			e.State = append(e.State, "error")
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, f.Name, exact)
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Field:     "text",
+				Positions: pos,
+				Start:     start,
+			}
+
+			if m.Description != "" {
+				if s2, p2, start2 := common.FuzzyScore(query, m.Description, exact); s2 > score {
+					e.Score = s2
+					e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+						Field:     "subtext",
+						Positions: p2,
+						Start:     start2,
+					}
+				}
+			}
+		}
+
+		if config.History {
+			if e.Score > config.MinScore || query == "" && config.HistoryWhenEmpty {
+				usageScore := h.CalcUsageScore(query, e.Identifier)
+
+				if usageScore != 0 {
+					e.State = append(e.State, "history")
+					e.Actions = append(e.Actions, history.ActionDelete)
+				}
+
+				e.Score = e.Score + usageScore
+			}
+		}
+
+		if e.Score > config.MinScore || query == "" {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+func preview(f Flake, m Metadata, valid bool) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n", f.Name)
+
+	if m.Description != "" {
+		fmt.Fprintf(&b, "%s\n", m.Description)
+	}
+
+	b.WriteString("\n")
+
+	if f.Path != "" {
+		fmt.Fprintf(&b, "Path: %s\n", f.Path)
+	}
+
+	if f.Target != "" {
+		fmt.Fprintf(&b, "Registry: %s\n", f.Target)
+	}
+
+	if m.URL != "" {
+		fmt.Fprintf(&b, "Resolved: %s\n", m.URL)
+	}
+
+	if m.Revision != "" {
+		fmt.Fprintf(&b, "Revision: %s\n", m.Revision)
+	}
+
+	if m.LastModified != 0 {
+		fmt.Fprintf(&b, "Last modified: %s\n", time.Unix(m.LastModified, 0).Format(time.DateTime))
+	}
+
+	if m.Error != "" {
+		fmt.Fprintf(&b, "\nError: %s\n", m.Error)
+	}
+
+	if len(m.Outputs) > 0 {
+		fmt.Fprintf(&b, "\nOutputs (%s):\n", system())
+
+		for _, v := range m.Outputs {
+			fmt.Fprintf(&b, "  %s\n", v)
+		}
+	}
+
+	if !valid {
+		b.WriteString("\nevaluating...\n")
+	}
+
+	return b.String()
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
@@ -0,0 +1,268 @@
+//go:build linux
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// Notification is a notification shown or remembered by the notification daemon.
+type Notification struct {
+	ID      string
+	App     string
+	Summary string
+	Body    string
+	Icon    string
+}
+
+var errUnsupported = errors.New("not supported by the notification daemon")
+
+// daemon controls a notification daemon through its control client. Functions are nil if the daemon
+// doesn't support them.
+type daemon struct {
+	name    string
+	dnd     func() (bool, error)
+	setDND  func(on bool) error
+	list    func() ([]Notification, error)
+	dismiss func(id string) error
+	invoke  func(id string) error
+	clear   func() error
+	open    func() error
+}
+
+var daemons = []daemon{
+	{
+		name: "swaync",
+		dnd: func() (bool, error) {
+			out, err := output("swaync-client -D -sw")
+			return out == "true", err
+		},
+		setDND: func(on bool) error {
+			if on {
+				return run("swaync-client -dn -sw")
+			}
+
+			return run("swaync-client -df -sw")
+		},
+		clear: func() error {
+			return run("swaync-client -C -sw")
+		},
+		open: func() error {
+			return run("swaync-client -op -sw")
+		},
+	},
+	{
+		name: "mako",
+		dnd: func() (bool, error) {
+			out, err := output("makoctl mode")
+			if err != nil {
+				return false, err
+			}
+
+			for line := range strings.Lines(out) {
+				if strings.TrimSpace(line) == config.MakoMode {
+					return true, nil
+				}
+			}
+
+			return false, nil
+		},
+		setDND: func(on bool) error {
+			if on {
+				return run(fmt.Sprintf("makoctl mode -a %s", config.MakoMode))
+			}
+
+			return run(fmt.Sprintf("makoctl mode -r %s", config.MakoMode))
+		},
+		list: func() ([]Notification, error) {
+			out, err := output("makoctl list")
+			if err != nil {
+				return nil, err
+			}
+
+			return parseMako(out)
+		},
+		dismiss: func(id string) error {
+			return run(fmt.Sprintf("makoctl dismiss -n %s", id))
+		},
+		invoke: func(id string) error {
+			return run(fmt.Sprintf("makoctl invoke -n %s", id))
+		},
+		clear: func() error {
+			return run("makoctl dismiss -a")
+		},
+	},
+	{
+		name: "dunst",
+		dnd: func() (bool, error) {
+			out, err := output("dunstctl is-paused")
+			return out == "true", err
+		},
+		setDND: func(on bool) error {
+			return run(fmt.Sprintf("dunstctl set-paused %t", on))
+		},
+		list: func() ([]Notification, error) {
+			out, err := output("dunstctl history")
+			if err != nil {
+				return nil, err
+			}
+
+			return parseVariants(out)
+		},
+		dismiss: func(id string) error {
+			return run(fmt.Sprintf("dunstctl history-rm %s", id))
+		},
+		invoke: func(id string) error {
+			// shows the notification again, so its actions can be used
+			return run(fmt.Sprintf("dunstctl history-pop %s", id))
+		},
+		clear: func() error {
+			if err := run("dunstctl close-all"); err != nil {
+				return err
+			}
+
+			return run("dunstctl history-clear")
+		},
+	},
+}
+
+// detectDaemon returns the configured daemon or the first one that's running.
+func detectDaemon() (daemon, bool) {
+	for _, v := range daemons {
+		if config.Daemon != "auto" && v.name != config.Daemon {
+			continue
+		}
+
+		if _, err := v.dnd(); err == nil {
+			return v, true
+		}
+	}
+
+	return daemon{}, false
+}
+
+func run(cmd string) error {
+	out, err := common.HostShell(cmd).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", cmd, err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func output(cmd string) (string, error) {
+	out, err := common.ExecShell(Name, cmd, common.ExecOptions{})
+	return strings.TrimSpace(string(out)), err
+}
+
+// variant is a D-Bus variant as printed by makoctl and dunstctl.
+type variant struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+func (v variant) String() string {
+	switch d := v.Data.(type) {
+	case string:
+		return d
+	case float64:
+		return strconv.FormatFloat(d, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(d)
+	}
+}
+
+// parseVariants parses the 'aa{sv}' JSON output of dunstctl and older versions of makoctl.
+func parseVariants(out string) ([]Notification, error) {
+	var res struct {
+		Data [][]map[string]variant `json:"data"`
+	}
+
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		return nil, err
+	}
+
+	list := []Notification{}
+
+	if len(res.Data) == 0 {
+		return list, nil
+	}
+
+	first := func(m map[string]variant, keys ...string) string {
+		for _, k := range keys {
+			if v, ok := m[k]; ok && v.String() != "" {
+				return v.String()
+			}
+		}
+
+		return ""
+	}
+
+	for _, v := range res.Data[0] {
+		list = append(list, Notification{
+			ID:      first(v, "id"),
+			App:     first(v, "app-name", "appname"),
+			Summary: first(v, "summary"),
+			Body:    first(v, "body"),
+			Icon:    first(v, "app-icon", "icon_path"),
+		})
+	}
+
+	return list, nil
+}
+
+// parseMako parses the output of makoctl list. Newer versions print text instead of JSON:
+//
+//	Notification 3: Summary
+//	  App name: app
+func parseMako(out string) ([]Notification, error) {
+	if strings.HasPrefix(out, "{") {
+		return parseVariants(out)
+	}
+
+	list := []Notification{}
+	scanner := bufio.NewScanner(bytes.NewBufferString(out))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if after, ok := strings.CutPrefix(line, "Notification "); ok {
+			id, summary, _ := strings.Cut(after, ":")
+			list = append(list, Notification{ID: id, Summary: strings.TrimSpace(summary)})
+			continue
+		}
+
+		if len(list) == 0 {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+
+		n := &list[len(list)-1]
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "App name":
+			n.App = value
+		case "Body":
+			n.Body = value
+		case "App icon":
+			n.Icon = value
+		}
+	}
+
+	return list, scanner.Err()
+}
@@ -0,0 +1,305 @@
+//go:build linux
+
+// Package notifications controls do-not-disturb and recent notifications of swaync, mako or dunst.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "notifications"
+	NamePretty = "Notifications"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Daemon        string `koanf:"daemon" desc:"notification daemon to control. 'auto', 'swaync', 'mako' or 'dunst'." default:"auto"`
+	MakoMode      string `koanf:"mako_mode" desc:"mode of mako used for do-not-disturb" default:"do-not-disturb"`
+}
+
+const (
+	ActionDNDOn   = "dnd_on"
+	ActionDNDOff  = "dnd_off"
+	ActionDismiss = "dismiss"
+	ActionInvoke  = "invoke"
+	ActionClear   = "clear"
+	ActionOpen    = "open"
+
+	// identifier of the do-not-disturb item
+	IdentifierDND = "dnd"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "preferences-system-notifications",
+			MinScore: 30,
+		},
+		Daemon:   "auto",
+		MakoMode: "do-not-disturb",
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+}
+
+func Available() bool {
+	if _, ok := detectDaemon(); !ok {
+		slog.Info(Name, "available", "no supported notification daemon running. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionInvoke, Label: "Invoke", Icon: "system-run", Default: true},
+		{Action: ActionDismiss, Label: "Dismiss", Icon: "window-close"},
+		{Action: ActionDNDOn, Label: "Enable do not disturb", Icon: "notifications-disabled"},
+		{Action: ActionDNDOff, Label: "Disable do not disturb", Icon: "preferences-system-notifications"},
+		{Action: ActionClear, Label: "Clear all", Icon: "edit-clear-all"},
+		{Action: ActionOpen, Label: "Open notification center", Icon: "preferences-system-notifications"},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	d, ok := detectDaemon()
+	if !ok {
+		slog.Error(Name, "activate", "no supported notification daemon running")
+		return
+	}
+
+	if action == "" {
+		action = ActionInvoke
+
+		if identifier == IdentifierDND {
+			action = ActionDNDOn
+
+			if on, _ := d.dnd(); on {
+				action = ActionDNDOff
+			}
+		}
+	}
+
+	var err error
+
+	switch action {
+	case ActionDNDOn, ActionDNDOff:
+		err = d.setDND(action == ActionDNDOn)
+	case ActionClear:
+		err = call(d.clear)
+	case ActionOpen:
+		err = call(d.open)
+	case ActionDismiss, ActionInvoke:
+		if _, perr := strconv.ParseUint(identifier, 10, 32); perr != nil {
+			slog.Error(Name, "activate", fmt.Sprintf("unknown notification: %s", identifier))
+			return
+		}
+
+		fn := d.dismiss
+		if action == ActionInvoke {
+			fn = d.invoke
+		}
+
+		if fn == nil {
+			err = errUnsupported
+		} else {
+			err = fn(identifier)
+		}
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	if err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	handlers.ProviderUpdated <- Name
+}
+
+func call(fn func() error) error {
+	if fn == nil {
+		return errUnsupported
+	}
+
+	return fn()
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	d, ok := detectDaemon()
+	if !ok {
+		return entries
+	}
+
+	dnd, _ := d.dnd()
+
+	toggle := &pb.QueryResponse_Item{
+		Identifier: IdentifierDND,
+		Text:       "Do not disturb",
+		Subtext:    "off",
+		Icon:       "preferences-system-notifications",
+		Provider:   Name,
+		Actions:    []string{ActionDNDOn},
+		State:      []string{"dnd_off"},
+		Score:      1000,
+		Type:       pb.QueryResponse_REGULAR,
+	}
+
+	if dnd {
+		toggle.Subtext = "on"
+		toggle.Icon = "notifications-disabled"
+		toggle.Actions = []string{ActionDNDOff}
+		toggle.State = []string{"dnd_on"}
+	}
+
+	if d.open != nil {
+		toggle.Actions = append(toggle.Actions, ActionOpen)
+	}
+
+	if d.clear != nil {
+		toggle.Actions = append(toggle.Actions, ActionClear)
+	}
+
+	list := []Notification{}
+
+	if d.list != nil {
+		var err error
+
+		list, err = d.list()
+		if err != nil {
+			slog.Error(Name, "list", err)
+		}
+	}
+
+	candidates := []*pb.QueryResponse_Item{toggle}
+
+	for k, v := range list {
+		icon := v.Icon
+		if icon == "" {
+			icon = config.Icon
+		}
+
+		subtext := []string{}
+
+		if v.App != "" {
+			subtext = append(subtext, v.App)
+		}
+
+		if v.Body != "" {
+			subtext = append(subtext, strings.ReplaceAll(v.Body, "\n", " "))
+		}
+
+		actions := []string{}
+
+		if d.invoke != nil {
+			actions = append(actions, ActionInvoke)
+		}
+
+		if d.dismiss != nil {
+			actions = append(actions, ActionDismiss)
+		}
+
+		candidates = append(candidates, &pb.QueryResponse_Item{
+			Identifier: v.ID,
+			Text:       v.Summary,
+			Subtext:    strings.Join(subtext, " - "),
+			Icon:       icon,
+			Provider:   Name,
+			Actions:    actions,
+			Score:      int32(len(list) - k),
+			Type:       pb.QueryResponse_REGULAR,
+		})
+	}
+
+	for _, e := range candidates {
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, e.Text, exact)
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	d, ok := detectDaemon()
+	if !ok {
+		return &pb.ProviderStateResponse{
+			States: []string{"no_daemon"},
+		}
+	}
+
+	states := []string{fmt.Sprintf("daemon:%s", d.name)}
+	actions := []string{}
+
+	if on, _ := d.dnd(); on {
+		states = append(states, "dnd_on")
+		actions = append(actions, ActionDNDOff)
+	} else {
+		states = append(states, "dnd_off")
+		actions = append(actions, ActionDNDOn)
+	}
+
+	if d.clear != nil {
+		actions = append(actions, ActionClear)
+	}
+
+	if d.open != nil {
+		actions = append(actions, ActionOpen)
+	}
+
+	return &pb.ProviderStateResponse{
+		States:  states,
+		Actions: actions,
+	}
+}
@@ -0,0 +1,135 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// Result is recognized text, newest first.
+type Result struct {
+	ID   uint64
+	Text string
+	// Source is the result the text was translated from, 0 for captures.
+	Source uint64
+	Time   time.Time
+}
+
+var (
+	mu      sync.Mutex
+	results = []Result{}
+	nextID  uint64
+)
+
+func loadResults() {
+	file := common.CacheFile(fmt.Sprintf("%s.gob", Name))
+
+	if !common.FileExists(file) {
+		return
+	}
+
+	f, err := os.ReadFile(file)
+	if err != nil {
+		slog.Error(Name, "results", err)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := gob.NewDecoder(bytes.NewReader(f)).Decode(&results); err != nil {
+		slog.Error(Name, "decoding", err)
+	}
+
+	for _, v := range results {
+		nextID = max(nextID, v.ID)
+	}
+}
+
+// addResult stores the text as newest result and returns it.
+func addResult(text string, source uint64) Result {
+	mu.Lock()
+	defer mu.Unlock()
+
+	nextID++
+
+	r := Result{
+		ID:     nextID,
+		Text:   text,
+		Source: source,
+		Time:   time.Now(),
+	}
+
+	results = append([]Result{r}, results...)
+
+	saveResults()
+
+	return r
+}
+
+func removeResult(id uint64) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for k, v := range results {
+		if v.ID == id {
+			results = append(results[:k], results[k+1:]...)
+			break
+		}
+	}
+
+	saveResults()
+}
+
+func resultByIdentifier(identifier string) (Result, bool) {
+	id, err := strconv.ParseUint(identifier, 10, 64)
+	if err != nil {
+		return Result{}, false
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, v := range results {
+		if v.ID == id {
+			return v, true
+		}
+	}
+
+	return Result{}, false
+}
+
+// saveResults expects mu to be locked.
+func saveResults() {
+	if len(results) > config.MaxItems {
+		results = results[:config.MaxItems]
+	}
+
+	var b bytes.Buffer
+
+	if err := gob.NewEncoder(&b).Encode(results); err != nil {
+		slog.Error(Name, "encode", err)
+		return
+	}
+
+	file := common.CacheFile(fmt.Sprintf("%s.gob", Name))
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		slog.Error(Name, "createdirs", err)
+		return
+	}
+
+	if err := os.WriteFile(file, b.Bytes(), 0o600); err != nil {
+		slog.Error(Name, "writefile", err)
+	}
+}
@@ -0,0 +1,295 @@
+//go:build linux
+
+// Package ocr recognizes text in a region of the screen.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "ocr"
+	NamePretty = "Screen OCR"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Capture       string `koanf:"capture" desc:"command printing the captured region as image" default:"grim -g \"$(slurp)\" -, maim -s on X11"`
+	Command       string `koanf:"command" desc:"OCR command, reads the image from stdin and prints the text" default:"tesseract stdin stdout -l eng"`
+	Copy          string `koanf:"copy" desc:"command to copy. supports %VALUE%." default:"wl-copy, xclip on X11, pbcopy on macOS, clip on Windows"`
+	Type          string `koanf:"type" desc:"command to type. supports %VALUE%." default:"wtype -, xdotool type --file - on X11"`
+	Translate     string `koanf:"translate" desc:"command to translate, prints the translation. supports %VALUE%." default:"trans -brief :en"`
+	Delay         int    `koanf:"delay" desc:"delay in ms before typing to avoid potential focus issues" default:"100"`
+	MaxItems      int    `koanf:"max_items" desc:"max amount of cached results" default:"20"`
+	AutoCopy      bool   `koanf:"auto_copy" desc:"copy recognized text right away" default:"true"`
+	Notify        bool   `koanf:"notify" desc:"show a notification with the recognized text" default:"true"`
+}
+
+const (
+	ActionCapture   = "capture"
+	ActionCopy      = "copy"
+	ActionType      = "type"
+	ActionTranslate = "translate"
+	ActionDelete    = "delete"
+
+	// identifier of the item capturing a new region
+	IdentifierCapture = "capture"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "scanner",
+			MinScore: 30,
+		},
+		Capture:   common.CaptureCommand(),
+		Command:   "tesseract stdin stdout -l eng",
+		Copy:      common.CopyCommand(),
+		Type:      common.TypeCommand(),
+		Translate: "trans -brief :en",
+		Delay:     100,
+		MaxItems:  20,
+		AutoCopy:  true,
+		Notify:    true,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	loadResults()
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionCopy, Label: "Copy", Icon: "edit-copy", Default: true},
+		{Action: ActionType, Label: "Type", Icon: "input-keyboard"},
+		{Action: ActionTranslate, Label: "Translate", Icon: "preferences-desktop-locale"},
+		{Action: ActionDelete, Label: "Delete", Icon: "edit-delete"},
+		{Action: ActionCapture, Label: "Capture region", Icon: "scanner"},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == ActionCapture || identifier == IdentifierCapture {
+		// selecting the region blocks until the user is done
+		go capture()
+		return
+	}
+
+	r, ok := resultByIdentifier(identifier)
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown result: %s", identifier))
+		return
+	}
+
+	if action == "" {
+		action = ActionCopy
+	}
+
+	switch action {
+	case ActionCopy:
+		copyText(r.Text)
+	case ActionType:
+		time.Sleep(time.Duration(config.Delay) * time.Millisecond)
+
+		if out, err := common.QuoteResultOrStdinCmd(config.Type, r.Text).CombinedOutput(); err != nil {
+			slog.Error(Name, "type", err, "output", strings.TrimSpace(string(out)))
+		}
+	case ActionTranslate:
+		go translate(r)
+	case ActionDelete:
+		removeResult(r.ID)
+		handlers.ProviderUpdated <- Name
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+func copyText(text string) {
+	cmd := common.QuoteResultOrStdinCmd(config.Copy, text)
+
+	if err := cmd.Start(); err != nil {
+		slog.Error(Name, "copy", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+}
+
+func capture() {
+	out, err := common.HostShell(fmt.Sprintf("%s | %s", config.Capture, config.Command)).Output()
+	if err != nil {
+		slog.Error(Name, "capture", err)
+		return
+	}
+
+	text := strings.TrimSpace(string(out))
+	if text == "" {
+		slog.Info(Name, "capture", "no text recognized")
+		notify("No text recognized", "", 0)
+		return
+	}
+
+	r := addResult(text, 0)
+
+	if config.AutoCopy {
+		copyText(text)
+	}
+
+	notify("Text recognized", text, r.ID)
+
+	handlers.ProviderUpdated <- Name
+}
+
+func translate(src Result) {
+	out, err := common.QuoteResultOrStdinCmd(config.Translate, src.Text).Output()
+	if err != nil {
+		slog.Error(Name, "translate", err)
+		return
+	}
+
+	text := strings.TrimSpace(string(out))
+	if text == "" {
+		return
+	}
+
+	r := addResult(text, src.ID)
+
+	notify("Translated", text, r.ID)
+
+	handlers.ProviderUpdated <- Name
+}
+
+func notify(title, text string, id uint64) {
+	if !config.Notify {
+		return
+	}
+
+	n := common.Notification{
+		Title:   title,
+		Body:    text,
+		Icon:    config.Icon,
+		Urgency: "low",
+	}
+
+	if id != 0 {
+		identifier := strconv.FormatUint(id, 10)
+
+		n.Actions = []common.NotificationAction{
+			{Label: "Copy", Default: true, Provider: Name, Identifier: identifier, Action: ActionCopy},
+			{Label: "Type", Provider: Name, Identifier: identifier, Action: ActionType},
+			{Label: "Translate", Provider: Name, Identifier: identifier, Action: ActionTranslate},
+		}
+	}
+
+	if _, err := common.Notify(n); err != nil {
+		slog.Error(Name, "notify", err)
+	}
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	if query == "" {
+		entries = append(entries, &pb.QueryResponse_Item{
+			Identifier: IdentifierCapture,
+			Text:       "Capture region",
+			Subtext:    "recognize text on the screen",
+			Icon:       config.Icon,
+			Provider:   Name,
+			Actions:    []string{ActionCapture},
+			Score:      1_000_000,
+			Type:       pb.QueryResponse_REGULAR,
+		})
+	}
+
+	mu.Lock()
+	list := append([]Result{}, results...)
+	mu.Unlock()
+
+	for k, v := range list {
+		text := strings.Join(strings.Fields(v.Text), " ")
+
+		subtext := v.Time.Format(time.DateTime)
+		if v.Source != 0 {
+			subtext = fmt.Sprintf("%s - translated", subtext)
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier:  strconv.FormatUint(v.ID, 10),
+			Text:        text,
+			Subtext:     subtext,
+			Icon:        config.Icon,
+			Provider:    Name,
+			Actions:     []string{ActionCopy, ActionType, ActionTranslate, ActionDelete},
+			Score:       int32(len(list) - k),
+			Preview:     v.Text,
+			PreviewType: util.PreviewTypeText,
+			Type:        pb.QueryResponse_REGULAR,
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, text, exact)
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{
+		Actions: []string{ActionCapture},
+	}
+}
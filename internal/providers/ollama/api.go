@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+type Model struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified_at"`
+	Details  struct {
+		Family        string `json:"family"`
+		ParameterSize string `json:"parameter_size"`
+		Quantization  string `json:"quantization_level"`
+	} `json:"details"`
+}
+
+var (
+	client = http.Client{Timeout: 5 * time.Second}
+
+	// models of the last successful request, so the list doesn't flicker if the server is busy
+	modelsMu sync.Mutex
+	models   []Model
+)
+
+func endpoint() string {
+	return strings.TrimSuffix(config.Endpoint, "/")
+}
+
+func get(path string, v any) error {
+	resp, err := client.Get(endpoint() + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// installed returns the installed models, or the last known ones if the server can't be reached.
+func installed() ([]Model, error) {
+	var res struct {
+		Models []Model `json:"models"`
+	}
+
+	if err := get("/api/tags", &res); err != nil {
+		modelsMu.Lock()
+		defer modelsMu.Unlock()
+
+		return models, err
+	}
+
+	modelsMu.Lock()
+	models = res.Models
+	modelsMu.Unlock()
+
+	return res.Models, nil
+}
+
+// loaded returns the names of the models in memory.
+func loaded() map[string]bool {
+	var res struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+
+	loaded := map[string]bool{}
+
+	if err := get("/api/ps", &res); err != nil {
+		return loaded
+	}
+
+	for _, v := range res.Models {
+		loaded[v.Name] = true
+	}
+
+	return loaded
+}
+
+// Progress is a line streamed by /api/pull.
+type Progress struct {
+	Status    string `json:"status"`
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+	Error     string `json:"error"`
+}
+
+func (p Progress) String() string {
+	// "pulling 6a0746a1ec1a" is shown as "pulling 42%"
+	if fields := strings.Fields(p.Status); p.Total > 0 && len(fields) > 0 {
+		return fmt.Sprintf("%s %d%%", fields[0], 100*p.Completed/p.Total)
+	}
+
+	return p.Status
+}
+
+// pullCommand streams the progress of the pull as json lines, which the jobs pass on.
+func pullCommand(model string) string {
+	body, _ := json.Marshal(map[string]any{"model": model, "stream": true})
+
+	return fmt.Sprintf("curl -sSN --fail-with-body %s -d %s", common.Quote(endpoint()+"/api/pull"), common.Quote(string(body)))
+}
+
+func deleteCommand(model string) string {
+	body, _ := json.Marshal(map[string]any{"model": model})
+
+	return fmt.Sprintf("curl -sS --fail-with-body -X DELETE %s -d %s", common.Quote(endpoint()+"/api/delete"), common.Quote(string(body)))
+}
@@ -0,0 +1,365 @@
+// Package ollama manages the models of a local ollama server.
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/history"
+	"github.com/abenz1267/elephant/v2/pkg/common/jobs"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "ollama"
+	NamePretty = "Ollama"
+	config     *Config
+	h          = history.Load(Name)
+
+	// progress of the running pulls and deletes by model
+	progressMu sync.Mutex
+	progress   = map[string]string{}
+
+	validModel = regexp.MustCompile(`^[\w.-]+(/[\w.-]+)*(:[\w.-]+)?$`)
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config    `koanf:",squash"`
+	Endpoint         string `koanf:"endpoint" desc:"url of the ollama server" default:"http://localhost:11434"`
+	RunCommand       string `koanf:"run_command" desc:"command chatting with the model, run in a terminal. supports %MODEL%." default:"ollama run %MODEL%"`
+	History          bool   `koanf:"history" desc:"make use of history for sorting" default:"true"`
+	HistoryWhenEmpty bool   `koanf:"history_when_empty" desc:"consider history when query is empty" default:"true"`
+}
+
+const (
+	ActionRun    = "run"
+	ActionPull   = "pull"
+	ActionDelete = "delete"
+
+	StateLoaded  = "loaded"
+	StatePulling = "pulling"
+
+	prefixPull = "pull:"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "applications-science",
+			MinScore: 20,
+		},
+		Endpoint:         "http://localhost:11434",
+		RunCommand:       "ollama run %MODEL%",
+		History:          true,
+		HistoryWhenEmpty: true,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	jobs.Listen(jobEvent)
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionRun, Label: "Chat", Icon: "utilities-terminal", Default: true},
+		{Action: ActionPull, Label: "Pull", Icon: "download"},
+		{Action: ActionDelete, Label: "Delete", Icon: "edit-delete"},
+	}
+}
+
+// Identifiers are the model names, "pull:<model>" for models that aren't installed.
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == history.ActionDelete {
+		h.Remove(identifier)
+		return
+	}
+
+	model, pull := strings.CutPrefix(identifier, prefixPull)
+
+	if action == "" {
+		action = ActionRun
+
+		if pull {
+			action = ActionPull
+		}
+	}
+
+	if !validModel.MatchString(model) {
+		slog.Error(Name, "activate", fmt.Sprintf("invalid model: %s", model))
+		return
+	}
+
+	switch action {
+	case ActionRun:
+		cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), common.WrapWithTerminal(strings.ReplaceAll(config.RunCommand, "%MODEL%", common.Quote(model))))))
+		common.Detach(cmd)
+		cmd.Env = append(os.Environ(), "OLLAMA_HOST="+endpoint())
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "activate", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+
+		if config.History {
+			h.Save(query, identifier)
+		}
+	case ActionPull, ActionDelete:
+		title, command := "Pull "+model, pullCommand(model)
+
+		if action == ActionDelete {
+			title, command = "Delete "+model, deleteCommand(model)
+		}
+
+		if _, err := jobs.Start(Name, title, command); err != nil {
+			slog.Error(Name, action, err)
+		}
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+// jobEvent follows the pulls and deletes. The jobs are titled "Pull <model>" and "Delete <model>", pulls print
+// the progress as json lines.
+func jobEvent(e jobs.Event) {
+	if e.Job.Provider != Name {
+		return
+	}
+
+	_, model, _ := strings.Cut(e.Job.Title, " ")
+
+	progressMu.Lock()
+
+	switch {
+	case e.Job.State != jobs.StateRunning:
+		delete(progress, model)
+
+		if e.Job.State == jobs.StateFailed {
+			slog.Error(Name, "job", e.Job.Title, "log", e.Job.Log)
+		}
+	case e.Line != "":
+		var p Progress
+
+		if err := json.Unmarshal([]byte(e.Line), &p); err != nil {
+			progressMu.Unlock()
+			return
+		}
+
+		if p.Error != "" {
+			slog.Error(Name, "pull", p.Error, "model", model)
+		}
+
+		// lines are streamed for every chunk, updates only when the shown progress changes
+		if progress[model] == p.String() {
+			progressMu.Unlock()
+			return
+		}
+
+		progress[model] = p.String()
+	default:
+		progress[model] = strings.ToLower(strings.Fields(e.Job.Title)[0]) + "…"
+	}
+
+	progressMu.Unlock()
+
+	handlers.ProviderUpdated <- Name
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	query = strings.TrimSpace(query)
+
+	list, err := installed()
+	if err != nil {
+		slog.Error(Name, "models", err)
+	}
+
+	running := loaded()
+
+	progressMu.Lock()
+	current := maps.Clone(progress)
+	progressMu.Unlock()
+
+	for k, v := range list {
+		subtext := strings.Join(nonEmpty(formatBytes(v.Size), v.Details.ParameterSize, v.Details.Quantization), " · ")
+
+		if amount, last, _ := h.FindUsage("", v.Name); amount > 0 {
+			subtext = fmt.Sprintf("%s · used %s", subtext, ago(last))
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier: v.Name,
+			Text:       v.Name,
+			Subtext:    subtext,
+			Provider:   Name,
+			Icon:       config.Icon,
+			State:      []string{},
+			Actions:    []string{ActionRun, ActionPull, ActionDelete},
+			Type:       pb.QueryResponse_REGULAR,
+			Score:      int32(len(list) - k),
+		}
+
+		if running[v.Name] {
+			e.State = append(e.State, StateLoaded)
+		}
+
+		if p, ok := current[v.Name]; ok {
+			e.State = append(e.State, StatePulling)
+			e.Subtext = fmt.Sprintf("%s · %s", e.Subtext, p)
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, v.Name, exact)
+
+			// matches of the family don't highlight the name
+			if s, _, _ := common.FuzzyScore(query, v.Details.Family, exact); s > score {
+				score, pos, start = s, nil, 0
+			}
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		var usageScore int32
+
+		if config.History {
+			if e.Score > config.MinScore || query == "" && config.HistoryWhenEmpty {
+				usageScore = h.CalcUsageScore(query, e.Identifier)
+
+				if usageScore != 0 {
+					e.State = append(e.State, history.ActionDelete)
+					e.Actions = append(e.Actions, history.ActionDelete)
+				}
+
+				e.Score = e.Score + usageScore
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	// models that aren't installed can be pulled by name, f.e. "qwen3:8b"
+	if validModel.MatchString(query) && !slices.ContainsFunc(list, func(m Model) bool { return m.Name == query || m.Name == query+":latest" }) {
+		subtext, state := "from the ollama library", []string{}
+
+		if p, ok := current[query]; ok {
+			subtext, state = p, []string{StatePulling}
+		}
+
+		entries = append(entries, &pb.QueryResponse_Item{
+			Identifier: prefixPull + query,
+			Text:       fmt.Sprintf("Pull %s", query),
+			Subtext:    subtext,
+			Provider:   Name,
+			Icon:       "download",
+			State:      state,
+			Actions:    []string{ActionPull},
+			Type:       pb.QueryResponse_REGULAR,
+			Score:      1,
+		})
+	}
+
+	return entries
+}
+
+func nonEmpty(values ...string) []string {
+	res := []string{}
+
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+func formatBytes(b int64) string {
+	const unit = 1024
+
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// ago is a short relative time, f.e. "5m", "3h" or "2d".
+func ago(t time.Time) string {
+	d := time.Since(t)
+
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 7*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+
+	return t.Format("02.01.2006")
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common/store"
+)
+
+var db *store.Store
+
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS parcels (
+		number TEXT PRIMARY KEY,
+		carrier TEXT NOT NULL,
+		name TEXT NOT NULL,
+		added INTEGER NOT NULL,
+		checked INTEGER NOT NULL DEFAULT 0,
+		state TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT '',
+		events TEXT NOT NULL DEFAULT '[]'
+	);`,
+}
+
+type Parcel struct {
+	Number  string
+	Carrier string
+	Name    string
+	Added   time.Time
+	// Checked is the last time the tracker was asked, zero if never.
+	Checked time.Time
+	Tracking
+}
+
+func openDB() error {
+	var err error
+
+	db, err = store.Open(Name, migrations...)
+
+	return err
+}
+
+// addParcel adds the parcel, existing ones keep their tracking but get the new carrier and name.
+func addParcel(p Parcel) error {
+	_, err := db.Exec(`INSERT INTO parcels (number, carrier, name, added) VALUES (?, ?, ?, ?)
+		ON CONFLICT(number) DO UPDATE SET carrier = excluded.carrier, name = excluded.name`,
+		p.Number, p.Carrier, p.Name, time.Now().Unix())
+
+	return err
+}
+
+func setTracking(number string, t Tracking) error {
+	events, err := json.Marshal(t.Events)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("UPDATE parcels SET checked = ?, state = ?, status = ?, events = ? WHERE number = ?",
+		time.Now().Unix(), t.State, t.Status, string(events), number)
+
+	return err
+}
+
+func setName(number, name string) error {
+	_, err := db.Exec("UPDATE parcels SET name = ? WHERE number = ?", name, number)
+	return err
+}
+
+func removeParcel(number string) error {
+	_, err := db.Exec("DELETE FROM parcels WHERE number = ?", number)
+	return err
+}
+
+// parcels returns all parcels, the last added first.
+func parcels() ([]Parcel, error) {
+	rows, err := db.Query("SELECT number, carrier, name, added, checked, state, status, events FROM parcels ORDER BY added DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []Parcel{}
+
+	for rows.Next() {
+		var p Parcel
+		var added, checked int64
+		var events string
+
+		if err := rows.Scan(&p.Number, &p.Carrier, &p.Name, &added, &checked, &p.State, &p.Status, &events); err != nil {
+			return nil, err
+		}
+
+		p.Added = time.Unix(added, 0)
+
+		if checked != 0 {
+			p.Checked = time.Unix(checked, 0)
+		}
+
+		if err := json.Unmarshal([]byte(events), &p.Events); err != nil {
+			return nil, err
+		}
+
+		res = append(res, p)
+	}
+
+	return res, rows.Err()
+}
+
+func getParcel(number string) (Parcel, bool) {
+	all, err := parcels()
+	if err != nil {
+		return Parcel{}, false
+	}
+
+	for _, v := range all {
+		if v.Number == number {
+			return v, true
+		}
+	}
+
+	return Parcel{}, false
+}
@@ -0,0 +1,596 @@
+// Package parcels tracks shipments via a tracking api, a self-hosted tracker or a command.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "parcels"
+	NamePretty = "Parcels"
+	config     *Config
+
+	refreshing atomic.Bool
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Tracker       string            `koanf:"tracker" desc:"'17track', 'url' for a self-hosted tracker or 'command'" default:"17track"`
+	APIKey        string            `koanf:"api_key" desc:"key of the 17track api, or bearer token of the self-hosted tracker" default:""`
+	APIURL        string            `koanf:"api_url" desc:"url of the 17track api" default:"https://api.17track.net"`
+	URL           string            `koanf:"url" desc:"url of the self-hosted tracker returning the tracking as json. supports %NUMBER% and %CARRIER%." default:""`
+	Command       string            `koanf:"command" desc:"command printing the tracking as json. supports %NUMBER% and %CARRIER%." default:""`
+	Interval      int               `koanf:"interval" desc:"minutes between updates" default:"60"`
+	Pages         map[string]string `koanf:"pages" desc:"tracking pages by carrier, overriding the builtin ones. supports %NUMBER%." default:""`
+	DefaultPage   string            `koanf:"default_page" desc:"tracking page for unknown carriers. supports %NUMBER%." default:"https://parcelsapp.com/en/tracking/%NUMBER%"`
+	HideDelivered int               `koanf:"hide_delivered" desc:"days after which delivered parcels are hidden, 0 to show them until removed" default:"3"`
+	Clipboard     bool              `koanf:"clipboard" desc:"offer to track a number in the clipboard when the query is empty" default:"true"`
+	Notify        bool              `koanf:"notify" desc:"show a notification when the status of a parcel changes" default:"true"`
+}
+
+const (
+	ActionOpen    = "open"
+	ActionAdd     = "add"
+	ActionCopy    = "copy"
+	ActionRename  = "rename"
+	ActionRemove  = "remove"
+	ActionRefresh = "refresh"
+
+	prefixAdd = "add:"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "package-x-generic",
+			MinScore: 30,
+		},
+		Tracker:       Tracker17Track,
+		APIURL:        "https://api.17track.net",
+		Interval:      60,
+		Pages:         map[string]string{},
+		DefaultPage:   "https://parcelsapp.com/en/tracking/%NUMBER%",
+		HideDelivered: 3,
+		Clipboard:     true,
+		Notify:        true,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	config.Interval = max(config.Interval, 5)
+
+	if err := openDB(); err != nil {
+		slog.Error(Name, "db", err)
+		return
+	}
+
+	if _, ok := usedTracker(); !ok {
+		slog.Info(Name, "tracker", "not configured, parcels only open their tracking page")
+		return
+	}
+
+	go func() {
+		for {
+			refresh(nil)
+			time.Sleep(time.Duration(config.Interval) * time.Minute)
+		}
+	}()
+}
+
+// usedTracker returns the configured tracker, if it has what it needs.
+func usedTracker() (tracker, bool) {
+	switch config.Tracker {
+	case Tracker17Track:
+		if config.APIKey == "" {
+			return nil, false
+		}
+	case TrackerURL:
+		if config.URL == "" {
+			return nil, false
+		}
+	case TrackerCommand:
+		if config.Command == "" {
+			return nil, false
+		}
+	}
+
+	t, ok := trackers[config.Tracker]
+
+	return t, ok
+}
+
+func Available() bool {
+	return db != nil
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+var name = &pb.ActionArgument{Name: "name", Type: util.ArgumentText, Placeholder: "Name, f.e. the content"}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionOpen, Label: "Open tracking page", Icon: "web-browser", Default: true},
+		{Action: ActionAdd, Label: "Track", Icon: "list-add"},
+		{Action: ActionCopy, Label: "Copy number", Icon: "edit-copy"},
+		{Action: ActionRename, Label: "Rename", Icon: "document-edit", Arguments: []*pb.ActionArgument{name}},
+		{Action: ActionRemove, Label: "Remove", Icon: "edit-delete"},
+		{Action: ActionRefresh, Label: "Refresh", Icon: "view-refresh"},
+	}
+}
+
+// refresh updates the given parcels, or all that aren't delivered yet, and notifies about changes.
+func refresh(only []Parcel) {
+	t, ok := usedTracker()
+	if !ok {
+		return
+	}
+
+	if !refreshing.CompareAndSwap(false, true) {
+		return
+	}
+	defer refreshing.Store(false)
+
+	list := only
+
+	if list == nil {
+		all, err := parcels()
+		if err != nil {
+			slog.Error(Name, "refresh", err)
+			return
+		}
+
+		list = slices.DeleteFunc(all, func(p Parcel) bool {
+			return p.State == StateDelivered
+		})
+	}
+
+	if len(list) == 0 {
+		return
+	}
+
+	res, err := t.track(list)
+	if err != nil {
+		slog.Error(Name, "track", err)
+	}
+
+	for _, p := range list {
+		tr, ok := res[p.Number]
+		if !ok {
+			continue
+		}
+
+		if err := setTracking(p.Number, tr); err != nil {
+			slog.Error(Name, "refresh", err)
+			continue
+		}
+
+		// the first tracking isn't news
+		if !p.Checked.IsZero() && changed(p.Tracking, tr) {
+			p.Tracking = tr
+			notify(p)
+		}
+	}
+
+	slog.Info(Name, "tracked", len(res), "of", len(list))
+
+	handlers.ProviderUpdated <- Name
+}
+
+func changed(a, b Tracking) bool {
+	if a.State != b.State || a.Status != b.Status || len(a.Events) != len(b.Events) {
+		return true
+	}
+
+	return len(a.Events) > 0 && a.Events[0] != b.Events[0]
+}
+
+func notify(p Parcel) {
+	slog.Info(Name, "changed", p.Number, "status", latest(p))
+
+	if !config.Notify {
+		return
+	}
+
+	_, err := common.Notify(common.Notification{
+		Title:   title(p),
+		Body:    latest(p),
+		Icon:    config.Icon,
+		Actions: []common.NotificationAction{{Default: true, Provider: Name, Identifier: p.Number, Action: ActionOpen}},
+	})
+	if err != nil {
+		slog.Error(Name, "notify", err)
+	}
+}
+
+// parse reads "<number> [carrier] [name]". Numbers may contain spaces as printed on labels, if the input is
+// nothing but the number.
+func parse(input string) (Parcel, bool) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return Parcel{}, false
+	}
+
+	if !slices.ContainsFunc(fields, func(f string) bool { return !strings.ContainsFunc(f, unicode.IsDigit) }) {
+		fields = []string{strings.Join(fields, "")}
+	}
+
+	p := Parcel{Number: normalize(fields[0])}
+
+	if !isNumber(p.Number) {
+		return Parcel{}, false
+	}
+
+	fields = fields[1:]
+
+	if len(fields) > 0 && knownCarrier(strings.ToLower(fields[0])) {
+		p.Carrier = strings.ToLower(fields[0])
+		fields = fields[1:]
+	} else {
+		p.Carrier = detect(p.Number)
+	}
+
+	p.Name = strings.Join(fields, " ")
+
+	return p, true
+}
+
+func knownCarrier(carrier string) bool {
+	_, ok := pages[carrier]
+	if !ok {
+		_, ok = config.Pages[carrier]
+	}
+
+	return ok
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == ActionRefresh {
+		go refresh(nil)
+		return
+	}
+
+	if input, ok := strings.CutPrefix(identifier, prefixAdd); ok {
+		add(input)
+		return
+	}
+
+	p, ok := getParcel(identifier)
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown parcel: %s", identifier))
+		return
+	}
+
+	if action == "" {
+		action = ActionOpen
+	}
+
+	switch action {
+	case ActionOpen:
+		cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix("", Name, identifier), common.OpenCommand(), common.Quote(page(p)))))
+		common.Detach(cmd)
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "open", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+
+		return
+	case ActionCopy:
+		cmd := common.CopyCmd(p.Number)
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "copy", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+
+		return
+	case ActionRename:
+		if err := setName(p.Number, strings.TrimSpace(args)); err != nil {
+			slog.Error(Name, "rename", err)
+			return
+		}
+	case ActionRemove:
+		if err := removeParcel(p.Number); err != nil {
+			slog.Error(Name, "remove", err)
+			return
+		}
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	handlers.ProviderUpdated <- Name
+}
+
+// add tracks the parcel of the input, registering it with the tracker.
+func add(input string) {
+	p, ok := parse(input)
+	if !ok {
+		slog.Error(Name, "add", fmt.Sprintf("not a tracking number: %s", input))
+		return
+	}
+
+	if err := addParcel(p); err != nil {
+		slog.Error(Name, "add", err)
+		return
+	}
+
+	slog.Info(Name, "add", p.Number, "carrier", p.Carrier)
+
+	handlers.ProviderUpdated <- Name
+
+	t, ok := usedTracker()
+	if !ok {
+		return
+	}
+
+	go func() {
+		if err := t.register(p); err != nil {
+			slog.Error(Name, "register", err)
+			return
+		}
+
+		// trackers need a moment for new numbers
+		for _, wait := range []time.Duration{0, 30 * time.Second} {
+			time.Sleep(wait)
+
+			if p, ok := getParcel(p.Number); ok && (p.Checked.IsZero() || p.State == StatePending) {
+				refresh([]Parcel{p})
+			}
+		}
+	}()
+}
+
+func title(p Parcel) string {
+	if p.Name != "" {
+		return p.Name
+	}
+
+	return p.Number
+}
+
+// latest describes the latest event, or the status if there are no events.
+func latest(p Parcel) string {
+	if len(p.Events) == 0 {
+		return p.Status
+	}
+
+	e := p.Events[0]
+
+	return strings.Join(nonEmpty(e.Description, e.Location), " · ")
+}
+
+func preview(p Parcel) string {
+	var b strings.Builder
+
+	fmt.Fprintln(&b, strings.Join(nonEmpty(p.Number, p.Carrier, p.Status), " · "))
+
+	if len(p.Events) > 0 {
+		fmt.Fprintln(&b)
+	}
+
+	for _, e := range p.Events {
+		fmt.Fprintf(&b, "%s  %s\n", e.Time.Local().Format("02.01. 15:04"), strings.Join(nonEmpty(e.Description, e.Location), " · "))
+	}
+
+	return b.String()
+}
+
+// hidden reports if the parcel was delivered longer than hide_delivered days ago.
+func hidden(p Parcel) bool {
+	if p.State != StateDelivered || config.HideDelivered <= 0 {
+		return false
+	}
+
+	delivered := p.Checked
+
+	if len(p.Events) > 0 && !p.Events[0].Time.IsZero() {
+		delivered = p.Events[0].Time
+	}
+
+	return time.Since(delivered) > time.Duration(config.HideDelivered)*24*time.Hour
+}
+
+func entry(p Parcel, score int32) *pb.QueryResponse_Item {
+	subtext := latest(p)
+
+	if subtext == "" {
+		subtext = "not tracked yet"
+	}
+
+	info := []string{subtext}
+
+	if len(p.Events) > 0 && !p.Events[0].Time.IsZero() {
+		info = append(info, ago(p.Events[0].Time))
+	}
+
+	if p.Name != "" {
+		info = append(info, p.Number)
+	}
+
+	info = append(info, p.Carrier)
+
+	e := &pb.QueryResponse_Item{
+		Identifier:  p.Number,
+		Text:        title(p),
+		Subtext:     strings.Join(nonEmpty(info...), " · "),
+		Provider:    Name,
+		Icon:        config.Icon,
+		State:       []string{},
+		Actions:     []string{ActionOpen, ActionCopy, ActionRename, ActionRemove},
+		Type:        pb.QueryResponse_REGULAR,
+		Score:       score,
+		Preview:     preview(p),
+		PreviewType: util.PreviewTypeText,
+	}
+
+	if p.State != "" {
+		e.State = append(e.State, p.State)
+	}
+
+	return e
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	if db == nil {
+		return entries
+	}
+
+	all, err := parcels()
+	if err != nil {
+		slog.Error(Name, "query", err)
+		return entries
+	}
+
+	query = strings.TrimSpace(query)
+
+	// typed or copied numbers can be tracked
+	input, fromClipboard := query, false
+
+	if input == "" && config.Clipboard {
+		input, fromClipboard = common.ClipboardText(), true
+	}
+
+	if p, ok := parse(input); ok && !slices.ContainsFunc(all, func(v Parcel) bool { return v.Number == p.Number }) {
+		subtext := strings.Join(nonEmpty(p.Name, p.Carrier), " · ")
+
+		if fromClipboard {
+			subtext = strings.Join(nonEmpty("clipboard", subtext), " · ")
+		}
+
+		entries = append(entries, &pb.QueryResponse_Item{
+			Identifier: prefixAdd + input,
+			Text:       fmt.Sprintf("Track %s", p.Number),
+			Subtext:    subtext,
+			Provider:   Name,
+			Icon:       "list-add",
+			Actions:    []string{ActionAdd},
+			Type:       pb.QueryResponse_REGULAR,
+			Score:      1_000_000,
+		})
+	}
+
+	for k, v := range all {
+		if hidden(v) {
+			continue
+		}
+
+		// parcels on their way first
+		score := int32(len(all) - k)
+
+		if v.State != StateDelivered {
+			score += int32(len(all))
+		}
+
+		e := entry(v, score)
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, e.Text, exact)
+
+			// matches of the number or carrier don't highlight the name
+			if s, _, _ := common.FuzzyScore(query, v.Number+" "+v.Carrier, exact); s > score {
+				score, pos, start = s, nil, 0
+			}
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func ago(t time.Time) string {
+	d := time.Since(t)
+
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 7*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+
+	return t.Format("02.01.2006")
+}
+
+func nonEmpty(values ...string) []string {
+	res := []string{}
+
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	states := []string{}
+
+	if refreshing.Load() {
+		states = append(states, "refreshing")
+	}
+
+	return &pb.ProviderStateResponse{
+		States:  states,
+		Actions: []string{ActionRefresh},
+	}
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+const (
+	Tracker17Track = "17track"
+	TrackerURL     = "url"
+	TrackerCommand = "command"
+
+	StatePending   = "pending"
+	StateTransit   = "transit"
+	StateDelivered = "delivered"
+	StateException = "exception"
+)
+
+type Tracking struct {
+	// State is one of pending, transit, delivered or exception.
+	State  string `json:"state"`
+	Status string `json:"status"`
+	// Events are the newest first.
+	Events []Event `json:"events"`
+}
+
+type Event struct {
+	Time        time.Time `json:"time"`
+	Description string    `json:"description"`
+	Location    string    `json:"location"`
+}
+
+// tracker fetches the tracking of parcels, f.e. of a carrier aggregating api or a self-hosted tracker.
+type tracker interface {
+	// register announces a new parcel, some apis only track registered numbers.
+	register(p Parcel) error
+	track(p []Parcel) (map[string]Tracking, error)
+}
+
+var (
+	client = http.Client{Timeout: 30 * time.Second}
+
+	trackers = map[string]tracker{
+		Tracker17Track: track17{},
+		TrackerURL:     trackURL{},
+		TrackerCommand: trackCommand{},
+	}
+)
+
+// pages are the tracking pages of carriers, %NUMBER% is replaced.
+var pages = map[string]string{
+	"dhl":       "https://www.dhl.com/global-en/home/tracking.html?tracking-id=%NUMBER%",
+	"dpd":       "https://tracking.dpd.de/status/en_US/parcel/%NUMBER%",
+	"fedex":     "https://www.fedex.com/fedextrack/?trknbr=%NUMBER%",
+	"gls":       "https://gls-group.com/track/%NUMBER%",
+	"hermes":    "https://www.myhermes.de/empfangen/sendungsverfolgung/sendungsinformation#%NUMBER%",
+	"postnl":    "https://jouw.postnl.nl/track-and-trace/%NUMBER%",
+	"royalmail": "https://www.royalmail.com/track-your-item#/tracking-results/%NUMBER%",
+	"ups":       "https://www.ups.com/track?tracknum=%NUMBER%",
+	"usps":      "https://tools.usps.com/go/TrackConfirmAction?tLabels=%NUMBER%",
+}
+
+var (
+	validNumber = regexp.MustCompile(`^[A-Z0-9-]{8,40}$`)
+	upu         = regexp.MustCompile(`^[A-Z]{2}\d{9}([A-Z]{2})$`)
+	formats     = []struct {
+		carrier string
+		re      *regexp.Regexp
+	}{
+		{"ups", regexp.MustCompile(`^1Z[0-9A-Z]{16}$`)},
+		{"usps", regexp.MustCompile(`^9[2-5]\d{20}$`)},
+		{"dhl", regexp.MustCompile(`^(JJD\d{10,}|00340\d{15}|\d{10})$`)},
+		{"dpd", regexp.MustCompile(`^\d{14}$`)},
+		{"fedex", regexp.MustCompile(`^(\d{12}|\d{15})$`)},
+	}
+	// postal services by the country of international numbers like "RR123456785DE"
+	postal = map[string]string{
+		"US": "usps",
+		"GB": "royalmail",
+		"DE": "dhl",
+		"NL": "postnl",
+	}
+)
+
+// normalize uppercases the number and drops spaces, as printed on labels.
+func normalize(number string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(number), ""))
+}
+
+func isNumber(number string) bool {
+	return validNumber.MatchString(number) && strings.ContainsFunc(number, unicode.IsDigit)
+}
+
+// detect guesses the carrier by the format of the number.
+func detect(number string) string {
+	if m := upu.FindStringSubmatch(number); m != nil {
+		return postal[m[1]]
+	}
+
+	for _, v := range formats {
+		if v.re.MatchString(number) {
+			return v.carrier
+		}
+	}
+
+	return ""
+}
+
+// page is the tracking page of the parcel, of its carrier or the default one.
+func page(p Parcel) string {
+	tpl, ok := config.Pages[p.Carrier]
+	if !ok {
+		tpl, ok = pages[p.Carrier]
+	}
+
+	if !ok {
+		tpl = config.DefaultPage
+	}
+
+	return strings.ReplaceAll(tpl, "%NUMBER%", url.PathEscape(p.Number))
+}
+
+func replace(s string, p Parcel, quote func(string) string) string {
+	return strings.NewReplacer("%NUMBER%", quote(p.Number), "%CARRIER%", quote(p.Carrier)).Replace(s)
+}
+
+func do(req *http.Request, v any) error {
+	req.Header.Set("User-Agent", "elephant")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", req.URL, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// track17 uses the api of 17track.net, which detects the carrier itself.
+type track17 struct{}
+
+// request posts the numbers to the endpoint and returns the accepted ones.
+func (track17) request(endpoint string, numbers []string, v any) error {
+	body := []map[string]string{}
+
+	for _, n := range numbers {
+		body = append(body, map[string]string{"number": n})
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(config.APIURL, "/")+"/track/v2.2/"+endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("17token", config.APIKey)
+
+	var res struct {
+		Code int `json:"code"`
+		Data struct {
+			Accepted json.RawMessage `json:"accepted"`
+			Rejected []struct {
+				Number string `json:"number"`
+				Error  struct {
+					Code    int    `json:"code"`
+					Message string `json:"message"`
+				} `json:"error"`
+			} `json:"rejected"`
+		} `json:"data"`
+	}
+
+	if err := do(req, &res); err != nil {
+		return err
+	}
+
+	if res.Code != 0 {
+		return fmt.Errorf("17track: %s: code %d", endpoint, res.Code)
+	}
+
+	var errs []error
+
+	for _, v := range res.Data.Rejected {
+		// already registered
+		if v.Error.Code == -18019901 {
+			continue
+		}
+
+		errs = append(errs, fmt.Errorf("17track: %s: %s", v.Number, v.Error.Message))
+	}
+
+	if err := json.Unmarshal(res.Data.Accepted, v); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (t track17) register(p Parcel) error {
+	var accepted []any
+
+	return t.request("register", []string{p.Number}, &accepted)
+}
+
+func (t track17) track(parcels []Parcel) (map[string]Tracking, error) {
+	type event struct {
+		Time        time.Time `json:"time_iso"`
+		Description string    `json:"description"`
+		Location    string    `json:"location"`
+	}
+
+	res := map[string]Tracking{}
+
+	var errs []error
+
+	// the api takes 40 numbers per request
+	for chunk := range chunks(parcels, 40) {
+		numbers := []string{}
+
+		for _, v := range chunk {
+			numbers = append(numbers, v.Number)
+		}
+
+		var accepted []struct {
+			Number    string `json:"number"`
+			TrackInfo struct {
+				LatestStatus struct {
+					Status string `json:"status"`
+				} `json:"latest_status"`
+				Tracking struct {
+					Providers []struct {
+						Events []event `json:"events"`
+					} `json:"providers"`
+				} `json:"tracking"`
+			} `json:"track_info"`
+		}
+
+		if err := t.request("gettrackinfo", numbers, &accepted); err != nil {
+			errs = append(errs, err)
+		}
+
+		for _, v := range accepted {
+			status := v.TrackInfo.LatestStatus.Status
+
+			tr := Tracking{State: state17(status), Status: words(status), Events: []Event{}}
+
+			for _, p := range v.TrackInfo.Tracking.Providers {
+				for _, e := range p.Events {
+					tr.Events = append(tr.Events, Event(e))
+				}
+			}
+
+			res[v.Number] = tr
+		}
+	}
+
+	return res, errors.Join(errs...)
+}
+
+func chunks(parcels []Parcel, size int) func(yield func([]Parcel) bool) {
+	return func(yield func([]Parcel) bool) {
+		for i := 0; i < len(parcels); i += size {
+			if !yield(parcels[i:min(i+size, len(parcels))]) {
+				return
+			}
+		}
+	}
+}
+
+func state17(status string) string {
+	switch status {
+	case "Delivered":
+		return StateDelivered
+	case "Exception", "DeliveryFailure", "Expired":
+		return StateException
+	case "InTransit", "OutForDelivery", "AvailableForPickup":
+		return StateTransit
+	}
+
+	return StatePending
+}
+
+// words splits statuses like "InTransit" into "In transit".
+func words(status string) string {
+	var b strings.Builder
+
+	for i, r := range status {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteRune(' ')
+			r = unicode.ToLower(r)
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// trackURL requests the tracking of a self-hosted tracker per parcel.
+type trackURL struct{}
+
+func (trackURL) register(p Parcel) error {
+	return nil
+}
+
+func (trackURL) track(parcels []Parcel) (map[string]Tracking, error) {
+	res := map[string]Tracking{}
+
+	var errs []error
+
+	for _, p := range parcels {
+		req, err := http.NewRequest(http.MethodGet, replace(config.URL, p, url.PathEscape), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if config.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+config.APIKey)
+		}
+
+		var t Tracking
+
+		if err := do(req, &t); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		res[p.Number] = sorted(t)
+	}
+
+	return res, errors.Join(errs...)
+}
+
+// trackCommand runs the command per parcel, it prints the tracking like the self-hosted tracker.
+type trackCommand struct{}
+
+func (trackCommand) register(p Parcel) error {
+	return nil
+}
+
+func (trackCommand) track(parcels []Parcel) (map[string]Tracking, error) {
+	res := map[string]Tracking{}
+
+	var errs []error
+
+	for _, p := range parcels {
+		out, err := output(common.Shell(replace(config.Command, p, common.Quote)))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("command: %s: %w", p.Number, err))
+			continue
+		}
+
+		var t Tracking
+
+		if err := json.Unmarshal(out, &t); err != nil {
+			errs = append(errs, fmt.Errorf("command: %s: %w", p.Number, err))
+			continue
+		}
+
+		res[p.Number] = sorted(t)
+	}
+
+	return res, errors.Join(errs...)
+}
+
+// sorted orders the events newest first and fills in the state of trackers only reporting a status.
+func sorted(t Tracking) Tracking {
+	t.State = strings.ToLower(t.State)
+
+	if t.State == "" {
+		t.State = StatePending
+
+		if len(t.Events) > 0 {
+			t.State = StateTransit
+		}
+	}
+
+	if t.Events == nil {
+		t.Events = []Event{}
+	}
+
+	slices.SortStableFunc(t.Events, func(a, b Event) int {
+		return b.Time.Compare(a.Time)
+	})
+
+	return t
+}
+
+// output runs the command, killing it after 30 seconds.
+func output(cmd *exec.Cmd) ([]byte, error) {
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	timer := time.AfterFunc(30*time.Second, func() {
+		cmd.Process.Kill()
+	})
+	defer timer.Stop()
+
+	err := cmd.Wait()
+
+	return out.Bytes(), err
+}
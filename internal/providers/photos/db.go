@@ -0,0 +1,179 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common/store"
+)
+
+var db *store.Store
+
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS photos (
+		path TEXT PRIMARY KEY,
+		modified INTEGER NOT NULL,
+		taken INTEGER NOT NULL,
+		camera TEXT NOT NULL,
+		lat REAL,
+		lon REAL,
+		place TEXT NOT NULL,
+		search TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_photos_taken ON photos(taken DESC);
+	CREATE TABLE IF NOT EXISTS places (
+		key TEXT PRIMARY KEY,
+		name TEXT NOT NULL
+	);`,
+}
+
+type Photo struct {
+	Path   string
+	Taken  time.Time
+	Camera string
+	Place  string
+	HasGPS bool
+	Lat    float64
+	Lon    float64
+}
+
+func openDB() error {
+	var err error
+
+	db, err = store.Open(Name, migrations...)
+
+	return err
+}
+
+// indexed returns the indexed photos with their modification time.
+func indexed() (map[string]time.Time, error) {
+	rows, err := db.Query("SELECT path, modified FROM photos")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[string]time.Time)
+
+	for rows.Next() {
+		var path string
+		var modified int64
+
+		if err := rows.Scan(&path, &modified); err != nil {
+			return nil, err
+		}
+
+		res[path] = time.Unix(modified, 0)
+	}
+
+	return res, rows.Err()
+}
+
+func putPhoto(p Photo, modified time.Time) error {
+	var lat, lon any
+
+	if p.HasGPS {
+		lat, lon = p.Lat, p.Lon
+	}
+
+	_, err := db.Exec("INSERT OR REPLACE INTO photos (path, modified, taken, camera, lat, lon, place, search) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		p.Path, modified.Unix(), p.Taken.Unix(), p.Camera, lat, lon, p.Place, searchText(p))
+
+	return err
+}
+
+func removePhoto(path string) error {
+	_, err := db.Exec("DELETE FROM photos WHERE path = ?", path)
+	return err
+}
+
+// search returns the photos matching all terms of the query, newest first.
+func search(query string, limit int) ([]Photo, error) {
+	where := []string{}
+	args := []any{}
+
+	for _, v := range strings.Fields(strings.ToLower(query)) {
+		where = append(where, "search LIKE ? ESCAPE '\\'")
+		args = append(args, "%"+likeEscaper.Replace(v)+"%")
+	}
+
+	q := "SELECT path, taken, camera, place FROM photos"
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	q += " ORDER BY taken DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []Photo{}
+
+	for rows.Next() {
+		var p Photo
+		var taken int64
+
+		if err := rows.Scan(&p.Path, &taken, &p.Camera, &p.Place); err != nil {
+			return nil, err
+		}
+
+		p.Taken = time.Unix(taken, 0)
+		res = append(res, p)
+	}
+
+	return res, rows.Err()
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// place returns the cached name of the location, if it was looked up before.
+func place(key string) (string, bool) {
+	var name string
+
+	if err := db.QueryRow("SELECT name FROM places WHERE key = ?", key).Scan(&name); err != nil {
+		return "", false
+	}
+
+	return name, true
+}
+
+func putPlace(key, name string) error {
+	_, err := db.Exec("INSERT OR REPLACE INTO places (key, name) VALUES (?, ?)", key, name)
+	return err
+}
+
+// unresolved returns photos with coordinates but without place, f.e. as geocoding was disabled when they were
+// indexed.
+func unresolved() ([]Photo, error) {
+	rows, err := db.Query("SELECT path, taken, camera, lat, lon FROM photos WHERE lat IS NOT NULL AND place = ''")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []Photo{}
+
+	for rows.Next() {
+		var p Photo
+		var taken int64
+
+		if err := rows.Scan(&p.Path, &taken, &p.Camera, &p.Lat, &p.Lon); err != nil {
+			return nil, err
+		}
+
+		p.Taken = time.Unix(taken, 0)
+		p.HasGPS = true
+		res = append(res, p)
+	}
+
+	return res, rows.Err()
+}
+
+func setPlace(p Photo) error {
+	_, err := db.Exec("UPDATE photos SET place = ?, search = ? WHERE path = ?", p.Place, searchText(p), p.Path)
+	return err
+}
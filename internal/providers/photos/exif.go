@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Exif holds the indexed tags of a photo.
+type Exif struct {
+	Taken  time.Time
+	Camera string
+	HasGPS bool
+	Lat    float64
+	Lon    float64
+}
+
+const (
+	tagMake             = 0x010f
+	tagModel            = 0x0110
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagGPSLatitudeRef   = 0x0001
+	tagGPSLatitude      = 0x0002
+	tagGPSLongitudeRef  = 0x0003
+	tagGPSLongitude     = 0x0004
+)
+
+var errNoExif = errors.New("no exif data")
+
+// readExif reads the exif segment of a jpeg. Only the segment headers before it are read, not the image.
+func readExif(path string) (Exif, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Exif{}, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xff, 0xd8} {
+		return Exif{}, errNoExif
+	}
+
+	for {
+		var marker [4]byte
+		if _, err := io.ReadFull(r, marker[:]); err != nil {
+			return Exif{}, errNoExif
+		}
+
+		// start of scan, the image data follows
+		if marker[0] != 0xff || marker[1] == 0xda {
+			return Exif{}, errNoExif
+		}
+
+		length := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if length < 0 {
+			return Exif{}, errNoExif
+		}
+
+		if marker[1] != 0xe1 {
+			if _, err := r.Discard(length); err != nil {
+				return Exif{}, errNoExif
+			}
+
+			continue
+		}
+
+		b := make([]byte, length)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return Exif{}, errNoExif
+		}
+
+		if tiff, ok := bytes.CutPrefix(b, []byte("Exif\x00\x00")); ok {
+			return parseTIFF(tiff)
+		}
+	}
+}
+
+type ifdEntry struct {
+	tag    uint16
+	count  uint32
+	offset []byte
+}
+
+// parseTIFF reads the tags of the first IFD and the exif and gps IFDs it points to.
+func parseTIFF(b []byte) (Exif, error) {
+	if len(b) < 8 {
+		return Exif{}, errNoExif
+	}
+
+	var order binary.ByteOrder
+
+	switch string(b[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return Exif{}, errNoExif
+	}
+
+	ifd := func(offset uint32) []ifdEntry {
+		if int(offset)+2 > len(b) {
+			return nil
+		}
+
+		n := int(order.Uint16(b[offset:]))
+		res := []ifdEntry{}
+
+		for i := range n {
+			start := int(offset) + 2 + i*12
+			if start+12 > len(b) {
+				break
+			}
+
+			res = append(res, ifdEntry{
+				tag:    order.Uint16(b[start:]),
+				count:  order.Uint32(b[start+4:]),
+				offset: b[start+8 : start+12],
+			})
+		}
+
+		return res
+	}
+
+	// value returns the data of the entry, which is inline if it fits into 4 bytes.
+	value := func(e ifdEntry, size int) []byte {
+		n := size * int(e.count)
+		if n <= 4 {
+			return e.offset[:n]
+		}
+
+		offset := int(order.Uint32(e.offset))
+		if offset+n > len(b) {
+			return nil
+		}
+
+		return b[offset : offset+n]
+	}
+
+	ascii := func(e ifdEntry) string {
+		return strings.TrimSpace(strings.TrimRight(string(value(e, 1)), "\x00"))
+	}
+
+	// degrees converts three rationals of degrees, minutes and seconds.
+	degrees := func(e ifdEntry) (float64, bool) {
+		v := value(e, 8)
+		if e.count != 3 || len(v) != 24 {
+			return 0, false
+		}
+
+		res := 0.0
+
+		for i, div := range []float64{1, 60, 3600} {
+			num, den := order.Uint32(v[i*8:]), order.Uint32(v[i*8+4:])
+			if den == 0 {
+				return 0, false
+			}
+
+			res += float64(num) / float64(den) / div
+		}
+
+		return res, true
+	}
+
+	var res Exif
+	var maker string
+
+	for _, e := range ifd(order.Uint32(b[4:])) {
+		switch e.tag {
+		case tagMake:
+			maker = ascii(e)
+		case tagModel:
+			res.Camera = ascii(e)
+		case tagExifIFD:
+			for _, e := range ifd(order.Uint32(e.offset)) {
+				if e.tag == tagDateTimeOriginal {
+					res.Taken, _ = time.ParseInLocation("2006:01:02 15:04:05", ascii(e), time.Local)
+				}
+			}
+		case tagGPSIFD:
+			latRef, lonRef := "", ""
+			hasLat, hasLon := false, false
+
+			for _, e := range ifd(order.Uint32(e.offset)) {
+				switch e.tag {
+				case tagGPSLatitudeRef:
+					latRef = ascii(e)
+				case tagGPSLongitudeRef:
+					lonRef = ascii(e)
+				case tagGPSLatitude:
+					res.Lat, hasLat = degrees(e)
+				case tagGPSLongitude:
+					res.Lon, hasLon = degrees(e)
+				}
+			}
+
+			if latRef == "S" {
+				res.Lat = -res.Lat
+			}
+
+			if lonRef == "W" {
+				res.Lon = -res.Lon
+			}
+
+			res.HasGPS = hasLat && hasLon
+		}
+	}
+
+	// models mostly start with the make, f.e. "Canon EOS R6", but not always
+	if maker != "" && !strings.HasPrefix(strings.ToLower(res.Camera), strings.ToLower(maker)) {
+		res.Camera = strings.TrimSpace(maker + " " + res.Camera)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	client     = http.Client{Timeout: 10 * time.Second}
+	lastLookup time.Time
+)
+
+// placeName returns the place of the coordinates, looked up via nominatim. Coordinates are rounded to about a
+// kilometer, which is sent and cached. Lookups are limited to one per second, as the public instance asks.
+func placeName(lat, lon float64) (string, error) {
+	key := fmt.Sprintf("%.2f,%.2f", lat, lon)
+
+	if name, ok := place(key); ok {
+		return name, nil
+	}
+
+	if !config.ReverseGeocoding {
+		return "", nil
+	}
+
+	time.Sleep(time.Until(lastLookup.Add(time.Second)))
+	lastLookup = time.Now()
+
+	q := url.Values{}
+	q.Set("format", "jsonv2")
+	q.Set("zoom", "10")
+	q.Set("lat", fmt.Sprintf("%.2f", lat))
+	q.Set("lon", fmt.Sprintf("%.2f", lon))
+
+	if config.Language != "" {
+		q.Set("accept-language", config.Language)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, config.GeocodingURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("User-Agent", "elephant")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("geocoding: %s", resp.Status)
+	}
+
+	var res struct {
+		Address map[string]string `json:"address"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", err
+	}
+
+	parts := []string{}
+
+	for _, v := range []string{"city", "town", "village", "municipality", "county"} {
+		if name := res.Address[v]; name != "" {
+			parts = append(parts, name)
+			break
+		}
+	}
+
+	for _, v := range []string{"state", "country"} {
+		if name := res.Address[v]; name != "" {
+			parts = append(parts, name)
+		}
+	}
+
+	name := strings.Join(parts, ", ")
+
+	if err := putPlace(key, name); err != nil {
+		return name, err
+	}
+
+	return name, nil
+}
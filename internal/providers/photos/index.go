@@ -0,0 +1,171 @@
+package main
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+)
+
+var indexing atomic.Bool
+
+// dirs returns the configured directories as clean absolute paths.
+func dirs() []string {
+	res := []string{}
+
+	for _, v := range config.Dirs {
+		if after, ok := strings.CutPrefix(v, "~/"); ok {
+			home, _ := os.UserHomeDir()
+			v = filepath.Join(home, after)
+		}
+
+		res = append(res, filepath.Clean(v))
+	}
+
+	return res
+}
+
+// searchText is what queries are matched against: the file name, camera, place and the date in a few formats, so
+// "paris 2023" or "june" match.
+func searchText(p Photo) string {
+	parts := []string{filepath.Base(p.Path), p.Camera, p.Place}
+
+	if !p.Taken.IsZero() {
+		parts = append(parts, p.Taken.Format("2006-01-02 January Jan 2006 Monday"))
+	}
+
+	return strings.ToLower(strings.Join(parts, " "))
+}
+
+// index reads photos that changed since they were indexed and removes the ones that are gone.
+func index() {
+	if !indexing.CompareAndSwap(false, true) {
+		return
+	}
+	defer indexing.Store(false)
+
+	start := time.Now()
+
+	known, err := indexed()
+	if err != nil {
+		slog.Error(Name, "index", err)
+		return
+	}
+
+	found := make(map[string]bool)
+	changed := 0
+
+	for _, dir := range dirs() {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if strings.HasPrefix(d.Name(), ".") && path != dir {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+
+			if d.IsDir() || !slices.Contains(config.Extensions, ext) {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+
+			found[path] = true
+
+			if modified, ok := known[path]; ok && modified.Equal(info.ModTime().Truncate(time.Second)) {
+				return nil
+			}
+
+			p := Photo{Path: path, Taken: info.ModTime()}
+
+			// photos without exif data are still found by name and modification time
+			if e, err := readExif(path); err == nil {
+				p.Camera = e.Camera
+				p.HasGPS, p.Lat, p.Lon = e.HasGPS, e.Lat, e.Lon
+
+				if !e.Taken.IsZero() {
+					p.Taken = e.Taken
+				}
+			}
+
+			if err := putPhoto(p, info.ModTime()); err != nil {
+				slog.Error(Name, "index", err, "path", path)
+				return nil
+			}
+
+			changed++
+
+			return nil
+		})
+	}
+
+	for path := range known {
+		if found[path] {
+			continue
+		}
+
+		if err := removePhoto(path); err != nil {
+			slog.Error(Name, "index", err, "path", path)
+		}
+
+		changed++
+	}
+
+	changed += resolvePlaces()
+
+	slog.Info(Name, "indexed", changed, "photos", len(found), "time", time.Since(start))
+
+	if changed > 0 {
+		handlers.ProviderUpdated <- Name
+	}
+}
+
+// resolvePlaces looks up the places of photos with coordinates and returns the number of updated photos.
+func resolvePlaces() int {
+	photos, err := unresolved()
+	if err != nil {
+		slog.Error(Name, "places", err)
+		return 0
+	}
+
+	updated := 0
+
+	for _, p := range photos {
+		name, err := placeName(p.Lat, p.Lon)
+		if err != nil {
+			slog.Error(Name, "places", err)
+			return updated
+		}
+
+		if name == "" {
+			continue
+		}
+
+		p.Place = name
+
+		if err := setPlace(p); err != nil {
+			slog.Error(Name, "places", err)
+			continue
+		}
+
+		updated++
+	}
+
+	return updated
+}
@@ -0,0 +1,207 @@
+// Package photos searches photos by date, camera and place from their exif data.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "photos"
+	NamePretty = "Photos"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config    `koanf:",squash"`
+	Dirs             []string `koanf:"dirs" desc:"directories to index" default:"['~/Pictures']"`
+	Extensions       []string `koanf:"extensions" desc:"file types to index, exif data is read from jpegs" default:"['jpg', 'jpeg']"`
+	ReindexInterval  int      `koanf:"reindex_interval" desc:"minutes between checks for changed photos" default:"30"`
+	MaxResults       int      `koanf:"max_results" desc:"maximum number of photos returned" default:"50"`
+	ReverseGeocoding bool     `koanf:"reverse_geocoding" desc:"look up place names of gps coordinates, which are sent to geocoding_url" default:"false"`
+	GeocodingURL     string   `koanf:"geocoding_url" desc:"nominatim reverse geocoding endpoint" default:"https://nominatim.openstreetmap.org/reverse"`
+	Language         string   `koanf:"language" desc:"language of place names, f.e. 'en'. the servers default if empty" default:""`
+}
+
+const (
+	ActionOpen    = "open"
+	ActionCopy    = "copy"
+	ActionOpenDir = "opendir"
+	ActionReindex = "reindex"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "image-x-generic",
+			MinScore: 20,
+		},
+		Dirs:            []string{"~/Pictures"},
+		Extensions:      []string{"jpg", "jpeg"},
+		ReindexInterval: 30,
+		MaxResults:      50,
+		GeocodingURL:    "https://nominatim.openstreetmap.org/reverse",
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	if err := openDB(); err != nil {
+		slog.Error(Name, "db", err)
+		return
+	}
+
+	go func() {
+		for {
+			index()
+			time.Sleep(time.Duration(config.ReindexInterval) * time.Minute)
+		}
+	}()
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionOpen, Label: "Open", Icon: "document-open", Default: true},
+		{Action: ActionCopy, Label: "Copy", Icon: "edit-copy"},
+		{Action: ActionOpenDir, Label: "Show in folder", Icon: "folder-open"},
+		{Action: ActionReindex, Label: "Reindex", Icon: "view-refresh"},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionOpen
+	}
+
+	var cmd *exec.Cmd
+
+	switch action {
+	case ActionReindex:
+		go index()
+		return
+	case ActionOpen, ActionOpenDir:
+		path := identifier
+
+		if action == ActionOpenDir {
+			path = filepath.Dir(path)
+		}
+
+		cmd = common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix("", Name, identifier), common.OpenCommand(), common.Quote(path))))
+		common.Detach(cmd)
+	case ActionCopy:
+		// only wl-clipboard can set the uri-list type, elsewhere the path is copied
+		if runtime.GOOS == "linux" {
+			cmd = exec.Command("wl-copy", "-t", "text/uri-list", "file://"+identifier)
+		} else {
+			cmd = common.CopyCmd(identifier)
+		}
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	if err := cmd.Start(); err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	if db == nil {
+		return entries
+	}
+
+	photos, err := search(query, config.MaxResults)
+	if err != nil {
+		slog.Error(Name, "query", err)
+		return entries
+	}
+
+	for k, v := range photos {
+		subtext := []string{v.Taken.Format("January 2, 2006")}
+
+		for _, s := range []string{v.Place, v.Camera} {
+			if s != "" {
+				subtext = append(subtext, s)
+			}
+		}
+
+		entries = append(entries, &pb.QueryResponse_Item{
+			Identifier:  v.Path,
+			Text:        filepath.Base(v.Path),
+			Subtext:     strings.Join(subtext, " · "),
+			Provider:    Name,
+			Icon:        config.Icon,
+			Actions:     []string{ActionOpen, ActionCopy, ActionOpenDir},
+			Preview:     v.Path,
+			PreviewType: util.PreviewTypeFile,
+			Type:        pb.QueryResponse_REGULAR,
+			Score:       int32(config.MaxResults - k),
+		})
+	}
+
+	// thumbnails are created after filtering, so only matching photos get one
+	if common.WantsThumbnails(conn) {
+		for _, e := range entries {
+			e.Thumbnail = common.Thumbnail(e.Preview)
+		}
+	}
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+// State is "indexing" while photos are read.
+func State(provider string) *pb.ProviderStateResponse {
+	states := []string{}
+
+	if indexing.Load() {
+		states = append(states, "indexing")
+	}
+
+	return &pb.ProviderStateResponse{
+		States:  states,
+		Actions: []string{ActionReindex},
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common/store"
+)
+
+var db *store.Store
+
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS episodes (
+		guid TEXT PRIMARY KEY,
+		feed TEXT NOT NULL,
+		podcast TEXT NOT NULL,
+		title TEXT NOT NULL,
+		url TEXT NOT NULL,
+		published INTEGER NOT NULL,
+		duration INTEGER NOT NULL,
+		played INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS idx_episodes_published ON episodes(published DESC);`,
+}
+
+type Episode struct {
+	GUID      string
+	Feed      string
+	Podcast   string
+	Title     string
+	URL       string
+	Published time.Time
+	Duration  time.Duration
+	Played    bool
+}
+
+func openDB() error {
+	var err error
+
+	db, err = store.Open(Name, migrations...)
+
+	return err
+}
+
+// putEpisodes stores the episodes, keeping the played state of known ones, and returns the number of new ones.
+func putEpisodes(episodes []Episode) (int, error) {
+	added := 0
+
+	err := db.Tx(func(tx *sql.Tx) error {
+		for _, e := range episodes {
+			var exists int
+
+			if err := tx.QueryRow("SELECT count(*) FROM episodes WHERE guid = ?", e.GUID).Scan(&exists); err != nil {
+				return err
+			}
+
+			if exists == 0 {
+				added++
+			}
+
+			_, err := tx.Exec(`INSERT INTO episodes (guid, feed, podcast, title, url, published, duration) VALUES (?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT(guid) DO UPDATE SET podcast = excluded.podcast, title = excluded.title, url = excluded.url, duration = excluded.duration`,
+				e.GUID, e.Feed, e.Podcast, e.Title, e.URL, e.Published.Unix(), int64(e.Duration.Seconds()))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return added, err
+}
+
+// recent returns the newest episodes of the configured feeds.
+func recent(feeds []string, limit int) ([]Episode, error) {
+	res := []Episode{}
+
+	if len(feeds) == 0 {
+		return res, nil
+	}
+
+	args := []any{}
+	for _, v := range feeds {
+		args = append(args, v)
+	}
+
+	args = append(args, limit)
+
+	rows, err := db.Query(`SELECT guid, feed, podcast, title, url, published, duration, played FROM episodes
+		WHERE feed IN (?`+strings.Repeat(", ?", len(feeds)-1)+`) ORDER BY published DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e Episode
+		var published, duration int64
+
+		if err := rows.Scan(&e.GUID, &e.Feed, &e.Podcast, &e.Title, &e.URL, &published, &duration, &e.Played); err != nil {
+			return nil, err
+		}
+
+		e.Published = time.Unix(published, 0)
+		e.Duration = time.Duration(duration) * time.Second
+
+		res = append(res, e)
+	}
+
+	return res, rows.Err()
+}
+
+func getEpisode(guid string) *Episode {
+	var e Episode
+	var published, duration int64
+
+	err := db.QueryRow("SELECT guid, feed, podcast, title, url, published, duration, played FROM episodes WHERE guid = ?", guid).
+		Scan(&e.GUID, &e.Feed, &e.Podcast, &e.Title, &e.URL, &published, &duration, &e.Played)
+	if err != nil {
+		return nil
+	}
+
+	e.Published = time.Unix(published, 0)
+	e.Duration = time.Duration(duration) * time.Second
+
+	return &e
+}
+
+func setPlayed(guid string, played bool) error {
+	_, err := db.Exec("UPDATE episodes SET played = ? WHERE guid = ?", played, guid)
+	return err
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+)
+
+var (
+	client     = http.Client{Timeout: 30 * time.Second}
+	refreshing atomic.Bool
+)
+
+type rss struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title     string `xml:"title"`
+			GUID      string `xml:"guid"`
+			PubDate   string `xml:"pubDate"`
+			Duration  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+			Enclosure struct {
+				URL string `xml:"url,attr"`
+			} `xml:"enclosure"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// fetch reads the episodes of the feed. Items without an enclosure aren't episodes.
+func fetch(url string) ([]Episode, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "elephant")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	var feed rss
+
+	d := xml.NewDecoder(resp.Body)
+	d.Strict = false
+
+	if err := d.Decode(&feed); err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+
+	res := []Episode{}
+
+	for _, v := range feed.Channel.Items {
+		if v.Enclosure.URL == "" {
+			continue
+		}
+
+		e := Episode{
+			GUID:     strings.TrimSpace(v.GUID),
+			Feed:     url,
+			Podcast:  strings.TrimSpace(feed.Channel.Title),
+			Title:    strings.TrimSpace(v.Title),
+			URL:      v.Enclosure.URL,
+			Duration: parseDuration(v.Duration),
+		}
+
+		if e.GUID == "" {
+			e.GUID = e.URL
+		}
+
+		for _, layout := range []string{time.RFC1123Z, time.RFC1123, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST"} {
+			if t, err := time.Parse(layout, strings.TrimSpace(v.PubDate)); err == nil {
+				e.Published = t
+				break
+			}
+		}
+
+		res = append(res, e)
+	}
+
+	return res, nil
+}
+
+// parseDuration parses itunes durations, which are seconds or "[hh:]mm:ss".
+func parseDuration(s string) time.Duration {
+	res := 0
+
+	for v := range strings.SplitSeq(strings.TrimSpace(s), ":") {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0
+		}
+
+		res = res*60 + n
+	}
+
+	return time.Duration(res) * time.Second
+}
+
+// refresh fetches all feeds and stores new episodes.
+func refresh() {
+	if !refreshing.CompareAndSwap(false, true) {
+		return
+	}
+	defer refreshing.Store(false)
+
+	added := 0
+
+	for _, url := range config.Feeds {
+		episodes, err := fetch(url)
+		if err != nil {
+			slog.Error(Name, "refresh", err)
+			continue
+		}
+
+		n, err := putEpisodes(episodes)
+		if err != nil {
+			slog.Error(Name, "refresh", err)
+			continue
+		}
+
+		added += n
+	}
+
+	slog.Info(Name, "refreshed", len(config.Feeds), "new", added)
+
+	if added > 0 {
+		handlers.ProviderUpdated <- Name
+	}
+}
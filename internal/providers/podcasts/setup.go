@@ -0,0 +1,247 @@
+// Package podcasts lists and streams episodes of podcast feeds.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "podcasts"
+	NamePretty = "Podcasts"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config   `koanf:",squash"`
+	Feeds           []string `koanf:"feeds" desc:"urls of the rss feeds to subscribe to" default:"[]"`
+	PlayCommand     string   `koanf:"play_command" desc:"streams an episode, %URL% is replaced" default:"mpv --no-video --force-window=no %URL%"`
+	RefreshInterval int      `koanf:"refresh_interval" desc:"minutes between fetching the feeds" default:"60"`
+	MaxEpisodes     int      `koanf:"max_episodes" desc:"maximum number of recent episodes listed" default:"100"`
+	HidePlayed      bool     `koanf:"hide_played" desc:"don't list played episodes" default:"false"`
+}
+
+const (
+	ActionPlay         = "play"
+	ActionMarkPlayed   = "mark_played"
+	ActionMarkUnplayed = "mark_unplayed"
+	ActionRefresh      = "refresh"
+
+	StatePlayed   = "played"
+	StateUnplayed = "unplayed"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "application-rss+xml",
+			MinScore: 20,
+		},
+		Feeds:           []string{},
+		PlayCommand:     "mpv --no-video --force-window=no %URL%",
+		RefreshInterval: 60,
+		MaxEpisodes:     100,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	if err := openDB(); err != nil {
+		slog.Error(Name, "db", err)
+		return
+	}
+
+	go func() {
+		for {
+			refresh()
+			time.Sleep(time.Duration(config.RefreshInterval) * time.Minute)
+		}
+	}()
+}
+
+func Available() bool {
+	if len(config.Feeds) == 0 {
+		slog.Info(Name, "available", "no feeds configured. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionPlay, Label: "Play", Icon: "media-playback-start", Default: true},
+		{Action: ActionMarkPlayed, Label: "Mark as played", Icon: "object-select"},
+		{Action: ActionMarkUnplayed, Label: "Mark as unplayed", Icon: "edit-undo"},
+		{Action: ActionRefresh, Label: "Refresh feeds", Icon: "view-refresh"},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == ActionRefresh {
+		go refresh()
+		return
+	}
+
+	e := getEpisode(identifier)
+	if e == nil {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown episode: %s", identifier))
+		return
+	}
+
+	if action == "" {
+		action = ActionPlay
+	}
+
+	switch action {
+	case ActionPlay:
+		run := strings.ReplaceAll(config.PlayCommand, "%URL%", common.Quote(e.URL))
+
+		cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), run)))
+		common.Detach(cmd)
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "play", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+
+		if err := setPlayed(e.GUID, true); err != nil {
+			slog.Error(Name, "play", err)
+		}
+	case ActionMarkPlayed, ActionMarkUnplayed:
+		if err := setPlayed(e.GUID, action == ActionMarkPlayed); err != nil {
+			slog.Error(Name, action, err)
+			return
+		}
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	handlers.ProviderUpdated <- Name
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	if db == nil {
+		return entries
+	}
+
+	episodes, err := recent(config.Feeds, config.MaxEpisodes)
+	if err != nil {
+		slog.Error(Name, "query", err)
+		return entries
+	}
+
+	for k, v := range episodes {
+		if config.HidePlayed && v.Played {
+			continue
+		}
+
+		subtext := []string{v.Podcast, v.Published.Format("Jan 2, 2006")}
+
+		if v.Duration > 0 {
+			subtext = append(subtext, formatDuration(v.Duration))
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier: v.GUID,
+			Text:       v.Title,
+			Subtext:    strings.Join(subtext, " · "),
+			Provider:   Name,
+			Icon:       config.Icon,
+			State:      []string{StateUnplayed},
+			Actions:    []string{ActionPlay, ActionMarkPlayed},
+			Type:       pb.QueryResponse_REGULAR,
+			Score:      int32(len(episodes) - k),
+		}
+
+		if v.Played {
+			e.State = []string{StatePlayed}
+			e.Actions = []string{ActionPlay, ActionMarkUnplayed}
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, e.Text, exact)
+
+			// matches of the podcast name don't highlight the title
+			if s, _, _ := common.FuzzyScore(query, v.Podcast, exact); s > score {
+				score, pos, start = s, nil, 0
+			}
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// formatDuration formats durations like "1h 5min" or "45min".
+func formatDuration(d time.Duration) string {
+	h, m := int(d.Hours()), int(d.Minutes())%60
+
+	if h == 0 {
+		return fmt.Sprintf("%dmin", max(m, 1))
+	}
+
+	return fmt.Sprintf("%dh %dmin", h, m)
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	states := []string{}
+
+	if refreshing.Load() {
+		states = append(states, "refreshing")
+	}
+
+	return &pb.ProviderStateResponse{
+		States:  states,
+		Actions: []string{ActionRefresh},
+	}
+}
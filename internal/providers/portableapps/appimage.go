@@ -0,0 +1,80 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type AppImage struct {
+	Name       string
+	Path       string
+	Size       int64
+	Modified   time.Time
+	Executable bool
+}
+
+// versionRe strips versions and architectures from file names, f.e. "Obsidian-1.5.3-x86_64".
+var versionRe = regexp.MustCompile(`(?i)[-_ .]+(v?\d[\w.]*|x86[-_]64|amd64|aarch64|arm64|i[36]86|linux(64)?)$`)
+
+func listAppImages() []AppImage {
+	res := []AppImage{}
+
+	for _, dir := range config.AppImageDirs {
+		dir = expand(dir)
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, v := range entries {
+			if v.IsDir() || !strings.EqualFold(filepath.Ext(v.Name()), ".appimage") {
+				continue
+			}
+
+			info, err := v.Info()
+			if err != nil {
+				continue
+			}
+
+			res = append(res, AppImage{
+				Name:       appImageName(v.Name()),
+				Path:       filepath.Join(dir, v.Name()),
+				Size:       info.Size(),
+				Modified:   info.ModTime(),
+				Executable: info.Mode()&0o111 != 0,
+			})
+		}
+	}
+
+	return res
+}
+
+func appImageName(file string) string {
+	name := strings.TrimSuffix(file, filepath.Ext(file))
+
+	for {
+		stripped := versionRe.ReplaceAllString(name, "")
+		if stripped == name || stripped == "" {
+			break
+		}
+
+		name = stripped
+	}
+
+	return strings.NewReplacer("_", " ", "-", " ").Replace(name)
+}
+
+func expand(path string) string {
+	if after, ok := strings.CutPrefix(path, "~/"); ok {
+		home, _ := os.UserHomeDir()
+		path = filepath.Join(home, after)
+	}
+
+	return path
+}
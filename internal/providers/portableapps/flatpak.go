@@ -0,0 +1,139 @@
+//go:build linux
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+type Flatpak struct {
+	ID           string
+	Name         string
+	Version      string
+	Branch       string
+	Origin       string
+	Installation string
+}
+
+var (
+	permMu      sync.Mutex
+	permissions = make(map[string]string)
+)
+
+func listFlatpaks() []Flatpak {
+	res := []Flatpak{}
+
+	out, err := exec.Command("flatpak", "list", "--app", "--columns=application,name,version,branch,origin,installation").Output()
+	if err != nil {
+		slog.Error(Name, "flatpak", err)
+		return res
+	}
+
+	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 6 {
+			continue
+		}
+
+		res = append(res, Flatpak{
+			ID:           fields[0],
+			Name:         fields[1],
+			Version:      fields[2],
+			Branch:       fields[3],
+			Origin:       fields[4],
+			Installation: fields[5],
+		})
+	}
+
+	return res
+}
+
+// loadPermissions fetches the permission summaries in the background, as 'flatpak info' is slow.
+func loadPermissions(list []Flatpak) {
+	for _, v := range list {
+		permMu.Lock()
+		_, ok := permissions[v.ID]
+		permMu.Unlock()
+
+		if ok {
+			continue
+		}
+
+		out, err := common.Exec(Name, exec.Command("flatpak", "info", "--show-permissions", v.ID), common.ExecOptions{})
+		if err != nil {
+			slog.Debug(Name, "permissions", err, "app", v.ID)
+			continue
+		}
+
+		permMu.Lock()
+		permissions[v.ID] = summarize(string(out))
+		permMu.Unlock()
+	}
+}
+
+// summarize turns the permissions keyfile into a readable summary, f.e. "Sockets: wayland, pulseaudio".
+func summarize(keyfile string) string {
+	labels := map[string]string{
+		"shared":      "Shared",
+		"sockets":     "Sockets",
+		"devices":     "Devices",
+		"filesystems": "Filesystems",
+		"features":    "Features",
+		"persistent":  "Persistent",
+	}
+
+	lines := []string{}
+	section := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(keyfile))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[]")
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok || v == "" {
+			continue
+		}
+
+		switch section {
+		case "Context":
+			label, ok := labels[k]
+			if !ok {
+				label = k
+			}
+
+			lines = append(lines, fmt.Sprintf("%s: %s", label, strings.ReplaceAll(strings.TrimSuffix(v, ";"), ";", ", ")))
+		case "Session Bus Policy", "System Bus Policy":
+			lines = append(lines, fmt.Sprintf("%s: %s (%s)", strings.TrimSuffix(section, " Policy"), k, v))
+		case "Environment":
+			lines = append(lines, fmt.Sprintf("Environment: %s=%s", k, v))
+		}
+	}
+
+	if len(lines) == 0 {
+		return "No permissions"
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func permissionsOf(id string) (string, bool) {
+	permMu.Lock()
+	defer permMu.Unlock()
+
+	v, ok := permissions[id]
+
+	return v, ok
+}
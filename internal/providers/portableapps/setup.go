@@ -0,0 +1,357 @@
+//go:build linux
+
+// Package portableapps provides installed Flatpaks and AppImages found in configured folders.
+package main
+
+import (
+	"crypto/md5"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/history"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "portableapps"
+	NamePretty = "Flatpaks & AppImages"
+	config     *Config
+	h          = history.Load(Name)
+	mu         sync.RWMutex
+	flatpaks   = []Flatpak{}
+	appimages  = []AppImage{}
+	loaded     time.Time
+	hasFlatpak bool
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config        `koanf:",squash"`
+	History              bool     `koanf:"history" desc:"make use of history for sorting" default:"true"`
+	HistoryWhenEmpty     bool     `koanf:"history_when_empty" desc:"consider history when query is empty" default:"false"`
+	AppImageDirs         []string `koanf:"appimage_dirs" desc:"folders containing AppImages" default:"['~/Applications', '~/.local/bin']"`
+	CommandUpdate        string   `koanf:"command_update" desc:"command to update a flatpak. supports %VALUE%." default:"flatpak update %VALUE%"`
+	CommandUninstall     string   `koanf:"command_uninstall" desc:"command to uninstall a flatpak. supports %VALUE%." default:"flatpak uninstall %VALUE%"`
+	AutoWrapWithTerminal bool     `koanf:"auto_wrap_with_terminal" desc:"runs update and uninstall in a terminal" default:"true"`
+}
+
+const (
+	ActionRun        = "run"
+	ActionUpdate     = "update"
+	ActionUninstall  = "uninstall"
+	ActionExecutable = "make_executable"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "application-x-executable",
+			MinScore: 30,
+		},
+		History:              true,
+		HistoryWhenEmpty:     false,
+		AppImageDirs:         []string{"~/Applications", "~/.local/bin"},
+		CommandUpdate:        "flatpak update %VALUE%",
+		CommandUninstall:     "flatpak uninstall %VALUE%",
+		AutoWrapWithTerminal: true,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	_, err := exec.LookPath("flatpak")
+	hasFlatpak = err == nil
+
+	load()
+}
+
+// Refresh re-reads installed Flatpaks and AppImages.
+func Refresh() {
+	load()
+}
+
+func load() {
+	f := []Flatpak{}
+
+	if hasFlatpak {
+		f = listFlatpaks()
+		go loadPermissions(f)
+	}
+
+	a := listAppImages()
+
+	mu.Lock()
+	flatpaks = f
+	appimages = a
+	loaded = time.Now()
+	mu.Unlock()
+}
+
+func Available() bool {
+	if hasFlatpak {
+		return true
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if len(appimages) == 0 {
+		slog.Info(Name, "available", "flatpak not found and no AppImages found. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func appImageID(a AppImage) string {
+	md5 := md5.Sum([]byte(a.Path))
+	return hex.EncodeToString(md5[:])
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == history.ActionDelete {
+		h.Remove(identifier)
+		return
+	}
+
+	if action == "" {
+		action = ActionRun
+	}
+
+	mu.RLock()
+	fp := flatpaks
+	ai := appimages
+	mu.RUnlock()
+
+	var run string
+
+	for _, v := range fp {
+		if v.ID != identifier {
+			continue
+		}
+
+		switch action {
+		case ActionRun:
+			run = fmt.Sprintf("flatpak run %s", common.Quote(v.ID))
+		case ActionUpdate, ActionUninstall:
+			pkgcmd := config.CommandUpdate
+			if action == ActionUninstall {
+				pkgcmd = config.CommandUninstall
+			}
+
+			pkgcmd = strings.ReplaceAll(pkgcmd, "%VALUE%", common.Quote(v.ID))
+
+			if config.AutoWrapWithTerminal {
+				pkgcmd = common.WrapWithTerminal(pkgcmd)
+			}
+
+			// wait for the command to finish, so the list can be reloaded.
+			go func() {
+				cmd := common.HostShell(pkgcmd)
+
+				if out, err := cmd.CombinedOutput(); err != nil {
+					slog.Error(Name, action, err, "out", string(out))
+				}
+
+				load()
+			}()
+
+			return
+		}
+	}
+
+	for _, v := range ai {
+		if appImageID(v) != identifier {
+			continue
+		}
+
+		switch action {
+		case ActionExecutable:
+			if err := os.Chmod(v.Path, 0o755); err != nil {
+				slog.Error(Name, "activate", err)
+			}
+
+			load()
+
+			return
+		case ActionRun:
+			if !v.Executable {
+				if err := os.Chmod(v.Path, 0o755); err != nil {
+					slog.Error(Name, "activate", err)
+					return
+				}
+			}
+
+			run = common.Quote(v.Path)
+		}
+	}
+
+	if run == "" {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown identifier or action: %s %s", identifier, action))
+		return
+	}
+
+	cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), run)))
+	common.Detach(cmd)
+
+	err := cmd.Start()
+	if err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+
+	if config.History {
+		h.Save(query, identifier)
+	}
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	res := []*pb.QueryResponse_Item{}
+
+	mu.RLock()
+	stale := time.Since(loaded) > 5*time.Minute
+	mu.RUnlock()
+
+	if stale {
+		load()
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, v := range flatpaks {
+		subtext := v.ID
+
+		if v.Version != "" {
+			subtext = fmt.Sprintf("%s %s", subtext, v.Version)
+		}
+
+		preview := fmt.Sprintf("%s\n%s\n\nVersion: %s\nBranch: %s\nOrigin: %s\nInstallation: %s", v.Name, v.ID, v.Version, v.Branch, v.Origin, v.Installation)
+
+		if p, ok := permissionsOf(v.ID); ok {
+			preview = fmt.Sprintf("%s\n\n%s", preview, p)
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier:  v.ID,
+			Text:        v.Name,
+			Subtext:     subtext,
+			Icon:        v.ID,
+			Provider:    Name,
+			Actions:     []string{ActionRun, ActionUpdate, ActionUninstall},
+			State:       []string{"flatpak", v.Installation},
+			Type:        pb.QueryResponse_REGULAR,
+			Preview:     preview,
+			PreviewType: util.PreviewTypeText,
+		}
+
+		if match(e, query, exact, v.Name, v.ID) {
+			res = append(res, e)
+		}
+	}
+
+	for _, v := range appimages {
+		actions := []string{ActionRun}
+		state := []string{"appimage"}
+
+		if !v.Executable {
+			actions = append(actions, ActionExecutable)
+			state = append(state, "not_executable")
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier:  appImageID(v),
+			Text:        v.Name,
+			Subtext:     v.Path,
+			Icon:        config.Icon,
+			Provider:    Name,
+			Actions:     actions,
+			State:       state,
+			Type:        pb.QueryResponse_REGULAR,
+			Preview:     fmt.Sprintf("%s\n%s\n\nSize: %.1f MB\nModified: %s\nExecutable: %t\n\nAppImages are not sandboxed and have the same permissions as your user.", v.Name, v.Path, float64(v.Size)/1024/1024, v.Modified.Format(time.DateTime), v.Executable),
+			PreviewType: util.PreviewTypeText,
+		}
+
+		if match(e, query, exact, v.Name, v.Path) {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+// match scores the entry against the query and applies the history.
+func match(e *pb.QueryResponse_Item, query string, exact bool, text, subtext string) bool {
+	if query != "" {
+		score, pos, start := common.FuzzyScore(query, text, exact)
+
+		e.Score = score
+		e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+			Field:     "text",
+			Positions: pos,
+			Start:     start,
+		}
+
+		if s2, p2, start2 := common.FuzzyScore(query, subtext, exact); s2 > score {
+			e.Score = s2
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Field:     "subtext",
+				Positions: p2,
+				Start:     start2,
+			}
+		}
+	}
+
+	if config.History {
+		if e.Score > config.MinScore || query == "" && config.HistoryWhenEmpty {
+			usageScore := h.CalcUsageScore(query, e.Identifier)
+
+			if usageScore != 0 {
+				e.State = append(e.State, "history")
+				e.Actions = append(e.Actions, history.ActionDelete)
+			}
+
+			e.Score = e.Score + usageScore
+		}
+	}
+
+	return e.Score > config.MinScore || query == ""
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
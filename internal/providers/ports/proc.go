@@ -0,0 +1,224 @@
+//go:build linux
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type Port struct {
+	Proto string
+	Addr  netip.Addr
+	Port  uint16
+	inode uint64
+	// Pid is 0 if the owner is unknown, f.e. for sockets of other users.
+	Pid     int
+	Process string
+	Cmdline string
+}
+
+// ID identifies the port as "<proto>/<address>:<port>".
+func (p Port) ID() string {
+	return fmt.Sprintf("%s/%s", p.Proto, p.Address())
+}
+
+func (p Port) Address() string {
+	return netip.AddrPortFrom(p.Addr, p.Port).String()
+}
+
+// Host is the address to connect to, wildcard addresses are reachable at localhost.
+func (p Port) Host() string {
+	if p.Addr.IsUnspecified() || p.Addr.IsLoopback() {
+		return net.JoinHostPort("localhost", strconv.Itoa(int(p.Port)))
+	}
+
+	return p.Address()
+}
+
+const (
+	tcpListen      = "0A"
+	udpUnconnected = "07"
+)
+
+// listening reads the listening tcp and bound udp sockets of /proc/net and finds their owners.
+func listening() ([]Port, error) {
+	res := []Port{}
+
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		ports, err := readNet(proto)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		res = append(res, ports...)
+	}
+
+	owners := socketOwners()
+
+	for k, v := range res {
+		pid, ok := owners[v.inode]
+		if !ok {
+			continue
+		}
+
+		res[k].Pid = pid
+		res[k].Process, res[k].Cmdline = process(pid)
+	}
+
+	slices.SortFunc(res, func(a, b Port) int {
+		if a.Port != b.Port {
+			return int(a.Port) - int(b.Port)
+		}
+
+		if c := strings.Compare(a.Proto, b.Proto); c != 0 {
+			return c
+		}
+
+		return a.Addr.Compare(b.Addr)
+	})
+
+	// with SO_REUSEPORT several sockets listen on the same address
+	return slices.CompactFunc(res, func(a, b Port) bool {
+		return a.ID() == b.ID()
+	}), nil
+}
+
+// readNet parses a file like /proc/net/tcp. Lines are
+// "sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...".
+func readNet(proto string) ([]Port, error) {
+	f, err := os.Open(filepath.Join("/proc/net", proto))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	state := tcpListen
+	if strings.HasPrefix(proto, "udp") {
+		state = udpUnconnected
+	}
+
+	res := []Port{}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan()
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != state {
+			continue
+		}
+
+		addr, port, err := parseAddress(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", proto, err)
+		}
+
+		inode, _ := strconv.ParseUint(fields[9], 10, 64)
+
+		res = append(res, Port{
+			Proto: strings.TrimSuffix(proto, "6"),
+			Addr:  addr,
+			Port:  port,
+			inode: inode,
+		})
+	}
+
+	return res, scanner.Err()
+}
+
+// parseAddress parses "0100007F:1F90". The address consists of 32 bit words in host byte order, the port is big
+// endian.
+func parseAddress(s string) (netip.Addr, uint16, error) {
+	a, p, ok := strings.Cut(s, ":")
+	if !ok {
+		return netip.Addr{}, 0, fmt.Errorf("invalid address: %s", s)
+	}
+
+	port, err := strconv.ParseUint(p, 16, 16)
+	if err != nil {
+		return netip.Addr{}, 0, fmt.Errorf("invalid address: %s", s)
+	}
+
+	b, err := hex.DecodeString(a)
+	if err != nil || (len(b) != 4 && len(b) != 16) {
+		return netip.Addr{}, 0, fmt.Errorf("invalid address: %s", s)
+	}
+
+	for i := 0; i < len(b); i += 4 {
+		binary.BigEndian.PutUint32(b[i:], binary.NativeEndian.Uint32(b[i:]))
+	}
+
+	addr, _ := netip.AddrFromSlice(b)
+
+	return addr.Unmap(), uint16(port), nil
+}
+
+// socketOwners maps socket inodes to the pids having them open. Only processes of the same user can be read.
+func socketOwners() map[uint64]int {
+	res := map[uint64]int{}
+
+	dirs, err := os.ReadDir("/proc")
+	if err != nil {
+		return res
+	}
+
+	for _, d := range dirs {
+		pid, err := strconv.Atoi(d.Name())
+		if err != nil {
+			continue
+		}
+
+		fds, err := os.ReadDir(filepath.Join("/proc", d.Name(), "fd"))
+		if err != nil {
+			continue
+		}
+
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join("/proc", d.Name(), "fd", fd.Name()))
+			if err != nil {
+				continue
+			}
+
+			v, ok := strings.CutPrefix(link, "socket:[")
+			if !ok {
+				continue
+			}
+
+			inode, err := strconv.ParseUint(strings.TrimSuffix(v, "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+
+			// forked servers share the socket, the parent usually has the lowest pid
+			if v, ok := res[inode]; !ok || pid < v {
+				res[inode] = pid
+			}
+		}
+	}
+
+	return res
+}
+
+// process returns the name and command line of the process.
+func process(pid int) (string, string) {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+
+	comm, _ := os.ReadFile(filepath.Join(dir, "comm"))
+	cmdline, _ := os.ReadFile(filepath.Join(dir, "cmdline"))
+
+	return strings.TrimSpace(string(comm)), strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+}
@@ -0,0 +1,265 @@
+//go:build linux
+
+// Package ports lists listening tcp and udp ports with the processes owning them.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "ports"
+	NamePretty = "Ports"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	UDP           bool `koanf:"udp" desc:"list bound udp ports as well" default:"true"`
+	Unknown       bool `koanf:"unknown" desc:"list ports of processes that can't be read, f.e. of other users" default:"true"`
+}
+
+const (
+	ActionOpen      = "open"
+	ActionCopy      = "copy"
+	ActionKill      = "kill"
+	ActionForceKill = "force_kill"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "network-server",
+			MinScore: 20,
+		},
+		UDP:     true,
+		Unknown: true,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionOpen, Label: "Open in browser", Icon: "web-browser", Default: true},
+		{Action: ActionCopy, Label: "Copy address", Icon: "edit-copy"},
+		{Action: ActionKill, Label: "Kill owner", Icon: "process-stop"},
+		{Action: ActionForceKill, Label: "Force kill owner", Icon: "process-stop"},
+	}
+}
+
+func find(identifier string) (Port, bool) {
+	ports, err := listening()
+	if err != nil {
+		slog.Error(Name, "ports", err)
+		return Port{}, false
+	}
+
+	for _, v := range ports {
+		if v.ID() == identifier {
+			return v, true
+		}
+	}
+
+	return Port{}, false
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	p, ok := find(identifier)
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("not listening anymore: %s", identifier))
+		return
+	}
+
+	if action == "" {
+		action = ActionOpen
+
+		if p.Proto != "tcp" {
+			action = ActionCopy
+		}
+	}
+
+	var cmd *exec.Cmd
+
+	switch action {
+	case ActionOpen:
+		cmd = common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix("", Name, identifier), common.OpenCommand(), common.Quote("http://"+p.Host()))))
+		common.Detach(cmd)
+	case ActionCopy:
+		cmd = common.CopyCmd(p.Host())
+	case ActionKill, ActionForceKill:
+		if p.Pid == 0 {
+			slog.Error(Name, "kill", fmt.Sprintf("owner of %s unknown", identifier))
+			return
+		}
+
+		sig := syscall.SIGTERM
+		if action == ActionForceKill {
+			sig = syscall.SIGKILL
+		}
+
+		if err := syscall.Kill(p.Pid, sig); err != nil {
+			slog.Error(Name, "kill", err, "pid", p.Pid)
+			return
+		}
+
+		slog.Info(Name, "killed", p.Process, "pid", p.Pid, "signal", sig)
+
+		// give the process time to close the socket
+		go func() {
+			time.Sleep(500 * time.Millisecond)
+			handlers.ProviderUpdated <- Name
+		}()
+
+		return
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	if err := cmd.Start(); err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	ports, err := listening()
+	if err != nil {
+		slog.Error(Name, "ports", err)
+		return entries
+	}
+
+	query = strings.TrimPrefix(strings.TrimSpace(query), ":")
+
+	for k, v := range ports {
+		if (!config.UDP && v.Proto == "udp") || (!config.Unknown && v.Pid == 0) {
+			continue
+		}
+
+		process := v.Process
+		if v.Pid == 0 {
+			process = "unknown"
+		}
+
+		text := fmt.Sprintf("%d %s", v.Port, process)
+		subtext := fmt.Sprintf("%s · %s", v.Proto, v.Addr)
+
+		if v.Pid != 0 {
+			subtext = fmt.Sprintf("%s · pid %d", subtext, v.Pid)
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier:  v.ID(),
+			Text:        text,
+			Subtext:     subtext,
+			Provider:    Name,
+			Icon:        config.Icon,
+			State:       []string{v.Proto},
+			Actions:     []string{ActionCopy},
+			Type:        pb.QueryResponse_REGULAR,
+			Score:       int32(len(ports) - k),
+			Preview:     v.Cmdline,
+			PreviewType: util.PreviewTypeText,
+		}
+
+		if v.Proto == "tcp" {
+			e.Actions = append([]string{ActionOpen}, e.Actions...)
+		}
+
+		if v.Pid != 0 {
+			e.Actions = append(e.Actions, ActionKill, ActionForceKill)
+		}
+
+		if query != "" {
+			// ports are searched by prefix, exact ones first
+			if port := fmt.Sprint(v.Port); strings.HasPrefix(port, query) {
+				e.Score = 500 + e.Score
+
+				if port == query {
+					e.Score = 1000
+				}
+
+				pos := []int32{}
+				for i := range len(query) {
+					pos = append(pos, int32(i))
+				}
+
+				e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+					Field:     "text",
+					Positions: pos,
+				}
+			} else {
+				score, pos, start := common.FuzzyScore(query, text, exact)
+
+				// matches of the command line don't highlight the text
+				if s, _, _ := common.FuzzyScore(query, v.Cmdline, exact); s > score {
+					score, pos, start = s, nil, 0
+				}
+
+				if score <= config.MinScore {
+					continue
+				}
+
+				e.Score = score
+				e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+					Start:     start,
+					Field:     "text",
+					Positions: pos,
+				}
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
@@ -0,0 +1,99 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// conservation keeps the battery from charging fully, which extends its lifetime on laptops plugged in
+// most of the time.
+type conservation interface {
+	enabled() bool
+	set(on bool) error
+	description() string
+}
+
+// detectConservation returns the supported mechanism, nil if there's none.
+func detectConservation() conservation {
+	// Lenovo IdeaPads stop charging at about 60%
+	if files, _ := filepath.Glob("/sys/bus/platform/drivers/ideapad_acpi/*/conservation_mode"); len(files) > 0 {
+		return ideapad{file: files[0]}
+	}
+
+	if files, _ := filepath.Glob("/sys/class/power_supply/BAT*/charge_control_end_threshold"); len(files) > 0 {
+		return threshold{file: files[0]}
+	}
+
+	return nil
+}
+
+type ideapad struct {
+	file string
+}
+
+func (i ideapad) enabled() bool {
+	return readFile(i.file) == "1"
+}
+
+func (i ideapad) set(on bool) error {
+	v := "0"
+	if on {
+		v = "1"
+	}
+
+	return writeFile(i.file, v)
+}
+
+func (i ideapad) description() string {
+	return "charging stops at about 60%"
+}
+
+// threshold is the charge limit of the kernel, supported f.e. by ThinkPads and ASUS laptops.
+type threshold struct {
+	file string
+}
+
+func (t threshold) enabled() bool {
+	v, err := strconv.Atoi(readFile(t.file))
+
+	return err == nil && v < 100
+}
+
+func (t threshold) set(on bool) error {
+	v := 100
+	if on {
+		v = config.ChargeLimit
+	}
+
+	return writeFile(t.file, strconv.Itoa(v))
+}
+
+func (t threshold) description() string {
+	return "charging stops at " + strconv.Itoa(config.ChargeLimit) + "%"
+}
+
+func readFile(file string) string {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(b))
+}
+
+// writeFile writes the sysfs file, via pkexec if elephant isn't allowed to, f.e. without udev rule.
+func writeFile(file, value string) error {
+	if err := os.WriteFile(file, []byte(value), 0o644); err == nil {
+		return nil
+	}
+
+	cmd := exec.Command("pkexec", "tee", file)
+	cmd.Stdin = strings.NewReader(value)
+
+	return cmd.Run()
+}
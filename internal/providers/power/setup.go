@@ -0,0 +1,303 @@
+//go:build linux
+
+// Package power shows the battery and switches power profiles.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"github.com/godbus/dbus/v5"
+)
+
+var (
+	Name       = "power"
+	NamePretty = "Power"
+	config     *Config
+	conserve   conservation
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	ChargeLimit   int `koanf:"charge_limit" desc:"charge in percent at which charging stops with conservation mode, for batteries with a charge threshold" default:"80"`
+}
+
+const (
+	ActionSetProfile         = "set_profile"
+	ActionToggleConservation = "toggle_conservation"
+
+	itemBattery      = "battery"
+	itemConservation = "conservation"
+	profilePrefix    = "profile:"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "battery",
+			MinScore: 20,
+		},
+		ChargeLimit: 80,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	conserve = detectConservation()
+
+	if err := connectBus(); err != nil {
+		slog.Error(Name, "dbus", err)
+	}
+}
+
+func Available() bool {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		slog.Info(Name, "available", "system bus not available. disabling")
+		return false
+	}
+
+	var running bool
+
+	err = conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, upower).Store(&running)
+	if err != nil || !running {
+		slog.Info(Name, "available", "upower not running. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionSetProfile, Label: "Switch profile", Icon: "power-profile-balanced-symbolic", Default: true},
+		{Action: ActionToggleConservation, Label: "Toggle conservation mode", Icon: "battery-good-charging-symbolic"},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionSetProfile
+
+		if identifier == itemConservation {
+			action = ActionToggleConservation
+		}
+	}
+
+	switch action {
+	case ActionSetProfile:
+		profile, ok := strings.CutPrefix(identifier, profilePrefix)
+		if !ok || profileDaemon < 0 {
+			slog.Error(Name, "activate", fmt.Sprintf("no profile: %s", identifier))
+			return
+		}
+
+		if err := setProfile(profile); err != nil {
+			slog.Error(Name, "activate", err)
+			return
+		}
+	case ActionToggleConservation:
+		if conserve == nil {
+			slog.Error(Name, "activate", "conservation mode not supported")
+			return
+		}
+
+		if err := conserve.set(!conserve.enabled()); err != nil {
+			slog.Error(Name, "activate", err)
+			return
+		}
+
+		// sysfs has no change signals
+		handlers.ProviderUpdated <- Name
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+func batteryState(b Battery) string {
+	switch b.State {
+	case stateCharging, statePendingCharge:
+		return "charging"
+	case stateFullyCharged:
+		return "full"
+	case stateEmpty:
+		return "empty"
+	default:
+		return "discharging"
+	}
+}
+
+func batterySubtext(b Battery) string {
+	switch {
+	case b.State == stateCharging && b.TimeToFull > 0:
+		return fmt.Sprintf("Charging, full in %s", formatDuration(b.TimeToFull))
+	case b.State == stateDischarging && b.TimeToEmpty > 0:
+		return fmt.Sprintf("Discharging, %s remaining", formatDuration(b.TimeToEmpty))
+	case b.State == stateFullyCharged:
+		return "Fully charged"
+	case b.State == statePendingCharge, b.State == statePendingDischarge:
+		return "Plugged in, not charging"
+	default:
+		return strings.ToUpper(batteryState(b)[:1]) + batteryState(b)[1:]
+	}
+}
+
+// formatDuration formats seconds like "2h 15m".
+func formatDuration(seconds int64) string {
+	d := (time.Duration(seconds) * time.Second).Round(time.Minute)
+
+	if h := int(d.Hours()); h > 0 {
+		return fmt.Sprintf("%dh %dm", h, int(d.Minutes())%60)
+	}
+
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
+
+// profileLabel turns "power-saver" into "Power saver".
+func profileLabel(profile string) string {
+	s := strings.ReplaceAll(profile, "-", " ")
+
+	if s == "" {
+		return s
+	}
+
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	if b := battery(); b.Present {
+		entries = append(entries, &pb.QueryResponse_Item{
+			Identifier: itemBattery,
+			Text:       fmt.Sprintf("Battery %.0f%%", b.Percentage),
+			Subtext:    batterySubtext(b),
+			Icon:       config.Icon,
+			Provider:   Name,
+			State:      []string{batteryState(b)},
+			Type:       pb.QueryResponse_REGULAR,
+		})
+	}
+
+	available, active := profiles()
+
+	for _, v := range available {
+		e := &pb.QueryResponse_Item{
+			Identifier: profilePrefix + v,
+			Text:       profileLabel(v),
+			Subtext:    "Power profile",
+			Icon:       fmt.Sprintf("power-profile-%s-symbolic", v),
+			Provider:   Name,
+			Actions:    []string{ActionSetProfile},
+			Type:       pb.QueryResponse_REGULAR,
+		}
+
+		if v == active {
+			e.State = []string{"active"}
+		}
+
+		entries = append(entries, e)
+	}
+
+	if conserve != nil {
+		state := "off"
+		if conserve.enabled() {
+			state = "on"
+		}
+
+		entries = append(entries, &pb.QueryResponse_Item{
+			Identifier: itemConservation,
+			Text:       "Battery conservation",
+			Subtext:    fmt.Sprintf("%s, %s", state, conserve.description()),
+			Icon:       "battery-good-charging-symbolic",
+			Provider:   Name,
+			Actions:    []string{ActionToggleConservation},
+			State:      []string{state},
+			Type:       pb.QueryResponse_REGULAR,
+		})
+	}
+
+	res := entries[:0]
+
+	for k, e := range entries {
+		e.Score = int32(len(entries) - k)
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, e.Text, exact)
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		res = append(res, e)
+	}
+
+	return res
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+// State contains the current values as '<kind>:<value>', f.e. 'profile:balanced'.
+func State(provider string) *pb.ProviderStateResponse {
+	states := []string{}
+	actions := []string{}
+
+	if b := battery(); b.Present {
+		states = append(states, fmt.Sprintf("battery:%s", batteryState(b)))
+	}
+
+	if _, active := profiles(); active != "" {
+		states = append(states, fmt.Sprintf("profile:%s", active))
+		actions = append(actions, ActionSetProfile)
+	}
+
+	if conserve != nil {
+		state := "off"
+		if conserve.enabled() {
+			state = "on"
+		}
+
+		states = append(states, fmt.Sprintf("conservation:%s", state))
+		actions = append(actions, ActionToggleConservation)
+	}
+
+	return &pb.ProviderStateResponse{
+		States:  states,
+		Actions: actions,
+	}
+}
@@ -0,0 +1,173 @@
+//go:build linux
+
+package main
+
+import (
+	"log/slog"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	upower        = "org.freedesktop.UPower"
+	displayDevice = "/org/freedesktop/UPower/devices/DisplayDevice"
+	deviceIface   = "org.freedesktop.UPower.Device"
+	properties    = "org.freedesktop.DBus.Properties"
+)
+
+// power-profiles-daemon moved to the UPower namespace in 0.20, older versions only have the hadess names.
+var profileDaemons = []struct {
+	name  string
+	path  dbus.ObjectPath
+	iface string
+}{
+	{"org.freedesktop.UPower.PowerProfiles", "/org/freedesktop/UPower/PowerProfiles", "org.freedesktop.UPower.PowerProfiles"},
+	{"net.hadess.PowerProfiles", "/net/hadess/PowerProfiles", "net.hadess.PowerProfiles"},
+}
+
+// UPower device states.
+const (
+	stateCharging         = 1
+	stateDischarging      = 2
+	stateEmpty            = 3
+	stateFullyCharged     = 4
+	statePendingCharge    = 5
+	statePendingDischarge = 6
+)
+
+type Battery struct {
+	Present     bool
+	Percentage  float64
+	State       uint32
+	TimeToEmpty int64
+	TimeToFull  int64
+}
+
+var (
+	bus *dbus.Conn
+	// index into profileDaemons, -1 without power-profiles-daemon
+	profileDaemon = -1
+)
+
+func connectBus() error {
+	var err error
+
+	bus, err = dbus.ConnectSystemBus()
+	if err != nil {
+		return err
+	}
+
+	for i, v := range profileDaemons {
+		if hasOwner(v.name) {
+			profileDaemon = i
+			break
+		}
+	}
+
+	err = bus.AddMatchSignal(
+		dbus.WithMatchInterface(properties),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchObjectPath(displayDevice),
+	)
+	if err != nil {
+		return err
+	}
+
+	if profileDaemon >= 0 {
+		err = bus.AddMatchSignal(
+			dbus.WithMatchInterface(properties),
+			dbus.WithMatchMember("PropertiesChanged"),
+			dbus.WithMatchObjectPath(profileDaemons[profileDaemon].path),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	signals := make(chan *dbus.Signal, 10)
+	bus.Signal(signals)
+
+	go func() {
+		for range signals {
+			handlers.ProviderUpdated <- Name
+		}
+	}()
+
+	return nil
+}
+
+func hasOwner(name string) bool {
+	var running bool
+
+	if err := bus.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, name).Store(&running); err != nil {
+		return false
+	}
+
+	return running
+}
+
+// battery returns the combined battery of UPower, Present is false without battery.
+func battery() Battery {
+	res := Battery{}
+
+	if bus == nil {
+		return res
+	}
+
+	var props map[string]dbus.Variant
+
+	err := bus.Object(upower, displayDevice).Call(properties+".GetAll", 0, deviceIface).Store(&props)
+	if err != nil {
+		slog.Error(Name, "battery", err)
+		return res
+	}
+
+	res.Present, _ = props["IsPresent"].Value().(bool)
+	res.Percentage, _ = props["Percentage"].Value().(float64)
+	res.State, _ = props["State"].Value().(uint32)
+	res.TimeToEmpty, _ = props["TimeToEmpty"].Value().(int64)
+	res.TimeToFull, _ = props["TimeToFull"].Value().(int64)
+
+	return res
+}
+
+// profiles returns the available power profiles and the active one.
+func profiles() ([]string, string) {
+	if bus == nil || profileDaemon < 0 {
+		return nil, ""
+	}
+
+	d := profileDaemons[profileDaemon]
+	obj := bus.Object(d.name, d.path)
+
+	var active string
+
+	if err := obj.Call(properties+".Get", 0, d.iface, "ActiveProfile").Store(&active); err != nil {
+		slog.Error(Name, "profiles", err)
+		return nil, ""
+	}
+
+	var available []map[string]dbus.Variant
+
+	if err := obj.Call(properties+".Get", 0, d.iface, "Profiles").Store(&available); err != nil {
+		slog.Error(Name, "profiles", err)
+		return nil, ""
+	}
+
+	res := []string{}
+
+	for _, v := range available {
+		if p, ok := v["Profile"].Value().(string); ok {
+			res = append(res, p)
+		}
+	}
+
+	return res, active
+}
+
+func setProfile(profile string) error {
+	d := profileDaemons[profileDaemon]
+
+	return bus.Object(d.name, d.path).Call(properties+".Set", 0, d.iface, "ActiveProfile", dbus.MakeVariant(profile)).Err
+}
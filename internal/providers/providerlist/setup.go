@@ -3,12 +3,15 @@ package main
 import (
 	_ "embed"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
 	"github.com/abenz1267/elephant/v2/internal/providers"
 	"github.com/abenz1267/elephant/v2/internal/util"
 	"github.com/abenz1267/elephant/v2/pkg/common"
@@ -19,14 +22,32 @@ var (
 	Name       = "providerlist"
 	NamePretty = "Providerlist"
 	config     *Config
+	counts     = make(map[string]count)
+	docs       = make(map[string]string)
+	mu         sync.Mutex
 )
 
+const (
+	ActionActivate = "activate"
+	ActionRefresh  = "refresh"
+	ActionDisable  = "disable"
+	ActionEnable   = "enable"
+
+	countTTL = 5 * time.Minute
+)
+
+type count struct {
+	items   int
+	counted time.Time
+}
+
 //go:embed README.md
 var readme string
 
 type Config struct {
 	common.Config `koanf:",squash"`
 	Hidden        []string `koanf:"hidden" desc:"hidden providers" default:"<empty>"`
+	Unavailable   bool     `koanf:"unavailable" desc:"list unavailable and disabled providers when querying the providerlist directly" default:"true"`
 }
 
 func Setup() {
@@ -35,7 +56,8 @@ func Setup() {
 			Icon:     "applications-other",
 			MinScore: 10,
 		},
-		Hidden: []string{},
+		Hidden:      []string{},
+		Unavailable: true,
 	}
 
 	common.LoadConfig(Name, config)
@@ -49,20 +71,41 @@ func Available() bool {
 	return true
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	switch action {
+	case ActionActivate:
+		// switching to the provider is up to the client.
+	case ActionRefresh:
+		go func() {
+			if !providers.Refresh(identifier) {
+				slog.Error(Name, "refresh", fmt.Sprintf("provider can't be refreshed: %s", identifier))
+				return
+			}
+
+			mu.Lock()
+			delete(counts, identifier)
+			mu.Unlock()
+		}()
+	case ActionDisable:
+		providers.Disable(identifier)
+	case ActionEnable:
+		providers.Enable(identifier)
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
 }
 
-func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+func Query(conn net.Conn, query string, single bool, exact bool, format uint8) []*pb.QueryResponse_Item {
 	start := time.Now()
 	entries := []*pb.QueryResponse_Item{}
 
-	for _, v := range providers.Providers {
+	for _, v := range providers.All() {
 		if *v.Name == Name || v.HideFromProviderlist() {
 			continue
 		}
@@ -80,7 +123,7 @@ func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.
 					Text:       v.NamePretty,
 					Subtext:    v.Description,
 					Provider:   Name,
-					Actions:    []string{"activate"},
+					Actions:    []string{ActionActivate},
 					Type:       pb.QueryResponse_REGULAR,
 					Icon:       v.Icon,
 				}
@@ -112,13 +155,30 @@ func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.
 				continue
 			}
 
+			actions := []string{ActionActivate, ActionDisable}
+
+			if v.Refresh != nil {
+				actions = append(actions, ActionRefresh)
+			}
+
 			e := &pb.QueryResponse_Item{
-				Identifier: *v.Name,
-				Text:       *v.NamePretty,
-				Icon:       v.Icon(),
-				Provider:   Name,
-				Actions:    []string{"activate"},
-				Type:       pb.QueryResponse_REGULAR,
+				Identifier:  *v.Name,
+				Text:        *v.NamePretty,
+				Icon:        v.Icon(),
+				Provider:    Name,
+				Actions:     actions,
+				Type:        pb.QueryResponse_REGULAR,
+				Preview:     doc(*v.Name),
+				PreviewType: util.PreviewTypeText,
+			}
+
+			e.Subtext = subtext(v, query, format, conn, e)
+
+			for _, s := range providers.Statuses() {
+				if s.Name == *v.Name && s.Unhealthy {
+					e.State = []string{"unhealthy"}
+					e.Subtext = fmt.Sprintf("unhealthy: %s, restarted %d times", s.LastError, s.Restarts)
+				}
 			}
 
 			if query != "" {
@@ -135,6 +195,10 @@ func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.
 		}
 	}
 
+	if single && config.Unavailable {
+		entries = append(entries, unavailable(query, exact)...)
+	}
+
 	slices.SortFunc(entries, func(a, b *pb.QueryResponse_Item) int {
 		if a.Score > b.Score {
 			return 1
@@ -152,6 +216,160 @@ func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.
 	return entries
 }
 
+// unavailable lists providers that were found, but aren't loaded.
+func unavailable(query string, exact bool) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	for _, v := range providers.Statuses() {
+		if v.Available && !v.Disabled || slices.Contains(config.Hidden, v.Name) {
+			continue
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier:  v.Name,
+			Text:        v.Name,
+			Subtext:     v.Reason,
+			Icon:        config.Icon,
+			Provider:    Name,
+			Actions:     []string{},
+			State:       []string{"unavailable"},
+			Type:        pb.QueryResponse_REGULAR,
+			Preview:     doc(v.Name),
+			PreviewType: util.PreviewTypeText,
+		}
+
+		if v.Disabled {
+			e.State = []string{"disabled"}
+			e.Actions = []string{ActionEnable}
+		}
+
+		if query != "" {
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Field: "text",
+			}
+
+			e.Score, e.Fuzzyinfo.Positions, e.Fuzzyinfo.Start = common.FuzzyScore(query, e.Text, exact)
+		}
+
+		if e.Score > config.MinScore || query == "" {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+// subtext shows the amount of items and the last refresh. Outdated counts are updated in the background.
+func subtext(p providers.Provider, query string, format uint8, conn net.Conn, e *pb.QueryResponse_Item) string {
+	name := *p.Name
+	refreshed := ""
+
+	for _, v := range providers.Statuses() {
+		if v.Name == name && !v.Refreshed.IsZero() {
+			refreshed = fmt.Sprintf("refreshed %s", since(v.Refreshed))
+		}
+	}
+
+	mu.Lock()
+	c, ok := counts[name]
+	fresh := ok && time.Since(c.counted) < countTTL
+
+	if !fresh {
+		// mark as counted, so concurrent queries don't count as well.
+		counts[name] = count{items: c.items, counted: time.Now()}
+	}
+	mu.Unlock()
+
+	text := func(c count, ok bool) string {
+		if !ok {
+			return refreshed
+		}
+
+		if refreshed == "" {
+			return fmt.Sprintf("%d items", c.items)
+		}
+
+		return fmt.Sprintf("%d items, %s", c.items, refreshed)
+	}
+
+	if !fresh {
+		item := &pb.QueryResponse_Item{
+			Identifier:  e.Identifier,
+			Text:        e.Text,
+			Icon:        e.Icon,
+			Provider:    e.Provider,
+			Actions:     e.Actions,
+			Type:        e.Type,
+			Preview:     e.Preview,
+			PreviewType: e.PreviewType,
+		}
+
+		go func() {
+			c := count{items: countItems(p), counted: time.Now()}
+
+			mu.Lock()
+			counts[name] = c
+			mu.Unlock()
+
+			if conn != nil {
+				item.Subtext = text(c, true)
+				handlers.UpdateItem(format, query, conn, item)
+			}
+		}()
+	}
+
+	return text(c, ok)
+}
+
+// countItems queries the provider without a query. Async updates of the provider go nowhere.
+func countItems(p providers.Provider) int {
+	if *p.Name == "menus" {
+		n := 0
+
+		for _, v := range common.Menus {
+			n += len(v.Entries)
+		}
+
+		return n
+	}
+
+	local, remote := net.Pipe()
+
+	go io.Copy(io.Discard, remote)
+
+	defer local.Close()
+
+	return len(p.Query(local, "", true, false, 0))
+}
+
+func since(t time.Time) string {
+	d := time.Since(t)
+
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+func doc(name string) string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if d, ok := docs[name]; ok {
+		return d
+	}
+
+	docs[name] = strings.TrimSpace(providers.Doc(name))
+
+	return docs[name]
+}
+
 func Icon() string {
 	return ""
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	client = http.Client{Timeout: 10 * time.Second}
+
+	// found remembers stations returned by searches, so they can be activated by their uuid.
+	foundMu sync.Mutex
+	found   = map[string]Station{}
+)
+
+type browserStation struct {
+	UUID        string `json:"stationuuid"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	URLResolved string `json:"url_resolved"`
+	Tags        string `json:"tags"`
+	Country     string `json:"country"`
+	Codec       string `json:"codec"`
+	Bitrate     int    `json:"bitrate"`
+}
+
+// browserGet requests the path of the radio-browser.info api.
+func browserGet(path string, query url.Values) ([]Station, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(config.RadioBrowserURL, "/")+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "elephant")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("radio-browser: %s", resp.Status)
+	}
+
+	var stations []browserStation
+
+	if err := json.NewDecoder(resp.Body).Decode(&stations); err != nil {
+		return nil, fmt.Errorf("radio-browser: %w", err)
+	}
+
+	res := []Station{}
+
+	foundMu.Lock()
+	defer foundMu.Unlock()
+
+	// older results are requested by uuid again if needed
+	if len(found) > 1000 {
+		clear(found)
+	}
+
+	for _, v := range stations {
+		s := Station{
+			Name: strings.TrimSpace(v.Name),
+			URL:  v.URLResolved,
+			Tags: v.Tags,
+			id:   "rb:" + v.UUID,
+		}
+
+		if s.URL == "" {
+			s.URL = v.URL
+		}
+
+		details := []string{}
+
+		for _, d := range []string{v.Country, v.Codec} {
+			if d != "" {
+				details = append(details, d)
+			}
+		}
+
+		if v.Bitrate > 0 {
+			details = append(details, fmt.Sprintf("%dkbps", v.Bitrate))
+		}
+
+		s.details = strings.Join(details, " · ")
+
+		found[s.id] = s
+		res = append(res, s)
+	}
+
+	return res, nil
+}
+
+// search finds stations by name, most popular first.
+func search(query string) ([]Station, error) {
+	return browserGet("/json/stations/search", url.Values{
+		"name":       {query},
+		"limit":      {strconv.Itoa(config.MaxResults)},
+		"hidebroken": {"true"},
+		"order":      {"clickcount"},
+		"reverse":    {"true"},
+	})
+}
+
+// lookup returns a station found by a search. Unknown ones, f.e. after a restart, are requested by uuid.
+func lookup(id string) (Station, error) {
+	foundMu.Lock()
+	s, ok := found[id]
+	foundMu.Unlock()
+
+	if ok {
+		return s, nil
+	}
+
+	res, err := browserGet("/json/stations/byuuid", url.Values{"uuids": {strings.TrimPrefix(id, "rb:")}})
+	if err != nil {
+		return Station{}, err
+	}
+
+	if len(res) == 0 {
+		return Station{}, fmt.Errorf("unknown station: %s", id)
+	}
+
+	return res[0], nil
+}
@@ -0,0 +1,339 @@
+// Package radio plays internet radio stations, configured ones or found on radio-browser.info.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/jobs"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "radio"
+	NamePretty = "Radio"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config   `koanf:",squash"`
+	Stations        []Station `koanf:"stations" desc:"stations to list" default:"[]"`
+	PlayCommand     string    `koanf:"play_command" desc:"plays a stream, %URL% is replaced" default:"mpv --no-video --force-window=no %URL%"`
+	RadioBrowser    bool      `koanf:"radio_browser" desc:"search stations on radio-browser.info. sends the query to the api" default:"false"`
+	RadioBrowserURL string    `koanf:"radio_browser_url" desc:"api server of radio-browser.info" default:"https://all.api.radio-browser.info"`
+	MinQueryLength  int       `koanf:"min_query_length" desc:"minimum query length to search radio-browser.info" default:"3"`
+	MaxResults      int       `koanf:"max_results" desc:"maximum number of stations found on radio-browser.info" default:"20"`
+}
+
+type Station struct {
+	Name string `koanf:"name" desc:"name of the station" default:""`
+	URL  string `koanf:"url" desc:"url of the stream or playlist" default:""`
+	Tags string `koanf:"tags" desc:"comma separated tags, matched by queries as well" default:""`
+
+	id      string
+	details string
+}
+
+const (
+	ActionPlay = "play"
+	ActionStop = "stop"
+
+	StatePlaying = "playing"
+	StateStopped = "stopped"
+)
+
+// the station playing and its job, title is the current track as reported by the stream
+var (
+	playerMu sync.Mutex
+	playing  *Station
+	jobID    uint32
+	title    string
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "radio",
+			MinScore: 20,
+		},
+		Stations:        []Station{},
+		PlayCommand:     "mpv --no-video --force-window=no %URL%",
+		RadioBrowserURL: "https://all.api.radio-browser.info",
+		MinQueryLength:  3,
+		MaxResults:      20,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	for k := range config.Stations {
+		config.Stations[k].id = "station:" + config.Stations[k].Name
+	}
+
+	jobs.Listen(jobEvent)
+}
+
+func Available() bool {
+	if len(config.Stations) == 0 && !config.RadioBrowser {
+		slog.Info(Name, "available", "no stations configured and radio_browser disabled. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionPlay, Label: "Play", Icon: "media-playback-start", Default: true},
+		{Action: ActionStop, Label: "Stop", Icon: "media-playback-stop"},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionPlay
+	}
+
+	switch action {
+	case ActionStop:
+		stop()
+	case ActionPlay:
+		s, err := station(identifier)
+		if err != nil {
+			slog.Error(Name, "activate", err)
+			return
+		}
+
+		if err := play(s); err != nil {
+			slog.Error(Name, "play", err)
+			return
+		}
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	handlers.ProviderUpdated <- Name
+}
+
+func station(identifier string) (Station, error) {
+	if strings.HasPrefix(identifier, "rb:") {
+		return lookup(identifier)
+	}
+
+	for _, v := range config.Stations {
+		if v.id == identifier {
+			return v, nil
+		}
+	}
+
+	return Station{}, fmt.Errorf("unknown station: %s", identifier)
+}
+
+// play stops the current station and starts the stream as a job, so it's listed and can be cancelled there as
+// well.
+func play(s Station) error {
+	stop()
+
+	id, err := jobs.Start(Name, "Radio: "+s.Name, strings.ReplaceAll(config.PlayCommand, "%URL%", common.Quote(s.URL)))
+	if err != nil {
+		return err
+	}
+
+	playerMu.Lock()
+	playing, jobID, title = &s, id, ""
+	playerMu.Unlock()
+
+	// the stream might have failed before its job was known
+	if j, ok := jobs.Get(id); !ok || j.State != jobs.StateRunning {
+		playerMu.Lock()
+		if jobID == id {
+			playing, jobID, title = nil, 0, ""
+		}
+		playerMu.Unlock()
+
+		return fmt.Errorf("%s: stream ended", s.Name)
+	}
+
+	return nil
+}
+
+func stop() {
+	playerMu.Lock()
+	id := jobID
+	playerMu.Unlock()
+
+	if id != 0 {
+		jobs.Cancel(id)
+	}
+}
+
+// jobEvent follows the player job. mpv prints the track title of icecast streams as "icy-title: ...".
+func jobEvent(e jobs.Event) {
+	if e.Job.Provider != Name {
+		return
+	}
+
+	playerMu.Lock()
+
+	if e.Job.ID != jobID {
+		playerMu.Unlock()
+		return
+	}
+
+	switch {
+	case e.Job.State != jobs.StateRunning:
+		playing, jobID, title = nil, 0, ""
+	case e.Line != "":
+		t, ok := strings.CutPrefix(strings.TrimSpace(e.Line), "icy-title:")
+		if !ok || strings.TrimSpace(t) == title {
+			playerMu.Unlock()
+			return
+		}
+
+		title = strings.TrimSpace(t)
+	}
+
+	playerMu.Unlock()
+
+	handlers.ProviderUpdated <- Name
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	playerMu.Lock()
+	current := ""
+	if playing != nil {
+		current = playing.id
+	}
+	playerMu.Unlock()
+
+	for k, v := range config.Stations {
+		e := item(v, current)
+		e.Score = int32(len(config.Stations) - k)
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, v.Name, exact)
+
+			// matches of tags don't highlight the name
+			if s, _, _ := common.FuzzyScore(query, v.Tags, exact); s > score {
+				score, pos, start = s, nil, 0
+			}
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	if !config.RadioBrowser || len(query) < config.MinQueryLength {
+		return entries
+	}
+
+	found, err := search(query)
+	if err != nil {
+		slog.Error(Name, "search", err)
+		return entries
+	}
+
+	for k, v := range found {
+		e := item(v, current)
+
+		// the api filtered and sorted by popularity, configured stations come first
+		e.Score = int32(len(found) - k)
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func item(s Station, current string) *pb.QueryResponse_Item {
+	subtext := []string{}
+
+	for _, v := range []string{s.details, s.Tags} {
+		if v != "" {
+			subtext = append(subtext, v)
+		}
+	}
+
+	e := &pb.QueryResponse_Item{
+		Identifier: s.id,
+		Text:       s.Name,
+		Subtext:    strings.Join(subtext, " · "),
+		Provider:   Name,
+		Icon:       config.Icon,
+		State:      []string{StateStopped},
+		Actions:    []string{ActionPlay},
+		Type:       pb.QueryResponse_REGULAR,
+	}
+
+	if s.id == current {
+		e.State = []string{StatePlaying}
+		e.Actions = []string{ActionStop}
+	}
+
+	return e
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+// State is "playing" with "station:<name>" and "title:<track>", if the stream reports it, or "stopped".
+func State(provider string) *pb.ProviderStateResponse {
+	playerMu.Lock()
+	defer playerMu.Unlock()
+
+	if playing == nil {
+		return &pb.ProviderStateResponse{
+			States:  []string{StateStopped},
+			Actions: []string{},
+		}
+	}
+
+	states := []string{StatePlaying, "station:" + playing.Name}
+
+	if title != "" {
+		states = append(states, "title:"+title)
+	}
+
+	return &pb.ProviderStateResponse{
+		States:  states,
+		Actions: []string{ActionStop},
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Request struct {
+	File string
+	// Line of the request line, identifying the request within the file.
+	Line    int
+	Name    string
+	Method  string
+	URL     string
+	Headers [][2]string
+	Body    string
+}
+
+// ID identifies the request as "<file>#<line>".
+func (r Request) ID() string {
+	return fmt.Sprintf("%s#%d", r.File, r.Line)
+}
+
+// Title is the name of the request, or its method and url.
+func (r Request) Title() string {
+	if r.Name != "" {
+		return r.Name
+	}
+
+	return r.Method + " " + r.URL
+}
+
+// text formats the request as written, before it was sent.
+func (r Request) text() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %s\n", r.Method, r.URL)
+
+	for _, v := range r.Headers {
+		fmt.Fprintf(&b, "%s: %s\n", v[0], v[1])
+	}
+
+	if r.Body != "" {
+		fmt.Fprintf(&b, "\n%s\n", r.Body)
+	}
+
+	return b.String()
+}
+
+var (
+	methods     = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "TRACE", "CONNECT"}
+	fileVar     = regexp.MustCompile(`^@([\w-]+)\s*=\s*(.*)$`)
+	nameComment = regexp.MustCompile(`^(?:#|//)\s*@name\s+(.+)$`)
+	variable    = regexp.MustCompile(`{{\s*(\S.*?)\s*}}`)
+)
+
+// parse reads the requests of a VSCode REST Client file. Requests are separated by lines starting with "###",
+// which may name the request. "# @name" names it as well. Variables defined with "@name = value" are
+// substituted when the request is sent.
+func parse(file string) ([]Request, map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	res := []Request{}
+	vars := map[string]string{}
+
+	var cur *Request
+	title, name := "", ""
+	// headers end at the first empty line, the body at the next separator
+	inBody := false
+	var body []string
+
+	flush := func() {
+		if cur == nil {
+			return
+		}
+
+		cur.Body = strings.TrimSpace(strings.Join(body, "\n"))
+
+		if cur.Name == "" {
+			cur.Name = title
+		}
+
+		res = append(res, *cur)
+		cur, body, inBody = nil, nil, false
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+
+		if after, ok := strings.CutPrefix(trimmed, "###"); ok {
+			flush()
+			title, name = strings.TrimSpace(after), ""
+
+			continue
+		}
+
+		if inBody {
+			body = append(body, text)
+			continue
+		}
+
+		if m := nameComment.FindStringSubmatch(trimmed); m != nil {
+			name = strings.TrimSpace(m[1])
+			continue
+		}
+
+		if trimmed == "" {
+			if cur != nil {
+				inBody = true
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+
+		if cur == nil {
+			if m := fileVar.FindStringSubmatch(trimmed); m != nil {
+				vars[m[1]] = strings.TrimSpace(m[2])
+				continue
+			}
+
+			method, rest := "GET", trimmed
+
+			if m, r, ok := strings.Cut(trimmed, " "); ok && slices.Contains(methods, m) {
+				method, rest = m, strings.TrimSpace(r)
+			}
+
+			// the http version is optional
+			if i := strings.LastIndex(rest, " HTTP/"); i > 0 {
+				rest = strings.TrimSpace(rest[:i])
+			}
+
+			cur = &Request{File: file, Line: line, Name: name, Method: method, URL: rest}
+
+			continue
+		}
+
+		// query parameters may continue on the following lines
+		if strings.HasPrefix(trimmed, "?") || strings.HasPrefix(trimmed, "&") {
+			cur.URL += trimmed
+			continue
+		}
+
+		if k, v, ok := strings.Cut(trimmed, ":"); ok {
+			cur.Headers = append(cur.Headers, [2]string{strings.TrimSpace(k), strings.TrimSpace(v)})
+		}
+	}
+
+	flush()
+
+	return res, vars, scanner.Err()
+}
+
+// resolve substitutes the variables of the request: file variables, which may reference each other,
+// {{$processEnv NAME}}, {{$dotenv NAME}} of a .env file next to the request file, {{$guid}}, {{$timestamp}} and
+// {{$datetime iso8601}}. Unknown variables are kept, so they show up in the request.
+func resolve(r Request, vars map[string]string) Request {
+	var dotenv map[string]string
+
+	var replace func(s string, depth int) string
+
+	replace = func(s string, depth int) string {
+		if depth > 10 {
+			return s
+		}
+
+		return variable.ReplaceAllStringFunc(s, func(m string) string {
+			expr := variable.FindStringSubmatch(m)[1]
+			fields := strings.Fields(expr)
+
+			if len(fields) == 0 {
+				return m
+			}
+
+			switch fields[0] {
+			case "$processEnv":
+				if len(fields) > 1 {
+					return os.Getenv(strings.TrimPrefix(fields[1], "%"))
+				}
+			case "$dotenv":
+				if len(fields) > 1 {
+					if dotenv == nil {
+						dotenv = readDotenv(filepath.Join(filepath.Dir(r.File), ".env"))
+					}
+
+					return dotenv[strings.TrimPrefix(fields[1], "%")]
+				}
+			case "$guid":
+				return guid()
+			case "$timestamp":
+				return fmt.Sprint(time.Now().Unix())
+			case "$datetime":
+				return time.Now().UTC().Format(time.RFC3339)
+			}
+
+			if v, ok := vars[expr]; ok {
+				return replace(v, depth+1)
+			}
+
+			return m
+		})
+	}
+
+	r.URL = replace(r.URL, 0)
+	r.Body = replace(r.Body, 0)
+
+	headers := make([][2]string, len(r.Headers))
+
+	for k, v := range r.Headers {
+		headers[k] = [2]string{v[0], replace(v[1], 0)}
+	}
+
+	r.Headers = headers
+
+	return r
+}
+
+func readDotenv(file string) map[string]string {
+	res := map[string]string{}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return res
+	}
+
+	for v := range strings.Lines(string(b)) {
+		v = strings.TrimSpace(v)
+
+		if v == "" || strings.HasPrefix(v, "#") {
+			continue
+		}
+
+		if k, val, ok := strings.Cut(strings.TrimPrefix(v, "export "), "="); ok {
+			res[strings.TrimSpace(k)] = strings.Trim(strings.TrimSpace(val), `"'`)
+		}
+	}
+
+	return res
+}
+
+func guid() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	t.Setenv("RESTCLIENT_TOKEN", "secret")
+
+	r := resolve(Request{
+		URL:     "{{host}}/users/{{ id }}?q={{unknown}}",
+		Headers: [][2]string{{"Authorization", "Bearer {{$processEnv RESTCLIENT_TOKEN}}"}},
+		Body:    "{{ }}",
+	}, map[string]string{
+		"host": "{{base}}/api",
+		"base": "https://example.com",
+		"id":   "1",
+	})
+
+	if want := "https://example.com/api/users/1?q={{unknown}}"; r.URL != want {
+		t.Errorf("url = %q, want %q", r.URL, want)
+	}
+
+	if r.Headers[0][1] != "Bearer secret" {
+		t.Errorf("header = %q", r.Headers[0][1])
+	}
+
+	if r.Body != "{{ }}" {
+		t.Errorf("body = %q", r.Body)
+	}
+}
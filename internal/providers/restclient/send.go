@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// bodies beyond this are cut in the preview
+const maxBody = 1 << 20
+
+type Response struct {
+	Status   string
+	Code     int
+	Proto    string
+	Duration time.Duration
+	Headers  http.Header
+	Body     []byte
+	Sent     time.Time
+	Err      error
+}
+
+var (
+	responsesMu sync.Mutex
+	responses   = map[string]Response{}
+)
+
+func lastResponse(id string) (Response, bool) {
+	responsesMu.Lock()
+	defer responsesMu.Unlock()
+
+	r, ok := responses[id]
+
+	return r, ok
+}
+
+// bodyFile returns the file of a body like "< ./payload.json", relative to the request file.
+func bodyFile(r Request) (string, bool) {
+	file, ok := strings.CutPrefix(r.Body, "< ")
+	if !ok || strings.Contains(file, "\n") {
+		return "", false
+	}
+
+	file = strings.TrimSpace(file)
+
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(filepath.Dir(r.File), file)
+	}
+
+	return file, true
+}
+
+func body(r Request) (io.Reader, error) {
+	if file, ok := bodyFile(r); ok {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		return bytes.NewReader(b), nil
+	}
+
+	return strings.NewReader(r.Body), nil
+}
+
+// send executes the request and remembers the response for the preview.
+func send(r Request) Response {
+	res := Response{Sent: time.Now()}
+
+	defer func() {
+		responsesMu.Lock()
+		responses[r.ID()] = res
+		responsesMu.Unlock()
+	}()
+
+	b, err := body(r)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	req, err := http.NewRequest(r.Method, r.URL, b)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	for _, v := range r.Headers {
+		if strings.EqualFold(v[0], "Host") {
+			req.Host = v[1]
+			continue
+		}
+
+		req.Header.Add(v[0], v[1])
+	}
+
+	client := http.Client{Timeout: time.Duration(config.Timeout) * time.Second}
+
+	if !config.FollowRedirects {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		res.Err = err
+		res.Duration = time.Since(res.Sent)
+		return res
+	}
+	defer resp.Body.Close()
+
+	res.Body, res.Err = io.ReadAll(io.LimitReader(resp.Body, maxBody+1))
+	res.Duration = time.Since(res.Sent)
+	res.Status, res.Code, res.Proto, res.Headers = resp.Status, resp.StatusCode, resp.Proto, resp.Header
+
+	return res
+}
+
+// text formats the response like a raw http response, with indented json bodies.
+func (r Response) text() string {
+	if r.Code == 0 && r.Err != nil {
+		return fmt.Sprintf("Error: %s", r.Err)
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %s (%s)\n", r.Proto, r.Status, r.Duration.Round(time.Millisecond))
+
+	keys := make([]string, 0, len(r.Headers))
+	for k := range r.Headers {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		for _, v := range r.Headers[k] {
+			fmt.Fprintf(&b, "%s: %s\n", k, v)
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(r.body())
+
+	if len(r.Body) > maxBody {
+		b.WriteString("\n…")
+	}
+
+	return b.String()
+}
+
+func (r Response) body() string {
+	data := r.Body[:min(len(r.Body), maxBody)]
+
+	if strings.Contains(r.Headers.Get("Content-Type"), "json") {
+		var out bytes.Buffer
+
+		if err := json.Indent(&out, data, "", "  "); err == nil {
+			return out.String()
+		}
+	}
+
+	return string(data)
+}
+
+// curl formats the request as a curl command.
+func curl(r Request) string {
+	parts := []string{"curl"}
+
+	if r.Method != http.MethodGet {
+		parts = append(parts, "-X", r.Method)
+	}
+
+	parts = append(parts, common.Quote(r.URL))
+
+	for _, v := range r.Headers {
+		parts = append(parts, "-H", common.Quote(v[0]+": "+v[1]))
+	}
+
+	if r.Body != "" {
+		if file, ok := bodyFile(r); ok {
+			parts = append(parts, "--data-binary", common.Quote("@"+file))
+		} else {
+			parts = append(parts, "--data-raw", common.Quote(r.Body))
+		}
+	}
+
+	if config.FollowRedirects {
+		parts = append(parts, "-L")
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,401 @@
+// Package restclient lists and sends the requests of .http and .rest files in project folders.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"github.com/charlievieth/fastwalk"
+)
+
+var (
+	Name       = "restclient"
+	NamePretty = "HTTP Requests"
+	config     *Config
+
+	filesMu sync.RWMutex
+	files   = map[string]parsed{}
+)
+
+// parsed are the requests of a file, parsed again when it changed.
+type parsed struct {
+	modified time.Time
+	requests []Request
+	vars     map[string]string
+}
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config   `koanf:",squash"`
+	Dirs            []string `koanf:"dirs" desc:"directories to scan for .http and .rest files" default:"['~/projects']"`
+	Depth           int      `koanf:"depth" desc:"max depth to scan directories" default:"4"`
+	Timeout         int      `koanf:"timeout" desc:"seconds to wait for a response" default:"30"`
+	FollowRedirects bool     `koanf:"follow_redirects" desc:"follow redirects instead of showing them" default:"true"`
+}
+
+const (
+	ActionSend         = "send"
+	ActionCopyResponse = "copy_response"
+	ActionCopyCurl     = "copy_curl"
+	ActionRescan       = "rescan"
+
+	StateSent    = "sent"
+	StateFailed  = "failed"
+	StateSending = "sending"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "network-transmit-receive",
+			MinScore: 30,
+		},
+		Dirs:            []string{"~/projects"},
+		Depth:           4,
+		Timeout:         30,
+		FollowRedirects: true,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	go scan()
+}
+
+// Refresh rescans the directories.
+func Refresh() {
+	scan()
+}
+
+func expand(path string) string {
+	if after, ok := strings.CutPrefix(path, "~/"); ok {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, after)
+	}
+
+	return path
+}
+
+// scan finds the request files in the configured directories.
+func scan() {
+	start := time.Now()
+
+	found := []string{}
+	var mut sync.Mutex
+
+	conf := fastwalk.Config{
+		Follow: false,
+	}
+
+	for _, root := range config.Dirs {
+		root = filepath.Clean(expand(root))
+		depth := strings.Count(root, string(filepath.Separator))
+
+		walkFn := func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if d.IsDir() {
+				if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "vendor") {
+					return filepath.SkipDir
+				}
+
+				if strings.Count(path, string(filepath.Separator))-depth >= config.Depth {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if ext := filepath.Ext(path); ext == ".http" || ext == ".rest" {
+				mut.Lock()
+				found = append(found, path)
+				mut.Unlock()
+			}
+
+			return nil
+		}
+
+		if err := fastwalk.Walk(&conf, root, walkFn); err != nil {
+			slog.Error(Name, "scan", err, "root", root)
+		}
+	}
+
+	slices.Sort(found)
+
+	res := map[string]parsed{}
+
+	filesMu.RLock()
+	for _, v := range found {
+		res[v] = files[v]
+	}
+	filesMu.RUnlock()
+
+	filesMu.Lock()
+	files = res
+	filesMu.Unlock()
+
+	slog.Info(Name, "files", len(found), "time", time.Since(start))
+}
+
+// requests returns the requests of all files, parsing the changed ones.
+func requests() []Request {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+
+	res := []Request{}
+
+	for file, p := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+
+		if !info.ModTime().Equal(p.modified) {
+			reqs, vars, err := parse(file)
+			if err != nil {
+				slog.Error(Name, "parse", err)
+				continue
+			}
+
+			p = parsed{modified: info.ModTime(), requests: reqs, vars: vars}
+			files[file] = p
+		}
+
+		res = append(res, p.requests...)
+	}
+
+	slices.SortFunc(res, func(a, b Request) int {
+		if c := strings.Compare(a.File, b.File); c != 0 {
+			return c
+		}
+
+		return a.Line - b.Line
+	})
+
+	return res
+}
+
+// request returns the request with its variables resolved.
+func request(id string) (Request, bool) {
+	for _, v := range requests() {
+		if v.ID() != id {
+			continue
+		}
+
+		filesMu.RLock()
+		vars := files[v.File].vars
+		filesMu.RUnlock()
+
+		return resolve(v, vars), true
+	}
+
+	return Request{}, false
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionSend, Label: "Send", Icon: "mail-send", Default: true},
+		{Action: ActionCopyResponse, Label: "Copy response", Icon: "edit-copy"},
+		{Action: ActionCopyCurl, Label: "Copy as curl", Icon: "utilities-terminal"},
+		{Action: ActionRescan, Label: "Rescan", Icon: "view-refresh"},
+	}
+}
+
+var (
+	sendingMu sync.Mutex
+	sending   = map[string]bool{}
+)
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionSend
+	}
+
+	if action == ActionRescan {
+		go func() {
+			scan()
+			handlers.ProviderUpdated <- Name
+		}()
+
+		return
+	}
+
+	r, ok := request(identifier)
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown request: %s", identifier))
+		return
+	}
+
+	switch action {
+	case ActionSend:
+		sendingMu.Lock()
+		sending[identifier] = true
+		sendingMu.Unlock()
+
+		handlers.ProviderUpdated <- Name
+
+		go func() {
+			res := send(r)
+
+			sendingMu.Lock()
+			delete(sending, identifier)
+			sendingMu.Unlock()
+
+			slog.Info(Name, "sent", r.Title(), "status", res.Status, "time", res.Duration, "err", res.Err)
+
+			handlers.ProviderUpdated <- Name
+		}()
+	case ActionCopyResponse, ActionCopyCurl:
+		text := curl(r)
+
+		if action == ActionCopyResponse {
+			res, ok := lastResponse(identifier)
+			if !ok {
+				slog.Error(Name, "copy_response", fmt.Sprintf("not sent yet: %s", identifier))
+				return
+			}
+
+			text = res.body()
+		}
+
+		cmd := common.CopyCmd(text)
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "activate", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	all := requests()
+
+	for k, v := range all {
+		title := v.Title()
+
+		e := &pb.QueryResponse_Item{
+			Identifier:  v.ID(),
+			Text:        title,
+			Subtext:     fmt.Sprintf("%s · %s:%d", v.Method, relative(v.File), v.Line),
+			Provider:    Name,
+			Icon:        config.Icon,
+			State:       []string{},
+			Actions:     []string{ActionSend, ActionCopyCurl},
+			Type:        pb.QueryResponse_REGULAR,
+			Score:       int32(len(all) - k),
+			Preview:     v.text(),
+			PreviewType: util.PreviewTypeText,
+		}
+
+		if res, ok := lastResponse(e.Identifier); ok {
+			e.Preview = res.text()
+			e.State = []string{StateSent}
+
+			if res.Code == 0 || res.Code >= 400 {
+				e.State = []string{StateFailed}
+			}
+
+			if res.Code != 0 {
+				e.Subtext = fmt.Sprintf("%s · %s · %s", e.Subtext, res.Status, res.Duration.Round(time.Millisecond))
+				e.Actions = append(e.Actions, ActionCopyResponse)
+			} else {
+				e.Subtext = fmt.Sprintf("%s · error", e.Subtext)
+			}
+		}
+
+		sendingMu.Lock()
+		if sending[e.Identifier] {
+			e.State = []string{StateSending}
+		}
+		sendingMu.Unlock()
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, title, exact)
+
+			// matches of the url or file don't highlight the title
+			if s, _, _ := common.FuzzyScore(query, v.URL+" "+relative(v.File), exact); s > score {
+				score, pos, start = s, nil, 0
+			}
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// relative shortens the path of the file to be relative to the configured directory containing it.
+func relative(file string) string {
+	for _, v := range config.Dirs {
+		if rel, err := filepath.Rel(filepath.Clean(expand(v)), file); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+
+	return file
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{
+		States:  []string{},
+		Actions: []string{ActionRescan},
+	}
+}
@@ -0,0 +1,270 @@
+package providers
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"github.com/abenz1267/elephant/v2/pkg/sdk"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+)
+
+// pluginDirs returns the folders containing providers built with the sdk.
+func pluginDirs() []string {
+	res := []string{}
+
+	for _, v := range common.ConfigDirs() {
+		res = append(res, filepath.Join(v, "plugins"))
+	}
+
+	if dir := os.Getenv("ELEPHANT_PLUGIN_DIR"); dir != "" {
+		res = append(res, dir)
+	}
+
+	return res
+}
+
+// loadRPC starts the executables in the plugin dirs and registers the providers they serve. Providers
+// already loaded as Go plugins take precedence.
+func loadRPC(setup bool, ignored, disabled []string) {
+	for _, dir := range pluginDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, e := range entries {
+			path := filepath.Join(dir, e.Name())
+
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() || !executable(path, info) {
+				continue
+			}
+
+			name := strings.TrimSuffix(e.Name(), ".exe")
+
+			if slices.Contains(ignored, name) {
+				setStatus(Status{Name: name, Reason: ReasonIgnored})
+				continue
+			}
+
+			p, client, err := dispense(path)
+			if err != nil {
+				slog.Error("providers", "plugin", path, "err", err)
+				setStatus(Status{Name: name, Reason: err.Error()})
+				continue
+			}
+
+			pname := p.Info().Name
+
+			providersMu.Lock()
+			_, exists := loaded[pname]
+			providersMu.Unlock()
+
+			if exists || slices.Contains(ignored, pname) {
+				client.Kill()
+				continue
+			}
+
+			// the setup may change the name or icon, so it runs before the info is read and the provider
+			// is registered
+			ran := false
+
+			if setup && !slices.Contains(disabled, pname) && p.Available() {
+				p.Setup()
+				ran = true
+			}
+
+			provider := rpcProvider(p, client, path)
+
+			if !register(provider, setup && !ran, disabled) {
+				client.Kill()
+			}
+		}
+	}
+}
+
+// executable reports whether the file is an executable. Windows has no executable bit, so the
+// extension is checked instead.
+func executable(path string, info os.FileInfo) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(path), ".exe")
+	}
+
+	return info.Mode()&0o111 != 0 && filepath.Ext(path) != ".wasm"
+}
+
+func dispense(path string) (sdk.Provider, *plugin.Client, error) {
+	client := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig: sdk.Handshake,
+		Plugins: plugin.PluginSet{
+			sdk.PluginName: &sdk.Plugin{},
+		},
+		Cmd:              exec.Command(path),
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolNetRPC},
+		Managed:          true,
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Name:   filepath.Base(path),
+			Output: os.Stderr,
+			Level:  hclog.Info,
+		}),
+	})
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, nil, err
+	}
+
+	raw, err := rpcClient.Dispense(sdk.PluginName)
+	if err != nil {
+		client.Kill()
+		return nil, nil, err
+	}
+
+	p, ok := raw.(sdk.Provider)
+	if !ok {
+		client.Kill()
+		return nil, nil, fmt.Errorf("unexpected type %T", raw)
+	}
+
+	return p, client, nil
+}
+
+// rpcProvider adapts a provider served via RPC to the functions of Go plugin providers. Restarting
+// starts a new process of the executable at path and replaces the current one. The names are read
+// once, handlers read them without locking.
+func rpcProvider(p sdk.Provider, client *plugin.Client, path string) Provider {
+	var mu sync.Mutex
+
+	info := p.Info()
+
+	get := func() sdk.Provider {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return p
+	}
+
+	getInfo := func() sdk.Info {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return info
+	}
+
+	name := info.Name
+	namePretty := info.NamePretty
+
+	return Provider{
+		Name:       &name,
+		NamePretty: &namePretty,
+		Setup: func() {
+			np := get()
+			np.Setup()
+
+			updated := np.Info()
+
+			mu.Lock()
+			info = updated
+			mu.Unlock()
+		},
+		Available: func() bool {
+			return get().Available()
+		},
+		PrintDoc: func(w io.Writer) {
+			fmt.Fprintln(w, get().Doc())
+		},
+		State: func(string) *pb.ProviderStateResponse {
+			return get().State()
+		},
+		HideFromProviderlist: func() bool {
+			return getInfo().HideFromProviderlist
+		},
+		Icon: func() string {
+			return getInfo().Icon
+		},
+		Activate: func(single bool, identifier, action, query, args string, _ uint8, _ net.Conn) {
+			get().Activate(sdk.ActivateRequest{
+				Single:     single,
+				Identifier: identifier,
+				Action:     action,
+				Query:      query,
+				Arguments:  args,
+			})
+		},
+		Query: func(_ net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+			return get().Query(sdk.QueryRequest{
+				Query:  query,
+				Single: single,
+				Exact:  exact,
+			})
+		},
+		Refresh: func() {
+			get().Refresh()
+		},
+		Restart: func() error {
+			np, nc, err := dispense(path)
+			if err != nil {
+				return err
+			}
+
+			np.Setup()
+
+			updated := np.Info()
+
+			mu.Lock()
+			old := client
+			p, client = np, nc
+			info = updated
+			mu.Unlock()
+
+			old.Kill()
+
+			return nil
+		},
+		Actions: func() []*pb.ActionDescriptor {
+			actions := getInfo().Actions
+
+			res := make([]*pb.ActionDescriptor, 0, len(actions))
+
+			for _, v := range actions {
+				d := &pb.ActionDescriptor{
+					Action:  v.Action,
+					Label:   v.Label,
+					Icon:    v.Icon,
+					Default: v.Default,
+				}
+
+				for _, a := range v.Arguments {
+					d.Arguments = append(d.Arguments, &pb.ActionArgument{
+						Name:        a.Name,
+						Type:        a.Type,
+						Placeholder: a.Placeholder,
+						Required:    a.Required,
+					})
+				}
+
+				res = append(res, d)
+			}
+
+			return res
+		},
+	}
+}
+
+// Shutdown stops providers running in their own process.
+func Shutdown() {
+	plugin.CleanupClients()
+}
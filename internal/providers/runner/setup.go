@@ -6,20 +6,20 @@ import (
 	_ "embed"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
 	"net"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/abenz1267/elephant/v2/internal/util"
 	"github.com/abenz1267/elephant/v2/pkg/common"
 	"github.com/abenz1267/elephant/v2/pkg/common/history"
+	"github.com/abenz1267/elephant/v2/pkg/common/jobs"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 	"github.com/charlievieth/fastwalk"
 )
@@ -76,6 +76,19 @@ func Setup() {
 		NamePretty = config.NamePretty
 	}
 
+	items = loadItems()
+
+	slog.Info(Name, "executables", len(items), "time", time.Since(start))
+}
+
+// Refresh rescans $PATH.
+func Refresh() {
+	items = loadItems()
+}
+
+func loadItems() []Item {
+	res := []Item{}
+
 	if len(config.Explicits) == 0 {
 		bins := []string{}
 
@@ -83,14 +96,14 @@ func Setup() {
 			Follow: true,
 		}
 
-		for p := range strings.SplitSeq(os.Getenv("PATH"), ":") {
+		for _, p := range filepath.SplitList(os.Getenv("PATH")) {
 			walkFn := func(path string, d fs.DirEntry, err error) error {
 				info, serr := os.Stat(path)
 				if info == nil || serr != nil {
 					return nil
 				}
 
-				if info.Mode()&0o111 != 0 && !d.IsDir() {
+				if !d.IsDir() && common.IsExecutable(path, info) {
 					bins = append(bins, filepath.Base(path))
 				}
 
@@ -109,7 +122,7 @@ func Setup() {
 			md5 := md5.Sum([]byte(v))
 			md5str := hex.EncodeToString(md5[:])
 
-			items = append(items, Item{
+			res = append(res, Item{
 				Identifier: md5str,
 				Bin:        v,
 			})
@@ -119,7 +132,7 @@ func Setup() {
 			md5 := md5.Sum([]byte(v.Exec))
 			identifier := hex.EncodeToString(md5[:])
 
-			items = append(items, Item{
+			res = append(res, Item{
 				Identifier: identifier,
 				Bin:        v.Exec,
 				Alias:      v.Alias,
@@ -127,30 +140,39 @@ func Setup() {
 		}
 	}
 
-	slog.Info(Name, "executables", len(items), "time", time.Since(start))
+	return res
 }
 
 func Available() bool {
 	return true
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 const (
 	ActionRun           = "run"
 	ActionRunInTerminal = "runterminal"
+	ActionRunJob        = "runjob"
 )
 
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionRun, Label: "Run", Icon: "system-run", Default: true},
+		{Action: ActionRunInTerminal, Label: "Run in terminal", Icon: "utilities-terminal"},
+		{Action: ActionRunJob, Label: "Run in background", Icon: "system-run"},
+	}
+}
+
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
 	switch action {
 	case history.ActionDelete:
 		h.Remove(identifier)
 		return
-	case ActionRunInTerminal, ActionRun:
+	case ActionRunInTerminal, ActionRun, ActionRunJob:
 		bin := ""
 
 		if identifier == "generic" {
@@ -165,16 +187,26 @@ func Activate(single bool, identifier, action string, query string, args string,
 			}
 		}
 
-		run := strings.TrimSpace(fmt.Sprintf("%s %s %s", common.LaunchPrefix(""), bin, args))
+		if action == ActionRunJob {
+			if _, err := jobs.Start(Name, strings.TrimSpace(fmt.Sprintf("%s %s", bin, args)), fmt.Sprintf("%s %s", bin, args)); err != nil {
+				slog.Error(Name, "activate", err)
+				return
+			}
+
+			if config.History {
+				h.Save(query, identifier)
+			}
+
+			return
+		}
+
+		run := strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix("", Name, identifier), bin, args))
 		if action == ActionRunInTerminal {
 			run = common.WrapWithTerminal(run)
 		}
 
-		cmd := exec.Command("sh", "-c", run)
-
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			Setsid: true,
-		}
+		cmd := common.Shell(run)
+		common.Detach(cmd)
 
 		err := cmd.Start()
 		if err != nil {
@@ -202,7 +234,7 @@ func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.
 		e := &pb.QueryResponse_Item{
 			Identifier: v.Identifier,
 			Text:       v.Bin,
-			Actions:    []string{ActionRun, ActionRunInTerminal},
+			Actions:    []string{ActionRun, ActionRunInTerminal, ActionRunJob},
 			Provider:   Name,
 			Icon:       config.Icon,
 			Score:      0,
@@ -247,7 +279,7 @@ func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.
 		e := &pb.QueryResponse_Item{
 			Identifier: "generic",
 			Text:       fmt.Sprintf("%s%s", config.GenericText, query),
-			Actions:    []string{ActionRun, ActionRunInTerminal},
+			Actions:    []string{ActionRun, ActionRunInTerminal, ActionRunJob},
 			Provider:   Name,
 			Icon:       config.Icon,
 			Score:      100000,
@@ -2,9 +2,9 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
-	"os/exec"
 	"strconv"
 	"strings"
 	"time"
@@ -32,7 +32,7 @@ const (
 
 type Config struct {
 	common.Config `koanf:",squash"`
-	Command       string    `koanf:"command" desc:"default command to be executed. supports %VALUE%." default:"wtype %CONTENT%"`
+	Command       string    `koanf:"command" desc:"default command to be executed. supports %VALUE%." default:"wtype %CONTENT%, xdotool type %CONTENT% on X11"`
 	Snippets      []Snippet `koanf:"snippets" desc:"available snippets" default:""`
 	Delay         int       `koanf:"delay" desc:"delay in ms before executing command to avoid potential focus issues" default:"100"`
 }
@@ -53,6 +53,10 @@ func Setup() {
 		Delay:   100,
 	}
 
+	if common.X11() {
+		config.Command = "xdotool type %CONTENT%"
+	}
+
 	common.LoadConfig(Name, config)
 
 	if config.NamePretty != "" {
@@ -64,10 +68,10 @@ func Available() bool {
 	return true
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
@@ -77,7 +81,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 	s := config.Snippets[i]
 
 	toRun := strings.ReplaceAll(config.Command, "%CONTENT%", shellescape.Quote(s.Content))
-	cmd := exec.Command("sh", "-c", toRun)
+	cmd := common.HostShell(toRun)
 
 	err := cmd.Start()
 	if err != nil {
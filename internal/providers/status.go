@@ -0,0 +1,226 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/gob"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// Status describes a provider that was found, whether it got loaded or not.
+type Status struct {
+	Name      string
+	Available bool
+	Disabled  bool
+	Reason    string
+	Refreshed time.Time
+	// Unhealthy is set by the watchdog after repeatedly failed probes, until a probe succeeds again.
+	Unhealthy bool
+	// Failures counts the failed probes in a row.
+	Failures  int
+	Restarts  int
+	LastError string
+	Probed    time.Time
+	provider  *Provider
+}
+
+const (
+	ReasonIgnored     = "ignored in config"
+	ReasonDisabled    = "disabled"
+	ReasonUnavailable = "requirements not met"
+)
+
+var (
+	statuses = make(map[string]*Status)
+	statusMu sync.Mutex
+)
+
+// Statuses returns a copy of all known provider states.
+func Statuses() []Status {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+
+	res := []Status{}
+
+	for _, v := range statuses {
+		res = append(res, *v)
+	}
+
+	return res
+}
+
+func setStatus(s Status) {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+
+	if existing, ok := statuses[s.Name]; ok && existing.Available && !s.Available {
+		return
+	}
+
+	statuses[s.Name] = &s
+}
+
+func runSetup(p Provider) {
+	p.Setup()
+
+	statusMu.Lock()
+	if s, ok := statuses[*p.Name]; ok {
+		s.Refreshed = time.Now()
+	}
+	statusMu.Unlock()
+}
+
+// Refresh runs the providers Refresh function, if it exports one.
+func Refresh(name string) bool {
+	p, ok := Get(name)
+	if !ok || p.Refresh == nil {
+		return false
+	}
+
+	p.Refresh()
+
+	statusMu.Lock()
+	if s, ok := statuses[name]; ok {
+		s.Refreshed = time.Now()
+	}
+	statusMu.Unlock()
+
+	return true
+}
+
+// Disable unloads the provider until it gets enabled again. This persists across restarts.
+func Disable(name string) {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+
+	s, ok := statuses[name]
+	if !ok || s.Disabled {
+		return
+	}
+
+	s.Disabled = true
+	s.Reason = ReasonDisabled
+
+	providersMu.Lock()
+	delete(loaded, name)
+	providersMu.Unlock()
+
+	writeDisabled()
+}
+
+// Enable loads a previously disabled provider.
+func Enable(name string) {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+
+	s, ok := statuses[name]
+	if !ok || !s.Disabled {
+		return
+	}
+
+	s.Disabled = false
+	s.Reason = ""
+
+	writeDisabled()
+
+	if s.provider == nil {
+		return
+	}
+
+	p := *s.provider
+
+	if !p.Available() {
+		s.Available = false
+		s.Reason = ReasonUnavailable
+		return
+	}
+
+	s.Available = true
+
+	go runSetup(p)
+
+	providersMu.Lock()
+	loaded[name] = p
+	providersMu.Unlock()
+}
+
+// Doc returns what the providers PrintDoc function writes.
+func Doc(name string) string {
+	statusMu.Lock()
+	s, ok := statuses[name]
+	statusMu.Unlock()
+
+	if !ok || s.provider == nil {
+		return ""
+	}
+
+	p := *s.provider
+
+	var b strings.Builder
+
+	p.PrintDoc(&b)
+
+	return b.String()
+}
+
+func disabledFile() string {
+	return common.CacheFile("disabled_providers.gob")
+}
+
+func loadDisabled() []string {
+	res := []string{}
+
+	file := disabledFile()
+
+	if common.FileExists(file) {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			slog.Error("providers", "disabled", err)
+			return res
+		}
+
+		if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&res); err != nil {
+			slog.Error("providers", "disabled", err)
+		}
+	}
+
+	return res
+}
+
+// writeDisabled expects statusMu to be locked.
+func writeDisabled() {
+	disabled := []string{}
+
+	for k, v := range statuses {
+		if v.Disabled {
+			disabled = append(disabled, k)
+		}
+	}
+
+	slices.Sort(disabled)
+
+	var b bytes.Buffer
+
+	if err := gob.NewEncoder(&b).Encode(disabled); err != nil {
+		slog.Error("providers", "disabled", err)
+		return
+	}
+
+	file := disabledFile()
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		slog.Error("providers", "disabled", err)
+		return
+	}
+
+	if err := os.WriteFile(file, b.Bytes(), 0o600); err != nil {
+		slog.Error("providers", "disabled", err)
+	}
+}
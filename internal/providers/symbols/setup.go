@@ -4,6 +4,7 @@ package main
 import (
 	_ "embed"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"net"
@@ -31,7 +32,7 @@ type Config struct {
 	Locale           string `koanf:"locale" desc:"locale to use for symbols" default:"en"`
 	History          bool   `koanf:"history" desc:"make use of history for sorting" default:"true"`
 	HistoryWhenEmpty bool   `koanf:"history_when_empty" desc:"consider history when query is empty" default:"false"`
-	Command          string `koanf:"command" desc:"default command to be executed. supports %VALUE%." default:"wl-copy"`
+	Command          string `koanf:"command" desc:"default command to be executed. supports %VALUE%." default:"wl-copy, xclip on X11, pbcopy on macOS, clip on Windows"`
 }
 
 var config *Config
@@ -47,7 +48,7 @@ func Setup() {
 		Locale:           "en",
 		History:          true,
 		HistoryWhenEmpty: false,
-		Command:          "wl-copy",
+		Command:          common.CopyCommand(),
 	}
 
 	common.LoadConfig(Name, config)
@@ -65,10 +66,10 @@ func Available() bool {
 	return true
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	fmt.Println("#### Possible locales")
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "#### Possible locales")
 
 	entries, err := files.ReadDir("data")
 	if err != nil {
@@ -76,12 +77,12 @@ func PrintDoc() {
 	}
 
 	for _, v := range entries {
-		fmt.Printf("%s,", strings.TrimSuffix(filepath.Base(v.Name()), ".xml"))
+		fmt.Fprintf(w, "%s,", strings.TrimSuffix(filepath.Base(v.Name()), ".xml"))
 	}
 
-	fmt.Println()
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 const ActionRunCmd = "run_cmd"
@@ -0,0 +1,304 @@
+//go:build linux
+
+// Package sysmonitor shows cpu, memory, disk and battery usage.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "sysmonitor"
+	NamePretty = "System Monitor"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config   `koanf:",squash"`
+	MonitorCommand  string   `koanf:"monitor_command" desc:"command opening a monitor tool" default:"btop"`
+	Terminal        bool     `koanf:"terminal" desc:"run the monitor command in a terminal" default:"true"`
+	Disks           []string `koanf:"disks" desc:"mount points to show the usage of" default:"['/']"`
+	Interval        int      `koanf:"interval" desc:"milliseconds between updates while the items are shown" default:"2000"`
+	Warning         int      `koanf:"warning" desc:"usage in percent from which items are in the 'warning' state" default:"80"`
+	Critical        int      `koanf:"critical" desc:"usage in percent from which items are in the 'critical' state" default:"95"`
+	BatteryWarning  int      `koanf:"battery_warning" desc:"charge in percent below which discharging batteries are in the 'warning' state" default:"20"`
+	BatteryCritical int      `koanf:"battery_critical" desc:"charge in percent below which discharging batteries are in the 'critical' state" default:"10"`
+}
+
+const (
+	ActionOpen = "open"
+
+	StateOK       = "ok"
+	StateWarning  = "warning"
+	StateCritical = "critical"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "utilities-system-monitor",
+			MinScore: 20,
+		},
+		MonitorCommand:  "btop",
+		Terminal:        true,
+		Disks:           []string{"/"},
+		Interval:        2000,
+		Warning:         80,
+		Critical:        95,
+		BatteryWarning:  20,
+		BatteryCritical: 10,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	// the first query needs a previous sample to compute the cpu usage
+	if _, err := cpuUsage(); err != nil {
+		slog.Error(Name, "cpu", err)
+	}
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionOpen, Label: "Open monitor", Icon: "utilities-system-monitor", Default: true},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionOpen
+	}
+
+	if action != ActionOpen {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	run := config.MonitorCommand
+	if config.Terminal {
+		run = common.WrapWithTerminal(run)
+	}
+
+	cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), run)))
+	common.Detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+}
+
+// usageState returns the state of a usage in percent.
+func usageState(percent float64) string {
+	switch {
+	case percent >= float64(config.Critical):
+		return StateCritical
+	case percent >= float64(config.Warning):
+		return StateWarning
+	default:
+		return StateOK
+	}
+}
+
+func batteryState(b battery) string {
+	if b.status != "Discharging" {
+		return StateOK
+	}
+
+	switch {
+	case b.capacity <= config.BatteryCritical:
+		return StateCritical
+	case b.capacity <= config.BatteryWarning:
+		return StateWarning
+	default:
+		return StateOK
+	}
+}
+
+// identifiers returns the identifiers of all items: cpu, memory, disk:<mount point> and battery:<name>.
+func identifiers() []string {
+	res := []string{"cpu", "memory"}
+
+	for _, v := range config.Disks {
+		res = append(res, "disk:"+v)
+	}
+
+	for _, v := range batteries() {
+		res = append(res, "battery:"+v.name)
+	}
+
+	return res
+}
+
+// entry reads the current usage of the item, nil if it can't be read.
+func entry(identifier string) *pb.QueryResponse_Item {
+	e := &pb.QueryResponse_Item{
+		Identifier:   identifier,
+		Provider:     Name,
+		Actions:      []string{ActionOpen},
+		LiveInterval: int32(config.Interval),
+		Type:         pb.QueryResponse_REGULAR,
+	}
+
+	kind, arg, _ := strings.Cut(identifier, ":")
+
+	switch kind {
+	case "cpu":
+		used, err := cpuUsage()
+		if err != nil {
+			slog.Error(Name, "cpu", err)
+			return nil
+		}
+
+		e.Text = fmt.Sprintf("CPU %.0f%%", used)
+		e.Subtext = fmt.Sprintf("load %s", loadAverage())
+		e.Icon = "cpu"
+		e.State = []string{usageState(used)}
+	case "memory":
+		total, available, err := memory()
+		if err != nil {
+			slog.Error(Name, "memory", err)
+			return nil
+		}
+
+		used := 100 * float64(total-available) / float64(total)
+
+		e.Text = fmt.Sprintf("Memory %.0f%%", used)
+		e.Subtext = fmt.Sprintf("%s of %s used", formatBytes(total-available), formatBytes(total))
+		e.Icon = "memory"
+		e.State = []string{usageState(used)}
+	case "disk":
+		size, available, err := disk(arg)
+		if err != nil || size == 0 {
+			slog.Error(Name, "disk", err, "path", arg)
+			return nil
+		}
+
+		used := 100 * float64(size-available) / float64(size)
+
+		e.Text = fmt.Sprintf("Disk %s %.0f%%", arg, used)
+		e.Subtext = fmt.Sprintf("%s free of %s", formatBytes(available), formatBytes(size))
+		e.Icon = "drive-harddisk"
+		e.State = []string{usageState(used)}
+	case "battery":
+		for _, v := range batteries() {
+			if v.name != arg {
+				continue
+			}
+
+			e.Text = fmt.Sprintf("Battery %d%%", v.capacity)
+			e.Subtext = v.status
+			e.Icon = "battery"
+			e.State = []string{batteryState(v)}
+
+			return e
+		}
+
+		return nil
+	default:
+		return nil
+	}
+
+	return e
+}
+
+// Live updates the usage while clients show the items.
+func Live(identifier string) *pb.QueryResponse_Item {
+	return entry(identifier)
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	ids := identifiers()
+
+	for k, v := range ids {
+		e := entry(v)
+		if e == nil {
+			continue
+		}
+
+		e.Score = int32(len(ids) - k)
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, e.Text, exact)
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+// State is "critical" or "warning" if any item is, otherwise "ok".
+func State(provider string) *pb.ProviderStateResponse {
+	state := StateOK
+
+	for _, v := range identifiers() {
+		e := entry(v)
+		if e == nil {
+			continue
+		}
+
+		switch e.State[0] {
+		case StateCritical:
+			state = StateCritical
+		case StateWarning:
+			if state == StateOK {
+				state = StateWarning
+			}
+		}
+	}
+
+	return &pb.ProviderStateResponse{
+		States: []string{state},
+	}
+}
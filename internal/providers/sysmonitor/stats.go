@@ -0,0 +1,206 @@
+//go:build linux
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// cpuSample holds the jiffies of /proc/stat, usage is the difference between two samples.
+type cpuSample struct {
+	idle  uint64
+	total uint64
+	time  time.Time
+}
+
+var (
+	cpuMu   sync.Mutex
+	lastCPU cpuSample
+	cpuUsed float64
+)
+
+func readCPU() (cpuSample, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return cpuSample{}, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan()
+
+	fields := strings.Fields(scanner.Text())
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return cpuSample{}, fmt.Errorf("unexpected /proc/stat: %s", scanner.Text())
+	}
+
+	res := cpuSample{time: time.Now()}
+
+	for i, v := range fields[1:] {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return cpuSample{}, err
+		}
+
+		res.total += n
+
+		// idle and iowait
+		if i == 3 || i == 4 {
+			res.idle += n
+		}
+	}
+
+	return res, nil
+}
+
+// cpuUsage returns the percentage of cpu time used since the last sample. Samples are taken at most
+// twice a second, so several clients updating the item don't shrink the measured time.
+func cpuUsage() (float64, error) {
+	cpuMu.Lock()
+	defer cpuMu.Unlock()
+
+	if time.Since(lastCPU.time) < 500*time.Millisecond {
+		return cpuUsed, nil
+	}
+
+	s, err := readCPU()
+	if err != nil {
+		return 0, err
+	}
+
+	if total := s.total - lastCPU.total; lastCPU.total > 0 && total > 0 {
+		cpuUsed = 100 * float64(total-(s.idle-lastCPU.idle)) / float64(total)
+	}
+
+	lastCPU = s
+
+	return cpuUsed, nil
+}
+
+// loadAverage returns the first three fields of /proc/loadavg.
+func loadAverage() string {
+	b, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return ""
+	}
+
+	fields := strings.Fields(string(b))
+	if len(fields) < 3 {
+		return ""
+	}
+
+	return strings.Join(fields[:3], " ")
+}
+
+// memory returns the total and available memory in bytes.
+func memory() (total, available uint64, err error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		switch fields[0] {
+		case "MemTotal:":
+			total = kb * 1024
+		case "MemAvailable:":
+			available = kb * 1024
+		}
+	}
+
+	if total == 0 {
+		return 0, 0, fmt.Errorf("no MemTotal in /proc/meminfo")
+	}
+
+	return total, available, scanner.Err()
+}
+
+// disk returns the size and the space available to users of the filesystem, like df.
+func disk(path string) (size, available uint64, err error) {
+	var st syscall.Statfs_t
+
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+
+	bsize := uint64(st.Bsize)
+	used := (st.Blocks - st.Bfree) * bsize
+	available = st.Bavail * bsize
+
+	return used + available, available, nil
+}
+
+type battery struct {
+	name     string
+	capacity int
+	status   string
+}
+
+// batteries reads the batteries from /sys/class/power_supply.
+func batteries() []battery {
+	dirs, _ := filepath.Glob("/sys/class/power_supply/*")
+
+	res := []battery{}
+
+	for _, v := range dirs {
+		if readSys(v, "type") != "Battery" {
+			continue
+		}
+
+		capacity, err := strconv.Atoi(readSys(v, "capacity"))
+		if err != nil {
+			continue
+		}
+
+		res = append(res, battery{name: filepath.Base(v), capacity: capacity, status: readSys(v, "status")})
+	}
+
+	return res
+}
+
+func readSys(dir, file string) string {
+	b, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(b))
+}
+
+func formatBytes(b uint64) string {
+	const unit = 1024
+
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := uint64(unit), 0
+
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// Backend fetches the issues assigned to the user.
+type Backend interface {
+	// Account identifies the configured account, f.e. "jira:work".
+	Account() string
+	Issues() ([]Issue, error)
+}
+
+// Issue is a Jira or Linear issue.
+type Issue struct {
+	Key      string
+	Title    string
+	URL      string
+	Status   string
+	Priority string
+	Type     string
+	Project  string
+	Updated  time.Time
+	Account  string
+}
+
+var client = http.Client{Timeout: 20 * time.Second}
+
+// secret returns the value or the output of the command.
+// secret resolves the value, the command takes precedence for configs written before secrets could
+// reference commands.
+func secret(value common.Secret, cmd string) (string, error) {
+	if cmd != "" {
+		value = common.Secret(fmt.Sprintf("cmd:%s", cmd))
+	}
+
+	return value.Resolve()
+}
+
+func doJSON(req *http.Request, v any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s %s", req.URL.Path, resp.Status, strings.TrimSpace(string(b)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func label(kind, name string) string {
+	if name == "" {
+		return kind
+	}
+
+	return fmt.Sprintf("%s:%s", kind, name)
+}
+
+type cache struct {
+	Fetched time.Time
+	Issues  map[string][]Issue
+}
+
+func cacheFile() string {
+	return common.CacheFile(fmt.Sprintf("%s.gob", Name))
+}
+
+func loadCache() cache {
+	res := cache{Issues: make(map[string][]Issue)}
+
+	file := cacheFile()
+
+	if !common.FileExists(file) {
+		return res
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		slog.Error(Name, "cache", err)
+		return res
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&res); err != nil {
+		slog.Error(Name, "cache", err)
+	}
+
+	return res
+}
+
+func writeCache(c cache) {
+	var b bytes.Buffer
+
+	if err := gob.NewEncoder(&b).Encode(c); err != nil {
+		slog.Error(Name, "cache", err)
+		return
+	}
+
+	file := cacheFile()
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		slog.Error(Name, "cache", err)
+		return
+	}
+
+	if err := os.WriteFile(file, b.Bytes(), 0o600); err != nil {
+		slog.Error(Name, "cache", err)
+	}
+}
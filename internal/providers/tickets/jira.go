@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+type Jira struct {
+	Name     string        `koanf:"name" desc:"name of the account" default:""`
+	URL      string        `koanf:"url" desc:"url of the instance, f.e. https://example.atlassian.net" default:""`
+	Email    string        `koanf:"email" desc:"email for api tokens of Jira Cloud. personal access tokens of Jira Server are used without" default:""`
+	Token    common.Secret `koanf:"token" desc:"api token or personal access token, f.e. 'cmd:pass show jira'" default:""`
+	TokenCmd string        `koanf:"token_cmd" desc:"command printing the token" default:""`
+	JQL      string        `koanf:"jql" desc:"query selecting the issues" default:"assignee = currentUser() AND statusCategory != Done ORDER BY updated DESC"`
+	Server   bool          `koanf:"server" desc:"use the api of Jira Server and Data Center" default:"false"`
+}
+
+const jiraJQL = "assignee = currentUser() AND statusCategory != Done ORDER BY updated DESC"
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary string `json:"summary"`
+		Updated string `json:"updated"`
+		Status  struct {
+			Name string `json:"name"`
+		} `json:"status"`
+		Priority *struct {
+			Name string `json:"name"`
+		} `json:"priority"`
+		IssueType struct {
+			Name string `json:"name"`
+		} `json:"issuetype"`
+		Project struct {
+			Key string `json:"key"`
+		} `json:"project"`
+	} `json:"fields"`
+}
+
+func (j Jira) Account() string {
+	return label("jira", j.Name)
+}
+
+func (j Jira) Issues() ([]Issue, error) {
+	token, err := secret(j.Token, j.TokenCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	jql := j.JQL
+	if jql == "" {
+		jql = jiraJQL
+	}
+
+	base := strings.TrimSuffix(j.URL, "/")
+
+	// Jira Cloud removed the old search endpoint in favour of one paginating with tokens.
+	path := "/rest/api/3/search/jql"
+	if j.Server {
+		path = "/rest/api/2/search"
+	}
+
+	res := []Issue{}
+	next := ""
+	startAt := 0
+
+	for {
+		params := url.Values{
+			"jql":        {jql},
+			"fields":     {"summary,status,priority,issuetype,project,updated"},
+			"maxResults": {"100"},
+		}
+
+		if j.Server {
+			params.Set("startAt", fmt.Sprint(startAt))
+		} else if next != "" {
+			params.Set("nextPageToken", next)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s%s?%s", base, path, params.Encode()), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if j.Email != "" {
+			req.SetBasicAuth(j.Email, token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		req.Header.Set("Accept", "application/json")
+
+		var page struct {
+			Issues        []jiraIssue `json:"issues"`
+			NextPageToken string      `json:"nextPageToken"`
+			Total         int         `json:"total"`
+		}
+
+		if err := doJSON(req, &page); err != nil {
+			return nil, err
+		}
+
+		for _, v := range page.Issues {
+			i := Issue{
+				Key:     v.Key,
+				Title:   v.Fields.Summary,
+				URL:     fmt.Sprintf("%s/browse/%s", base, v.Key),
+				Status:  v.Fields.Status.Name,
+				Type:    v.Fields.IssueType.Name,
+				Project: v.Fields.Project.Key,
+				Account: j.Account(),
+			}
+
+			if v.Fields.Priority != nil {
+				i.Priority = v.Fields.Priority.Name
+			}
+
+			i.Updated, _ = time.Parse("2006-01-02T15:04:05.000-0700", v.Fields.Updated)
+
+			res = append(res, i)
+		}
+
+		startAt += len(page.Issues)
+
+		switch {
+		case len(page.Issues) == 0:
+			return res, nil
+		case j.Server && startAt >= page.Total:
+			return res, nil
+		case !j.Server && page.NextPageToken == "":
+			return res, nil
+		}
+
+		next = page.NextPageToken
+	}
+}
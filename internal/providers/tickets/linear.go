@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+type Linear struct {
+	Name     string        `koanf:"name" desc:"name of the account" default:""`
+	Token    common.Secret `koanf:"token" desc:"personal api key, f.e. 'libsecret:service linear'" default:""`
+	TokenCmd string        `koanf:"token_cmd" desc:"command printing the api key" default:""`
+}
+
+const linearQuery = `query($after: String) {
+  viewer {
+    assignedIssues(first: 100, after: $after, filter: {state: {type: {nin: ["completed", "canceled"]}}}) {
+      nodes {
+        identifier
+        title
+        url
+        priorityLabel
+        updatedAt
+        state { name }
+        team { key }
+        labels(first: 1) { nodes { name } }
+      }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`
+
+func (l Linear) Account() string {
+	return label("linear", l.Name)
+}
+
+func (l Linear) Issues() ([]Issue, error) {
+	token, err := secret(l.Token, l.TokenCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Issue{}
+	var after *string
+
+	for {
+		body, err := json.Marshal(map[string]any{
+			"query":     linearQuery,
+			"variables": map[string]any{"after": after},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequest(http.MethodPost, "https://api.linear.app/graphql", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Authorization", token)
+		req.Header.Set("Content-Type", "application/json")
+
+		var resp struct {
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+			Data struct {
+				Viewer struct {
+					AssignedIssues struct {
+						Nodes []struct {
+							Identifier    string    `json:"identifier"`
+							Title         string    `json:"title"`
+							URL           string    `json:"url"`
+							PriorityLabel string    `json:"priorityLabel"`
+							UpdatedAt     time.Time `json:"updatedAt"`
+							State         struct {
+								Name string `json:"name"`
+							} `json:"state"`
+							Team struct {
+								Key string `json:"key"`
+							} `json:"team"`
+							Labels struct {
+								Nodes []struct {
+									Name string `json:"name"`
+								} `json:"nodes"`
+							} `json:"labels"`
+						} `json:"nodes"`
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+					} `json:"assignedIssues"`
+				} `json:"viewer"`
+			} `json:"data"`
+		}
+
+		if err := doJSON(req, &resp); err != nil {
+			return nil, err
+		}
+
+		if len(resp.Errors) > 0 {
+			return nil, errors.New(resp.Errors[0].Message)
+		}
+
+		issues := resp.Data.Viewer.AssignedIssues
+
+		for _, v := range issues.Nodes {
+			i := Issue{
+				Key:      v.Identifier,
+				Title:    v.Title,
+				URL:      v.URL,
+				Status:   v.State.Name,
+				Priority: v.PriorityLabel,
+				Project:  v.Team.Key,
+				Updated:  v.UpdatedAt,
+				Account:  l.Account(),
+			}
+
+			if len(v.Labels.Nodes) > 0 {
+				i.Type = v.Labels.Nodes[0].Name
+			}
+
+			res = append(res, i)
+		}
+
+		if !issues.PageInfo.HasNextPage {
+			return res, nil
+		}
+
+		after = &issues.PageInfo.EndCursor
+	}
+}
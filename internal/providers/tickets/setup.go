@@ -0,0 +1,348 @@
+// Package tickets provides issues assigned to you in Jira and Linear.
+package main
+
+import (
+	"crypto/md5"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/history"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "tickets"
+	NamePretty = "Tickets"
+	config     *Config
+	h          = history.Load(Name)
+	mu         sync.RWMutex
+	issues     = cache{Issues: make(map[string][]Issue)}
+	backends   = []Backend{}
+	fetching   sync.Mutex
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config    `koanf:",squash"`
+	History          bool     `koanf:"history" desc:"make use of history for sorting" default:"true"`
+	HistoryWhenEmpty bool     `koanf:"history_when_empty" desc:"consider history when query is empty" default:"true"`
+	Jira             []Jira   `koanf:"jira" desc:"jira accounts" default:""`
+	Linear           []Linear `koanf:"linear" desc:"linear accounts" default:""`
+	CacheTTL         int      `koanf:"cache_ttl" desc:"minutes to cache issues" default:"10"`
+	Open             string   `koanf:"open" desc:"command to open an issue. supports %URL%" default:"xdg-open %URL%"`
+	Copy             string   `koanf:"copy" desc:"command to copy a value. supports %VALUE%." default:"wl-copy, xclip on X11, pbcopy on macOS, clip on Windows"`
+	BranchTemplate   string   `koanf:"branch_template" desc:"template for branch names. supports %KEY%, %TITLE%, %TYPE% and %PROJECT%, all but the key are slugified" default:"%KEY%-%TITLE%"`
+	BranchMaxLength  int      `koanf:"branch_max_length" desc:"max length of branch names" default:"60"`
+}
+
+const (
+	ActionOpen       = "open"
+	ActionCopyBranch = "copy_branch"
+	ActionCopyKey    = "copy_key"
+	ActionRefresh    = "refresh"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "view-task",
+			MinScore: 30,
+		},
+		History:          true,
+		HistoryWhenEmpty: true,
+		CacheTTL:         10,
+		Open:             "xdg-open %URL%",
+		Copy:             common.CopyCommand(),
+		BranchTemplate:   "%KEY%-%TITLE%",
+		BranchMaxLength:  60,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	backends = []Backend{}
+
+	for _, v := range config.Jira {
+		backends = append(backends, v)
+	}
+
+	for _, v := range config.Linear {
+		backends = append(backends, v)
+	}
+
+	c := loadCache()
+
+	mu.Lock()
+	issues = c
+	mu.Unlock()
+
+	if stale() {
+		go fetch()
+	}
+}
+
+// Refresh fetches the issues, regardless of the cache.
+func Refresh() {
+	fetch()
+}
+
+func stale() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return time.Since(issues.Fetched) > time.Duration(config.CacheTTL)*time.Minute
+}
+
+// fetch queries all backends in parallel. Accounts that fail keep their cached issues.
+func fetch() {
+	if !fetching.TryLock() {
+		return
+	}
+	defer fetching.Unlock()
+
+	var wg sync.WaitGroup
+
+	mu.RLock()
+	res := cache{Fetched: time.Now(), Issues: maps.Clone(issues.Issues)}
+	mu.RUnlock()
+
+	var resMu sync.Mutex
+
+	for _, b := range backends {
+		wg.Go(func() {
+			list, err := b.Issues()
+			if err != nil {
+				slog.Error(Name, "fetch", err, "account", b.Account())
+				return
+			}
+
+			resMu.Lock()
+			res.Issues[b.Account()] = list
+			resMu.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	mu.Lock()
+	issues = res
+	mu.Unlock()
+
+	writeCache(res)
+
+	handlers.ProviderUpdated <- Name
+}
+
+func Available() bool {
+	if len(config.Jira) == 0 && len(config.Linear) == 0 {
+		slog.Info(Name, "available", "no accounts configured. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func issueID(i Issue) string {
+	md5 := md5.Sum([]byte(i.Account + i.Key))
+	return hex.EncodeToString(md5[:])
+}
+
+var nonAlnum = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slugify(s string) string {
+	return strings.Trim(nonAlnum.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+func branchName(i Issue) string {
+	res := strings.NewReplacer(
+		"%KEY%", i.Key,
+		"%TITLE%", slugify(i.Title),
+		"%TYPE%", slugify(i.Type),
+		"%PROJECT%", slugify(i.Project),
+	).Replace(config.BranchTemplate)
+
+	if config.BranchMaxLength > 0 && len(res) > config.BranchMaxLength {
+		res = res[:config.BranchMaxLength]
+	}
+
+	return strings.Trim(res, "-/")
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	switch action {
+	case history.ActionDelete:
+		h.Remove(identifier)
+		return
+	case ActionRefresh:
+		go fetch()
+		return
+	case "":
+		action = ActionOpen
+	}
+
+	var issue Issue
+	var found bool
+
+	mu.RLock()
+	for _, list := range issues.Issues {
+		for _, v := range list {
+			if issueID(v) == identifier {
+				issue = v
+				found = true
+			}
+		}
+	}
+	mu.RUnlock()
+
+	if !found {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown identifier: %s", identifier))
+		return
+	}
+
+	var cmd *exec.Cmd
+
+	switch action {
+	case ActionOpen:
+		cmd = common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), strings.ReplaceAll(config.Open, "%URL%", common.Quote(issue.URL)))))
+		common.Detach(cmd)
+	case ActionCopyBranch:
+		cmd = common.ReplaceResultOrStdinCmd(config.Copy, branchName(issue))
+	case ActionCopyKey:
+		cmd = common.ReplaceResultOrStdinCmd(config.Copy, issue.Key)
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	err := cmd.Start()
+	if err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+
+	if config.History {
+		h.Save(query, identifier)
+	}
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	res := []*pb.QueryResponse_Item{}
+
+	if stale() {
+		go fetch()
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for account, list := range issues.Issues {
+		for _, v := range list {
+			subtext := v.Status
+
+			if v.Priority != "" {
+				subtext = fmt.Sprintf("%s - %s", subtext, v.Priority)
+			}
+
+			subtext = fmt.Sprintf("%s - %s", v.Key, subtext)
+
+			state := []string{strings.Split(account, ":")[0], slugify(v.Status)}
+
+			if v.Priority != "" {
+				state = append(state, "priority_"+slugify(v.Priority))
+			}
+
+			e := &pb.QueryResponse_Item{
+				Identifier:  issueID(v),
+				Text:        v.Title,
+				Subtext:     subtext,
+				Icon:        config.Icon,
+				Provider:    Name,
+				Actions:     []string{ActionOpen, ActionCopyBranch, ActionCopyKey},
+				State:       state,
+				Type:        pb.QueryResponse_REGULAR,
+				Preview:     fmt.Sprintf("%s %s\n\nStatus: %s\nPriority: %s\nType: %s\nProject: %s\nUpdated: %s\nAccount: %s\n\n%s\nBranch: %s", v.Key, v.Title, v.Status, v.Priority, v.Type, v.Project, v.Updated.Local().Format(time.DateTime), account, v.URL, branchName(v)),
+				PreviewType: util.PreviewTypeText,
+			}
+
+			if query == "" {
+				// most recently updated first
+				e.Score = int32(max(0, 1000-int(time.Since(v.Updated).Hours())))
+			} else {
+				score, pos, start := common.FuzzyScore(query, v.Title, exact)
+
+				e.Score = score
+				e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+					Field:     "text",
+					Positions: pos,
+					Start:     start,
+				}
+
+				if s2, _, _ := common.FuzzyScore(query, v.Key, exact); s2 > score {
+					e.Score = s2
+					e.Fuzzyinfo = nil
+				}
+			}
+
+			if config.History {
+				if e.Score > config.MinScore || query == "" && config.HistoryWhenEmpty {
+					usageScore := h.CalcUsageScore(query, e.Identifier)
+
+					if usageScore != 0 {
+						e.State = append(e.State, "history")
+						e.Actions = append(e.Actions, history.ActionDelete)
+					}
+
+					e.Score = e.Score + usageScore
+				}
+			}
+
+			if e.Score > config.MinScore || query == "" {
+				res = append(res, e)
+			}
+		}
+	}
+
+	return res
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{
+		Actions: []string{ActionRefresh},
+	}
+}
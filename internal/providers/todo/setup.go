@@ -5,6 +5,7 @@ import (
 	_ "embed"
 	"encoding/gob"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"os"
@@ -65,6 +66,14 @@ func (config *Config) SetRepository(val *git.Repository) {
 	config.r = val
 }
 
+func (config *Config) GitReload() {
+	loadMu.Lock()
+	loaded = false
+	loadMu.Unlock()
+
+	loadItems()
+}
+
 type Category struct {
 	Name   string `koanf:"name" desc:"name for category" default:""`
 	Prefix string `koanf:"prefix" desc:"prefix to store item in category" default:""`
@@ -311,13 +320,17 @@ func duckPlayers() {
 	}
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if common.GitResolve(Name, action) {
+		return
+	}
+
 	i, _ := strconv.Atoi(identifier)
 
 	switch action {
@@ -672,6 +685,10 @@ func State(provider string) *pb.ProviderStateResponse {
 		actions = append(actions, ActionCreate)
 	}
 
+	gitStates, gitActions := common.GitState(Name)
+	states = append(states, gitStates...)
+	actions = append(actions, gitActions...)
+
 	for _, v := range items {
 		if v.State == StateDone {
 			return &pb.ProviderStateResponse{
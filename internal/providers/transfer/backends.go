@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// Device is a device files can be sent to.
+type Device struct {
+	// ID of the device within its backend.
+	ID        string
+	Name      string
+	Backend   string
+	Reachable bool
+}
+
+// Backend lists devices and sends files to them. Progress is called with the percentage of the
+// transfer, if the backend reports it.
+type Backend interface {
+	Name() string
+	Devices() ([]Device, error)
+	SendFile(id, file string, progress func(int)) error
+	SendText(id, text string) error
+}
+
+// listOptions shares the device lists between queries typed in quick succession.
+var listOptions = common.ExecOptions{CacheFor: 2 * time.Second}
+
+// kdeconnect uses kdeconnect-cli and only lists paired devices.
+type kdeconnect struct{}
+
+func (kdeconnect) Name() string {
+	return "kdeconnect"
+}
+
+// idNames parses the output of kdeconnect-cli with --id-name-only, one "<id> <name>" per line.
+func idNames(out []byte) map[string]string {
+	res := make(map[string]string)
+
+	for line := range strings.Lines(string(out)) {
+		id, name, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if id == "" {
+			continue
+		}
+
+		res[id] = name
+	}
+
+	return res
+}
+
+func (k kdeconnect) Devices() ([]Device, error) {
+	out, err := common.Exec(Name, exec.Command("kdeconnect-cli", "--list-devices", "--id-name-only"), listOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	known := idNames(out)
+
+	out, err = common.Exec(Name, exec.Command("kdeconnect-cli", "--list-available", "--id-name-only"), listOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	available := idNames(out)
+
+	res := []Device{}
+
+	for id, name := range known {
+		_, reachable := available[id]
+
+		res = append(res, Device{
+			ID:        id,
+			Name:      name,
+			Backend:   k.Name(),
+			Reachable: reachable,
+		})
+	}
+
+	return res, nil
+}
+
+func (kdeconnect) SendFile(id, file string, _ func(int)) error {
+	if out, err := exec.Command("kdeconnect-cli", "-d", id, "--share", file).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func (kdeconnect) SendText(id, text string) error {
+	if out, err := exec.Command("kdeconnect-cli", "-d", id, "--share-text", text).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// localsend runs the configured commands of a LocalSend CLI. Devices are always reachable, as only
+// devices found in the local network are listed.
+type localsend struct{}
+
+func (localsend) Name() string {
+	return "localsend"
+}
+
+func (l localsend) Devices() ([]Device, error) {
+	out, err := common.ExecShell(Name, config.LocalSend.Devices, listOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Device{}
+
+	for id, name := range idNames(out) {
+		if name == "" {
+			name = id
+		}
+
+		res = append(res, Device{
+			ID:        id,
+			Name:      name,
+			Backend:   l.Name(),
+			Reachable: true,
+		})
+	}
+
+	return res, nil
+}
+
+var percentage = regexp.MustCompile(`(\d{1,3})(?:\.\d+)?%`)
+
+func (localsend) SendFile(id, file string, progress func(int)) error {
+	cmd := strings.ReplaceAll(config.LocalSend.Send, "%DEVICE%", common.Quote(id))
+	cmd = strings.ReplaceAll(cmd, "%FILE%", common.Quote(file))
+
+	c := common.HostShell(fmt.Sprintf("%s 2>&1", cmd))
+
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	var last string
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Split(scanProgress)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			last = line
+		}
+
+		m := percentage.FindAllStringSubmatch(line, -1)
+		if len(m) == 0 {
+			continue
+		}
+
+		if p, err := strconv.Atoi(m[len(m)-1][1]); err == nil {
+			progress(min(p, 100))
+		}
+	}
+
+	if err := c.Wait(); err != nil {
+		return fmt.Errorf("%w: %s", err, last)
+	}
+
+	return nil
+}
+
+func (l localsend) SendText(id, text string) error {
+	f, err := os.CreateTemp("", "elephant-transfer-*.txt")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+
+	f.Close()
+
+	return l.SendFile(id, f.Name(), func(int) {})
+}
+
+// scanProgress splits lines at carriage returns as well, as progress bars redraw the same line.
+func scanProgress(data []byte, atEOF bool) (int, []byte, error) {
+	for i, b := range data {
+		if b == '\n' || b == '\r' {
+			return i + 1, data[:i], nil
+		}
+	}
+
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+func backends() []Backend {
+	res := []Backend{}
+
+	for _, v := range config.Backends {
+		switch v {
+		case "kdeconnect":
+			if _, err := exec.LookPath("kdeconnect-cli"); err == nil {
+				res = append(res, kdeconnect{})
+			}
+		case "localsend":
+			if config.LocalSend.Devices != "" && config.LocalSend.Send != "" {
+				res = append(res, localsend{})
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,446 @@
+// Package transfer sends files and the clipboard to devices via KDE Connect or LocalSend.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "transfer"
+	NamePretty = "Send to Device"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Backends      []string  `koanf:"backends" desc:"backends to use, 'kdeconnect' and 'localsend'" default:"['kdeconnect', 'localsend']"`
+	LocalSend     LocalSend `koanf:"localsend" desc:"commands of the LocalSend CLI" default:""`
+	Notify        bool      `koanf:"notify" desc:"show a notification when a transfer is done" default:"true"`
+}
+
+type LocalSend struct {
+	Devices string `koanf:"devices" desc:"command printing the devices in the network, one '<id> <name>' per line" default:"localsend devices"`
+	Send    string `koanf:"send" desc:"command sending a file, supports %DEVICE% and %FILE%. a percentage in the output is shown as progress" default:"localsend send %DEVICE% %FILE%"`
+}
+
+const (
+	ActionSendFile      = "send_file"
+	ActionSendClipboard = "send_clipboard"
+)
+
+// transfer is the last transfer to a device.
+type transfer struct {
+	File     string
+	Progress int
+	State    string
+	Error    string
+}
+
+var (
+	mu sync.Mutex
+	// devices of the last query, by identifier
+	devices   = make(map[string]Device)
+	transfers = make(map[string]*transfer)
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "send-to",
+			MinScore: 20,
+		},
+		Backends: []string{"kdeconnect", "localsend"},
+		LocalSend: LocalSend{
+			Devices: "localsend devices",
+			Send:    "localsend send %DEVICE% %FILE%",
+		},
+		Notify: true,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	// the LocalSend commands are configurable, so it's only used if its binary exists
+	if f := strings.Fields(config.LocalSend.Devices); len(f) > 0 {
+		if _, err := exec.LookPath(f[0]); err != nil {
+			config.Backends = slices.DeleteFunc(config.Backends, func(v string) bool {
+				return v == "localsend"
+			})
+		}
+	}
+}
+
+func Available() bool {
+	if len(backends()) == 0 {
+		slog.Info(Name, "available", "kdeconnect-cli or a LocalSend CLI not found. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+var fileArgument = &pb.ActionArgument{Name: "file", Type: util.ArgumentPath, Placeholder: "File to send"}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionSendFile, Label: "Send file", Icon: "document-send", Default: true, Arguments: []*pb.ActionArgument{fileArgument}},
+		{Action: ActionSendClipboard, Label: "Send clipboard", Icon: "edit-paste"},
+	}
+}
+
+func backendByName(name string) (Backend, bool) {
+	for _, v := range backends() {
+		if v.Name() == name {
+			return v, true
+		}
+	}
+
+	return nil, false
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	mu.Lock()
+	d, ok := devices[identifier]
+	mu.Unlock()
+
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown device: %s", identifier))
+		return
+	}
+
+	if !d.Reachable {
+		slog.Error(Name, "activate", fmt.Sprintf("device not reachable: %s", d.Name))
+		return
+	}
+
+	b, ok := backendByName(d.Backend)
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown backend: %s", d.Backend))
+		return
+	}
+
+	if action == "" {
+		action = ActionSendFile
+	}
+
+	switch action {
+	case ActionSendFile:
+		if args == "" {
+			args = query
+		}
+
+		f := strings.TrimSpace(args)
+
+		if !common.FileExists(f) {
+			slog.Error(Name, "send", fmt.Sprintf("file not found: %s", f))
+			return
+		}
+
+		send(d, b, []string{f}, "", format, query, conn)
+	case ActionSendClipboard:
+		text := common.ClipboardText()
+		if text == "" {
+			slog.Error(Name, "send", "clipboard is empty")
+			return
+		}
+
+		// files copied in file managers are sent as files instead of their paths
+		if files := clipboardFiles(text); len(files) > 0 {
+			send(d, b, files, "", format, query, conn)
+			return
+		}
+
+		send(d, b, nil, text, format, query, conn)
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+// clipboardFiles returns the files of a clipboard containing only existing paths or file uris.
+func clipboardFiles(text string) []string {
+	res := []string{}
+
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+
+		if u, err := url.Parse(line); err == nil && u.Scheme == "file" {
+			line = u.Path
+		}
+
+		if !filepath.IsAbs(line) || !common.FileExists(line) {
+			return nil
+		}
+
+		res = append(res, line)
+	}
+
+	return res
+}
+
+// send transfers the files, or the text if there are none, and pushes the progress as updates of the
+// device item.
+func send(d Device, b Backend, files []string, text string, format uint8, query string, conn net.Conn) {
+	identifier := fmt.Sprintf("%s/%s", d.Backend, d.ID)
+
+	name := "clipboard"
+	if len(files) == 1 {
+		name = filepath.Base(files[0])
+	} else if len(files) > 1 {
+		name = fmt.Sprintf("%d files", len(files))
+	}
+
+	t := &transfer{
+		File:     name,
+		Progress: -1,
+		State:    "sending",
+	}
+
+	mu.Lock()
+	if c, ok := transfers[identifier]; ok && c.State == "sending" {
+		mu.Unlock()
+		slog.Error(Name, "send", fmt.Sprintf("already sending to %s", d.Name))
+		return
+	}
+
+	transfers[identifier] = t
+	mu.Unlock()
+
+	update := func() {
+		mu.Lock()
+		e := deviceEntry(d, t)
+		mu.Unlock()
+
+		handlers.UpdateItem(format, query, conn, e)
+	}
+
+	update()
+
+	go func() {
+		var err error
+		last := time.Now()
+
+		progress := func(done int) func(int) {
+			return func(p int) {
+				mu.Lock()
+				t.Progress = (done*100 + p) / max(len(files), 1)
+				mu.Unlock()
+
+				// limits the amount of updates sent to the client
+				if time.Since(last) < 250*time.Millisecond {
+					return
+				}
+
+				last = time.Now()
+				update()
+			}
+		}
+
+		if len(files) == 0 {
+			err = b.SendText(d.ID, text)
+		}
+
+		for k, v := range files {
+			if err = b.SendFile(d.ID, v, progress(k)); err != nil {
+				break
+			}
+		}
+
+		mu.Lock()
+		if err != nil {
+			slog.Error(Name, "send", err)
+
+			t.State = "failed"
+			t.Error = err.Error()
+		} else {
+			t.State = "sent"
+			t.Progress = 100
+		}
+		mu.Unlock()
+
+		update()
+		notify(d, t)
+	}()
+}
+
+func notify(d Device, t *transfer) {
+	if !config.Notify {
+		return
+	}
+
+	n := common.Notification{
+		Title:   fmt.Sprintf("Sent to %s", d.Name),
+		Body:    t.File,
+		Icon:    config.Icon,
+		Urgency: "low",
+	}
+
+	if t.State == "failed" {
+		n.Title = fmt.Sprintf("Sending to %s failed", d.Name)
+		n.Body = fmt.Sprintf("%s: %s", t.File, t.Error)
+		n.Urgency = "normal"
+	}
+
+	if _, err := common.Notify(n); err != nil {
+		slog.Error(Name, "notify", err)
+	}
+}
+
+// deviceEntry expects mu to be locked.
+func deviceEntry(d Device, t *transfer) *pb.QueryResponse_Item {
+	s := []string{}
+	a := []string{}
+
+	sub := d.Backend
+
+	if d.Reachable {
+		s = append(s, "reachable")
+		a = append(a, ActionSendFile, ActionSendClipboard)
+	} else {
+		s = append(s, "unreachable")
+		sub = fmt.Sprintf("%s - unreachable", sub)
+	}
+
+	if t != nil {
+		s = append(s, t.State)
+
+		switch t.State {
+		case "sending":
+			if t.Progress >= 0 {
+				sub = fmt.Sprintf("%s - sending %s %d%%", d.Backend, t.File, t.Progress)
+			} else {
+				sub = fmt.Sprintf("%s - sending %s...", d.Backend, t.File)
+			}
+		case "sent":
+			sub = fmt.Sprintf("%s - sent %s", d.Backend, t.File)
+		case "failed":
+			sub = fmt.Sprintf("%s - failed: %s", d.Backend, t.Error)
+		}
+	}
+
+	icon := "phone"
+	if d.Backend == "localsend" {
+		icon = "computer"
+	}
+
+	return &pb.QueryResponse_Item{
+		Identifier: fmt.Sprintf("%s/%s", d.Backend, d.ID),
+		Text:       d.Name,
+		Subtext:    sub,
+		Icon:       icon,
+		Provider:   Name,
+		State:      s,
+		Actions:    a,
+		Type:       pb.QueryResponse_REGULAR,
+	}
+}
+
+func Query(conn net.Conn, query string, _ bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	start := time.Now()
+	entries := []*pb.QueryResponse_Item{}
+
+	list := []Device{}
+
+	for _, b := range backends() {
+		d, err := b.Devices()
+		if err != nil {
+			slog.Error(Name, b.Name(), err)
+			continue
+		}
+
+		list = append(list, d...)
+	}
+
+	slices.SortFunc(list, func(a, b Device) int {
+		if a.Reachable != b.Reachable {
+			if a.Reachable {
+				return -1
+			}
+
+			return 1
+		}
+
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	clear(devices)
+
+	for k, v := range list {
+		identifier := fmt.Sprintf("%s/%s", v.Backend, v.ID)
+		devices[identifier] = v
+
+		e := deviceEntry(v, transfers[identifier])
+		e.Score = 1000 - int32(k)
+
+		if query != "" && !common.FileExists(strings.TrimSpace(query)) {
+			score, pos, start := common.FuzzyScore(query, v.Name, exact)
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Field:     "text",
+				Positions: pos,
+				Start:     start,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	slog.Debug(Name, "query", time.Since(start))
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	states := []string{}
+
+	for _, v := range backends() {
+		states = append(states, fmt.Sprintf("backend:%s", v.Name()))
+	}
+
+	return &pb.ProviderStateResponse{
+		States: states,
+	}
+}
@@ -3,10 +3,12 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	_ "embed"
@@ -14,6 +16,7 @@ import (
 	"github.com/abenz1267/elephant/v2/internal/util"
 	"github.com/abenz1267/elephant/v2/pkg/common"
 	"github.com/abenz1267/elephant/v2/pkg/common/history"
+	"github.com/abenz1267/elephant/v2/pkg/common/memory"
 	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 )
 
@@ -34,12 +37,15 @@ type Config struct {
 	Locale           string `koanf:"locale" desc:"locale to use for symbols" default:"en"`
 	History          bool   `koanf:"history" desc:"make use of history for sorting" default:"true"`
 	HistoryWhenEmpty bool   `koanf:"history_when_empty" desc:"consider history when query is empty" default:"false"`
-	Command          string `koanf:"command" desc:"default command to be executed. supports %VALUE%." default:"wl-copy"`
+	Command          string `koanf:"command" desc:"default command to be executed. supports %VALUE%." default:"wl-copy, xclip on X11, pbcopy on macOS, clip on Windows"`
 }
 
 var (
-	config  *Config
-	symbols = make(map[string]string)
+	config *Config
+	// symbols maps names to code points. It's nil after being evicted, then pages of the store are read.
+	symbols   = make(map[string]string)
+	size      int64
+	symbolsMu sync.RWMutex
 )
 
 func Setup() {
@@ -53,7 +59,7 @@ func Setup() {
 		Locale:           "en",
 		History:          true,
 		HistoryWhenEmpty: false,
-		Command:          "wl-copy",
+		Command:          common.CopyCommand(),
 	}
 
 	common.LoadConfig(Name, config)
@@ -62,26 +68,57 @@ func Setup() {
 		NamePretty = config.NamePretty
 	}
 
+	// the data is embedded, parsing it is faster than decoding a snapshot. It's parsed off the start
+	// path instead, queries wait for the lock until it's done.
+	symbolsMu.Lock()
+
+	go func() {
+		defer symbolsMu.Unlock()
+
+		symbols, size = parse()
+
+		slog.Info(Name, "loaded", time.Since(start))
+	}()
+
+	memory.Register(memory.Index{
+		Name: Name,
+		Size: func() int64 {
+			symbolsMu.RLock()
+			defer symbolsMu.RUnlock()
+
+			return size
+		},
+		Evict:  evict,
+		Reload: reload,
+	})
+}
+
+// parse returns the symbols of the embedded data and their size.
+func parse() (map[string]string, int64) {
+	res := make(map[string]string)
+	var size int64
+
 	for v := range strings.Lines(data) {
 		if v == "" {
 			continue
 		}
 
 		fields := strings.SplitN(v, ";", 3)
-		symbols[fields[1]] = fields[0]
+		res[fields[1]] = fields[0]
+		size += int64(len(fields[0]) + len(fields[1]))
 	}
 
-	slog.Info(Name, "loaded", time.Since(start))
+	return res, size
 }
 
 func Available() bool {
 	return true
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 const ActionRunCmd = "run_cmd"
@@ -92,7 +129,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 		h.Remove(identifier)
 		return
 	case ActionRunCmd:
-		codePoint, err := strconv.ParseInt(symbols[identifier], 16, 32)
+		codePoint, err := strconv.ParseInt(lookupCodePoint(identifier), 16, 32)
 		if err != nil {
 			slog.Error(Name, "activate parse unicode", err)
 			return
@@ -124,7 +161,9 @@ func Query(conn net.Conn, query string, _ bool, exact bool, _ uint8) []*pb.Query
 	start := time.Now()
 	entries := []*pb.QueryResponse_Item{}
 
-	for k, v := range symbols {
+	memory.Touch(Name)
+
+	each(func(k, v string) {
 		score, positions, start := common.FuzzyScore(query, k, exact)
 
 		var usageScore int32
@@ -158,7 +197,7 @@ func Query(conn net.Conn, query string, _ bool, exact bool, _ uint8) []*pb.Query
 				Type: pb.QueryResponse_REGULAR,
 			})
 		}
-	}
+	})
 
 	slog.Debug(Name, "query", time.Since(start))
 	return entries
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"database/sql"
+	"log/slog"
+
+	"github.com/abenz1267/elephant/v2/pkg/common/store"
+)
+
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS symbols (
+		name TEXT PRIMARY KEY,
+		codepoint TEXT NOT NULL
+	);`,
+}
+
+var db *store.Store
+
+// evict spills the symbols to the store and drops them from memory.
+func evict() {
+	symbolsMu.Lock()
+	defer symbolsMu.Unlock()
+
+	if symbols == nil {
+		return
+	}
+
+	if err := spill(); err != nil {
+		slog.Error(Name, "spill", err)
+		return
+	}
+
+	symbols = nil
+	size = 0
+}
+
+// reload parses the symbols again after they got evicted. Queries read the store until it's done.
+func reload() {
+	parsed, n := parse()
+
+	symbolsMu.Lock()
+	symbols, size = parsed, n
+	symbolsMu.Unlock()
+
+	slog.Info(Name, "reloaded", len(parsed))
+}
+
+// spill writes the symbols to the store. The table is only derived from the embedded data, so it's
+// replaced completely.
+func spill() error {
+	if db == nil {
+		var err error
+
+		if db, err = store.Open(Name, migrations...); err != nil {
+			return err
+		}
+	}
+
+	return db.Tx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM symbols"); err != nil {
+			return err
+		}
+
+		stmt, err := tx.Prepare("INSERT INTO symbols (name, codepoint) VALUES (?, ?)")
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for k, v := range symbols {
+			if _, err := stmt.Exec(k, v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// each calls fn with every symbol, reading pages of the store if they got evicted.
+func each(fn func(name, codePoint string)) {
+	symbolsMu.RLock()
+	defer symbolsMu.RUnlock()
+
+	if symbols != nil {
+		for k, v := range symbols {
+			fn(k, v)
+		}
+
+		return
+	}
+
+	err := db.Pages("SELECT name, codepoint FROM symbols ORDER BY name", 2000, func(rows *sql.Rows) error {
+		var name, cp string
+
+		if err := rows.Scan(&name, &cp); err != nil {
+			return err
+		}
+
+		fn(name, cp)
+
+		return nil
+	})
+	if err != nil {
+		slog.Error(Name, "read", err)
+	}
+}
+
+func lookupCodePoint(name string) string {
+	symbolsMu.RLock()
+	defer symbolsMu.RUnlock()
+
+	if symbols != nil {
+		return symbols[name]
+	}
+
+	var cp string
+
+	if err := db.QueryRow("SELECT codepoint FROM symbols WHERE name = ?", name).Scan(&cp); err != nil {
+		slog.Error(Name, "read", err)
+	}
+
+	return cp
+}
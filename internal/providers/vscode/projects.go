@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charlievieth/fastwalk"
+)
+
+var (
+	projects   = []Entry{}
+	projectsMu sync.Mutex
+)
+
+// scanProjects looks for git repositories and *.code-workspace files in the configured project roots.
+// Repositories aren't descended into.
+func scanProjects() {
+	start := time.Now()
+
+	found := []Entry{}
+	var mut sync.Mutex
+
+	conf := fastwalk.Config{
+		Follow: false,
+	}
+
+	for _, root := range config.ProjectRoots {
+		if after, ok := strings.CutPrefix(root, "~/"); ok {
+			home, _ := os.UserHomeDir()
+			root = filepath.Join(home, after)
+		}
+
+		root = filepath.Clean(root)
+		depth := strings.Count(root, string(filepath.Separator))
+
+		walkFn := func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if d.IsDir() {
+				if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+					return filepath.SkipDir
+				}
+
+				if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+					mut.Lock()
+					found = append(found, newEntry(fileURI(path), KindFolder, ""))
+					mut.Unlock()
+
+					return filepath.SkipDir
+				}
+
+				if strings.Count(path, string(filepath.Separator))-depth >= config.ProjectDepth {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if strings.HasSuffix(d.Name(), ".code-workspace") {
+				mut.Lock()
+				found = append(found, newEntry(fileURI(path), KindWorkspace, ""))
+				mut.Unlock()
+			}
+
+			return nil
+		}
+
+		if err := fastwalk.Walk(&conf, root, walkFn); err != nil {
+			slog.Error(Name, "projects", err, "root", root)
+		}
+	}
+
+	slices.SortFunc(found, func(a, b Entry) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	projectsMu.Lock()
+	projects = found
+	projectsMu.Unlock()
+
+	entriesMu.Lock()
+	clear(modified)
+	entriesMu.Unlock()
+
+	slog.Info(Name, "projects", len(found), "time", time.Since(start))
+}
+
+func fileURI(path string) string {
+	u := url.URL{Scheme: "file", Path: path}
+	return u.String()
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	KindFolder    = "folder"
+	KindWorkspace = "workspace"
+	KindFile      = "file"
+)
+
+type Flavor struct {
+	Name     string `koanf:"name" desc:"name of the flavor, f.e. 'codium'" default:""`
+	Label    string `koanf:"label" desc:"label displayed for entries of this flavor" default:""`
+	Command  string `koanf:"command" desc:"command used to open entries" default:""`
+	Dir      string `koanf:"dir" desc:"config dir, relative to $XDG_CONFIG_HOME, absolute or starting with ~/" default:""`
+	Icon     string `koanf:"icon" desc:"icon for entries of this flavor" default:""`
+	Disabled bool   `koanf:"disabled" desc:"ignore this flavor" default:"false"`
+}
+
+var defaultFlavors = []Flavor{
+	{Name: "code", Label: "VSCode", Command: "code", Dir: "Code", Icon: "vscode"},
+	{Name: "code-oss", Label: "Code - OSS", Command: "code-oss", Dir: "Code - OSS", Icon: "code-oss"},
+	{Name: "codium", Label: "VSCodium", Command: "codium", Dir: "VSCodium", Icon: "vscodium"},
+	{Name: "code-insiders", Label: "VSCode Insiders", Command: "code-insiders", Dir: "Code - Insiders", Icon: "vscode-insiders"},
+	{Name: "cursor", Label: "Cursor", Command: "cursor", Dir: "Cursor", Icon: "cursor"},
+}
+
+// Entry is a recently opened folder, workspace or file. Entries opened with several flavors are merged,
+// Flavors is ordered by recency. Discovered projects that haven't been opened yet have no flavors.
+type Entry struct {
+	Identifier string
+	URI        string
+	Kind       string
+	Label      string
+	Path       string
+	Flavors    []string
+	Rank       int
+	Remote     Remote
+}
+
+type recentList struct {
+	Entries []struct {
+		FolderURI string `json:"folderUri"`
+		FileURI   string `json:"fileUri"`
+		Label     string `json:"label"`
+		Workspace *struct {
+			ConfigPath string `json:"configPath"`
+		} `json:"workspace"`
+	} `json:"entries"`
+}
+
+var (
+	entries   = []Entry{}
+	entriesMu sync.Mutex
+	modified  = make(map[string]time.Time)
+)
+
+// mergeFlavors applies the configured flavors onto the builtin ones, matched by name.
+func mergeFlavors(configured []Flavor) []Flavor {
+	res := append([]Flavor{}, defaultFlavors...)
+
+	for _, c := range configured {
+		i := -1
+
+		for k, v := range res {
+			if v.Name == c.Name {
+				i = k
+				break
+			}
+		}
+
+		if i == -1 {
+			if c.Label == "" {
+				c.Label = c.Name
+			}
+
+			if c.Command == "" {
+				c.Command = c.Name
+			}
+
+			res = append(res, c)
+			continue
+		}
+
+		f := &res[i]
+		f.Disabled = c.Disabled
+
+		if c.Label != "" {
+			f.Label = c.Label
+		}
+
+		if c.Command != "" {
+			f.Command = c.Command
+		}
+
+		if c.Dir != "" {
+			f.Dir = c.Dir
+		}
+
+		if c.Icon != "" {
+			f.Icon = c.Icon
+		}
+	}
+
+	return res
+}
+
+func (f Flavor) stateFile() string {
+	dir := f.Dir
+
+	if after, ok := strings.CutPrefix(dir, "~/"); ok {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, after)
+	}
+
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(xdg.ConfigHome, dir)
+	}
+
+	return filepath.Join(dir, "User", "globalStorage", "state.vscdb")
+}
+
+func (f Flavor) installed() bool {
+	if f.Disabled {
+		return false
+	}
+
+	fields := strings.Fields(f.Command)
+	if len(fields) == 0 {
+		return false
+	}
+
+	if _, err := exec.LookPath(fields[0]); err != nil {
+		return false
+	}
+
+	_, err := os.Stat(f.stateFile())
+
+	return err == nil
+}
+
+func flavorByName(name string) (Flavor, bool) {
+	for _, v := range flavors {
+		if v.Name == name {
+			return v, true
+		}
+	}
+
+	return Flavor{}, false
+}
+
+// loadEntries re-reads the recent lists if any state.vscdb changed since the last call.
+func loadEntries() []Entry {
+	entriesMu.Lock()
+	defer entriesMu.Unlock()
+
+	changed := false
+
+	for _, f := range flavors {
+		if !f.installed() {
+			continue
+		}
+
+		info, err := os.Stat(f.stateFile())
+		if err != nil {
+			continue
+		}
+
+		if !info.ModTime().Equal(modified[f.Name]) {
+			modified[f.Name] = info.ModTime()
+			changed = true
+		}
+	}
+
+	if !changed {
+		return entries
+	}
+
+	merged := []Entry{}
+	index := make(map[string]int)
+	ranks := make(map[string]map[string]int)
+
+	for _, f := range flavors {
+		if !f.installed() {
+			continue
+		}
+
+		for k, v := range readRecent(f) {
+			if i, ok := index[v.Identifier]; ok {
+				merged[i].Flavors = append(merged[i].Flavors, f.Name)
+				merged[i].Rank = min(merged[i].Rank, k)
+				ranks[v.Identifier][f.Name] = k
+				continue
+			}
+
+			v.Rank = k
+			v.Flavors = []string{f.Name}
+			index[v.Identifier] = len(merged)
+			ranks[v.Identifier] = map[string]int{f.Name: k}
+			merged = append(merged, v)
+		}
+	}
+
+	// there are no timestamps, the position in each recent list is the best guess for recency.
+	for _, v := range merged {
+		slices.SortStableFunc(v.Flavors, func(a, b string) int {
+			return ranks[v.Identifier][a] - ranks[v.Identifier][b]
+		})
+	}
+
+	projectsMu.Lock()
+	for _, v := range projects {
+		if _, ok := index[v.Identifier]; !ok {
+			v.Rank = len(merged)
+			merged = append(merged, v)
+		}
+	}
+	projectsMu.Unlock()
+
+	entries = merged
+
+	return entries
+}
+
+func readRecent(f Flavor) []Entry {
+	db, err := sql.Open("sqlite3", "file:"+f.stateFile()+"?mode=ro")
+	if err != nil {
+		slog.Error(Name, "open", err, "flavor", f.Name)
+		return nil
+	}
+	defer db.Close()
+
+	var value string
+
+	err = db.QueryRow(`SELECT value FROM ItemTable WHERE key = 'history.recentlyOpenedPathsList'`).Scan(&value)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.Error(Name, "recent", err, "flavor", f.Name)
+		}
+
+		return nil
+	}
+
+	var list recentList
+
+	if err := json.Unmarshal([]byte(value), &list); err != nil {
+		slog.Error(Name, "recent", err, "flavor", f.Name)
+		return nil
+	}
+
+	res := []Entry{}
+
+	for _, v := range list.Entries {
+		switch {
+		case v.FolderURI != "":
+			res = append(res, newEntry(v.FolderURI, KindFolder, v.Label))
+		case v.Workspace != nil && v.Workspace.ConfigPath != "":
+			res = append(res, newEntry(v.Workspace.ConfigPath, KindWorkspace, v.Label))
+		case v.FileURI != "":
+			res = append(res, newEntry(v.FileURI, KindFile, v.Label))
+		}
+	}
+
+	return res
+}
+
+// newEntry identifies local entries by their path, so entries from the recent lists and discovered
+// projects match regardless of how the uri got encoded.
+func newEntry(uri, kind, label string) Entry {
+	e := Entry{
+		URI:   uri,
+		Kind:  kind,
+		Label: label,
+		Path:  displayPath(uri),
+	}
+
+	if r, ok := parseRemote(uri); ok {
+		e.Remote = r
+
+		_, e.Path, _ = remoteParts(uri)
+
+		if label == "" {
+			e.Label = filepath.Base(e.Path)
+		}
+	}
+
+	if e.Label == "" {
+		e.Label = strings.TrimSuffix(filepath.Base(e.Path), ".code-workspace")
+	}
+
+	key := uri
+
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		key = u.Path
+	}
+
+	md5 := md5.Sum([]byte(key))
+	e.Identifier = hex.EncodeToString(md5[:])
+
+	return e
+}
+
+func displayPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+
+	if u.Scheme != "file" {
+		return uri
+	}
+
+	home, _ := os.UserHomeDir()
+
+	if home != "" {
+		if after, ok := strings.CutPrefix(u.Path, home); ok {
+			return "~" + after
+		}
+	}
+
+	return u.Path
+}
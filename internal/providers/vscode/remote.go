@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RemoteSSH          = "ssh-remote"
+	RemoteDevContainer = "dev-container"
+	RemoteAttached     = "attached-container"
+	RemoteTunnel       = "tunnel"
+	RemoteWSL          = "wsl"
+	RemoteCodespaces   = "codespaces"
+
+	probeTimeout = 2 * time.Second
+	probeTTL     = time.Minute
+)
+
+// Remote is the decoded authority of a vscode-remote uri.
+type Remote struct {
+	Kind  string
+	Host  string
+	Label string
+	// Local is the folder on this machine, if the remote has one. F.e. the host folder of a dev container.
+	Local string
+}
+
+var remoteLabels = map[string]string{
+	RemoteSSH:          "SSH",
+	RemoteDevContainer: "Dev Container",
+	RemoteAttached:     "Container",
+	RemoteTunnel:       "Tunnel",
+	RemoteWSL:          "WSL",
+	RemoteCodespaces:   "Codespaces",
+}
+
+type probe struct {
+	reachable bool
+	checked   time.Time
+}
+
+var (
+	probes   = make(map[string]probe)
+	probesMu sync.Mutex
+)
+
+// remoteParts splits a vscode-remote uri into authority and path. net/url rejects the escaped '+' in the authority.
+func remoteParts(uri string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(uri, "vscode-remote://")
+	if !ok {
+		return "", "", false
+	}
+
+	authority, path, _ := strings.Cut(rest, "/")
+
+	authority, err := url.PathUnescape(authority)
+	if err != nil {
+		return "", "", false
+	}
+
+	path, err = url.PathUnescape("/" + path)
+	if err != nil {
+		return "", "", false
+	}
+
+	return authority, path, true
+}
+
+func parseRemote(uri string) (Remote, bool) {
+	authority, _, ok := remoteParts(uri)
+	if !ok {
+		return Remote{}, false
+	}
+
+	kind, value, _ := strings.Cut(authority, "+")
+
+	r := Remote{
+		Kind: kind,
+		Host: value,
+	}
+
+	switch kind {
+	case RemoteDevContainer, RemoteAttached:
+		r.Host, r.Local = decodeContainer(value)
+	}
+
+	label, ok := remoteLabels[kind]
+	if !ok {
+		label = kind
+	}
+
+	r.Label = label
+
+	if r.Host != "" {
+		r.Label = label + ": " + r.Host
+	}
+
+	return r, true
+}
+
+// decodeContainer decodes the hex encoded container authority. It's either a plain host path or json.
+func decodeContainer(value string) (string, string) {
+	b, err := hex.DecodeString(value)
+	if err != nil {
+		return value, ""
+	}
+
+	var info struct {
+		HostPath      string `json:"hostPath"`
+		ContainerName string `json:"containerName"`
+	}
+
+	if err := json.Unmarshal(b, &info); err != nil {
+		path := string(b)
+		return filepath.Base(path), path
+	}
+
+	if info.ContainerName != "" {
+		return strings.TrimPrefix(info.ContainerName, "/"), ""
+	}
+
+	return filepath.Base(info.HostPath), info.HostPath
+}
+
+// cachedProbe returns the last probe result and whether it's still fresh. Unprobed remotes count as reachable.
+func cachedProbe(uri string) (bool, bool) {
+	probesMu.Lock()
+	defer probesMu.Unlock()
+
+	p, ok := probes[uri]
+	if !ok {
+		return true, false
+	}
+
+	return p.reachable, time.Since(p.checked) < probeTTL
+}
+
+// startProbe runs the probe in the background and calls done with the result.
+func startProbe(uri string, r Remote, done func(bool)) {
+	probesMu.Lock()
+	p, ok := probes[uri]
+
+	// keep the previous result while probing, so concurrent queries don't start the same probe.
+	probes[uri] = probe{reachable: !ok || p.reachable, checked: time.Now()}
+	probesMu.Unlock()
+
+	go func() {
+		res := runProbe(r)
+
+		probesMu.Lock()
+		probes[uri] = probe{reachable: res, checked: time.Now()}
+		probesMu.Unlock()
+
+		done(res)
+	}()
+}
+
+func runProbe(r Remote) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	switch r.Kind {
+	case RemoteSSH:
+		host, port := sshTarget(ctx, r.Host)
+
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+		if err != nil {
+			return false
+		}
+
+		conn.Close()
+
+		return true
+	case RemoteDevContainer, RemoteAttached:
+		if r.Local != "" {
+			if _, err := os.Stat(r.Local); err != nil {
+				return false
+			}
+		}
+
+		for _, v := range []string{"docker", "podman"} {
+			if _, err := exec.LookPath(v); err != nil {
+				continue
+			}
+
+			if exec.CommandContext(ctx, v, "info").Run() == nil {
+				return true
+			}
+		}
+
+		return false
+	case RemoteWSL:
+		return false
+	}
+
+	return true
+}
+
+// sshTarget resolves aliases from the ssh config.
+func sshTarget(ctx context.Context, host string) (string, string) {
+	if _, after, ok := strings.Cut(host, "@"); ok {
+		host = after
+	}
+
+	resolved, port := host, "22"
+
+	out, err := exec.CommandContext(ctx, "ssh", "-G", host).Output()
+	if err != nil {
+		return resolved, port
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), " ")
+
+		switch key {
+		case "hostname":
+			resolved = value
+		case "port":
+			port = value
+		}
+	}
+
+	return resolved, port
+}
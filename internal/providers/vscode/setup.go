@@ -0,0 +1,363 @@
+// Package vscode provides recently opened folders, workspaces and files of VSCode and its forks,
+// as well as projects found in configured directories.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/history"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	Name       = "vscode"
+	NamePretty = "VSCode"
+	config     *Config
+	flavors    = []Flavor{}
+	h          = history.Load(Name)
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config    `koanf:",squash"`
+	History          bool     `koanf:"history" desc:"make use of history for sorting" default:"true"`
+	HistoryWhenEmpty bool     `koanf:"history_when_empty" desc:"consider history when query is empty" default:"false"`
+	Flavors          []Flavor `koanf:"flavors" desc:"override or add flavors, matched by name. builtin: code, code-oss, codium, code-insiders, cursor" default:""`
+	ProjectRoots     []string `koanf:"project_roots" desc:"directories to scan for git repositories and *.code-workspace files" default:""`
+	ProjectDepth     int      `koanf:"project_depth" desc:"max depth to scan project roots" default:"3"`
+	ProbeRemotes     bool     `koanf:"probe_remotes" desc:"check if ssh remotes and dev containers are reachable" default:"true"`
+	Copy             string   `koanf:"copy" desc:"command to copy the remote uri. supports %VALUE%." default:"wl-copy, xclip on X11, pbcopy on macOS, clip on Windows"`
+}
+
+const (
+	ActionOpen      = "open"
+	ActionNewWindow = "open_new_window"
+	ActionOpenLocal = "open_local"
+	ActionCopyURI   = "copy_remote_uri"
+	// ActionOpenWith is followed by the flavor name, f.e. 'open_with:codium'.
+	ActionOpenWith = "open_with:"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "vscode",
+			MinScore: 30,
+		},
+		History:          true,
+		HistoryWhenEmpty: false,
+		ProjectDepth:     3,
+		ProbeRemotes:     true,
+		Copy:             common.CopyCommand(),
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	flavors = mergeFlavors(config.Flavors)
+
+	if len(config.ProjectRoots) > 0 {
+		go scanProjects()
+	}
+}
+
+// Refresh rescans the project roots.
+func Refresh() {
+	scanProjects()
+}
+
+func Available() bool {
+	for _, v := range flavors {
+		if v.installed() {
+			return true
+		}
+	}
+
+	slog.Info(Name, "available", "no vscode flavor found. disabling")
+
+	return false
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == history.ActionDelete {
+		h.Remove(identifier)
+		return
+	}
+
+	list := loadEntries()
+
+	i := slices.IndexFunc(list, func(e Entry) bool {
+		return e.Identifier == identifier
+	})
+
+	if i == -1 {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown identifier: %s", identifier))
+		return
+	}
+
+	e := list[i]
+
+	if action == ActionCopyURI {
+		cmd := common.ReplaceResultOrStdinCmd(config.Copy, e.URI)
+
+		err := cmd.Start()
+		if err != nil {
+			slog.Error(Name, "copy", err)
+		} else {
+			go func() {
+				cmd.Wait()
+			}()
+		}
+
+		return
+	}
+
+	f, ok := primaryFlavor(e)
+	newWindow := false
+
+	switch {
+	case action == ActionOpen:
+	case action == ActionNewWindow:
+		newWindow = true
+	case action == ActionOpenLocal:
+		if e.Remote.Local == "" {
+			slog.Error(Name, "activate", fmt.Sprintf("no local folder for: %s", e.URI))
+			return
+		}
+
+		e.URI = fileURI(e.Remote.Local)
+		e.Kind = KindFolder
+	case strings.HasPrefix(action, ActionOpenWith):
+		f, ok = flavorByName(strings.TrimPrefix(action, ActionOpenWith))
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown flavor: %s", action))
+		return
+	}
+
+	open(f, e, newWindow)
+
+	if config.History {
+		h.Save(query, identifier)
+	}
+}
+
+// primaryFlavor is the flavor that opened the entry last. Discovered projects use the first installed flavor.
+func primaryFlavor(e Entry) (Flavor, bool) {
+	if len(e.Flavors) > 0 {
+		return flavorByName(e.Flavors[0])
+	}
+
+	for _, v := range flavors {
+		if v.installed() {
+			return v, true
+		}
+	}
+
+	return Flavor{}, false
+}
+
+func open(f Flavor, e Entry, newWindow bool) {
+	flag := "--folder-uri"
+	if e.Kind != KindFolder {
+		flag = "--file-uri"
+	}
+
+	if newWindow {
+		flag = "--new-window " + flag
+	}
+
+	cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s %s %s", common.ScopedLaunchPrefix("", Name, e.Identifier), f.Command, flag, common.Quote(e.URI))))
+
+	common.Detach(cmd)
+
+	err := cmd.Start()
+	if err != nil {
+		slog.Error(Name, "activate", err)
+	} else {
+		go func() {
+			cmd.Wait()
+		}()
+	}
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, format uint8) []*pb.QueryResponse_Item {
+	res := []*pb.QueryResponse_Item{}
+
+	available := []string{}
+
+	for _, v := range flavors {
+		if v.installed() {
+			available = append(available, v.Name)
+		}
+	}
+
+	for _, v := range loadEntries() {
+		f, _ := primaryFlavor(v)
+
+		labels := []string{}
+
+		for _, n := range v.Flavors {
+			if o, ok := flavorByName(n); ok {
+				labels = append(labels, o.Label)
+			}
+		}
+
+		actions := []string{ActionOpen, ActionNewWindow}
+
+		if v.Remote.Local != "" {
+			actions = append(actions, ActionOpenLocal)
+		}
+
+		if v.Remote.Kind != "" {
+			actions = append(actions, ActionCopyURI)
+		}
+
+		for _, n := range available {
+			if n != f.Name {
+				actions = append(actions, ActionOpenWith+n)
+			}
+		}
+
+		icon := f.Icon
+		if icon == "" {
+			icon = config.Icon
+		}
+
+		subtext := v.Path
+		state := append([]string{v.Kind}, v.Flavors...)
+
+		if v.Remote.Kind != "" {
+			subtext = fmt.Sprintf("%s %s", v.Remote.Label, v.Path)
+			state = append(state, "remote", v.Remote.Kind)
+		}
+
+		if len(labels) > 0 {
+			subtext = fmt.Sprintf("%s (%s)", subtext, strings.Join(labels, ", "))
+		} else {
+			state = append(state, "discovered")
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier: v.Identifier,
+			Text:       v.Label,
+			Subtext:    subtext,
+			Icon:       icon,
+			Provider:   Name,
+			Actions:    actions,
+			State:      state,
+			Score:      int32(1000 - v.Rank),
+			Type:       pb.QueryResponse_REGULAR,
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, v.Label, exact)
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Field:     "text",
+				Positions: pos,
+				Start:     start,
+			}
+
+			if s2, p2, start2 := common.FuzzyScore(query, v.Path, exact); s2 > score {
+				e.Score = s2
+				e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+					Field:     "subtext",
+					Positions: p2,
+					Start:     start2,
+				}
+			}
+		}
+
+		if config.History {
+			if e.Score > config.MinScore || query == "" && config.HistoryWhenEmpty {
+				usageScore := h.CalcUsageScore(query, e.Identifier)
+
+				if usageScore != 0 {
+					e.State = append(e.State, "history")
+					e.Actions = append(e.Actions, history.ActionDelete)
+				}
+
+				e.Score = e.Score + usageScore
+			}
+		}
+
+		if e.Score > config.MinScore || query == "" {
+			if v.Remote.Kind != "" && config.ProbeRemotes {
+				probeEntry(e, v, query, format, conn)
+			}
+
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+// probeEntry marks the entry as unreachable, based on the cached probe. If the probe is outdated, a new one runs
+// and changes are sent as an update.
+func probeEntry(e *pb.QueryResponse_Item, v Entry, query string, format uint8, conn net.Conn) {
+	ok, fresh := cachedProbe(v.URI)
+
+	if !ok {
+		e.State = append(e.State, "unreachable")
+	}
+
+	if fresh {
+		return
+	}
+
+	item := proto.Clone(e).(*pb.QueryResponse_Item)
+
+	startProbe(v.URI, v.Remote, func(res bool) {
+		if res == ok || conn == nil {
+			return
+		}
+
+		if res {
+			item.State = slices.DeleteFunc(item.State, func(s string) bool { return s == "unreachable" })
+		} else {
+			item.State = append(item.State, "unreachable")
+		}
+
+		handlers.UpdateItem(format, query, conn, item)
+	})
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
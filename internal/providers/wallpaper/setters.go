@@ -0,0 +1,276 @@
+//go:build linux
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// setter sets the wallpaper of an output, or of all outputs if output is empty.
+type setter struct {
+	name   string
+	detect func() bool
+	set    func(file, output string) error
+}
+
+var setters = []setter{
+	{
+		name: "swww",
+		detect: func() bool {
+			return exec.Command("swww", "query").Run() == nil
+		},
+		set: func(file, output string) error {
+			args := []string{"img"}
+
+			if output != "" {
+				args = append(args, "-o", output)
+			}
+
+			args = append(args, strings.Fields(config.SwwwArgs)...)
+
+			return run(exec.Command("swww", append(args, file)...))
+		},
+	},
+	{
+		name: "hyprpaper",
+		detect: func() bool {
+			return exec.Command("pgrep", "-x", "hyprpaper").Run() == nil
+		},
+		set: func(file, output string) error {
+			// reload preloads the file, sets it and unloads the previous one
+			return run(exec.Command("hyprctl", "hyprpaper", "reload", fmt.Sprintf("%s,%s", output, file)))
+		},
+	},
+	{
+		name: "swaybg",
+		detect: func() bool {
+			_, err := exec.LookPath("swaybg")
+			return !common.X11() && err == nil
+		},
+		set: setSwaybg,
+	},
+	{
+		name: "feh",
+		detect: func() bool {
+			_, err := exec.LookPath("feh")
+			return common.X11() && err == nil
+		},
+		set: func(file, output string) error {
+			// feh can't address single outputs, the wallpaper is set on all of them
+			return run(exec.Command("feh", "--no-fehbg", "--bg-fill", file))
+		},
+	},
+	{
+		name: "command",
+		set: func(file, output string) error {
+			if config.Command == "" {
+				return fmt.Errorf("command not set")
+			}
+
+			cmd := strings.ReplaceAll(config.Command, "%FILE%", common.Quote(file))
+			cmd = strings.ReplaceAll(cmd, "%OUTPUT%", common.Quote(output))
+
+			return run(common.HostShell(cmd))
+		},
+	},
+}
+
+func detectSetter() (setter, bool) {
+	for _, v := range setters {
+		if config.Setter == "auto" && v.detect != nil && v.detect() {
+			return v, true
+		}
+
+		if v.name == config.Setter {
+			return v, true
+		}
+	}
+
+	return setter{}, false
+}
+
+var (
+	swaybgMu sync.Mutex
+	// swaybg instances started by elephant by output
+	swaybgs = make(map[string]*exec.Cmd)
+)
+
+// setSwaybg starts swaybg for the output and stops the instance it replaces. Setting all outputs stops
+// every instance, including ones not started by elephant.
+func setSwaybg(file, output string) error {
+	swaybgMu.Lock()
+	defer swaybgMu.Unlock()
+
+	if output == "" {
+		for k, v := range swaybgs {
+			v.Process.Kill()
+			delete(swaybgs, k)
+		}
+
+		exec.Command("pkill", "-x", "swaybg").Run()
+	} else if v, ok := swaybgs[output]; ok {
+		v.Process.Kill()
+		delete(swaybgs, output)
+	}
+
+	args := []string{"-i", file, "-m", config.SwaybgMode}
+
+	if output != "" {
+		args = append([]string{"-o", output}, args...)
+	}
+
+	cmd := exec.Command("swaybg", args...)
+	common.Detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+
+	swaybgs[output] = cmd
+
+	return nil
+}
+
+func run(cmd *exec.Cmd) error {
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", strings.Join(cmd.Args, " "), err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// outputs returns the names of the connected outputs, f.e. "DP-1".
+func outputs() []string {
+	type output struct {
+		Name string `json:"name"`
+	}
+
+	candidates := [][]string{
+		{"hyprctl", "monitors", "-j"},
+		{"swaymsg", "-r", "-t", "get_outputs"},
+		{"wlr-randr", "--json"},
+	}
+
+	for _, v := range candidates {
+		out, err := exec.Command(v[0], v[1:]...).Output()
+		if err != nil {
+			continue
+		}
+
+		list := []output{}
+
+		if err := json.Unmarshal(out, &list); err != nil {
+			slog.Debug(Name, "outputs", err)
+			continue
+		}
+
+		res := []string{}
+
+		for _, o := range list {
+			res = append(res, o.Name)
+		}
+
+		return res
+	}
+
+	// niri prints an object keyed by output name
+	if out, err := exec.Command("niri", "msg", "-j", "outputs").Output(); err == nil {
+		list := map[string]output{}
+
+		if err := json.Unmarshal(out, &list); err == nil {
+			res := []string{}
+
+			for k := range list {
+				res = append(res, k)
+			}
+
+			return res
+		}
+	}
+
+	return []string{}
+}
+
+// current wallpapers by output. The empty output is the wallpaper set for all outputs.
+var (
+	currentMu sync.Mutex
+	current   = make(map[string]string)
+)
+
+func loadCurrent() {
+	b, err := os.ReadFile(common.CacheFile("wallpaper.json"))
+	if err != nil {
+		return
+	}
+
+	currentMu.Lock()
+	defer currentMu.Unlock()
+
+	if err := json.Unmarshal(b, &current); err != nil {
+		slog.Error(Name, "load current", err)
+	}
+}
+
+func setCurrent(file, output string) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+
+	if output == "" {
+		clear(current)
+	}
+
+	current[output] = file
+
+	b, err := json.Marshal(current)
+	if err != nil {
+		slog.Error(Name, "save current", err)
+		return
+	}
+
+	cache := common.CacheFile("wallpaper.json")
+
+	if err := os.MkdirAll(filepath.Dir(cache), 0o755); err != nil {
+		slog.Error(Name, "save current", err)
+		return
+	}
+
+	if err := os.WriteFile(cache, b, 0o600); err != nil {
+		slog.Error(Name, "save current", err)
+	}
+}
+
+// isCurrent reports the outputs the file is the wallpaper of. "*" stands for all outputs.
+func isCurrent(file string) []string {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+
+	res := []string{}
+
+	for k, v := range current {
+		if v != file {
+			continue
+		}
+
+		if k == "" {
+			k = "*"
+		}
+
+		res = append(res, k)
+	}
+
+	return res
+}
@@ -0,0 +1,266 @@
+//go:build linux
+
+// Package wallpaper lists images of wallpaper directories and sets them as wallpaper.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "wallpaper"
+	NamePretty = "Wallpaper"
+	config     *Config
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Directories   []string `koanf:"directories" desc:"directories containing wallpapers, searched recursively" default:"[\"~/Pictures/Wallpapers\"]"`
+	Setter        string   `koanf:"setter" desc:"how to set the wallpaper. 'auto', 'swww', 'hyprpaper', 'swaybg', 'feh' on X11 or 'command'." default:"auto"`
+	Command       string   `koanf:"command" desc:"command for the command setter. supports %FILE% and %OUTPUT%, which is empty for all outputs." default:""`
+	SwwwArgs      string   `koanf:"swww_args" desc:"additional arguments for 'swww img', f.e. '--transition-type grow'" default:""`
+	SwaybgMode    string   `koanf:"swaybg_mode" desc:"scaling mode for swaybg" default:"fill"`
+}
+
+const (
+	ActionSet       = "set"
+	ActionSetOutput = "set_output:"
+	ActionRandom    = "random"
+)
+
+var extensions = []string{".jpg", ".jpeg", ".png", ".webp", ".gif", ".bmp", ".avif", ".jxl"}
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "preferences-desktop-wallpaper",
+			MinScore: 30,
+		},
+		Directories: []string{"~/Pictures/Wallpapers"},
+		Setter:      "auto",
+		SwaybgMode:  "fill",
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	loadCurrent()
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionSet, Label: "Set wallpaper", Icon: "preferences-desktop-wallpaper", Default: true},
+		{Action: ActionRandom, Label: "Random wallpaper", Icon: "media-playlist-shuffle"},
+	}
+}
+
+func directories() []string {
+	home, _ := os.UserHomeDir()
+	res := []string{}
+
+	for _, v := range config.Directories {
+		if after, ok := strings.CutPrefix(v, "~/"); ok {
+			v = filepath.Join(home, after)
+		}
+
+		res = append(res, v)
+	}
+
+	return res
+}
+
+// wallpapers returns the images of the wallpaper directories.
+func wallpapers() []string {
+	res := []string{}
+
+	for _, dir := range directories() {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if !d.IsDir() && slices.Contains(extensions, strings.ToLower(filepath.Ext(path))) {
+				res = append(res, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			slog.Error(Name, "walk", err)
+		}
+	}
+
+	return res
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	s, ok := detectSetter()
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("no wallpaper setter found: %s", config.Setter))
+		return
+	}
+
+	output := ""
+
+	switch {
+	case action == "" || action == ActionSet:
+	case action == ActionRandom:
+		list := wallpapers()
+		if len(list) == 0 {
+			slog.Error(Name, "activate", "no wallpapers found")
+			return
+		}
+
+		identifier = list[rand.IntN(len(list))]
+	case strings.HasPrefix(action, ActionSetOutput):
+		output = strings.TrimPrefix(action, ActionSetOutput)
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	if !common.FileExists(identifier) {
+		slog.Error(Name, "activate", fmt.Sprintf("file doesn't exist: %s", identifier))
+		return
+	}
+
+	if err := s.set(identifier, output); err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	setCurrent(identifier, output)
+
+	handlers.ProviderUpdated <- Name
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	start := time.Now()
+	entries := []*pb.QueryResponse_Item{}
+
+	actions := []string{ActionSet}
+
+	if names := outputs(); len(names) > 1 {
+		for _, v := range names {
+			actions = append(actions, ActionSetOutput+v)
+		}
+	}
+
+	thumbnails := common.WantsThumbnails(conn)
+	list := wallpapers()
+
+	for k, v := range list {
+		e := &pb.QueryResponse_Item{
+			Identifier:  v,
+			Text:        filepath.Base(v),
+			Subtext:     filepath.Dir(v),
+			Icon:        config.Icon,
+			Provider:    Name,
+			Actions:     actions,
+			Score:       int32(len(list) - k),
+			Preview:     v,
+			PreviewType: util.PreviewTypeFile,
+			Type:        pb.QueryResponse_REGULAR,
+		}
+
+		if on := isCurrent(v); len(on) > 0 {
+			e.State = []string{"current"}
+
+			for _, o := range on {
+				e.State = append(e.State, fmt.Sprintf("current:%s", o))
+			}
+		}
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, e.Text, exact)
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	// thumbnails are created after filtering, so only matching wallpapers get one
+	if thumbnails {
+		for _, e := range entries {
+			e.Thumbnail = common.Thumbnail(e.Preview)
+		}
+	}
+
+	slog.Debug(Name, "query", time.Since(start))
+
+	return entries
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+// State contains the current wallpapers as 'wallpaper:<output>:<file>', with '*' as output if it was
+// set for all outputs.
+func State(provider string) *pb.ProviderStateResponse {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+
+	states := []string{}
+
+	for k, v := range current {
+		if k == "" {
+			k = "*"
+		}
+
+		states = append(states, fmt.Sprintf("wallpaper:%s:%s", k, v))
+	}
+
+	slices.Sort(states)
+
+	return &pb.ProviderStateResponse{
+		States:  states,
+		Actions: []string{ActionRandom},
+	}
+}
@@ -0,0 +1,518 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/common/metrics"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// Wasm providers export these functions. Strings and JSON are passed as pointer and length into the
+// guest memory, allocated with 'elephant_alloc'. Results are returned packed as 'ptr<<32 | len' and
+// released by the host with 'elephant_free'.
+const (
+	wasmAlloc    = "elephant_alloc"
+	wasmFree     = "elephant_free"
+	wasmInfo     = "elephant_info"
+	wasmSetup    = "elephant_setup"
+	wasmQuery    = "elephant_query"
+	wasmActivate = "elephant_activate"
+	wasmState    = "elephant_state"
+	wasmDoc      = "elephant_doc"
+	wasmRefresh  = "elephant_refresh"
+)
+
+// wasmProvider is a sandboxed provider. Wasm modules aren't safe for concurrent use, so calls are serialized.
+type wasmProvider struct {
+	mu      sync.Mutex
+	name    string
+	runtime wazero.Runtime
+	mod     api.Module
+	caps    common.Wasm
+}
+
+type wasmInfoResponse struct {
+	Name                 string                 `json:"name"`
+	NamePretty           string                 `json:"name_pretty"`
+	Icon                 string                 `json:"icon"`
+	HideFromProviderlist bool                   `json:"hide_from_providerlist"`
+	Available            bool                   `json:"available"`
+	Actions              []*pb.ActionDescriptor `json:"actions"`
+}
+
+type wasmQueryRequest struct {
+	Query  string `json:"query"`
+	Single bool   `json:"single"`
+	Exact  bool   `json:"exact"`
+}
+
+type wasmActivateRequest struct {
+	Single     bool   `json:"single"`
+	Identifier string `json:"identifier"`
+	Action     string `json:"action"`
+	Query      string `json:"query"`
+	Arguments  string `json:"arguments"`
+}
+
+type wasmHTTPResponse struct {
+	Status int    `json:"status"`
+	Body   string `json:"body"`
+	Error  string `json:"error,omitempty"`
+}
+
+// wasmCache persists compiled modules, as compiling takes a few seconds.
+var wasmCache wazero.CompilationCache
+
+// loadWasm loads the *.wasm files in the plugin dirs. Providers loaded otherwise take precedence.
+func loadWasm(setup bool, ignored, disabled []string) {
+	for _, dir := range pluginDirs() {
+		files, err := filepath.Glob(filepath.Join(dir, "*.wasm"))
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			fn := strings.TrimSuffix(filepath.Base(file), ".wasm")
+
+			if slices.Contains(ignored, fn) {
+				setStatus(Status{Name: fn, Reason: ReasonIgnored})
+				continue
+			}
+
+			w, info, err := startWasm(file, fn)
+			if err != nil {
+				slog.Error("providers", "wasm", file, "err", err)
+				setStatus(Status{Name: fn, Reason: err.Error()})
+				continue
+			}
+
+			providersMu.Lock()
+			_, exists := loaded[info.Name]
+			providersMu.Unlock()
+
+			if exists || slices.Contains(ignored, info.Name) {
+				w.runtime.Close(context.Background())
+				continue
+			}
+
+			if !register(w.provider(info), setup, disabled) {
+				w.runtime.Close(context.Background())
+			}
+		}
+	}
+}
+
+func wasmCapabilities(fn string) common.Wasm {
+	if cfg := common.GetElephantConfig(); cfg != nil {
+		for _, v := range cfg.Wasm {
+			if v.Provider == fn {
+				return v
+			}
+		}
+	}
+
+	return common.Wasm{Provider: fn}
+}
+
+func startWasm(file, fn string) (*wasmProvider, wasmInfoResponse, error) {
+	var info wasmInfoResponse
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, info, err
+	}
+
+	ctx := context.Background()
+
+	if wasmCache == nil {
+		wasmCache, err = wazero.NewCompilationCacheWithDir(common.CacheFile("wasm"))
+		if err != nil {
+			slog.Error("providers", "wasm", err)
+			wasmCache = wazero.NewCompilationCache()
+		}
+	}
+
+	w := &wasmProvider{
+		name:    fn,
+		runtime: wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCompilationCache(wasmCache).WithCloseOnContextDone(true)),
+		caps:    wasmCapabilities(fn),
+	}
+
+	wasi_snapshot_preview1.MustInstantiate(ctx, w.runtime)
+
+	if err := w.hostModule(ctx); err != nil {
+		w.runtime.Close(ctx)
+		return nil, info, err
+	}
+
+	compiled, err := w.runtime.CompileModule(ctx, b)
+	if err != nil {
+		w.runtime.Close(ctx)
+		return nil, info, err
+	}
+
+	fsConfig := wazero.NewFSConfig()
+
+	for _, dir := range w.caps.FS {
+		if after, ok := strings.CutPrefix(dir, "~/"); ok {
+			home, _ := os.UserHomeDir()
+			dir = filepath.Join(home, after)
+		}
+
+		fsConfig = fsConfig.WithReadOnlyDirMount(dir, dir)
+	}
+
+	modConfig := wazero.NewModuleConfig().
+		WithName(fn).
+		WithFSConfig(fsConfig).
+		WithStdout(os.Stdout).
+		WithStderr(os.Stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(nil).
+		// reactors, f.e. built with 'go build -buildmode=c-shared', have to be initialized.
+		WithStartFunctions("_initialize")
+
+	mod, err := w.runtime.InstantiateModule(ctx, compiled, modConfig)
+	if err != nil {
+		w.runtime.Close(ctx)
+		return nil, info, err
+	}
+
+	w.mod = mod
+
+	if err := w.call(wasmInfo, nil, &info); err != nil {
+		w.runtime.Close(ctx)
+		return nil, info, err
+	}
+
+	if info.Name == "" {
+		info.Name = fn
+	}
+
+	w.name = info.Name
+
+	return w, info, nil
+}
+
+// call passes the input as JSON and decodes the returned JSON into out. Missing exports are ignored.
+func (w *wasmProvider) call(name string, in any, out any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fn := w.mod.ExportedFunction(name)
+	if fn == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	params := []uint64{}
+
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+
+		ptr, err := w.write(ctx, b)
+		if err != nil {
+			return err
+		}
+
+		defer w.release(ctx, ptr)
+
+		params = append(params, uint64(ptr), uint64(len(b)))
+	}
+
+	res, err := fn.Call(ctx, params...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if out == nil || len(res) == 0 || res[0] == 0 {
+		return nil
+	}
+
+	b, err := w.read(ctx, res[0])
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, out)
+}
+
+// write copies the data into memory allocated by the guest.
+func (w *wasmProvider) write(ctx context.Context, b []byte) (uint32, error) {
+	alloc := w.mod.ExportedFunction(wasmAlloc)
+	if alloc == nil {
+		return 0, fmt.Errorf("missing export %s", wasmAlloc)
+	}
+
+	res, err := alloc.Call(ctx, uint64(len(b)))
+	if err != nil {
+		return 0, err
+	}
+
+	ptr := uint32(res[0])
+
+	if !w.mod.Memory().Write(ptr, b) {
+		return 0, errors.New("out of memory range")
+	}
+
+	return ptr, nil
+}
+
+// read copies a packed result out of the guest memory and releases it.
+func (w *wasmProvider) read(ctx context.Context, packed uint64) ([]byte, error) {
+	ptr, size := uint32(packed>>32), uint32(packed)
+
+	b, ok := w.mod.Memory().Read(ptr, size)
+	if !ok {
+		return nil, errors.New("out of memory range")
+	}
+
+	res := slices.Clone(b)
+
+	w.release(ctx, ptr)
+
+	return res, nil
+}
+
+func (w *wasmProvider) release(ctx context.Context, ptr uint32) {
+	if free := w.mod.ExportedFunction(wasmFree); free != nil {
+		free.Call(ctx, uint64(ptr))
+	}
+}
+
+// hostModule provides the host api, limited by the granted capabilities.
+func (w *wasmProvider) hostModule(ctx context.Context) error {
+	_, err := w.runtime.NewHostModuleBuilder("elephant").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, ptr, size uint32) {
+			if b, ok := m.Memory().Read(ptr, size); ok {
+				slog.Info(w.name, "wasm", string(b))
+			}
+		}).
+		Export("log").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, ptr, size uint32) uint64 {
+			b, ok := m.Memory().Read(ptr, size)
+			if !ok {
+				return 0
+			}
+
+			resp, _ := json.Marshal(w.httpGet(string(b)))
+
+			return w.hostResult(ctx, m, resp)
+		}).
+		Export("http_get").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, ptr, size uint32) uint32 {
+			b, ok := m.Memory().Read(ptr, size)
+			if !ok || !w.caps.Open {
+				return 0
+			}
+
+			cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s %s", common.LaunchPrefix(""), common.OpenCommand(), common.Quote(string(b)))))
+			common.Detach(cmd)
+
+			return startHostCmd(w.name, cmd)
+		}).
+		Export("open").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, ptr, size uint32) uint32 {
+			b, ok := m.Memory().Read(ptr, size)
+			if !ok || !w.caps.Clipboard {
+				return 0
+			}
+
+			return startHostCmd(w.name, common.ReplaceResultOrStdinCmd(common.CopyCommand(), string(b)))
+		}).
+		Export("copy").
+		Instantiate(ctx)
+
+	return err
+}
+
+// hostResult passes data to the guest. The guest has to free it.
+func (w *wasmProvider) hostResult(ctx context.Context, m api.Module, b []byte) uint64 {
+	alloc := m.ExportedFunction(wasmAlloc)
+	if alloc == nil {
+		return 0
+	}
+
+	res, err := alloc.Call(ctx, uint64(len(b)))
+	if err != nil {
+		return 0
+	}
+
+	ptr := uint32(res[0])
+
+	if !m.Memory().Write(ptr, b) {
+		return 0
+	}
+
+	return uint64(ptr)<<32 | uint64(len(b))
+}
+
+func startHostCmd(name string, cmd *exec.Cmd) uint32 {
+	if err := cmd.Start(); err != nil {
+		slog.Error(name, "wasm", err)
+		return 0
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+
+	return 1
+}
+
+var wasmHTTP = http.Client{Timeout: 10 * time.Second}
+
+func (w *wasmProvider) httpGet(raw string) wasmHTTPResponse {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return wasmHTTPResponse{Error: err.Error()}
+	}
+
+	if (u.Scheme != "https" && u.Scheme != "http") || !slices.Contains(w.caps.HTTP, u.Hostname()) {
+		return wasmHTTPResponse{Error: fmt.Sprintf("access to %s not granted", u.Hostname())}
+	}
+
+	resp, err := wasmHTTP.Get(u.String())
+	if err != nil {
+		return wasmHTTPResponse{Error: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return wasmHTTPResponse{Status: resp.StatusCode, Error: err.Error()}
+	}
+
+	return wasmHTTPResponse{Status: resp.StatusCode, Body: string(b)}
+}
+
+// provider adapts the wasm provider to the functions of Go plugin providers.
+func (w *wasmProvider) provider(info wasmInfoResponse) Provider {
+	name := info.Name
+	namePretty := info.NamePretty
+
+	var infoMu sync.Mutex
+
+	return Provider{
+		Name:       &name,
+		NamePretty: &namePretty,
+		Setup: func() {
+			if err := w.call(wasmSetup, nil, nil); err != nil {
+				slog.Error(name, "wasm", err)
+			}
+
+			var updated wasmInfoResponse
+
+			if err := w.call(wasmInfo, nil, &updated); err == nil {
+				infoMu.Lock()
+				info = updated
+				infoMu.Unlock()
+
+				namePretty = updated.NamePretty
+			}
+		},
+		Available: func() bool {
+			infoMu.Lock()
+			defer infoMu.Unlock()
+
+			return info.Available
+		},
+		PrintDoc: func(out io.Writer) {
+			var doc string
+
+			if err := w.call(wasmDoc, nil, &doc); err != nil {
+				slog.Error(name, "wasm", err)
+			}
+
+			fmt.Fprintln(out, doc)
+		},
+		State: func(string) *pb.ProviderStateResponse {
+			res := &pb.ProviderStateResponse{}
+
+			if err := w.call(wasmState, nil, res); err != nil {
+				slog.Error(name, "wasm", err)
+			}
+
+			return res
+		},
+		HideFromProviderlist: func() bool {
+			infoMu.Lock()
+			defer infoMu.Unlock()
+
+			return info.HideFromProviderlist
+		},
+		Icon: func() string {
+			infoMu.Lock()
+			defer infoMu.Unlock()
+
+			return info.Icon
+		},
+		Activate: func(single bool, identifier, action, query, args string, _ uint8, _ net.Conn) {
+			req := wasmActivateRequest{
+				Single:     single,
+				Identifier: identifier,
+				Action:     action,
+				Query:      query,
+				Arguments:  args,
+			}
+
+			if err := w.call(wasmActivate, req, nil); err != nil {
+				metrics.ActivationFailures.Inc(name)
+				slog.Error(name, "wasm", err)
+			}
+		},
+		Query: func(_ net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+			res := []*pb.QueryResponse_Item{}
+
+			if err := w.call(wasmQuery, wasmQueryRequest{Query: query, Single: single, Exact: exact}, &res); err != nil {
+				slog.Error(name, "wasm", err)
+			}
+
+			for _, v := range res {
+				v.Provider = name
+			}
+
+			return res
+		},
+		Refresh: func() {
+			if err := w.call(wasmRefresh, nil, nil); err != nil {
+				slog.Error(name, "wasm", err)
+			}
+		},
+		Actions: func() []*pb.ActionDescriptor {
+			infoMu.Lock()
+			defer infoMu.Unlock()
+
+			return info.Actions
+		},
+	}
+}
@@ -0,0 +1,146 @@
+package providers
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+var (
+	// probing holds the providers with a probe that didn't return yet. Hanging probes can't be stopped,
+	// so these aren't probed again until they return.
+	probing   = make(map[string]bool)
+	probingMu sync.Mutex
+)
+
+// Watchdog probes all loaded providers periodically with an empty query. Providers failing several
+// probes in a row are marked unhealthy and restarted.
+func Watchdog() {
+	cfg := common.GetElephantConfig().Watchdog
+
+	if !cfg.Enabled || cfg.Interval <= 0 {
+		return
+	}
+
+	for range time.Tick(time.Duration(cfg.Interval) * time.Second) {
+		for name, p := range All() {
+			go watch(name, p, cfg)
+		}
+	}
+}
+
+func watch(name string, p Provider, cfg common.Watchdog) {
+	err := probe(name, p, time.Duration(cfg.Timeout)*time.Second)
+
+	statusMu.Lock()
+	s, ok := statuses[name]
+	if !ok {
+		statusMu.Unlock()
+		return
+	}
+
+	s.Probed = time.Now()
+
+	if err == nil {
+		if s.Unhealthy {
+			slog.Info("watchdog", "recovered", name)
+		}
+
+		s.Failures = 0
+		s.Unhealthy = false
+		s.LastError = ""
+		statusMu.Unlock()
+
+		return
+	}
+
+	s.Failures++
+	s.LastError = err.Error()
+
+	slog.Error("watchdog", "probe", err, "provider", name, "failures", s.Failures)
+
+	if s.Failures < cfg.Failures {
+		statusMu.Unlock()
+		return
+	}
+
+	s.Unhealthy = true
+	s.Failures = 0
+	statusMu.Unlock()
+
+	if !restart(name, p) {
+		return
+	}
+
+	statusMu.Lock()
+	s.Restarts++
+	statusMu.Unlock()
+}
+
+// probe runs an empty query, failing if it panics or exceeds the timeout.
+func probe(name string, p Provider, timeout time.Duration) error {
+	probingMu.Lock()
+	if probing[name] {
+		probingMu.Unlock()
+		return fmt.Errorf("previous probe still running")
+	}
+
+	probing[name] = true
+	probingMu.Unlock()
+
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			probingMu.Lock()
+			delete(probing, name)
+			probingMu.Unlock()
+
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+
+		// async updates of the provider go nowhere
+		local, remote := net.Pipe()
+
+		go io.Copy(io.Discard, remote)
+
+		defer local.Close()
+
+		p.Query(local, "", false, false, 0)
+
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+}
+
+// restart starts a new process of providers running as executables. Providers running in the daemon
+// can't be restarted, running their setup again would start their goroutines and watchers twice, they're
+// only marked as unhealthy. Returns whether the provider got restarted.
+func restart(name string, p Provider) bool {
+	if p.Restart == nil {
+		slog.Warn("watchdog", "unhealthy", name, "restart", "not supported by in-process providers")
+		return false
+	}
+
+	slog.Info("watchdog", "restart", name)
+
+	if err := p.Restart(); err != nil {
+		slog.Error("watchdog", "restart", err, "provider", name)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+const (
+	SourceYahoo     = "yahoo"
+	SourceCoinGecko = "coingecko"
+	SourceCommand   = "command"
+)
+
+type Quote struct {
+	Price float64
+	// Change is the change since the previous close, or within 24 hours for crypto.
+	Change        float64
+	ChangePercent float64
+	Currency      string
+	Fetched       time.Time
+}
+
+// source fetches the quotes of symbols. Sources are picked per symbol, so stocks and crypto can be mixed.
+type source interface {
+	quotes(symbols []string) (map[string]Quote, error)
+	// link is the page of the symbol
+	link(symbol string) string
+}
+
+var (
+	client = http.Client{Timeout: 10 * time.Second}
+
+	sources = map[string]source{
+		SourceYahoo:     yahoo{},
+		SourceCoinGecko: coingecko{},
+		SourceCommand:   command{},
+	}
+
+	quotesMu sync.Mutex
+	quotes   = map[string]Quote{}
+)
+
+func getJSON(u string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	// yahoo rejects requests without a user agent
+	req.Header.Set("User-Agent", "Mozilla/5.0 (elephant)")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", u, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+type yahoo struct{}
+
+// quotes requests the chart of every symbol, the meta data holds the price and the previous close.
+func (yahoo) quotes(symbols []string) (map[string]Quote, error) {
+	res := map[string]Quote{}
+
+	var errs []error
+
+	for _, symbol := range symbols {
+		var chart struct {
+			Chart struct {
+				Result []struct {
+					Meta struct {
+						Currency      string  `json:"currency"`
+						Price         float64 `json:"regularMarketPrice"`
+						PreviousClose float64 `json:"chartPreviousClose"`
+					} `json:"meta"`
+				} `json:"result"`
+			} `json:"chart"`
+		}
+
+		u := fmt.Sprintf("%s/v8/finance/chart/%s?interval=1d&range=1d", strings.TrimSuffix(config.YahooURL, "/"), url.PathEscape(symbol))
+
+		if err := getJSON(u, &chart); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if len(chart.Chart.Result) == 0 {
+			errs = append(errs, fmt.Errorf("yahoo: unknown symbol %s", symbol))
+			continue
+		}
+
+		m := chart.Chart.Result[0].Meta
+
+		q := Quote{Price: m.Price, Currency: m.Currency, Fetched: time.Now()}
+
+		if m.PreviousClose != 0 {
+			q.Change = m.Price - m.PreviousClose
+			q.ChangePercent = 100 * q.Change / m.PreviousClose
+		}
+
+		res[symbol] = q
+	}
+
+	return res, errors.Join(errs...)
+}
+
+func (yahoo) link(symbol string) string {
+	return "https://finance.yahoo.com/quote/" + url.PathEscape(symbol)
+}
+
+type coingecko struct{}
+
+// quotes requests all coins at once. Symbols are coingecko ids, f.e. "bitcoin".
+func (coingecko) quotes(symbols []string) (map[string]Quote, error) {
+	currency := strings.ToLower(config.Currency)
+
+	u := fmt.Sprintf("%s/api/v3/simple/price?ids=%s&vs_currencies=%s&include_24hr_change=true",
+		strings.TrimSuffix(config.CoinGeckoURL, "/"), url.QueryEscape(strings.Join(symbols, ",")), url.QueryEscape(currency))
+
+	var prices map[string]map[string]float64
+
+	if err := getJSON(u, &prices); err != nil {
+		return nil, err
+	}
+
+	res := map[string]Quote{}
+
+	for _, symbol := range symbols {
+		p, ok := prices[symbol]
+		if !ok {
+			continue
+		}
+
+		q := Quote{Price: p[currency], ChangePercent: p[currency+"_24h_change"], Currency: strings.ToUpper(currency), Fetched: time.Now()}
+
+		// the change in percent is relative to the price 24 hours ago
+		q.Change = q.Price - q.Price/(1+q.ChangePercent/100)
+
+		res[symbol] = q
+	}
+
+	return res, nil
+}
+
+func (coingecko) link(symbol string) string {
+	return "https://www.coingecko.com/en/coins/" + url.PathEscape(symbol)
+}
+
+type command struct{}
+
+// quotes runs the command for every symbol. It prints the price, optionally followed by the change in percent
+// and the currency, f.e. "189.23 0.64 USD".
+func (command) quotes(symbols []string) (map[string]Quote, error) {
+	res := map[string]Quote{}
+
+	var errs []error
+
+	for _, symbol := range symbols {
+		out, err := output(common.Shell(strings.ReplaceAll(config.Command, "%SYMBOL%", common.Quote(symbol))))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("command: %s: %w", symbol, err))
+			continue
+		}
+
+		fields := strings.Fields(out)
+		if len(fields) == 0 {
+			errs = append(errs, fmt.Errorf("command: %s: no output", symbol))
+			continue
+		}
+
+		price, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("command: %s: %w", symbol, err))
+			continue
+		}
+
+		q := Quote{Price: price, Fetched: time.Now()}
+
+		if len(fields) > 1 {
+			q.ChangePercent, _ = strconv.ParseFloat(strings.TrimSuffix(fields[1], "%"), 64)
+			q.Change = q.Price - q.Price/(1+q.ChangePercent/100)
+		}
+
+		if len(fields) > 2 {
+			q.Currency = fields[2]
+		}
+
+		res[symbol] = q
+	}
+
+	return res, errors.Join(errs...)
+}
+
+func (command) link(symbol string) string {
+	return ""
+}
+
+// output runs the command, killing it after 10 seconds.
+func output(cmd *exec.Cmd) (string, error) {
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	timer := time.AfterFunc(10*time.Second, func() {
+		cmd.Process.Kill()
+	})
+	defer timer.Stop()
+
+	err := cmd.Wait()
+
+	return out.String(), err
+}
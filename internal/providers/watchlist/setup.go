@@ -0,0 +1,465 @@
+// Package watchlist shows live quotes of stocks and crypto currencies and alerts on price thresholds.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "watchlist"
+	NamePretty = "Watchlist"
+	config     *Config
+
+	refreshing  atomic.Bool
+	lastRefresh time.Time
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config `koanf:",squash"`
+	Symbols       []Symbol `koanf:"symbols" desc:"symbols to watch" default:""`
+	Interval      int      `koanf:"interval" desc:"seconds between updates" default:"60"`
+	Currency      string   `koanf:"currency" desc:"currency of coingecko prices" default:"usd"`
+	YahooURL      string   `koanf:"yahoo_url" desc:"url of the yahoo finance api" default:"https://query1.finance.yahoo.com"`
+	CoinGeckoURL  string   `koanf:"coingecko_url" desc:"url of the coingecko api" default:"https://api.coingecko.com"`
+	Command       string   `koanf:"command" desc:"command of the 'command' source printing the price, the change in percent and the currency. supports %SYMBOL%." default:""`
+	Notify        bool     `koanf:"notify" desc:"show a notification when a price crosses a threshold" default:"true"`
+	AlertCommand  string   `koanf:"alert_command" desc:"command to run when a price crosses a threshold. supports %SYMBOL%, %PRICE% and %DIRECTION%." default:""`
+}
+
+type Symbol struct {
+	Symbol string  `koanf:"symbol" desc:"ticker, f.e. 'AAPL', or the coingecko id, f.e. 'bitcoin'" default:""`
+	Name   string  `koanf:"name" desc:"displayed name" default:""`
+	Source string  `koanf:"source" desc:"'yahoo', 'coingecko' or 'command'" default:"yahoo"`
+	Above  float64 `koanf:"above" desc:"alert when the price rises above" default:"0"`
+	Below  float64 `koanf:"below" desc:"alert when the price falls below" default:"0"`
+}
+
+// id identifies the symbol as "<source>:<symbol>", the same ticker may be listed by several sources.
+func (s Symbol) id() string {
+	return s.Source + ":" + s.Symbol
+}
+
+const (
+	ActionOpen    = "open"
+	ActionCopy    = "copy"
+	ActionRefresh = "refresh"
+
+	StateUp    = "up"
+	StateDown  = "down"
+	StateAbove = "above"
+	StateBelow = "below"
+)
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "office-chart-line",
+			MinScore: 30,
+		},
+		Interval:     60,
+		Currency:     "usd",
+		YahooURL:     "https://query1.finance.yahoo.com",
+		CoinGeckoURL: "https://api.coingecko.com",
+		Notify:       true,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	config.Interval = max(config.Interval, 10)
+
+	for k, v := range config.Symbols {
+		if v.Source == "" {
+			config.Symbols[k].Source = SourceYahoo
+		}
+	}
+
+	// quotes are fetched when shown, alerts need them regardless
+	if slices.ContainsFunc(config.Symbols, func(s Symbol) bool { return s.Above != 0 || s.Below != 0 }) {
+		go poll()
+	}
+}
+
+func Available() bool {
+	if len(config.Symbols) == 0 {
+		slog.Info(Name, "available", "no symbols configured. disabling")
+		return false
+	}
+
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionOpen, Label: "Open", Icon: "web-browser", Default: true},
+		{Action: ActionCopy, Label: "Copy price", Icon: "edit-copy"},
+		{Action: ActionRefresh, Label: "Refresh", Icon: "view-refresh"},
+	}
+}
+
+func poll() {
+	for {
+		refresh(false)
+		time.Sleep(time.Duration(config.Interval) * time.Second)
+	}
+}
+
+// stale reports if the quotes are older than the interval. Failed fetches count as well, so unknown symbols
+// aren't requested on every query.
+func stale() bool {
+	quotesMu.Lock()
+	defer quotesMu.Unlock()
+
+	return time.Since(lastRefresh) >= time.Duration(config.Interval)*time.Second
+}
+
+// refresh fetches the quotes of all symbols, grouped by source, and checks the thresholds.
+func refresh(force bool) {
+	if !force && !stale() {
+		return
+	}
+
+	if !refreshing.CompareAndSwap(false, true) {
+		return
+	}
+	defer refreshing.Store(false)
+
+	quotesMu.Lock()
+	lastRefresh = time.Now()
+	quotesMu.Unlock()
+
+	bySource := map[string][]string{}
+
+	for _, v := range config.Symbols {
+		bySource[v.Source] = append(bySource[v.Source], v.Symbol)
+	}
+
+	for name, symbols := range bySource {
+		src, ok := sources[name]
+		if !ok {
+			slog.Error(Name, "source", fmt.Sprintf("unknown source: %s", name))
+			continue
+		}
+
+		res, err := src.quotes(symbols)
+		if err != nil {
+			slog.Error(Name, name, err)
+		}
+
+		for symbol, q := range res {
+			s := Symbol{Source: name, Symbol: symbol}
+
+			quotesMu.Lock()
+			prev, ok := quotes[s.id()]
+			quotes[s.id()] = q
+			quotesMu.Unlock()
+
+			if ok {
+				for _, v := range config.Symbols {
+					if v.id() == s.id() {
+						alert(v, prev.Price, q)
+					}
+				}
+			}
+		}
+	}
+
+	handlers.ProviderUpdated <- Name
+}
+
+// alert notifies if the price crossed a threshold since the previous quote.
+func alert(s Symbol, prev float64, q Quote) {
+	direction := ""
+
+	switch {
+	case s.Above != 0 && prev <= s.Above && q.Price > s.Above:
+		direction = StateAbove
+	case s.Below != 0 && prev >= s.Below && q.Price < s.Below:
+		direction = StateBelow
+	default:
+		return
+	}
+
+	price := formatPrice(q)
+
+	slog.Info(Name, "alert", s.Symbol, "price", price, "direction", direction)
+
+	if config.Notify {
+		threshold := s.Above
+		if direction == StateBelow {
+			threshold = s.Below
+		}
+
+		_, err := common.Notify(common.Notification{
+			Title:   fmt.Sprintf("%s %s %s", s.Symbol, direction, strconv.FormatFloat(threshold, 'f', -1, 64)),
+			Body:    fmt.Sprintf("%s · %s", price, formatChange(q)),
+			Icon:    config.Icon,
+			Urgency: "critical",
+			Actions: []common.NotificationAction{{Default: true, Provider: Name, Identifier: s.id(), Action: ActionOpen}},
+		})
+		if err != nil {
+			slog.Error(Name, "notify", err)
+		}
+	}
+
+	if config.AlertCommand != "" {
+		cmd := common.Shell(strings.NewReplacer("%SYMBOL%", common.Quote(s.Symbol), "%PRICE%", strconv.FormatFloat(q.Price, 'f', -1, 64), "%DIRECTION%", direction).Replace(config.AlertCommand))
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "alert_command", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+	}
+}
+
+func find(identifier string) (Symbol, bool) {
+	i := slices.IndexFunc(config.Symbols, func(s Symbol) bool {
+		return s.id() == identifier
+	})
+
+	if i < 0 {
+		return Symbol{}, false
+	}
+
+	return config.Symbols[i], true
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionOpen
+	}
+
+	if action == ActionRefresh {
+		go refresh(true)
+		return
+	}
+
+	s, ok := find(identifier)
+	if !ok {
+		slog.Error(Name, "activate", fmt.Sprintf("unknown symbol: %s", identifier))
+		return
+	}
+
+	var run string
+
+	switch action {
+	case ActionOpen:
+		link := ""
+		if src, ok := sources[s.Source]; ok {
+			link = src.link(s.Symbol)
+		}
+
+		if link == "" {
+			slog.Error(Name, "open", fmt.Sprintf("no page for %s", identifier))
+			return
+		}
+
+		run = strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix("", Name, identifier), common.OpenCommand(), common.Quote(link)))
+	case ActionCopy:
+		quotesMu.Lock()
+		q, ok := quotes[s.id()]
+		quotesMu.Unlock()
+
+		if !ok {
+			slog.Error(Name, "copy", fmt.Sprintf("no quote for %s", identifier))
+			return
+		}
+
+		cmd := common.CopyCmd(strconv.FormatFloat(q.Price, 'f', -1, 64))
+
+		if err := cmd.Start(); err != nil {
+			slog.Error(Name, "copy", err)
+			return
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+
+		return
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	cmd := common.Shell(run)
+	common.Detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+}
+
+func formatPrice(q Quote) string {
+	price := strconv.FormatFloat(q.Price, 'f', 2, 64)
+
+	// f.e. small crypto currencies
+	if math.Abs(q.Price) < 1 {
+		price = strconv.FormatFloat(q.Price, 'g', 4, 64)
+	}
+
+	return strings.TrimSpace(price + " " + q.Currency)
+}
+
+func formatChange(q Quote) string {
+	return fmt.Sprintf("%+.2f (%+.2f%%)", q.Change, q.ChangePercent)
+}
+
+// entry is the item of the symbol with its last quote.
+func entry(s Symbol) *pb.QueryResponse_Item {
+	e := &pb.QueryResponse_Item{
+		Identifier:   s.id(),
+		Text:         s.Symbol,
+		Subtext:      s.Name,
+		Provider:     Name,
+		Icon:         config.Icon,
+		State:        []string{},
+		Actions:      []string{ActionRefresh},
+		LiveInterval: int32(config.Interval * 1000),
+		Type:         pb.QueryResponse_REGULAR,
+	}
+
+	if sources[s.Source] != nil && sources[s.Source].link(s.Symbol) != "" {
+		e.Actions = append(e.Actions, ActionOpen)
+	}
+
+	quotesMu.Lock()
+	q, ok := quotes[s.id()]
+	quotesMu.Unlock()
+
+	if !ok {
+		e.Subtext = strings.Join(nonEmpty(s.Name, "loading…"), " · ")
+		return e
+	}
+
+	e.Text = fmt.Sprintf("%s %s", s.Symbol, formatPrice(q))
+	e.Subtext = strings.Join(nonEmpty(s.Name, formatChange(q)), " · ")
+	e.Actions = append(e.Actions, ActionCopy)
+
+	switch {
+	case q.Change > 0:
+		e.State = append(e.State, StateUp)
+	case q.Change < 0:
+		e.State = append(e.State, StateDown)
+	}
+
+	if s.Above != 0 && q.Price > s.Above {
+		e.State = append(e.State, StateAbove)
+	}
+
+	if s.Below != 0 && q.Price < s.Below {
+		e.State = append(e.State, StateBelow)
+	}
+
+	return e
+}
+
+// Live updates the quotes while clients show the items.
+func Live(identifier string) *pb.QueryResponse_Item {
+	s, ok := find(identifier)
+	if !ok {
+		return nil
+	}
+
+	go refresh(false)
+
+	return entry(s)
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	// cached quotes are shown right away, clients get the new ones as update
+	go refresh(false)
+
+	for k, v := range config.Symbols {
+		e := entry(v)
+		e.Score = int32(len(config.Symbols) - k)
+
+		if query != "" {
+			score, pos, start := common.FuzzyScore(query, v.Symbol, exact)
+
+			// matches of the name don't highlight the symbol
+			if s, _, _ := common.FuzzyScore(query, v.Name, exact); s > score {
+				score, pos, start = s, nil, 0
+			}
+
+			if score <= config.MinScore {
+				continue
+			}
+
+			e.Score = score
+			e.Fuzzyinfo = &pb.QueryResponse_Item_FuzzyInfo{
+				Start:     start,
+				Field:     "text",
+				Positions: pos,
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func nonEmpty(values ...string) []string {
+	res := []string{}
+
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{
+		Actions: []string{ActionRefresh},
+	}
+}
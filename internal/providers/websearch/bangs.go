@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// IdentifierBang is used for bangs that don't match any engine. These get handed to the bang fallback.
+const IdentifierBang = "bang"
+
+// bang resolves "!gh elephant" to the engine index and the search term. Unknown bangs return -1 and
+// the complete bang, so the fallback can resolve it.
+func bang(query string) (int, string, bool) {
+	if !config.Bangs || config.BangPrefix == "" {
+		return 0, "", false
+	}
+
+	after, ok := strings.CutPrefix(query, config.BangPrefix)
+	if !ok {
+		return 0, "", false
+	}
+
+	keyword, term, _ := strings.Cut(after, " ")
+	if keyword == "" {
+		return 0, "", false
+	}
+
+	if i, ok := bangs[keyword]; ok {
+		return i, strings.TrimSpace(term), true
+	}
+
+	return -1, strings.TrimSpace(fmt.Sprintf("!%s %s", keyword, term)), true
+}
+
+func bangEntry(i int, query, term string) *pb.QueryResponse_Item {
+	if i < 0 {
+		return &pb.QueryResponse_Item{
+			Identifier: IdentifierBang,
+			Text:       fmt.Sprintf("%s%s", config.TextPrefix, query),
+			Actions:    []string{ActionSearch},
+			Icon:       config.Icon,
+			Provider:   Name,
+			Score:      int32(100),
+			Type:       0,
+		}
+	}
+
+	e := config.Engines[i]
+
+	icon := e.Icon
+	if icon == "" {
+		icon = config.Icon
+	}
+
+	return &pb.QueryResponse_Item{
+		Identifier: strconv.Itoa(i),
+		Text:       e.Name,
+		Subtext:    term,
+		Actions:    []string{ActionSearch},
+		Icon:       icon,
+		Provider:   Name,
+		Score:      int32(100),
+		Type:       0,
+	}
+}
+
+// importKeywords adds the keyword searches defined in firefox and chromium based browsers as engines.
+// Keywords become both a prefix ("yt cats") and a bang ("!yt cats").
+func importKeywords() {
+	existing := make(map[string]struct{})
+
+	for _, v := range config.Engines {
+		if v.Bang != "" {
+			existing[v.Bang] = struct{}{}
+		}
+
+		if v.Prefix != "" {
+			existing[strings.TrimSpace(v.Prefix)] = struct{}{}
+		}
+	}
+
+	imported := 0
+
+	for _, path := range browserDatabases() {
+		var engines []Engine
+
+		if filepath.Base(path) == "places.sqlite" {
+			engines = readFirefoxKeywords(path)
+		} else {
+			engines = readChromiumKeywords(path)
+		}
+
+		for _, v := range engines {
+			if _, ok := existing[v.Bang]; ok {
+				continue
+			}
+
+			existing[v.Bang] = struct{}{}
+			config.Engines = append(config.Engines, v)
+			imported++
+		}
+	}
+
+	slog.Info(Name, "keywords imported", imported)
+}
+
+// browserDatabases finds the databases of browser profiles containing keywords, 'places.sqlite' of firefox
+// and 'Web Data' of chromium based browsers.
+func browserDatabases() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	var roots []string
+
+	switch runtime.GOOS {
+	case "darwin":
+		roots = []string{filepath.Join(home, "Library", "Application Support")}
+	case "windows":
+		roots = []string{os.Getenv("APPDATA"), os.Getenv("LOCALAPPDATA")}
+	default:
+		for _, v := range []string{".config", ".mozilla", ".zen", ".librewolf", ".waterfox", ".floorp"} {
+			roots = append(roots, filepath.Join(home, v))
+		}
+	}
+
+	res := []string{}
+
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if !d.IsDir() && (d.Name() == "places.sqlite" || d.Name() == "Web Data") {
+				res = append(res, path)
+			}
+
+			return nil
+		})
+	}
+
+	return res
+}
+
+func readFirefoxKeywords(path string) []Engine {
+	query := "SELECT k.keyword, k.keyword, p.url FROM moz_keywords k JOIN moz_places p ON k.place_id = p.id"
+	return readKeywords(path, query, "%s")
+}
+
+// readChromiumKeywords skips keywords containing a dot, as these are the browsers' prepopulated engines.
+func readChromiumKeywords(path string) []Engine {
+	query := "SELECT short_name, keyword, url FROM keywords WHERE url LIKE '%{searchTerms}%' AND keyword NOT LIKE '%.%'"
+	return readKeywords(path, query, "{searchTerms}")
+}
+
+func readKeywords(path, query, placeholder string) []Engine {
+	res := []Engine{}
+
+	escapedPath := strings.ReplaceAll(path, " ", "%20")
+	cmd := exec.Command("sqlite3", "-separator", "|||", fmt.Sprintf("file:%s?immutable=1", escapedPath), query)
+
+	out, err := cmd.Output()
+	if err != nil {
+		slog.Error(Name, "sqlite3", err, "path", path)
+		return res
+	}
+
+	for line := range strings.Lines(string(out)) {
+		parts := strings.SplitN(strings.TrimSpace(line), "|||", 3)
+		if len(parts) != 3 || parts[1] == "" || !strings.Contains(parts[2], placeholder) {
+			continue
+		}
+
+		res = append(res, Engine{
+			Name:   parts[0],
+			Prefix: fmt.Sprintf("%s ", parts[1]),
+			Bang:   parts[1],
+			URL:    strings.ReplaceAll(parts[2], placeholder, "%TERM%"),
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+const ActionDeleteSearch = "delete_search"
+
+// Search is a submitted search term. Unlike the generic history, which only influences sorting,
+// searches are displayed as entries themselves.
+type Search struct {
+	Identifier string
+	Engine     string
+	Term       string
+	LastUsed   time.Time
+}
+
+var (
+	searches    = []Search{}
+	searchesMut sync.Mutex
+)
+
+func searchIdentifier(engine, term string) string {
+	md5 := md5.Sum(fmt.Appendf([]byte(""), "%s%s", engine, term))
+	return hex.EncodeToString(md5[:])
+}
+
+func saveSearch(engine, term string) {
+	term = strings.TrimSpace(term)
+
+	if !config.SearchHistory || term == "" {
+		return
+	}
+
+	searchesMut.Lock()
+	defer searchesMut.Unlock()
+
+	identifier := searchIdentifier(engine, term)
+
+	searches = slices.DeleteFunc(searches, func(s Search) bool {
+		return s.Identifier == identifier
+	})
+
+	searches = append([]Search{{
+		Identifier: identifier,
+		Engine:     engine,
+		Term:       term,
+		LastUsed:   time.Now(),
+	}}, searches...)
+
+	writeSearches()
+}
+
+func deleteSearch(identifier string) {
+	searchesMut.Lock()
+	defer searchesMut.Unlock()
+
+	searches = slices.DeleteFunc(searches, func(s Search) bool {
+		return s.Identifier == identifier
+	})
+
+	writeSearches()
+}
+
+func findSearch(identifier string) (Search, bool) {
+	searchesMut.Lock()
+	defer searchesMut.Unlock()
+
+	i := slices.IndexFunc(searches, func(s Search) bool {
+		return s.Identifier == identifier
+	})
+
+	if i == -1 {
+		return Search{}, false
+	}
+
+	return searches[i], true
+}
+
+// searchSuggestions returns previous searches starting with term. An empty engine matches all engines.
+func searchSuggestions(engine, term string) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	term = strings.ToLower(strings.TrimSpace(term))
+
+	if !config.SearchHistory || term == "" {
+		return entries
+	}
+
+	searchesMut.Lock()
+	defer searchesMut.Unlock()
+
+	for k, v := range searches {
+		if engine != "" && v.Engine != engine {
+			continue
+		}
+
+		if !strings.HasPrefix(strings.ToLower(v.Term), term) || strings.ToLower(v.Term) == term {
+			continue
+		}
+
+		entries = append(entries, &pb.QueryResponse_Item{
+			Identifier: v.Identifier,
+			Text:       v.Term,
+			Subtext:    v.Engine,
+			Actions:    []string{ActionSearch, ActionDeleteSearch},
+			Icon:       config.Icon,
+			Provider:   Name,
+			Score:      max(config.MinScore+1, 50-int32(k)),
+			State:      []string{"searched"},
+			Type:       0,
+		})
+	}
+
+	return entries
+}
+
+func pruneSearches() {
+	if config.SearchMaxAge <= 0 {
+		return
+	}
+
+	maxAge := time.Duration(config.SearchMaxAge) * 24 * time.Hour
+
+	searches = slices.DeleteFunc(searches, func(s Search) bool {
+		return time.Since(s.LastUsed) > maxAge
+	})
+}
+
+func loadSearches() {
+	file := common.CacheFile(fmt.Sprintf("%s_searches.gob", Name))
+
+	if common.FileExists(file) {
+		f, err := os.ReadFile(file)
+		if err != nil {
+			slog.Error(Name, "searches", err)
+		} else {
+			decoder := gob.NewDecoder(bytes.NewReader(f))
+
+			err = decoder.Decode(&searches)
+			if err != nil {
+				slog.Error(Name, "decoding", err)
+			}
+		}
+	}
+
+	pruneSearches()
+}
+
+func writeSearches() {
+	pruneSearches()
+
+	var b bytes.Buffer
+	encoder := gob.NewEncoder(&b)
+
+	err := encoder.Encode(searches)
+	if err != nil {
+		slog.Error(Name, "encode", err)
+		return
+	}
+
+	file := common.CacheFile(fmt.Sprintf("%s_searches.gob", Name))
+
+	err = os.MkdirAll(filepath.Dir(file), 0o755)
+	if err != nil {
+		slog.Error(Name, "createdirs", err)
+		return
+	}
+
+	err = os.WriteFile(file, b.Bytes(), 0o600)
+	if err != nil {
+		slog.Error(Name, "writefile", err)
+	}
+}
@@ -3,17 +3,15 @@ package main
 import (
 	_ "embed"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"net/url"
 	"os"
-	"os/exec"
 	"slices"
 	"strconv"
 	"strings"
-	"syscall"
 
-	"al.essio.dev/pkg/shellescape"
 	"github.com/abenz1267/elephant/v2/internal/comm/handlers"
 	"github.com/abenz1267/elephant/v2/internal/util"
 	"github.com/abenz1267/elephant/v2/pkg/common"
@@ -26,6 +24,7 @@ var (
 	NamePretty = "Websearch"
 	config     *Config
 	prefixes   = make(map[string]int)
+	bangs      = make(map[string]int)
 	h          = history.Load(Name)
 )
 
@@ -39,7 +38,13 @@ type Config struct {
 	HistoryWhenEmpty bool     `koanf:"history_when_empty" desc:"consider history when query is empty" default:"false"`
 	EnginesAsActions bool     `koanf:"engines_as_actions" desc:"run engines as actions" default:"true"`
 	TextPrefix       string   `koanf:"text_prefix" desc:"prefix for the entry text" default:"Search: "`
-	Command          string   `koanf:"command" desc:"default command to be executed. supports %VALUE%." default:"xdg-open"`
+	Command          string   `koanf:"command" desc:"default command to be executed. supports %VALUE%." default:"xdg-open, open on macOS, start on Windows"`
+	Bangs            bool     `koanf:"bangs" desc:"enable DuckDuckGo-style bangs, f.e. '!gh elephant'" default:"true"`
+	BangPrefix       string   `koanf:"bang_prefix" desc:"prefix that starts a bang" default:"!"`
+	BangFallback     string   `koanf:"bang_fallback" desc:"url for unknown bangs, receives the full bang query" default:"https://duckduckgo.com/?q=%TERM%"`
+	ImportKeywords   bool     `koanf:"import_keywords" desc:"import keyword searches from firefox and chromium based browsers" default:"false"`
+	SearchHistory    bool     `koanf:"search_history" desc:"remember submitted search terms per engine and suggest them" default:"true"`
+	SearchMaxAge     int      `koanf:"search_history_max_age" desc:"days after which submitted search terms are forgotten. 0 keeps them forever." default:"90"`
 }
 
 type Engine struct {
@@ -48,6 +53,7 @@ type Engine struct {
 	Prefix  string `koanf:"prefix" desc:"prefix to actively trigger this entry" default:""`
 	URL     string `koanf:"url" desc:"url, example: 'https://www.google.com/search?q=%TERM%'" default:""`
 	Icon    string `koanf:"icon" desc:"icon to display, fallsback to global" default:""`
+	Bang    string `koanf:"bang" desc:"bang to trigger this entry, f.e. 'gh' for '!gh'" default:""`
 }
 
 func Setup() {
@@ -60,7 +66,13 @@ func Setup() {
 		HistoryWhenEmpty: false,
 		EnginesAsActions: false,
 		TextPrefix:       "Search: ",
-		Command:          "xdg-open",
+		Command:          common.OpenCommand(),
+		Bangs:            true,
+		BangPrefix:       "!",
+		BangFallback:     "https://duckduckgo.com/?q=%TERM%",
+		ImportKeywords:   false,
+		SearchHistory:    true,
+		SearchMaxAge:     90,
 	}
 
 	common.LoadConfig(Name, config)
@@ -81,15 +93,12 @@ func Setup() {
 		config.Engines[0].Default = true
 	}
 
-	for k, v := range config.Engines {
-		if v.Default {
-			handlers.MaxGlobalItemsToDisplayWebsearch++
-		}
+	if config.ImportKeywords {
+		importKeywords()
+	}
 
-		if v.Prefix != "" {
-			prefixes[v.Prefix] = k
-			handlers.WebsearchPrefixes[v.Prefix] = v.Name
-		}
+	if config.SearchHistory {
+		loadSearches()
 	}
 
 	slices.SortFunc(config.Engines, func(a, b Engine) int {
@@ -103,32 +112,100 @@ func Setup() {
 
 		return 0
 	})
+
+	for k, v := range config.Engines {
+		if v.Default {
+			handlers.MaxGlobalItemsToDisplayWebsearch++
+		}
+
+		if v.Prefix != "" {
+			prefixes[v.Prefix] = k
+			handlers.WebsearchPrefixes[v.Prefix] = v.Name
+		}
+
+		if v.Bang != "" && config.Bangs {
+			bangs[v.Bang] = k
+			handlers.WebsearchPrefixes[fmt.Sprintf("%s%s ", config.BangPrefix, v.Bang)] = v.Name
+		}
+	}
 }
 
 func Available() bool {
 	return true
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 const ActionSearch = "search"
 
+// term is passed as argument when searching for something else than the query.
+var term = &pb.ActionArgument{Name: "term", Type: util.ArgumentText, Placeholder: "Search term"}
+
+func Actions() []*pb.ActionDescriptor {
+	res := []*pb.ActionDescriptor{
+		{Action: ActionSearch, Label: "Search", Icon: "system-search", Default: true, Arguments: []*pb.ActionArgument{term}},
+		{Action: ActionDeleteSearch, Label: "Forget search", Icon: "edit-delete"},
+	}
+
+	if config.EnginesAsActions {
+		for _, v := range config.Engines {
+			res = append(res, &pb.ActionDescriptor{
+				Action:    v.Name,
+				Label:     v.Name,
+				Icon:      v.Icon,
+				Default:   v.Default,
+				Arguments: []*pb.ActionArgument{term},
+			})
+		}
+	}
+
+	return res
+}
+
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
 	switch action {
 	case history.ActionDelete:
 		h.Remove(identifier)
 		return
+	case ActionDeleteSearch:
+		deleteSearch(identifier)
+		return
 	case ActionSearch:
+		if s, ok := findSearch(identifier); ok {
+			i := slices.IndexFunc(config.Engines, func(e Engine) bool {
+				return e.Name == s.Engine
+			})
+
+			if i == -1 {
+				slog.Error(Name, "activate", fmt.Sprintf("unknown engine: %s", s.Engine))
+				return
+			}
+
+			identifier = strconv.Itoa(i)
+			query = s.Term
+			args = s.Term
+		}
+
+		if identifier == IdentifierBang {
+			_, term, _ := bang(query)
+			run(query, identifier, strings.ReplaceAll(os.ExpandEnv(config.BangFallback), "%TERM%", url.QueryEscape(term)))
+			return
+		}
+
 		i, _ := strconv.Atoi(identifier)
 
-		for k := range prefixes {
-			if after, ok := strings.CutPrefix(query, k); ok {
-				query = after
-				break
+		if _, term, ok := bang(query); ok {
+			query = term
+		} else {
+			for k := range prefixes {
+				if after, ok := strings.CutPrefix(query, k); ok {
+					query = after
+					break
+				}
 			}
 		}
 
@@ -149,6 +226,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 			q = strings.ReplaceAll(os.ExpandEnv(config.Engines[i].URL), "%CLIPBOARD%", url.QueryEscape(clipboard))
 		} else {
 			q = strings.ReplaceAll(os.ExpandEnv(config.Engines[i].URL), "%TERM%", url.QueryEscape(strings.TrimSpace(args)))
+			saveSearch(config.Engines[i].Name, args)
 		}
 
 		run(query, identifier, q)
@@ -160,9 +238,16 @@ func Activate(single bool, identifier, action string, query string, args string,
 			return
 		}
 
+		engine := ""
+
+		if args != "" {
+			query = args
+		}
+
 		for _, v := range config.Engines {
 			if v.Name == action {
 				q = v.URL
+				engine = v.Name
 				break
 			}
 		}
@@ -178,6 +263,7 @@ func Activate(single bool, identifier, action string, query string, args string,
 			q = strings.ReplaceAll(q, "%CLIPBOARD%", url.QueryEscape(clipboard))
 		} else {
 			q = strings.ReplaceAll(q, "%TERM%", url.QueryEscape(strings.TrimSpace(query)))
+			saveSearch(engine, query)
 		}
 
 		run(query, identifier, q)
@@ -185,11 +271,8 @@ func Activate(single bool, identifier, action string, query string, args string,
 }
 
 func run(query, identifier, q string) {
-	cmd := exec.Command("sh", "-c", strings.TrimSpace(fmt.Sprintf("%s %s %s", common.LaunchPrefix(""), config.Command, shellescape.Quote(q))))
-
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setsid: true,
-	}
+	cmd := common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix("", Name, identifier), config.Command, common.Quote(q))))
+	common.Detach(cmd)
 
 	err := cmd.Start()
 	if err != nil {
@@ -208,6 +291,16 @@ func run(query, identifier, q string) {
 func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
 	entries := []*pb.QueryResponse_Item{}
 
+	if i, term, ok := bang(query); ok {
+		entries = append(entries, bangEntry(i, query, term))
+
+		if i >= 0 {
+			entries = append(entries, searchSuggestions(config.Engines[i].Name, term)...)
+		}
+
+		return entries
+	}
+
 	prefix := ""
 
 	for k := range prefixes {
@@ -310,6 +403,12 @@ func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.
 		}
 	}
 
+	if prefix != "" {
+		entries = append(entries, searchSuggestions(config.Engines[prefixes[prefix]].Name, strings.TrimPrefix(query, prefix))...)
+	} else if single {
+		entries = append(entries, searchSuggestions("", query)...)
+	}
+
 	return entries
 }
 
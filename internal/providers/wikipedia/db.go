@@ -0,0 +1,144 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common/store"
+)
+
+var db *store.Store
+
+// searches caches the articles found for a query, articles their summaries. Both are used when offline,
+// regardless of their age.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS searches (
+		site TEXT NOT NULL,
+		query TEXT NOT NULL,
+		articles TEXT NOT NULL,
+		fetched INTEGER NOT NULL,
+		PRIMARY KEY (site, query)
+	);
+	CREATE TABLE IF NOT EXISTS articles (
+		url TEXT PRIMARY KEY,
+		site TEXT NOT NULL,
+		title TEXT NOT NULL,
+		description TEXT NOT NULL,
+		extract TEXT NOT NULL,
+		fetched INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_articles_site ON articles(site);`,
+}
+
+type Article struct {
+	Title       string
+	URL         string
+	Description string
+	Extract     string
+	fetched     time.Time
+}
+
+func openDB() error {
+	var err error
+
+	db, err = store.Open(Name, migrations...)
+
+	return err
+}
+
+func cachedSearch(site, query string) ([]Article, time.Time, bool) {
+	var data string
+	var fetched int64
+
+	if err := db.QueryRow("SELECT articles, fetched FROM searches WHERE site = ? AND query = ?", site, query).Scan(&data, &fetched); err != nil {
+		return nil, time.Time{}, false
+	}
+
+	var res []Article
+
+	if err := json.Unmarshal([]byte(data), &res); err != nil {
+		return nil, time.Time{}, false
+	}
+
+	return res, time.Unix(fetched, 0), true
+}
+
+func putSearch(site, query string, articles []Article) error {
+	data, err := json.Marshal(articles)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`INSERT INTO searches (site, query, articles, fetched) VALUES (?, ?, ?, ?)
+		ON CONFLICT(site, query) DO UPDATE SET articles = excluded.articles, fetched = excluded.fetched`,
+		site, query, string(data), time.Now().Unix())
+
+	return err
+}
+
+func cachedArticle(url string) (Article, bool) {
+	var a Article
+	var fetched int64
+
+	err := db.QueryRow("SELECT title, url, description, extract, fetched FROM articles WHERE url = ?", url).
+		Scan(&a.Title, &a.URL, &a.Description, &a.Extract, &fetched)
+	if err != nil {
+		return a, false
+	}
+
+	a.fetched = time.Unix(fetched, 0)
+
+	return a, true
+}
+
+func putArticle(site string, a Article) error {
+	_, err := db.Exec(`INSERT INTO articles (url, site, title, description, extract, fetched) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(url) DO UPDATE SET title = excluded.title, description = excluded.description, extract = excluded.extract, fetched = excluded.fetched`,
+		a.URL, site, a.Title, a.Description, a.Extract, time.Now().Unix())
+
+	return err
+}
+
+// offlineSearch finds cached articles by title, for queries that weren't searched before.
+func offlineSearch(site, query string, limit int) ([]Article, error) {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(query)
+
+	rows, err := db.Query(`SELECT title, url, description, extract FROM articles WHERE site = ? AND title LIKE ? ESCAPE '\'
+		ORDER BY length(title) LIMIT ?`, site, "%"+escaped+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []Article{}
+
+	for rows.Next() {
+		var a Article
+
+		if err := rows.Scan(&a.Title, &a.URL, &a.Description, &a.Extract); err != nil {
+			return nil, err
+		}
+
+		res = append(res, a)
+	}
+
+	return res, rows.Err()
+}
+
+// prune removes cached searches and articles older than the max age.
+func prune(maxAge time.Duration) error {
+	before := time.Now().Add(-maxAge).Unix()
+
+	return db.Tx(func(tx *sql.Tx) error {
+		_, err := tx.Exec("DELETE FROM searches WHERE fetched < ?", before)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("DELETE FROM articles WHERE fetched < ?", before)
+
+		return err
+	})
+}
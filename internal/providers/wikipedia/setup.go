@@ -0,0 +1,231 @@
+// Package wikipedia shows summaries of wikipedia or kiwix articles.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "wikipedia"
+	NamePretty = "Wikipedia"
+	config     *Config
+
+	queries common.Debouncer
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config  `koanf:",squash"`
+	Backend        string `koanf:"backend" desc:"'wikipedia' or 'kiwix'" default:"wikipedia"`
+	Language       string `koanf:"language" desc:"language of wikipedia, f.e. 'de'" default:"en"`
+	KiwixURL       string `koanf:"kiwix_url" desc:"url of kiwix-serve" default:"http://localhost:8080"`
+	KiwixBook      string `koanf:"kiwix_book" desc:"name of the book in kiwix-serve, f.e. 'wikipedia_en_all_maxi'" default:""`
+	Debounce       int    `koanf:"debounce" desc:"milliseconds to wait for further typing before searching" default:"300"`
+	MinQueryLength int    `koanf:"min_query_length" desc:"minimum query length to search" default:"3"`
+	MaxResults     int    `koanf:"max_results" desc:"maximum number of articles returned" default:"8"`
+	CacheTTL       int    `koanf:"cache_ttl" desc:"days searches and summaries are cached. they're used offline regardless" default:"7"`
+	CacheMaxAge    int    `koanf:"cache_max_age" desc:"days after which cached searches and summaries are removed" default:"90"`
+}
+
+const (
+	ActionOpen = "open"
+	ActionCopy = "copy"
+)
+
+func cacheTTL() time.Duration {
+	return time.Duration(config.CacheTTL) * 24 * time.Hour
+}
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "accessories-dictionary",
+			MinScore: 20,
+		},
+		Backend:        "wikipedia",
+		Language:       "en",
+		KiwixURL:       "http://localhost:8080",
+		Debounce:       300,
+		MinQueryLength: 3,
+		MaxResults:     8,
+		CacheTTL:       7,
+		CacheMaxAge:    90,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	if err := openDB(); err != nil {
+		slog.Error(Name, "db", err)
+		return
+	}
+
+	if err := prune(time.Duration(config.CacheMaxAge) * 24 * time.Hour); err != nil {
+		slog.Error(Name, "prune", err)
+	}
+}
+
+func Available() bool {
+	if config.Backend == "kiwix" && config.KiwixBook == "" {
+		slog.Info(Name, "available", "kiwix_book not set. disabling")
+		return false
+	}
+
+	return db != nil
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionOpen, Label: "Open article", Icon: "web-browser", Default: true},
+		{Action: ActionCopy, Label: "Copy summary", Icon: "edit-copy"},
+	}
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionOpen
+	}
+
+	var cmd *exec.Cmd
+
+	switch action {
+	case ActionOpen:
+		cmd = common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix("", Name, identifier), common.OpenCommand(), common.Quote(identifier))))
+		common.Detach(cmd)
+	case ActionCopy:
+		a, ok := cachedArticle(identifier)
+		if !ok || a.Extract == "" {
+			slog.Error(Name, "copy", fmt.Sprintf("no summary: %s", identifier))
+			return
+		}
+
+		cmd = common.CopyCmd(a.Extract)
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	if err := cmd.Start(); err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	query = strings.TrimSpace(query)
+
+	if db == nil || len(query) < config.MinQueryLength {
+		return entries
+	}
+
+	if _, fetched, ok := cachedSearch(site(), query); !queries.Wait(config.Debounce, ok && time.Since(fetched) < cacheTTL()) {
+		return entries
+	}
+
+	articles, err := search(query)
+	if err != nil {
+		slog.Error(Name, "search", err)
+		return entries
+	}
+
+	var wg sync.WaitGroup
+
+	for k := range articles {
+		wg.Go(func() {
+			a, err := summary(articles[k])
+			if err != nil {
+				slog.Error(Name, "summary", err)
+			}
+
+			articles[k] = a
+		})
+	}
+
+	wg.Wait()
+
+	// the wiki sorted by relevance
+	for k, v := range articles {
+		subtext := v.Description
+
+		if subtext == "" {
+			subtext = firstSentence(v.Extract)
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier: v.URL,
+			Text:       v.Title,
+			Subtext:    subtext,
+			Provider:   Name,
+			Icon:       config.Icon,
+			Actions:    []string{ActionOpen},
+			Type:       pb.QueryResponse_REGULAR,
+			Score:      int32(len(articles) - k),
+		}
+
+		if v.Extract != "" {
+			e.Actions = append(e.Actions, ActionCopy)
+			e.Preview = fmt.Sprintf("%s\n\n%s\n\n%s", v.Title, v.Extract, v.URL)
+			e.PreviewType = util.PreviewTypeText
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// firstSentence returns the first paragraph up to the first full stop, or its first 120 characters.
+func firstSentence(s string) string {
+	s, _, _ = strings.Cut(s, "\n")
+
+	if i := strings.Index(s, ". "); i > 0 {
+		s = s[:i+1]
+	}
+
+	if r := []rune(s); len(r) > 120 {
+		return string(r[:119]) + "…"
+	}
+
+	return s
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
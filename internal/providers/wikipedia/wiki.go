@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var client = http.Client{Timeout: 10 * time.Second}
+
+// wikimedia asks for a user agent identifying the client
+const userAgent = "elephant (https://github.com/abenz1267/elephant)"
+
+// site identifies the wiki in the cache.
+func site() string {
+	if config.Backend == "kiwix" {
+		return "kiwix:" + config.KiwixBook
+	}
+
+	return "wikipedia:" + config.Language
+}
+
+func wikipediaURL() string {
+	return fmt.Sprintf("https://%s.wikipedia.org", config.Language)
+}
+
+func kiwixURL() string {
+	return strings.TrimSuffix(config.KiwixURL, "/")
+}
+
+func get(u string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+
+	return resp, nil
+}
+
+// search returns the articles matching the query. Searches are cached for cache_ttl, older ones and cached
+// articles are used if the wiki can't be reached.
+func search(query string) ([]Article, error) {
+	cached, fetched, ok := cachedSearch(site(), query)
+	if ok && time.Since(fetched) < cacheTTL() {
+		return cached, nil
+	}
+
+	var res []Article
+	var err error
+
+	if config.Backend == "kiwix" {
+		res, err = searchKiwix(query)
+	} else {
+		res, err = searchWikipedia(query)
+	}
+
+	if err != nil {
+		if ok {
+			slog.Info(Name, "offline", err)
+			return cached, nil
+		}
+
+		if res, offlineErr := offlineSearch(site(), query, config.MaxResults); offlineErr == nil && len(res) > 0 {
+			slog.Info(Name, "offline", err)
+			return res, nil
+		}
+
+		return nil, err
+	}
+
+	if err := putSearch(site(), query, res); err != nil {
+		slog.Error(Name, "cache", err)
+	}
+
+	return res, nil
+}
+
+// summary returns the article with its summary, cached for cache_ttl. Cached summaries of any age are used if the
+// wiki can't be reached.
+func summary(a Article) (Article, error) {
+	cached, ok := cachedArticle(a.URL)
+	if ok && time.Since(cached.fetched) < cacheTTL() {
+		return cached, nil
+	}
+
+	var res Article
+	var err error
+
+	if config.Backend == "kiwix" {
+		res, err = summaryKiwix(a)
+	} else {
+		res, err = summaryWikipedia(a)
+	}
+
+	if err != nil {
+		if ok {
+			return cached, nil
+		}
+
+		return a, err
+	}
+
+	if err := putArticle(site(), res); err != nil {
+		slog.Error(Name, "cache", err)
+	}
+
+	return res, nil
+}
+
+// searchWikipedia uses the opensearch api, which answers with [query, titles, descriptions, urls].
+func searchWikipedia(query string) ([]Article, error) {
+	resp, err := get(wikipediaURL() + "/w/api.php?" + url.Values{
+		"action":    {"opensearch"},
+		"search":    {query},
+		"limit":     {strconv.Itoa(config.MaxResults)},
+		"namespace": {"0"},
+		"format":    {"json"},
+	}.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var data []json.RawMessage
+
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	if len(data) < 4 {
+		return nil, errors.New("unexpected opensearch response")
+	}
+
+	var titles, urls []string
+
+	if err := json.Unmarshal(data[1], &titles); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data[3], &urls); err != nil {
+		return nil, err
+	}
+
+	res := []Article{}
+
+	for k, v := range titles {
+		if k < len(urls) {
+			res = append(res, Article{Title: v, URL: urls[k]})
+		}
+	}
+
+	return res, nil
+}
+
+func summaryWikipedia(a Article) (Article, error) {
+	resp, err := get(wikipediaURL() + "/api/rest_v1/page/summary/" + url.PathEscape(strings.ReplaceAll(a.Title, " ", "_")))
+	if err != nil {
+		return a, err
+	}
+	defer resp.Body.Close()
+
+	var data struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Extract     string `json:"extract"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return a, err
+	}
+
+	a.Description = data.Description
+	a.Extract = data.Extract
+
+	return a, nil
+}
+
+// searchKiwix uses the full text search of kiwix-serve, which answers with an rss feed.
+func searchKiwix(query string) ([]Article, error) {
+	resp, err := get(kiwixURL() + "/search?" + url.Values{
+		"books.name": {config.KiwixBook},
+		"pattern":    {query},
+		"format":     {"xml"},
+		"pageLength": {strconv.Itoa(config.MaxResults)},
+	}.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var data struct {
+		Items []struct {
+			Title string `xml:"title"`
+			Link  string `xml:"link"`
+		} `xml:"channel>item"`
+	}
+
+	if err := xml.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	res := []Article{}
+
+	for _, v := range data.Items {
+		link := v.Link
+
+		if strings.HasPrefix(link, "/") {
+			link = kiwixURL() + link
+		}
+
+		res = append(res, Article{Title: strings.TrimSpace(v.Title), URL: link})
+	}
+
+	return res, nil
+}
+
+// summaryKiwix reads the first paragraphs of the article.
+func summaryKiwix(a Article) (Article, error) {
+	resp, err := get(a.URL)
+	if err != nil {
+		return a, err
+	}
+	defer resp.Body.Close()
+
+	a.Extract = paragraphs(resp.Body, 1000)
+
+	return a, nil
+}
+
+// paragraphs returns the text of the html documents paragraphs, until it's at least n characters long.
+func paragraphs(r io.Reader, n int) string {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	res := []string{}
+	length := 0
+
+	var p strings.Builder
+
+	depth := 0
+
+	for length < n {
+		t, err := d.Token()
+		if err != nil {
+			break
+		}
+
+		switch v := t.(type) {
+		case xml.StartElement:
+			if v.Name.Local == "p" {
+				depth++
+			}
+		case xml.EndElement:
+			if v.Name.Local != "p" || depth == 0 {
+				continue
+			}
+
+			depth--
+
+			if depth > 0 {
+				continue
+			}
+
+			if text := strings.Join(strings.Fields(p.String()), " "); text != "" {
+				res = append(res, text)
+				length += len(text)
+			}
+
+			p.Reset()
+		case xml.CharData:
+			if depth > 0 {
+				p.Write(v)
+			}
+		}
+	}
+
+	return strings.Join(res, "\n\n")
+}
@@ -1,12 +1,16 @@
+//go:build linux
+
 // Package windows provides window focusing.
 package main
 
 import (
 	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
 	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -47,7 +51,7 @@ var config *Config
 func Setup() {
 	start := time.Now()
 
-	if !wlr.IsSetup {
+	if !common.X11() && !wlr.IsSetup {
 		go wlr.Init()
 	}
 
@@ -71,13 +75,21 @@ func Setup() {
 }
 
 func Available() bool {
+	if common.X11() {
+		p, err := exec.LookPath("wmctrl")
+		if p == "" || err != nil {
+			slog.Info(Name, "available", "wmctrl not found. disabling")
+			return false
+		}
+	}
+
 	return true
 }
 
-func PrintDoc() {
-	fmt.Println(readme)
-	fmt.Println()
-	util.PrintConfig(Config{}, Name)
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
 }
 
 const (
@@ -87,6 +99,11 @@ const (
 func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
 	time.Sleep(time.Duration(config.Delay) * time.Millisecond)
 
+	if common.X11() {
+		x11Activate(identifier)
+		return
+	}
+
 	i, _ := strconv.Atoi(identifier)
 
 	wlr.Activate(wl.ProxyId(i))
@@ -97,11 +114,9 @@ func Query(conn net.Conn, query string, _ bool, exact bool, _ uint8) []*pb.Query
 
 	entries := []*pb.QueryResponse_Item{}
 
-	windows := wlr.Windows()
-
-	for k, window := range windows {
+	for k, window := range listWindows() {
 		e := &pb.QueryResponse_Item{
-			Identifier: fmt.Sprintf("%d", k),
+			Identifier: k,
 			Text:       window.Title,
 			Subtext:    window.AppID,
 			Actions:    []string{ActionFocus},
@@ -144,6 +159,21 @@ func Query(conn net.Conn, query string, _ bool, exact bool, _ uint8) []*pb.Query
 	return entries
 }
 
+// listWindows returns the open windows keyed by the identifier used for activating them.
+func listWindows() map[string]*wlr.Window {
+	if common.X11() {
+		return x11Windows()
+	}
+
+	res := make(map[string]*wlr.Window)
+
+	for k, window := range wlr.Windows() {
+		res[fmt.Sprintf("%d", k)] = window
+	}
+
+	return res
+}
+
 func Icon() string {
 	return config.Icon
 }
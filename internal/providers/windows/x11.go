@@ -0,0 +1,52 @@
+//go:build linux
+
+package main
+
+import (
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/common/wlr"
+)
+
+// wmctrlLine matches "<id> <desktop> <instance>.<class> <host> <title>". Sticky windows like panels are on
+// desktop -1.
+var wmctrlLine = regexp.MustCompile(`^(\S+)\s+(\S+)\s+(\S+)\s+\S+\s*(.*)$`)
+
+// x11Windows lists the windows of an X11 session with wmctrl, keyed by their window id.
+func x11Windows() map[string]*wlr.Window {
+	res := make(map[string]*wlr.Window)
+
+	out, err := exec.Command("wmctrl", "-lx").Output()
+	if err != nil {
+		slog.Error(Name, "wmctrl", err)
+		return res
+	}
+
+	for l := range strings.Lines(string(out)) {
+		m := wmctrlLine.FindStringSubmatch(strings.TrimSpace(l))
+		if m == nil || m[2] == "-1" {
+			continue
+		}
+
+		class := m[3]
+		if _, after, ok := strings.Cut(class, "."); ok {
+			class = after
+		}
+
+		res[m[1]] = &wlr.Window{
+			AppID: class,
+			Title: m[4],
+		}
+	}
+
+	return res
+}
+
+func x11Activate(id string) {
+	if out, err := exec.Command("wmctrl", "-ia", id).CombinedOutput(); err != nil {
+		slog.Error(Name, "activate", err, "out", string(out))
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+var client = http.Client{Timeout: 10 * time.Second}
+
+type Video struct {
+	ID        string
+	Title     string
+	Author    string
+	Duration  time.Duration
+	Views     int64
+	Published string
+	Thumbnail string
+}
+
+type cached struct {
+	fetched time.Time
+	videos  []Video
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]cached{}
+)
+
+// cachedVideos returns the videos of the query if they were fetched within cache_ttl.
+func cachedVideos(query string) ([]Video, bool) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	c, ok := cache[query]
+	if !ok || time.Since(c.fetched) >= time.Duration(config.CacheTTL)*time.Minute {
+		return nil, false
+	}
+
+	return c.videos, true
+}
+
+// search returns the videos of the query, from the cache if possible. Instances are tried in order, as public
+// ones are often down or rate limited.
+func search(query string) ([]Video, error) {
+	if videos, ok := cachedVideos(query); ok {
+		return videos, nil
+	}
+
+	ttl := time.Duration(config.CacheTTL) * time.Minute
+
+	var errs []error
+
+	for _, instance := range config.Instances {
+		instance = strings.TrimSuffix(instance, "/")
+
+		var videos []Video
+		var err error
+
+		if config.API == "piped" {
+			videos, err = searchPiped(instance, query)
+		} else {
+			videos, err = searchInvidious(instance, query)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", instance, err))
+			continue
+		}
+
+		if len(videos) > config.MaxResults {
+			videos = videos[:config.MaxResults]
+		}
+
+		cacheMu.Lock()
+		for k, v := range cache {
+			if time.Since(v.fetched) >= ttl {
+				delete(cache, k)
+			}
+		}
+
+		cache[query] = cached{fetched: time.Now(), videos: videos}
+		cacheMu.Unlock()
+
+		return videos, nil
+	}
+
+	if len(errs) == 0 {
+		return nil, errors.New("no instances configured")
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+func get(u string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("User-Agent", "elephant")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func searchInvidious(instance, query string) ([]Video, error) {
+	var res []struct {
+		Type          string `json:"type"`
+		VideoID       string `json:"videoId"`
+		Title         string `json:"title"`
+		Author        string `json:"author"`
+		LengthSeconds int64  `json:"lengthSeconds"`
+		ViewCount     int64  `json:"viewCount"`
+		PublishedText string `json:"publishedText"`
+		Thumbnails    []struct {
+			Quality string `json:"quality"`
+			URL     string `json:"url"`
+		} `json:"videoThumbnails"`
+	}
+
+	if err := get(instance+"/api/v1/search?"+url.Values{"q": {query}, "type": {"video"}}.Encode(), &res); err != nil {
+		return nil, err
+	}
+
+	videos := []Video{}
+
+	for _, v := range res {
+		if v.Type != "video" || !validID(v.VideoID) {
+			continue
+		}
+
+		video := Video{
+			ID:        v.VideoID,
+			Title:     v.Title,
+			Author:    v.Author,
+			Duration:  time.Duration(v.LengthSeconds) * time.Second,
+			Views:     v.ViewCount,
+			Published: v.PublishedText,
+		}
+
+		for _, t := range v.Thumbnails {
+			if t.Quality == "medium" || video.Thumbnail == "" {
+				video.Thumbnail = t.URL
+			}
+		}
+
+		// some instances return thumbnails relative to themselves
+		if strings.HasPrefix(video.Thumbnail, "/") {
+			video.Thumbnail = instance + video.Thumbnail
+		}
+
+		videos = append(videos, video)
+	}
+
+	return videos, nil
+}
+
+func searchPiped(instance, query string) ([]Video, error) {
+	var res struct {
+		Items []struct {
+			Type         string `json:"type"`
+			URL          string `json:"url"`
+			Title        string `json:"title"`
+			Thumbnail    string `json:"thumbnail"`
+			UploaderName string `json:"uploaderName"`
+			Duration     int64  `json:"duration"`
+			Views        int64  `json:"views"`
+			UploadedDate string `json:"uploadedDate"`
+		} `json:"items"`
+	}
+
+	if err := get(instance+"/search?"+url.Values{"q": {query}, "filter": {"videos"}}.Encode(), &res); err != nil {
+		return nil, err
+	}
+
+	videos := []Video{}
+
+	for _, v := range res.Items {
+		id := strings.TrimPrefix(v.URL, "/watch?v=")
+
+		if v.Type != "stream" || !validID(id) {
+			continue
+		}
+
+		videos = append(videos, Video{
+			ID:        id,
+			Title:     v.Title,
+			Author:    v.UploaderName,
+			Duration:  time.Duration(max(v.Duration, 0)) * time.Second,
+			Views:     v.Views,
+			Published: v.UploadedDate,
+			Thumbnail: v.Thumbnail,
+		})
+	}
+
+	return videos, nil
+}
+
+// validID reports whether the id looks like a video id. Ids name the thumbnail files.
+func validID(id string) bool {
+	if id == "" {
+		return false
+	}
+
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func thumbnailDir() string {
+	return common.CacheFile(filepath.Join(Name, "thumbnails"))
+}
+
+// thumbnails downloads the thumbnails of the videos in parallel and returns their files by video id. Already
+// downloaded ones are reused.
+func thumbnails(videos []Video) map[string]string {
+	res := map[string]string{}
+
+	if err := os.MkdirAll(thumbnailDir(), 0o755); err != nil {
+		return res
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, v := range videos {
+		if v.Thumbnail == "" {
+			continue
+		}
+
+		file := filepath.Join(thumbnailDir(), v.ID+".jpg")
+
+		if common.FileExists(file) {
+			// keeps it from being cleaned up
+			now := time.Now()
+			os.Chtimes(file, now, now)
+
+			res[v.ID] = file
+			continue
+		}
+
+		wg.Go(func() {
+			if err := download(v.Thumbnail, file); err != nil {
+				return
+			}
+
+			mu.Lock()
+			res[v.ID] = file
+			mu.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	return res
+}
+
+func download(u, file string) error {
+	resp, err := client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+
+	tmp := file + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
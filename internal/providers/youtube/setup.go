@@ -0,0 +1,246 @@
+// Package youtube searches videos via invidious or piped and watches them with mpv.
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/internal/util"
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+var (
+	Name       = "youtube"
+	NamePretty = "YouTube"
+	config     *Config
+
+	queries common.Debouncer
+)
+
+//go:embed README.md
+var readme string
+
+type Config struct {
+	common.Config  `koanf:",squash"`
+	API            string   `koanf:"api" desc:"'invidious' or 'piped'" default:"invidious"`
+	Instances      []string `koanf:"instances" desc:"api urls of the instances, tried in order" default:"[\"https://inv.nadeko.net\", \"https://yewtu.be\"]"`
+	VideoURL       string   `koanf:"video_url" desc:"url of a video, %ID% is replaced" default:"https://www.youtube.com/watch?v=%ID%"`
+	WatchCommand   string   `koanf:"watch_command" desc:"plays a video, %URL% is replaced" default:"mpv %URL%"`
+	Debounce       int      `koanf:"debounce" desc:"milliseconds to wait for further typing before searching" default:"400"`
+	MinQueryLength int      `koanf:"min_query_length" desc:"minimum query length to search" default:"3"`
+	MaxResults     int      `koanf:"max_results" desc:"maximum number of videos returned" default:"20"`
+	CacheTTL       int      `koanf:"cache_ttl" desc:"minutes results of a query are cached" default:"30"`
+}
+
+const (
+	ActionWatch = "watch"
+	ActionOpen  = "open"
+	ActionCopy  = "copy"
+)
+
+// thumbnails not shown within this time are removed on startup
+const thumbnailMaxAge = 7 * 24 * time.Hour
+
+func Setup() {
+	config = &Config{
+		Config: common.Config{
+			Icon:     "video-x-generic",
+			MinScore: 20,
+		},
+		API:            "invidious",
+		Instances:      []string{"https://inv.nadeko.net", "https://yewtu.be"},
+		VideoURL:       "https://www.youtube.com/watch?v=%ID%",
+		WatchCommand:   "mpv %URL%",
+		Debounce:       400,
+		MinQueryLength: 3,
+		MaxResults:     20,
+		CacheTTL:       30,
+	}
+
+	common.LoadConfig(Name, config)
+
+	if config.NamePretty != "" {
+		NamePretty = config.NamePretty
+	}
+
+	go cleanThumbnails()
+}
+
+func cleanThumbnails() {
+	files, err := os.ReadDir(thumbnailDir())
+	if err != nil {
+		return
+	}
+
+	for _, v := range files {
+		if info, err := v.Info(); err == nil && time.Since(info.ModTime()) > thumbnailMaxAge {
+			os.Remove(filepath.Join(thumbnailDir(), v.Name()))
+		}
+	}
+}
+
+func Available() bool {
+	return true
+}
+
+func PrintDoc(w io.Writer) {
+	fmt.Fprintln(w, readme)
+	fmt.Fprintln(w)
+	util.PrintConfig(w, Config{}, Name)
+}
+
+func Actions() []*pb.ActionDescriptor {
+	return []*pb.ActionDescriptor{
+		{Action: ActionWatch, Label: "Watch", Icon: "media-playback-start", Default: true},
+		{Action: ActionOpen, Label: "Open in browser", Icon: "web-browser"},
+		{Action: ActionCopy, Label: "Copy URL", Icon: "edit-copy"},
+	}
+}
+
+func videoURL(id string) string {
+	return strings.ReplaceAll(config.VideoURL, "%ID%", id)
+}
+
+func Activate(single bool, identifier, action string, query string, args string, format uint8, conn net.Conn) {
+	if action == "" {
+		action = ActionWatch
+	}
+
+	u := videoURL(identifier)
+
+	var cmd *exec.Cmd
+
+	switch action {
+	case ActionWatch:
+		run := strings.ReplaceAll(config.WatchCommand, "%URL%", common.Quote(u))
+
+		cmd = common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s", common.ScopedLaunchPrefix("", Name, identifier), run)))
+		common.Detach(cmd)
+	case ActionOpen:
+		cmd = common.Shell(strings.TrimSpace(fmt.Sprintf("%s %s %s", common.ScopedLaunchPrefix("", Name, identifier), common.OpenCommand(), common.Quote(u))))
+		common.Detach(cmd)
+	case ActionCopy:
+		cmd = common.CopyCmd(u)
+	default:
+		slog.Error(Name, "activate", fmt.Sprintf("unknown action: %s", action))
+		return
+	}
+
+	if err := cmd.Start(); err != nil {
+		slog.Error(Name, "activate", err)
+		return
+	}
+
+	go func() {
+		cmd.Wait()
+	}()
+}
+
+func Query(conn net.Conn, query string, single bool, exact bool, _ uint8) []*pb.QueryResponse_Item {
+	entries := []*pb.QueryResponse_Item{}
+
+	query = strings.TrimSpace(query)
+
+	if len(query) < config.MinQueryLength {
+		return entries
+	}
+
+	if _, ok := cachedVideos(query); !queries.Wait(config.Debounce, ok) {
+		return entries
+	}
+
+	videos, err := search(query)
+	if err != nil {
+		slog.Error(Name, "search", err)
+		return entries
+	}
+
+	thumbs := thumbnails(videos)
+
+	// the api sorted by relevance
+	for k, v := range videos {
+		subtext := []string{}
+
+		for _, s := range []string{v.Author, formatDuration(v.Duration), formatViews(v.Views), v.Published} {
+			if s != "" {
+				subtext = append(subtext, s)
+			}
+		}
+
+		e := &pb.QueryResponse_Item{
+			Identifier: v.ID,
+			Text:       v.Title,
+			Subtext:    strings.Join(subtext, " · "),
+			Provider:   Name,
+			Icon:       config.Icon,
+			Actions:    []string{ActionWatch, ActionOpen, ActionCopy},
+			Type:       pb.QueryResponse_REGULAR,
+			Score:      int32(len(videos) - k),
+		}
+
+		if file, ok := thumbs[v.ID]; ok {
+			e.Preview = file
+			e.PreviewType = util.PreviewTypeFile
+
+			if common.WantsThumbnails(conn) {
+				e.Thumbnail = common.Thumbnail(file)
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// formatDuration formats durations like "1:02:03" or "4:05". Live streams have none.
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// formatViews formats view counts like "1.2M views".
+func formatViews(n int64) string {
+	switch {
+	case n <= 0:
+		return ""
+	case n >= 1_000_000_000:
+		return fmt.Sprintf("%.1fB views", float64(n)/1e9)
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM views", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fK views", float64(n)/1e3)
+	default:
+		return fmt.Sprintf("%d views", n)
+	}
+}
+
+func Icon() string {
+	return config.Icon
+}
+
+func HideFromProviderlist() bool {
+	return config.HideFromProviderlist
+}
+
+func State(provider string) *pb.ProviderStateResponse {
+	return &pb.ProviderStateResponse{}
+}
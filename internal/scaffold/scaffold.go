@@ -0,0 +1,119 @@
+// Package scaffold generates the skeleton of a new provider within the elephant repository.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+var validName = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+const module = "module github.com/abenz1267/elephant/v2"
+
+type data struct {
+	Name   string
+	Pretty string
+}
+
+// Provider writes the skeleton of the provider to internal/providers/<name> and adds it to the
+// dev and release builds and the list of providers. root is the root of the repository.
+func Provider(root, name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid name %q: only lowercase letters and digits, starting with a letter", name)
+	}
+
+	b, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil || !strings.HasPrefix(string(b), module) {
+		return errors.New("not in the root of the elephant repository")
+	}
+
+	dir := filepath.Join(root, "internal", "providers", name)
+
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("%s already exists", dir)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	d := data{Name: name, Pretty: strings.ToUpper(name[:1]) + name[1:]}
+
+	for _, file := range []string{"setup.go", "setup_test.go", "README.md", "makefile"} {
+		t, err := template.ParseFS(templates, "templates/"+file+".tmpl")
+		if err != nil {
+			return err
+		}
+
+		var out bytes.Buffer
+
+		if err := t.Execute(&out, d); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, file), out.Bytes(), 0o644); err != nil {
+			return err
+		}
+
+		fmt.Printf("wrote %s\n", filepath.Join(dir, file))
+	}
+
+	return register(root, d)
+}
+
+// insertBefore inserts s before the first occurrence of marker in the file.
+func insertBefore(file, marker, s string) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	content := string(b)
+
+	i := strings.Index(content, marker)
+	if i < 0 {
+		return fmt.Errorf("%s: %q not found", file, marker)
+	}
+
+	fmt.Printf("updated %s\n", file)
+
+	return os.WriteFile(file, []byte(content[:i]+s+content[i:]), 0o644)
+}
+
+// register adds the provider to the dev build, the release workflow and the README.
+func register(root string, d data) error {
+	if err := insertBefore(filepath.Join(root, ".air.toml"), "\n]\n# Binary",
+		fmt.Sprintf("\n  \"cd internal/providers/%[1]s && go build -buildmode=plugin && cp %[1]s.so /tmp/elephant/providers/\",", d.Name)); err != nil {
+		return err
+	}
+
+	workflow := filepath.Join(root, ".github", "workflows", "build.yml")
+
+	if err := insertBefore(workflow, "    - name: Upload build artifacts", fmt.Sprintf(`    - name: Build %[1]s plugin for linux/amd64
+      run: |
+        echo "Building %[1]s plugin for linux/amd64..."
+        GOOS=linux GOARCH=amd64 CGO_ENABLED=1 go build -buildmode=plugin -o build/%[1]s-linux-amd64.so ./internal/providers/%[1]s
+
+`, d.Name)); err != nil {
+		return err
+	}
+
+	if err := insertBefore(workflow, `        echo "Build completed successfully!"`, fmt.Sprintf(`        # Archive %[1]s plugin
+        tar -czf %[1]s-linux-amd64.tar.gz %[1]s-linux-amd64.so
+
+`, d.Name)); err != nil {
+		return err
+	}
+
+	return insertBefore(filepath.Join(root, "README.md"), "\n## Installation", fmt.Sprintf("\n- **%s**\n  - TODO\n", d.Pretty))
+}
@@ -0,0 +1,8 @@
+package util
+
+// types of action arguments, see pb.ActionArgument
+var (
+	ArgumentText   = "text"
+	ArgumentNumber = "number"
+	ArgumentPath   = "path"
+)
@@ -3,17 +3,21 @@ package util
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"reflect"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/abenz1267/elephant/v2/internal/providers"
 	"github.com/abenz1267/elephant/v2/pkg/common"
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
 )
 
 func GenerateDoc(provider string) {
 	provider = strings.ToLower(provider)
-	
+
 	if provider == "" || provider == "elephant" {
 		fmt.Println("# Elephant")
 
@@ -23,16 +27,16 @@ func GenerateDoc(provider string) {
 
 		fmt.Println("## Elephant Configuration")
 
-		PrintConfig(common.ElephantConfig{}, "elephant")
+		PrintConfig(os.Stdout, common.ElephantConfig{}, "elephant")
 	}
 
 	if provider == "" {
 		fmt.Println("## Provider Configuration")
 	}
-	
+
 	p := []providers.Provider{}
 
-	for _, v := range providers.Providers {
+	for _, v := range providers.All() {
 		p = append(p, v)
 	}
 
@@ -42,82 +46,120 @@ func GenerateDoc(provider string) {
 
 	for _, v := range p {
 		if provider == "" || provider == strings.ToLower(*v.Name) || provider == strings.ToLower(*v.NamePretty) {
-			v.PrintDoc()	
+			v.PrintDoc(os.Stdout)
 		}
 	}
 }
 
-func PrintConfig(c any, name string) {
-	fmt.Printf("`~/.config/elephant/%s.toml`\n", name)
-	printStructTable(c, getStructName(c))
-}
+var (
+	schemas   = make(map[string][]*pb.DocsResponse_Table)
+	schemasMu sync.Mutex
+)
 
-func getStructName(c any) string {
-	val := reflect.ValueOf(c)
-	if val.Kind() == reflect.Ptr {
-		val = val.Elem()
+// PrintConfig writes the tables of the config to w and keeps them for Schema.
+func PrintConfig(w io.Writer, c any, name string) {
+	fmt.Fprintf(w, "`~/.config/elephant/%s.toml`\n", name)
+
+	tables := ConfigTables(c)
+
+	schemasMu.Lock()
+	schemas[name] = tables
+	schemasMu.Unlock()
+
+	for _, t := range tables {
+		fmt.Fprintf(w, "#### %s\n", t.Name)
+		fmt.Fprintln(w, "| Field | Type | Default | Description |")
+		fmt.Fprintln(w, "| --- | ---- | ---- | --- |")
+
+		for _, f := range t.Fields {
+			fmt.Fprintf(w, "|%s|%s|%s|%s|\n", f.Key, f.Type, f.Default, f.Description)
+		}
+
+		fmt.Fprintln(w)
 	}
-	return val.Type().Name()
 }
 
-func printStructTable(c any, structName string) {
-	fmt.Printf("#### %s\n", structName)
-	fmt.Println("| Field | Type | Default | Description |")
-	fmt.Println("| --- | ---- | ---- | --- |")
+// Schema returns the config tables printed for the provider, nil if it didn't print any yet.
+func Schema(name string) []*pb.DocsResponse_Table {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
 
-	printStructDesc(c)
-	fmt.Println()
+	return schemas[name]
 }
 
-func printStructDesc(c any) {
+// ConfigTables describes the config struct and the structs nested within, based on the koanf, desc and
+// default tags of their fields.
+func ConfigTables(c any) []*pb.DocsResponse_Table {
 	val := reflect.ValueOf(c)
-
 	if val.Kind() == reflect.Ptr {
 		val = val.Elem()
 	}
 
 	if val.Kind() != reflect.Struct {
-		fmt.Println("Not a struct")
-		return
+		return nil
+	}
+
+	return configTables(val.Type())
+}
+
+func configTables(typ reflect.Type) []*pb.DocsResponse_Table {
+	table := &pb.DocsResponse_Table{Name: typ.Name()}
+	nested := configFields(typ, table)
+
+	res := []*pb.DocsResponse_Table{table}
+
+	for _, v := range nested {
+		res = append(res, configTables(v)...)
 	}
 
-	typ := val.Type()
+	return res
+}
+
+// configFields adds the fields of the struct to the table, embedded structs inline, and returns the
+// structs nested within.
+func configFields(typ reflect.Type, table *pb.DocsResponse_Table) []reflect.Type {
 	var nestedStructs []reflect.Type
 
-	for i := 0; i < val.NumField(); i++ {
+	for i := 0; i < typ.NumField(); i++ {
 		field := typ.Field(i)
-		fieldValue := val.Field(i)
 
-		if field.PkgPath == "" {
-			if field.Anonymous {
-				printStructDesc(fieldValue.Interface())
-				continue
-			}
+		if field.PkgPath != "" {
+			continue
+		}
 
-			name := field.Tag.Get("koanf")
+		if field.Anonymous {
+			nestedStructs = append(nestedStructs, configFields(field.Type, table)...)
+			continue
+		}
 
-			if name == "" {
-				name = field.Tag.Get("toml")
-			}
+		name := field.Tag.Get("koanf")
 
-			if name == "-" {
-				continue
-			}
+		if name == "" {
+			name = field.Tag.Get("toml")
+		}
 
-			fmt.Printf("|%s|%s|%s|%s|\n",
-				name, field.Type, field.Tag.Get("default"), field.Tag.Get("desc"))
+		if name == "-" {
+			continue
+		}
 
-			if field.Type.Kind() == reflect.Slice {
-				elemType := field.Type.Elem()
-				if elemType.Kind() == reflect.Struct {
-					nestedStructs = append(nestedStructs, elemType)
-				}
+		table.Fields = append(table.Fields, &pb.DocsResponse_Field{
+			Key:         name,
+			Type:        field.Type.String(),
+			Default:     field.Tag.Get("default"),
+			Description: field.Tag.Get("desc"),
+		})
+
+		if field.Type.Kind() == reflect.Slice {
+			elemType := field.Type.Elem()
+			if elemType.Kind() == reflect.Struct {
+				nestedStructs = append(nestedStructs, elemType)
 			}
 		}
-	}
 
-	for _, structType := range nestedStructs {
-		elemVal := reflect.New(structType).Elem()
-		printStructTable(elemVal.Interface(), structType.Name())
+		if field.Type.Kind() == reflect.Struct {
+			nestedStructs = append(nestedStructs, field.Type)
+		}
 	}
+
+	return nestedStructs
 }
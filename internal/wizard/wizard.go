@@ -0,0 +1,227 @@
+// Package wizard sets up a starter configuration on first run, based on the tools found.
+package wizard
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// tools lists providers depending on external tools. Any of the tools is enough. Providers without
+// entry don't need any and are left alone.
+var tools = []struct {
+	provider string
+	pretty   string
+	binaries []string
+}{
+	{"vscode", "VSCode", []string{"code", "codium", "code-insiders", "cursor"}},
+	{"bluetooth", "Bluetooth", []string{"bluetoothctl"}},
+	{"clipboard", "Clipboard", []string{"wl-copy", "xclip"}},
+	{"browsertabs", "Browser Tabs", []string{"firefox", "chromium", "google-chrome-stable", "brave", "vivaldi", "bt"}},
+	{"calc", "Calculator", []string{"qalc"}},
+	{"1password", "1Password", []string{"op"}},
+	{"archlinuxpkgs", "Archlinux Packages", []string{"pacman"}},
+	{"nirisessions", "Niri Sessions", []string{"niri"}},
+	{"notifications", "Notifications", []string{"swaync-client", "makoctl", "dunstctl"}},
+	{"wallpaper", "Wallpaper", []string{"swww", "hyprpaper", "swaybg", "feh"}},
+	{"ocr", "Screen OCR", []string{"tesseract"}},
+	{"transfer", "Send to Device", []string{"kdeconnect-cli", "localsend"}},
+	{"nix", "Nix Flakes", []string{"nix"}},
+	{"portableapps", "Flatpaks & AppImages", []string{"flatpak"}},
+	{"mail", "Mail", []string{"notmuch"}},
+	{"calendar", "Calendar", []string{"khal"}},
+}
+
+const exampleMenu = `name = "example"
+name_pretty = "Example"
+icon = "applications-other"
+
+[[entries]]
+text = "Disk"
+keywords = ["disk", "drive", "space"]
+async = """echo $(df -h / | tail -1 | awk '{print "Used: " $3 " - Available: " $4}')"""
+icon = "drive-harddisk"
+
+[[entries]]
+text = "Today"
+keywords = ["date", "today"]
+async = """echo $(date "+%H:%M - %d.%m. %A")"""
+icon = "clock"
+`
+
+type prompter struct {
+	r   *bufio.Reader
+	w   io.Writer
+	yes bool
+}
+
+// ask asks a yes/no question, an empty answer picks the default.
+func (p prompter) ask(question string, def bool) bool {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+
+	if p.yes {
+		answer := "n"
+		if def {
+			answer = "y"
+		}
+
+		fmt.Fprintf(p.w, "%s %s %s\n", question, hint, answer)
+
+		return def
+	}
+
+	for {
+		fmt.Fprintf(p.w, "%s %s ", question, hint)
+
+		line, err := p.r.ReadString('\n')
+		if err != nil {
+			return def
+		}
+
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "":
+			return def
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+	}
+}
+
+func found(names []string) string {
+	for _, v := range names {
+		if _, err := exec.LookPath(v); err == nil {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// configDir is the folder the starter config is written to, the one of the profile if selected.
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	if p := common.Profile(); p != "" {
+		return filepath.Join(dir, "elephant", common.ProfilesDir, p), nil
+	}
+
+	return filepath.Join(dir, "elephant"), nil
+}
+
+// Run detects the installed tools, proposes the providers using them and writes a starter config and
+// an example menu. With yes, all defaults are taken without asking. The service is enabled via the
+// given function.
+func Run(yes bool, enableService func() error) error {
+	p := prompter{r: bufio.NewReader(os.Stdin), w: os.Stdout, yes: yes}
+
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Setting up elephant in %s\n\n", dir)
+
+	ignored := []string{}
+
+	for _, v := range tools {
+		tool := found(v.binaries)
+
+		if tool == "" {
+			fmt.Printf("[missing] %s, needs one of: %s\n", v.pretty, strings.Join(v.binaries, ", "))
+			ignored = append(ignored, v.provider)
+
+			continue
+		}
+
+		if !p.ask(fmt.Sprintf("[found] %s via %s. Enable?", v.pretty, tool), true) {
+			ignored = append(ignored, v.provider)
+		}
+	}
+
+	fmt.Println()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	cfg := filepath.Join(dir, "elephant.toml")
+
+	if !common.FileExists(cfg) || p.ask(fmt.Sprintf("%s exists. Overwrite?", cfg), false) {
+		if err := os.WriteFile(cfg, []byte(starterConfig(ignored)), 0o644); err != nil {
+			return err
+		}
+
+		fmt.Printf("wrote %s\n", cfg)
+	}
+
+	menu := filepath.Join(dir, "menus", "example.toml")
+
+	if !common.FileExists(menu) && p.ask("Add an example menu?", true) {
+		if err := os.MkdirAll(filepath.Dir(menu), 0o755); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(menu, []byte(exampleMenu), 0o644); err != nil {
+			return err
+		}
+
+		fmt.Printf("wrote %s\n", menu)
+	}
+
+	if hasServiceManager() && p.ask("Enable the user service?", false) {
+		if err := enableService(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("\ndone. providers are installed separately, f.e. 'yay -S elephant-desktopapplications'")
+
+	return nil
+}
+
+// hasServiceManager reports whether the service can be enabled. macOS and Windows always have one, other
+// systems need systemd.
+func hasServiceManager() bool {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		return true
+	}
+
+	_, err := exec.LookPath("systemctl")
+
+	return err == nil
+}
+
+func starterConfig(ignored []string) string {
+	var b strings.Builder
+
+	b.WriteString("# written by 'elephant init'. see 'elephant generatedoc' for all options.\n\n")
+	b.WriteString("# providers whose tools weren't found or that weren't wanted.\n")
+	b.WriteString("ignored_providers = [")
+
+	for k, v := range ignored {
+		if k > 0 {
+			b.WriteString(", ")
+		}
+
+		fmt.Fprintf(&b, "%q", v)
+	}
+
+	b.WriteString("]\n")
+
+	return b.String()
+}
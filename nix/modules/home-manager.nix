@@ -76,7 +76,7 @@ in {
       default = {};
       example = literalExpression ''
         {
-          auto_detect_launch_prefix = false;
+          launch_backend = "none";
         }
       '';
       description = ''
@@ -88,7 +88,7 @@ in {
       default = {};
       example = literalExpression ''
         {
-          auto_detect_launch_prefix = false;
+          launch_backend = "none";
         }
       '';
       description = ''
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"net"
+	"slices"
+	"sync"
+)
+
+// ClientFeatures are the features a client declared in its handshake.
+type ClientFeatures struct {
+	Features     []string
+	PreviewTypes []string
+}
+
+var clientFeatures sync.Map
+
+// SetClientFeatures stores the handshake of the client connected via conn.
+func SetClientFeatures(conn net.Conn, f ClientFeatures) {
+	clientFeatures.Store(client(conn), f)
+}
+
+// Supports reports whether the client connected via conn can handle the feature. Clients that didn't
+// send a handshake support everything, so older clients keep working.
+func Supports(conn net.Conn, feature string) bool {
+	if conn == nil {
+		return true
+	}
+
+	f, ok := clientFeatures.Load(client(conn))
+	if !ok {
+		return true
+	}
+
+	return slices.Contains(f.(ClientFeatures).Features, feature)
+}
+
+// SupportsPreview reports whether the client can render previews of the type.
+func SupportsPreview(conn net.Conn, previewType string) bool {
+	if conn == nil {
+		return true
+	}
+
+	f, ok := clientFeatures.Load(client(conn))
+	if !ok {
+		return true
+	}
+
+	return slices.Contains(f.(ClientFeatures).PreviewTypes, previewType)
+}
+
+// HasHandshake reports whether the client connected via conn sent a handshake.
+func HasHandshake(conn net.Conn) bool {
+	if conn == nil {
+		return false
+	}
+
+	_, ok := clientFeatures.Load(client(conn))
+
+	return ok
+}
+
+// ForgetClient removes the capabilities of a closed connection.
+func ForgetClient(conn net.Conn) {
+	thumbnailClients.Delete(conn)
+	clientFeatures.Delete(conn)
+}
+
+// client returns the connection of the client. Handlers of a request may get a connection wrapping it,
+// f.e. to tag responses with the request id, which unwraps via NetConn.
+func client(conn net.Conn) net.Conn {
+	for {
+		c, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			return conn
+		}
+
+		conn = c.NetConn()
+	}
+}
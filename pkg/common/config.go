@@ -7,8 +7,6 @@ import (
 	"path/filepath"
 
 	"github.com/joho/godotenv"
-	"github.com/knadh/koanf/parsers/toml/v2"
-	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/providers/structs"
 	"github.com/knadh/koanf/v2"
 )
@@ -25,21 +23,176 @@ type Command struct {
 	Command     string `koanf:"command" desc:"command to execute" default:""`
 }
 
+type Registry struct {
+	Name string `koanf:"name" desc:"name of the registry. install packages from it with 'name/package'" default:""`
+	URL  string `koanf:"url" desc:"git url or https url of an index json. use ssh urls for private repositories" default:""`
+}
+
 type ElephantConfig struct {
-	AutoDetectLaunchPrefix bool      `koanf:"auto_detect_launch_prefix" desc:"automatically detects uwsm, app2unit or systemd-run" default:"true"`
-	OverloadLocalEnv       bool      `koanf:"overload_local_env" desc:"overloads the local env" default:"false"`
-	IgnoredProviders       []string  `koanf:"ignored_providers" desc:"providers to ignore" default:"<empty>"`
-	GitOnDemand            bool      `koanf:"git_on_demand" desc:"sets up git repositories on first query instead of on start" default:"true"`
-	BeforeLoad             []Command `koanf:"before_load" desc:"commands to run before starting to load the providers" default:""`
+	LaunchBackend        string            `koanf:"launch_backend" desc:"how applications are launched: auto, app2unit, uwsm, niri, systemd, systemd-service or none. inside Flatpak, flatpak-spawn --host is always used" default:"auto"`
+	Scopes               Scopes            `koanf:"scopes" desc:"launch every activated application in its own systemd scope, named after the provider and item" default:""`
+	OverloadLocalEnv     bool              `koanf:"overload_local_env" desc:"overloads the local env" default:"false"`
+	IgnoredProviders     []string          `koanf:"ignored_providers" desc:"providers to ignore" default:"<empty>"`
+	GitOnDemand          bool              `koanf:"git_on_demand" desc:"sets up git repositories on first query instead of on start" default:"true"`
+	BeforeLoad           []Command         `koanf:"before_load" desc:"commands to run before starting to load the providers" default:""`
+	Registries           []Registry        `koanf:"registries" desc:"additional registries for community menus and providers" default:""`
+	GitEncryption        GitEncryption     `koanf:"git_encryption" desc:"encrypt files synced via git" default:""`
+	Hooks                []Hook            `koanf:"hooks" desc:"commands to run before or after activations, f.e. to play a sound" default:""`
+	Pipelines            []Pipeline        `koanf:"pipelines" desc:"actions of providers running commands, copying and notifying one after another" default:""`
+	Wasm                 []Wasm            `koanf:"wasm" desc:"capabilities granted to wasm providers. without an entry they can't access files or the network" default:""`
+	Groups               Groups            `koanf:"groups" desc:"grouping of results, for clients asking for grouped results" default:""`
+	Rewrite              Rewrite           `koanf:"rewrite" desc:"rewriting of queries before they're passed to providers" default:""`
+	Macros               []Macro           `koanf:"macros" desc:"prefixes searching a set of providers at once, f.e. 'proj ' for repositories, editors and sessions" default:""`
+	Annotators           []Annotator       `koanf:"annotators" desc:"annotators enriching items of all providers before they're sent, in order, f.e. with git status badges or file sizes" default:""`
+	Dashboard            []Section         `koanf:"dashboard" desc:"sections of the start page, for clients asking for it with an empty query" default:"pinned apps, recent files, running jobs, active todos, unread mail and chats"`
+	Speech               Speech            `koanf:"speech" desc:"speech-to-text for clients sending speech requests, f.e. for push-to-talk" default:""`
+	Watchdog             Watchdog          `koanf:"watchdog" desc:"periodic probing of providers, restarting the ones that hang or fail" default:""`
+	CompressionThreshold int               `koanf:"compression_threshold" desc:"payloads of at least this many bytes are compressed for clients asking for compression" default:"1024"`
+	Executor             Executor          `koanf:"executor" desc:"limits of commands providers run while querying" default:""`
+	Memory               Memory            `koanf:"memory" desc:"budget of resident memory, evicting in-memory indexes of providers like unicode or archlinuxpkgs" default:""`
+	Snapshots            bool              `koanf:"snapshots" desc:"load caches of providers like desktopapplications, menus and unicode from a snapshot on start, refreshing them in the background" default:"true"`
+	Metrics              Metrics           `koanf:"metrics" desc:"prometheus metrics of queries, activations, socket connections and lua menus" default:""`
+	Keybindings          map[string]string `koanf:"keybindings" desc:"preferred keybindings of actions for clients, f.e. 'files:copy_path' = 'ctrl+c'. keys without provider apply to the action of all providers" default:""`
+	Limits               Limits            `koanf:"limits" desc:"debouncing of queries and rate limits of clients, so keystroke storms can't keep the cpu busy" default:""`
+}
+
+type Limits struct {
+	Debounce int `koanf:"debounce" desc:"milliseconds a query waits before running. a following query of the same connection within this time supersedes it. 0 disables debouncing" default:"0"`
+	Requests int `koanf:"requests" desc:"max requests per second of a connection, further ones are delayed. 0 disables the limit" default:"100"`
+	Burst    int `koanf:"burst" desc:"requests a connection can send at once before the limit applies" default:"50"`
+}
+
+type Metrics struct {
+	Enabled bool `koanf:"enabled" desc:"serve metrics at http://127.0.0.1:<port>/metrics" default:"false"`
+	Port    int  `koanf:"port" desc:"localhost port of the metrics endpoint" default:"9797"`
+}
+
+type Executor struct {
+	Concurrency  int            `koanf:"concurrency" desc:"max concurrent commands per provider" default:"4"`
+	Providers    map[string]int `koanf:"providers" desc:"max concurrent commands of single providers, f.e. transfer = 1" default:""`
+	MaxProcesses int            `koanf:"max_processes" desc:"max concurrent commands of all providers" default:"16"`
+	Timeout      int            `koanf:"timeout" desc:"seconds after which commands are killed" default:"10"`
+}
+
+type Memory struct {
+	Budget   int `koanf:"budget" desc:"max resident memory in MB. 0 disables evicting" default:"0"`
+	Interval int `koanf:"interval" desc:"seconds between checks" default:"30"`
+}
+
+type Watchdog struct {
+	Enabled  bool `koanf:"enabled" desc:"probe providers periodically" default:"false"`
+	Interval int  `koanf:"interval" desc:"seconds between probes" default:"300"`
+	Timeout  int  `koanf:"timeout" desc:"seconds a probe may take before it counts as failed" default:"5"`
+	Failures int  `koanf:"failures" desc:"failed probes in a row after which a provider is restarted" default:"3"`
+}
+
+type Speech struct {
+	Record      string `koanf:"record" desc:"command recording until it's interrupted. %FILE% is the wav file to write" default:"pw-record --rate 16000 --channels 1 %FILE%"`
+	Transcribe  string `koanf:"transcribe" desc:"command printing the text of the recording, f.e. a whisper.cpp wrapper. supports %FILE% and %LANGUAGE%" default:"whisper-cli -m ~/.local/share/whisper/ggml-base.bin -nt -np -l %LANGUAGE% -f %FILE%"`
+	Language    string `koanf:"language" desc:"language passed to the transcribe command, if the request doesn't set one" default:"auto"`
+	MaxDuration int    `koanf:"max_duration" desc:"seconds after which recording stops, in case no stop request is sent" default:"30"`
+}
+
+type Section struct {
+	Title    string   `koanf:"title" desc:"group of the items. defaults to the group of the provider" default:""`
+	Provider string   `koanf:"provider" desc:"provider to take items from, f.e. 'files' or 'menus:bookmarks'" default:""`
+	Query    string   `koanf:"query" desc:"query passed to the provider" default:""`
+	State    []string `koanf:"state" desc:"only take items with one of these states, f.e. ['pinned']" default:""`
+	Max      int      `koanf:"max" desc:"max items of the section. 0 for no limit" default:"5"`
+}
+
+type Rewrite struct {
+	Trim          bool              `koanf:"trim" desc:"remove leading and trailing whitespace" default:"false"`
+	Abbreviations map[string]string `koanf:"abbreviations" desc:"words to expand, f.e. ff = 'firefox'" default:""`
+	Spelling      bool              `koanf:"spelling" desc:"correct typos with words of queries in the history" default:"false"`
+	Home          bool              `koanf:"home" desc:"expand '~' to the home directory in file queries" default:"true"`
+}
+
+type Macro struct {
+	Name      string            `koanf:"name" desc:"name of the entry starting the macro" default:""`
+	Prefix    string            `koanf:"prefix" desc:"prefix of queries running the macro, f.e. 'proj '" default:""`
+	Providers []string          `koanf:"providers" desc:"providers to search, f.e. ['files', 'vscode', 'menus:tmux']" default:""`
+	Query     string            `koanf:"query" desc:"query passed to the providers. supports %QUERY%, the query without the prefix" default:"%QUERY%"`
+	Queries   map[string]string `koanf:"queries" desc:"queries of single providers, overriding query. supports %QUERY%" default:""`
+	Icon      string            `koanf:"icon" desc:"icon of the entry" default:"system-search"`
+}
+
+type Annotator struct {
+	Type      string   `koanf:"type" desc:"git_status, bookmarked, file_size or command" default:""`
+	Providers []string `koanf:"providers" desc:"providers whose items are annotated, f.e. ['files', 'menus:projects']. all if empty" default:""`
+	Command   string   `koanf:"command" desc:"command of the command annotator. gets the items as json, one per line, on stdin and prints the badge of every item on its own line" default:""`
+}
+
+type Scopes struct {
+	Enabled    bool     `koanf:"enabled" desc:"use scopes instead of the launch backend. a launch_prefix of a provider still takes precedence" default:"false"`
+	Slice      string   `koanf:"slice" desc:"slice the scopes are put into" default:"app-graphical.slice"`
+	Properties []string `koanf:"properties" desc:"properties of the scopes, f.e. resource limits like ['MemoryMax=4G', 'CPUWeight=50']" default:""`
+}
+
+type Groups struct {
+	Order       []string          `koanf:"order" desc:"order of groups, f.e. ['Applications', 'Files', 'Web']. other groups follow, ordered by their best result" default:""`
+	Names       map[string]string `koanf:"names" desc:"group names of providers, f.e. websearch = 'Web'. providers can set groups themselves, otherwise the providers pretty name is used" default:""`
+	MaxPerGroup int               `koanf:"max_per_group" desc:"max results per group. 0 for no limit" default:"0"`
+}
+
+type Wasm struct {
+	Provider  string   `koanf:"provider" desc:"file name of the provider without .wasm" default:""`
+	FS        []string `koanf:"fs" desc:"directories the provider can read, mounted at the same path" default:""`
+	HTTP      []string `koanf:"http" desc:"hosts the provider can send GET requests to, f.e. 'api.github.com'" default:""`
+	Open      bool     `koanf:"open" desc:"allow opening urls and files with xdg-open" default:"false"`
+	Clipboard bool     `koanf:"clipboard" desc:"allow copying to the clipboard" default:"false"`
 }
 
 var elephantConfig *ElephantConfig
 
 func LoadGlobalConfig() {
 	elephantConfig = &ElephantConfig{
-		AutoDetectLaunchPrefix: true,
-		OverloadLocalEnv:       false,
-		GitOnDemand:            true,
+		LaunchBackend:    "auto",
+		OverloadLocalEnv: false,
+		GitOnDemand:      true,
+		Scopes: Scopes{
+			Slice: "app-graphical.slice",
+		},
+		Rewrite: Rewrite{
+			Home: true,
+		},
+		CompressionThreshold: 1024,
+		Snapshots:            true,
+		Executor: Executor{
+			Concurrency:  4,
+			MaxProcesses: 16,
+			Timeout:      10,
+		},
+		Memory: Memory{
+			Interval: 30,
+		},
+		Metrics: Metrics{
+			Port: 9797,
+		},
+		Limits: Limits{
+			Requests: 100,
+			Burst:    50,
+		},
+		Watchdog: Watchdog{
+			Enabled:  false,
+			Interval: 300,
+			Timeout:  5,
+			Failures: 3,
+		},
+		Speech: Speech{
+			Record:      "pw-record --rate 16000 --channels 1 %FILE%",
+			Transcribe:  "whisper-cli -m ~/.local/share/whisper/ggml-base.bin -nt -np -l %LANGUAGE% -f %FILE%",
+			Language:    "auto",
+			MaxDuration: 30,
+		},
+		Dashboard: []Section{
+			{Title: "Pinned", Provider: "desktopapplications", State: []string{"pinned"}, Max: 8},
+			{Title: "Recent Files", Provider: "files", Max: 5},
+			{Title: "Running", Provider: "jobs", State: []string{"running"}, Max: 5},
+			{Title: "Todo", Provider: "todo", State: []string{"active", "urgent"}, Max: 5},
+			{Title: "Unread Mail", Provider: "mail", State: []string{"unread"}, Max: 5},
+			{Title: "Unread Chats", Provider: "chat", State: []string{"unread"}, Max: 5},
+		},
 	}
 
 	LoadConfig("elephant", elephantConfig)
@@ -79,24 +232,26 @@ func LoadConfig(provider string, config any) {
 		os.Exit(1)
 	}
 
-	userConfig, err := ProviderConfig(provider)
-	if err != nil {
+	files := configFiles(provider)
+	if len(files) == 0 {
 		slog.Info(provider, "config", "using default config")
 		return
 	}
 
-	user := koanf.New("")
+	for _, v := range files {
+		user := koanf.New("")
 
-	err = user.Load(file.Provider(userConfig), toml.Parser())
-	if err != nil {
-		slog.Error(provider, "config", err)
-		os.Exit(1)
-	}
+		err = user.Load(configFile{config: provider, path: v}, nil)
+		if err != nil {
+			slog.Error(provider, "config", err, "file", v)
+			os.Exit(1)
+		}
 
-	err = defaults.Merge(user)
-	if err != nil {
-		slog.Error(provider, "config", err)
-		os.Exit(1)
+		err = defaults.Merge(user)
+		if err != nil {
+			slog.Error(provider, "config", err, "file", v)
+			os.Exit(1)
+		}
 	}
 
 	err = defaults.Unmarshal("", &config)
@@ -0,0 +1,109 @@
+package common
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/knadh/koanf/parsers/toml/v2"
+)
+
+// envReference matches ${NAME} and the escaped $${NAME}. Plain $NAME isn't expanded, as commands in
+// configs commonly use it for the shell.
+var envReference = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces references to set environment variables. Unset ones are kept, so commands can
+// still reference variables only the shell knows.
+func expandEnv(s string) string {
+	return envReference.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref[1] == '$' {
+			return ref[1:]
+		}
+
+		if v, ok := os.LookupEnv(ref[2 : len(ref)-1]); ok {
+			return v
+		}
+
+		return ref
+	})
+}
+
+func expandEnvValues(v any) any {
+	switch val := v.(type) {
+	case string:
+		return expandEnv(val)
+	case map[string]any:
+		for k, e := range val {
+			val[k] = expandEnvValues(e)
+		}
+	case []any:
+		for k, e := range val {
+			val[k] = expandEnvValues(e)
+		}
+	case []map[string]any:
+		for _, e := range val {
+			expandEnvValues(e)
+		}
+	}
+
+	return v
+}
+
+// configFile is a koanf provider for a TOML config, with outdated options migrated and environment
+// variables expanded in its values.
+type configFile struct {
+	config string
+	path   string
+}
+
+func (c configFile) ReadBytes() ([]byte, error) {
+	return nil, errors.New("configFile provider does not support this method")
+}
+
+func (c configFile) Read() (map[string]any, error) {
+	b, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := toml.Parser().Unmarshal(b)
+	if err != nil {
+		return nil, err
+	}
+
+	logMigrations(c.config, c.path, migrate(c.config, m))
+
+	expandEnvValues(m)
+
+	return m, nil
+}
+
+// configFiles returns the config of the provider followed by the drop-ins of its "<provider>.d"
+// directory, in the order they're merged. Drop-ins are read from the directory next to the config or,
+// without config, from the first config dir having them.
+func configFiles(provider string) []string {
+	res := []string{}
+	dropIns := ""
+
+	if main, err := ProviderConfig(provider); err == nil {
+		res = append(res, main)
+		dropIns = filepath.Join(filepath.Dir(main), provider+".d")
+	} else {
+		for _, v := range ConfigDirs() {
+			if dir := filepath.Join(v, provider+".d"); FileExists(dir) {
+				dropIns = dir
+				break
+			}
+		}
+	}
+
+	if dropIns == "" {
+		return res
+	}
+
+	// sorted by name, so "10-work.toml" is merged before "20-laptop.toml"
+	matches, _ := filepath.Glob(filepath.Join(dropIns, "*.toml"))
+
+	return append(res, matches...)
+}
@@ -0,0 +1,26 @@
+package common
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Debouncer drops queries that were superseded while waiting. Typing sends a query per key, only the last
+// one within the debounce time should hit remote services.
+type Debouncer struct {
+	queries atomic.Uint64
+}
+
+// Wait waits for the milliseconds and reports whether no other query came in meanwhile. Cached queries are
+// answered right away, they still supersede waiting ones.
+func (d *Debouncer) Wait(ms int, cached bool) bool {
+	n := d.queries.Add(1)
+
+	if cached || ms <= 0 {
+		return true
+	}
+
+	time.Sleep(time.Duration(ms) * time.Millisecond)
+
+	return d.queries.Load() == n
+}
@@ -0,0 +1,40 @@
+package common
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestDebouncer(t *testing.T) {
+	var d Debouncer
+	var wg sync.WaitGroup
+
+	first := make(chan bool, 1)
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		first <- d.Wait(200, false)
+	}()
+
+	// let the first query start waiting
+	for d.queries.Load() == 0 {
+		runtime.Gosched()
+	}
+
+	if !d.Wait(0, true) {
+		t.Error("cached query waited")
+	}
+
+	wg.Wait()
+
+	if <-first {
+		t.Error("superseded query wasn't dropped")
+	}
+
+	if !d.Wait(10, false) {
+		t.Error("last query was dropped")
+	}
+}
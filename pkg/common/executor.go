@@ -0,0 +1,178 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecOptions configures a single command run via Exec.
+type ExecOptions struct {
+	// Timeout kills the command after it. Defaults to the configured timeout.
+	Timeout time.Duration
+	// CacheFor reuses the output of the same command of the provider for the duration. Concurrent runs
+	// of the same command always share one process.
+	CacheFor time.Duration
+}
+
+type execResult struct {
+	out []byte
+	err error
+}
+
+// execCall is a running or cached command, shared by callers running the same command.
+type execCall struct {
+	wg       sync.WaitGroup
+	res      execResult
+	finished bool
+	expires  time.Time
+}
+
+var (
+	execMu    sync.Mutex
+	execCalls = make(map[string]*execCall)
+	// execSlots limits the concurrent commands per provider
+	execSlots = make(map[string]chan struct{})
+	// execGlobal limits the concurrent commands of all providers
+	execGlobal chan struct{}
+)
+
+func executorConfig() Executor {
+	if c := GetElephantConfig(); c != nil {
+		return c.Executor
+	}
+
+	return Executor{Concurrency: 4, MaxProcesses: 16, Timeout: 10}
+}
+
+func slots(provider string, cfg Executor) (chan struct{}, chan struct{}) {
+	execMu.Lock()
+	defer execMu.Unlock()
+
+	if execGlobal == nil {
+		execGlobal = make(chan struct{}, max(cfg.MaxProcesses, 1))
+	}
+
+	s, ok := execSlots[provider]
+	if !ok {
+		n := cfg.Concurrency
+		if v, ok := cfg.Providers[provider]; ok {
+			n = v
+		}
+
+		s = make(chan struct{}, max(n, 1))
+		execSlots[provider] = s
+	}
+
+	return s, execGlobal
+}
+
+// ExecShell runs the command with sh on the host via Exec and returns its stdout.
+func ExecShell(provider, command string, opts ExecOptions) ([]byte, error) {
+	return Exec(provider, HostShell(command), opts)
+}
+
+// Exec runs the command with bounded concurrency per provider and in total, so bursts of queries don't
+// spawn unbounded processes. It returns stdout, the error includes stderr. Commands reading stdin are
+// neither shared nor cached, their input may differ.
+func Exec(provider string, cmd *exec.Cmd, opts ExecOptions) ([]byte, error) {
+	if cmd.Stdin != nil {
+		res := runBounded(provider, cmd, opts)
+		return res.out, res.err
+	}
+
+	key := fmt.Sprintf("%s\x00%s\x00%s", provider, cmd.Dir, strings.Join(cmd.Args, "\x00"))
+
+	execMu.Lock()
+	now := time.Now()
+
+	for k, v := range execCalls {
+		if v.finished && now.After(v.expires) {
+			delete(execCalls, k)
+		}
+	}
+
+	if c, ok := execCalls[key]; ok {
+		execMu.Unlock()
+
+		c.wg.Wait()
+
+		return c.res.out, c.res.err
+	}
+
+	c := &execCall{}
+	c.wg.Add(1)
+	execCalls[key] = c
+	execMu.Unlock()
+
+	c.res = runBounded(provider, cmd, opts)
+
+	execMu.Lock()
+	c.finished = true
+	c.expires = time.Now().Add(opts.CacheFor)
+
+	if opts.CacheFor <= 0 {
+		delete(execCalls, key)
+	}
+	execMu.Unlock()
+
+	c.wg.Done()
+
+	return c.res.out, c.res.err
+}
+
+func runBounded(provider string, cmd *exec.Cmd, opts ExecOptions) execResult {
+	cfg := executorConfig()
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	slot, global := slots(provider, cfg)
+
+	slot <- struct{}{}
+	defer func() { <-slot }()
+
+	global <- struct{}{}
+	defer func() { <-global }()
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// killing the process group also reaches children of shells
+	NewProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return execResult{err: err}
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var err error
+
+	select {
+	case err = <-done:
+	case <-time.After(timeout):
+		killProcessGroup(cmd)
+		<-done
+
+		err = fmt.Errorf("%s: timed out after %s", cmd.Args[0], timeout)
+	}
+
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+	}
+
+	return execResult{out: stdout.Bytes(), err: err}
+}
@@ -43,12 +43,21 @@ func ConfigDirs() []string {
 		}
 	}
 
+	// configs of the profile take precedence, everything else is shared with the default profile
+	if profile != "" {
+		res = append(profileConfigDirs(res), res...)
+	}
+
 	return res
 }
 
 func CacheFile(file string) string {
 	d, _ := os.UserCacheDir()
 
+	if profile != "" {
+		return filepath.Join(d, "elephant", ProfilesDir, profile, file)
+	}
+
 	return filepath.Join(d, "elephant", file)
 }
 
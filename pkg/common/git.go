@@ -1,14 +1,16 @@
 package common
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/abenz1267/elephant/pkg/common"
 	"github.com/go-git/go-git/v6"
 )
 
@@ -33,9 +35,13 @@ func SetupGit(provider string, cfg Gittable) {
 	gitMu.Lock()
 	defer gitMu.Unlock()
 
+	gitStateMu.Lock()
+	gitCfgs[provider] = cfg
+	gitStateMu.Unlock()
+
 	x := 0
 	base := filepath.Base(cfg.URL())
-	folder := common.CacheFile(base)
+	folder := CacheFile(base)
 	var w *git.Worktree
 	var r *git.Repository
 	var pull bool
@@ -49,7 +55,7 @@ func SetupGit(provider string, cfg Gittable) {
 			slog.Info(provider, "gitsetup", "trying to setup git...")
 
 			// clone
-			if !common.FileExists(folder) {
+			if !FileExists(folder) {
 				var err error
 
 				url := cfg.URL()
@@ -94,11 +100,17 @@ func SetupGit(provider string, cfg Gittable) {
 				}
 			}
 
+			decryptFromGit(provider, folder)
+
 			setupRepos[cfg.URL()] = Repo{
 				w: w,
 				r: r,
 			}
 
+			cfg.SetLocation(folder)
+			cfg.SetRepository(r)
+			cfg.SetWorktree(w)
+
 			break
 		}
 	} else {
@@ -115,6 +127,25 @@ type PushData struct {
 	r        *git.Repository
 }
 
+const (
+	ActionGitKeepLocal  = "git_keep_local"
+	ActionGitKeepRemote = "git_keep_remote"
+
+	pushAttempts = 3
+)
+
+// GitReloader can be implemented by a Gittable to reload its data after remote changes got pulled.
+type GitReloader interface {
+	GitReload()
+}
+
+var (
+	gitStateMu sync.Mutex
+	gitStates  = make(map[string]string)
+	gitRepos   = make(map[string]PushData)
+	gitCfgs    = make(map[string]Gittable)
+)
+
 var pushChan chan PushData
 
 func init() {
@@ -139,26 +170,34 @@ func init() {
 				if do {
 					mu.Lock()
 					for k, v := range work {
-						_, err := v.w.Add(v.file)
+						file, err := encryptForGit(v.w.Filesystem.Root(), v.file)
+						if err != nil {
+							slog.Error(v.provider, "gitencrypt", err)
+							continue
+						}
+
+						v.file = file
+						msg := commitMessage(v)
+
+						_, err = v.w.Add(v.file)
 						if err != nil {
 							slog.Error(v.provider, "gitadd", err)
 							continue
 						}
 
-						_, err = v.w.Commit("elephant", &git.CommitOptions{})
+						_, err = v.w.Commit(msg, &git.CommitOptions{})
 						if err != nil {
 							slog.Error(v.provider, "commit", err)
 							continue
 						}
 
-						err = v.r.Push(&git.PushOptions{})
-						if err != nil {
+						if err := push(v); err != nil {
 							slog.Error(v.provider, "push", err)
 							continue
 						}
 
 						delete(work, k)
-						slog.Info(v.provider, "git", "pushed to repository")
+						slog.Info(v.provider, "git", "pushed to repository", "message", msg)
 					}
 					mu.Unlock()
 
@@ -169,7 +208,153 @@ func init() {
 	}()
 }
 
-// TODO: this needs better commit messages somehow...
+// commitMessage describes the change, f.e. "todo: update todo.csv on laptop".
+func commitMessage(v PushData) string {
+	change := "update"
+
+	status, err := v.w.Status()
+	if err == nil {
+		switch status.File(v.file).Worktree {
+		case git.Untracked, git.Added:
+			change = "add"
+		case git.Deleted:
+			change = "remove"
+		}
+	}
+
+	msg := fmt.Sprintf("%s: %s %s", v.provider, change, v.file)
+
+	if host, err := os.Hostname(); err == nil {
+		msg = fmt.Sprintf("%s on %s", msg, host)
+	}
+
+	return msg
+}
+
+// push retries rejected pushes after rebasing onto the remote. Conflicts are kept in the providers git state.
+func push(v PushData) error {
+	var err error
+
+	for range pushAttempts {
+		err = v.r.Push(&git.PushOptions{})
+		if err == nil || errors.Is(err, git.NoErrAlreadyUpToDate) {
+			setGitState(v, "")
+			return nil
+		}
+
+		slog.Info(v.provider, "push", err, "retry", "pulling with rebase")
+
+		if out, rerr := gitCmd(v.w, "pull", "--rebase", "--autostash"); rerr != nil {
+			if _, conflict := gitCmd(v.w, "rebase", "--abort"); conflict == nil {
+				setGitState(v, "git_conflict")
+				return fmt.Errorf("conflict while rebasing onto remote: %s", out)
+			}
+
+			setGitState(v, "git_error")
+
+			return fmt.Errorf("pull: %s", out)
+		}
+
+		reload(v)
+	}
+
+	setGitState(v, "git_error")
+
+	return err
+}
+
+func gitCmd(w *git.Worktree, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = w.Filesystem.Root()
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_EDITOR=true")
+
+	out, err := cmd.CombinedOutput()
+
+	return strings.TrimSpace(string(out)), err
+}
+
+func setGitState(v PushData, state string) {
+	gitStateMu.Lock()
+	defer gitStateMu.Unlock()
+
+	gitRepos[v.provider] = v
+
+	if state == "" {
+		delete(gitStates, v.provider)
+		return
+	}
+
+	gitStates[v.provider] = state
+}
+
+func reload(v PushData) {
+	decryptFromGit(v.provider, v.w.Filesystem.Root())
+
+	gitStateMu.Lock()
+	cfg := gitCfgs[v.provider]
+	gitStateMu.Unlock()
+
+	if r, ok := cfg.(GitReloader); ok {
+		r.GitReload()
+	}
+}
+
+// GitState returns states and actions to add to the providers state, if syncing failed.
+func GitState(provider string) ([]string, []string) {
+	gitStateMu.Lock()
+	defer gitStateMu.Unlock()
+
+	switch gitStates[provider] {
+	case "git_conflict":
+		return []string{"git_conflict"}, []string{ActionGitKeepLocal, ActionGitKeepRemote}
+	case "git_error":
+		return []string{"git_error"}, []string{}
+	}
+
+	return []string{}, []string{}
+}
+
+// GitResolve resolves a conflict by force pushing the local state or by resetting to the remote.
+// Returns false if the action isn't a git action.
+func GitResolve(provider, action string) bool {
+	if action != ActionGitKeepLocal && action != ActionGitKeepRemote {
+		return false
+	}
+
+	gitStateMu.Lock()
+	v, ok := gitRepos[provider]
+	gitStateMu.Unlock()
+
+	if !ok {
+		return true
+	}
+
+	var out string
+	var err error
+
+	switch action {
+	case ActionGitKeepLocal:
+		out, err = gitCmd(v.w, "push", "--force-with-lease")
+	case ActionGitKeepRemote:
+		if out, err = gitCmd(v.w, "fetch"); err == nil {
+			out, err = gitCmd(v.w, "reset", "--hard", "@{u}")
+		}
+	}
+
+	if err != nil {
+		slog.Error(provider, "gitresolve", err, "out", out)
+		return true
+	}
+
+	setGitState(v, "")
+
+	if action == ActionGitKeepRemote {
+		reload(v)
+	}
+
+	return true
+}
+
 func GitPush(provider, file string, w *git.Worktree, r *git.Repository) {
 	gitMu.Lock()
 	defer gitMu.Unlock()
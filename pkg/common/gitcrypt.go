@@ -0,0 +1,256 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"filippo.io/age"
+)
+
+type GitEncryption struct {
+	Enabled      bool     `koanf:"enabled" desc:"encrypt files synced via git with age" default:"false"`
+	Recipients   []string `koanf:"recipients" desc:"age public keys to encrypt to" default:""`
+	IdentityFile string   `koanf:"identity_file" desc:"age identity file used to decrypt" default:""`
+	KeyCommand   string   `koanf:"key_command" desc:"command printing an age identity (AGE-SECRET-KEY-...) or a passphrase" default:""`
+}
+
+const encryptedExt = ".age"
+
+var errNoKeys = errors.New("git encryption enabled, but no recipients or identities configured")
+
+func gitEncryption() *GitEncryption {
+	cfg := GetElephantConfig()
+	if cfg == nil || !cfg.GitEncryption.Enabled {
+		return nil
+	}
+
+	return &cfg.GitEncryption
+}
+
+// ageKeys collects recipients and identities. A passphrase can't be combined with other recipients.
+func ageKeys(enc *GitEncryption) ([]age.Recipient, []age.Identity, error) {
+	recipients := []age.Recipient{}
+	identities := []age.Identity{}
+
+	for _, v := range enc.Recipients {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(v))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		recipients = append(recipients, r)
+	}
+
+	if enc.IdentityFile != "" {
+		file := enc.IdentityFile
+
+		if after, ok := strings.CutPrefix(file, "~/"); ok {
+			home, _ := os.UserHomeDir()
+			file = filepath.Join(home, after)
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer f.Close()
+
+		ids, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		identities = append(identities, ids...)
+	}
+
+	if enc.KeyCommand != "" {
+		out, err := HostShell(enc.KeyCommand).Output()
+		if err != nil {
+			return nil, nil, fmt.Errorf("key_command: %w", err)
+		}
+
+		key := strings.TrimSpace(string(out))
+
+		if strings.HasPrefix(key, "AGE-SECRET-KEY-") {
+			ids, err := age.ParseIdentities(strings.NewReader(key))
+			if err != nil {
+				return nil, nil, err
+			}
+
+			identities = append(identities, ids...)
+		} else {
+			r, err := age.NewScryptRecipient(key)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			i, err := age.NewScryptIdentity(key)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return []age.Recipient{r}, []age.Identity{i}, nil
+		}
+	}
+
+	for _, v := range identities {
+		if x, ok := v.(*age.X25519Identity); ok {
+			recipients = append(recipients, x.Recipient())
+		}
+	}
+
+	if len(recipients) == 0 {
+		return nil, nil, errNoKeys
+	}
+
+	return recipients, identities, nil
+}
+
+// encryptForGit writes the encrypted copy of file next to it and keeps the plain file out of the repository.
+// Returns the file to commit.
+func encryptForGit(root, file string) (string, error) {
+	enc := gitEncryption()
+	if enc == nil {
+		return file, nil
+	}
+
+	recipients, _, err := ageKeys(enc)
+	if err != nil {
+		return "", err
+	}
+
+	plain, err := os.ReadFile(filepath.Join(root, file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			os.Remove(filepath.Join(root, file+encryptedExt))
+			return file + encryptedExt, nil
+		}
+
+		return "", err
+	}
+
+	var b bytes.Buffer
+
+	w, err := age.Encrypt(&b, recipients...)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := w.Write(plain); err != nil {
+		return "", err
+	}
+
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(filepath.Join(root, file+encryptedExt), b.Bytes(), 0o600); err != nil {
+		return "", err
+	}
+
+	if err := excludeFromGit(root, file); err != nil {
+		return "", err
+	}
+
+	return file + encryptedExt, nil
+}
+
+// decryptFromGit restores the plain files of all encrypted files in the repository.
+func decryptFromGit(provider, root string) {
+	enc := gitEncryption()
+	if enc == nil {
+		return
+	}
+
+	files, err := filepath.Glob(filepath.Join(root, "*"+encryptedExt))
+	if err != nil || len(files) == 0 {
+		return
+	}
+
+	_, identities, err := ageKeys(enc)
+	if err != nil {
+		slog.Error(provider, "gitdecrypt", err)
+		return
+	}
+
+	for _, v := range files {
+		f, err := os.Open(v)
+		if err != nil {
+			slog.Error(provider, "gitdecrypt", err)
+			continue
+		}
+
+		r, err := age.Decrypt(f, identities...)
+		if err != nil {
+			f.Close()
+			slog.Error(provider, "gitdecrypt", err, "file", v)
+			continue
+		}
+
+		plain, err := io.ReadAll(r)
+		f.Close()
+
+		if err != nil {
+			slog.Error(provider, "gitdecrypt", err, "file", v)
+			continue
+		}
+
+		file := strings.TrimSuffix(v, encryptedExt)
+
+		if err := os.WriteFile(file, plain, 0o600); err != nil {
+			slog.Error(provider, "gitdecrypt", err)
+			continue
+		}
+
+		if err := excludeFromGit(root, filepath.Base(file)); err != nil {
+			slog.Error(provider, "gitdecrypt", err)
+		}
+	}
+}
+
+// excludeFromGit makes sure the plain file is never committed. Files that were committed before are untracked.
+func excludeFromGit(root, file string) error {
+	exclude := filepath.Join(root, ".git", "info", "exclude")
+
+	b, err := os.ReadFile(exclude)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	pattern := "/" + file
+
+	if slices.Contains(strings.Split(string(b), "\n"), pattern) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(exclude), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(exclude, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if len(b) > 0 && !bytes.HasSuffix(b, []byte("\n")) {
+		pattern = "\n" + pattern
+	}
+
+	if _, err := f.WriteString(pattern + "\n"); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("git", "rm", "--cached", "--quiet", "--ignore-unmatch", file)
+	cmd.Dir = root
+
+	return cmd.Run()
+}
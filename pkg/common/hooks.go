@@ -0,0 +1,106 @@
+package common
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	HookBefore = "before"
+	HookAfter  = "after"
+)
+
+type Hook struct {
+	Provider string `koanf:"provider" desc:"provider to run the hook for, f.e. 'calc' or 'menus:power'. all if empty" default:""`
+	Action   string `koanf:"action" desc:"action to run the hook for. all if empty" default:""`
+	When     string `koanf:"when" desc:"'before' or 'after' the activation. before hooks delay the activation by up to 5 seconds" default:"after"`
+	Command  string `koanf:"command" desc:"command to run. the activation is available as ELEPHANT_* environment variables" default:""`
+}
+
+// HookActivation describes the activation passed to hooks as environment variables.
+type HookActivation struct {
+	Provider   string
+	Action     string
+	Identifier string
+	Query      string
+	Arguments  string
+	Text       string
+	Subtext    string
+}
+
+func (a HookActivation) env() []string {
+	return append(os.Environ(),
+		"ELEPHANT_PROVIDER="+a.Provider,
+		"ELEPHANT_ACTION="+a.Action,
+		"ELEPHANT_IDENTIFIER="+a.Identifier,
+		"ELEPHANT_QUERY="+a.Query,
+		"ELEPHANT_ARGUMENTS="+a.Arguments,
+		"ELEPHANT_TEXT="+a.Text,
+		"ELEPHANT_SUBTEXT="+a.Subtext,
+	)
+}
+
+func (h Hook) matches(when string, a HookActivation) bool {
+	if h.When == "" {
+		h.When = HookAfter
+	}
+
+	if h.When != when {
+		return false
+	}
+
+	if h.Provider != "" && h.Provider != a.Provider {
+		base, _, _ := strings.Cut(a.Provider, ":")
+
+		if h.Provider != base {
+			return false
+		}
+	}
+
+	return h.Action == "" || h.Action == a.Action
+}
+
+// HasHooks reports whether any hooks are configured, so callers can skip collecting details.
+func HasHooks() bool {
+	return elephantConfig != nil && len(elephantConfig.Hooks) > 0
+}
+
+// RunHooks runs the matching hooks. Before hooks are waited for, with a timeout, after hooks are only started.
+func RunHooks(when string, a HookActivation) {
+	if !HasHooks() {
+		return
+	}
+
+	for _, h := range elephantConfig.Hooks {
+		if !h.matches(when, a) || h.Command == "" {
+			continue
+		}
+
+		if when == HookBefore {
+			cmd := Shell(h.Command)
+			cmd.Env = a.env()
+
+			if out, err := Exec("hooks", cmd, ExecOptions{Timeout: 5 * time.Second}); err != nil {
+				slog.Error("hooks", "before", err, "command", h.Command, "out", strings.TrimSpace(string(out)))
+			}
+
+			continue
+		}
+
+		cmd := HostShell(h.Command)
+		cmd.Env = a.env()
+		Detach(cmd)
+
+		if err := cmd.Start(); err != nil {
+			slog.Error("hooks", "after", fmt.Errorf("%s: %w", h.Command, err))
+			continue
+		}
+
+		go func() {
+			cmd.Wait()
+		}()
+	}
+}
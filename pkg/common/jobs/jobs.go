@@ -0,0 +1,281 @@
+// Package jobs runs long commands without a terminal, f.e. installing packages or cloning repositories.
+// Jobs run in the daemon, so they survive the client that started them. Their output is written to a log
+// file and passed to listeners line by line.
+package jobs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+const (
+	StateRunning   = "running"
+	StateDone      = "done"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+// finished jobs kept, older ones are removed with their log
+const keep = 50
+
+type Job struct {
+	ID       uint32
+	Provider string
+	Title    string
+	Command  string
+	State    string
+	ExitCode int
+	Started  time.Time
+	Finished time.Time
+	Log      string
+
+	cmd       *exec.Cmd
+	cancelled bool
+}
+
+// Event is passed to listeners. Line is empty if the state of the job changed.
+type Event struct {
+	Job  Job
+	Line string
+}
+
+var (
+	mut       sync.Mutex
+	jobs      []*Job
+	lastID    uint32
+	listeners []func(Event)
+	cleanup   sync.Once
+)
+
+// Listen registers a function that's called for every line of output and state change of all jobs.
+func Listen(fn func(Event)) {
+	mut.Lock()
+	defer mut.Unlock()
+
+	listeners = append(listeners, fn)
+}
+
+func emit(e Event) {
+	mut.Lock()
+	l := slices.Clone(listeners)
+	mut.Unlock()
+
+	for _, fn := range l {
+		fn(e)
+	}
+}
+
+func logDir() string {
+	return common.CacheFile("jobs")
+}
+
+// Start runs the command in the background and returns the id of the job.
+func Start(provider, title, command string) (uint32, error) {
+	cleanup.Do(func() {
+		// logs of a previous run, ids start over
+		os.RemoveAll(logDir())
+	})
+
+	if err := os.MkdirAll(logDir(), 0o755); err != nil {
+		return 0, err
+	}
+
+	mut.Lock()
+	lastID++
+	job := &Job{
+		ID:       lastID,
+		Provider: provider,
+		Title:    title,
+		Command:  command,
+		State:    StateRunning,
+		Started:  time.Now(),
+		Log:      filepath.Join(logDir(), fmt.Sprintf("%d.log", lastID)),
+	}
+	mut.Unlock()
+
+	f, err := os.Create(job.Log)
+	if err != nil {
+		return 0, err
+	}
+
+	r, w := io.Pipe()
+
+	job.cmd = common.HostShell(command)
+	job.cmd.Stdout = w
+	job.cmd.Stderr = w
+	common.Detach(job.cmd)
+
+	if err := job.cmd.Start(); err != nil {
+		f.Close()
+		os.Remove(job.Log)
+		return 0, err
+	}
+
+	mut.Lock()
+	jobs = append(jobs, job)
+	started := *job
+	mut.Unlock()
+
+	emit(Event{Job: started})
+
+	output := make(chan struct{})
+
+	go func() {
+		defer close(output)
+		defer f.Close()
+
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+		for scanner.Scan() {
+			line := scanner.Text()
+
+			fmt.Fprintln(f, line)
+
+			if line != "" {
+				emit(Event{Job: started, Line: line})
+			}
+		}
+
+		// drain, so the command doesn't block on overlong lines
+		io.Copy(f, r)
+	}()
+
+	go func() {
+		err := job.cmd.Wait()
+		w.Close()
+		<-output
+
+		mut.Lock()
+		job.Finished = time.Now()
+		job.ExitCode = job.cmd.ProcessState.ExitCode()
+
+		switch {
+		case job.cancelled:
+			job.State = StateCancelled
+		case err != nil:
+			job.State = StateFailed
+		default:
+			job.State = StateDone
+		}
+
+		prune()
+		done := *job
+		mut.Unlock()
+
+		slog.Info("jobs", "finished", done.Title, "state", done.State, "time", done.Finished.Sub(done.Started))
+
+		emit(Event{Job: done})
+	}()
+
+	return job.ID, nil
+}
+
+// prune removes the oldest finished jobs. Expects mut to be locked.
+func prune() {
+	finished := 0
+
+	for _, v := range jobs {
+		if v.State != StateRunning {
+			finished++
+		}
+	}
+
+	jobs = slices.DeleteFunc(jobs, func(j *Job) bool {
+		if finished <= keep || j.State == StateRunning {
+			return false
+		}
+
+		finished--
+		os.Remove(j.Log)
+
+		return true
+	})
+}
+
+// Cancel terminates a running job and everything it started.
+func Cancel(id uint32) bool {
+	mut.Lock()
+	defer mut.Unlock()
+
+	for _, v := range jobs {
+		if v.ID == id && v.State == StateRunning {
+			v.cancelled = true
+
+			if err := common.Terminate(v.cmd); err != nil {
+				slog.Error("jobs", "cancel", err)
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// Remove forgets a finished job and deletes its log.
+func Remove(id uint32) {
+	mut.Lock()
+	defer mut.Unlock()
+
+	jobs = slices.DeleteFunc(jobs, func(j *Job) bool {
+		if j.ID == id && j.State != StateRunning {
+			os.Remove(j.Log)
+			return true
+		}
+
+		return false
+	})
+}
+
+// Clear forgets all finished jobs.
+func Clear() {
+	mut.Lock()
+	defer mut.Unlock()
+
+	jobs = slices.DeleteFunc(jobs, func(j *Job) bool {
+		if j.State != StateRunning {
+			os.Remove(j.Log)
+			return true
+		}
+
+		return false
+	})
+}
+
+// List returns all jobs, newest first.
+func List() []Job {
+	mut.Lock()
+	defer mut.Unlock()
+
+	res := make([]Job, 0, len(jobs))
+
+	for i := len(jobs) - 1; i >= 0; i-- {
+		res = append(res, *jobs[i])
+	}
+
+	return res
+}
+
+func Get(id uint32) (Job, bool) {
+	mut.Lock()
+	defer mut.Unlock()
+
+	for _, v := range jobs {
+		if v.ID == id {
+			return *v, true
+		}
+	}
+
+	return Job{}, false
+}
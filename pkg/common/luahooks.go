@@ -0,0 +1,225 @@
+package common
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common/metrics"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	luaHooksFile    = "hooks.lua"
+	luaHooksTimeout = 2 * time.Second
+)
+
+// luaHooks is the state of hooks.lua, loaded again when the file changed. Lua states can't be shared, calls
+// are serialized.
+var (
+	luaHooksMu       sync.Mutex
+	luaHooksState    *lua.LState
+	luaHooksPath     string
+	luaHooksModified time.Time
+)
+
+// luaHooks returns the state of the first hooks.lua of the config dirs, or nil. Expects luaHooksMu to be locked.
+func luaHooks() *lua.LState {
+	path := ""
+	var info os.FileInfo
+
+	for _, v := range ConfigDirs() {
+		i, err := os.Stat(filepath.Join(v, luaHooksFile))
+		if err == nil {
+			path, info = filepath.Join(v, luaHooksFile), i
+			break
+		}
+	}
+
+	if path == "" {
+		if luaHooksState != nil {
+			luaHooksState.Close()
+			luaHooksState, luaHooksPath = nil, ""
+		}
+
+		return nil
+	}
+
+	if luaHooksState != nil && path == luaHooksPath && info.ModTime().Equal(luaHooksModified) {
+		return luaHooksState
+	}
+
+	if luaHooksState != nil {
+		luaHooksState.Close()
+		luaHooksState = nil
+	}
+
+	// a broken file isn't loaded again until it changed
+	luaHooksPath, luaHooksModified = path, info.ModTime()
+
+	l := lua.NewState()
+
+	l.SetGlobal("notify", l.NewFunction(luaNotify))
+	l.SetGlobal("run", l.NewFunction(luaRun))
+	l.SetGlobal("jsonEncode", l.NewFunction(JSONEncode))
+	l.SetGlobal("jsonDecode", l.NewFunction(JSONDecode))
+
+	if err := l.DoFile(path); err != nil {
+		slog.Error(luaHooksFile, "load", err)
+		l.Close()
+
+		return nil
+	}
+
+	slog.Info(luaHooksFile, "loaded", path)
+
+	luaHooksState = l
+
+	return l
+}
+
+// HasLuaHooks reports whether hooks.lua is loaded. It's loaded by the first query or activation.
+func HasLuaHooks() bool {
+	luaHooksMu.Lock()
+	defer luaHooksMu.Unlock()
+
+	return luaHooksState != nil
+}
+
+// callLuaHook calls the global function of hooks.lua, if it's defined, and returns its result.
+func callLuaHook(fn string, args func(l *lua.LState) []lua.LValue) (lua.LValue, bool) {
+	l := luaHooks()
+	if l == nil {
+		return lua.LNil, false
+	}
+
+	f, ok := l.GetGlobal(fn).(*lua.LFunction)
+	if !ok {
+		return lua.LNil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), luaHooksTimeout)
+	defer cancel()
+
+	l.SetContext(ctx)
+	defer l.RemoveContext()
+
+	start := time.Now()
+
+	err := l.CallByParam(lua.P{
+		Fn:      f,
+		NRet:    1,
+		Protect: true,
+	}, args(l)...)
+
+	metrics.LuaDuration.Since(start, "hooks", fn)
+
+	if err != nil {
+		slog.Error(luaHooksFile, fn, err)
+		return lua.LNil, false
+	}
+
+	ret := l.Get(-1)
+	l.Pop(1)
+
+	return ret, true
+}
+
+// LuaQueryHook passes the query to on_query(query, providers) of hooks.lua. It returns the query, rewritten
+// if the function returned a string, and false if it returned false to veto the query.
+func LuaQueryHook(query string, providers []string) (string, bool) {
+	luaHooksMu.Lock()
+	defer luaHooksMu.Unlock()
+
+	ret, ok := callLuaHook("on_query", func(l *lua.LState) []lua.LValue {
+		list := l.NewTable()
+
+		for _, v := range providers {
+			list.Append(lua.LString(v))
+		}
+
+		return []lua.LValue{lua.LString(query), list}
+	})
+
+	if !ok {
+		return query, true
+	}
+
+	switch v := ret.(type) {
+	case lua.LBool:
+		return query, bool(v)
+	case lua.LString:
+		return string(v), true
+	}
+
+	return query, true
+}
+
+// LuaActivateHook passes the activation to on_activate(provider, identifier, action, item) of hooks.lua. The
+// item holds the text, subtext, query and arguments. Returning false vetoes the activation, returning a table
+// replaces the fields it sets, f.e. { action = "open_dir" }.
+func LuaActivateHook(a HookActivation) (HookActivation, bool) {
+	luaHooksMu.Lock()
+	defer luaHooksMu.Unlock()
+
+	ret, ok := callLuaHook("on_activate", func(l *lua.LState) []lua.LValue {
+		item := l.NewTable()
+		item.RawSetString("text", lua.LString(a.Text))
+		item.RawSetString("subtext", lua.LString(a.Subtext))
+		item.RawSetString("query", lua.LString(a.Query))
+		item.RawSetString("arguments", lua.LString(a.Arguments))
+
+		return []lua.LValue{lua.LString(a.Provider), lua.LString(a.Identifier), lua.LString(a.Action), item}
+	})
+
+	if !ok {
+		return a, true
+	}
+
+	switch v := ret.(type) {
+	case lua.LBool:
+		return a, bool(v)
+	case *lua.LTable:
+		for key, field := range map[string]*string{
+			"provider":   &a.Provider,
+			"identifier": &a.Identifier,
+			"action":     &a.Action,
+			"query":      &a.Query,
+			"arguments":  &a.Arguments,
+		} {
+			if s, ok := v.RawGetString(key).(lua.LString); ok {
+				*field = string(s)
+			}
+		}
+	}
+
+	return a, true
+}
+
+// luaNotify shows a notification, notify(title, body).
+func luaNotify(l *lua.LState) int {
+	if _, err := Notify(Notification{Title: l.CheckString(1), Body: l.OptString(2, "")}); err != nil {
+		slog.Error(luaHooksFile, "notify", err)
+	}
+
+	return 0
+}
+
+// luaRun runs the command and returns its output, or nil and the error, run(command). Commands are killed
+// when the hook times out.
+func luaRun(l *lua.LState) int {
+	out, err := ExecShell("hooks", l.CheckString(1), ExecOptions{Timeout: luaHooksTimeout})
+	if err != nil {
+		l.Push(lua.LNil)
+		l.Push(lua.LString(err.Error()))
+
+		return 2
+	}
+
+	l.Push(lua.LString(out))
+
+	return 1
+}
@@ -0,0 +1,128 @@
+// Package memory keeps the daemon under a configurable budget of resident memory by evicting the
+// in-memory indexes of providers, least recently used first.
+package memory
+
+import (
+	"log/slog"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+)
+
+// Index is an in-memory dataset of a provider that can be dropped. After evicting, the provider loads
+// it again when needed, or reads pages of it from its store.
+type Index struct {
+	Name string
+	// Size estimates the bytes held by the index, 0 if it isn't loaded.
+	Size  func() int64
+	Evict func()
+	// Reload is optional. It loads the index into memory again when it's used after being evicted.
+	Reload func()
+}
+
+type entry struct {
+	index   Index
+	used    time.Time
+	evicted bool
+}
+
+var (
+	mu      sync.Mutex
+	indexes = make(map[string]*entry)
+)
+
+// Register adds the index, so it can be evicted when the budget is exceeded.
+func Register(i Index) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	indexes[i.Name] = &entry{index: i, used: time.Now()}
+}
+
+// Touch marks the index as used, so recently used ones are evicted last. Evicted indexes with Reload are
+// loaded again in the background.
+func Touch(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	e, ok := indexes[name]
+	if !ok {
+		return
+	}
+
+	e.used = time.Now()
+
+	if e.evicted && e.index.Reload != nil {
+		e.evicted = false
+		go e.index.Reload()
+	}
+}
+
+// Watch checks the resident memory periodically and evicts indexes while it exceeds the budget.
+func Watch() {
+	cfg := common.GetElephantConfig().Memory
+
+	if cfg.Budget <= 0 || cfg.Interval <= 0 {
+		return
+	}
+
+	budget := int64(cfg.Budget) * 1024 * 1024
+
+	for range time.Tick(time.Duration(cfg.Interval) * time.Second) {
+		enforce(budget)
+	}
+}
+
+// enforce evicts the least recently used indexes until the resident memory is within the budget.
+func enforce(budget int64) {
+	rss, err := RSS()
+	if err != nil {
+		slog.Error("memory", "rss", err)
+		return
+	}
+
+	if rss <= budget {
+		return
+	}
+
+	mu.Lock()
+	list := []entry{}
+
+	for _, v := range indexes {
+		list = append(list, *v)
+	}
+	mu.Unlock()
+
+	slices.SortFunc(list, func(a, b entry) int {
+		return a.used.Compare(b.used)
+	})
+
+	for _, v := range list {
+		size := v.index.Size()
+		if size == 0 {
+			continue
+		}
+
+		slog.Info("memory", "evict", v.index.Name, "size", size, "rss", rss, "budget", budget)
+
+		v.index.Evict()
+
+		mu.Lock()
+		if e, ok := indexes[v.index.Name]; ok {
+			e.evicted = true
+		}
+		mu.Unlock()
+
+		// the runtime keeps freed memory around otherwise
+		debug.FreeOSMemory()
+
+		if rss, err = RSS(); err != nil || rss <= budget {
+			return
+		}
+	}
+
+	slog.Debug("memory", "exceeded", "nothing left to evict", "rss", rss, "budget", budget)
+}
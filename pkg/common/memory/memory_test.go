@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	var size int64 = 1
+	reloaded := make(chan bool, 1)
+
+	Register(Index{
+		Name:   "test",
+		Size:   func() int64 { return size },
+		Evict:  func() { size = 0 },
+		Reload: func() { reloaded <- true },
+	})
+	defer func() {
+		mu.Lock()
+		delete(indexes, "test")
+		mu.Unlock()
+	}()
+
+	Touch("test")
+
+	select {
+	case <-reloaded:
+		t.Fatal("reloaded without being evicted")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// any process exceeds a budget of 1 byte
+	enforce(1)
+
+	if size != 0 {
+		t.Fatal("index wasn't evicted")
+	}
+
+	Touch("test")
+
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatal("evicted index wasn't reloaded")
+	}
+}
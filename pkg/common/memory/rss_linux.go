@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// RSS returns the resident memory of the process in bytes.
+func RSS() (int64, error) {
+	b, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, err
+	}
+
+	fields := bytes.Fields(b)
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("unexpected statm: %s", b)
+	}
+
+	pages, err := strconv.ParseInt(string(fields[1]), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return pages * int64(os.Getpagesize()), nil
+}
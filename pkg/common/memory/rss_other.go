@@ -0,0 +1,14 @@
+//go:build !linux
+
+package memory
+
+import "runtime"
+
+// RSS returns the memory the Go runtime holds from the system in bytes. Without /proc it's the closest to
+// the resident memory, memory allocated by C libraries isn't included.
+func RSS() (int64, error) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	return int64(m.Sys - m.HeapReleased), nil
+}
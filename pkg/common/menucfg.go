@@ -4,13 +4,19 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/abenz1267/elephant/v2/pkg/common/metrics"
 	"github.com/adrg/xdg"
 	"github.com/charlievieth/fastwalk"
 	"github.com/pelletier/go-toml/v2"
@@ -29,25 +35,26 @@ type MenuConfig struct {
 }
 
 type Menu struct {
-	HideFromProviderlist bool              `toml:"hide_from_providerlist" desc:"hides a provider from the providerlist provider. provider provider." default:"false"`
-	Name                 string            `toml:"name" desc:"name of the menu"`
-	NamePretty           string            `toml:"name_pretty" desc:"prettier name you usually want to display to the user."`
-	Description          string            `toml:"description" desc:"used as a subtext"`
-	Icon                 string            `toml:"icon" desc:"default icon"`
-	Action               string            `toml:"action" desc:"default menu action to use"`
-	Actions              map[string]string `toml:"actions" desc:"global actions"`
-	AsyncActions         []string          `toml:"async_actions" desc:"set which actions should update the item on the client asynchronously"`
-	SearchName           bool              `toml:"search_name" desc:"wether to search for the menu name as well when searching globally" default:"false"`
-	Cache                bool              `toml:"cache" desc:"will cache the results of the lua script on startup"`
-	Entries              []Entry           `toml:"entries" desc:"menu items"`
-	Terminal             bool              `toml:"terminal" desc:"execute action in terminal or not"`
-	Keywords             []string          `toml:"keywords" desc:"searchable keywords"`
-	FixedOrder           bool              `toml:"fixed_order" desc:"don't sort entries alphabetically"`
-	History              bool              `toml:"history" desc:"make use of history for sorting"`
-	HistoryWhenEmpty     bool              `toml:"history_when_empty" desc:"consider history when query is empty"`
-	MinScore             int32             `toml:"min_score" desc:"minimum score for items to be displayed" default:"depends on provider"`
-	Parent               string            `toml:"parent" desc:"defines the parent menu" default:""`
-	SubMenu              string            `toml:"submenu" desc:"defines submenu to trigger on activation" default:""`
+	HideFromProviderlist bool                      `toml:"hide_from_providerlist" desc:"hides a provider from the providerlist provider. provider provider." default:"false"`
+	Name                 string                    `toml:"name" desc:"name of the menu"`
+	NamePretty           string                    `toml:"name_pretty" desc:"prettier name you usually want to display to the user."`
+	Description          string                    `toml:"description" desc:"used as a subtext"`
+	Icon                 string                    `toml:"icon" desc:"default icon"`
+	Action               string                    `toml:"action" desc:"default menu action to use"`
+	Actions              map[string]string         `toml:"actions" desc:"global actions"`
+	AsyncActions         []string                  `toml:"async_actions" desc:"set which actions should update the item on the client asynchronously"`
+	Pipelines            map[string][]PipelineStep `toml:"pipelines" desc:"actions running steps one after another, f.e. running a command, copying its output and notifying"`
+	SearchName           bool                      `toml:"search_name" desc:"wether to search for the menu name as well when searching globally" default:"false"`
+	Cache                bool                      `toml:"cache" desc:"will cache the results of the lua script on startup"`
+	Entries              []Entry                   `toml:"entries" desc:"menu items"`
+	Terminal             bool                      `toml:"terminal" desc:"execute action in terminal or not"`
+	Keywords             []string                  `toml:"keywords" desc:"searchable keywords"`
+	FixedOrder           bool                      `toml:"fixed_order" desc:"don't sort entries alphabetically"`
+	History              bool                      `toml:"history" desc:"make use of history for sorting"`
+	HistoryWhenEmpty     bool                      `toml:"history_when_empty" desc:"consider history when query is empty"`
+	MinScore             int32                     `toml:"min_score" desc:"minimum score for items to be displayed" default:"depends on provider"`
+	Parent               string                    `toml:"parent" desc:"defines the parent menu" default:""`
+	SubMenu              string                    `toml:"submenu" desc:"defines submenu to trigger on activation" default:""`
 
 	// internal
 	LuaString string
@@ -230,11 +237,16 @@ func (m *Menu) CreateLuaEntries() {
 		return
 	}
 
-	if err := state.CallByParam(lua.P{
+	start := time.Now()
+	err := state.CallByParam(lua.P{
 		Fn:      state.GetGlobal("GetEntries"),
 		NRet:    1,
 		Protect: true,
-	}); err != nil {
+	})
+
+	metrics.LuaDuration.Since(start, m.Name, "GetEntries")
+
+	if err != nil {
 		slog.Error(m.Name, "GetLuaEntries", err)
 		return
 	}
@@ -326,18 +338,19 @@ func (m *Menu) CreateLuaEntries() {
 }
 
 type Entry struct {
-	Text        string            `toml:"text" desc:"text for entry"`
-	Async       string            `toml:"async" desc:"if the text should be updated asynchronously based on the action"`
-	Subtext     string            `toml:"subtext" desc:"sub text for entry"`
-	Value       string            `toml:"value" desc:"value to be used for the action."`
-	Actions     map[string]string `toml:"actions" desc:"actions items can use"`
-	Terminal    bool              `toml:"terminal" desc:"runs action in terminal if true"`
-	Icon        string            `toml:"icon" desc:"icon for entry"`
-	SubMenu     string            `toml:"submenu" desc:"submenu to open, if has prefix 'dmenu:' it'll launch that dmenu"`
-	Preview     string            `toml:"preview" desc:"filepath for the preview"`
-	PreviewType string            `toml:"preview_type" desc:"type of the preview: text, file [default], command"`
-	Keywords    []string          `toml:"keywords" desc:"searchable keywords"`
-	State       []string          `toml:"state" desc:"state of an item, can be used to f.e. mark it as current"`
+	Text        string                    `toml:"text" desc:"text for entry"`
+	Async       string                    `toml:"async" desc:"if the text should be updated asynchronously based on the action"`
+	Subtext     string                    `toml:"subtext" desc:"sub text for entry"`
+	Value       string                    `toml:"value" desc:"value to be used for the action."`
+	Actions     map[string]string         `toml:"actions" desc:"actions items can use"`
+	Pipelines   map[string][]PipelineStep `toml:"pipelines" desc:"pipelines items can use, overriding the ones of the menu"`
+	Terminal    bool                      `toml:"terminal" desc:"runs action in terminal if true"`
+	Icon        string                    `toml:"icon" desc:"icon for entry"`
+	SubMenu     string                    `toml:"submenu" desc:"submenu to open, if has prefix 'dmenu:' it'll launch that dmenu"`
+	Preview     string                    `toml:"preview" desc:"filepath for the preview"`
+	PreviewType string                    `toml:"preview_type" desc:"type of the preview: text, file [default], command"`
+	Keywords    []string                  `toml:"keywords" desc:"searchable keywords"`
+	State       []string                  `toml:"state" desc:"state of an item, can be used to f.e. mark it as current"`
 
 	Identifier string `toml:"-"`
 	Menu       string `toml:"-"`
@@ -372,15 +385,91 @@ func LoadMenus() {
 	installed := filepath.Join(xdg.DataHome, "elephant", "install")
 	MenuConfigLoaded.Paths = append(MenuConfigLoaded.Paths, installed)
 
+	start := time.Now()
+	files := menuFiles()
+	results := make([]menuResult, len(files))
+
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range min(runtime.NumCPU(), max(len(files), 1)) {
+		wg.Go(func() {
+			for i := range jobs {
+				results[i] = parseMenu(files[i])
+			}
+		})
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	summary := MenuLoadSummary{
+		Files:  len(files),
+		Loaded: time.Now(),
+	}
+
+	paths := make(map[string][]string)
+
+	// later paths take precedence, as they did when menus were loaded one after another
+	for _, v := range results {
+		if v.err != nil {
+			slog.Error(menuname, "path", v.path, "error", v.err)
+			summary.Errors = append(summary.Errors, MenuError{Path: v.path, Error: v.err.Error()})
+
+			continue
+		}
+
+		paths[v.menu.Name] = append(paths[v.menu.Name], v.path)
+		Menus[v.menu.Name] = v.menu
+	}
+
+	for name, v := range paths {
+		if len(v) < 2 {
+			continue
+		}
+
+		slog.Warn(menuname, "duplicate", name, "paths", v)
+		summary.Duplicates = append(summary.Duplicates, MenuDuplicate{
+			Name:    name,
+			Used:    v[len(v)-1],
+			Ignored: v[:len(v)-1],
+		})
+	}
+
+	slices.SortFunc(summary.Duplicates, func(a, b MenuDuplicate) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	summary.Menus = len(Menus)
+	summary.Took = time.Since(start)
+
+	slog.Info(menuname, "menus", summary.Menus, "files", summary.Files, "errors", len(summary.Errors), "duplicates", len(summary.Duplicates), "time", summary.Took)
+
+	setMenuSummary(summary)
+}
+
+// menuFiles lists the menu files of all paths, sorted within each path, so precedence between
+// duplicates doesn't depend on the order of walking.
+func menuFiles() []string {
 	conf := fastwalk.Config{
 		Follow: true,
 	}
 
+	res := []string{}
+
 	for _, root := range MenuConfigLoaded.Paths {
 		if _, err := os.Stat(root); err != nil {
 			continue
 		}
 
+		var mu sync.Mutex
+		files := []string{}
+
 		if err := fastwalk.Walk(&conf, root, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
@@ -391,10 +480,10 @@ func LoadMenus() {
 			}
 
 			switch filepath.Ext(path) {
-			case ".toml":
-				createTomlMenu(path)
-			case ".lua":
-				createLuaMenu(path)
+			case ".toml", ".lua":
+				mu.Lock()
+				files = append(files, path)
+				mu.Unlock()
 			}
 
 			return nil
@@ -402,22 +491,57 @@ func LoadMenus() {
 			slog.Error(menuname, "walk", err)
 			os.Exit(1)
 		}
+
+		slices.Sort(files)
+		res = append(res, files...)
 	}
+
+	return res
 }
 
-func createLuaMenu(path string) {
+type menuResult struct {
+	path string
+	menu *Menu
+	err  error
+}
+
+func parseMenu(path string) (res menuResult) {
+	res.path = path
+
+	// scripts with globals of unexpected types would take down the daemon otherwise
+	defer func() {
+		if r := recover(); r != nil {
+			res.menu = nil
+			res.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	switch filepath.Ext(path) {
+	case ".toml":
+		res.menu, res.err = createTomlMenu(path)
+	case ".lua":
+		res.menu, res.err = createLuaMenu(path)
+	}
+
+	return res
+}
+
+func createLuaMenu(path string) (*Menu, error) {
 	m := Menu{}
 	m.IsLua = true
 
 	b, err := os.ReadFile(path)
 	if err != nil {
-		slog.Error(m.Name, "lua read", err)
-		return
+		return nil, err
 	}
 
 	m.LuaString = string(b)
 
 	state := m.NewLuaState()
+	if state == nil {
+		return nil, errors.New("invalid lua script")
+	}
+	defer state.Close()
 
 	if val := state.GetGlobal("Name"); val != lua.LNil {
 		m.Name = string(val.(lua.LString))
@@ -503,29 +627,55 @@ func createLuaMenu(path string) {
 		m.SubMenu = string(val.(lua.LString))
 	}
 
+	if m.Name == "" || m.NamePretty == "" {
+		return nil, errors.New("missing Name or NamePretty")
+	}
+
 	if m.Cache {
-		m.CreateLuaEntries()
+		m.loadLuaEntries()
 	}
 
-	if m.Name == "" || m.NamePretty == "" {
-		slog.Error("menus", "path", path, "error", "missing Name or NamePretty")
+	return &m, nil
+}
+
+// loadLuaEntries serves the cached entries from the snapshot, if the script didn't change, and creates
+// them again in the background, as they might depend on more than the script.
+func (m *Menu) loadLuaEntries() {
+	name := fmt.Sprintf("menus_%s", m.Name)
+	sum := md5.Sum([]byte(m.LuaString))
+	version := hex.EncodeToString(sum[:])
+
+	entries := []Entry{}
+
+	if !LoadSnapshot(name, version, &entries) {
+		m.CreateLuaEntries()
+		SaveSnapshot(name, version, m.Entries)
+
 		return
 	}
 
-	Menus[m.Name] = &m
+	m.Entries = entries
+
+	go func() {
+		m.CreateLuaEntries()
+		SaveSnapshot(name, version, m.Entries)
+	}()
 }
 
-func createTomlMenu(path string) {
+func createTomlMenu(path string) (*Menu, error) {
 	m := Menu{}
 
 	b, err := os.ReadFile(path)
 	if err != nil {
-		slog.Error(menuname, "setup", err)
+		return nil, err
 	}
 
-	err = toml.Unmarshal(b, &m)
-	if err != nil {
-		slog.Error(menuname, "setup", err)
+	if err := toml.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	if m.Name == "" {
+		return nil, errors.New("missing name")
 	}
 
 	for k, v := range m.Entries {
@@ -541,5 +691,5 @@ func createTomlMenu(path string) {
 		}
 	}
 
-	Menus[m.Name] = &m
+	return &m, nil
 }
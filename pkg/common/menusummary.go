@@ -0,0 +1,69 @@
+package common
+
+import (
+	"bytes"
+	"encoding/gob"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MenuLoadSummary describes the last loading of menus. It's persisted, so 'elephant doctor' can report
+// it without asking the running daemon.
+type MenuLoadSummary struct {
+	Loaded     time.Time
+	Took       time.Duration
+	Files      int
+	Menus      int
+	Errors     []MenuError
+	Duplicates []MenuDuplicate
+}
+
+type MenuError struct {
+	Path  string
+	Error string
+}
+
+// MenuDuplicate is a menu name defined in several files. The one of the last path is used.
+type MenuDuplicate struct {
+	Name    string
+	Used    string
+	Ignored []string
+}
+
+func menuSummaryFile() string {
+	return CacheFile("menus_summary.gob")
+}
+
+func setMenuSummary(s MenuLoadSummary) {
+	var b bytes.Buffer
+
+	if err := gob.NewEncoder(&b).Encode(s); err != nil {
+		slog.Error(menuname, "summary", err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(menuSummaryFile()), 0o755); err != nil {
+		slog.Error(menuname, "createdirs", err)
+		return
+	}
+
+	if err := os.WriteFile(menuSummaryFile(), b.Bytes(), 0o600); err != nil {
+		slog.Error(menuname, "writefile", err)
+	}
+}
+
+// ReadMenuSummary reads the summary persisted by the daemon.
+func ReadMenuSummary() (MenuLoadSummary, error) {
+	var s MenuLoadSummary
+
+	b, err := os.ReadFile(menuSummaryFile())
+	if err != nil {
+		return s, err
+	}
+
+	err = gob.NewDecoder(bytes.NewReader(b)).Decode(&s)
+
+	return s, err
+}
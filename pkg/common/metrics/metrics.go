@@ -0,0 +1,258 @@
+// Package metrics collects counters and histograms of the daemon and exposes them in the Prometheus
+// text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	Queries            = NewCounter("elephant_queries_total", "Queries per provider.", "provider")
+	QueryDuration      = NewHistogram("elephant_query_duration_seconds", "Duration of queries per provider.", "provider")
+	ActivationFailures = NewCounter("elephant_activation_failures_total", "Activations that failed per provider.", "provider")
+	Connections        = NewCounter("elephant_socket_connections_total", "Accepted socket connections.")
+	OpenConnections    = NewGauge("elephant_socket_connections_open", "Currently open socket connections.")
+	LuaDuration        = NewHistogram("elephant_lua_duration_seconds", "Execution time of lua functions per menu.", "menu", "function")
+	DebouncedQueries   = NewCounter("elephant_queries_debounced_total", "Queries superseded by a following query while debouncing.")
+	ThrottledRequests  = NewCounter("elephant_requests_throttled_total", "Requests delayed by the rate limit of their connection.")
+)
+
+// DefaultBuckets are the upper bounds in seconds of histogram buckets.
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type metric interface {
+	write(w io.Writer)
+}
+
+var (
+	mu      sync.Mutex
+	metrics []metric
+)
+
+func register(m metric) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	metrics = append(metrics, m)
+}
+
+// series holds the label names of a metric and joins label values to keys.
+type series struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (s series) key(values []string) string {
+	if len(values) != len(s.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", s.name, len(s.labels), len(values)))
+	}
+
+	return strings.Join(values, "\xff")
+}
+
+// format renders the labels of a key, with extra appended, f.e. `{provider="files",le="0.5"}`.
+func (s series) format(key string, extra ...string) string {
+	pairs := []string{}
+
+	if len(s.labels) > 0 {
+		for i, v := range strings.Split(key, "\xff") {
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, s.labels[i], escape.Replace(v)))
+		}
+	}
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], escape.Replace(extra[i+1])))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var escape = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (s series) header(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", s.name, s.help, s.name, kind)
+}
+
+// Counter is a monotonically increasing value per combination of label values.
+type Counter struct {
+	series
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{series: series{name, help, labels}, values: make(map[string]float64)}
+	register(c)
+
+	return c
+}
+
+func (c *Counter) Inc(labels ...string) {
+	k := c.key(labels)
+
+	c.mu.Lock()
+	c.values[k]++
+	c.mu.Unlock()
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.header(w, "counter")
+
+	for _, k := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.format(k), formatFloat(c.values[k]))
+	}
+}
+
+// Gauge is a value without labels that can go up and down.
+type Gauge struct {
+	series
+	mu    sync.Mutex
+	value float64
+}
+
+func NewGauge(name, help string) *Gauge {
+	g := &Gauge{series: series{name: name, help: help}}
+	register(g)
+
+	return g
+}
+
+func (g *Gauge) Add(v float64) {
+	g.mu.Lock()
+	g.value += v
+	g.mu.Unlock()
+}
+
+func (g *Gauge) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.header(w, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.value))
+}
+
+// Histogram counts observations in DefaultBuckets per combination of label values.
+type Histogram struct {
+	series
+	mu     sync.Mutex
+	values map[string]*histogramValue
+}
+
+type histogramValue struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+func NewHistogram(name, help string, labels ...string) *Histogram {
+	h := &Histogram{series: series{name, help, labels}, values: make(map[string]*histogramValue)}
+	register(h)
+
+	return h
+}
+
+func (h *Histogram) Observe(v float64, labels ...string) {
+	k := h.key(labels)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	val, ok := h.values[k]
+	if !ok {
+		val = &histogramValue{buckets: make([]uint64, len(DefaultBuckets))}
+		h.values[k] = val
+	}
+
+	for i, b := range DefaultBuckets {
+		if v <= b {
+			val.buckets[i]++
+		}
+	}
+
+	val.count++
+	val.sum += v
+}
+
+// Since observes the seconds passed since start.
+func (h *Histogram) Since(start time.Time, labels ...string) {
+	h.Observe(time.Since(start).Seconds(), labels...)
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(w, "histogram")
+
+	for _, k := range sortedKeys(h.values) {
+		val := h.values[k]
+
+		for i, b := range DefaultBuckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.format(k, "le", formatFloat(b)), val.buckets[i])
+		}
+
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.format(k, "le", "+Inf"), val.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.format(k), formatFloat(val.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.format(k), val.count)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Write writes all metrics in the Prometheus text format.
+func Write(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// Serve exposes the metrics at /metrics on the given localhost port.
+func Serve(port int) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Write(w)
+	})
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	slog.Info("metrics", "listen", addr)
+
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		slog.Error("metrics", "listen", err)
+	}
+}
@@ -0,0 +1,223 @@
+package common
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/knadh/koanf/parsers/toml/v2"
+)
+
+// Migration maps options of an older version to the current ones. Configs are migrated in memory when
+// they're loaded, "elephant migrate" rewrites the files.
+type Migration struct {
+	// Config is "elephant" or the name of the provider.
+	Config string
+	// Version is the release the options changed in.
+	Version     string
+	Description string
+	// Apply changes the parsed config in place and returns whether anything changed.
+	Apply func(m map[string]any) bool
+}
+
+var (
+	migrationsMu sync.Mutex
+	migrations   = []Migration{
+		{
+			Config:      "elephant",
+			Version:     "2.17.0",
+			Description: "'auto_detect_launch_prefix = false' is 'launch_backend = \"none\"'",
+			Apply: func(m map[string]any) bool {
+				v, ok := m["auto_detect_launch_prefix"]
+				if !ok {
+					return false
+				}
+
+				delete(m, "auto_detect_launch_prefix")
+
+				if _, ok := m["launch_backend"]; !ok && v == false {
+					m["launch_backend"] = "none"
+				}
+
+				return true
+			},
+		},
+	}
+)
+
+// RegisterMigration adds a migration. Providers register theirs in init, so they are known to "elephant
+// migrate" as well, which doesn't run their setup.
+func RegisterMigration(m Migration) {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	migrations = append(migrations, m)
+}
+
+// Rename moves a renamed option to its new key. Keys of tables are separated by dots, f.e.
+// "scopes.slice". An option set with both keys keeps the value of the new one.
+func Rename(config, version, from, to string) Migration {
+	return Migration{
+		Config:      config,
+		Version:     version,
+		Description: fmt.Sprintf("'%s' is '%s'", from, to),
+		Apply: func(m map[string]any) bool {
+			v, ok := lookupPath(m, from)
+			if !ok {
+				return false
+			}
+
+			deletePath(m, from)
+
+			if _, ok := lookupPath(m, to); !ok {
+				setPath(m, to, v)
+			}
+
+			return true
+		},
+	}
+}
+
+// migrate applies the migrations of the config and returns the descriptions of the applied ones.
+func migrate(config string, m map[string]any) []string {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	res := []string{}
+
+	for _, v := range migrations {
+		if v.Config == config && v.Apply(m) {
+			res = append(res, fmt.Sprintf("%s (%s)", v.Description, v.Version))
+		}
+	}
+
+	return res
+}
+
+// MigrateFiles applies the migrations to the config files and their drop-ins and rewrites changed files,
+// unless dryRun is set. The previous content is kept as "<file>.bak", as comments are lost. It returns
+// the applied migrations by file.
+func MigrateFiles(dryRun bool) (map[string][]string, error) {
+	res := make(map[string][]string)
+
+	for _, config := range migratedConfigs() {
+		for _, file := range configFiles(config) {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return res, err
+			}
+
+			m, err := toml.Parser().Unmarshal(b)
+			if err != nil {
+				return res, fmt.Errorf("%s: %w", file, err)
+			}
+
+			applied := migrate(config, m)
+			if len(applied) == 0 {
+				continue
+			}
+
+			res[file] = applied
+
+			if dryRun {
+				continue
+			}
+
+			out, err := toml.Parser().Marshal(m)
+			if err != nil {
+				return res, fmt.Errorf("%s: %w", file, err)
+			}
+
+			if err := os.WriteFile(file+".bak", b, 0o600); err != nil {
+				return res, err
+			}
+
+			if err := os.WriteFile(file, out, 0o644); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// logMigrations warns about outdated options, which are still applied.
+func logMigrations(config, file string, applied []string) {
+	if len(applied) == 0 {
+		return
+	}
+
+	slog.Warn(config, "config", "outdated options, run 'elephant migrate' to update the file", "file", file, "migrated", strings.Join(applied, "; "))
+}
+
+func lookupPath(m map[string]any, path string) (any, bool) {
+	keys := strings.Split(path, ".")
+
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := m[k].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		m = next
+	}
+
+	v, ok := m[keys[len(keys)-1]]
+
+	return v, ok
+}
+
+func setPath(m map[string]any, path string, v any) {
+	keys := strings.Split(path, ".")
+
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := m[k].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			m[k] = next
+		}
+
+		m = next
+	}
+
+	m[keys[len(keys)-1]] = v
+}
+
+// deletePath removes the option, and tables that end up empty.
+func deletePath(m map[string]any, path string) {
+	keys := strings.Split(path, ".")
+
+	if len(keys) == 1 {
+		delete(m, path)
+		return
+	}
+
+	next, ok := m[keys[0]].(map[string]any)
+	if !ok {
+		return
+	}
+
+	deletePath(next, strings.Join(keys[1:], "."))
+
+	if len(next) == 0 {
+		delete(m, keys[0])
+	}
+}
+
+// migratedConfigs returns the names of configs with migrations, sorted.
+func migratedConfigs() []string {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	res := make(map[string]bool)
+
+	for _, v := range migrations {
+		res[v.Config] = true
+	}
+
+	return slices.Sorted(maps.Keys(res))
+}
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMigrateLaunchPrefix loads configs of before launch_backend, which must launch as they did.
+func TestMigrateLaunchPrefix(t *testing.T) {
+	tests := []struct {
+		config  string
+		backend string
+	}{
+		{"auto_detect_launch_prefix = false\n", "none"},
+		{"auto_detect_launch_prefix = true\n", "auto"},
+		{"auto_detect_launch_prefix = false\nlaunch_backend = \"uwsm\"\n", "uwsm"},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", dir)
+
+		if err := os.MkdirAll(filepath.Join(dir, "elephant"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		file := filepath.Join(dir, "elephant", "elephant.toml")
+
+		if err := os.WriteFile(file, []byte(tt.config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		LoadGlobalConfig()
+
+		if got := GetElephantConfig().LaunchBackend; got != tt.backend {
+			t.Errorf("%q: launch_backend = %q, want %q", tt.config, got, tt.backend)
+		}
+
+		res, err := MigrateFiles(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(res[file]) != 1 {
+			t.Errorf("%q: migrations = %v", tt.config, res[file])
+		}
+
+		if tt.backend != "none" {
+			continue
+		}
+
+		runPrefix = ""
+		InitRunPrefix()
+
+		// without auto detection applications were launched without prefix
+		if got := LaunchPrefix(""); got != "" {
+			t.Errorf("%q: launch prefix = %q", tt.config, got)
+		}
+	}
+}
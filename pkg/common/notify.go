@@ -0,0 +1,172 @@
+package common
+
+import (
+	"log/slog"
+	"strconv"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	notifications     = "org.freedesktop.Notifications"
+	notificationsPath = "/org/freedesktop/Notifications"
+)
+
+// Notification is shown via org.freedesktop.Notifications. Buttons trigger activations of elephant.
+type Notification struct {
+	Title string
+	Body  string
+	Icon  string
+	// Urgency is "low", "normal" or "critical".
+	Urgency string
+	// Timeout in milliseconds. 0 lets the notification daemon decide.
+	Timeout int32
+	Actions []NotificationAction
+}
+
+// NotificationAction is a button of a notification. Default actions run when the notification itself
+// is clicked, the label isn't shown.
+type NotificationAction struct {
+	Label      string
+	Default    bool
+	Provider   string
+	Identifier string
+	Action     string
+	Query      string
+	Arguments  string
+}
+
+var (
+	notifyMu  sync.Mutex
+	notifyBus *dbus.Conn
+	// pending actions of shown notifications by notification id and action key
+	pendingActions = make(map[uint32]map[string]NotificationAction)
+	activator      func(NotificationAction)
+)
+
+// SetNotificationActivator sets the function running the activations of notification buttons.
+func SetNotificationActivator(fn func(NotificationAction)) {
+	notifyMu.Lock()
+	defer notifyMu.Unlock()
+
+	activator = fn
+}
+
+// notificationBus connects to the session bus and listens for clicked and closed notifications. Expects
+// notifyMu to be locked.
+func notificationBus() (*dbus.Conn, error) {
+	if notifyBus != nil {
+		return notifyBus, nil
+	}
+
+	bus, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bus.AddMatchSignal(
+		dbus.WithMatchInterface(notifications),
+		dbus.WithMatchObjectPath(notificationsPath),
+	); err != nil {
+		bus.Close()
+		return nil, err
+	}
+
+	signals := make(chan *dbus.Signal, 10)
+	bus.Signal(signals)
+
+	go func() {
+		for s := range signals {
+			handleNotificationSignal(s)
+		}
+	}()
+
+	notifyBus = bus
+
+	return bus, nil
+}
+
+func handleNotificationSignal(s *dbus.Signal) {
+	if len(s.Body) < 2 {
+		return
+	}
+
+	id, ok := s.Body[0].(uint32)
+	if !ok {
+		return
+	}
+
+	notifyMu.Lock()
+	defer notifyMu.Unlock()
+
+	switch s.Name {
+	case notifications + ".ActionInvoked":
+		key, _ := s.Body[1].(string)
+
+		a, ok := pendingActions[id][key]
+		if !ok || activator == nil {
+			return
+		}
+
+		go activator(a)
+	case notifications + ".NotificationClosed":
+		delete(pendingActions, id)
+	}
+}
+
+// Notify shows the notification and returns its id.
+func Notify(n Notification) (uint32, error) {
+	notifyMu.Lock()
+	defer notifyMu.Unlock()
+
+	bus, err := notificationBus()
+	if err != nil {
+		return 0, err
+	}
+
+	actions := []string{}
+	keys := make(map[string]NotificationAction)
+
+	for i, v := range n.Actions {
+		key := strconv.Itoa(i)
+		if v.Default {
+			key = "default"
+		}
+
+		actions = append(actions, key, v.Label)
+		keys[key] = v
+	}
+
+	hints := map[string]dbus.Variant{}
+
+	switch n.Urgency {
+	case "low":
+		hints["urgency"] = dbus.MakeVariant(byte(0))
+	case "critical":
+		hints["urgency"] = dbus.MakeVariant(byte(2))
+	default:
+		hints["urgency"] = dbus.MakeVariant(byte(1))
+	}
+
+	timeout := n.Timeout
+	if timeout == 0 {
+		timeout = -1
+	}
+
+	var id uint32
+
+	err = bus.Object(notifications, notificationsPath).Call(notifications+".Notify", 0,
+		"elephant", uint32(0), n.Icon, n.Title, n.Body, actions, hints, timeout).Store(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(keys) > 0 {
+		pendingActions[id] = keys
+	}
+
+	slog.Debug("notify", "id", id, "title", n.Title)
+
+	return id, nil
+}
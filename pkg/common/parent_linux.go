@@ -0,0 +1,15 @@
+package common
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// DieWithParent terminates the command when elephant exits, also if it crashes.
+func DieWithParent(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Pdeathsig = syscall.SIGTERM
+}
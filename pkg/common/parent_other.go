@@ -0,0 +1,8 @@
+//go:build !linux
+
+package common
+
+import "os/exec"
+
+// DieWithParent is only supported on linux. Elsewhere the command has to be stopped on shutdown.
+func DieWithParent(cmd *exec.Cmd) {}
@@ -0,0 +1,133 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Pipeline is an action of a provider running steps one after another, f.e. running a command, copying
+// its output and notifying about it, instead of a wrapper script.
+type Pipeline struct {
+	Provider string         `koanf:"provider" desc:"provider whose items get the action, f.e. 'files' or 'menus:bookmarks'" default:""`
+	Action   string         `koanf:"action" desc:"name of the action. replaces an action of the provider with the same name" default:""`
+	Label    string         `koanf:"label" desc:"label of the action" default:"the name of the action"`
+	Icon     string         `koanf:"icon" desc:"icon of the action" default:""`
+	Steps    []PipelineStep `koanf:"steps" desc:"steps to run" default:""`
+}
+
+// PipelineStep does one thing: run a command, copy or notify.
+type PipelineStep struct {
+	Run         string `koanf:"run" toml:"run" desc:"command to run. placeholders are shell quoted. without %OUTPUT% the previous output is passed on stdin" default:""`
+	Copy        string `koanf:"copy" toml:"copy" desc:"text to copy" default:""`
+	Notify      string `koanf:"notify" toml:"notify" desc:"text to show as notification" default:""`
+	Timeout     int    `koanf:"timeout" toml:"timeout" desc:"seconds a command may run" default:"30"`
+	IgnoreError bool   `koanf:"ignore_error" toml:"ignore_error" desc:"continue with the next step if the command fails" default:"false"`
+}
+
+// PipelineVars are the placeholders of the steps: %VALUE%, %QUERY%, %ARGS%, %TEXT%, %CLIPBOARD% and %OUTPUT%,
+// the trimmed output of the last command.
+type PipelineVars struct {
+	Value string
+	Query string
+	Args  string
+	Text  string
+}
+
+// HasPipelines reports whether pipelines of providers are configured.
+func HasPipelines() bool {
+	return elephantConfig != nil && len(elephantConfig.Pipelines) > 0
+}
+
+// RunPipeline runs the steps of the pipeline named name. The first failing step stops it, the error is
+// shown as notification.
+func RunPipeline(name string, steps []PipelineStep, vars PipelineVars) error {
+	start := time.Now()
+
+	output := ""
+
+	for k, v := range steps {
+		var err error
+
+		replace := func(s string, quote bool) string {
+			return expandPipeline(s, vars, output, quote)
+		}
+
+		switch {
+		case v.Run != "":
+			var out string
+
+			out, err = runPipelineStep(name, replace(v.Run, true), output, strings.Contains(v.Run, "%OUTPUT%"), v.Timeout)
+			if err == nil {
+				output = out
+			}
+		case v.Copy != "":
+			err = CopyCmd(replace(v.Copy, false)).Run()
+		case v.Notify != "":
+			_, err = Notify(Notification{Title: name, Body: replace(v.Notify, false)})
+		default:
+			err = errors.New("step without run, copy or notify")
+		}
+
+		if err == nil {
+			continue
+		}
+
+		err = fmt.Errorf("step %d: %w", k+1, err)
+
+		if v.IgnoreError && v.Run != "" {
+			slog.Warn("pipeline", "name", name, "ignored", err)
+			continue
+		}
+
+		slog.Error("pipeline", "name", name, "error", err)
+
+		Notify(Notification{Title: name, Body: err.Error(), Icon: "dialog-error", Urgency: "critical"})
+
+		return err
+	}
+
+	slog.Info("pipeline", "name", name, "steps", len(steps), "time", time.Since(start))
+
+	return nil
+}
+
+func runPipelineStep(provider, command, output string, usesOutput bool, timeout int) (string, error) {
+	if timeout <= 0 {
+		timeout = 30
+	}
+
+	cmd := HostShell(command)
+
+	if !usesOutput && output != "" {
+		cmd.Stdin = strings.NewReader(output)
+	}
+
+	out, err := Exec(provider, cmd, ExecOptions{Timeout: time.Duration(timeout) * time.Second})
+
+	return strings.TrimSpace(string(out)), err
+}
+
+func expandPipeline(s string, vars PipelineVars, output string, quote bool) string {
+	values := []string{
+		"%VALUE%", vars.Value,
+		"%QUERY%", vars.Query,
+		"%ARGS%", vars.Args,
+		"%TEXT%", vars.Text,
+		"%OUTPUT%", output,
+	}
+
+	if strings.Contains(s, "%CLIPBOARD%") {
+		values = append(values, "%CLIPBOARD%", ClipboardText())
+	}
+
+	if quote {
+		for i := 1; i < len(values); i += 2 {
+			values[i] = Quote(values[i])
+		}
+	}
+
+	return strings.NewReplacer(values...).Replace(s)
+}
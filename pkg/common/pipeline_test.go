@@ -0,0 +1,36 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRunPipeline(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	file := filepath.Join(t.TempDir(), "out")
+
+	steps := []PipelineStep{
+		{Run: "printf %s %VALUE%"},
+		{Run: "tr a-z A-Z"},
+		{Run: "false", IgnoreError: true},
+		{Run: "printf '%s %s' %OUTPUT% %ARGS% > " + file},
+	}
+
+	if err := RunPipeline("test", steps, PipelineVars{Value: "it's", Args: "a b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != "IT'S a b" {
+		t.Errorf("output = %q", b)
+	}
+}
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"al.essio.dev/pkg/shellescape"
+)
+
+// OpenCommand opens the file or url appended to it with the default application. It's meant to be run
+// with Shell.
+func OpenCommand() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "open"
+	case "windows":
+		return `start ""`
+	default:
+		return "xdg-open"
+	}
+}
+
+// CopyCommand copies its stdin to the clipboard. It's meant to be run with Shell.
+func CopyCommand() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "pbcopy"
+	case "windows":
+		return "clip"
+	}
+
+	if X11() {
+		return "xclip -selection clipboard"
+	}
+
+	return "wl-copy"
+}
+
+// PasteCommand prints the text of the clipboard.
+func PasteCommand() *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("pbpaste")
+	case "windows":
+		return exec.Command("powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw")
+	}
+
+	if X11() {
+		return exec.Command("xclip", "-selection", "clipboard", "-o")
+	}
+
+	return exec.Command("wl-paste", "-t", "text", "-n")
+}
+
+// CopyCmd copies the text to the clipboard.
+func CopyCmd(text string) *exec.Cmd {
+	cmd := Shell(CopyCommand())
+	cmd.Stdin = strings.NewReader(text)
+
+	return cmd
+}
+
+// Quote quotes the argument for Shell.
+func Quote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+
+	return shellescape.Quote(s)
+}
+
+// IsExecutable reports whether the file can be run. Windows has no executable bit, the extensions in
+// PATHEXT are checked instead.
+func IsExecutable(path string, info os.FileInfo) bool {
+	if runtime.GOOS != "windows" {
+		return info.Mode()&0o111 != 0
+	}
+
+	exts := os.Getenv("PATHEXT")
+	if exts == "" {
+		exts = ".COM;.EXE;.BAT;.CMD"
+	}
+
+	for v := range strings.SplitSeq(exts, ";") {
+		if v != "" && strings.EqualFold(filepath.Ext(path), v) {
+			return true
+		}
+	}
+
+	return false
+}
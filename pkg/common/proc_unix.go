@@ -0,0 +1,46 @@
+//go:build !windows
+
+package common
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Detach starts the command in its own session, so it doesn't end with elephant.
+func Detach(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Setsid = true
+}
+
+// Shell runs the command with sh.
+func Shell(command string) *exec.Cmd {
+	return exec.Command("sh", "-c", command)
+}
+
+// NewProcessGroup puts the command into its own process group, so signals also reach children of
+// shells.
+func NewProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Setpgid = true
+}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// Terminate asks the detached command and its children to exit.
+func Terminate(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+}
+
+// Interrupt interrupts the command started in its own process group, like ctrl+c in a terminal.
+func Interrupt(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGINT)
+}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+const detachedProcess = 0x00000008
+
+// Detach starts the command without console in its own process group, so it doesn't end with elephant.
+func Detach(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess
+}
+
+// Shell runs the command with cmd. The command line is passed as is, as cmd doesn't follow the quoting
+// rules of Go.
+func Shell(command string) *exec.Cmd {
+	cmd := exec.Command("cmd", "/C", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: "cmd /C " + command}
+
+	return cmd
+}
+
+// NewProcessGroup starts the command in its own process group.
+func NewProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// killProcessGroup kills the process and its children.
+func killProcessGroup(cmd *exec.Cmd) {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run(); err != nil {
+		cmd.Process.Kill()
+	}
+}
+
+// Terminate ends the detached command and its children. Console applications can't be asked to exit, so
+// they're killed.
+func Terminate(cmd *exec.Cmd) error {
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
+
+// Interrupt asks the command started in its own process group and its children to close.
+func Interrupt(cmd *exec.Cmd) error {
+	return exec.Command("taskkill", "/T", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
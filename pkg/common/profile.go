@@ -0,0 +1,47 @@
+package common
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// profile is the name of the selected configuration set, empty for the default one. Profiles have
+// their own socket and cache, so daemons of several profiles can run side by side.
+var profile = os.Getenv("ELEPHANT_PROFILE")
+
+// ProfilesDir is the folder within config dirs holding the configs of profiles.
+const ProfilesDir = "profiles"
+
+// SetProfile selects the profile. It has to be called before loading any config.
+func SetProfile(name string) {
+	profile = name
+	slog.Info("common", "profile", name)
+}
+
+func Profile() string {
+	return profile
+}
+
+// profileConfigDirs returns the config dirs of the profile within the given config dirs.
+func profileConfigDirs(dirs []string) []string {
+	res := []string{}
+
+	for _, v := range dirs {
+		if dir := filepath.Join(v, ProfilesDir, profile); FileExists(dir) {
+			res = append(res, dir)
+		}
+	}
+
+	return res
+}
+
+// SocketFile is the socket of the daemon of the selected profile, a named pipe on Windows.
+func SocketFile() string {
+	if profile != "" {
+		return socketPath(fmt.Sprintf("elephant-%s", profile))
+	}
+
+	return socketPath("elephant")
+}
@@ -1,65 +1,203 @@
 package common
 
 import (
+	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"os"
 	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"al.essio.dev/pkg/shellescape"
 )
 
-var runPrefix = ""
+// LaunchBackend puts launched applications into their own unit or session, so they don't end up as
+// children of elephant.
+type LaunchBackend struct {
+	Name   string
+	Prefix string
+	// Detect reports whether the backend is used when detecting automatically. Backends without are only
+	// used when configured.
+	Detect func() bool
+}
 
-func InitRunPrefix() {
-	if !elephantConfig.AutoDetectLaunchPrefix {
-		return
+var launchBackends = []LaunchBackend{
+	{
+		Name:   "app2unit",
+		Prefix: "app2unit",
+		Detect: func() bool {
+			return hostHas("app2unit") && hostHas("xdg-terminal-exec")
+		},
+	},
+	{
+		Name:   "uwsm",
+		Prefix: "uwsm-app --",
+		Detect: func() bool {
+			return hostHas("uwsm") && hostCommand("uwsm", "check", "is-active").Run() == nil
+		},
+	},
+	{
+		Name:   "niri",
+		Prefix: "niri msg action spawn --",
+		Detect: func() bool {
+			return os.Getenv("XDG_CURRENT_DESKTOP") == "niri" && hostHas("niri")
+		},
+	},
+	{
+		// a scope would inherit the sandbox of the service, a transient service doesn't
+		Name:   "systemd-service",
+		Prefix: "systemd-run --user --collect --quiet --",
+		Detect: func() bool {
+			return os.Getenv("SYSTEMD_EXEC_PID") != "" && sandboxed() && hostHas("systemd-run")
+		},
+	},
+	{
+		Name:   "systemd",
+		Prefix: "systemd-run --user --scope",
+		Detect: func() bool {
+			return os.Getenv("SYSTEMD_EXEC_PID") != "" && hostHas("systemd-run")
+		},
+	},
+}
+
+var (
+	runPrefix = ""
+	// hostPrefix runs commands outside of the Flatpak sandbox elephant runs in
+	hostPrefix = ""
+)
+
+// InFlatpak reports whether elephant runs inside a Flatpak sandbox.
+func InFlatpak() bool {
+	return FileExists("/.flatpak-info")
+}
+
+// sandboxed guesses whether the systemd service elephant runs in is sandboxed. Most sandboxing options
+// imply NoNewPrivileges.
+func sandboxed() bool {
+	b, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return false
 	}
 
-	app2unit, err := exec.LookPath("app2unit")
-	if err == nil && app2unit != "" {
-		xdgTerminalExec, err := exec.LookPath("xdg-terminal-exec")
-		if err == nil && xdgTerminalExec != "" {
-			runPrefix = "app2unit"
-			slog.Info("config", "runprefix autodetect", runPrefix)
-			return
+	for line := range strings.Lines(string(b)) {
+		if v, ok := strings.CutPrefix(line, "NoNewPrivs:"); ok {
+			return strings.TrimSpace(v) == "1"
 		}
 	}
 
-	uwsm, err := exec.LookPath("uwsm")
-	if err == nil {
-		cmd := exec.Command(uwsm, "check", "is-active")
-		err := cmd.Run()
-		if err == nil {
-			runPrefix = "uwsm-app --"
-			slog.Info("config", "runprefix autodetect", runPrefix)
-			return
-		}
+	return false
+}
+
+// hostCommand runs the command on the host, also when running in a Flatpak sandbox.
+func hostCommand(name string, args ...string) *exec.Cmd {
+	if hostPrefix != "" {
+		return exec.Command("flatpak-spawn", append([]string{"--host", name}, args...)...)
 	}
 
-	if os.Getenv("XDG_CURRENT_DESKTOP") == "niri" {
-		niri, err := exec.LookPath("niri")
-		if err == nil && niri != "" {
-			runPrefix = "niri msg action spawn --"
-			slog.Info("config", "runprefix autodetect", runPrefix)
-			return
-		}
+	return exec.Command(name, args...)
+}
+
+// hostHas reports whether the executable exists on the host.
+func hostHas(name string) bool {
+	if hostPrefix != "" {
+		return hostCommand("sh", "-c", fmt.Sprintf("command -v %s", name)).Run() == nil
 	}
 
-	spid := os.Getenv("SYSTEMD_EXEC_PID")
-	if spid != "" {
-		systemdrun, err := exec.LookPath("systemd-run")
-		if err == nil && systemdrun != "" {
-			runPrefix = "systemd-run --user --scope"
-			slog.Info("config", "runprefix autodetect", runPrefix)
-			return
-		}
+	_, err := exec.LookPath(name)
+
+	return err == nil
+}
+
+func InitRunPrefix() {
+	if InFlatpak() {
+		hostPrefix = "flatpak-spawn --host"
+		slog.Info("config", "flatpak", "launching via flatpak-spawn --host")
 	}
 
-	slog.Info("config", "runprefix autodetect", "<empty>")
+	backend := elephantConfig.LaunchBackend
+
+	switch backend {
+	case "none", "":
+		slog.Info("config", "runprefix", "<empty>")
+		return
+	case "auto":
+		for _, v := range launchBackends {
+			if v.Detect != nil && v.Detect() {
+				runPrefix = v.Prefix
+				slog.Info("config", "runprefix autodetect", runPrefix)
+				return
+			}
+		}
+
+		slog.Info("config", "runprefix autodetect", "<empty>")
+	default:
+		for _, v := range launchBackends {
+			if v.Name == backend {
+				runPrefix = v.Prefix
+				slog.Info("config", "runprefix", runPrefix)
+				return
+			}
+		}
+
+		slog.Error("config", "launch_backend", fmt.Sprintf("unknown backend: %s", backend))
+	}
 }
 
+// LaunchPrefix returns the prefix for launching applications. The override, f.e. the launch_prefix of a
+// provider, replaces the detected backend, but not escaping a Flatpak sandbox.
 func LaunchPrefix(override string) string {
+	prefix := runPrefix
+
 	if override != "" {
-		return override
+		prefix = override
+	}
+
+	return strings.TrimSpace(fmt.Sprintf("%s %s", hostPrefix, prefix))
+}
+
+var unitNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_.:]+`)
+
+// ScopedLaunchPrefix is LaunchPrefix for launching the item of a provider. If scopes are enabled, the
+// application gets a transient systemd scope, f.e. 'app-elephant-desktopapplications_firefox-1a2b3c.scope',
+// so it's tracked and survives restarts of elephant.
+func ScopedLaunchPrefix(override, provider, item string) string {
+	if elephantConfig == nil || !elephantConfig.Scopes.Enabled || override != "" || runtime.GOOS != "linux" {
+		return LaunchPrefix(override)
+	}
+
+	cfg := elephantConfig.Scopes
+
+	id := unitNameInvalid.ReplaceAllString(fmt.Sprintf("%s_%s", provider, item), "_")
+	if len(id) > 64 {
+		id = id[:64]
+	}
+
+	args := []string{
+		"systemd-run", "--user", "--scope", "--collect", "--quiet",
+		fmt.Sprintf("--unit=app-elephant-%s-%x.scope", id, rand.Uint32()),
+	}
+
+	if cfg.Slice != "" {
+		args = append(args, fmt.Sprintf("--slice=%s", shellescape.Quote(cfg.Slice)))
+	}
+
+	for _, v := range cfg.Properties {
+		args = append(args, "-p", shellescape.Quote(v))
+	}
+
+	args = append(args, "--")
+
+	return strings.TrimSpace(fmt.Sprintf("%s %s", hostPrefix, strings.Join(args, " ")))
+}
+
+// HostShell runs the command with sh on the host, also when running in a Flatpak sandbox. Applications
+// are launched with LaunchPrefix instead, so they get their own unit.
+func HostShell(command string) *exec.Cmd {
+	if hostPrefix == "" {
+		return Shell(command)
 	}
 
-	return runPrefix
+	return hostCommand("sh", "-c", command)
 }
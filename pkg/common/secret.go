@@ -0,0 +1,101 @@
+package common
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Secret is a config value for tokens and passwords. Besides the plain value it can reference where
+// to get it from:
+//
+//	env:NAME                      the environment variable NAME
+//	cmd:pass show jira            the output of a command
+//	libsecret:service jira        the item with these attributes, looked up with secret-tool
+//	plain:env:...                 a plain value starting with one of the prefixes
+//
+// Plain values are redacted when printed or logged, references are shown as they are.
+type Secret string
+
+const (
+	secretEnv       = "env:"
+	secretCmd       = "cmd:"
+	secretLibsecret = "libsecret:"
+	secretPlain     = "plain:"
+	redacted        = "[redacted]"
+)
+
+// secretOptions allow for entering a passphrase, f.e. for pass, and reuse the value for a while.
+var secretOptions = ExecOptions{Timeout: time.Minute, CacheFor: 5 * time.Minute}
+
+// Resolve returns the value of the secret.
+func (s Secret) Resolve() (string, error) {
+	v := string(s)
+
+	switch {
+	case strings.HasPrefix(v, secretEnv):
+		name := strings.TrimPrefix(v, secretEnv)
+
+		res, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret: %s not set", name)
+		}
+
+		return res, nil
+	case strings.HasPrefix(v, secretCmd):
+		out, err := ExecShell("secret", strings.TrimPrefix(v, secretCmd), secretOptions)
+		if err != nil {
+			return "", fmt.Errorf("secret: %s: %w", v, err)
+		}
+
+		return strings.TrimSpace(string(out)), nil
+	case strings.HasPrefix(v, secretLibsecret):
+		attributes := strings.Fields(strings.TrimPrefix(v, secretLibsecret))
+		if len(attributes) == 0 || len(attributes)%2 != 0 {
+			return "", fmt.Errorf("secret: %s: expected pairs of attribute and value", v)
+		}
+
+		out, err := Exec("secret", exec.Command("secret-tool", append([]string{"lookup"}, attributes...)...), secretOptions)
+		if err != nil {
+			return "", fmt.Errorf("secret: %s: %w", v, err)
+		}
+
+		return strings.TrimSpace(string(out)), nil
+	default:
+		return strings.TrimPrefix(v, secretPlain), nil
+	}
+}
+
+// IsSet reports whether a value or reference is configured.
+func (s Secret) IsSet() bool {
+	return s != ""
+}
+
+func (s Secret) String() string {
+	v := string(s)
+
+	switch {
+	case v == "":
+		return ""
+	case strings.HasPrefix(v, secretEnv), strings.HasPrefix(v, secretCmd), strings.HasPrefix(v, secretLibsecret):
+		return v
+	default:
+		return redacted
+	}
+}
+
+func (s Secret) GoString() string {
+	return fmt.Sprintf("common.Secret(%q)", s.String())
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// MarshalText redacts the secret when configs are encoded, f.e. as TOML or JSON.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
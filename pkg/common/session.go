@@ -0,0 +1,53 @@
+package common
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+)
+
+// X11 reports whether elephant runs in an X11 session. Wayland tools don't work there, so their X11
+// equivalents are used instead: xclip for wl-clipboard, maim for grim and slurp, xdotool for wtype.
+var X11 = sync.OnceValue(func() bool {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		return false
+	}
+
+	switch os.Getenv("XDG_SESSION_TYPE") {
+	case "x11":
+		return true
+	case "wayland":
+		return false
+	}
+
+	return os.Getenv("WAYLAND_DISPLAY") == "" && os.Getenv("DISPLAY") != ""
+})
+
+// TypeCommand types its stdin into the focused window. It's meant to be run with Shell.
+func TypeCommand() string {
+	if X11() {
+		return "xdotool type --file -"
+	}
+
+	return "wtype -"
+}
+
+// CaptureCommand prints a region of the screen selected by the user as png. It's meant to be run with
+// Shell.
+func CaptureCommand() string {
+	if X11() {
+		return "maim -s"
+	}
+
+	return `grim -g "$(slurp)" -`
+}
+
+// ClearClipboard empties the clipboard.
+func ClearClipboard() *exec.Cmd {
+	if CopyCommand() == "wl-copy" {
+		return exec.Command("wl-copy", "--clear")
+	}
+
+	return CopyCmd("")
+}
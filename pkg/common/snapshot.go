@@ -0,0 +1,111 @@
+package common
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotHeader precedes the data. Snapshots of another version are ignored, so providers can put
+// everything the data depends on into the version, f.e. the locale or a schema number.
+type snapshotHeader struct {
+	Version string
+	Time    time.Time
+}
+
+func snapshotFile(name string) string {
+	return CacheFile(filepath.Join("snapshots", fmt.Sprintf("%s.gob", name)))
+}
+
+// SnapshotsEnabled reports whether snapshots are enabled in the config.
+func SnapshotsEnabled() bool {
+	if c := GetElephantConfig(); c != nil {
+		return c.Snapshots
+	}
+
+	return true
+}
+
+// SaveSnapshot persists v as the snapshot of the given name in the cache dir, so providers can serve it
+// right after starting while refreshing it in the background.
+func SaveSnapshot(name, version string, v any) {
+	if !SnapshotsEnabled() {
+		return
+	}
+
+	var b bytes.Buffer
+	encoder := gob.NewEncoder(&b)
+
+	if err := encoder.Encode(snapshotHeader{Version: version, Time: time.Now()}); err != nil {
+		slog.Error("snapshot", "encode", err, "name", name)
+		return
+	}
+
+	if err := encoder.Encode(v); err != nil {
+		slog.Error("snapshot", "encode", err, "name", name)
+		return
+	}
+
+	path := snapshotFile(name)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		slog.Error("snapshot", "createdirs", err)
+		return
+	}
+
+	// written to a temp file first, so a crash can't leave a truncated snapshot behind
+	tmp := fmt.Sprintf("%s.tmp", path)
+
+	if err := os.WriteFile(tmp, b.Bytes(), 0o600); err != nil {
+		slog.Error("snapshot", "writefile", err, "name", name)
+		return
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		slog.Error("snapshot", "rename", err, "name", name)
+	}
+}
+
+// LoadSnapshot decodes the snapshot of the given name into v. It returns false if there's no snapshot of the
+// version, in which case v must not be used.
+func LoadSnapshot(name, version string, v any) bool {
+	if !SnapshotsEnabled() {
+		return false
+	}
+
+	b, err := os.ReadFile(snapshotFile(name))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Error("snapshot", "readfile", err, "name", name)
+		}
+
+		return false
+	}
+
+	decoder := gob.NewDecoder(bytes.NewReader(b))
+
+	var h snapshotHeader
+
+	if err := decoder.Decode(&h); err != nil {
+		slog.Error("snapshot", "decode", err, "name", name)
+		return false
+	}
+
+	if h.Version != version {
+		slog.Info("snapshot", "name", name, "outdated", h.Version)
+		return false
+	}
+
+	if err := decoder.Decode(v); err != nil {
+		slog.Error("snapshot", "decode", err, "name", name)
+		return false
+	}
+
+	slog.Info("snapshot", "name", name, "age", time.Since(h.Time).Round(time.Second))
+
+	return true
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+
+package common
+
+import (
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// socketPath is the unix socket with the given name in the runtime dir.
+func socketPath(name string) string {
+	rd := os.Getenv("XDG_RUNTIME_DIR")
+
+	if rd == "" {
+		slog.Error("socket", "runtimedir", "XDG_RUNTIME_DIR not set. falling back to /tmp")
+		rd = os.TempDir()
+	}
+
+	return filepath.Join(rd, "elephant", name+".sock")
+}
+
+// Listen creates the socket at path, replacing a stale one of a previous run.
+func Listen(path string) (net.Listener, error) {
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	os.Remove(path)
+
+	return net.Listen("unix", path)
+}
+
+// Dial connects to the daemon of the selected profile.
+func Dial() (net.Conn, error) {
+	return net.Dial("unix", SocketFile())
+}
@@ -0,0 +1,23 @@
+package common
+
+import (
+	"net"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// socketPath is the named pipe with the given name, as Windows has no unix sockets usable by all
+// clients.
+func socketPath(name string) string {
+	return `\\.\pipe\` + name
+}
+
+// Listen creates the named pipe at path.
+func Listen(path string) (net.Listener, error) {
+	return winio.ListenPipe(path, nil)
+}
+
+// Dial connects to the daemon of the selected profile.
+func Dial() (net.Conn, error) {
+	return winio.DialPipe(SocketFile(), nil)
+}
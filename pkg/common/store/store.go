@@ -0,0 +1,291 @@
+// Package store provides a managed SQLite database per provider.
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/abenz1267/elephant/v2/pkg/common"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Store is a providers database. Statements are prepared once and reused.
+type Store struct {
+	*sql.DB
+	Name string
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+const dsn = "?_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=5000&_foreign_keys=on&_cache_size=10000&_temp_store=memory"
+
+var (
+	storesMu sync.Mutex
+	stores   = make(map[string]*Store)
+)
+
+// File is the location of the providers database.
+func File(name string) string {
+	return common.CacheFile(fmt.Sprintf("%s.db", name))
+}
+
+// Open opens the providers database and applies pending migrations. Migrations are applied in order and tracked
+// via the user_version pragma, so they must only ever be appended.
+func Open(name string, migrations ...string) (*Store, error) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	if s, ok := stores[name]; ok {
+		return s, nil
+	}
+
+	file := File(name)
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", file+dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		DB:    db,
+		Name:  name,
+		stmts: make(map[string]*sql.Stmt),
+	}
+
+	if err := s.migrate(migrations); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: migrate: %w", name, err)
+	}
+
+	stores[name] = s
+
+	return s, nil
+}
+
+func (s *Store) migrate(migrations []string) error {
+	var version int
+
+	if err := s.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	if version > len(migrations) {
+		return fmt.Errorf("database version %d is newer than the provider (%d)", version, len(migrations))
+	}
+
+	for i, m := range migrations[version:] {
+		err := s.Tx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(m); err != nil {
+				return err
+			}
+
+			// pragmas can't be parameterized
+			_, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+i+1))
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", version+i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// Stmt returns the prepared statement for the query, preparing it on first use.
+func (s *Store) Stmt(query string) (*sql.Stmt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stmt, ok := s.stmts[query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := s.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.stmts[query] = stmt
+
+	return stmt, nil
+}
+
+// Exec runs the query as a prepared statement.
+func (s *Store) Exec(query string, args ...any) (sql.Result, error) {
+	stmt, err := s.Stmt(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.Exec(args...)
+}
+
+// Query runs the query as a prepared statement.
+func (s *Store) Query(query string, args ...any) (*sql.Rows, error) {
+	stmt, err := s.Stmt(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.Query(args...)
+}
+
+// QueryRow runs the query as a prepared statement.
+func (s *Store) QueryRow(query string, args ...any) *sql.Row {
+	stmt, err := s.Stmt(query)
+	if err != nil {
+		// let the error surface on Scan
+		return s.DB.QueryRow(query, args...)
+	}
+
+	return stmt.QueryRow(args...)
+}
+
+// Pages runs the query in pages of size rows, so large tables can be read without loading all of them
+// at once. LIMIT and OFFSET are appended to the query, which must have a stable ORDER BY.
+func (s *Store) Pages(query string, size int, fn func(rows *sql.Rows) error, args ...any) error {
+	query = fmt.Sprintf("%s LIMIT ? OFFSET ?", query)
+
+	for offset := 0; ; offset += size {
+		rows, err := s.Query(query, append(args, size, offset)...)
+		if err != nil {
+			return err
+		}
+
+		n := 0
+
+		for rows.Next() {
+			n++
+
+			if err := fn(rows); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+
+		err = rows.Err()
+		rows.Close()
+
+		if err != nil {
+			return err
+		}
+
+		if n < size {
+			return nil
+		}
+	}
+}
+
+// Tx runs fn in a transaction, rolling back if it returns an error.
+func (s *Store) Tx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Release closes the idle connections, which frees their page caches. Connections are opened again when
+// needed.
+func (s *Store) Release() {
+	s.SetMaxIdleConns(0)
+	// the default of database/sql
+	s.SetMaxIdleConns(2)
+}
+
+// Close closes the prepared statements and the database.
+func (s *Store) Close() error {
+	storesMu.Lock()
+	delete(stores, s.Name)
+	storesMu.Unlock()
+
+	s.mu.Lock()
+	for _, v := range s.stmts {
+		v.Close()
+	}
+
+	clear(s.stmts)
+	s.mu.Unlock()
+
+	return s.DB.Close()
+}
+
+// Remove deletes the providers database, f.e. for pure caches that get rebuilt on start.
+func Remove(name string) error {
+	file := File(name)
+
+	var errs []error
+
+	for _, v := range []string{file, file + "-wal", file + "-shm"} {
+		if err := os.Remove(v); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// List returns the names of all provider databases.
+func List() []string {
+	files, err := filepath.Glob(common.CacheFile("*.db"))
+	if err != nil {
+		return nil
+	}
+
+	res := []string{}
+
+	for _, v := range files {
+		res = append(res, strings.TrimSuffix(filepath.Base(v), ".db"))
+	}
+
+	slices.Sort(res)
+
+	return res
+}
+
+// Backup writes a consistent copy of the providers database to dest. This is safe while elephant is running.
+func Backup(name, dest string) error {
+	file := File(name)
+
+	if !common.FileExists(file) {
+		return fmt.Errorf("no database for %s", name)
+	}
+
+	if common.FileExists(dest) {
+		return fmt.Errorf("%s already exists", dest)
+	}
+
+	db, err := openReadOnly(file)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec("VACUUM INTO ?", dest)
+
+	return err
+}
+
+// openReadOnly opens the database without taking write locks. Parameters without underscore, like mode, are
+// only passed on to SQLite for "file:" URIs.
+func openReadOnly(file string) (*sql.DB, error) {
+	return sql.Open("sqlite3", "file:"+file+"?mode=ro&_busy_timeout=5000")
+}
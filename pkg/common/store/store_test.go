@@ -0,0 +1,60 @@
+package store
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBackup(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	s, err := Open("backup", "CREATE TABLE items (name TEXT);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if _, err := s.Exec("INSERT INTO items (name) VALUES ('a')"); err != nil {
+		t.Fatal(err)
+	}
+
+	ro, err := openReadOnly(File("backup"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+
+	if _, err := ro.Exec("INSERT INTO items (name) VALUES ('b')"); err == nil || !strings.Contains(err.Error(), "readonly") {
+		t.Errorf("backup connection can write: %v", err)
+	}
+
+	// a running write transaction of the daemon doesn't block the backup
+	tx, err := s.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO items (name) VALUES ('c')"); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "backup.db")
+
+	if err := Backup("backup", dest); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := openReadOnly(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	var n int
+
+	if err := b.QueryRow("SELECT COUNT(*) FROM items").Scan(&n); err != nil || n != 1 {
+		t.Errorf("backup has %d items: %v", n, err)
+	}
+}
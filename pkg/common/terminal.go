@@ -9,8 +9,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
-	"syscall"
 
 	"github.com/adrg/xdg"
 	"github.com/charlievieth/fastwalk"
@@ -84,6 +84,13 @@ func GetTerminal() string {
 }
 
 func WrapWithTerminal(in string) string {
+	switch runtime.GOOS {
+	case "darwin":
+		return fmt.Sprintf("osascript -e %s", Quote(fmt.Sprintf(`tell application "Terminal" to do script %q`, in)))
+	case "windows":
+		return fmt.Sprintf(`start "" cmd /K %s`, in)
+	}
+
 	if terminal == "" {
 		return in
 	}
@@ -121,11 +128,14 @@ func findTerminalApps() {
 }
 
 func ForceTerminalForFile(file string) bool {
-	cmd := exec.Command("sh", "-c", fmt.Sprintf("xdg-mime query default $(xdg-mime query filetype %s)", file))
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setsid: true,
+	// desktop entries and xdg-mime only exist on linux and the BSDs
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		return false
 	}
 
+	cmd := exec.Command("sh", "-c", fmt.Sprintf("xdg-mime query default $(xdg-mime query filetype %s)", file))
+	Detach(cmd)
+
 	homedir, err := os.UserHomeDir()
 	if err != nil {
 		log.Panic(err)
@@ -0,0 +1,92 @@
+package common
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// ThumbnailSize is the max width and height of thumbnails sent to clients.
+const ThumbnailSize = 128
+
+// thumbnails larger than this aren't sent, to keep responses small.
+const maxThumbnailBytes = 64 << 10
+
+var (
+	thumbnailClients sync.Map
+	thumbnailCache   = make(map[string][]byte)
+	thumbnailMu      sync.Mutex
+)
+
+// SetWantsThumbnails stores whether the client connected via conn asked for inline thumbnails.
+func SetWantsThumbnails(conn net.Conn, v bool) {
+	if v {
+		thumbnailClients.Store(client(conn), true)
+	} else {
+		thumbnailClients.Delete(client(conn))
+	}
+}
+
+// WantsThumbnails reports whether providers should put thumbnails into items for this client. Clients
+// running on the same machine usually don't need them, as they can read the files.
+func WantsThumbnails(conn net.Conn) bool {
+	if conn == nil {
+		return false
+	}
+
+	_, ok := thumbnailClients.Load(client(conn))
+
+	return ok
+}
+
+// Thumbnail returns a small png of the image file, created with imagemagick. Results are cached until
+// the file changes. Returns nil if the thumbnail can't be created or is too big.
+func Thumbnail(file string) []byte {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s:%d:%d", file, info.ModTime().UnixNano(), info.Size())
+
+	thumbnailMu.Lock()
+	b, ok := thumbnailCache[key]
+	thumbnailMu.Unlock()
+
+	if ok {
+		return b
+	}
+
+	bin := "magick"
+
+	if _, err := exec.LookPath(bin); err != nil {
+		bin = "convert"
+	}
+
+	size := fmt.Sprintf("%dx%d>", ThumbnailSize, ThumbnailSize)
+
+	// only the first frame of animations
+	out, err := exec.Command(bin, file+"[0]", "-thumbnail", size, "-strip", "png:-").Output()
+	if err != nil {
+		slog.Error("thumbnail", "create", err, "file", file)
+		out = nil
+	}
+
+	if len(out) > maxThumbnailBytes {
+		out = nil
+	}
+
+	thumbnailMu.Lock()
+	// the cache isn't worth an lru, it's only meant for repeated queries.
+	if len(thumbnailCache) > 1000 {
+		clear(thumbnailCache)
+	}
+
+	thumbnailCache[key] = out
+	thumbnailMu.Unlock()
+
+	return out
+}
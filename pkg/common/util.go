@@ -8,17 +8,27 @@ import (
 
 func ReplaceResultOrStdinCmd(replace, result string) *exec.Cmd {
 	if !strings.Contains(replace, "%VALUE%") {
-		cmd := exec.Command("sh", "-c", replace)
+		cmd := Shell(replace)
 
 		cmd.Stdin = strings.NewReader(result)
 		return cmd
 	}
 
-	return exec.Command("sh", "-c", strings.ReplaceAll(replace, "%VALUE%", result))
+	return Shell(strings.ReplaceAll(replace, "%VALUE%", result))
+}
+
+// QuoteResultOrStdinCmd is ReplaceResultOrStdinCmd with the result quoted for the shell, for text that
+// isn't trusted, f.e. the output of other programs.
+func QuoteResultOrStdinCmd(replace, result string) *exec.Cmd {
+	if strings.Contains(replace, "%VALUE%") {
+		result = Quote(result)
+	}
+
+	return ReplaceResultOrStdinCmd(replace, result)
 }
 
 func ClipboardText() string {
-	cmd := exec.Command("wl-paste", "-t", "text", "-n")
+	cmd := PasteCommand()
 
 	out, err := cmd.CombinedOutput()
 	if err != nil {
@@ -1,3 +1,5 @@
+//go:build linux
+
 // This file is autogenerated from: wlr-foreign-toplevel-management-unstable-v1.xml
 // Do not edit
 
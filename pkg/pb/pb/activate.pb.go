@@ -29,6 +29,7 @@ type ActivateRequest struct {
 	Query         string                 `protobuf:"bytes,4,opt,name=query,proto3" json:"query,omitempty"`
 	Arguments     string                 `protobuf:"bytes,5,opt,name=arguments,proto3" json:"arguments,omitempty"`
 	Single        bool                   `protobuf:"varint,6,opt,name=single,proto3" json:"single,omitempty"`
+	Identifiers   []string               `protobuf:"bytes,7,rep,name=identifiers,proto3" json:"identifiers,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -105,11 +106,18 @@ func (x *ActivateRequest) GetSingle() bool {
 	return false
 }
 
+func (x *ActivateRequest) GetIdentifiers() []string {
+	if x != nil {
+		return x.Identifiers
+	}
+	return nil
+}
+
 var File_activate_proto protoreflect.FileDescriptor
 
 const file_activate_proto_rawDesc = "" +
 	"\n" +
-	"\x0eactivate.proto\x12\x02pb\"\xb1\x01\n" +
+	"\x0eactivate.proto\x12\x02pb\"\xd3\x01\n" +
 	"\x0fActivateRequest\x12\x1a\n" +
 	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x1e\n" +
 	"\n" +
@@ -118,7 +126,8 @@ const file_activate_proto_rawDesc = "" +
 	"\x06action\x18\x03 \x01(\tR\x06action\x12\x14\n" +
 	"\x05query\x18\x04 \x01(\tR\x05query\x12\x1c\n" +
 	"\targuments\x18\x05 \x01(\tR\targuments\x12\x16\n" +
-	"\x06single\x18\x06 \x01(\bR\x06singleB\x06Z\x04./pbb\x06proto3"
+	"\x06single\x18\x06 \x01(\bR\x06single\x12 \n" +
+	"\videntifiers\x18\a \x03(\tR\videntifiersB\x06Z\x04./pbb\x06proto3"
 
 var (
 	file_activate_proto_rawDescOnce sync.Once
@@ -64,7 +64,7 @@ func (x QueryResponse_Type) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use QueryResponse_Type.Descriptor instead.
 func (QueryResponse_Type) EnumDescriptor() ([]byte, []int) {
-	return file_query_proto_rawDescGZIP(), []int{1, 0}
+	return file_query_proto_rawDescGZIP(), []int{3, 0}
 }
 
 type QueryRequest struct {
@@ -73,6 +73,9 @@ type QueryRequest struct {
 	Query         string                 `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
 	Maxresults    int32                  `protobuf:"varint,3,opt,name=maxresults,proto3" json:"maxresults,omitempty"`
 	Exactsearch   bool                   `protobuf:"varint,4,opt,name=exactsearch,proto3" json:"exactsearch,omitempty"`
+	Thumbnails    bool                   `protobuf:"varint,5,opt,name=thumbnails,proto3" json:"thumbnails,omitempty"`
+	Grouped       bool                   `protobuf:"varint,6,opt,name=grouped,proto3" json:"grouped,omitempty"`
+	Dashboard     bool                   `protobuf:"varint,7,opt,name=dashboard,proto3" json:"dashboard,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -135,6 +138,187 @@ func (x *QueryRequest) GetExactsearch() bool {
 	return false
 }
 
+func (x *QueryRequest) GetThumbnails() bool {
+	if x != nil {
+		return x.Thumbnails
+	}
+	return false
+}
+
+func (x *QueryRequest) GetGrouped() bool {
+	if x != nil {
+		return x.Grouped
+	}
+	return false
+}
+
+func (x *QueryRequest) GetDashboard() bool {
+	if x != nil {
+		return x.Dashboard
+	}
+	return false
+}
+
+type ActionDescriptor struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Action        string                 `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"`
+	Label         string                 `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	Icon          string                 `protobuf:"bytes,3,opt,name=icon,proto3" json:"icon,omitempty"`
+	Default       bool                   `protobuf:"varint,4,opt,name=default,proto3" json:"default,omitempty"`
+	Arguments     []*ActionArgument      `protobuf:"bytes,5,rep,name=arguments,proto3" json:"arguments,omitempty"`
+	Multiple      bool                   `protobuf:"varint,6,opt,name=multiple,proto3" json:"multiple,omitempty"`
+	Keybinding    string                 `protobuf:"bytes,7,opt,name=keybinding,proto3" json:"keybinding,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ActionDescriptor) Reset() {
+	*x = ActionDescriptor{}
+	mi := &file_query_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ActionDescriptor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActionDescriptor) ProtoMessage() {}
+
+func (x *ActionDescriptor) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActionDescriptor.ProtoReflect.Descriptor instead.
+func (*ActionDescriptor) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ActionDescriptor) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *ActionDescriptor) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *ActionDescriptor) GetIcon() string {
+	if x != nil {
+		return x.Icon
+	}
+	return ""
+}
+
+func (x *ActionDescriptor) GetDefault() bool {
+	if x != nil {
+		return x.Default
+	}
+	return false
+}
+
+func (x *ActionDescriptor) GetArguments() []*ActionArgument {
+	if x != nil {
+		return x.Arguments
+	}
+	return nil
+}
+
+func (x *ActionDescriptor) GetMultiple() bool {
+	if x != nil {
+		return x.Multiple
+	}
+	return false
+}
+
+func (x *ActionDescriptor) GetKeybinding() string {
+	if x != nil {
+		return x.Keybinding
+	}
+	return ""
+}
+
+type ActionArgument struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Placeholder   string                 `protobuf:"bytes,3,opt,name=placeholder,proto3" json:"placeholder,omitempty"`
+	Required      bool                   `protobuf:"varint,4,opt,name=required,proto3" json:"required,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ActionArgument) Reset() {
+	*x = ActionArgument{}
+	mi := &file_query_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ActionArgument) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActionArgument) ProtoMessage() {}
+
+func (x *ActionArgument) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActionArgument.ProtoReflect.Descriptor instead.
+func (*ActionArgument) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ActionArgument) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ActionArgument) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ActionArgument) GetPlaceholder() string {
+	if x != nil {
+		return x.Placeholder
+	}
+	return ""
+}
+
+func (x *ActionArgument) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
 type QueryResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
@@ -146,7 +330,7 @@ type QueryResponse struct {
 
 func (x *QueryResponse) Reset() {
 	*x = QueryResponse{}
-	mi := &file_query_proto_msgTypes[1]
+	mi := &file_query_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -158,7 +342,7 @@ func (x *QueryResponse) String() string {
 func (*QueryResponse) ProtoMessage() {}
 
 func (x *QueryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_query_proto_msgTypes[1]
+	mi := &file_query_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -171,7 +355,7 @@ func (x *QueryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryResponse.ProtoReflect.Descriptor instead.
 func (*QueryResponse) Descriptor() ([]byte, []int) {
-	return file_query_proto_rawDescGZIP(), []int{1}
+	return file_query_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *QueryResponse) GetQuery() string {
@@ -196,27 +380,31 @@ func (x *QueryResponse) GetQid() int32 {
 }
 
 type QueryResponse_Item struct {
-	state         protoimpl.MessageState        `protogen:"open.v1"`
-	Identifier    string                        `protobuf:"bytes,1,opt,name=identifier,proto3" json:"identifier,omitempty"`
-	Text          string                        `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
-	Subtext       string                        `protobuf:"bytes,3,opt,name=subtext,proto3" json:"subtext,omitempty"`
-	Icon          string                        `protobuf:"bytes,4,opt,name=icon,proto3" json:"icon,omitempty"`
-	Provider      string                        `protobuf:"bytes,5,opt,name=provider,proto3" json:"provider,omitempty"`
-	Score         int32                         `protobuf:"varint,6,opt,name=score,proto3" json:"score,omitempty"`
-	Fuzzyinfo     *QueryResponse_Item_FuzzyInfo `protobuf:"bytes,7,opt,name=fuzzyinfo,proto3" json:"fuzzyinfo,omitempty"`
-	Type          QueryResponse_Type            `protobuf:"varint,8,opt,name=type,proto3,enum=pb.QueryResponse_Type" json:"type,omitempty"`
-	Mimetype      string                        `protobuf:"bytes,9,opt,name=mimetype,proto3" json:"mimetype,omitempty"`
-	Preview       string                        `protobuf:"bytes,10,opt,name=preview,proto3" json:"preview,omitempty"`
-	PreviewType   string                        `protobuf:"bytes,11,opt,name=preview_type,json=previewType,proto3" json:"preview_type,omitempty"`
-	State         []string                      `protobuf:"bytes,12,rep,name=state,proto3" json:"state,omitempty"`
-	Actions       []string                      `protobuf:"bytes,13,rep,name=actions,proto3" json:"actions,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state             protoimpl.MessageState        `protogen:"open.v1"`
+	Identifier        string                        `protobuf:"bytes,1,opt,name=identifier,proto3" json:"identifier,omitempty"`
+	Text              string                        `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Subtext           string                        `protobuf:"bytes,3,opt,name=subtext,proto3" json:"subtext,omitempty"`
+	Icon              string                        `protobuf:"bytes,4,opt,name=icon,proto3" json:"icon,omitempty"`
+	Provider          string                        `protobuf:"bytes,5,opt,name=provider,proto3" json:"provider,omitempty"`
+	Score             int32                         `protobuf:"varint,6,opt,name=score,proto3" json:"score,omitempty"`
+	Fuzzyinfo         *QueryResponse_Item_FuzzyInfo `protobuf:"bytes,7,opt,name=fuzzyinfo,proto3" json:"fuzzyinfo,omitempty"`
+	Type              QueryResponse_Type            `protobuf:"varint,8,opt,name=type,proto3,enum=pb.QueryResponse_Type" json:"type,omitempty"`
+	Mimetype          string                        `protobuf:"bytes,9,opt,name=mimetype,proto3" json:"mimetype,omitempty"`
+	Preview           string                        `protobuf:"bytes,10,opt,name=preview,proto3" json:"preview,omitempty"`
+	PreviewType       string                        `protobuf:"bytes,11,opt,name=preview_type,json=previewType,proto3" json:"preview_type,omitempty"`
+	State             []string                      `protobuf:"bytes,12,rep,name=state,proto3" json:"state,omitempty"`
+	Actions           []string                      `protobuf:"bytes,13,rep,name=actions,proto3" json:"actions,omitempty"`
+	Thumbnail         []byte                        `protobuf:"bytes,14,opt,name=thumbnail,proto3" json:"thumbnail,omitempty"`
+	Group             string                        `protobuf:"bytes,15,opt,name=group,proto3" json:"group,omitempty"`
+	ActionDescriptors []*ActionDescriptor           `protobuf:"bytes,16,rep,name=action_descriptors,json=actionDescriptors,proto3" json:"action_descriptors,omitempty"`
+	LiveInterval      int32                         `protobuf:"varint,17,opt,name=live_interval,json=liveInterval,proto3" json:"live_interval,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *QueryResponse_Item) Reset() {
 	*x = QueryResponse_Item{}
-	mi := &file_query_proto_msgTypes[2]
+	mi := &file_query_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -228,7 +416,7 @@ func (x *QueryResponse_Item) String() string {
 func (*QueryResponse_Item) ProtoMessage() {}
 
 func (x *QueryResponse_Item) ProtoReflect() protoreflect.Message {
-	mi := &file_query_proto_msgTypes[2]
+	mi := &file_query_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -241,7 +429,7 @@ func (x *QueryResponse_Item) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryResponse_Item.ProtoReflect.Descriptor instead.
 func (*QueryResponse_Item) Descriptor() ([]byte, []int) {
-	return file_query_proto_rawDescGZIP(), []int{1, 0}
+	return file_query_proto_rawDescGZIP(), []int{3, 0}
 }
 
 func (x *QueryResponse_Item) GetIdentifier() string {
@@ -335,6 +523,34 @@ func (x *QueryResponse_Item) GetActions() []string {
 	return nil
 }
 
+func (x *QueryResponse_Item) GetThumbnail() []byte {
+	if x != nil {
+		return x.Thumbnail
+	}
+	return nil
+}
+
+func (x *QueryResponse_Item) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *QueryResponse_Item) GetActionDescriptors() []*ActionDescriptor {
+	if x != nil {
+		return x.ActionDescriptors
+	}
+	return nil
+}
+
+func (x *QueryResponse_Item) GetLiveInterval() int32 {
+	if x != nil {
+		return x.LiveInterval
+	}
+	return 0
+}
+
 type QueryResponse_Item_FuzzyInfo struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Start         int32                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
@@ -346,7 +562,7 @@ type QueryResponse_Item_FuzzyInfo struct {
 
 func (x *QueryResponse_Item_FuzzyInfo) Reset() {
 	*x = QueryResponse_Item_FuzzyInfo{}
-	mi := &file_query_proto_msgTypes[3]
+	mi := &file_query_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -358,7 +574,7 @@ func (x *QueryResponse_Item_FuzzyInfo) String() string {
 func (*QueryResponse_Item_FuzzyInfo) ProtoMessage() {}
 
 func (x *QueryResponse_Item_FuzzyInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_query_proto_msgTypes[3]
+	mi := &file_query_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -371,7 +587,7 @@ func (x *QueryResponse_Item_FuzzyInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryResponse_Item_FuzzyInfo.ProtoReflect.Descriptor instead.
 func (*QueryResponse_Item_FuzzyInfo) Descriptor() ([]byte, []int) {
-	return file_query_proto_rawDescGZIP(), []int{1, 0, 0}
+	return file_query_proto_rawDescGZIP(), []int{3, 0, 0}
 }
 
 func (x *QueryResponse_Item_FuzzyInfo) GetStart() int32 {
@@ -399,18 +615,38 @@ var File_query_proto protoreflect.FileDescriptor
 
 const file_query_proto_rawDesc = "" +
 	"\n" +
-	"\vquery.proto\x12\x02pb\"\x84\x01\n" +
+	"\vquery.proto\x12\x02pb\"\xdc\x01\n" +
 	"\fQueryRequest\x12\x1c\n" +
 	"\tproviders\x18\x01 \x03(\tR\tproviders\x12\x14\n" +
 	"\x05query\x18\x02 \x01(\tR\x05query\x12\x1e\n" +
 	"\n" +
 	"maxresults\x18\x03 \x01(\x05R\n" +
 	"maxresults\x12 \n" +
-	"\vexactsearch\x18\x04 \x01(\bR\vexactsearch\"\xeb\x04\n" +
+	"\vexactsearch\x18\x04 \x01(\bR\vexactsearch\x12\x1e\n" +
+	"\n" +
+	"thumbnails\x18\x05 \x01(\bR\n" +
+	"thumbnails\x12\x18\n" +
+	"\agrouped\x18\x06 \x01(\bR\agrouped\x12\x1c\n" +
+	"\tdashboard\x18\a \x01(\bR\tdashboard\"\xdc\x01\n" +
+	"\x10ActionDescriptor\x12\x16\n" +
+	"\x06action\x18\x01 \x01(\tR\x06action\x12\x14\n" +
+	"\x05label\x18\x02 \x01(\tR\x05label\x12\x12\n" +
+	"\x04icon\x18\x03 \x01(\tR\x04icon\x12\x18\n" +
+	"\adefault\x18\x04 \x01(\bR\adefault\x120\n" +
+	"\targuments\x18\x05 \x03(\v2\x12.pb.ActionArgumentR\targuments\x12\x1a\n" +
+	"\bmultiple\x18\x06 \x01(\bR\bmultiple\x12\x1e\n" +
+	"\n" +
+	"keybinding\x18\a \x01(\tR\n" +
+	"keybinding\"v\n" +
+	"\x0eActionArgument\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12 \n" +
+	"\vplaceholder\x18\x03 \x01(\tR\vplaceholder\x12\x1a\n" +
+	"\brequired\x18\x04 \x01(\bR\brequired\"\x89\x06\n" +
 	"\rQueryResponse\x12\x14\n" +
 	"\x05query\x18\x01 \x01(\tR\x05query\x12*\n" +
 	"\x04item\x18\x02 \x01(\v2\x16.pb.QueryResponse.ItemR\x04item\x12\x10\n" +
-	"\x03qid\x18\x03 \x01(\x05R\x03qid\x1a\xe6\x03\n" +
+	"\x03qid\x18\x03 \x01(\x05R\x03qid\x1a\x84\x05\n" +
 	"\x04Item\x12\x1e\n" +
 	"\n" +
 	"identifier\x18\x01 \x01(\tR\n" +
@@ -427,7 +663,11 @@ const file_query_proto_rawDesc = "" +
 	" \x01(\tR\apreview\x12!\n" +
 	"\fpreview_type\x18\v \x01(\tR\vpreviewType\x12\x14\n" +
 	"\x05state\x18\f \x03(\tR\x05state\x12\x18\n" +
-	"\aactions\x18\r \x03(\tR\aactions\x1aU\n" +
+	"\aactions\x18\r \x03(\tR\aactions\x12\x1c\n" +
+	"\tthumbnail\x18\x0e \x01(\fR\tthumbnail\x12\x14\n" +
+	"\x05group\x18\x0f \x01(\tR\x05group\x12C\n" +
+	"\x12action_descriptors\x18\x10 \x03(\v2\x14.pb.ActionDescriptorR\x11actionDescriptors\x12#\n" +
+	"\rlive_interval\x18\x11 \x01(\x05R\fliveInterval\x1aU\n" +
 	"\tFuzzyInfo\x12\x14\n" +
 	"\x05start\x18\x01 \x01(\x05R\x05start\x12\x14\n" +
 	"\x05field\x18\x02 \x01(\tR\x05field\x12\x1c\n" +
@@ -449,23 +689,27 @@ func file_query_proto_rawDescGZIP() []byte {
 }
 
 var file_query_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_query_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_query_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_query_proto_goTypes = []any{
 	(QueryResponse_Type)(0),              // 0: pb.QueryResponse.Type
 	(*QueryRequest)(nil),                 // 1: pb.QueryRequest
-	(*QueryResponse)(nil),                // 2: pb.QueryResponse
-	(*QueryResponse_Item)(nil),           // 3: pb.QueryResponse.Item
-	(*QueryResponse_Item_FuzzyInfo)(nil), // 4: pb.QueryResponse.Item.FuzzyInfo
+	(*ActionDescriptor)(nil),             // 2: pb.ActionDescriptor
+	(*ActionArgument)(nil),               // 3: pb.ActionArgument
+	(*QueryResponse)(nil),                // 4: pb.QueryResponse
+	(*QueryResponse_Item)(nil),           // 5: pb.QueryResponse.Item
+	(*QueryResponse_Item_FuzzyInfo)(nil), // 6: pb.QueryResponse.Item.FuzzyInfo
 }
 var file_query_proto_depIdxs = []int32{
-	3, // 0: pb.QueryResponse.item:type_name -> pb.QueryResponse.Item
-	4, // 1: pb.QueryResponse.Item.fuzzyinfo:type_name -> pb.QueryResponse.Item.FuzzyInfo
-	0, // 2: pb.QueryResponse.Item.type:type_name -> pb.QueryResponse.Type
-	3, // [3:3] is the sub-list for method output_type
-	3, // [3:3] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	3, // 0: pb.ActionDescriptor.arguments:type_name -> pb.ActionArgument
+	5, // 1: pb.QueryResponse.item:type_name -> pb.QueryResponse.Item
+	6, // 2: pb.QueryResponse.Item.fuzzyinfo:type_name -> pb.QueryResponse.Item.FuzzyInfo
+	0, // 3: pb.QueryResponse.Item.type:type_name -> pb.QueryResponse.Type
+	2, // 4: pb.QueryResponse.Item.action_descriptors:type_name -> pb.ActionDescriptor
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_query_proto_init() }
@@ -479,7 +723,7 @@ func file_query_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_query_proto_rawDesc), len(file_query_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   4,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -84,6 +84,8 @@ func (x *SubscribeRequest) GetQuery() string {
 type SubscribeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Job           uint32                 `protobuf:"varint,3,opt,name=job,proto3" json:"job,omitempty"`
+	Output        string                 `protobuf:"bytes,4,opt,name=output,proto3" json:"output,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -125,6 +127,20 @@ func (x *SubscribeResponse) GetValue() string {
 	return ""
 }
 
+func (x *SubscribeResponse) GetJob() uint32 {
+	if x != nil {
+		return x.Job
+	}
+	return 0
+}
+
+func (x *SubscribeResponse) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
 var File_subscribe_proto protoreflect.FileDescriptor
 
 const file_subscribe_proto_rawDesc = "" +
@@ -133,9 +149,11 @@ const file_subscribe_proto_rawDesc = "" +
 	"\x10SubscribeRequest\x12\x1a\n" +
 	"\binterval\x18\x01 \x01(\x05R\binterval\x12\x1a\n" +
 	"\bprovider\x18\x02 \x01(\tR\bprovider\x12\x14\n" +
-	"\x05query\x18\x03 \x01(\tR\x05query\")\n" +
+	"\x05query\x18\x03 \x01(\tR\x05query\"S\n" +
 	"\x11SubscribeResponse\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05valueB\x06Z\x04./pbb\x06proto3"
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x10\n" +
+	"\x03job\x18\x03 \x01(\rR\x03job\x12\x16\n" +
+	"\x06output\x18\x04 \x01(\tR\x06outputB\x06Z\x04./pbb\x06proto3"
 
 var (
 	file_subscribe_proto_rawDescOnce sync.Once
@@ -0,0 +1,104 @@
+package sdk
+
+import (
+	"io"
+	"net"
+	"strings"
+
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+// Exports are the functions and variables a provider built as Go plugin exports. Refresh and Actions are
+// optional.
+type Exports struct {
+	Name                 *string
+	NamePretty           *string
+	Icon                 func() string
+	HideFromProviderlist func() bool
+	Setup                func()
+	Available            func() bool
+	PrintDoc             func(io.Writer)
+	State                func(string) *pb.ProviderStateResponse
+	Query                func(net.Conn, string, bool, bool, uint8) []*pb.QueryResponse_Item
+	Activate             func(bool, string, string, string, string, uint8, net.Conn)
+	Refresh              func()
+	Actions              func() []*pb.ActionDescriptor
+}
+
+// ServeExports serves a provider written as Go plugin via RPC, f.e. on Windows, which has no Go plugins.
+//
+//	func main() {
+//		sdk.ServeExports(sdk.Exports{Name: &Name, NamePretty: &NamePretty, Setup: Setup, ...})
+//	}
+func ServeExports(e Exports) {
+	Serve(&exported{e})
+}
+
+type exported struct {
+	e Exports
+}
+
+func (x *exported) Info() Info {
+	info := Info{
+		Name:                 *x.e.Name,
+		NamePretty:           *x.e.NamePretty,
+		Icon:                 x.e.Icon(),
+		HideFromProviderlist: x.e.HideFromProviderlist(),
+	}
+
+	if x.e.Actions == nil {
+		return info
+	}
+
+	for _, v := range x.e.Actions() {
+		a := Action{Action: v.Action, Label: v.Label, Icon: v.Icon, Default: v.Default}
+
+		for _, arg := range v.Arguments {
+			a.Arguments = append(a.Arguments, Argument{
+				Name:        arg.Name,
+				Type:        arg.Type,
+				Placeholder: arg.Placeholder,
+				Required:    arg.Required,
+			})
+		}
+
+		info.Actions = append(info.Actions, a)
+	}
+
+	return info
+}
+
+func (x *exported) Setup() {
+	x.e.Setup()
+}
+
+func (x *exported) Available() bool {
+	return x.e.Available()
+}
+
+func (x *exported) Doc() string {
+	var b strings.Builder
+
+	x.e.PrintDoc(&b)
+
+	return b.String()
+}
+
+func (x *exported) State() *pb.ProviderStateResponse {
+	return x.e.State("")
+}
+
+// Query passes no connection, so async updates of items are dropped.
+func (x *exported) Query(req QueryRequest) []*pb.QueryResponse_Item {
+	return x.e.Query(nil, req.Query, req.Single, req.Exact, 0)
+}
+
+func (x *exported) Activate(req ActivateRequest) {
+	x.e.Activate(req.Single, req.Identifier, req.Action, req.Query, req.Arguments, 0, nil)
+}
+
+func (x *exported) Refresh() {
+	if x.e.Refresh != nil {
+		x.e.Refresh()
+	}
+}
@@ -0,0 +1,173 @@
+package sdk
+
+import (
+	"log/slog"
+	"net/rpc"
+
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/protobuf/proto"
+)
+
+// Plugin connects a Provider to go-plugin's net/rpc transport.
+type Plugin struct {
+	Impl Provider
+}
+
+func (p *Plugin) Server(*plugin.MuxBroker) (any, error) {
+	return &RPCServer{Impl: p.Impl}, nil
+}
+
+func (p *Plugin) Client(_ *plugin.MuxBroker, c *rpc.Client) (any, error) {
+	return &RPCClient{client: c}, nil
+}
+
+// RPCServer runs in the provider process. Protobuf messages are passed marshalled, as gob can't encode
+// their internal state.
+type RPCServer struct {
+	Impl Provider
+}
+
+func (s *RPCServer) Info(_ any, resp *Info) error {
+	*resp = s.Impl.Info()
+	return nil
+}
+
+func (s *RPCServer) Setup(_ any, _ *struct{}) error {
+	s.Impl.Setup()
+	return nil
+}
+
+func (s *RPCServer) Available(_ any, resp *bool) error {
+	*resp = s.Impl.Available()
+	return nil
+}
+
+func (s *RPCServer) Doc(_ any, resp *string) error {
+	*resp = s.Impl.Doc()
+	return nil
+}
+
+func (s *RPCServer) State(_ any, resp *[]byte) error {
+	b, err := proto.Marshal(s.Impl.State())
+	if err != nil {
+		return err
+	}
+
+	*resp = b
+
+	return nil
+}
+
+func (s *RPCServer) Query(req QueryRequest, resp *[][]byte) error {
+	res := [][]byte{}
+
+	for _, v := range s.Impl.Query(req) {
+		b, err := proto.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		res = append(res, b)
+	}
+
+	*resp = res
+
+	return nil
+}
+
+func (s *RPCServer) Activate(req ActivateRequest, _ *struct{}) error {
+	s.Impl.Activate(req)
+	return nil
+}
+
+func (s *RPCServer) Refresh(_ any, _ *struct{}) error {
+	s.Impl.Refresh()
+	return nil
+}
+
+// RPCClient is used by elephant. Errors are logged, as the Provider interface has no error returns.
+type RPCClient struct {
+	client *rpc.Client
+}
+
+func (c *RPCClient) call(method string, args, resp any) bool {
+	if err := c.client.Call("Plugin."+method, args, resp); err != nil {
+		slog.Error("sdk", method, err)
+		return false
+	}
+
+	return true
+}
+
+func (c *RPCClient) Info() Info {
+	var res Info
+	c.call("Info", new(any), &res)
+
+	return res
+}
+
+func (c *RPCClient) Setup() {
+	c.call("Setup", new(any), &struct{}{})
+}
+
+func (c *RPCClient) Available() bool {
+	var res bool
+	c.call("Available", new(any), &res)
+
+	return res
+}
+
+func (c *RPCClient) Doc() string {
+	var res string
+	c.call("Doc", new(any), &res)
+
+	return res
+}
+
+func (c *RPCClient) State() *pb.ProviderStateResponse {
+	res := &pb.ProviderStateResponse{}
+
+	var b []byte
+
+	if !c.call("State", new(any), &b) {
+		return res
+	}
+
+	if err := proto.Unmarshal(b, res); err != nil {
+		slog.Error("sdk", "state", err)
+	}
+
+	return res
+}
+
+func (c *RPCClient) Query(req QueryRequest) []*pb.QueryResponse_Item {
+	res := []*pb.QueryResponse_Item{}
+
+	var items [][]byte
+
+	if !c.call("Query", req, &items) {
+		return res
+	}
+
+	for _, b := range items {
+		item := &pb.QueryResponse_Item{}
+
+		if err := proto.Unmarshal(b, item); err != nil {
+			slog.Error("sdk", "query", err)
+			continue
+		}
+
+		res = append(res, item)
+	}
+
+	return res
+}
+
+func (c *RPCClient) Activate(req ActivateRequest) {
+	c.call("Activate", req, &struct{}{})
+}
+
+func (c *RPCClient) Refresh() {
+	c.call("Refresh", new(any), &struct{}{})
+}
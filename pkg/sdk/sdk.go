@@ -0,0 +1,98 @@
+// Package sdk is used to write providers as standalone executables. Unlike providers built as Go plugins,
+// they don't need to be built with the exact same toolchain and dependencies as elephant, so they can be
+// distributed pre-compiled. Elephant loads them from the 'plugins' folder in its config dir and talks to
+// them via RPC.
+//
+//	func main() {
+//		sdk.Serve(&MyProvider{})
+//	}
+package sdk
+
+import (
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+	"github.com/hashicorp/go-plugin"
+)
+
+// ProtocolVersion is increased whenever the Provider interface changes. Elephant refuses to load
+// providers built against another version.
+const ProtocolVersion = 1
+
+// PluginName is the name the provider is served as.
+const PluginName = "provider"
+
+// Handshake is shared by elephant and providers. It's not a security measure, it only prevents
+// running arbitrary executables as providers.
+var Handshake = plugin.HandshakeConfig{
+	ProtocolVersion:  ProtocolVersion,
+	MagicCookieKey:   "ELEPHANT_PROVIDER",
+	MagicCookieValue: "0f5ff3bf-7d36-4d5c-9b1e-1f3a0f6a9c52",
+}
+
+// Info describes the provider. It's requested again after Setup, so values from the config can be used.
+type Info struct {
+	Name                 string
+	NamePretty           string
+	Icon                 string
+	HideFromProviderlist bool
+	// Actions describes the actions of the provider, see pb.ActionDescriptor.
+	Actions []Action
+}
+
+// Action describes an action, so clients can show a label and icon instead of the action name. Default
+// marks the action run when activating without one.
+type Action struct {
+	Action  string
+	Label   string
+	Icon    string
+	Default bool
+	// Arguments the client should prompt for before activating.
+	Arguments []Argument
+}
+
+// Argument describes an argument of an action, see pb.ActionArgument.
+type Argument struct {
+	Name        string
+	Type        string
+	Placeholder string
+	Required    bool
+}
+
+type QueryRequest struct {
+	Query string
+	// Single is true if this provider is the only one queried.
+	Single bool
+	Exact  bool
+}
+
+type ActivateRequest struct {
+	Single     bool
+	Identifier string
+	Action     string
+	Query      string
+	Arguments  string
+}
+
+// Provider mirrors the functions exported by providers built as Go plugins. As the provider runs in
+// its own process, it can't write to the client connection, so async item updates aren't available.
+type Provider interface {
+	Info() Info
+	Setup()
+	Available() bool
+	// Doc returns the markdown documentation, usually the README and util.PrintConfig.
+	Doc() string
+	State() *pb.ProviderStateResponse
+	Query(req QueryRequest) []*pb.QueryResponse_Item
+	Activate(req ActivateRequest)
+	// Refresh reloads the providers data. Can be a no-op.
+	Refresh()
+}
+
+// Serve runs the provider until elephant exits.
+func Serve(p Provider) {
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		Plugins: plugin.PluginSet{
+			PluginName: &Plugin{Impl: p},
+		},
+	})
+}
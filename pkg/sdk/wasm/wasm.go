@@ -0,0 +1,213 @@
+//go:build wasip1
+
+// Package wasm is used to write sandboxed providers, compiled to WebAssembly:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o myprovider.wasm
+//
+// Put the file into the 'plugins' folder of the elephant config dir. Providers can't access files or the
+// network, unless granted in elephant.toml:
+//
+//	[[wasm]]
+//	provider = "myprovider"
+//	fs = ["~/notes"]
+//	http = ["api.github.com"]
+//	open = true
+//	clipboard = true
+package wasm
+
+import (
+	"encoding/json"
+	"errors"
+	"unsafe"
+
+	"github.com/abenz1267/elephant/v2/pkg/pb/pb"
+)
+
+type Info struct {
+	Name                 string                 `json:"name"`
+	NamePretty           string                 `json:"name_pretty"`
+	Icon                 string                 `json:"icon"`
+	HideFromProviderlist bool                   `json:"hide_from_providerlist"`
+	Available            bool                   `json:"available"`
+	Actions              []*pb.ActionDescriptor `json:"actions"`
+}
+
+type QueryRequest struct {
+	Query  string `json:"query"`
+	Single bool   `json:"single"`
+	Exact  bool   `json:"exact"`
+}
+
+type ActivateRequest struct {
+	Single     bool   `json:"single"`
+	Identifier string `json:"identifier"`
+	Action     string `json:"action"`
+	Query      string `json:"query"`
+	Arguments  string `json:"arguments"`
+}
+
+// Provider is implemented by wasm providers. Info is requested again after Setup.
+type Provider interface {
+	Info() Info
+	Setup()
+	Doc() string
+	State() *pb.ProviderStateResponse
+	Query(req QueryRequest) []*pb.QueryResponse_Item
+	Activate(req ActivateRequest)
+	Refresh()
+}
+
+var provider Provider
+
+// Register sets the provider. Call it in an init function, as main isn't run for reactors.
+func Register(p Provider) {
+	provider = p
+}
+
+// buffers keeps memory passed to the host alive until it's freed.
+var buffers = make(map[uint32][]byte)
+
+//go:wasmexport elephant_alloc
+func alloc(size uint32) uint32 {
+	b := make([]byte, max(size, 1))
+	ptr := uint32(uintptr(unsafe.Pointer(unsafe.SliceData(b))))
+	buffers[ptr] = b
+
+	return ptr
+}
+
+//go:wasmexport elephant_free
+func free(ptr uint32) {
+	delete(buffers, ptr)
+}
+
+// input returns data written by the host into memory it allocated with elephant_alloc.
+func input(ptr, size uint32) []byte {
+	return buffers[ptr][:size]
+}
+
+// output passes the value as JSON to the host, which frees it.
+func output(v any) uint64 {
+	b, err := json.Marshal(v)
+	if err != nil {
+		Log(err.Error())
+		return 0
+	}
+
+	ptr := alloc(uint32(len(b)))
+	copy(buffers[ptr], b)
+
+	return uint64(ptr)<<32 | uint64(len(b))
+}
+
+//go:wasmexport elephant_info
+func info() uint64 {
+	return output(provider.Info())
+}
+
+//go:wasmexport elephant_setup
+func setup() {
+	provider.Setup()
+}
+
+//go:wasmexport elephant_doc
+func doc() uint64 {
+	return output(provider.Doc())
+}
+
+//go:wasmexport elephant_state
+func state() uint64 {
+	return output(provider.State())
+}
+
+//go:wasmexport elephant_refresh
+func refresh() {
+	provider.Refresh()
+}
+
+//go:wasmexport elephant_query
+func query(ptr, size uint32) uint64 {
+	var req QueryRequest
+
+	if err := json.Unmarshal(input(ptr, size), &req); err != nil {
+		Log(err.Error())
+		return 0
+	}
+
+	return output(provider.Query(req))
+}
+
+//go:wasmexport elephant_activate
+func activate(ptr, size uint32) {
+	var req ActivateRequest
+
+	if err := json.Unmarshal(input(ptr, size), &req); err != nil {
+		Log(err.Error())
+		return
+	}
+
+	provider.Activate(req)
+}
+
+//go:wasmimport elephant log
+func hostLog(ptr, size uint32)
+
+//go:wasmimport elephant http_get
+func hostHTTPGet(ptr, size uint32) uint64
+
+//go:wasmimport elephant open
+func hostOpen(ptr, size uint32) uint32
+
+//go:wasmimport elephant copy
+func hostCopy(ptr, size uint32) uint32
+
+func pass(s string) (uint32, uint32) {
+	if s == "" {
+		return 0, 0
+	}
+
+	return uint32(uintptr(unsafe.Pointer(unsafe.StringData(s)))), uint32(len(s))
+}
+
+// Log writes to the log of elephant.
+func Log(msg string) {
+	hostLog(pass(msg))
+}
+
+// Get sends a GET request. The host has to be granted.
+func Get(url string) (int, []byte, error) {
+	packed := hostHTTPGet(pass(url))
+	if packed == 0 {
+		return 0, nil, errors.New("http_get failed")
+	}
+
+	ptr := uint32(packed >> 32)
+	b := buffers[ptr][:uint32(packed)]
+	free(ptr)
+
+	var resp struct {
+		Status int    `json:"status"`
+		Body   string `json:"body"`
+		Error  string `json:"error"`
+	}
+
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return 0, nil, err
+	}
+
+	if resp.Error != "" {
+		return resp.Status, nil, errors.New(resp.Error)
+	}
+
+	return resp.Status, []byte(resp.Body), nil
+}
+
+// Open opens the url or file with xdg-open. Requires the 'open' capability.
+func Open(target string) bool {
+	return hostOpen(pass(target)) == 1
+}
+
+// Copy copies the value to the clipboard. Requires the 'clipboard' capability.
+func Copy(value string) bool {
+	return hostCopy(pass(value)) == 1
+}